@@ -0,0 +1,216 @@
+package main
+
+import (
+	"bytes"
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/rand"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/zalando/go-keyring"
+	"golang.org/x/crypto/argon2"
+)
+
+const (
+	// atRestMagic starts every file and cache entry ember encrypts
+	atRestMagic = "EMBERENC1"
+	// atRestLinePrefix starts each encrypted line of a JSON Lines file
+	atRestLinePrefix = "enc:"
+	// keychainEncryptionKey is the keychain entry holding the generated key
+	keychainEncryptionKey = "encryption"
+	// atRestCheckText is sealed into encryption.json to tell a wrong key from damage
+	atRestCheckText = "ember"
+)
+
+// atRest encrypts what ember keeps on disk, and is nil when encryption is
+// off. Data written before encryption was turned on is still read.
+var atRest cipher.AEAD
+
+// atRestMode is how the key was found, for ember doctor
+var atRestMode string
+
+// errAtRestLocked is returned for encrypted data when encryption is off
+var errAtRestLocked = errors.New("it's encrypted • set EMBER_ENCRYPT to passphrase or keyring, as when it was written")
+
+// atRestConfig is encryption.json in the global data directory: the salt a
+// passphrase is stretched with, and a sealed known text to check the key
+type atRestConfig struct {
+	Mode  string `json:"mode"`
+	Salt  []byte `json:"salt,omitempty"`
+	Check []byte `json:"check"`
+}
+
+// setupEncryption reads EMBER_ENCRYPT. With passphrase, the key is derived
+// from EMBER_ENCRYPT_PASSPHRASE with Argon2id; with keyring, a random key
+// is kept in the system keychain, created on first use.
+func setupEncryption() error {
+	mode := strings.ToLower(os.Getenv("EMBER_ENCRYPT"))
+	if mode == "" && os.Getenv("EMBER_ENCRYPT_PASSPHRASE") != "" {
+		mode = "passphrase"
+	}
+	if mode == "" || mode == "0" || mode == "off" {
+		return nil
+	}
+	if mode != "passphrase" && mode != "keyring" {
+		return fmt.Errorf("invalid EMBER_ENCRYPT %q (use passphrase or keyring)", os.Getenv("EMBER_ENCRYPT"))
+	}
+
+	dir, err := globalDataDir()
+	if err != nil {
+		return err
+	}
+	path := filepath.Join(dir, "encryption.json")
+	var cfg atRestConfig
+	data, err := os.ReadFile(path)
+	switch {
+	case err == nil:
+		if err := json.Unmarshal(data, &cfg); err != nil {
+			return fmt.Errorf("failed to parse %s: %w", path, err)
+		}
+		if cfg.Mode != mode {
+			return fmt.Errorf("ember's data was encrypted with EMBER_ENCRYPT=%s, not %s", cfg.Mode, mode)
+		}
+	case errors.Is(err, os.ErrNotExist):
+		cfg.Mode = mode
+		if mode == "passphrase" {
+			cfg.Salt = make([]byte, 16)
+			rand.Read(cfg.Salt)
+		}
+	default:
+		return fmt.Errorf("failed to read %s: %w", path, err)
+	}
+
+	var key []byte
+	if mode == "passphrase" {
+		passphrase := os.Getenv("EMBER_ENCRYPT_PASSPHRASE")
+		if passphrase == "" {
+			return errors.New("EMBER_ENCRYPT is passphrase, but EMBER_ENCRYPT_PASSPHRASE isn't set")
+		}
+		key = argon2.IDKey([]byte(passphrase), cfg.Salt, 2, 64<<10, 4, 32)
+	} else if key, err = keychainEncryptionKeyFor(cfg.Check == nil); err != nil {
+		return err
+	}
+
+	block, err := aes.NewCipher(key)
+	if err != nil {
+		return fmt.Errorf("failed to set up encryption: %w", err)
+	}
+	aead, err := cipher.NewGCM(block)
+	if err != nil {
+		return fmt.Errorf("failed to set up encryption: %w", err)
+	}
+	if cfg.Check != nil {
+		if check, err := openSealed(aead, cfg.Check); err != nil || string(check) != atRestCheckText {
+			if mode == "passphrase" {
+				return errors.New("wrong EMBER_ENCRYPT_PASSPHRASE")
+			}
+			return errors.New("the keychain's encryption key isn't the one ember's data was encrypted with")
+		}
+	} else {
+		cfg.Check = seal(aead, []byte(atRestCheckText))
+		data, err := json.MarshalIndent(cfg, "", "  ")
+		if err != nil {
+			return fmt.Errorf("failed to marshal encryption settings: %w", err)
+		}
+		if err := os.MkdirAll(dir, 0o755); err != nil {
+			return fmt.Errorf("failed to create %s: %w", dir, err)
+		}
+		if err := os.WriteFile(path, data, 0o600); err != nil {
+			return fmt.Errorf("failed to write %s: %w", path, err)
+		}
+	}
+	atRest, atRestMode = aead, mode
+	return nil
+}
+
+// keychainEncryptionKeyFor reads the encryption key from the system
+// keychain, generating and storing one when create is set and there's none
+func keychainEncryptionKeyFor(create bool) ([]byte, error) {
+	encoded, err := keyring.Get(keychainService, keychainEncryptionKey)
+	if errors.Is(err, keyring.ErrNotFound) && create {
+		key := make([]byte, 32)
+		rand.Read(key)
+		if err := keyring.Set(keychainService, keychainEncryptionKey, base64.StdEncoding.EncodeToString(key)); err != nil {
+			return nil, fmt.Errorf("failed to save the encryption key to the system keychain: %w", err)
+		}
+		return key, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to read the encryption key from the system keychain: %w", err)
+	}
+	key, err := base64.StdEncoding.DecodeString(encoded)
+	if err != nil || len(key) != 32 {
+		return nil, errors.New("the system keychain's ember encryption key is damaged")
+	}
+	return key, nil
+}
+
+// seal encrypts data with a random nonce, after the magic
+func seal(aead cipher.AEAD, data []byte) []byte {
+	out := make([]byte, len(atRestMagic)+aead.NonceSize(), len(atRestMagic)+aead.NonceSize()+len(data)+aead.Overhead())
+	copy(out, atRestMagic)
+	rand.Read(out[len(atRestMagic):])
+	return aead.Seal(out, out[len(atRestMagic):], data, nil)
+}
+
+// openSealed decrypts what seal wrote
+func openSealed(aead cipher.AEAD, data []byte) ([]byte, error) {
+	header := len(atRestMagic) + aead.NonceSize()
+	if len(data) < header+aead.Overhead() {
+		return nil, errors.New("it's cut short")
+	}
+	plain, err := aead.Open(nil, data[len(atRestMagic):header], data[header:], nil)
+	if err != nil {
+		return nil, errors.New("it doesn't decrypt with this key, or is damaged")
+	}
+	return plain, nil
+}
+
+// sealAtRest encrypts data when encryption is on, and returns it as it is
+// otherwise
+func sealAtRest(data []byte) []byte {
+	if atRest == nil {
+		return data
+	}
+	return seal(atRest, data)
+}
+
+// openAtRest decrypts data sealAtRest encrypted. Data that isn't encrypted
+// is returned as it is, so files from before encryption still load.
+func openAtRest(data []byte) ([]byte, error) {
+	if !bytes.HasPrefix(data, []byte(atRestMagic)) {
+		return data, nil
+	}
+	if atRest == nil {
+		return nil, errAtRestLocked
+	}
+	return openSealed(atRest, data)
+}
+
+// sealLine encrypts one line of a JSON Lines file as base64, so the file
+// can still be appended to a line at a time
+func sealLine(line []byte) []byte {
+	if atRest == nil {
+		return line
+	}
+	return []byte(atRestLinePrefix + base64.StdEncoding.EncodeToString(seal(atRest, line)))
+}
+
+// openLine decrypts a line sealLine wrote, passing other lines through
+func openLine(line []byte) ([]byte, error) {
+	line = bytes.TrimSpace(line)
+	if !bytes.HasPrefix(line, []byte(atRestLinePrefix)) {
+		return line, nil
+	}
+	sealed, err := base64.StdEncoding.DecodeString(string(line[len(atRestLinePrefix):]))
+	if err != nil {
+		return nil, errors.New("an encrypted line is damaged")
+	}
+	return openAtRest(sealed)
+}
@@ -0,0 +1,277 @@
+package main
+
+import (
+	"bufio"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+)
+
+// auditEntry is one line of the audit log: a request to a provider's API
+type auditEntry struct {
+	Time     time.Time `json:"time"`
+	Provider string    `json:"provider"`
+	Model    string    `json:"model"`
+	// Purpose is what the call was for, e.g. "input" or "eval"
+	Purpose string `json:"purpose"`
+	Project string `json:"project,omitempty"`
+	Texts   int    `json:"texts"`
+	// Tokens is what the provider reported using, 0 when it doesn't say
+	Tokens int `json:"tokens"`
+	// TextHashes are SHA-256 hashes of the texts sent, so a call can be
+	// traced to its data without the log holding the data
+	TextHashes []string `json:"text_hashes"`
+	// Error is set when the call failed
+	Error string `json:"error,omitempty"`
+}
+
+// auditLogger appends entries to the audit log as JSON lines. Every entry
+// is a single write to a file opened for appending, so concurrent requests
+// and processes never interleave lines.
+type auditLogger struct {
+	mu      sync.Mutex
+	f       *os.File
+	purpose string
+	project string
+}
+
+// auditLog is nil unless the audit log is on
+var auditLog *auditLogger
+
+// auditLogPath reads EMBER_AUDIT_LOG, or audit.jsonl in the global data
+// directory when EMBER_AUDIT is set, so one log covers every project. It
+// returns "" when the log is off.
+func auditLogPath() (string, error) {
+	if path := os.Getenv("EMBER_AUDIT_LOG"); path != "" {
+		return path, nil
+	}
+	if os.Getenv("EMBER_AUDIT") == "" {
+		return "", nil
+	}
+	dataDir, err := globalDataDir()
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(dataDir, "audit.jsonl"), nil
+}
+
+// openAuditLog turns the audit log on when it's configured. purpose is
+// recorded for calls whose provider wasn't given one: the command, or "tui".
+func openAuditLog(purpose string) error {
+	path, err := auditLogPath()
+	if err != nil || path == "" {
+		return err
+	}
+	if err := os.MkdirAll(filepath.Dir(path), 0o700); err != nil {
+		return fmt.Errorf("failed to create audit log directory: %w", err)
+	}
+	f, err := os.OpenFile(path, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0o600)
+	if err != nil {
+		return fmt.Errorf("failed to open audit log: %w", err)
+	}
+	auditLog = &auditLogger{f: f, purpose: purpose, project: projectName()}
+	return nil
+}
+
+// auditCall records a request for texts made with model. It does nothing
+// when the audit log is off, and a log that can't be written doesn't fail the call.
+func auditCall(info ModelInfo, purpose string, texts []string, tokens int, err error) {
+	if auditLog == nil {
+		return
+	}
+	entry := auditEntry{
+		Time:       time.Now().UTC(),
+		Provider:   info.Provider,
+		Model:      info.Model,
+		Purpose:    purpose,
+		Project:    auditLog.project,
+		Texts:      len(texts),
+		Tokens:     tokens,
+		TextHashes: make([]string, len(texts)),
+	}
+	if entry.Purpose == "" {
+		entry.Purpose = auditLog.purpose
+	}
+	for i, text := range texts {
+		sum := sha256.Sum256([]byte(text))
+		entry.TextHashes[i] = hex.EncodeToString(sum[:])
+	}
+	if err != nil {
+		entry.Error = err.Error()
+	}
+
+	line, jsonErr := json.Marshal(entry)
+	if jsonErr != nil {
+		return
+	}
+	auditLog.mu.Lock()
+	defer auditLog.mu.Unlock()
+	auditLog.f.Write(append(line, '\n'))
+}
+
+// purposeSelector is implemented by providers that record what their calls are for in the audit log
+type purposeSelector interface {
+	WithPurpose(purpose string) EmbeddingProvider
+}
+
+// withPurpose returns p recording purpose for its calls, or p itself when it makes no API calls
+func withPurpose(p EmbeddingProvider, purpose string) EmbeddingProvider {
+	if selector, ok := p.(purposeSelector); ok {
+		return selector.WithPurpose(purpose)
+	}
+	return p
+}
+
+// spendRow totals the calls for one day or project and model
+type spendRow struct {
+	Group  string
+	Model  string
+	Calls  int
+	Failed int
+	Texts  int
+	Tokens int
+}
+
+// readAuditLog totals the entries at path by day or project and model,
+// skipping entries before since
+func readAuditLog(path, by string, since time.Time) ([]spendRow, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open audit log: %w", err)
+	}
+	defer f.Close()
+
+	rows := make(map[[2]string]*spendRow)
+	scanner := bufio.NewScanner(f)
+	scanner.Buffer(make([]byte, 0, 64*1024), 16*1024*1024)
+	for line := 1; scanner.Scan(); line++ {
+		var entry auditEntry
+		if err := json.Unmarshal(scanner.Bytes(), &entry); err != nil {
+			return nil, fmt.Errorf("failed to read audit log line %d: %w", line, err)
+		}
+		if entry.Time.Before(since) {
+			continue
+		}
+		group := entry.Time.Local().Format("2006-01-02")
+		if by == "project" {
+			group = entry.Project
+			if group == "" {
+				group = "(none)"
+			}
+		}
+		key := [2]string{group, entry.Provider + "/" + entry.Model}
+		row := rows[key]
+		if row == nil {
+			row = &spendRow{Group: key[0], Model: key[1]}
+			rows[key] = row
+		}
+		row.Calls++
+		row.Texts += entry.Texts
+		row.Tokens += entry.Tokens
+		if entry.Error != "" {
+			row.Failed++
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, fmt.Errorf("failed to read audit log: %w", err)
+	}
+
+	result := make([]spendRow, 0, len(rows))
+	for _, row := range rows {
+		result = append(result, *row)
+	}
+	sort.Slice(result, func(i, j int) bool {
+		if result[i].Group != result[j].Group {
+			return result[i].Group < result[j].Group
+		}
+		return result[i].Model < result[j].Model
+	})
+	return result, nil
+}
+
+// runAuditCommand summarizes spend from the audit log by day or project
+func runAuditCommand(args []string) {
+	fs := flag.NewFlagSet("audit", flag.ExitOnError)
+	by := fs.String("by", "day", "group by day or project")
+	sinceFlag := fs.String("since", "", "only count calls on or after this date (YYYY-MM-DD)")
+	format := formatFlag(fs, "table")
+	fs.Parse(args)
+
+	if fs.NArg() != 0 || (*by != "day" && *by != "project") {
+		fmt.Println("Usage: ember audit [--by day|project] [--since YYYY-MM-DD] [--format FORMAT]")
+		os.Exit(2)
+	}
+	checkFormat(format)
+	var since time.Time
+	if *sinceFlag != "" {
+		var err error
+		if since, err = time.ParseInLocation("2006-01-02", *sinceFlag, time.Local); err != nil {
+			displayError(fmt.Errorf("invalid --since %q: use YYYY-MM-DD", *sinceFlag))
+			os.Exit(2)
+		}
+	}
+
+	path, err := auditLogPath()
+	if err == nil && path == "" {
+		err = fmt.Errorf("the audit log is off • set EMBER_AUDIT=1 or EMBER_AUDIT_LOG to a file")
+	}
+	if err != nil {
+		displayError(err)
+		os.Exit(1)
+	}
+	rows, err := readAuditLog(path, *by, since)
+	if err != nil {
+		displayError(err)
+		os.Exit(1)
+	}
+	output := spendOutput{By: *by, Since: *sinceFlag, Rows: []spendLine{}}
+	for _, row := range rows {
+		line := spendLine{By: *by, Group: row.Group, Model: row.Model, Calls: row.Calls, Failed: row.Failed, Texts: row.Texts, Tokens: row.Tokens}
+		provider, model, _ := strings.Cut(row.Model, "/")
+		if price, ok := modelPrice(ModelInfo{Provider: provider, Model: model}); ok {
+			spend := price * float64(row.Tokens) / 1e6
+			output.TotalCost += spend
+			line.Cost = &spend
+		} else {
+			output.Unpriced = true
+		}
+		output.Rows = append(output.Rows, line)
+	}
+	if *format != "table" {
+		if err := output.write(*format); err != nil {
+			displayError(err)
+			os.Exit(1)
+		}
+		return
+	}
+
+	if len(rows) == 0 {
+		fmt.Println("No calls recorded")
+		return
+	}
+	fmt.Printf("%-12s %-40s %7s %9s %11s %10s\n", *by, "model", "calls", "texts", "tokens", "cost")
+	for _, row := range output.Rows {
+		cost := "?"
+		if row.Cost != nil {
+			cost = fmt.Sprintf("$%.4f", *row.Cost)
+		}
+		calls := fmt.Sprintf("%d", row.Calls)
+		if row.Failed > 0 {
+			calls = fmt.Sprintf("%d (%d failed)", row.Calls, row.Failed)
+		}
+		fmt.Printf("%-12s %-40s %7s %9d %11d %10s\n", row.Group, truncateText(row.Model, 40), calls, row.Texts, row.Tokens, cost)
+	}
+	fmt.Printf("\nTotal: $%.4f", output.TotalCost)
+	if output.Unpriced {
+		fmt.Print(" plus models without a known price • set EMBER_PRICE_PER_MTOK")
+	}
+	fmt.Println()
+}
@@ -0,0 +1,371 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"maps"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/charmbracelet/bubbles/textinput"
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/lipgloss"
+)
+
+// Names typed in select mode
+const (
+	tagPrompt  = "tag"
+	movePrompt = "move"
+)
+
+func newBulkInput() textinput.Model {
+	ti := textinput.New()
+	ti.CharLimit = 64
+	ti.Width = 40
+	return ti
+}
+
+// comparisonDisabled reports whether the configure screen's entry i is left
+// out of the comparison set
+func (m model) comparisonDisabled(i int) bool {
+	return i < len(m.disabledComparisons) && m.disabledComparisons[i]
+}
+
+// enabledComparisonValues returns the texts of the entries that are embedded
+func (m model) enabledComparisonValues() []string {
+	var texts []string
+	for i, ta := range m.embeddingTexts {
+		if !m.comparisonDisabled(i) {
+			texts = append(texts, ta.Value())
+		}
+	}
+	return texts
+}
+
+// disabledComparisonValues returns the texts of the entries left out
+func (m model) disabledComparisonValues() []string {
+	var texts []string
+	for i, ta := range m.embeddingTexts {
+		if m.comparisonDisabled(i) {
+			texts = append(texts, ta.Value())
+		}
+	}
+	return texts
+}
+
+// disableComparisons marks the entries whose text is one of texts as disabled
+func (m *model) disableComparisons(texts []string) {
+	if len(texts) == 0 {
+		return
+	}
+	m.disabledComparisons = make([]bool, len(m.embeddingTexts))
+	for i, ta := range m.embeddingTexts {
+		m.disabledComparisons[i] = slices.ContainsFunc(texts, func(text string) bool { return cleanWhitespace(text) == cleanWhitespace(ta.Value()) })
+	}
+}
+
+// dropComparisonFlag forgets entry i's disabled flag once it's removed
+func (m *model) dropComparisonFlag(i int) {
+	if i < len(m.disabledComparisons) {
+		m.disabledComparisons = slices.Delete(m.disabledComparisons, i, i+1)
+	}
+}
+
+// comparisonIssues validates the enabled entries, reporting issues by their
+// place on the configure screen
+func (m model) comparisonIssues() []comparisonIssue {
+	var positions []int
+	for i := range m.embeddingTexts {
+		if !m.comparisonDisabled(i) {
+			positions = append(positions, i)
+		}
+	}
+	issues := validateComparisonTexts(m.enabledComparisonValues())
+	for i := range issues {
+		issues[i].index = positions[issues[i].index]
+	}
+	return issues
+}
+
+// startSelecting enters select mode, where keys pick entries instead of
+// typing into them
+func (m *model) startSelecting() {
+	m.selecting = true
+	m.selectedEntries = make(map[int]bool)
+	m.bulkPrompt = ""
+	m.bulkNotice = ""
+	for i := range m.embeddingTexts {
+		m.embeddingTexts[i].Blur()
+	}
+}
+
+func (m *model) stopSelecting() {
+	m.selecting = false
+	m.selectedEntries = nil
+	m.bulkPrompt = ""
+	m.bulkInput.Blur()
+	m.selectedTextArea = min(m.selectedTextArea, len(m.embeddingTexts)-1)
+	m.embeddingTexts[m.selectedTextArea].Focus()
+}
+
+// updateSelection handles keys in select mode
+func (m model) updateSelection(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
+	if m.bulkPrompt != "" {
+		switch msg.String() {
+		case "esc", "ctrl+c":
+			m.bulkPrompt = ""
+			m.bulkInput.Blur()
+		case "enter":
+			name := strings.TrimSpace(m.bulkInput.Value())
+			prompt := m.bulkPrompt
+			m.bulkPrompt = ""
+			m.bulkInput.Blur()
+			if name == "" {
+				return m, nil
+			}
+			if prompt == tagPrompt {
+				m.tagEntries(name)
+			} else {
+				m.moveEntries(name)
+			}
+		default:
+			var cmd tea.Cmd
+			m.bulkInput, cmd = m.bulkInput.Update(msg)
+			return m, cmd
+		}
+		return m, nil
+	}
+
+	switch msg.String() {
+	case "esc", "ctrl+c", "alt+x":
+		m.stopSelecting()
+	case "up", "k":
+		if m.selectedTextArea > 0 {
+			m.selectedTextArea--
+		}
+	case "down", "j", "tab":
+		if m.selectedTextArea < len(m.embeddingTexts)-1 {
+			m.selectedTextArea++
+		}
+	case " ":
+		m.selectedEntries[m.selectedTextArea] = !m.selectedEntries[m.selectedTextArea]
+	case "a", "A":
+		all := m.selectedCount() < len(m.embeddingTexts)
+		for i := range m.embeddingTexts {
+			m.selectedEntries[i] = all
+		}
+	case "d", "D":
+		targets := m.bulkTargets()
+		m.deleteEntries(targets)
+		m.bulkNotice = fmt.Sprintf("🗑  Removed %d entries • Alt+Enter to regenerate", len(targets))
+	case "e", "E":
+		m.toggleEntries()
+	case "t", "T", "m", "M":
+		m.bulkPrompt = tagPrompt
+		m.bulkInput.Placeholder = "tag, e.g. refund"
+		if strings.ToLower(msg.String()) == "m" {
+			m.bulkPrompt = movePrompt
+			m.bulkInput.Placeholder = "set name"
+		}
+		m.bulkInput.SetValue("")
+		m.bulkInput.Focus()
+		m.bulkNotice = ""
+	}
+	return m, nil
+}
+
+func (m model) selectedCount() int {
+	count := 0
+	for i := range m.embeddingTexts {
+		if m.selectedEntries[i] {
+			count++
+		}
+	}
+	return count
+}
+
+// bulkTargets returns the selected entries in order, or the entry under the
+// cursor when none are selected
+func (m model) bulkTargets() []int {
+	var targets []int
+	for i := range m.embeddingTexts {
+		if m.selectedEntries[i] {
+			targets = append(targets, i)
+		}
+	}
+	if len(targets) == 0 && m.selectedTextArea < len(m.embeddingTexts) {
+		targets = []int{m.selectedTextArea}
+	}
+	return targets
+}
+
+// deleteEntries removes targets from the configure screen, keeping the rest's
+// disabled flags
+func (m *model) deleteEntries(targets []int) {
+	var texts []string
+	var disabled []bool
+	for i, ta := range m.embeddingTexts {
+		if !slices.Contains(targets, i) {
+			texts = append(texts, ta.Value())
+			disabled = append(disabled, m.comparisonDisabled(i))
+		}
+	}
+	cursor := m.selectedTextArea
+	m.setComparisonTextAreas(texts)
+	m.disabledComparisons = disabled
+	m.embeddingTexts[0].Blur()
+	m.selectedTextArea = min(cursor, len(m.embeddingTexts)-1)
+	m.selectedEntries = make(map[int]bool)
+}
+
+// toggleEntries disables the targets, or enables them when all of them are
+// already disabled
+func (m *model) toggleEntries() {
+	targets := m.bulkTargets()
+	disable := slices.ContainsFunc(targets, func(i int) bool { return !m.comparisonDisabled(i) })
+	if len(m.disabledComparisons) < len(m.embeddingTexts) {
+		m.disabledComparisons = append(m.disabledComparisons, make([]bool, len(m.embeddingTexts)-len(m.disabledComparisons))...)
+	}
+	for _, i := range targets {
+		m.disabledComparisons[i] = disable
+	}
+	if disable {
+		m.bulkNotice = fmt.Sprintf("⏸  Disabled %d entries • Alt+Enter to regenerate without them", len(targets))
+	} else {
+		m.bulkNotice = fmt.Sprintf("▶️  Enabled %d entries • Alt+Enter to regenerate with them", len(targets))
+	}
+}
+
+// tagEntries appends #tag to each target that doesn't have it yet
+func (m *model) tagEntries(name string) {
+	tag := strings.ToLower(strings.TrimPrefix(name, "#"))
+	if tags := textTags("#" + tag); len(tags) != 1 || tags[0] != tag {
+		m.bulkNotice = fmt.Sprintf("⚠️  %q isn't a tag • use letters, digits, - and _", name)
+		return
+	}
+	tagged := 0
+	for _, i := range m.bulkTargets() {
+		text := m.embeddingTexts[i].Value()
+		if strings.TrimSpace(text) == "" || slices.Contains(textTags(text), tag) {
+			continue
+		}
+		m.embeddingTexts[i].SetValue(strings.TrimRight(text, " \n") + " #" + tag)
+		tagged++
+	}
+	m.bulkNotice = fmt.Sprintf("🏷  Tagged %d entries #%s • Alt+Enter to regenerate", tagged, tag)
+}
+
+// moveEntries appends the targets to the saved set name, creating it if
+// needed, and removes them from the configure screen. Vectors from the
+// comparison set are reused when the set is embedded with the current model;
+// otherwise the whole set is embedded again in the background.
+func (m *model) moveEntries(name string) {
+	if name == m.activeSet {
+		m.bulkNotice = fmt.Sprintf("⚠️  The entries are already in %q", name)
+		return
+	}
+	sets, err := m.store.ListSets()
+	if err != nil {
+		m.bulkNotice = "⚠️  " + err.Error()
+		return
+	}
+	set := comparisonSet{Name: name}
+	if i := slices.IndexFunc(sets, func(s comparisonSet) bool { return s.Name == name }); i >= 0 {
+		set = sets[i]
+	}
+
+	info := m.provider.ModelInfo()
+	if len(set.Embeddings) == 0 {
+		set.Model = info
+	}
+	reuse := set.Model.Provider == info.Provider && set.Model.Model == info.Model
+	targets := m.bulkTargets()
+	var moved []string
+	for _, i := range targets {
+		text := m.embeddingTexts[i].Value()
+		if strings.TrimSpace(text) == "" {
+			continue
+		}
+		if m.comparisonDisabled(i) {
+			if !slices.Contains(set.Disabled, text) {
+				set.Disabled = append(set.Disabled, text)
+			}
+			continue
+		}
+		if slices.ContainsFunc(set.Embeddings, func(e CustomEmbedding) bool { return e.source() == text }) {
+			continue
+		}
+		moved = append(moved, text)
+		for _, variable := range templateVars(text) {
+			if _, ok := set.Values[variable]; !ok && m.templateValues[variable] != "" {
+				if set.Values == nil {
+					set.Values = make(map[string]string)
+				}
+				set.Values[variable] = m.templateValues[variable]
+			}
+		}
+		j := slices.IndexFunc(m.customEmbeddings, func(e CustomEmbedding) bool { return e.source() == text })
+		if j < 0 || (len(set.Embeddings) > 0 && len(set.Embeddings[0].Embedding) != len(m.customEmbeddings[j].Embedding)) {
+			reuse = false
+		} else if reuse {
+			set.Embeddings = append(set.Embeddings, m.customEmbeddings[j])
+		}
+	}
+
+	if reuse {
+		set.Saved = time.Now()
+		if err := m.store.SaveSet(set); err != nil {
+			m.bulkNotice = "⚠️  " + err.Error()
+			return
+		}
+		m.bulkNotice = fmt.Sprintf("📦 Moved %d entries to %q", len(targets), name)
+	} else {
+		// Embed the set's own texts too, so every vector comes from one model
+		var texts []string
+		for _, e := range set.Embeddings {
+			if !slices.Contains(moved, e.source()) {
+				texts = append(texts, e.source())
+			}
+		}
+		texts = append(texts, moved...)
+		embed := m.embedComparisonsJob(texts, maps.Clone(set.Values))
+		store := m.store
+		m.jobs.Submit(fmt.Sprintf("Embed %d texts of set %q with %s", len(texts), name, info.Model), func(ctx context.Context, job *Job) (any, error) {
+			result, err := embed(ctx, job)
+			if err != nil {
+				return nil, err
+			}
+			set.Embeddings = result.([]CustomEmbedding)
+			set.Model = info
+			set.Saved = time.Now()
+			// The result is not returned, so the current comparison set is left alone
+			return nil, store.SaveSet(set)
+		})
+		m.bulkNotice = fmt.Sprintf("🔁 Moving %d entries to %q • embedding its texts with %s in the background", len(targets), name, info.Model)
+	}
+	m.deleteEntries(targets)
+}
+
+// renderSelection shows select mode's keys, the name being typed and the
+// result of the last operation
+func (m model) renderSelection() string {
+	instructStyle := lipgloss.NewStyle().Foreground(theme.Muted).Italic(true)
+	s := ""
+	if m.bulkPrompt != "" {
+		label := "🏷  Tag the entries with #"
+		if m.bulkPrompt == movePrompt {
+			label = "📦 Move the entries to the set"
+		}
+		s += lipgloss.NewStyle().Foreground(theme.Primary).Bold(true).Render(label) + " " + m.bulkInput.View() + "\n"
+		s += instructStyle.Render("Enter to apply • Esc to cancel") + "\n"
+	} else {
+		s += lipgloss.NewStyle().Foreground(theme.Accent).Bold(true).Render(fmt.Sprintf("☑️  Select mode • %d selected", m.selectedCount())) + "\n"
+		s += instructStyle.Render("↑/↓ to move • Space to select • A for all • D to delete • E to enable/disable • T to tag • M to move to a set • Esc to finish") + "\n"
+		s += instructStyle.Render("With nothing selected, keys act on the entry under the cursor") + "\n"
+	}
+	if m.bulkNotice != "" {
+		s += lipgloss.NewStyle().Foreground(theme.Muted).Render(m.bulkNotice) + "\n"
+	}
+	return s
+}
@@ -0,0 +1,302 @@
+package main
+
+import (
+	"archive/tar"
+	"compress/gzip"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"io"
+	"io/fs"
+	"net/url"
+	"os"
+	"path"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+)
+
+const (
+	bundleManifest = "manifest.json"
+	// Entries under these prefixes are restored to the data and cache directories
+	bundleDataPrefix  = "data/"
+	bundleCachePrefix = "cache/"
+)
+
+// bundleInfo describes a bundle and carries the non-secret EMBER_*
+// settings of the machine it came from
+type bundleInfo struct {
+	Created  time.Time         `json:"created"`
+	Settings map[string]string `json:"settings,omitempty"`
+}
+
+// isSecretSetting reports whether an environment variable may hold a
+// credential, such as an API key or the passphrase that encrypts the data
+// the bundle carries
+func isSecretSetting(name string) bool {
+	upper := strings.ToUpper(name)
+	for _, word := range []string{"KEY", "TOKEN", "SECRET", "PASSWORD", "PASSPHRASE", "CREDENTIAL"} {
+		if strings.Contains(upper, word) {
+			return true
+		}
+	}
+	return false
+}
+
+// redactSettingURL strips credentials from a URL-valued setting, such as
+// the user and password in EMBER_PGVECTOR_URL, leaving other values alone
+func redactSettingURL(value string) string {
+	u, err := url.Parse(value)
+	if err != nil || u.Scheme == "" || u.Host == "" {
+		return value
+	}
+	u.User = nil
+	if query := u.Query(); query.Has("password") || query.Has("sslpassword") {
+		query.Del("password")
+		query.Del("sslpassword")
+		u.RawQuery = query.Encode()
+	}
+	return u.String()
+}
+
+// isBundledFile reports whether a file in the data directory belongs in a
+// bundle. The SSH host key and redaction key are secrets, and embedding
+// caches are only included when asked for.
+func isBundledFile(rel string, withCache bool) bool {
+	base := path.Base(rel)
+	if strings.HasPrefix(base, "ssh_host_") || base == "redaction.key" {
+		return false
+	}
+	if strings.HasSuffix(base, ".db") {
+		return withCache
+	}
+	return true
+}
+
+// runExportBundleCommand writes comparison sets, history, macros and settings
+// to one archive for backups or moving to another machine
+func runExportBundleCommand(args []string) {
+	fs := flag.NewFlagSet("export-bundle", flag.ExitOnError)
+	withCache := fs.Bool("cache", false, "include the embedding cache")
+	fs.Parse(args)
+
+	out := fmt.Sprintf("ember-bundle-%s.tar.gz", time.Now().Format("20060102"))
+	if fs.NArg() > 0 {
+		out = fs.Arg(0)
+	}
+
+	count, err := exportBundle(out, *withCache)
+	if err != nil {
+		displayError(err)
+		os.Exit(1)
+	}
+	fmt.Printf("📦 Wrote %d files to %s\n", count, out)
+}
+
+func exportBundle(out string, withCache bool) (int, error) {
+	dataDir, err := userDataDir()
+	if err != nil {
+		return 0, err
+	}
+
+	f, err := os.Create(out)
+	if err != nil {
+		return 0, fmt.Errorf("failed to create %s: %w", out, err)
+	}
+	defer f.Close()
+
+	gz := gzip.NewWriter(f)
+	tw := tar.NewWriter(gz)
+
+	info := bundleInfo{Created: time.Now(), Settings: make(map[string]string)}
+	for _, kv := range os.Environ() {
+		name, value, _ := strings.Cut(kv, "=")
+		if strings.HasPrefix(name, "EMBER_") && !isSecretSetting(name) {
+			info.Settings[name] = redactSettingURL(value)
+		}
+	}
+	manifest, err := json.MarshalIndent(info, "", "  ")
+	if err != nil {
+		return 0, fmt.Errorf("failed to marshal manifest: %w", err)
+	}
+	if err := writeTarFile(tw, bundleManifest, manifest); err != nil {
+		return 0, err
+	}
+
+	count := 0
+	err = filepath.WalkDir(dataDir, func(p string, d fs.DirEntry, err error) error {
+		if err != nil || d.IsDir() || !d.Type().IsRegular() {
+			return err
+		}
+		rel, err := filepath.Rel(dataDir, p)
+		if err != nil {
+			return err
+		}
+		rel = filepath.ToSlash(rel)
+		if !isBundledFile(rel, withCache) {
+			return nil
+		}
+
+		data, err := os.ReadFile(p)
+		if err != nil {
+			return fmt.Errorf("failed to read %s: %w", p, err)
+		}
+		count++
+		return writeTarFile(tw, bundleDataPrefix+rel, data)
+	})
+	if err != nil && !os.IsNotExist(err) {
+		return 0, err
+	}
+
+	if withCache {
+		if cachePath, err := defaultCachePath(); err == nil {
+			if data, err := os.ReadFile(cachePath); err == nil {
+				count++
+				if err := writeTarFile(tw, bundleCachePrefix+filepath.Base(cachePath), data); err != nil {
+					return 0, err
+				}
+			}
+		}
+	}
+
+	if err := tw.Close(); err != nil {
+		return 0, fmt.Errorf("failed to finish archive: %w", err)
+	}
+	if err := gz.Close(); err != nil {
+		return 0, fmt.Errorf("failed to finish archive: %w", err)
+	}
+	return count, f.Close()
+}
+
+func writeTarFile(tw *tar.Writer, name string, data []byte) error {
+	hdr := &tar.Header{Name: name, Mode: 0o644, Size: int64(len(data)), ModTime: time.Now()}
+	if err := tw.WriteHeader(hdr); err != nil {
+		return fmt.Errorf("failed to add %s: %w", name, err)
+	}
+	if _, err := tw.Write(data); err != nil {
+		return fmt.Errorf("failed to add %s: %w", name, err)
+	}
+	return nil
+}
+
+// runImportBundleCommand restores a bundle, refusing to overwrite existing
+// files unless --force is given
+func runImportBundleCommand(args []string) {
+	fs := flag.NewFlagSet("import-bundle", flag.ExitOnError)
+	force := fs.Bool("force", false, "overwrite existing files")
+	fs.Parse(args)
+
+	if fs.NArg() != 1 {
+		fmt.Println("Usage: ember import-bundle [--force] BUNDLE")
+		os.Exit(2)
+	}
+
+	info, restored, skipped, err := importBundle(fs.Arg(0), *force)
+	if err != nil {
+		displayError(err)
+		os.Exit(1)
+	}
+
+	fmt.Printf("📦 Restored %d files from a bundle created %s\n", restored, info.Created.Format("2006-01-02 15:04"))
+	if len(skipped) > 0 {
+		fmt.Printf("⚠️  Kept %d existing files (use --force to overwrite):\n", len(skipped))
+		for _, name := range skipped {
+			fmt.Println("   " + name)
+		}
+	}
+	if len(info.Settings) > 0 {
+		names := make([]string, 0, len(info.Settings))
+		for name := range info.Settings {
+			names = append(names, name)
+		}
+		sort.Strings(names)
+
+		fmt.Println("\nSettings from the original machine (API keys are never bundled):")
+		for _, name := range names {
+			fmt.Printf("   export %s=%q\n", name, info.Settings[name])
+		}
+	}
+}
+
+func importBundle(bundle string, force bool) (info bundleInfo, restored int, skipped []string, err error) {
+	dataDir, err := userDataDir()
+	if err != nil {
+		return info, 0, nil, err
+	}
+	cachePath, err := defaultCachePath()
+	if err != nil {
+		return info, 0, nil, err
+	}
+
+	f, err := os.Open(bundle)
+	if err != nil {
+		return info, 0, nil, fmt.Errorf("failed to open bundle: %w", err)
+	}
+	defer f.Close()
+
+	gz, err := gzip.NewReader(f)
+	if err != nil {
+		return info, 0, nil, fmt.Errorf("not a bundle: %w", err)
+	}
+	tr := tar.NewReader(gz)
+
+	for {
+		hdr, err := tr.Next()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return info, restored, skipped, fmt.Errorf("failed to read bundle: %w", err)
+		}
+		if hdr.Typeflag != tar.TypeReg {
+			continue
+		}
+
+		// Reject absolute paths and .. so a bundle can't write outside ember's directories
+		name := path.Clean(hdr.Name)
+		if path.IsAbs(name) || strings.HasPrefix(name, "../") || name == ".." {
+			return info, restored, skipped, fmt.Errorf("bundle contains unsafe path %q", hdr.Name)
+		}
+
+		var dest string
+		switch {
+		case name == bundleManifest:
+			if err := json.NewDecoder(tr).Decode(&info); err != nil {
+				return info, restored, skipped, fmt.Errorf("invalid bundle manifest: %w", err)
+			}
+			continue
+		case strings.HasPrefix(name, bundleDataPrefix):
+			dest = filepath.Join(dataDir, filepath.FromSlash(strings.TrimPrefix(name, bundleDataPrefix)))
+		case strings.HasPrefix(name, bundleCachePrefix):
+			dest = cachePath
+		default:
+			continue
+		}
+
+		if _, err := os.Stat(dest); err == nil && !force {
+			skipped = append(skipped, dest)
+			continue
+		}
+		if err := restoreFile(dest, tr); err != nil {
+			return info, restored, skipped, err
+		}
+		restored++
+	}
+	return info, restored, skipped, nil
+}
+
+func restoreFile(dest string, r io.Reader) error {
+	if err := os.MkdirAll(filepath.Dir(dest), 0o755); err != nil {
+		return fmt.Errorf("failed to create %s: %w", filepath.Dir(dest), err)
+	}
+	out, err := os.Create(dest)
+	if err != nil {
+		return fmt.Errorf("failed to create %s: %w", dest, err)
+	}
+	if _, err := io.Copy(out, r); err != nil {
+		out.Close()
+		return fmt.Errorf("failed to write %s: %w", dest, err)
+	}
+	return out.Close()
+}
@@ -0,0 +1,703 @@
+package main
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"database/sql"
+	"encoding/binary"
+	"encoding/hex"
+	"errors"
+	"flag"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/charmbracelet/lipgloss"
+	_ "modernc.org/sqlite"
+)
+
+// cacheMigrations bring a cache up to date, one per schema version, and the
+// database's user_version records how many have run. Entries are keyed by a
+// SHA-256 of the model key and normalized text, so the cache never stores
+// the texts themselves; the first migration drops the original table keyed
+// by raw text, which only ever held cached data.
+var cacheMigrations = []string{`
+DROP TABLE IF EXISTS embeddings;
+CREATE TABLE IF NOT EXISTS entries (
+	hash      TEXT PRIMARY KEY,
+	model     TEXT NOT NULL,
+	embedding BLOB NOT NULL,
+	created   INTEGER NOT NULL,
+	accessed  INTEGER NOT NULL
+);
+CREATE INDEX IF NOT EXISTS entries_accessed ON entries (accessed)`,
+}
+
+// migrateCache runs the migrations db hasn't had yet, refusing a cache
+// written by a newer ember rather than dropping what it doesn't know
+func migrateCache(db *sql.DB) error {
+	var version int
+	if err := db.QueryRow(`PRAGMA user_version`).Scan(&version); err != nil {
+		return fmt.Errorf("failed to read cache version: %w", err)
+	}
+	if version > len(cacheMigrations) {
+		return fmt.Errorf("cache is version %d, newer than this ember understands (%d)", version, len(cacheMigrations))
+	}
+	for ; version < len(cacheMigrations); version++ {
+		tx, err := db.Begin()
+		if err != nil {
+			return fmt.Errorf("failed to migrate cache: %w", err)
+		}
+		if _, err := tx.Exec(cacheMigrations[version]); err != nil {
+			tx.Rollback()
+			return fmt.Errorf("failed to migrate cache to version %d: %w", version+1, err)
+		}
+		// PRAGMA doesn't take parameters
+		if _, err := tx.Exec(fmt.Sprintf(`PRAGMA user_version = %d`, version+1)); err != nil {
+			tx.Rollback()
+			return fmt.Errorf("failed to migrate cache to version %d: %w", version+1, err)
+		}
+		if err := tx.Commit(); err != nil {
+			return fmt.Errorf("failed to migrate cache to version %d: %w", version+1, err)
+		}
+	}
+	return nil
+}
+
+// ErrOffline is returned for cache misses in offline mode
+var ErrOffline = errors.New("not in the embedding cache (offline mode)")
+
+// EmbeddingCache stores every (model, text) → embedding pair in SQLite so
+// the same text is never sent to a provider twice
+type EmbeddingCache struct {
+	db   *sql.DB
+	path string
+
+	// ttl expires entries this long after they were written; zero keeps them forever
+	ttl time.Duration
+	// maxBytes evicts the least recently used entries once vectors take more
+	// than this much space; zero means no limit
+	maxBytes int64
+	// offline serves only from the cache and never calls the provider
+	offline bool
+
+	mu     sync.Mutex
+	size   int64
+	hits   atomic.Int64
+	misses atomic.Int64
+
+	// writeErr is the first failed write and writeFailures counts them all,
+	// so a full disk or read-only cache doesn't go unnoticed
+	writeErr      error
+	writeFailures int64
+}
+
+type cacheOptions struct {
+	ttl      time.Duration
+	maxBytes int64
+	offline  bool
+}
+
+// loadCacheOptions reads EMBER_CACHE_TTL (a duration such as 720h),
+// EMBER_CACHE_MAX_MB and EMBER_OFFLINE
+func loadCacheOptions() (cacheOptions, error) {
+	var opts cacheOptions
+
+	if v := os.Getenv("EMBER_CACHE_TTL"); v != "" {
+		ttl, err := time.ParseDuration(v)
+		if err != nil || ttl < 0 {
+			return opts, fmt.Errorf("invalid EMBER_CACHE_TTL %q: use a duration such as 720h", v)
+		}
+		opts.ttl = ttl
+	}
+	if v := os.Getenv("EMBER_CACHE_MAX_MB"); v != "" {
+		mb, err := strconv.Atoi(v)
+		if err != nil || mb < 0 {
+			return opts, fmt.Errorf("invalid EMBER_CACHE_MAX_MB %q", v)
+		}
+		opts.maxBytes = int64(mb) << 20
+	}
+	opts.offline = os.Getenv("EMBER_OFFLINE") != ""
+	return opts, nil
+}
+
+// defaultCachePath reads EMBER_CACHE_PATH, falling back to the project's
+// cache directory and then the user cache directory
+func defaultCachePath() (string, error) {
+	if path := os.Getenv("EMBER_CACHE_PATH"); path != "" {
+		return path, nil
+	}
+	if dir := projectDataDir(); dir != "" {
+		return filepath.Join(dir, "cache", "embeddings.db"), nil
+	}
+	dir, err := os.UserCacheDir()
+	if err != nil {
+		return "", fmt.Errorf("failed to find cache directory: %w", err)
+	}
+	return filepath.Join(dir, "ember", "embeddings.db"), nil
+}
+
+func openEmbeddingCache(path string, opts cacheOptions) (*EmbeddingCache, error) {
+	if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+		return nil, fmt.Errorf("failed to create cache directory: %w", err)
+	}
+
+	db, err := sql.Open("sqlite", path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open cache: %w", err)
+	}
+	// A single connection serializes writers instead of failing with SQLITE_BUSY
+	db.SetMaxOpenConns(1)
+	// The daemon keeps the cache open while other ember processes write to it
+	if _, err := db.Exec(`PRAGMA busy_timeout = 5000`); err != nil {
+		db.Close()
+		return nil, fmt.Errorf("failed to open cache: %w", err)
+	}
+	if err := migrateCache(db); err != nil {
+		db.Close()
+		return nil, err
+	}
+
+	c := &EmbeddingCache{db: db, path: path, ttl: opts.ttl, maxBytes: opts.maxBytes, offline: opts.offline}
+	if err := c.expire(); err != nil {
+		db.Close()
+		return nil, err
+	}
+	if err := db.QueryRow(`SELECT COALESCE(SUM(length(embedding)), 0) FROM entries`).Scan(&c.size); err != nil {
+		db.Close()
+		return nil, fmt.Errorf("failed to read cache: %w", err)
+	}
+	return c, nil
+}
+
+// Close reports any failed writes on stderr, once, and closes the database
+func (c *EmbeddingCache) Close() error {
+	if failures, err := c.WriteError(); err != nil {
+		fmt.Fprintf(os.Stderr, "⚠️  %d embeddings couldn't be cached: %v\n", failures, err)
+	}
+	return c.db.Close()
+}
+
+// noteWriteError records a failed write; the embedding itself is still used
+func (c *EmbeddingCache) noteWriteError(err error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if c.writeErr == nil {
+		c.writeErr = err
+	}
+	c.writeFailures++
+}
+
+// WriteError returns how many writes failed and the first failure, if any
+func (c *EmbeddingCache) WriteError() (int64, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.writeFailures, c.writeErr
+}
+
+// cacheModelKey identifies everything that changes a vector: provider, model,
+// dimensions and input type
+func cacheModelKey(info ModelInfo, inputType string) string {
+	key := info.Provider + "/" + info.Model
+	if info.Dimensions > 0 {
+		key += fmt.Sprintf("@%d", info.Dimensions)
+	}
+	if inputType != "" {
+		key += "#" + inputType
+	}
+	return key
+}
+
+// cacheHash keys an entry by model and text; surrounding whitespace and line
+// endings are normalized so pasted copies of a text share one entry
+func cacheHash(model, text string) string {
+	text = strings.TrimSpace(strings.ReplaceAll(text, "\r\n", "\n"))
+	sum := sha256.Sum256([]byte(model + "\x00" + text))
+	return hex.EncodeToString(sum[:])
+}
+
+// expire removes entries older than the TTL
+func (c *EmbeddingCache) expire() error {
+	if c.ttl == 0 {
+		return nil
+	}
+	cutoff := time.Now().Add(-c.ttl).Unix()
+	if _, err := c.db.Exec(`DELETE FROM entries WHERE created < ?`, cutoff); err != nil {
+		return fmt.Errorf("failed to expire cache entries: %w", err)
+	}
+	return nil
+}
+
+func (c *EmbeddingCache) Get(model, text string) ([]float64, bool) {
+	hash := cacheHash(model, text)
+
+	var blob []byte
+	var created int64
+	err := c.db.QueryRow(`SELECT embedding, created FROM entries WHERE hash = ?`, hash).Scan(&blob, &created)
+	if err != nil || (c.ttl > 0 && time.Since(time.Unix(created, 0)) > c.ttl) {
+		c.misses.Add(1)
+		return nil, false
+	}
+
+	blob, err = openAtRest(blob)
+	if err != nil {
+		c.misses.Add(1)
+		return nil, false
+	}
+	embedding, err := decodeEmbedding(blob)
+	if err != nil {
+		c.misses.Add(1)
+		return nil, false
+	}
+	c.hits.Add(1)
+	c.db.Exec(`UPDATE entries SET accessed = ? WHERE hash = ?`, time.Now().UnixNano(), hash)
+	return embedding, true
+}
+
+// Has reports whether text is cached for model without counting a hit or
+// refreshing the entry, so looking doesn't change what gets evicted
+func (c *EmbeddingCache) Has(model, text string) bool {
+	var created int64
+	err := c.db.QueryRow(`SELECT created FROM entries WHERE hash = ?`, cacheHash(model, text)).Scan(&created)
+	return err == nil && (c.ttl == 0 || time.Since(time.Unix(created, 0)) <= c.ttl)
+}
+
+func (c *EmbeddingCache) Put(model, text string, embedding []float64) error {
+	hash := cacheHash(model, text)
+	blob := sealAtRest(encodeEmbedding(embedding))
+	now := time.Now()
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	var previous int64
+	c.db.QueryRow(`SELECT length(embedding) FROM entries WHERE hash = ?`, hash).Scan(&previous)
+
+	_, err := c.db.Exec(`INSERT OR REPLACE INTO entries (hash, model, embedding, created, accessed) VALUES (?, ?, ?, ?, ?)`,
+		hash, model, blob, now.Unix(), now.UnixNano())
+	if err != nil {
+		return fmt.Errorf("failed to write cache: %w", err)
+	}
+	c.size += int64(len(blob)) - previous
+
+	if c.maxBytes > 0 && c.size > c.maxBytes {
+		return c.evict()
+	}
+	return nil
+}
+
+// evict drops the least recently used entries until the cache fits in maxBytes
+// again, leaving a tenth of the limit free so evictions don't run on every write
+func (c *EmbeddingCache) evict() error {
+	target := c.maxBytes - c.maxBytes/10
+
+	rows, err := c.db.Query(`SELECT hash, length(embedding) FROM entries ORDER BY accessed`)
+	if err != nil {
+		return fmt.Errorf("failed to evict cache entries: %w", err)
+	}
+
+	var victims []string
+	size := c.size
+	for size > target && rows.Next() {
+		var hash string
+		var n int64
+		if err := rows.Scan(&hash, &n); err != nil {
+			rows.Close()
+			return fmt.Errorf("failed to evict cache entries: %w", err)
+		}
+		victims = append(victims, hash)
+		size -= n
+	}
+	rows.Close()
+
+	for _, hash := range victims {
+		if _, err := c.db.Exec(`DELETE FROM entries WHERE hash = ?`, hash); err != nil {
+			return fmt.Errorf("failed to evict cache entries: %w", err)
+		}
+	}
+	c.size = size
+	return nil
+}
+
+// Session returns this process's hit and miss counts
+func (c *EmbeddingCache) Session() (hits, misses int64) {
+	return c.hits.Load(), c.misses.Load()
+}
+
+type cacheModelStats struct {
+	Model   string `json:"model"`
+	Entries int    `json:"entries"`
+	// Bytes is the space the model's vectors take
+	Bytes int64 `json:"bytes"`
+	// Oldest and Newest are when its first and latest entries were written
+	Oldest time.Time `json:"oldest"`
+	Newest time.Time `json:"newest"`
+	// Used is when one of its entries was last read or written
+	Used time.Time `json:"used"`
+}
+
+// Stats counts cached embeddings and the space they take per model
+func (c *EmbeddingCache) Stats() ([]cacheModelStats, error) {
+	rows, err := c.db.Query(`SELECT model, COUNT(*), SUM(length(embedding)), MIN(created), MAX(created), MAX(accessed) FROM entries GROUP BY model ORDER BY model`)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read cache: %w", err)
+	}
+	defer rows.Close()
+
+	var stats []cacheModelStats
+	for rows.Next() {
+		var s cacheModelStats
+		var oldest, newest, used int64
+		if err := rows.Scan(&s.Model, &s.Entries, &s.Bytes, &oldest, &newest, &used); err != nil {
+			return nil, fmt.Errorf("failed to read cache: %w", err)
+		}
+		s.Oldest, s.Newest, s.Used = time.Unix(oldest, 0), time.Unix(newest, 0), time.Unix(0, used)
+		stats = append(stats, s)
+	}
+	return stats, rows.Err()
+}
+
+// pruneRules pick the entries `ember cache prune` deletes. An entry goes when
+// any rule matches it; a zero rule is off.
+type pruneRules struct {
+	// olderThan removes entries written longer ago than this
+	olderThan time.Duration
+	// unusedFor removes entries not read or written for this long
+	unusedFor time.Duration
+	// maxBytes then removes the least recently used entries until the
+	// vectors fit in this much space, when limitSize is set; 0 removes them all
+	maxBytes  int64
+	limitSize bool
+	// model limits the rules to one model key, such as openai/text-embedding-3-small
+	model string
+}
+
+// Prune deletes the entries the rules pick, compacts the file and returns
+// how many entries were removed
+func (c *EmbeddingCache) Prune(rules pruneRules) (int64, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	var removed int64
+	remove := func(query string, args ...any) error {
+		if rules.model != "" {
+			query += ` AND model = ?`
+			args = append(args, rules.model)
+		}
+		result, err := c.db.Exec(query, args...)
+		if err != nil {
+			return fmt.Errorf("failed to prune cache: %w", err)
+		}
+		n, _ := result.RowsAffected()
+		removed += n
+		return nil
+	}
+
+	if rules.olderThan > 0 {
+		if err := remove(`DELETE FROM entries WHERE created < ?`, time.Now().Add(-rules.olderThan).Unix()); err != nil {
+			return removed, err
+		}
+	}
+	if rules.unusedFor > 0 {
+		if err := remove(`DELETE FROM entries WHERE accessed < ?`, time.Now().Add(-rules.unusedFor).UnixNano()); err != nil {
+			return removed, err
+		}
+	}
+	if rules.limitSize {
+		scope, args := ``, []any{}
+		if rules.model != "" {
+			scope, args = ` WHERE model = ?`, []any{rules.model}
+		}
+		var size int64
+		if err := c.db.QueryRow(`SELECT COALESCE(SUM(length(embedding)), 0) FROM entries`+scope, args...).Scan(&size); err != nil {
+			return removed, fmt.Errorf("failed to prune cache: %w", err)
+		}
+		rows, err := c.db.Query(`SELECT hash, length(embedding) FROM entries`+scope+` ORDER BY accessed`, args...)
+		if err != nil {
+			return removed, fmt.Errorf("failed to prune cache: %w", err)
+		}
+		var victims []string
+		for size > rules.maxBytes && rows.Next() {
+			var hash string
+			var n int64
+			if err := rows.Scan(&hash, &n); err != nil {
+				rows.Close()
+				return removed, fmt.Errorf("failed to prune cache: %w", err)
+			}
+			victims = append(victims, hash)
+			size -= n
+		}
+		rows.Close()
+		for _, hash := range victims {
+			if _, err := c.db.Exec(`DELETE FROM entries WHERE hash = ?`, hash); err != nil {
+				return removed, fmt.Errorf("failed to prune cache: %w", err)
+			}
+			removed++
+		}
+	}
+
+	if _, err := c.db.Exec(`VACUUM`); err != nil {
+		return removed, fmt.Errorf("failed to compact cache: %w", err)
+	}
+	if err := c.db.QueryRow(`SELECT COALESCE(SUM(length(embedding)), 0) FROM entries`).Scan(&c.size); err != nil {
+		return removed, fmt.Errorf("failed to read cache: %w", err)
+	}
+	return removed, nil
+}
+
+// Clear deletes every cached embedding and returns how many there were
+func (c *EmbeddingCache) Clear() (int64, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	result, err := c.db.Exec(`DELETE FROM entries`)
+	if err != nil {
+		return 0, fmt.Errorf("failed to clear cache: %w", err)
+	}
+	if _, err := c.db.Exec(`VACUUM`); err != nil {
+		return 0, fmt.Errorf("failed to compact cache: %w", err)
+	}
+	c.size = 0
+	return result.RowsAffected()
+}
+
+// Vectors are stored as little-endian float64s so cached scores match fresh ones exactly
+func encodeEmbedding(embedding []float64) []byte {
+	var buf bytes.Buffer
+	binary.Write(&buf, binary.LittleEndian, embedding)
+	return buf.Bytes()
+}
+
+func decodeEmbedding(blob []byte) ([]float64, error) {
+	if len(blob)%8 != 0 {
+		return nil, fmt.Errorf("corrupt cache entry of %d bytes", len(blob))
+	}
+	embedding := make([]float64, len(blob)/8)
+	if err := binary.Read(bytes.NewReader(blob), binary.LittleEndian, embedding); err != nil {
+		return nil, err
+	}
+	return embedding, nil
+}
+
+// cachedProvider answers from the cache and only sends misses to the wrapped provider
+type cachedProvider struct {
+	EmbeddingProvider
+	cache *EmbeddingCache
+	model string
+}
+
+// withCache wraps p so its results are cached under the model and input type
+func (m model) withCache(p EmbeddingProvider, inputType string) EmbeddingProvider {
+	if m.cache == nil {
+		return p
+	}
+	return &cachedProvider{EmbeddingProvider: p, cache: m.cache, model: cacheModelKey(p.ModelInfo(), inputType)}
+}
+
+func (p *cachedProvider) GenerateEmbedding(text string) ([]float64, error) {
+	embedding, _, err := p.generateCached(text)
+	return embedding, err
+}
+
+// generateCached embeds text and reports whether it came from the cache
+func (p *cachedProvider) generateCached(text string) ([]float64, bool, error) {
+	if embedding, ok := p.cache.Get(p.model, text); ok {
+		return embedding, true, nil
+	}
+	if p.cache.offline {
+		return nil, false, ErrOffline
+	}
+
+	embedding, err := p.EmbeddingProvider.GenerateEmbedding(text)
+	if err != nil {
+		return nil, false, err
+	}
+	if err := p.cache.Put(p.model, text, embedding); err != nil {
+		p.cache.noteWriteError(err)
+	}
+	return embedding, false, nil
+}
+
+func (p *cachedProvider) GenerateBatch(texts []string) ([][]float64, error) {
+	embeddings := make([][]float64, len(texts))
+	var missing []string
+	var missingIdx []int
+
+	for i, text := range texts {
+		if embedding, ok := p.cache.Get(p.model, text); ok {
+			embeddings[i] = embedding
+		} else {
+			missing = append(missing, text)
+			missingIdx = append(missingIdx, i)
+		}
+	}
+	if len(missing) == 0 {
+		return embeddings, nil
+	}
+	if p.cache.offline {
+		return nil, fmt.Errorf("%d of %d texts: %w", len(missing), len(texts), ErrOffline)
+	}
+
+	fetched, err := p.EmbeddingProvider.GenerateBatch(missing)
+	if err != nil {
+		return nil, err
+	}
+	for j, embedding := range fetched {
+		embeddings[missingIdx[j]] = embedding
+		if err := p.cache.Put(p.model, missing[j], embedding); err != nil {
+			p.cache.noteWriteError(err)
+		}
+	}
+	return embeddings, nil
+}
+
+// renderCacheStatus shows this session's cache hits on the input screen
+func (m model) renderCacheStatus() string {
+	if m.cache == nil {
+		return ""
+	}
+	s := ""
+	if m.cache.offline {
+		s += lipgloss.NewStyle().
+			Foreground(theme.Warning).
+			Bold(true).
+			Render("📴 Offline: only cached embeddings are used") + "\n"
+	}
+	if failures, err := m.cache.WriteError(); err != nil {
+		s += lipgloss.NewStyle().
+			Foreground(theme.Warning).
+			Render(fmt.Sprintf("⚠️  %d embeddings couldn't be cached: %v", failures, err)) + "\n"
+	}
+	hits, misses := m.cache.Session()
+	if hits+misses == 0 {
+		return s
+	}
+	return s + lipgloss.NewStyle().
+		Foreground(theme.Muted).
+		Render(fmt.Sprintf("⚡ Cache: %d hits • %d misses this session", hits, misses)) + "\n"
+}
+
+// runCacheCommand handles `ember cache stats`, `ember cache prune` and `ember cache clear`
+func runCacheCommand(args []string) {
+	if len(args) == 0 || (args[0] != "stats" && args[0] != "prune" && args[0] != "clear") {
+		fmt.Println("Usage: ember cache stats|prune|clear")
+		os.Exit(2)
+	}
+
+	var rules pruneRules
+	format := new(string)
+	if args[0] == "stats" {
+		fs := flag.NewFlagSet("cache stats", flag.ExitOnError)
+		format = formatFlag(fs, "table")
+		fs.Parse(args[1:])
+		checkFormat(format)
+	}
+	if args[0] == "prune" {
+		fs := flag.NewFlagSet("cache prune", flag.ExitOnError)
+		fs.DurationVar(&rules.olderThan, "older-than", 0, "remove entries written longer ago than this, such as 720h (default: EMBER_CACHE_TTL)")
+		fs.DurationVar(&rules.unusedFor, "unused-for", 0, "remove entries not used for this long, such as 2160h")
+		maxMB := fs.Int("max-mb", 0, "then remove the least recently used entries until the cache fits in this many MB (default: EMBER_CACHE_MAX_MB)")
+		fs.StringVar(&rules.model, "model", "", "only prune this model, as listed by ember cache stats")
+		fs.Parse(args[1:])
+		// --max-mb 0 empties the cache, so it's told apart from no --max-mb
+		fs.Visit(func(f *flag.Flag) {
+			rules.limitSize = rules.limitSize || f.Name == "max-mb"
+		})
+		if *maxMB < 0 {
+			fmt.Println("Usage: ember cache prune [--older-than 720h] [--unused-for 2160h] [--max-mb 500] [--model key]")
+			os.Exit(2)
+		}
+		rules.maxBytes = int64(*maxMB) << 20
+	}
+
+	path, err := defaultCachePath()
+	if err != nil {
+		displayError(err)
+		os.Exit(1)
+	}
+	opts, err := loadCacheOptions()
+	if err != nil {
+		displayError(err)
+		os.Exit(1)
+	}
+	cache, err := openEmbeddingCache(path, opts)
+	if err != nil {
+		displayError(err)
+		os.Exit(1)
+	}
+	defer cache.Close()
+
+	switch args[0] {
+	case "stats":
+		stats, err := cache.Stats()
+		if err != nil {
+			displayError(err)
+			os.Exit(1)
+		}
+
+		output := cacheStatsOutput{Path: cache.path, TTLSeconds: int64(opts.ttl.Seconds()), LimitBytes: opts.maxBytes, Models: []cacheModelStats{}}
+		if info, err := os.Stat(cache.path); err == nil {
+			output.SizeBytes = info.Size()
+		}
+		for _, s := range stats {
+			output.Entries += s.Entries
+			output.Models = append(output.Models, s)
+		}
+		if *format != "table" {
+			if err := output.write(*format); err != nil {
+				displayError(err)
+				os.Exit(1)
+			}
+			return
+		}
+
+		lines := make([]string, len(stats))
+		for i, s := range stats {
+			lines[i] = fmt.Sprintf("  %-48s %8d %9.1f MB  %s – %s  %s", s.Model, s.Entries, float64(s.Bytes)/(1<<20),
+				s.Oldest.Format("2006-01-02"), s.Newest.Format("2006-01-02"), s.Used.Format("2006-01-02"))
+		}
+
+		fmt.Printf("Path:     %s\n", cache.path)
+		fmt.Printf("Size:     %.1f MB\n", float64(output.SizeBytes)/(1<<20))
+		fmt.Printf("Entries:  %d\n", output.Entries)
+		if opts.ttl > 0 {
+			fmt.Printf("TTL:      %s\n", opts.ttl)
+		}
+		if opts.maxBytes > 0 {
+			fmt.Printf("Limit:    %d MB\n", opts.maxBytes>>20)
+		}
+		if len(lines) > 0 {
+			fmt.Printf("  %-48s %8s %12s  %-23s  %s\n", "Model", "Entries", "Vectors", "Written", "Last used")
+			fmt.Println(strings.Join(lines, "\n"))
+		}
+	case "prune":
+		// Without rules, prune applies the configured TTL and size limit now
+		if rules.olderThan == 0 && rules.unusedFor == 0 && !rules.limitSize {
+			rules.olderThan = opts.ttl
+			rules.maxBytes, rules.limitSize = opts.maxBytes, opts.maxBytes > 0
+		}
+		if rules.olderThan == 0 && rules.unusedFor == 0 && !rules.limitSize {
+			fmt.Println("Usage: ember cache prune [--older-than 720h] [--unused-for 2160h] [--max-mb 500] [--model key]")
+			fmt.Println("Give a rule, or set EMBER_CACHE_TTL or EMBER_CACHE_MAX_MB.")
+			os.Exit(2)
+		}
+		n, err := cache.Prune(rules)
+		if err != nil {
+			displayError(err)
+			os.Exit(1)
+		}
+		fmt.Printf("🧹 Removed %d cached embeddings • %.1f MB of vectors left\n", n, float64(cache.size)/(1<<20))
+	case "clear":
+		n, err := cache.Clear()
+		if err != nil {
+			displayError(err)
+			os.Exit(1)
+		}
+		fmt.Printf("🧹 Removed %d cached embeddings\n", n)
+	}
+}
@@ -0,0 +1,154 @@
+package main
+
+import (
+	"fmt"
+	"math"
+	"os"
+	"strconv"
+	"strings"
+)
+
+// chunkAggregation decides how chunk embeddings combine into one score
+type chunkAggregation string
+
+const (
+	// aggregateSingle compares one vector per text, as the provider pools it
+	aggregateSingle chunkAggregation = "single"
+	// aggregateMax takes the best-matching pair of chunks
+	aggregateMax chunkAggregation = "max"
+	// aggregateMean averages every pair of chunks
+	aggregateMean chunkAggregation = "mean"
+	// aggregateLate matches each input chunk to its best comparison chunk and
+	// averages those, like ColBERT's MaxSim over chunks
+	aggregateLate chunkAggregation = "late"
+)
+
+// Words per chunk unless EMBER_CHUNK_WORDS says otherwise
+const defaultChunkWords = 128
+
+var chunkAggregationLabels = map[chunkAggregation]string{
+	aggregateMax:  "max-pairwise",
+	aggregateMean: "mean-pairwise",
+	aggregateLate: "late interaction",
+}
+
+type chunkOptions struct {
+	aggregation chunkAggregation
+	words       int
+}
+
+// loadChunkOptions reads EMBER_CHUNK_AGGREGATION (single, max, mean or late)
+// and EMBER_CHUNK_WORDS
+func loadChunkOptions() (chunkOptions, error) {
+	opts := chunkOptions{aggregation: aggregateSingle, words: defaultChunkWords}
+
+	if value := os.Getenv("EMBER_CHUNK_AGGREGATION"); value != "" {
+		opts.aggregation = chunkAggregation(strings.ToLower(value))
+		if _, ok := chunkAggregationLabels[opts.aggregation]; !ok && opts.aggregation != aggregateSingle {
+			return opts, fmt.Errorf("invalid EMBER_CHUNK_AGGREGATION %q (use single, max, mean or late)", value)
+		}
+	}
+	if value := os.Getenv("EMBER_CHUNK_WORDS"); value != "" {
+		words, err := strconv.Atoi(value)
+		if err != nil || words < 1 {
+			return opts, fmt.Errorf("invalid EMBER_CHUNK_WORDS %q", value)
+		}
+		opts.words = words
+	}
+	return opts, nil
+}
+
+// splitChunks cuts text into pieces of up to words words. Texts that fit in
+// one chunk return nil, since their chunk would just repeat the whole vector.
+func splitChunks(text string, words int) []string {
+	fields := strings.Fields(text)
+	if len(fields) <= words {
+		return nil
+	}
+
+	var chunks []string
+	for start := 0; start < len(fields); start += words {
+		chunks = append(chunks, strings.Join(fields[start:min(start+words, len(fields))], " "))
+	}
+	return chunks
+}
+
+// embedChunks embeds text's chunks when chunk aggregation is on and the text is long enough
+func (o chunkOptions) embedChunks(provider EmbeddingProvider, text string) ([][]float64, error) {
+	if o.aggregation == aggregateSingle {
+		return nil, nil
+	}
+	chunks := splitChunks(text, o.words)
+	if chunks == nil {
+		return nil, nil
+	}
+
+	vectors, err := provider.GenerateBatch(chunks)
+	if err != nil {
+		return nil, fmt.Errorf("failed to embed chunks: %w", err)
+	}
+	return vectors, nil
+}
+
+// aggregateChunks scores two texts from their chunk vectors. A text without
+// chunks counts as one chunk, its whole vector.
+func aggregateChunks(aggregation chunkAggregation, a, b [][]float64) float64 {
+	switch aggregation {
+	case aggregateMax:
+		best := math.Inf(-1)
+		for _, x := range a {
+			for _, y := range b {
+				best = math.Max(best, cosineSimilarity(x, y))
+			}
+		}
+		return best
+	case aggregateMean:
+		var sum float64
+		for _, x := range a {
+			for _, y := range b {
+				sum += cosineSimilarity(x, y)
+			}
+		}
+		return sum / float64(len(a)*len(b))
+	case aggregateLate:
+		var sum float64
+		for _, x := range a {
+			best := math.Inf(-1)
+			for _, y := range b {
+				best = math.Max(best, cosineSimilarity(x, y))
+			}
+			sum += best
+		}
+		return sum / float64(len(a))
+	}
+	return cosineSimilarity(a[0], b[0])
+}
+
+// compareWithChunks scores the input against a comparison with the chosen
+// aggregation, falling back to plain cosine similarity when neither side
+// was chunked
+func compareWithChunks(aggregation chunkAggregation, input []float64, inputChunks [][]float64, e CustomEmbedding) SimilarityResult {
+	result := SimilarityResult{Text: e.Text, Similarity: cosineSimilarity(input, e.Embedding)}
+	if aggregation == aggregateSingle || (len(inputChunks) == 0 && len(e.Chunks) == 0) {
+		return result
+	}
+
+	a := inputChunks
+	if len(a) == 0 {
+		a = [][]float64{input}
+	}
+	b := e.Chunks
+	if len(b) == 0 || len(b[0]) != len(input) {
+		b = [][]float64{e.Embedding}
+	}
+	result.Similarity = aggregateChunks(aggregation, a, b)
+	return result
+}
+
+// label describes the aggregation on the results screen, or "" when it's off
+func (o chunkOptions) label() string {
+	if o.aggregation == aggregateSingle {
+		return ""
+	}
+	return fmt.Sprintf("🧩 %s over %d-word chunks", chunkAggregationLabels[o.aggregation], o.words)
+}
@@ -0,0 +1,237 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+)
+
+const (
+	cohereEmbedURL      = "https://api.cohere.com/v1/embed"
+	cohereModelsURL     = "https://api.cohere.com/v1/models?endpoint=embed"
+	cohereDefaultModel  = "embed-english-v3.0"
+	cohereDefaultType   = "search_document"
+	cohereMaxBatchTexts = 96
+)
+
+// Cohere embeds queries and documents differently; these are the accepted input_type values
+var cohereInputTypes = []string{"search_document", "search_query", "classification", "clustering"}
+
+// CohereProvider embeds text with Cohere's /v1/embed endpoint
+type CohereProvider struct {
+	apiKey    string
+	client    *http.Client
+	model     string
+	inputType string
+	// purpose is recorded in the audit log for this copy's calls
+	purpose string
+	// notices are the deprecations and quota warnings the API sent
+	notices *noticeLog
+}
+
+type CohereEmbedRequest struct {
+	Texts     []string `json:"texts"`
+	Model     string   `json:"model"`
+	InputType string   `json:"input_type"`
+}
+
+type CohereModelsResponse struct {
+	Models []struct {
+		Name string `json:"name"`
+	} `json:"models"`
+}
+
+type CohereEmbedResponse struct {
+	ID         string      `json:"id"`
+	Embeddings [][]float64 `json:"embeddings"`
+	Texts      []string    `json:"texts"`
+	Meta       struct {
+		BilledUnits struct {
+			InputTokens int `json:"input_tokens"`
+		} `json:"billed_units"`
+	} `json:"meta"`
+}
+
+func init() {
+	RegisterProvider("cohere", func() (EmbeddingProvider, error) {
+		return NewCohereProvider()
+	})
+	RegisterModelReader("cohere", modelFromEnv("EMBER_COHERE_MODEL", cohereDefaultModel))
+}
+
+func NewCohereProvider() (*CohereProvider, error) {
+	apiKey := os.Getenv("COHERE_API_KEY")
+	if apiKey == "" {
+		return nil, fmt.Errorf("COHERE_API_KEY environment variable not set")
+	}
+
+	model := os.Getenv("EMBER_COHERE_MODEL")
+	if model == "" {
+		model = cohereDefaultModel
+	}
+
+	return &CohereProvider{
+		apiKey:    apiKey,
+		client:    &http.Client{},
+		model:     model,
+		inputType: cohereDefaultType,
+		notices:   newNoticeLog(),
+	}, nil
+}
+
+func (c *CohereProvider) ModelInfo() ModelInfo {
+	return ModelInfo{Provider: "cohere", Model: c.model}
+}
+
+func (c *CohereProvider) WithModel(model string) EmbeddingProvider {
+	copied := *c
+	copied.model = model
+	return &copied
+}
+
+// ListModels asks Cohere for the models that support the embed endpoint
+func (c *CohereProvider) ListModels() ([]string, error) {
+	req, err := http.NewRequest("GET", cohereModelsURL, nil)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create request: %w", err)
+	}
+	req.Header.Set("Authorization", "Bearer "+c.apiKey)
+
+	resp, err := c.client.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("failed to make request: %w", err)
+	}
+	defer resp.Body.Close()
+
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read response: %w", err)
+	}
+	c.notices.record("cohere", resp, body)
+	if resp.StatusCode != http.StatusOK {
+		return nil, &APIStatusError{StatusCode: resp.StatusCode, Body: string(body)}
+	}
+
+	var modelsResp CohereModelsResponse
+	if err := json.Unmarshal(body, &modelsResp); err != nil {
+		return nil, fmt.Errorf("failed to unmarshal response: %w", err)
+	}
+
+	models := make([]string, len(modelsResp.Models))
+	for i, m := range modelsResp.Models {
+		models[i] = m.Name
+	}
+	return models, nil
+}
+
+// Notices returns the deprecations and quota warnings the API sent this session
+func (c *CohereProvider) Notices() []ProviderNotice {
+	return c.notices.list()
+}
+
+func (c *CohereProvider) DismissNotices() {
+	c.notices.dismiss()
+}
+
+// InputTypes lists the input_type values the embeddings screen can cycle through
+func (c *CohereProvider) InputTypes() []string {
+	return cohereInputTypes
+}
+
+// WithPurpose returns a copy of the provider whose calls the audit log records as for purpose
+func (c *CohereProvider) WithPurpose(purpose string) EmbeddingProvider {
+	copied := *c
+	copied.purpose = purpose
+	return &copied
+}
+
+// WithInputType returns a copy of the provider that sends the given input_type
+func (c *CohereProvider) WithInputType(inputType string) EmbeddingProvider {
+	copied := *c
+	copied.inputType = inputType
+	return &copied
+}
+
+func (c *CohereProvider) GenerateEmbedding(text string) ([]float64, error) {
+	embeddings, err := c.GenerateBatch([]string{text})
+	if err != nil {
+		return nil, err
+	}
+	return embeddings[0], nil
+}
+
+func (c *CohereProvider) GenerateBatch(texts []string) ([][]float64, error) {
+	embeddings := make([][]float64, 0, len(texts))
+	for start := 0; start < len(texts); start += cohereMaxBatchTexts {
+		end := min(start+cohereMaxBatchTexts, len(texts))
+		chunk, err := c.embed(texts[start:end])
+		if err != nil {
+			return nil, err
+		}
+		embeddings = append(embeddings, chunk...)
+	}
+	return embeddings, nil
+}
+
+func (c *CohereProvider) embed(texts []string) (embeddings [][]float64, err error) {
+	reqBody := CohereEmbedRequest{
+		Texts:     texts,
+		Model:     c.model,
+		InputType: c.inputType,
+	}
+
+	jsonData, err := json.Marshal(reqBody)
+	if err != nil {
+		return nil, fmt.Errorf("failed to marshal request: %w", err)
+	}
+
+	req, err := http.NewRequest("POST", cohereEmbedURL, bytes.NewBuffer(jsonData))
+	if err != nil {
+		return nil, fmt.Errorf("failed to create request: %w", err)
+	}
+
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("Authorization", "Bearer "+c.apiKey)
+
+	tokens := 0
+	defer func() { auditCall(c.ModelInfo(), c.purpose, texts, tokens, err) }()
+
+	resp, err := c.client.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("failed to make request: %w", err)
+	}
+	defer resp.Body.Close()
+
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read response: %w", err)
+	}
+	c.notices.record("cohere/"+c.model, resp, body)
+
+	if resp.StatusCode == http.StatusTooManyRequests {
+		wait := retryAfter(resp)
+		if wait <= 0 {
+			wait = rateLimitCooldown
+		}
+		return nil, &RateLimitError{RetryAfter: wait}
+	}
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, &APIStatusError{StatusCode: resp.StatusCode, Body: string(body)}
+	}
+
+	var embedResp CohereEmbedResponse
+	if err := json.Unmarshal(body, &embedResp); err != nil {
+		return nil, fmt.Errorf("failed to unmarshal response: %w", err)
+	}
+	tokens = embedResp.Meta.BilledUnits.InputTokens
+
+	if len(embedResp.Embeddings) != len(texts) {
+		return nil, fmt.Errorf("expected %d embeddings, got %d", len(texts), len(embedResp.Embeddings))
+	}
+
+	return embedResp.Embeddings, nil
+}
@@ -0,0 +1,216 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+)
+
+// compareMatch is one corpus text ranked by `ember compare`
+type compareMatch struct {
+	Rank       int
+	Text       string
+	Similarity float64
+}
+
+// runCompareCommand handles `ember compare`, ranking every line of a corpus
+// file, or the configured comparison set, by its similarity to an input
+// without starting the TUI. With --min, the exit status says whether anything
+// scored that high, for scripts and CI. ember runs it when stdin is a pipe.
+func runCompareCommand(args []string) {
+	fs := flag.NewFlagSet("compare", flag.ExitOnError)
+	input := fs.String("input", "", "file whose contents to compare, or - for stdin (default: the arguments, or stdin when piped)")
+	against := fs.String("against", "", "file with one text per line to rank (default: the comparison set opened on start)")
+	k := fs.Int("k", 0, "number of results (default: all)")
+	minScore := fs.Float64("min", -1, "list only texts scoring at least this, and exit with status 1 if none do")
+	format := formatFlag(fs, "table", "tsv")
+	batch := fs.Int("batch", defaultGenerateBatch, "texts per API request")
+	dryRun := fs.Bool("dry-run", false, "report what would be embedded without calling the provider")
+	fs.Parse(args)
+
+	if *k < 0 {
+		fmt.Fprintln(os.Stderr, `Usage: ember compare [--against corpus.txt] [--input query.txt | "TEXT"] [-k N] [--min SCORE] [--format table|json|ndjson|csv|tsv] [--batch N] [--dry-run]`)
+		os.Exit(2)
+	}
+	checkFormat(format, "tsv")
+	if *batch < 1 {
+		displayError(fmt.Errorf("--batch must be at least 1"))
+		os.Exit(2)
+	}
+	query, err := readEmbedInput(fs.Args(), *input)
+	if err == nil && query == "" {
+		err = fmt.Errorf("nothing to compare • pass --input FILE or the text as arguments")
+	}
+	if err != nil {
+		displayError(err)
+		os.Exit(1)
+	}
+
+	provider := setupProvider()
+	defer closeProvider(provider)
+	info := provider.ModelInfo()
+	texts, corpus, err := compareCorpus(*against, info)
+	if err != nil {
+		displayError(err)
+		os.Exit(1)
+	}
+	documentType := ""
+	if typed, ok := provider.(inputTypeProvider); ok && len(typed.InputTypes()) > 0 {
+		documentType = typed.InputTypes()[0]
+	}
+	queryType := queryInputTypeFor(documentType)
+	queries := withInputType(provider, queryType)
+	documents := withInputType(provider, documentType)
+	if cache := setupCache(); cache != nil {
+		defer cache.Close()
+		queries = &cachedProvider{EmbeddingProvider: queries, cache: cache, model: cacheModelKey(info, queryType)}
+		documents = &cachedProvider{EmbeddingProvider: documents, cache: cache, model: cacheModelKey(info, documentType)}
+	}
+	if *dryRun {
+		plan := newEmbeddingPlan(info)
+		plan.add(queries, query)
+		plan.add(documents, texts...)
+		fmt.Fprint(os.Stderr, plan.render())
+		return
+	}
+
+	embedding, err := queries.GenerateEmbedding(query)
+	if err != nil {
+		displayError(err)
+		os.Exit(1)
+	}
+	if len(texts) > 0 {
+		if corpus, err = generateBatched(documents, texts, *batch, nil); err != nil {
+			displayError(err)
+			os.Exit(1)
+		}
+	}
+
+	matches := rankCorpus(embedding, corpus, *minScore)
+	if *k > 0 {
+		matches = matches[:min(*k, len(matches))]
+	}
+	out := newRankedOutput(info, len(embedding), "cosine", query)
+	for _, match := range matches {
+		out.add(match.Similarity, match.Text, "")
+	}
+	if err := out.write(*format); err != nil {
+		displayError(err)
+		os.Exit(1)
+	}
+	if len(matches) == 0 {
+		os.Exit(1)
+	}
+}
+
+// compareCorpus returns what `ember compare` ranks: the texts that need
+// embedding, or the already embedded corpus. Without a file that's the
+// comparison set opened on start, whose vectors are used when they came from
+// the model in use; otherwise its texts are embedded again.
+func compareCorpus(path string, info ModelInfo) ([]string, []CustomEmbedding, error) {
+	if path != "" {
+		texts, err := readTextLines(path)
+		if err == nil && len(texts) == 0 {
+			err = fmt.Errorf("no texts found in %s", path)
+		}
+		return texts, nil, err
+	}
+
+	dataDir, err := userDataDir()
+	if err != nil {
+		return nil, nil, err
+	}
+	store, closeStore, err := openStore(dataDir)
+	if err != nil {
+		return nil, nil, err
+	}
+	defer closeStore()
+	set, err := openStartupSet(store, filepath.Join(dataDir, "sets"))
+	if err != nil {
+		return nil, nil, err
+	}
+	if len(set.Embeddings) == 0 {
+		return nil, nil, fmt.Errorf("no comparison set to compare against • pass --against FILE")
+	}
+	if set.Model.Provider == info.Provider && set.Model.Model == info.Model {
+		return nil, set.Embeddings, nil
+	}
+	texts := make([]string, len(set.Embeddings))
+	for i, e := range set.Embeddings {
+		texts[i] = e.Text
+	}
+	return texts, nil, nil
+}
+
+// rankCorpus scores every corpus text against embedding, best first,
+// keeping those that score at least minScore
+func rankCorpus(embedding []float64, corpus []CustomEmbedding, minScore float64) []compareMatch {
+	var matches []compareMatch
+	for _, e := range corpus {
+		if similarity := cosineSimilarity(embedding, e.Embedding); similarity >= minScore {
+			matches = append(matches, compareMatch{Text: e.Text, Similarity: similarity})
+		}
+	}
+	sort.SliceStable(matches, func(i, j int) bool { return matches[i].Similarity > matches[j].Similarity })
+	for i := range matches {
+		matches[i].Rank = i + 1
+	}
+	return matches
+}
+
+// runSimilarityCommand handles `ember similarity A B`, printing one score
+// for a pair of texts. Only the number goes to stdout, for shell pipelines.
+func runSimilarityCommand(args []string) {
+	fs := flag.NewFlagSet("similarity", flag.ExitOnError)
+	metric := fs.String("metric", "cosine", "cosine, dot, euclidean or manhattan")
+	format := formatFlag(fs, "table")
+	fs.Parse(args)
+
+	if fs.NArg() != 2 {
+		fmt.Fprintln(os.Stderr, `Usage: ember similarity [--metric cosine|dot|euclidean|manhattan] [--format table|json|ndjson|csv] "TEXT A" "TEXT B"`)
+		os.Exit(2)
+	}
+	checkFormat(format)
+	score, ok := similarityMetrics[*metric]
+	if !ok {
+		displayError(fmt.Errorf("unknown metric %q (available: cosine, dot, euclidean, manhattan)", *metric))
+		os.Exit(2)
+	}
+	a, b := strings.TrimSpace(fs.Arg(0)), strings.TrimSpace(fs.Arg(1))
+	if a == "" || b == "" {
+		displayError(fmt.Errorf("both texts must be non-empty"))
+		os.Exit(2)
+	}
+
+	var provider EmbeddingProvider = setupProvider()
+	defer closeProvider(provider)
+	if cache := setupCache(); cache != nil {
+		defer cache.Close()
+		provider = &cachedProvider{EmbeddingProvider: provider, cache: cache, model: cacheModelKey(provider.ModelInfo(), "")}
+	}
+
+	vectors, err := provider.GenerateBatch([]string{a, b})
+	if err == nil && len(vectors) != 2 {
+		err = fmt.Errorf("expected 2 embeddings, got %d", len(vectors))
+	}
+	if err != nil {
+		displayError(err)
+		os.Exit(1)
+	}
+	info := provider.ModelInfo()
+	out := similarityOutput{
+		Model:      info.Provider + "/" + info.Model,
+		Dimensions: len(vectors[0]),
+		Metric:     *metric,
+		A:          a,
+		B:          b,
+		Score:      score(vectors[0], vectors[1]),
+	}
+	if err := out.write(*format); err != nil {
+		displayError(err)
+		os.Exit(1)
+	}
+}
@@ -0,0 +1,177 @@
+package main
+
+import (
+	"errors"
+	"flag"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+
+	"github.com/BurntSushi/toml"
+)
+
+// fileConfig is the layout of config.toml. Every setting maps onto the
+// environment variable ember already reads, so the file only fills in what
+// the environment leaves unset.
+type fileConfig struct {
+	Provider       string `toml:"provider"`
+	Model          string `toml:"model"`
+	Theme          string `toml:"theme"`
+	Set            string `toml:"set"`
+	MaxConcurrency int    `toml:"max_concurrency"`
+	// Env sets any other EMBER_* variable, e.g. EMBER_POSTPROCESS
+	Env map[string]string `toml:"env"`
+}
+
+// modelEnvVars is the variable that picks each provider's model
+var modelEnvVars = map[string]string{
+	"openai":   "EMBER_OPENAI_MODEL",
+	"cohere":   "EMBER_COHERE_MODEL",
+	"lmstudio": "EMBER_LMSTUDIO_MODEL",
+	"onnx":     "EMBER_ONNX_MODEL",
+	"llamacpp": "EMBER_LLAMACPP_MODEL",
+}
+
+// defaultConfigPath reads EMBER_CONFIG, falling back to ember/config.toml in
+// $XDG_CONFIG_HOME or ~/.config
+func defaultConfigPath() (string, error) {
+	if path := os.Getenv("EMBER_CONFIG"); path != "" {
+		return path, nil
+	}
+	if dir := os.Getenv("XDG_CONFIG_HOME"); dir != "" {
+		return filepath.Join(dir, "ember", "config.toml"), nil
+	}
+	home, err := os.UserHomeDir()
+	if err != nil {
+		return "", fmt.Errorf("failed to find home directory: %w", err)
+	}
+	return filepath.Join(home, ".config", "ember", "config.toml"), nil
+}
+
+// loadConfigFile reads the config file at path. A missing file is an empty config.
+func loadConfigFile(path string) (fileConfig, error) {
+	var cfg fileConfig
+	meta, err := toml.DecodeFile(path, &cfg)
+	if errors.Is(err, os.ErrNotExist) {
+		return cfg, nil
+	}
+	if err != nil {
+		return cfg, fmt.Errorf("failed to read %s: %w", path, err)
+	}
+	if undecoded := meta.Undecoded(); len(undecoded) > 0 {
+		return cfg, fmt.Errorf("failed to read %s: unknown setting %q", path, undecoded[0].String())
+	}
+	for name := range cfg.Env {
+		if !strings.HasPrefix(name, "EMBER_") {
+			return cfg, fmt.Errorf("failed to read %s: [env] only sets EMBER_* variables, not %q", path, name)
+		}
+	}
+	return cfg, nil
+}
+
+// settings lists the variables cfg sets. The model goes to the variable of
+// the provider that will be used, which the environment may have chosen.
+func (cfg fileConfig) settings() map[string]string {
+	settings := make(map[string]string)
+	for name, value := range cfg.Env {
+		settings[name] = value
+	}
+	set := func(name, value string) {
+		if value != "" {
+			settings[name] = value
+		}
+	}
+
+	set("EMBER_PROVIDER", cfg.Provider)
+	set("EMBER_THEME", cfg.Theme)
+	set("EMBER_SET", cfg.Set)
+	if cfg.MaxConcurrency > 0 {
+		set("EMBER_MAX_CONCURRENCY", strconv.Itoa(cfg.MaxConcurrency))
+	}
+
+	provider := os.Getenv("EMBER_PROVIDER")
+	if provider == "" {
+		provider = settings["EMBER_PROVIDER"]
+	}
+	if provider == "" {
+		provider = loadProviderName()
+	}
+	if name, ok := modelEnvVars[provider]; ok {
+		set(name, cfg.Model)
+	}
+	return settings
+}
+
+// applyConfigFile sets each of cfg's variables that the environment doesn't
+func applyConfigFile(cfg fileConfig) {
+	for name, value := range cfg.settings() {
+		if _, ok := os.LookupEnv(name); !ok {
+			os.Setenv(name, value)
+		}
+	}
+}
+
+// loadConfig applies the config file and then the command-line flags, so
+// flags override the environment, which overrides the file. It returns the
+// arguments left after the flags.
+func loadConfig(args []string) ([]string, error) {
+	fs := flag.NewFlagSet("ember", flag.ExitOnError)
+	configPath := fs.String("config", "", "config file (default: ember/config.toml in $XDG_CONFIG_HOME or ~/.config)")
+	provider := fs.String("provider", "", "embedding provider")
+	model := fs.String("model", "", "model of the embedding provider")
+	theme := fs.String("theme", "", "color theme")
+	set := fs.String("set", "", "comparison set to open: a saved set's name or a file to import")
+	concurrency := fs.Int("max-concurrency", 0, "most requests to the provider at once")
+	// Subcommands parse their own flags
+	if len(args) == 0 || strings.HasPrefix(args[0], "-") {
+		fs.Parse(args)
+		args = fs.Args()
+	}
+
+	path := *configPath
+	if path == "" {
+		var err error
+		if path, err = defaultConfigPath(); err != nil {
+			return args, err
+		}
+	} else if _, err := os.Stat(path); err != nil {
+		return args, fmt.Errorf("failed to read config: %w", err)
+	}
+	cfg, err := loadConfigFile(path)
+	if err != nil {
+		return args, err
+	}
+	// A project's config.toml overrides the global file setting by setting.
+	// Until it is trusted, only settings that can't run code or send data
+	// elsewhere apply, since it may have come with a cloned repository.
+	if dir := projectDataDir(); dir != "" {
+		project, err := loadConfigFile(filepath.Join(dir, "config.toml"))
+		if err != nil {
+			return args, err
+		}
+		if !projectTrusted(projectRoot()) {
+			var ignored []string
+			if project, ignored = project.restrict(); len(ignored) > 0 {
+				fmt.Fprintf(os.Stderr, "⚠️  Ignoring %s from %s • run `ember trust` to allow them\n", strings.Join(ignored, ", "), filepath.Join(dir, "config.toml"))
+			}
+		}
+		cfg = cfg.overlay(project)
+	}
+
+	flags := fileConfig{Provider: *provider, Model: *model, Theme: *theme, Set: *set, MaxConcurrency: *concurrency}
+	if flags.Provider != "" {
+		os.Setenv("EMBER_PROVIDER", flags.Provider)
+	}
+	applyConfigFile(cfg)
+	if flags.Model != "" {
+		if _, ok := modelEnvVars[loadProviderName()]; !ok {
+			return args, fmt.Errorf("provider %q has no model to choose", loadProviderName())
+		}
+	}
+	for name, value := range flags.settings() {
+		os.Setenv(name, value)
+	}
+	return args, nil
+}
@@ -0,0 +1,179 @@
+package main
+
+import (
+	"fmt"
+	"sort"
+
+	"github.com/charmbracelet/lipgloss"
+)
+
+const (
+	// Labels whose centroids are at least this similar are reported as overlapping
+	overlapThreshold = 0.8
+	// Inputs whose best label scores below this are reported as uncovered
+	coverageThreshold = 0.3
+	// Inputs kept for coverage analysis this session
+	maxInputHistory = 200
+	// Uncovered inputs listed on the coverage screen
+	maxUncoveredShown = 10
+)
+
+// labelCentroid is the mean embedding of the comparison texts sharing a label
+type labelCentroid struct {
+	Label     string
+	Texts     int
+	Embedding []float64
+}
+
+type labelOverlap struct {
+	A, B       string
+	Similarity float64
+}
+
+type uncoveredInput struct {
+	Text      string
+	BestLabel string
+	BestScore float64
+}
+
+type coverageReport struct {
+	Labels    []labelCentroid
+	Overlaps  []labelOverlap
+	Uncovered []uncoveredInput
+	Inputs    int
+}
+
+// comparisonLabel groups comparison texts by their first #tag; untagged texts stand alone
+func comparisonLabel(text string) string {
+	if tags := textTags(text); len(tags) > 0 {
+		return "#" + tags[0]
+	}
+	return truncateText(text, 40)
+}
+
+// labelCentroids averages the embeddings of each label's comparison texts
+func labelCentroids(anchors []CustomEmbedding) []labelCentroid {
+	var centroids []labelCentroid
+	index := make(map[string]int)
+
+	for _, anchor := range anchors {
+		label := comparisonLabel(anchor.Text)
+		i, ok := index[label]
+		if !ok {
+			i = len(centroids)
+			index[label] = i
+			centroids = append(centroids, labelCentroid{Label: label, Embedding: make([]float64, len(anchor.Embedding))})
+		}
+		if len(anchor.Embedding) != len(centroids[i].Embedding) {
+			continue
+		}
+
+		centroids[i].Texts++
+		for d, v := range anchor.Embedding {
+			centroids[i].Embedding[d] += v
+		}
+	}
+
+	for i := range centroids {
+		for d := range centroids[i].Embedding {
+			centroids[i].Embedding[d] /= float64(max(centroids[i].Texts, 1))
+		}
+	}
+	return centroids
+}
+
+// analyzeCoverage reports label pairs that are too close and inputs no label covers
+func analyzeCoverage(anchors, history []CustomEmbedding) coverageReport {
+	report := coverageReport{Labels: labelCentroids(anchors), Inputs: len(history)}
+
+	for i := range report.Labels {
+		for j := i + 1; j < len(report.Labels); j++ {
+			sim := cosineSimilarity(report.Labels[i].Embedding, report.Labels[j].Embedding)
+			if sim >= overlapThreshold {
+				report.Overlaps = append(report.Overlaps, labelOverlap{A: report.Labels[i].Label, B: report.Labels[j].Label, Similarity: sim})
+			}
+		}
+	}
+	sort.Slice(report.Overlaps, func(i, j int) bool { return report.Overlaps[i].Similarity > report.Overlaps[j].Similarity })
+
+	for _, input := range history {
+		best := uncoveredInput{Text: input.Text, BestScore: -1}
+		for _, label := range report.Labels {
+			if sim := cosineSimilarity(input.Embedding, label.Embedding); sim > best.BestScore {
+				best.BestScore, best.BestLabel = sim, label.Label
+			}
+		}
+		if best.BestScore < coverageThreshold {
+			report.Uncovered = append(report.Uncovered, best)
+		}
+	}
+	sort.Slice(report.Uncovered, func(i, j int) bool { return report.Uncovered[i].BestScore < report.Uncovered[j].BestScore })
+
+	return report
+}
+
+// recordInput keeps an embedded input for coverage analysis, dropping the oldest past the limit
+func (m *model) recordInput(text string, embedding []float64) {
+	m.inputHistory = append(m.inputHistory, CustomEmbedding{Text: text, Embedding: embedding})
+	if len(m.inputHistory) > maxInputHistory {
+		m.inputHistory = m.inputHistory[len(m.inputHistory)-maxInputHistory:]
+	}
+}
+
+func (m model) renderCoverageScreen() string {
+	s := "\033[2J\033[H" // Clear screen and move cursor to top
+
+	s += "╭─────────────────────────────────────────────────────────────────────────────╮\n"
+	s += "│                           🧭 ANCHOR COVERAGE 🧭                             │\n"
+	s += "│               Gaps and overlaps in your comparison set                      │\n"
+	s += "╰─────────────────────────────────────────────────────────────────────────────╯\n\n"
+
+	labelStyle := lipgloss.NewStyle().
+		Foreground(theme.Primary).
+		Bold(true)
+	warningStyle := lipgloss.NewStyle().
+		Foreground(theme.Warning)
+	instructStyle := lipgloss.NewStyle().
+		Foreground(theme.Muted).
+		Italic(true)
+
+	report := analyzeCoverage(m.customEmbeddings, m.inputHistory)
+
+	s += labelStyle.Render(fmt.Sprintf("🏷  %d labels from %d comparison texts", len(report.Labels), len(m.customEmbeddings))) + "\n"
+	for _, label := range report.Labels {
+		s += fmt.Sprintf("   %s (%d)\n", staticTextStyle.Render(label.Label), label.Texts)
+	}
+	s += "\n"
+
+	s += labelStyle.Render(fmt.Sprintf("🔀 Overlapping labels (centroid similarity ≥ %.2f)", overlapThreshold)) + "\n"
+	if len(report.Overlaps) == 0 {
+		s += instructStyle.Render("   None — every label is distinct") + "\n"
+	}
+	for _, o := range report.Overlaps {
+		s += warningStyle.Render(fmt.Sprintf("   %.3f  %s ↔ %s", o.Similarity, o.A, o.B)) + "\n"
+	}
+	s += "\n"
+
+	s += labelStyle.Render(fmt.Sprintf("🕳  Uncovered inputs (best score < %.2f)", coverageThreshold)) + "\n"
+	switch {
+	case report.Inputs == 0:
+		s += instructStyle.Render("   No inputs compared yet this session") + "\n"
+	case len(report.Uncovered) == 0:
+		s += instructStyle.Render(fmt.Sprintf("   None — all %d inputs match a label", report.Inputs)) + "\n"
+	default:
+		s += instructStyle.Render(fmt.Sprintf("   %d of %d inputs", len(report.Uncovered), report.Inputs)) + "\n"
+	}
+	for i, u := range report.Uncovered {
+		if i == maxUncoveredShown {
+			s += instructStyle.Render(fmt.Sprintf("   …and %d more", len(report.Uncovered)-i)) + "\n"
+			break
+		}
+		s += warningStyle.Render(fmt.Sprintf("   %.3f  %s", u.BestScore, truncateText(u.Text, 50))) +
+			instructStyle.Render(fmt.Sprintf("  (closest: %s)", u.BestLabel)) + "\n"
+	}
+	s += "\n"
+
+	s += instructStyle.Render("💡 Tag comparison texts with #label to group them • S to label ambiguous inputs • N for neighbors • V to evaluate on a test CSV • Esc to return") + "\n"
+
+	return s
+}
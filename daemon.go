@@ -0,0 +1,469 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"os"
+	"os/signal"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"syscall"
+	"time"
+)
+
+// defaultSocketPath picks EMBER_SOCKET, then $XDG_RUNTIME_DIR, then the temp dir
+func defaultSocketPath() string {
+	if path := os.Getenv("EMBER_SOCKET"); path != "" {
+		return path
+	}
+	if dir := os.Getenv("XDG_RUNTIME_DIR"); dir != "" {
+		return filepath.Join(dir, "ember.sock")
+	}
+	return filepath.Join(os.TempDir(), fmt.Sprintf("ember-%d.sock", os.Getuid()))
+}
+
+type daemonEmbedRequest struct {
+	Texts     []string `json:"texts"`
+	InputType string   `json:"input_type,omitempty"`
+}
+
+type daemonEmbedResponse struct {
+	Embeddings [][]float64 `json:"embeddings"`
+}
+
+type daemonModelResponse struct {
+	ModelInfo
+	InputTypes []string `json:"input_types,omitempty"`
+}
+
+type daemonErrorResponse struct {
+	Error string `json:"error"`
+}
+
+// daemon keeps one provider (and its HTTP connections) alive across ember
+// invocations, along with the embedding cache and the vector store's HNSW
+// indexes, so none of them is opened again per command
+type daemon struct {
+	mu       sync.RWMutex
+	provider EmbeddingProvider
+	started  time.Time
+	reloaded time.Time
+	shutdown func()
+
+	// cache answers repeated texts without the provider; nil when disabled
+	cache *EmbeddingCache
+	// vectors is the local vector store with its indexes loaded; nil when
+	// it couldn't be opened
+	vectors *vectorStore
+	// jobs runs work that outlives the request that started it
+	jobs *JobManager
+
+	requests atomic.Int64
+	failures atomic.Int64
+	inFlight atomic.Int64
+}
+
+func runDaemonCommand(args []string) {
+	if len(args) > 0 {
+		if control, ok := daemonControls[args[0]]; ok {
+			runDaemonControl(args[0], control, args[1:])
+			return
+		}
+	}
+
+	fs := flag.NewFlagSet("daemon", flag.ExitOnError)
+	socketPath := fs.String("socket", defaultSocketPath(), "unix socket to listen on")
+	demo := fs.Bool("demo", false, "serve the mock provider publicly with per-IP rate limits")
+	listen := fs.String("listen", ":8080", "TCP address to listen on with --demo")
+	fs.Parse(args)
+
+	if *demo {
+		if err := serveDemo(*listen, loadDemoLimits()); err != nil {
+			fmt.Printf("❌ Error: %v\n", err)
+			os.Exit(1)
+		}
+		return
+	}
+
+	provider, err := NewProvider(loadProviderName())
+	if err != nil {
+		displayError(err)
+		os.Exit(1)
+	}
+
+	if err := serveDaemon(provider, *socketPath); err != nil {
+		fmt.Printf("❌ Error: %v\n", err)
+		os.Exit(1)
+	}
+}
+
+func serveDaemon(provider EmbeddingProvider, socketPath string) error {
+	if err := removeStaleSocket(socketPath); err != nil {
+		return err
+	}
+
+	listener, err := net.Listen("unix", socketPath)
+	if err != nil {
+		return fmt.Errorf("failed to listen on %s: %w", socketPath, err)
+	}
+	defer os.Remove(socketPath)
+
+	d := &daemon{provider: provider, started: time.Now(), cache: setupCache(), jobs: NewJobManager()}
+	if d.cache != nil {
+		defer d.cache.Close()
+	}
+	if dir, err := vectorStoreDir(); err == nil {
+		if d.vectors, err = openVectorStore(dir); err != nil {
+			fmt.Printf("⚠️  Vector store not loaded: %v\n", err)
+		} else {
+			defer d.vectors.Close()
+			if warmed := d.vectors.warm(); warmed > 0 {
+				fmt.Printf("🔥 Loaded %d HNSW indexes from %s\n", warmed, dir)
+			}
+		}
+	}
+
+	// Ingest jobs write to the store, so they stop before it closes
+	defer d.jobs.Stop()
+
+	// Embedding through a remote provider can take a while, so only the
+	// headers have a deadline; the socket is reachable from this machine alone
+	return d.serve(listener, &http.Server{Handler: d.routes(), ReadHeaderTimeout: 10 * time.Second}, socketPath)
+}
+
+// serve runs server on listener until the process is interrupted or the
+// daemon is told to stop
+func (d *daemon) serve(listener net.Listener, server *http.Server, where string) error {
+	// Shut down cleanly so the socket file is removed
+	stop := make(chan os.Signal, 1)
+	signal.Notify(stop, os.Interrupt, syscall.SIGTERM)
+	d.shutdown = func() {
+		select {
+		case stop <- syscall.SIGTERM:
+		default:
+		}
+	}
+	go func() {
+		<-stop
+		ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+		defer cancel()
+		server.Shutdown(ctx)
+	}()
+
+	info := d.currentProvider().ModelInfo()
+	fmt.Printf("🟣 ember daemon listening on %s (%s/%s)\n", where, info.Provider, info.Model)
+
+	err := server.Serve(listener)
+	closeProvider(d.currentProvider())
+	if err != nil && !errors.Is(err, http.ErrServerClosed) {
+		return fmt.Errorf("daemon stopped: %w", err)
+	}
+	return nil
+}
+
+// removeStaleSocket deletes a socket file left behind by a daemon that is no longer running
+func removeStaleSocket(socketPath string) error {
+	if _, err := os.Stat(socketPath); os.IsNotExist(err) {
+		return nil
+	}
+
+	if conn, err := net.DialTimeout("unix", socketPath, time.Second); err == nil {
+		conn.Close()
+		return fmt.Errorf("a daemon is already listening on %s", socketPath)
+	}
+
+	if err := os.Remove(socketPath); err != nil {
+		return fmt.Errorf("failed to remove stale socket: %w", err)
+	}
+	return nil
+}
+
+func (d *daemon) routes() http.Handler {
+	mux := http.NewServeMux()
+	mux.HandleFunc("POST /v1/embed", d.handleEmbed)
+	mux.HandleFunc("GET /v1/model", d.handleModel)
+	mux.HandleFunc("POST /v1/vectors/search", d.handleVectorSearch)
+	mux.HandleFunc("POST /v1/ingest", d.handleIngest)
+	mux.HandleFunc("GET /v1/control/status", d.handleStatus)
+	mux.HandleFunc("POST /v1/control/reload", d.handleReload)
+	mux.HandleFunc("POST /v1/control/stop", d.handleStop)
+	mux.HandleFunc("POST /v1/control/flush", d.handleFlush)
+	mux.HandleFunc("GET /v1/control/jobs", d.handleJobs)
+	return mux
+}
+
+func (d *daemon) handleEmbed(w http.ResponseWriter, r *http.Request) {
+	var req daemonEmbedRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		writeDaemonError(w, http.StatusBadRequest, fmt.Errorf("invalid request: %w", err))
+		return
+	}
+
+	d.requests.Add(1)
+	d.inFlight.Add(1)
+	defer d.inFlight.Add(-1)
+
+	embeddings, err := d.embedder(req.InputType).GenerateBatch(req.Texts)
+	if err != nil {
+		d.failures.Add(1)
+	}
+
+	var rateErr *RateLimitError
+	if errors.As(err, &rateErr) {
+		w.Header().Set("Retry-After", strconv.Itoa(int(rateErr.RetryAfter.Seconds())))
+		writeDaemonError(w, http.StatusTooManyRequests, err)
+		return
+	}
+	if err != nil {
+		writeDaemonError(w, http.StatusBadGateway, err)
+		return
+	}
+
+	writeDaemonJSON(w, daemonEmbedResponse{Embeddings: embeddings})
+}
+
+// embedder is the provider for inputType, answering from the cache first
+func (d *daemon) embedder(inputType string) EmbeddingProvider {
+	provider := withInputType(d.currentProvider(), inputType)
+	if d.cache == nil {
+		return provider
+	}
+	return &cachedProvider{EmbeddingProvider: provider, cache: d.cache, model: cacheModelKey(provider.ModelInfo(), inputType)}
+}
+
+// daemonVectorSearchRequest asks the daemon to search its vector store. Dir
+// is the store the client would open, so a client pointed at another store
+// never gets the daemon's results.
+type daemonVectorSearchRequest struct {
+	Dir    string    `json:"dir"`
+	Model  ModelInfo `json:"model"`
+	Vector []float64 `json:"vector"`
+	K      int       `json:"k"`
+}
+
+type daemonVectorSearchResponse struct {
+	Texts  []string  `json:"texts"`
+	Scores []float64 `json:"scores"`
+}
+
+// handleVectorSearch searches the store through its loaded indexes
+func (d *daemon) handleVectorSearch(w http.ResponseWriter, r *http.Request) {
+	var req daemonVectorSearchRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		writeDaemonError(w, http.StatusBadRequest, fmt.Errorf("invalid request: %w", err))
+		return
+	}
+	if d.vectors == nil || req.Dir != d.vectors.dir {
+		writeDaemonError(w, http.StatusConflict, fmt.Errorf("the daemon serves another vector store"))
+		return
+	}
+	hits, scores, err := d.vectors.Search(req.Model, req.Vector, max(req.K, 1))
+	if err != nil {
+		writeDaemonError(w, http.StatusInternalServerError, err)
+		return
+	}
+	resp := daemonVectorSearchResponse{Texts: make([]string, len(hits)), Scores: scores}
+	for i, hit := range hits {
+		resp.Texts[i] = hit.Text
+	}
+	writeDaemonJSON(w, resp)
+}
+
+// daemonVectorStore searches the vector store through a running daemon,
+// whose indexes are already loaded, and falls back to the store itself
+type daemonVectorStore struct {
+	*vectorStore
+	client *DaemonProvider
+}
+
+// withDaemon returns s, searched through the running daemon if there is one
+func (s *vectorStore) withDaemon() remoteStore {
+	if os.Getenv("EMBER_NO_DAEMON") != "" {
+		return s
+	}
+	client, err := connectDaemon(defaultSocketPath())
+	if err != nil {
+		return s
+	}
+	return daemonVectorStore{vectorStore: s, client: client}
+}
+
+func (s daemonVectorStore) search(model ModelInfo, vector []float64, k int) ([]CustomEmbedding, []float64, error) {
+	var resp daemonVectorSearchResponse
+	req := daemonVectorSearchRequest{Dir: s.dir, Model: model, Vector: vector, K: k}
+	if err := s.client.do("POST", "/v1/vectors/search", req, &resp); err != nil || len(resp.Texts) != len(resp.Scores) {
+		return s.vectorStore.search(model, vector, k)
+	}
+	hits := make([]CustomEmbedding, len(resp.Texts))
+	for i, text := range resp.Texts {
+		hits[i] = CustomEmbedding{Text: text}
+	}
+	return hits, resp.Scores, nil
+}
+
+func (d *daemon) handleModel(w http.ResponseWriter, r *http.Request) {
+	provider := d.currentProvider()
+	resp := daemonModelResponse{ModelInfo: provider.ModelInfo()}
+	if typed, ok := provider.(inputTypeProvider); ok {
+		resp.InputTypes = typed.InputTypes()
+	}
+	writeDaemonJSON(w, resp)
+}
+
+func writeDaemonJSON(w http.ResponseWriter, v any) {
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(v)
+}
+
+func writeDaemonError(w http.ResponseWriter, status int, err error) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	json.NewEncoder(w).Encode(daemonErrorResponse{Error: err.Error()})
+}
+
+// DaemonProvider forwards embedding requests to a running ember daemon
+type DaemonProvider struct {
+	client     *http.Client
+	info       ModelInfo
+	inputTypes []string
+	inputType  string
+}
+
+// connectDaemon returns a provider backed by the daemon, or an error if none is running
+func connectDaemon(socketPath string) (*DaemonProvider, error) {
+	client := &http.Client{
+		Transport: &http.Transport{
+			DialContext: func(ctx context.Context, _, _ string) (net.Conn, error) {
+				var dialer net.Dialer
+				return dialer.DialContext(ctx, "unix", socketPath)
+			},
+		},
+	}
+
+	d := &DaemonProvider{client: client}
+
+	var model daemonModelResponse
+	if err := d.do("GET", "/v1/model", nil, &model); err != nil {
+		return nil, err
+	}
+	d.info = model.ModelInfo
+	d.inputTypes = model.InputTypes
+
+	return d, nil
+}
+
+// daemonMismatch says why a daemon serving info can't stand in for the
+// configured provider, or returns "" when it can
+func daemonMismatch(info ModelInfo) string {
+	name := strings.ToLower(loadProviderName())
+	if os.Getenv("EMBER_PROVIDER") == "" && info.Provider == "lmstudio" && missingAPIKey("openai") {
+		// Without OpenAI credentials ember falls back to LM Studio too
+		name = "lmstudio"
+	}
+	served := info.Provider + "/" + info.Model
+	if info.Provider != name {
+		return fmt.Sprintf("it serves %s, but the provider is %s", served, name)
+	}
+	if model, ok := configuredModel(name); ok && model != info.Model {
+		return fmt.Sprintf("it serves %s, but the model is %s", served, model)
+	}
+	if dimensions, err := loadDimensions(); err == nil && name == "openai" && dimensions != info.Dimensions {
+		return fmt.Sprintf("it serves %s at %s, but EMBER_DIMENSIONS asks for %s", served, dimensionLabel(info.Dimensions), dimensionLabel(dimensions))
+	}
+	return ""
+}
+
+func (d *DaemonProvider) ModelInfo() ModelInfo {
+	return d.info
+}
+
+func (d *DaemonProvider) InputTypes() []string {
+	return d.inputTypes
+}
+
+func (d *DaemonProvider) WithInputType(inputType string) EmbeddingProvider {
+	copied := *d
+	copied.inputType = inputType
+	return &copied
+}
+
+func (d *DaemonProvider) GenerateEmbedding(text string) ([]float64, error) {
+	embeddings, err := d.GenerateBatch([]string{text})
+	if err != nil {
+		return nil, err
+	}
+	return embeddings[0], nil
+}
+
+func (d *DaemonProvider) GenerateBatch(texts []string) ([][]float64, error) {
+	var resp daemonEmbedResponse
+	req := daemonEmbedRequest{Texts: texts, InputType: d.inputType}
+	if err := d.do("POST", "/v1/embed", req, &resp); err != nil {
+		return nil, err
+	}
+
+	if len(resp.Embeddings) != len(texts) {
+		return nil, fmt.Errorf("expected %d embeddings, got %d", len(texts), len(resp.Embeddings))
+	}
+	return resp.Embeddings, nil
+}
+
+// do sends a JSON request to the daemon; the host is ignored by the unix transport
+func (d *DaemonProvider) do(method, path string, body, out any) error {
+	var reader io.Reader
+	if body != nil {
+		jsonData, err := json.Marshal(body)
+		if err != nil {
+			return fmt.Errorf("failed to marshal request: %w", err)
+		}
+		reader = bytes.NewBuffer(jsonData)
+	}
+
+	req, err := http.NewRequest(method, "http://ember"+path, reader)
+	if err != nil {
+		return fmt.Errorf("failed to create request: %w", err)
+	}
+	req.Header.Set("Content-Type", "application/json")
+
+	resp, err := d.client.Do(req)
+	if err != nil {
+		return fmt.Errorf("failed to reach daemon: %w", err)
+	}
+	defer resp.Body.Close()
+
+	respBody, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return fmt.Errorf("failed to read response: %w", err)
+	}
+
+	if resp.StatusCode == http.StatusTooManyRequests {
+		wait := retryAfter(resp)
+		if wait <= 0 {
+			wait = rateLimitCooldown
+		}
+		return &RateLimitError{RetryAfter: wait}
+	}
+
+	if resp.StatusCode != http.StatusOK {
+		var errResp daemonErrorResponse
+		if json.Unmarshal(respBody, &errResp) == nil && errResp.Error != "" {
+			return fmt.Errorf("daemon error: %s", errResp.Error)
+		}
+		return fmt.Errorf("daemon returned status %d", resp.StatusCode)
+	}
+
+	if err := json.Unmarshal(respBody, out); err != nil {
+		return fmt.Errorf("failed to unmarshal response: %w", err)
+	}
+	return nil
+}
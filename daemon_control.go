@@ -0,0 +1,227 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"net/http"
+	"os"
+	"time"
+
+	"github.com/charmbracelet/lipgloss"
+)
+
+// DaemonStatus is returned by the daemon's status endpoint
+type DaemonStatus struct {
+	PID      int       `json:"pid"`
+	Provider ModelInfo `json:"provider"`
+	Started  time.Time `json:"started"`
+	Reloaded time.Time `json:"reloaded,omitempty"`
+	Requests int64     `json:"requests"`
+	Failures int64     `json:"failures"`
+	InFlight int64     `json:"in_flight"`
+	// CacheHits and CacheMisses count the texts the daemon's cache was asked
+	// for; both are zero when the cache is off
+	CacheHits   int64 `json:"cache_hits"`
+	CacheMisses int64 `json:"cache_misses"`
+	// Indexes counts the vector store's HNSW indexes held in memory
+	Indexes int `json:"indexes"`
+}
+
+type daemonControl struct {
+	method string
+	path   string
+}
+
+// Control commands available as `ember daemon <name>`
+var daemonControls = map[string]daemonControl{
+	"status": {method: "GET", path: "/v1/control/status"},
+	"reload": {method: "POST", path: "/v1/control/reload"},
+	"stop":   {method: "POST", path: "/v1/control/stop"},
+	"flush":  {method: "POST", path: "/v1/control/flush"},
+	"jobs":   {method: "GET", path: "/v1/control/jobs"},
+}
+
+// DaemonJob is a background job of the daemon, as listed by `ember daemon jobs`
+type DaemonJob struct {
+	ID       int       `json:"id"`
+	Name     string    `json:"name"`
+	State    string    `json:"state"`
+	Done     int       `json:"done"`
+	Total    int       `json:"total"`
+	Error    string    `json:"error,omitempty"`
+	Started  time.Time `json:"started,omitempty"`
+	Finished time.Time `json:"finished,omitempty"`
+}
+
+func (d *daemon) currentProvider() EmbeddingProvider {
+	d.mu.RLock()
+	defer d.mu.RUnlock()
+	return d.provider
+}
+
+func (d *daemon) status() DaemonStatus {
+	d.mu.RLock()
+	defer d.mu.RUnlock()
+
+	status := DaemonStatus{
+		PID:      os.Getpid(),
+		Provider: d.provider.ModelInfo(),
+		Started:  d.started,
+		Reloaded: d.reloaded,
+		Requests: d.requests.Load(),
+		Failures: d.failures.Load(),
+		InFlight: d.inFlight.Load(),
+	}
+	if d.cache != nil {
+		status.CacheHits, status.CacheMisses = d.cache.Session()
+	}
+	if d.vectors != nil {
+		d.vectors.mu.Lock()
+		status.Indexes = len(d.vectors.indexes)
+		d.vectors.mu.Unlock()
+	}
+	return status
+}
+
+func (d *daemon) handleStatus(w http.ResponseWriter, r *http.Request) {
+	writeDaemonJSON(w, d.status())
+}
+
+// handleReload rebuilds the provider from the current configuration. Requests
+// already in flight finish on the old provider unless it owns a server, which
+// is stopped once the new one is ready.
+func (d *daemon) handleReload(w http.ResponseWriter, r *http.Request) {
+	provider, err := NewProvider(loadProviderName())
+	if err != nil {
+		writeDaemonError(w, http.StatusBadRequest, fmt.Errorf("reload failed: %w", err))
+		return
+	}
+
+	d.mu.Lock()
+	old := d.provider
+	d.provider = provider
+	d.reloaded = time.Now()
+	d.mu.Unlock()
+
+	closeProvider(old)
+
+	writeDaemonJSON(w, d.status())
+}
+
+// handleFlush empties the embedding cache and unloads the vector store's
+// indexes, which are read from disk again by the next search
+func (d *daemon) handleFlush(w http.ResponseWriter, r *http.Request) {
+	if d.cache != nil {
+		if _, err := d.cache.Clear(); err != nil {
+			writeDaemonError(w, http.StatusInternalServerError, err)
+			return
+		}
+	}
+	if d.vectors != nil {
+		d.vectors.mu.Lock()
+		clear(d.vectors.indexes)
+		clear(d.vectors.unusable)
+		d.vectors.mu.Unlock()
+	}
+	writeDaemonJSON(w, d.status())
+}
+
+func (d *daemon) handleJobs(w http.ResponseWriter, r *http.Request) {
+	snapshots := d.jobs.Snapshots()
+	jobs := make([]DaemonJob, len(snapshots))
+	for i, s := range snapshots {
+		jobs[i] = DaemonJob{ID: s.ID, Name: s.Name, State: s.State.String(), Done: s.Done, Total: s.Total, Started: s.Started, Finished: s.Finished}
+		if s.Err != nil {
+			jobs[i].Error = s.Err.Error()
+		}
+	}
+	writeDaemonJSON(w, jobs)
+}
+
+func (d *daemon) handleStop(w http.ResponseWriter, r *http.Request) {
+	writeDaemonJSON(w, d.status())
+	d.shutdown()
+}
+
+func runDaemonControl(name string, control daemonControl, args []string) {
+	fs := flag.NewFlagSet("daemon "+name, flag.ExitOnError)
+	socketPath := fs.String("socket", defaultSocketPath(), "unix socket of the running daemon")
+	fs.Parse(args)
+
+	client, err := connectDaemon(*socketPath)
+	if err != nil {
+		fmt.Printf("❌ Error: no daemon running on %s\n", *socketPath)
+		os.Exit(1)
+	}
+
+	if name == "jobs" {
+		var jobs []DaemonJob
+		if err := client.do(control.method, control.path, nil, &jobs); err != nil {
+			fmt.Printf("❌ Error: %v\n", err)
+			os.Exit(1)
+		}
+		printDaemonJobs(jobs)
+		return
+	}
+
+	var status DaemonStatus
+	if err := client.do(control.method, control.path, nil, &status); err != nil {
+		fmt.Printf("❌ Error: %v\n", err)
+		os.Exit(1)
+	}
+
+	switch name {
+	case "reload":
+		fmt.Println("🔄 Daemon reloaded")
+	case "stop":
+		fmt.Println("🛑 Daemon stopping")
+	case "flush":
+		fmt.Println("🧹 Daemon cache flushed")
+	}
+	printDaemonStatus(status)
+}
+
+func printDaemonJobs(jobs []DaemonJob) {
+	if len(jobs) == 0 {
+		fmt.Println("No jobs")
+		return
+	}
+	for _, job := range jobs {
+		line := fmt.Sprintf("%3d  %-12s %s", job.ID, job.State, job.Name)
+		if job.Total > 0 {
+			line += fmt.Sprintf(" (%d/%d)", job.Done, job.Total)
+		}
+		if job.Error != "" {
+			line += " • " + job.Error
+		}
+		fmt.Println(line)
+	}
+}
+
+func printDaemonStatus(status DaemonStatus) {
+	fmt.Printf("PID:       %d\n", status.PID)
+	fmt.Printf("Provider:  %s/%s\n", status.Provider.Provider, status.Provider.Model)
+	fmt.Printf("Uptime:    %s\n", time.Since(status.Started).Round(time.Second))
+	if !status.Reloaded.IsZero() {
+		fmt.Printf("Reloaded:  %s ago\n", time.Since(status.Reloaded).Round(time.Second))
+	}
+	fmt.Printf("Requests:  %d (%d failed, %d in flight)\n", status.Requests, status.Failures, status.InFlight)
+	if status.CacheHits+status.CacheMisses > 0 {
+		fmt.Printf("Cache:     %d hits, %d misses\n", status.CacheHits, status.CacheMisses)
+	}
+	fmt.Printf("Indexes:   %d loaded\n", status.Indexes)
+}
+
+// renderDaemonStatus notes on the input screen when requests go through a daemon
+func (m model) renderDaemonStatus() string {
+	client, ok := m.provider.(*DaemonProvider)
+	if !ok {
+		return ""
+	}
+
+	statusStyle := lipgloss.NewStyle().
+		Foreground(theme.Muted)
+
+	info := client.ModelInfo()
+	return statusStyle.Render(fmt.Sprintf("🔌 via ember daemon (%s/%s)", info.Provider, info.Model)) + "\n"
+}
@@ -0,0 +1,225 @@
+package main
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"strings"
+	"time"
+)
+
+// daemonIngestRequest carries documents for the daemon to put in its
+// vector store. A document without an ID is named after a hash of its
+// text, so posting the same text twice stores it once.
+type daemonIngestRequest struct {
+	Documents []daemonIngestDocument `json:"documents"`
+}
+
+type daemonIngestDocument struct {
+	ID   string `json:"id,omitempty"`
+	Text string `json:"text"`
+}
+
+// daemonIngestResponse names the job putting the accepted documents, and
+// the documents the store already holds as they are
+type daemonIngestResponse struct {
+	Job        int      `json:"job,omitempty"`
+	Accepted   []string `json:"accepted"`
+	Duplicates []string `json:"duplicates"`
+}
+
+// ingestDocument is a document chunked the way `ember vectors put` chunks
+// a file, with texts as they'll be stored
+type ingestDocument struct {
+	id     string
+	chunks []string
+	texts  []string
+}
+
+// handleIngest chunks the documents, leaves out the ones already stored
+// with the same chunks, and puts the rest in the vector store from a job,
+// answering before they're embedded
+func (d *daemon) handleIngest(w http.ResponseWriter, r *http.Request) {
+	var req daemonIngestRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		writeDaemonError(w, http.StatusBadRequest, fmt.Errorf("invalid request: %w", err))
+		return
+	}
+	if d.vectors == nil {
+		writeDaemonError(w, http.StatusServiceUnavailable, errors.New("the daemon has no vector store"))
+		return
+	}
+	chunking, err := loadChunkOptions()
+	if err != nil {
+		writeDaemonError(w, http.StatusInternalServerError, err)
+		return
+	}
+	redact := loadRedaction()
+	if redact {
+		if err := setupRedaction(); err != nil {
+			writeDaemonError(w, http.StatusInternalServerError, err)
+			return
+		}
+	}
+	model := d.currentProvider().ModelInfo()
+	name := model.Provider + "/" + model.Model
+
+	// A document posted twice in one request is put once, with its last text
+	var docs []ingestDocument
+	index := make(map[string]int)
+	for i, doc := range req.Documents {
+		text := strings.TrimSpace(doc.Text)
+		if text == "" {
+			writeDaemonError(w, http.StatusBadRequest, fmt.Errorf("document %d has no text", i+1))
+			return
+		}
+		if doc.ID == "" {
+			sum := sha256.Sum256([]byte(text))
+			doc.ID = "ingest:" + hex.EncodeToString(sum[:8])
+		}
+		chunks := splitChunks(text, chunking.words)
+		if chunks == nil {
+			chunks = []string{text}
+		}
+		texts := chunks
+		if redact {
+			texts = make([]string, len(chunks))
+			for j, chunk := range chunks {
+				texts[j] = redactText(chunk)
+			}
+		}
+		if j, ok := index[doc.ID]; ok {
+			docs[j] = ingestDocument{id: doc.ID, chunks: chunks, texts: texts}
+			continue
+		}
+		index[doc.ID] = len(docs)
+		docs = append(docs, ingestDocument{id: doc.ID, chunks: chunks, texts: texts})
+	}
+
+	resp := daemonIngestResponse{Accepted: []string{}, Duplicates: []string{}}
+	var accepted []ingestDocument
+	for _, doc := range docs {
+		stored, err := d.vectors.hasDocument(name, doc.id, doc.texts)
+		if err != nil {
+			writeDaemonError(w, http.StatusInternalServerError, err)
+			return
+		}
+		if stored {
+			resp.Duplicates = append(resp.Duplicates, doc.id)
+		} else {
+			resp.Accepted = append(resp.Accepted, doc.id)
+			accepted = append(accepted, doc)
+		}
+	}
+	if len(accepted) == 0 {
+		writeDaemonJSON(w, resp)
+		return
+	}
+
+	job := d.jobs.Submit(fmt.Sprintf("ingest %d documents", len(accepted)), d.ingestJob(model, accepted, redact))
+	resp.Job = job.ID
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusAccepted)
+	json.NewEncoder(w).Encode(resp)
+}
+
+// ingestJob embeds and puts each document, waiting out rate limits. A
+// document stored by another request in the meantime is skipped.
+func (d *daemon) ingestJob(model ModelInfo, docs []ingestDocument, redact bool) jobFunc {
+	return func(ctx context.Context, job *Job) (any, error) {
+		ids := make([]string, len(docs))
+		for i, doc := range docs {
+			ids[i] = doc.id
+		}
+		job.SetItems(ids)
+
+		name := model.Provider + "/" + model.Model
+		for _, doc := range docs {
+			if err := job.Checkpoint(ctx); err != nil {
+				return nil, err
+			}
+			if stored, err := d.vectors.hasDocument(name, doc.id, doc.texts); err != nil {
+				return nil, err
+			} else if stored {
+				job.Logf("%s is already stored", doc.id)
+				job.Advance()
+				continue
+			}
+
+			provider := d.embedder(documentInputType(d.currentProvider()))
+			if info := provider.ModelInfo(); info.Provider != model.Provider || info.Model != model.Model {
+				return nil, fmt.Errorf("the provider was reloaded with %s/%s", info.Provider, info.Model)
+			}
+			embeddings := make([]CustomEmbedding, 0, len(doc.chunks))
+			for len(embeddings) < len(doc.chunks) {
+				batch := doc.chunks[len(embeddings):min(len(embeddings)+defaultRemoteBatch, len(doc.chunks))]
+				vectors, err := provider.GenerateBatch(batch)
+				var rateErr *RateLimitError
+				if errors.As(err, &rateErr) {
+					job.Logf("rate limited, retrying in %s", rateErr.RetryAfter.Round(time.Second))
+					if err := job.WaitForRetry(ctx, rateErr.RetryAfter); err != nil {
+						return nil, err
+					}
+					continue
+				}
+				if err != nil {
+					return nil, fmt.Errorf("failed to embed %s: %w", doc.id, err)
+				}
+				for i, text := range batch {
+					embeddings = append(embeddings, CustomEmbedding{Text: text, Embedding: vectors[i]})
+				}
+			}
+			if redact {
+				embeddings = redactEmbeddings(embeddings)
+			}
+			chunkDocs := make([]string, len(embeddings))
+			for i := range chunkDocs {
+				chunkDocs[i] = doc.id
+			}
+			if err := d.vectors.putDocuments(model, chunkDocs, embeddings); err != nil {
+				return nil, err
+			}
+			job.Logf("put %s • %d chunks", doc.id, len(embeddings))
+			job.Advance()
+		}
+		return nil, nil
+	}
+}
+
+// documentInputType is the input type provider embeds stored documents
+// with, or "" when it takes none
+func documentInputType(provider EmbeddingProvider) string {
+	if typed, ok := provider.(inputTypeProvider); ok && len(typed.InputTypes()) > 0 {
+		return typed.InputTypes()[0]
+	}
+	return ""
+}
+
+// hasDocument reports whether doc's live chunks from the model named model
+// are exactly texts, so putting it again would change nothing
+func (s *vectorStore) hasDocument(model, doc string, texts []string) (bool, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if err := s.refresh(); err != nil {
+		return false, err
+	}
+	want := make(map[string]bool, len(texts))
+	for _, text := range texts {
+		want[vectorKey(model, text)+"\x00"+doc] = true
+	}
+	found := 0
+	for _, key := range s.docs[doc] {
+		if !strings.HasPrefix(key, model+"\x00") {
+			continue
+		}
+		if !want[key] {
+			return false, nil
+		}
+		found++
+	}
+	return found == len(want), nil
+}
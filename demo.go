@@ -0,0 +1,165 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"os"
+	"strconv"
+	"sync"
+	"time"
+	"unicode/utf8"
+)
+
+// demoLimits bound what a single visitor of a public demo can ask for
+type demoLimits struct {
+	// PerMinute is how many requests each IP may make per minute
+	PerMinute int
+	// MaxTexts and MaxChars cap a single embed request
+	MaxTexts int
+	MaxChars int
+}
+
+// loadDemoLimits reads EMBER_DEMO_RATE (requests per IP per minute, default 30)
+func loadDemoLimits() demoLimits {
+	limits := demoLimits{PerMinute: 30, MaxTexts: 16, MaxChars: 2000}
+	if n, err := strconv.Atoi(os.Getenv("EMBER_DEMO_RATE")); err == nil && n > 0 {
+		limits.PerMinute = n
+	}
+	return limits
+}
+
+// ipLimiter is a token bucket per client IP
+type ipLimiter struct {
+	mu      sync.Mutex
+	rate    float64 // tokens per second
+	burst   float64
+	buckets map[string]*bucket
+}
+
+type bucket struct {
+	tokens float64
+	last   time.Time
+}
+
+func newIPLimiter(perMinute int) *ipLimiter {
+	return &ipLimiter{
+		rate:    float64(perMinute) / 60,
+		burst:   float64(perMinute),
+		buckets: make(map[string]*bucket),
+	}
+}
+
+// allow takes a token for ip, or reports how long until one is available
+func (l *ipLimiter) allow(ip string) (bool, time.Duration) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	now := time.Now()
+	b, ok := l.buckets[ip]
+	if !ok {
+		// Forget idle visitors so the map doesn't grow without bound
+		if len(l.buckets) > 10000 {
+			l.prune(now)
+		}
+		b = &bucket{tokens: l.burst, last: now}
+		l.buckets[ip] = b
+	}
+
+	b.tokens = min(l.burst, b.tokens+now.Sub(b.last).Seconds()*l.rate)
+	b.last = now
+	if b.tokens < 1 {
+		return false, time.Duration((1 - b.tokens) / l.rate * float64(time.Second))
+	}
+	b.tokens--
+	return true, 0
+}
+
+// prune drops buckets that have refilled completely
+func (l *ipLimiter) prune(now time.Time) {
+	for ip, b := range l.buckets {
+		if b.tokens+now.Sub(b.last).Seconds()*l.rate >= l.burst {
+			delete(l.buckets, ip)
+		}
+	}
+}
+
+// demoServer bounds how long a visitor may hold a connection open, so slow
+// clients can't tie up the demo. The mock provider answers at once.
+func demoServer(handler http.Handler) *http.Server {
+	return &http.Server{
+		Handler:           handler,
+		ReadHeaderTimeout: 5 * time.Second,
+		ReadTimeout:       15 * time.Second,
+		WriteTimeout:      15 * time.Second,
+		IdleTimeout:       60 * time.Second,
+		MaxHeaderBytes:    16 << 10,
+	}
+}
+
+// serveDemo serves the embed and model endpoints over TCP with the mock
+// provider. No API key is ever loaded, and the control endpoints are left out
+// so visitors can't reload or stop the server.
+func serveDemo(addr string, limits demoLimits) error {
+	listener, err := net.Listen("tcp", addr)
+	if err != nil {
+		return fmt.Errorf("failed to listen on %s: %w", addr, err)
+	}
+
+	d := &daemon{provider: MockProvider{}, started: time.Now()}
+	limiter := newIPLimiter(limits.PerMinute)
+
+	mux := http.NewServeMux()
+	mux.Handle("POST /v1/embed", d.limitDemo(limiter, limits, http.HandlerFunc(d.handleEmbed)))
+	mux.Handle("GET /v1/model", d.limitDemo(limiter, limits, http.HandlerFunc(d.handleModel)))
+
+	fmt.Printf("🌐 Demo mode: %d requests per IP per minute, up to %d texts of %d characters\n",
+		limits.PerMinute, limits.MaxTexts, limits.MaxChars)
+	return d.serve(listener, demoServer(mux), addr)
+}
+
+// limitDemo rejects requests over the per-IP rate and embed requests over the size limits
+func (d *daemon) limitDemo(limiter *ipLimiter, limits demoLimits, next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		ip, _, err := net.SplitHostPort(r.RemoteAddr)
+		if err != nil {
+			ip = r.RemoteAddr
+		}
+
+		if ok, wait := limiter.allow(ip); !ok {
+			w.Header().Set("Retry-After", strconv.Itoa(int(wait.Seconds())+1))
+			writeDaemonError(w, http.StatusTooManyRequests, fmt.Errorf("rate limit of %d requests per minute exceeded", limits.PerMinute))
+			return
+		}
+
+		if r.Method == http.MethodPost {
+			body, err := io.ReadAll(http.MaxBytesReader(w, r.Body, int64(limits.MaxTexts*limits.MaxChars*4+1024)))
+			if err != nil {
+				writeDaemonError(w, http.StatusRequestEntityTooLarge, fmt.Errorf("request too large: %w", err))
+				return
+			}
+
+			var req daemonEmbedRequest
+			if err := json.Unmarshal(body, &req); err != nil {
+				writeDaemonError(w, http.StatusBadRequest, fmt.Errorf("invalid request: %w", err))
+				return
+			}
+			if len(req.Texts) > limits.MaxTexts {
+				writeDaemonError(w, http.StatusRequestEntityTooLarge, fmt.Errorf("at most %d texts per request", limits.MaxTexts))
+				return
+			}
+			for _, text := range req.Texts {
+				if utf8.RuneCountInString(text) > limits.MaxChars {
+					writeDaemonError(w, http.StatusRequestEntityTooLarge, fmt.Errorf("texts are limited to %d characters", limits.MaxChars))
+					return
+				}
+			}
+			r.Body = io.NopCloser(bytes.NewReader(body))
+		}
+
+		next.ServeHTTP(w, r)
+	})
+}
@@ -0,0 +1,253 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"net"
+	"net/http"
+	"net/url"
+	"os"
+	"strings"
+	"time"
+)
+
+// endpointProvider is implemented by providers that talk to an HTTP endpoint
+type endpointProvider interface {
+	Endpoint() string
+}
+
+func (e *OpenAIProvider) Endpoint() string {
+	return e.baseURL
+}
+
+func (c *CohereProvider) Endpoint() string {
+	return cohereEmbedURL
+}
+
+// Environment variables holding each provider's API key
+var providerKeyEnv = map[string]string{
+	"openai": "OPENAI_API_KEY",
+	"cohere": "COHERE_API_KEY",
+}
+
+// doctorCheck prints each check as it's made in the table format, and
+// collects them for the machine formats
+type doctorCheck struct {
+	failed bool
+	format string
+	checks []doctorEntry
+}
+
+func (d *doctorCheck) ok(name, detail string) {
+	d.checks = append(d.checks, doctorEntry{Name: name, Status: "ok", Detail: detail})
+	if d.format == "table" {
+		fmt.Printf("✅ %-16s %s\n", name, detail)
+	}
+}
+
+func (d *doctorCheck) info(name, detail string) {
+	d.checks = append(d.checks, doctorEntry{Name: name, Status: "info", Detail: detail})
+	if d.format == "table" {
+		fmt.Printf("ℹ️  %-16s %s\n", name, detail)
+	}
+}
+
+func (d *doctorCheck) fail(name, detail, hint string) {
+	d.failed = true
+	d.checks = append(d.checks, doctorEntry{Name: name, Status: "fail", Detail: detail, Hint: hint})
+	if d.format != "table" {
+		return
+	}
+	fmt.Printf("❌ %-16s %s\n", name, detail)
+	if hint != "" {
+		fmt.Printf("   %-16s → %s\n", "", hint)
+	}
+}
+
+// finish writes the checks in the machine formats and exits with status 1
+// if any failed
+func (d *doctorCheck) finish() {
+	if d.format != "table" {
+		if err := (doctorOutput{OK: !d.failed, Checks: d.checks}).write(d.format); err != nil {
+			displayError(err)
+			os.Exit(1)
+		}
+		if d.failed {
+			os.Exit(1)
+		}
+		return
+	}
+
+	fmt.Println()
+	if d.failed {
+		fmt.Println("Some checks failed.")
+		os.Exit(1)
+	}
+	fmt.Println("Everything looks good.")
+}
+
+// runDoctorCommand checks configuration, DNS and a test embedding, printing a
+// fix for each problem it finds
+func runDoctorCommand(args []string) {
+	fs := flag.NewFlagSet("doctor", flag.ExitOnError)
+	format := formatFlag(fs, "table")
+	fs.Parse(args)
+	checkFormat(format)
+
+	d := &doctorCheck{format: *format}
+	if d.format == "table" {
+		fmt.Print("🩺 ember doctor\n\n")
+	}
+
+	name := loadProviderName()
+	d.checkKey(name)
+
+	provider, err := NewProvider(name)
+	if err != nil {
+		d.fail("Provider", name, err.Error())
+		d.finish()
+		return
+	}
+	defer closeProvider(provider)
+
+	info := provider.ModelInfo()
+	d.ok("Provider", fmt.Sprintf("%s (%s)", info.Provider, info.Model))
+
+	if ep, ok := provider.(endpointProvider); ok {
+		d.checkDNS(ep.Endpoint())
+	}
+
+	d.checkEmbedding(provider)
+
+	if dir := projectDataDir(); dir != "" {
+		d.info("Project", projectName()+" • data in "+dir)
+	} else {
+		d.info("Project", "none • data in the global config directory (ember init starts one)")
+	}
+
+	if atRest != nil {
+		d.info("Encryption", "on • key from "+atRestMode)
+	} else {
+		d.info("Encryption", "off • EMBER_ENCRYPT encrypts sets, history, the cache and the vector store")
+	}
+
+	if daemon, err := connectDaemon(defaultSocketPath()); err != nil {
+		d.info("Daemon", "not running")
+	} else if reason := daemonMismatch(daemon.ModelInfo()); reason != "" {
+		d.info("Daemon", "running on "+defaultSocketPath()+", but not used: "+reason)
+	} else {
+		d.info("Daemon", "running on "+defaultSocketPath()+" (the TUI will use it)")
+	}
+	d.finish()
+}
+
+func (d *doctorCheck) checkKey(provider string) {
+	env, ok := providerKeyEnv[provider]
+	if !ok {
+		d.info("API key", "not needed for "+provider)
+		return
+	}
+
+	if provider == "openai" {
+		keys := loadAPIKeys()
+		if len(keys) == 0 {
+			if os.Getenv("EMBER_BASE_URL") != "" {
+				d.info("API key", "none set (fine for servers without authentication)")
+				return
+			}
+			d.fail("API key", "OPENAI_API_KEY is not set", "run ember key set, export OPENAI_API_KEY=sk-... or set OPENAI_API_KEYS for several keys")
+			return
+		}
+		masked := make([]string, len(keys))
+		for i, k := range keys {
+			masked[i] = maskKey(k)
+			if strings.ContainsAny(k, "\"'") {
+				d.fail("API key", maskKey(k)+" contains quotes", "remove stray quotes from the exported value")
+				return
+			}
+		}
+		d.ok("API key", fmt.Sprintf("%d configured (%s)%s", len(keys), strings.Join(masked, ", "), keySource(provider)))
+		return
+	}
+
+	if os.Getenv(env) == "" {
+		d.fail("API key", env+" is not set", "run ember key set "+provider+" or export "+env+"=...")
+		return
+	}
+	d.ok("API key", maskKey(os.Getenv(env))+keySource(provider))
+}
+
+// keySource notes when provider's key came from the system keychain
+func keySource(provider string) string {
+	if keychainProviders[provider] {
+		return " from the system keychain"
+	}
+	return ""
+}
+
+func (d *doctorCheck) checkDNS(endpoint string) {
+	u, err := url.Parse(endpoint)
+	if err != nil || u.Hostname() == "" {
+		d.fail("DNS", "invalid endpoint "+endpoint, "check EMBER_BASE_URL")
+		return
+	}
+	host := u.Hostname()
+	if net.ParseIP(host) != nil || host == "localhost" {
+		d.info("DNS", host+" needs no lookup")
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	start := time.Now()
+	addrs, err := net.DefaultResolver.LookupHost(ctx, host)
+	if err != nil {
+		d.fail("DNS", fmt.Sprintf("%s: %v", host, err), "check your network connection, proxy or VPN")
+		return
+	}
+	d.ok("DNS", fmt.Sprintf("%s → %d addresses (%s)", host, len(addrs), time.Since(start).Round(time.Millisecond)))
+}
+
+func (d *doctorCheck) checkEmbedding(provider EmbeddingProvider) {
+	start := time.Now()
+	embedding, err := provider.GenerateEmbedding("ok")
+	latency := time.Since(start).Round(time.Millisecond)
+
+	if err != nil {
+		d.fail("Test embedding", err.Error(), embeddingErrorHint(err))
+		return
+	}
+	d.ok("Test embedding", fmt.Sprintf("%d dimensions in %s", len(embedding), latency))
+}
+
+// embeddingErrorHint suggests a fix for common embedding failures
+func embeddingErrorHint(err error) string {
+	var statusErr *APIStatusError
+	var rateErr *RateLimitError
+	var netErr net.Error
+
+	switch {
+	case errors.As(err, &rateErr):
+		return "rate limited or out of quota; check your plan and billing, or add keys with OPENAI_API_KEYS"
+	case errors.As(err, &statusErr):
+		switch statusErr.StatusCode {
+		case http.StatusUnauthorized:
+			return "the API key was rejected; create a new key and check it was copied whole"
+		case http.StatusForbidden:
+			return "the key has no access to this model or project"
+		case http.StatusNotFound:
+			return "model or endpoint not found; check the model name and EMBER_BASE_URL"
+		case http.StatusBadRequest:
+			return "the request was rejected; check the model supports embeddings and EMBER_DIMENSIONS"
+		}
+		if statusErr.StatusCode >= 500 {
+			return "the provider is having problems; try again shortly"
+		}
+	case errors.As(err, &netErr):
+		return "could not reach the provider; check your network, proxy or EMBER_BASE_URL"
+	}
+	return ""
+}
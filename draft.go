@@ -0,0 +1,111 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+)
+
+// draft is the text typed but not yet compared or embedded, kept so a
+// crash or an accidental quit doesn't lose it
+type draft struct {
+	Input string `json:"input,omitempty"`
+	// Comparisons is only set while the configure screen's texts differ
+	// from the embedded comparison set
+	Comparisons []string `json:"comparisons,omitempty"`
+}
+
+func loadDraft(path string) draft {
+	var d draft
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return d
+	}
+	if data, err = openAtRest(data); err != nil {
+		return d
+	}
+	json.Unmarshal(data, &d)
+	return d
+}
+
+// writeDraft replaces the draft file in one step, so a crash mid-write
+// leaves the previous draft intact
+func writeDraft(path string, d draft) error {
+	if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+		return fmt.Errorf("failed to create draft directory: %w", err)
+	}
+
+	data, err := json.Marshal(d)
+	if err != nil {
+		return fmt.Errorf("failed to marshal draft: %w", err)
+	}
+
+	tmp := path + ".tmp"
+	if err := os.WriteFile(tmp, sealAtRest(data), 0o644); err != nil {
+		return fmt.Errorf("failed to write draft: %w", err)
+	}
+	if err := os.Rename(tmp, path); err != nil {
+		return fmt.Errorf("failed to write draft: %w", err)
+	}
+	return nil
+}
+
+// currentDraft collects the input and any comparison texts not yet embedded.
+// Texts being embedded by a background job count as sent.
+func (m model) currentDraft() draft {
+	d := draft{Input: m.textarea.Value()}
+	if m.jobs.Active() > 0 {
+		return d
+	}
+
+	embedded := make([]string, len(m.customEmbeddings))
+	for i, e := range m.customEmbeddings {
+		embedded[i] = e.Text
+	}
+	texts := m.comparisonValues()
+	blank := !slices.ContainsFunc(texts, func(t string) bool { return strings.TrimSpace(t) != "" })
+	if !blank && !slices.Equal(m.enabledComparisonValues(), embedded) {
+		d.Comparisons = texts
+	}
+	return d
+}
+
+// saveDraft writes the draft whenever it has changed since the last write
+func (m *model) saveDraft() {
+	if m.draftPath == "" {
+		return
+	}
+
+	d := m.currentDraft()
+	key, _ := json.Marshal(d)
+	if string(key) == m.savedDraft {
+		return
+	}
+	if err := writeDraft(m.draftPath, d); err != nil {
+		m.modelNotice = "⚠️  " + err.Error()
+		return
+	}
+	m.savedDraft = string(key)
+}
+
+// restoreDraft puts back the text left over from the last run
+func (m *model) restoreDraft() {
+	d := loadDraft(m.draftPath)
+	if d.Input != "" {
+		m.textarea.SetValue(d.Input)
+	}
+	if len(d.Comparisons) > 0 {
+		m.setComparisonTextAreas(d.Comparisons)
+		m.embeddingTexts[0].Blur()
+		m.comparisonNotice = "📝 Restored unsaved comparison texts • Alt+Enter to embed them"
+	}
+	if d.Input != "" || len(d.Comparisons) > 0 {
+		m.modelNotice = "📝 Restored your draft from last time"
+	}
+
+	key, _ := json.Marshal(m.currentDraft())
+	m.savedDraft = string(key)
+}
@@ -0,0 +1,71 @@
+package main
+
+import (
+	"fmt"
+
+	"github.com/charmbracelet/lipgloss"
+)
+
+// embeddingPlan tallies what a batch command would send to the provider,
+// for --dry-run. Nothing is embedded; the cache is only looked at.
+type embeddingPlan struct {
+	info   ModelInfo
+	texts  int
+	cached int
+	// chars counts the texts that would be sent
+	chars int
+}
+
+func newEmbeddingPlan(info ModelInfo) *embeddingPlan {
+	return &embeddingPlan{info: info}
+}
+
+// add counts texts that provider would embed, leaving out those its cache holds
+func (p *embeddingPlan) add(provider EmbeddingProvider, texts ...string) {
+	cached, _ := provider.(*cachedProvider)
+	for _, text := range texts {
+		p.texts++
+		if cached != nil && cached.cache.Has(cached.model, text) {
+			p.cached++
+			continue
+		}
+		p.chars += len(text)
+	}
+}
+
+// tokens estimates the tokens that would be sent from their length, as for previews
+func (p *embeddingPlan) tokens() int {
+	return (p.chars + charsPerToken - 1) / charsPerToken
+}
+
+// cost is the dollar cost of the tokens that would be sent, when the model's price is known
+func (p *embeddingPlan) cost() (float64, bool) {
+	price, ok := modelPrice(p.info)
+	return price * float64(p.tokens()) / 1e6, ok
+}
+
+// render reports the plan
+func (p *embeddingPlan) render() string {
+	labelStyle := lipgloss.NewStyle().
+		Foreground(theme.Primary).
+		Bold(true)
+	mutedStyle := lipgloss.NewStyle().
+		Foreground(theme.Muted)
+	warningStyle := lipgloss.NewStyle().
+		Foreground(theme.Warning)
+
+	tokens := p.tokens()
+	cost := warningStyle.Render("unknown price • set EMBER_PRICE_PER_MTOK")
+	if dollars, ok := p.cost(); ok {
+		cost = fmt.Sprintf("$%.2f", dollars)
+	}
+
+	s := labelStyle.Render(fmt.Sprintf("🧪 Dry run • %s/%s • nothing was embedded", p.info.Provider, p.info.Model)) + "\n"
+	s += fmt.Sprintf("  Texts             %d\n", p.texts)
+	s += fmt.Sprintf("  Already cached    %d\n", p.cached)
+	s += fmt.Sprintf("  Would embed       %d\n", p.texts-p.cached)
+	s += fmt.Sprintf("  Estimated tokens  ~%d\n", tokens)
+	s += fmt.Sprintf("  Estimated cost    %s\n", cost)
+	s += mutedStyle.Render("  Tokens assume ~4 characters per token.") + "\n"
+	return s
+}
@@ -0,0 +1,118 @@
+package main
+
+import (
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+)
+
+// embedOutput is what `ember embed --format json` prints, on one line so
+// it's also the ndjson format
+type embedOutput struct {
+	Model      string    `json:"model"`
+	Dimensions int       `json:"dimensions"`
+	Text       string    `json:"text"`
+	Embedding  []float64 `json:"embedding"`
+}
+
+// runEmbedCommand handles `ember embed`, printing the vector of one text
+// given as arguments, in a file or on stdin. `ember generate` is the
+// command for a file of many texts.
+func runEmbedCommand(args []string) {
+	fs := flag.NewFlagSet("embed", flag.ExitOnError)
+	file := fs.String("file", "", "file whose contents to embed, or - for stdin")
+	format := formatFlag(fs, "json")
+	f32 := fs.Bool("float32", false, "write float32 values")
+	fs.Parse(args)
+
+	checkFormat(format)
+	text, err := readEmbedInput(fs.Args(), *file)
+	if err != nil {
+		displayError(err)
+		os.Exit(1)
+	}
+	if text == "" {
+		fmt.Fprintln(os.Stderr, `Usage: ember embed [--format json|ndjson|csv|table] [--float32] "TEXT" | --file FILE | < FILE`)
+		os.Exit(2)
+	}
+
+	var provider EmbeddingProvider = setupProvider()
+	defer closeProvider(provider)
+	if cache := setupCache(); cache != nil {
+		defer cache.Close()
+		provider = &cachedProvider{EmbeddingProvider: provider, cache: cache, model: cacheModelKey(provider.ModelInfo(), "")}
+	}
+
+	embedding, err := provider.GenerateEmbedding(text)
+	if err != nil {
+		displayError(err)
+		os.Exit(1)
+	}
+	if *f32 {
+		for i, v := range embedding {
+			embedding[i] = float64(float32(v))
+		}
+	}
+
+	info := provider.ModelInfo()
+	switch *format {
+	case "csv":
+		fmt.Println(strings.ReplaceAll(formatVector(embedding, *f32), ", ", ","))
+		return
+	case "table":
+		fmt.Printf("%s/%s • %d dimensions\n%s\n", info.Provider, info.Model, len(embedding), formatVector(embedding, *f32))
+		return
+	}
+	out := json.NewEncoder(os.Stdout)
+	err = out.Encode(embedOutput{
+		Model:      info.Provider + "/" + info.Model,
+		Dimensions: len(embedding),
+		Text:       text,
+		Embedding:  embedding,
+	})
+	if err != nil {
+		displayError(fmt.Errorf("failed to write output: %w", err))
+		os.Exit(1)
+	}
+}
+
+// stdinIsPiped reports whether stdin is a pipe or file rather than a terminal
+func stdinIsPiped() bool {
+	info, err := os.Stdin.Stat()
+	return err == nil && info.Mode()&os.ModeCharDevice == 0
+}
+
+// readEmbedInput returns the text to embed: the arguments joined, the
+// contents of file, or stdin when there are neither and it isn't a terminal
+func readEmbedInput(args []string, file string) (string, error) {
+	if len(args) > 0 && file != "" {
+		return "", errors.New("give the text as arguments or with --file, not both")
+	}
+	if len(args) > 0 {
+		return strings.TrimSpace(strings.Join(args, " ")), nil
+	}
+
+	r := io.Reader(os.Stdin)
+	switch {
+	case file != "" && file != "-":
+		f, err := os.Open(file)
+		if err != nil {
+			return "", fmt.Errorf("failed to open %s: %w", file, err)
+		}
+		defer f.Close()
+		r = f
+	case file == "":
+		if !stdinIsPiped() {
+			return "", nil
+		}
+	}
+	data, err := io.ReadAll(r)
+	if err != nil {
+		return "", fmt.Errorf("failed to read the text: %w", err)
+	}
+	return strings.TrimSpace(string(data)), nil
+}
@@ -0,0 +1,491 @@
+package main
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	"os/signal"
+	"path"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"syscall"
+	"time"
+)
+
+// Chunks sent to the provider per request by `ember embed-batch`
+const defaultEmbedBatchSize = 32
+
+// batchRecord is one line of the JSONL `ember embed-batch` writes
+type batchRecord struct {
+	ID        string    `json:"id"`
+	Path      string    `json:"path"`
+	Chunk     int       `json:"chunk"`
+	Text      string    `json:"text"`
+	Model     string    `json:"model"`
+	Embedding []float64 `json:"embedding"`
+}
+
+// batchDone is a chunk already in the output, with a hash of the text it
+// was embedded from
+type batchDone struct {
+	path string
+	sum  [sha256.Size]byte
+}
+
+// batchChunk is a piece of a file waiting to be embedded
+type batchChunk struct {
+	id    string
+	path  string
+	index int
+	text  string
+}
+
+// runEmbedBatchCommand handles `ember embed-batch`: it chunks every file
+// matching a glob, embeds the chunks with several requests in flight and
+// appends them to a JSONL file. The file doubles as the record of what's
+// done, so an interrupted run picks up where it stopped.
+func runEmbedBatchCommand(args []string) {
+	chunking, err := loadChunkOptions()
+	if err != nil {
+		displayError(err)
+		os.Exit(2)
+	}
+
+	fs := flag.NewFlagSet("embed-batch", flag.ExitOnError)
+	glob := fs.String("glob", "", `files to embed; ** matches any number of directories, as in "docs/**/*.md"`)
+	out := fs.String("out", "embeddings.jsonl", "JSONL file to write, resumed when it exists")
+	words := fs.Int("chunk-words", chunking.words, "words per chunk")
+	workers := fs.Int("workers", loadMaxConcurrency(), "requests in flight at once")
+	batch := fs.Int("batch", defaultEmbedBatchSize, "chunks per request")
+	yes := fs.Bool("yes", false, "start without previewing a sample first")
+	dryRun := fs.Bool("dry-run", false, "report what would be embedded without calling the provider")
+	fs.Parse(args)
+
+	if *glob == "" || fs.NArg() != 0 || *words < 1 || *workers < 1 || *batch < 1 {
+		fmt.Fprintln(os.Stderr, `Usage: ember embed-batch --glob "docs/**/*.md" [--out embeddings.jsonl] [--chunk-words N] [--workers N] [--batch N] [--yes] [--dry-run]`)
+		os.Exit(2)
+	}
+
+	files, err := globFiles(*glob, *out)
+	if err == nil && len(files) == 0 {
+		err = fmt.Errorf("no files match %s", *glob)
+	}
+	if err != nil {
+		displayError(err)
+		os.Exit(1)
+	}
+	chunks, err := chunkFiles(files, *words)
+	if err != nil {
+		displayError(err)
+		os.Exit(1)
+	}
+
+	var provider EmbeddingProvider = setupProvider()
+	defer closeProvider(provider)
+	info := provider.ModelInfo()
+	if cache := setupCache(); cache != nil {
+		defer cache.Close()
+		provider = &cachedProvider{EmbeddingProvider: provider, cache: cache, model: cacheModelKey(info, "")}
+	}
+	model := info.Provider + "/" + info.Model
+
+	done, written, err := readBatchOutput(*out, model)
+	if err != nil {
+		displayError(err)
+		os.Exit(1)
+	}
+	// A chunk whose text changed since it was written is embedded again, and
+	// its old record dropped along with those past the end of a shorter file
+	var pending []batchChunk
+	current := make(map[string]bool, len(chunks))
+	matched := make(map[string]bool, len(files))
+	stale := make(map[string]bool)
+	for _, chunk := range chunks {
+		current[chunk.id] = true
+		matched[chunk.path] = true
+		record, ok := done[chunk.id]
+		if ok && record.sum == sha256.Sum256([]byte(chunk.text)) {
+			continue
+		}
+		if ok {
+			stale[chunk.id] = true
+		}
+		pending = append(pending, chunk)
+	}
+	for id, record := range done {
+		if matched[record.path] && !current[id] {
+			stale[id] = true
+		}
+	}
+	if len(stale) > 0 {
+		fmt.Fprintf(os.Stderr, "♻️  %d chunks in %s are from older versions of their files and will be replaced\n", len(stale), *out)
+		if !*dryRun {
+			if written, err = dropBatchRecords(*out, written, stale); err != nil {
+				displayError(err)
+				os.Exit(1)
+			}
+		}
+	}
+	if len(pending) == 0 {
+		fmt.Printf("✅ All %d chunks of %d files are already in %s\n", len(chunks), len(files), *out)
+		return
+	}
+	texts := make([]string, len(pending))
+	for i, chunk := range pending {
+		texts[i] = chunk.text
+	}
+
+	if *dryRun {
+		if resumed := len(chunks) - len(pending); resumed > 0 {
+			fmt.Fprintf(os.Stderr, "⏯  %d of %d chunks are already in %s\n", resumed, len(chunks), *out)
+		}
+		plan := newEmbeddingPlan(info)
+		plan.add(provider, texts...)
+		fmt.Fprint(os.Stderr, plan.render())
+		return
+	}
+	if !*yes {
+		sampler := newCorpusSampler()
+		for _, text := range texts {
+			sampler.add(text)
+		}
+		ok, err := confirmBatchJob(provider, sampler)
+		if err != nil {
+			displayError(err)
+			os.Exit(1)
+		}
+		if !ok {
+			return
+		}
+	}
+
+	f, err := openBatchOutput(*out, written)
+	if err != nil {
+		displayError(err)
+		os.Exit(1)
+	}
+	defer f.Close()
+
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+	go func() {
+		// A second Ctrl+C stops ember without waiting for requests in flight
+		<-ctx.Done()
+		stop()
+	}()
+	if resumed := len(chunks) - len(pending); resumed > 0 {
+		fmt.Fprintf(os.Stderr, "⏯  Resuming • %d of %d chunks are already in %s\n", resumed, len(chunks), *out)
+	}
+	embedded, err := embedChunksConcurrently(ctx, provider, pending, model, *batch, *workers, f)
+	switch {
+	case ctx.Err() != nil:
+		fmt.Fprintf(os.Stderr, "⏸  Stopped after %d of %d chunks • run the same command again to resume\n", embedded, len(pending))
+		os.Exit(130)
+	case err != nil:
+		displayError(err)
+		fmt.Fprintf(os.Stderr, "%d of %d chunks were saved • run the same command again to resume\n", embedded, len(pending))
+		os.Exit(1)
+	}
+	fmt.Printf("📦 Embedded %d chunks of %d files into %s with %s\n", embedded, len(files), *out, info.Model)
+}
+
+// globFiles returns the regular files matching pattern, sorted, other than
+// skip, the output file. ** matches any number of directories; hidden
+// directories are skipped below the part of the pattern without wildcards.
+func globFiles(pattern, skip string) ([]string, error) {
+	skip, _ = filepath.Abs(skip)
+	segments := strings.Split(filepath.ToSlash(pattern), "/")
+	fixed := 0
+	for fixed < len(segments) && !strings.ContainsAny(segments[fixed], "*?[") {
+		fixed++
+	}
+	if fixed == len(segments) {
+		// No wildcards: the pattern names one file
+		if info, err := os.Stat(pattern); err == nil && info.Mode().IsRegular() {
+			return []string{pattern}, nil
+		}
+		return nil, nil
+	}
+	root := strings.Join(segments[:fixed], "/")
+	if root == "" {
+		root = "."
+		if fixed > 0 {
+			root = "/"
+		}
+	}
+	for _, segment := range segments[fixed:] {
+		if _, err := path.Match(segment, ""); err != nil {
+			return nil, fmt.Errorf("invalid glob %q: %w", pattern, err)
+		}
+	}
+
+	var files []string
+	err := filepath.WalkDir(filepath.FromSlash(root), func(p string, d fs.DirEntry, err error) error {
+		if err != nil {
+			if p == filepath.FromSlash(root) && errors.Is(err, fs.ErrNotExist) {
+				return fs.SkipAll
+			}
+			return err
+		}
+		if d.IsDir() {
+			if p != filepath.FromSlash(root) && strings.HasPrefix(d.Name(), ".") {
+				return fs.SkipDir
+			}
+			return nil
+		}
+		rel, err := filepath.Rel(filepath.FromSlash(root), p)
+		if err != nil || !d.Type().IsRegular() {
+			return nil
+		}
+		if abs, _ := filepath.Abs(p); abs == skip {
+			return nil
+		}
+		if matchGlobSegments(segments[fixed:], strings.Split(filepath.ToSlash(rel), "/")) {
+			files = append(files, p)
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to list files for %s: %w", pattern, err)
+	}
+	sort.Strings(files)
+	return files, nil
+}
+
+// matchGlobSegments matches a path, split into segments, against a pattern
+// split the same way, where a ** segment matches any number of segments
+func matchGlobSegments(pattern, name []string) bool {
+	for len(pattern) > 0 {
+		if pattern[0] == "**" {
+			for i := 0; i <= len(name); i++ {
+				if matchGlobSegments(pattern[1:], name[i:]) {
+					return true
+				}
+			}
+			return false
+		}
+		if len(name) == 0 {
+			return false
+		}
+		if ok, _ := path.Match(pattern[0], name[0]); !ok {
+			return false
+		}
+		pattern, name = pattern[1:], name[1:]
+	}
+	return len(name) == 0
+}
+
+// chunkFiles reads files and cuts each into chunks of up to words words
+func chunkFiles(files []string, words int) ([]batchChunk, error) {
+	var chunks []batchChunk
+	for _, file := range files {
+		data, err := os.ReadFile(file)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read %s: %w", file, err)
+		}
+		text := strings.TrimSpace(string(data))
+		if text == "" {
+			continue
+		}
+		pieces := splitChunks(text, words)
+		if pieces == nil {
+			pieces = []string{text}
+		}
+		name := filepath.ToSlash(file)
+		for i, piece := range pieces {
+			chunks = append(chunks, batchChunk{id: name + "#" + strconv.Itoa(i), path: name, index: i, text: piece})
+		}
+	}
+	return chunks, nil
+}
+
+// readBatchOutput collects the chunks already in path by ID and the length
+// of its complete lines. A line cut short by an interrupted run is left
+// out, to be written again.
+func readBatchOutput(path, model string) (map[string]batchDone, int64, error) {
+	done := make(map[string]batchDone)
+	f, err := os.Open(path)
+	if errors.Is(err, os.ErrNotExist) {
+		return done, 0, nil
+	}
+	if err != nil {
+		return nil, 0, fmt.Errorf("failed to open %s: %w", path, err)
+	}
+	defer f.Close()
+
+	var written int64
+	r := bufio.NewReader(f)
+	for {
+		line, err := r.ReadBytes('\n')
+		if err == io.EOF {
+			return done, written, nil
+		}
+		if err != nil {
+			return nil, 0, fmt.Errorf("failed to read %s: %w", path, err)
+		}
+		var record struct {
+			ID    string `json:"id"`
+			Path  string `json:"path"`
+			Text  string `json:"text"`
+			Model string `json:"model"`
+		}
+		if err := json.Unmarshal(line, &record); err != nil {
+			return nil, 0, fmt.Errorf("failed to parse %s after %d records: %w", path, len(done), err)
+		}
+		if record.Model != model {
+			return nil, 0, fmt.Errorf("%s was written with %s, not %s • pass another --out or delete it to start over", path, record.Model, model)
+		}
+		done[record.ID] = batchDone{path: record.Path, sum: sha256.Sum256([]byte(record.Text))}
+		written += int64(len(line))
+	}
+}
+
+// dropBatchRecords rewrites the first written bytes of path without the
+// records whose IDs are in stale, returning the new length
+func dropBatchRecords(path string, written int64, stale map[string]bool) (int64, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return 0, fmt.Errorf("failed to read %s: %w", path, err)
+	}
+	var kept []byte
+	for _, line := range bytes.SplitAfter(data[:written], []byte("\n")) {
+		var record struct {
+			ID string `json:"id"`
+		}
+		if len(line) == 0 || json.Unmarshal(line, &record) != nil || stale[record.ID] {
+			continue
+		}
+		kept = append(kept, line...)
+	}
+	if err := os.WriteFile(path+".tmp", kept, 0o644); err != nil {
+		return 0, fmt.Errorf("failed to write %s: %w", path, err)
+	}
+	if err := os.Rename(path+".tmp", path); err != nil {
+		return 0, fmt.Errorf("failed to write %s: %w", path, err)
+	}
+	return int64(len(kept)), nil
+}
+
+// openBatchOutput opens path for appending after its first written bytes
+func openBatchOutput(path string, written int64) (*os.File, error) {
+	f, err := os.OpenFile(path, os.O_CREATE|os.O_WRONLY, 0o644)
+	if err == nil {
+		err = f.Truncate(written)
+	}
+	if err == nil {
+		_, err = f.Seek(written, io.SeekStart)
+	}
+	if err != nil {
+		if f != nil {
+			f.Close()
+		}
+		return nil, fmt.Errorf("failed to open %s: %w", path, err)
+	}
+	return f, nil
+}
+
+// batchResult is one request's worth of embedded chunks
+type batchResult struct {
+	chunks  []batchChunk
+	vectors [][]float64
+	err     error
+}
+
+// embedChunksConcurrently embeds chunks size at a time with workers requests
+// in flight, writing each batch to w as it finishes, so records are in the
+// order requests finish rather than file order. It stops handing out batches
+// when ctx is done or a request fails, and returns how many chunks were
+// written.
+func embedChunksConcurrently(ctx context.Context, provider EmbeddingProvider, chunks []batchChunk, model string, size, workers int, w io.Writer) (int, error) {
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	batches := make(chan []batchChunk)
+	results := make(chan batchResult)
+	go func() {
+		defer close(batches)
+		for start := 0; start < len(chunks); start += size {
+			select {
+			case batches <- chunks[start:min(start+size, len(chunks))]:
+			case <-ctx.Done():
+				return
+			}
+		}
+	}()
+	running := workers
+	for range workers {
+		go func() {
+			for batch := range batches {
+				texts := make([]string, len(batch))
+				for i, chunk := range batch {
+					texts[i] = chunk.text
+				}
+				vectors, err := provider.GenerateBatch(texts)
+				if err == nil && len(vectors) != len(batch) {
+					err = fmt.Errorf("expected %d embeddings, got %d", len(batch), len(vectors))
+				}
+				if err != nil {
+					err = fmt.Errorf("failed to embed %s: %w", batch[0].id, err)
+				}
+				results <- batchResult{chunks: batch, vectors: vectors, err: err}
+			}
+			results <- batchResult{}
+		}()
+	}
+
+	bar := newProgressBar()
+	bar.Width = 40
+	lastDrawn := time.Time{}
+	draw := func(done int) {
+		if done < len(chunks) && time.Since(lastDrawn) < 100*time.Millisecond {
+			return
+		}
+		lastDrawn = time.Now()
+		fmt.Fprintf(os.Stderr, "\r🧮 %s %d/%d chunks", bar.ViewAs(float64(done)/float64(len(chunks))), done, len(chunks))
+	}
+	draw(0)
+
+	buf := bufio.NewWriter(w)
+	enc := json.NewEncoder(buf)
+	written := 0
+	var firstErr error
+	for running > 0 {
+		result := <-results
+		if result.chunks == nil {
+			running--
+			continue
+		}
+		if result.err == nil {
+			for i, chunk := range result.chunks {
+				if result.err = enc.Encode(batchRecord{ID: chunk.id, Path: chunk.path, Chunk: chunk.index, Text: chunk.text, Model: model, Embedding: result.vectors[i]}); result.err != nil {
+					break
+				}
+			}
+		}
+		if result.err == nil {
+			// Flushing per batch keeps what's done on disk if ember is killed
+			result.err = buf.Flush()
+		}
+		if result.err != nil {
+			if firstErr == nil {
+				firstErr = result.err
+			}
+			cancel()
+			continue
+		}
+		written += len(result.chunks)
+		draw(written)
+	}
+	fmt.Fprintln(os.Stderr)
+	return written, firstErr
+}
@@ -0,0 +1,400 @@
+package main
+
+import (
+	"bufio"
+	"context"
+	"encoding/csv"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"os/signal"
+	"path/filepath"
+	"slices"
+	"strconv"
+	"strings"
+	"syscall"
+	"time"
+
+	"github.com/parquet-go/parquet-go"
+)
+
+// runEmbedCSVCommand handles `ember embed-csv`: it streams the rows of a CSV
+// file, embeds one column a batch at a time and writes the rows back out
+// with the vectors in an extra column, as Parquet or CSV going by the
+// output's extension.
+func runEmbedCSVCommand(args []string) {
+	fs := flag.NewFlagSet("embed-csv", flag.ExitOnError)
+	file := fs.String("file", "", "CSV file to read; its first row names the columns")
+	column := fs.String("column", "", "column holding the texts to embed")
+	out := fs.String("out", "", "file to write, .parquet or .csv")
+	as := fs.String("as", "embedding", "name of the column the vectors are written to")
+	batch := fs.Int("batch", defaultEmbedBatchSize, "rows per request")
+	f32 := fs.Bool("float32", false, "write float32 vectors to .parquet files")
+	yes := fs.Bool("yes", false, "start without previewing a sample first")
+	dryRun := fs.Bool("dry-run", false, "report what would be embedded without calling the provider")
+	fs.Parse(args)
+
+	if *file == "" || *column == "" || *out == "" || *as == "" || fs.NArg() != 0 || *batch < 1 {
+		fmt.Fprintln(os.Stderr, "Usage: ember embed-csv --file data.csv --column NAME --out FILE.parquet|FILE.csv [--as embedding] [--batch N] [--float32] [--yes] [--dry-run]")
+		os.Exit(2)
+	}
+	switch strings.ToLower(filepath.Ext(*out)) {
+	case ".parquet", ".csv":
+	default:
+		fmt.Fprintf(os.Stderr, "--out must end in .parquet or .csv, not %q\n", filepath.Ext(*out))
+		os.Exit(2)
+	}
+	outPath, _ := filepath.Abs(*out)
+	if inPath, _ := filepath.Abs(*file); outPath == inPath {
+		fmt.Fprintln(os.Stderr, "--out must not be the file being read")
+		os.Exit(2)
+	}
+
+	var provider EmbeddingProvider = setupProvider()
+	defer closeProvider(provider)
+	info := provider.ModelInfo()
+	if cache := setupCache(); cache != nil {
+		defer cache.Close()
+		provider = &cachedProvider{EmbeddingProvider: provider, cache: cache, model: cacheModelKey(info, "")}
+	}
+
+	// A first pass checks the header and counts the texts, for the progress
+	// bar and the preview, without keeping the rows
+	plan := newEmbeddingPlan(info)
+	sampler := newCorpusSampler()
+	header, rows, err := scanCSVColumn(*file, *column, func(text string) {
+		if *dryRun {
+			plan.add(provider, text)
+		} else {
+			sampler.add(text)
+		}
+	})
+	if err == nil && slices.Contains(header, *as) {
+		err = fmt.Errorf("%s already has a %q column • pass --as to name the new one", *file, *as)
+	}
+	if err != nil {
+		displayError(err)
+		os.Exit(1)
+	}
+
+	if *dryRun {
+		fmt.Fprintf(os.Stderr, "📄 %d rows, %d with a %q to embed\n", rows, plan.texts, *column)
+		fmt.Fprint(os.Stderr, plan.render())
+		return
+	}
+	if !*yes {
+		ok, err := confirmBatchJob(provider, sampler)
+		if err != nil {
+			displayError(err)
+			os.Exit(1)
+		}
+		if !ok {
+			return
+		}
+	}
+
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+	go func() {
+		// A second Ctrl+C stops ember without waiting for the request in flight
+		<-ctx.Done()
+		stop()
+	}()
+	// The vectors embedded so far are kept until the output is written, so
+	// an interrupted run picks up where it stopped
+	resume, err := openBatchResume("embed-csv", *file, *column, cacheModelKey(info, ""))
+	if err != nil {
+		displayError(err)
+		os.Exit(1)
+	}
+	resume.noteResumed(sampler.texts, "embedded")
+	embedded, err := embedCSVColumn(ctx, provider, csvEmbedJob{
+		in: *file, out: *out, column: *column, as: *as,
+		model: info.Provider + "/" + info.Model,
+		texts: sampler.texts, batch: *batch, single: *f32,
+		resume: resume,
+	})
+	switch {
+	case ctx.Err() != nil:
+		resume.close()
+		fmt.Fprintf(os.Stderr, "⏸  Stopped after %d of %d texts • run the same command again to resume\n", resume.done, sampler.texts)
+		os.Exit(130)
+	case err != nil:
+		resume.close()
+		displayError(err)
+		if resume.done > 0 {
+			fmt.Fprintf(os.Stderr, "%d of %d texts were saved • run the same command again to resume\n", resume.done, sampler.texts)
+		}
+		os.Exit(1)
+	}
+	resume.finish()
+	fmt.Printf("📦 Embedded %d of %d rows of %s into %s with %s\n", embedded, rows, *file, *out, info.Model)
+}
+
+// csvEmbedJob is what embedCSVColumn reads, embeds and writes
+type csvEmbedJob struct {
+	in, out string
+	column  string
+	as      string
+	model   string
+	texts   int
+	batch   int
+	single  bool
+	// resume holds the vectors of an earlier run, and keeps new ones
+	resume *batchResume
+}
+
+// scanCSVColumn reads path once, calling each for every non-blank cell of
+// column, and returns the header and the number of rows after it
+func scanCSVColumn(path, column string, each func(string)) ([]string, int, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, 0, fmt.Errorf("failed to open %s: %w", path, err)
+	}
+	defer f.Close()
+
+	r, header, index, err := openCSVColumn(f, path, column)
+	if err != nil {
+		return nil, 0, err
+	}
+	rows := 0
+	for {
+		record, err := r.Read()
+		if err == io.EOF {
+			return header, rows, nil
+		}
+		if err != nil {
+			return nil, 0, fmt.Errorf("failed to read %s: %w", path, err)
+		}
+		rows++
+		if text := strings.TrimSpace(record[index]); text != "" {
+			each(text)
+		}
+	}
+}
+
+// openCSVColumn reads the header of a CSV file and finds column in it
+func openCSVColumn(f io.Reader, path, column string) (*csv.Reader, []string, int, error) {
+	r := csv.NewReader(bufio.NewReader(f))
+	header, err := r.Read()
+	if err == io.EOF {
+		return nil, nil, 0, fmt.Errorf("%s is empty", path)
+	}
+	if err != nil {
+		return nil, nil, 0, fmt.Errorf("failed to read %s: %w", path, err)
+	}
+	if len(header) > 0 {
+		// Spreadsheets often save a byte order mark before the first name
+		header[0] = strings.TrimPrefix(header[0], "\ufeff")
+	}
+	index := slices.Index(header, column)
+	if index < 0 {
+		return nil, nil, 0, fmt.Errorf("%s has no %q column • its columns are %s", path, column, strings.Join(header, ", "))
+	}
+	for i, name := range header {
+		if name == "" {
+			header[i] = "column_" + strconv.Itoa(i+1)
+		}
+		if slices.Index(header, header[i]) < i {
+			return nil, nil, 0, fmt.Errorf("%s has two columns named %q", path, header[i])
+		}
+	}
+	return r, header, index, nil
+}
+
+// embeddedRowWriter writes rows of the input with their vector appended; a
+// nil vector is a row whose text was blank
+type embeddedRowWriter interface {
+	write(record []string, vector []float64) error
+	close() error
+}
+
+// embedCSVColumn streams the rows of job.in, embedding job.column a batch at
+// a time, into a temporary file that replaces job.out once every row is
+// written. It returns how many texts were embedded.
+func embedCSVColumn(ctx context.Context, provider EmbeddingProvider, job csvEmbedJob) (int, error) {
+	in, err := os.Open(job.in)
+	if err != nil {
+		return 0, fmt.Errorf("failed to open %s: %w", job.in, err)
+	}
+	defer in.Close()
+	r, header, index, err := openCSVColumn(in, job.in, job.column)
+	if err != nil {
+		return 0, err
+	}
+
+	tmp, err := os.CreateTemp(filepath.Dir(job.out), ".embed-csv-*")
+	if err != nil {
+		return 0, fmt.Errorf("failed to create %s: %w", job.out, err)
+	}
+	defer os.Remove(tmp.Name())
+	defer tmp.Close()
+
+	var w embeddedRowWriter
+	if strings.EqualFold(filepath.Ext(job.out), ".parquet") {
+		w = newParquetRowWriter(tmp, header, job.as, job.model, job.single)
+	} else {
+		w, err = newCSVRowWriter(tmp, header, job.as)
+		if err != nil {
+			return 0, fmt.Errorf("failed to write %s: %w", job.out, err)
+		}
+	}
+
+	bar := newProgressBar()
+	bar.Width = 40
+	lastDrawn := time.Time{}
+	embedded := 0
+	draw := func() {
+		if embedded < job.texts && time.Since(lastDrawn) < 100*time.Millisecond {
+			return
+		}
+		lastDrawn = time.Now()
+		fmt.Fprintf(os.Stderr, "\r🧮 %s %d/%d texts", bar.ViewAs(float64(embedded)/float64(max(job.texts, 1))), embedded, job.texts)
+	}
+	draw()
+	defer fmt.Fprintln(os.Stderr)
+
+	// Rows wait here until the batch holding their text is embedded
+	var records [][]string
+	var texts []string
+	flush := func() error {
+		var vectors [][]float64
+		if len(texts) > 0 {
+			vectors, err = job.resume.embed(provider, embedded, texts)
+			if err != nil {
+				return fmt.Errorf("failed to embed rows of %s: %w", job.in, err)
+			}
+		}
+		next := 0
+		for _, record := range records {
+			var vector []float64
+			if strings.TrimSpace(record[index]) != "" {
+				vector = vectors[next]
+				next++
+			}
+			if err := w.write(record, vector); err != nil {
+				return fmt.Errorf("failed to write %s: %w", job.out, err)
+			}
+		}
+		embedded += len(texts)
+		records, texts = records[:0], texts[:0]
+		draw()
+		return nil
+	}
+
+	for {
+		if ctx.Err() != nil {
+			return embedded, ctx.Err()
+		}
+		record, err := r.Read()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return embedded, fmt.Errorf("failed to read %s: %w", job.in, err)
+		}
+		records = append(records, record)
+		if text := strings.TrimSpace(record[index]); text != "" {
+			texts = append(texts, text)
+		}
+		if len(texts) == job.batch || len(records) == parquetBatchSize {
+			if err := flush(); err != nil {
+				return embedded, err
+			}
+		}
+	}
+	if err := flush(); err != nil {
+		return embedded, err
+	}
+
+	if err := w.close(); err != nil {
+		return embedded, fmt.Errorf("failed to finish %s: %w", job.out, err)
+	}
+	if err := tmp.Close(); err != nil {
+		return embedded, fmt.Errorf("failed to write %s: %w", job.out, err)
+	}
+	if err := os.Rename(tmp.Name(), job.out); err != nil {
+		return embedded, fmt.Errorf("failed to write %s: %w", job.out, err)
+	}
+	return embedded, nil
+}
+
+// parquetRowWriter writes every input column as an optional string, since
+// CSV has no types, and the vectors as an optional LIST column. Parquet
+// orders the columns by name.
+type parquetRowWriter struct {
+	w      *parquet.Writer
+	header []string
+	as     string
+	single bool
+	row    map[string]any
+}
+
+func newParquetRowWriter(w io.Writer, header []string, as, model string, single bool) *parquetRowWriter {
+	element := parquet.Leaf(parquet.DoubleType)
+	if single {
+		element = parquet.Leaf(parquet.FloatType)
+	}
+	group := parquet.Group{as: parquet.Optional(parquet.List(element))}
+	for _, name := range header {
+		group[name] = parquet.Optional(parquet.String())
+	}
+	pw := parquet.NewWriter(w, parquet.NewSchema("row", group), parquet.Compression(&parquet.Snappy))
+	pw.SetKeyValueMetadata("ember.model", model)
+	return &parquetRowWriter{w: pw, header: header, as: as, single: single, row: make(map[string]any, len(header)+1)}
+}
+
+func (p *parquetRowWriter) write(record []string, vector []float64) error {
+	for i, name := range p.header {
+		if i < len(record) && record[i] != "" {
+			p.row[name] = record[i]
+		} else {
+			p.row[name] = nil
+		}
+	}
+	switch {
+	case vector == nil:
+		p.row[p.as] = nil
+	case p.single:
+		single := make([]float32, len(vector))
+		for i, v := range vector {
+			single[i] = float32(v)
+		}
+		p.row[p.as] = single
+	default:
+		p.row[p.as] = vector
+	}
+	return p.w.Write(p.row)
+}
+
+func (p *parquetRowWriter) close() error {
+	return p.w.Close()
+}
+
+// csvRowWriter keeps the input's column order and writes each vector as a
+// JSON array in the last column
+type csvRowWriter struct {
+	w *csv.Writer
+}
+
+func newCSVRowWriter(w io.Writer, header []string, as string) (*csvRowWriter, error) {
+	cw := csv.NewWriter(w)
+	if err := cw.Write(append(slices.Clone(header), as)); err != nil {
+		return nil, err
+	}
+	return &csvRowWriter{w: cw}, nil
+}
+
+func (c *csvRowWriter) write(record []string, vector []float64) error {
+	cell := ""
+	if vector != nil {
+		cell = "[" + formatVector(vector, false) + "]"
+	}
+	return c.w.Write(append(record, cell))
+}
+
+func (c *csvRowWriter) close() error {
+	c.w.Flush()
+	return c.w.Error()
+}
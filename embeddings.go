@@ -3,20 +3,80 @@ package main
 import (
 	"bytes"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"io"
 	"net/http"
+	"net/url"
 	"os"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
 )
 
-type EmbeddingsService struct {
-	apiKey string
-	client *http.Client
+const (
+	openAIDefaultModel   = "text-embedding-3-small"
+	openAIDefaultBaseURL = "https://api.openai.com/v1"
+)
+
+// OpenAIProvider embeds text with OpenAI's /v1/embeddings endpoint, or any
+// OpenAI-compatible server (vLLM, LiteLLM, LocalAI, llama.cpp) via EMBER_BASE_URL
+type OpenAIProvider struct {
+	keys    *KeyPool
+	client  *http.Client
+	model   string
+	baseURL string
+	// dimensions truncates text-embedding-3 vectors; 0 keeps the model's native size
+	dimensions int
+	// name is the provider recorded in the audit log, for the local servers
+	// that speak OpenAI's API; empty means openai
+	name string
+	// purpose is recorded in the audit log for this copy's calls
+	purpose string
+	// notices are the deprecations and quota warnings the API sent
+	notices *noticeLog
+}
+
+func init() {
+	RegisterProvider("openai", func() (EmbeddingProvider, error) {
+		return NewOpenAIProvider()
+	})
+	RegisterModelReader("openai", modelFromEnv("EMBER_OPENAI_MODEL", openAIDefaultModel))
+}
+
+// RateLimitError is returned when the provider rate-limits every available key
+type RateLimitError struct {
+	RetryAfter time.Duration
+}
+
+func (e *RateLimitError) Error() string {
+	return fmt.Sprintf("rate limited, retry after %s", e.RetryAfter.Round(time.Second))
+}
+
+// APIStatusError is returned when a provider answers with an unexpected HTTP status
+type APIStatusError struct {
+	StatusCode int
+	Body       string
+}
+
+func (e *APIStatusError) Error() string {
+	if e.Body == "" {
+		return fmt.Sprintf("API returned status %d", e.StatusCode)
+	}
+	return fmt.Sprintf("API error (status %d): %s", e.StatusCode, apiErrorMessage(e.Body))
 }
 
 type OpenAIEmbeddingRequest struct {
-	Input string `json:"input"`
-	Model string `json:"model"`
+	Input      []string `json:"input"`
+	Model      string   `json:"model"`
+	Dimensions int      `json:"dimensions,omitempty"`
+}
+
+type OpenAIModelsResponse struct {
+	Data []struct {
+		ID string `json:"id"`
+	} `json:"data"`
 }
 
 type OpenAIEmbeddingResponse struct {
@@ -33,67 +93,300 @@ type OpenAIEmbeddingResponse struct {
 	} `json:"usage"`
 }
 
-func NewEmbeddingsService() *EmbeddingsService {
-	apiKey := os.Getenv("OPENAI_API_KEY")
-	if apiKey == "" {
-		fmt.Println("Warning: OPENAI_API_KEY environment variable not set")
+func NewOpenAIProvider() (*OpenAIProvider, error) {
+	baseURL, err := loadBaseURL()
+	if err != nil {
+		return nil, err
+	}
+
+	keys := loadAPIKeys()
+	if len(keys) == 0 {
+		if baseURL == openAIDefaultBaseURL {
+			return nil, fmt.Errorf("OPENAI_API_KEY environment variable not set")
+		}
+		// Self-hosted servers often run without authentication
+		keys = []string{""}
+	}
+
+	model := os.Getenv("EMBER_OPENAI_MODEL")
+	if model == "" {
+		model = openAIDefaultModel
+	}
+
+	dimensions, err := loadDimensions()
+	if err != nil {
+		return nil, err
+	}
+	if dimensions > 0 && baseURL == openAIDefaultBaseURL && !supportsDimensions(model) {
+		return nil, fmt.Errorf("EMBER_DIMENSIONS requires a text-embedding-3 model, not %s", model)
+	}
+
+	return &OpenAIProvider{
+		keys:       NewKeyPool(keys, loadKeyRotation(), loadKeyQuota()),
+		client:     &http.Client{},
+		model:      model,
+		baseURL:    baseURL,
+		dimensions: dimensions,
+		notices:    newNoticeLog(),
+	}, nil
+}
+
+// loadBaseURL reads EMBER_BASE_URL and checks it is an absolute http(s) URL
+func loadBaseURL() (string, error) {
+	raw := os.Getenv("EMBER_BASE_URL")
+	if raw == "" {
+		return openAIDefaultBaseURL, nil
+	}
+
+	u, err := url.Parse(raw)
+	if err != nil {
+		return "", fmt.Errorf("invalid EMBER_BASE_URL %q: %w", raw, err)
+	}
+	if u.Scheme != "http" && u.Scheme != "https" {
+		return "", fmt.Errorf("invalid EMBER_BASE_URL %q: scheme must be http or https", raw)
+	}
+	if u.Host == "" {
+		return "", fmt.Errorf("invalid EMBER_BASE_URL %q: missing host", raw)
+	}
+
+	return strings.TrimRight(raw, "/"), nil
+}
+
+// loadDimensions reads EMBER_DIMENSIONS, returning 0 for the model's native size
+func loadDimensions() (int, error) {
+	raw := os.Getenv("EMBER_DIMENSIONS")
+	if raw == "" {
+		return 0, nil
+	}
+	n, err := strconv.Atoi(raw)
+	if err != nil || n < 1 {
+		return 0, fmt.Errorf("invalid EMBER_DIMENSIONS %q: must be a positive integer", raw)
+	}
+	return n, nil
+}
+
+// supportsDimensions reports whether OpenAI accepts the dimensions field for model
+func supportsDimensions(model string) bool {
+	return strings.HasPrefix(model, "text-embedding-3")
+}
+
+func (e *OpenAIProvider) ModelInfo() ModelInfo {
+	return ModelInfo{Provider: "openai", Model: e.model, Dimensions: e.dimensions}
+}
+
+// WithDimensions returns a copy of the provider that requests n-dimensional vectors
+func (e *OpenAIProvider) WithDimensions(n int) EmbeddingProvider {
+	copied := *e
+	copied.dimensions = n
+	return &copied
+}
+
+// WithPurpose returns a copy of the provider whose calls the audit log records as for purpose
+func (e *OpenAIProvider) WithPurpose(purpose string) EmbeddingProvider {
+	copied := *e
+	copied.purpose = purpose
+	return &copied
+}
+
+// auditInfo names the provider and model in the audit log
+func (e *OpenAIProvider) auditInfo(model string) ModelInfo {
+	name := e.name
+	if name == "" {
+		name = "openai"
+	}
+	return ModelInfo{Provider: name, Model: model}
+}
+
+// WithModel returns a copy of the provider that requests model, sharing its keys
+func (e *OpenAIProvider) WithModel(model string) EmbeddingProvider {
+	copied := *e
+	copied.model = model
+	return &copied
+}
+
+// ListModels fetches /models and keeps the embedding models. Self-hosted
+// servers often use names without "embed", so then every model is returned.
+func (e *OpenAIProvider) ListModels() ([]string, error) {
+	key, err := e.keys.Acquire()
+	if err != nil {
+		return nil, err
+	}
+
+	req, err := http.NewRequest("GET", e.baseURL+"/models", nil)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create request: %w", err)
+	}
+	if key.value != "" {
+		req.Header.Set("Authorization", "Bearer "+key.value)
 	}
 
-	return &EmbeddingsService{
-		apiKey: apiKey,
-		client: &http.Client{},
+	resp, err := e.client.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("failed to make request: %w", err)
 	}
+	defer resp.Body.Close()
+
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read response: %w", err)
+	}
+	e.notices.record(e.auditInfo("").Provider, resp, body)
+	if resp.StatusCode != http.StatusOK {
+		return nil, &APIStatusError{StatusCode: resp.StatusCode, Body: string(body)}
+	}
+
+	var modelsResp OpenAIModelsResponse
+	if err := json.Unmarshal(body, &modelsResp); err != nil {
+		return nil, fmt.Errorf("failed to unmarshal response: %w", err)
+	}
+
+	var all, embedding []string
+	for _, m := range modelsResp.Data {
+		all = append(all, m.ID)
+		if strings.Contains(m.ID, "embed") {
+			embedding = append(embedding, m.ID)
+		}
+	}
+	if len(embedding) == 0 {
+		embedding = all
+	}
+	sort.Strings(embedding)
+	return embedding, nil
 }
 
-func (e *EmbeddingsService) GenerateEmbedding(text string) ([]float64, error) {
-	if e.apiKey == "" {
-		fmt.Printf("Cannot generate embedding: API key not configured\n")
-		return nil, fmt.Errorf("API key not configured")
+func (e *OpenAIProvider) GenerateEmbedding(text string) ([]float64, error) {
+	embeddings, err := e.GenerateBatch([]string{text})
+	if err != nil {
+		return nil, err
 	}
+	return embeddings[0], nil
+}
+
+// GenerateBatch embeds texts in one request, rotating to another key when one is rate limited
+func (e *OpenAIProvider) GenerateBatch(texts []string) ([][]float64, error) {
+	var lastErr error
+	for attempt := 0; attempt < max(e.keys.Len(), 1); attempt++ {
+		key, err := e.keys.Acquire()
+		if err != nil {
+			var rateErr *RateLimitError
+			if !errors.As(err, &rateErr) {
+				fmt.Printf("Cannot generate embedding: %v\n", err)
+			}
+			return nil, err
+		}
 
+		embeddings, retry, err := e.generateWithKey(key, texts)
+		if !retry {
+			return embeddings, err
+		}
+		lastErr = err
+	}
+	return nil, lastErr
+}
+
+// generateWithKey makes a single request; retry reports whether another key should be tried
+func (e *OpenAIProvider) generateWithKey(key *apiKey, texts []string) (embeddings [][]float64, retry bool, err error) {
 	reqBody := OpenAIEmbeddingRequest{
-		Input: text,
-		Model: "text-embedding-3-small",
+		Input:      texts,
+		Model:      e.model,
+		Dimensions: e.dimensions,
 	}
 
 	jsonData, err := json.Marshal(reqBody)
 	if err != nil {
-		return nil, fmt.Errorf("failed to marshal request: %w", err)
+		return nil, false, fmt.Errorf("failed to marshal request: %w", err)
 	}
 
-	req, err := http.NewRequest("POST", "https://api.openai.com/v1/embeddings", bytes.NewBuffer(jsonData))
+	req, err := http.NewRequest("POST", e.baseURL+"/embeddings", bytes.NewBuffer(jsonData))
 	if err != nil {
-		return nil, fmt.Errorf("failed to create request: %w", err)
+		return nil, false, fmt.Errorf("failed to create request: %w", err)
 	}
 
 	req.Header.Set("Content-Type", "application/json")
-	req.Header.Set("Authorization", "Bearer "+e.apiKey)
+	if key.value != "" {
+		req.Header.Set("Authorization", "Bearer "+key.value)
+	}
+
+	tokens := 0
+	defer func() { auditCall(e.auditInfo(e.model), e.purpose, texts, tokens, err) }()
 
 	resp, err := e.client.Do(req)
 	if err != nil {
-		return nil, fmt.Errorf("failed to make request: %w", err)
+		e.keys.Record(key, 0, true)
+		return nil, false, fmt.Errorf("failed to make request: %w", err)
 	}
 	defer resp.Body.Close()
 
 	body, err := io.ReadAll(resp.Body)
 	if err != nil {
-		return nil, fmt.Errorf("failed to read response: %w", err)
+		return nil, false, fmt.Errorf("failed to read response: %w", err)
+	}
+	info := e.auditInfo(e.model)
+	e.notices.record(info.Provider+"/"+info.Model, resp, body)
+
+	if resp.StatusCode == http.StatusTooManyRequests && classifyNotice(apiErrorMessage(string(body)), "") == noticeQuota {
+		// Waiting won't help a key out of quota, but another key may have some left
+		e.keys.Record(key, 0, true)
+		return nil, true, &APIStatusError{StatusCode: resp.StatusCode, Body: string(body)}
+	}
+	if resp.StatusCode == http.StatusTooManyRequests {
+		e.keys.Record(key, 0, true)
+		wait := retryAfter(resp)
+		e.keys.Cooldown(key, wait)
+		if wait <= 0 {
+			wait = rateLimitCooldown
+		}
+		return nil, true, &RateLimitError{RetryAfter: wait}
 	}
 
 	if resp.StatusCode != http.StatusOK {
-		fmt.Printf("API error (status %d): %s\n", resp.StatusCode, string(body))
-		return nil, fmt.Errorf("API returned status %d", resp.StatusCode)
+		e.keys.Record(key, 0, true)
+		return nil, false, &APIStatusError{StatusCode: resp.StatusCode, Body: string(body)}
 	}
 
 	var embeddingResp OpenAIEmbeddingResponse
 	if err := json.Unmarshal(body, &embeddingResp); err != nil {
-		return nil, fmt.Errorf("failed to unmarshal response: %w", err)
+		return nil, false, fmt.Errorf("failed to unmarshal response: %w", err)
+	}
+
+	tokens = embeddingResp.Usage.TotalTokens
+	e.keys.Record(key, tokens, false)
+
+	if len(embeddingResp.Data) != len(texts) {
+		return nil, false, fmt.Errorf("expected %d embeddings, got %d", len(texts), len(embeddingResp.Data))
 	}
 
-	if len(embeddingResp.Data) > 0 {
-		embedding := embeddingResp.Data[0].Embedding
-		return embedding, nil
+	// Results carry their input index and are not guaranteed to be in order
+	embeddings = make([][]float64, len(texts))
+	for _, d := range embeddingResp.Data {
+		if d.Index < 0 || d.Index >= len(texts) {
+			return nil, false, fmt.Errorf("embedding index %d out of range", d.Index)
+		}
+		embeddings[d.Index] = d.Embedding
 	}
 
-	return nil, fmt.Errorf("no embedding data returned")
+	return embeddings, false, nil
+}
+
+// Notices returns the deprecations and quota warnings the API sent this session
+func (e *OpenAIProvider) Notices() []ProviderNotice {
+	return e.notices.list()
+}
+
+func (e *OpenAIProvider) DismissNotices() {
+	e.notices.dismiss()
+}
+
+// KeyUsage reports per-key request and token counts for this session
+func (e *OpenAIProvider) KeyUsage() []KeyUsage {
+	return e.keys.Usage()
+}
+
+// retryAfter reads the Retry-After header in seconds, returning 0 when absent
+func retryAfter(resp *http.Response) time.Duration {
+	seconds, err := strconv.Atoi(resp.Header.Get("Retry-After"))
+	if err != nil || seconds < 0 {
+		return 0
+	}
+	return time.Duration(seconds) * time.Second
 }
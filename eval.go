@@ -0,0 +1,513 @@
+package main
+
+import (
+	"encoding/csv"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/lipgloss"
+)
+
+// Test file the eval screen reads unless EMBER_EVAL_FILE says otherwise
+const defaultEvalFile = "ember-eval.csv"
+
+// Prefix of the CSV files W writes on the eval screen
+const defaultEvalReport = "ember-eval-report"
+
+// Most-confused label pairs listed
+const maxConfusedPairs = 5
+
+// Test texts embedded per request
+const evalBatch = 64
+
+// evalExample is one row of a labeled test file
+type evalExample struct {
+	Text  string
+	Label string
+}
+
+// labelMetrics is how well one label was predicted
+type labelMetrics struct {
+	Label     string  `json:"label"`
+	Precision float64 `json:"precision"`
+	Recall    float64 `json:"recall"`
+	F1        float64 `json:"f1"`
+	// Support is the number of test texts with this label
+	Support int `json:"support"`
+}
+
+// confusedPair is how often texts of one label were classified as another
+type confusedPair struct {
+	Actual    string `json:"actual"`
+	Predicted string `json:"predicted"`
+	Count     int    `json:"count"`
+}
+
+// evalReport is the result of classifying a test file by nearest label centroid
+type evalReport struct {
+	// Labels names the rows and columns of Matrix, the set's labels first
+	Labels []string
+	// Matrix counts test texts by actual label (row) and predicted label (column)
+	Matrix   [][]int
+	Metrics  []labelMetrics
+	Confused []confusedPair
+	Accuracy float64
+	MacroF1  float64
+	Total    int
+	// Unknown lists test labels the comparison set has no texts for
+	Unknown []string
+}
+
+type evalCompleteMsg struct {
+	report evalReport
+	path   string
+	err    error
+}
+
+// readEvalFile reads a CSV with a header naming a text and a label column.
+// Other columns are ignored.
+func readEvalFile(path string) ([]evalExample, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open %s: %w", path, err)
+	}
+	defer f.Close()
+
+	r := csv.NewReader(f)
+	r.FieldsPerRecord = -1
+	header, err := r.Read()
+	if err != nil {
+		return nil, fmt.Errorf("failed to read %s: %w", path, err)
+	}
+	textColumn, labelColumn := -1, -1
+	for i, name := range header {
+		switch strings.ToLower(strings.TrimSpace(name)) {
+		case "text":
+			textColumn = i
+		case "label":
+			labelColumn = i
+		}
+	}
+	if textColumn < 0 || labelColumn < 0 {
+		return nil, fmt.Errorf("%s needs a header with text and label columns", path)
+	}
+
+	var examples []evalExample
+	for {
+		record, err := r.Read()
+		if errors.Is(err, io.EOF) {
+			break
+		}
+		if err != nil {
+			return nil, fmt.Errorf("failed to read %s: %w", path, err)
+		}
+		if max(textColumn, labelColumn) >= len(record) {
+			continue
+		}
+		text, label := strings.TrimSpace(record[textColumn]), strings.TrimSpace(record[labelColumn])
+		if text != "" && label != "" {
+			examples = append(examples, evalExample{Text: text, Label: label})
+		}
+	}
+	if len(examples) == 0 {
+		return nil, fmt.Errorf("no labeled texts found in %s", path)
+	}
+	return examples, nil
+}
+
+// evalLabel matches a test label to a centroid's: "positive" and "#Positive" both mean #positive
+func evalLabel(label string) string {
+	return "#" + strings.ToLower(strings.TrimPrefix(strings.TrimSpace(label), "#"))
+}
+
+// evaluateClassifier assigns each test text the label whose centroid is most
+// similar and scores the predictions against the test labels
+func evaluateClassifier(anchors []CustomEmbedding, examples []evalExample, vectors [][]float64) (evalReport, error) {
+	centroids := labelCentroids(anchors)
+	if len(centroids) < 2 {
+		return evalReport{}, fmt.Errorf("the comparison set needs at least two #labels to classify")
+	}
+
+	var report evalReport
+	index := make(map[string]int)
+	for _, c := range centroids {
+		index[c.Label] = len(report.Labels)
+		report.Labels = append(report.Labels, c.Label)
+	}
+	for _, e := range examples {
+		label := evalLabel(e.Label)
+		if _, ok := index[label]; !ok {
+			index[label] = len(report.Labels)
+			report.Labels = append(report.Labels, label)
+			report.Unknown = append(report.Unknown, label)
+		}
+	}
+
+	report.Matrix = make([][]int, len(report.Labels))
+	for i := range report.Matrix {
+		report.Matrix[i] = make([]int, len(report.Labels))
+	}
+	correct := 0
+	for i, e := range examples {
+		best, bestScore := 0, -2.0
+		for c, centroid := range centroids {
+			if len(centroid.Embedding) != len(vectors[i]) {
+				continue
+			}
+			if score := cosineSimilarity(vectors[i], centroid.Embedding); score > bestScore {
+				best, bestScore = c, score
+			}
+		}
+		actual := index[evalLabel(e.Label)]
+		report.Matrix[actual][best]++
+		if actual == best {
+			correct++
+		}
+	}
+	report.Total = len(examples)
+	report.Accuracy = float64(correct) / float64(report.Total)
+
+	for i, label := range report.Labels {
+		predicted, support := 0, 0
+		for j := range report.Labels {
+			predicted += report.Matrix[j][i]
+			support += report.Matrix[i][j]
+		}
+		m := labelMetrics{Label: label, Support: support}
+		if tp := float64(report.Matrix[i][i]); tp > 0 {
+			m.Precision = tp / float64(predicted)
+			m.Recall = tp / float64(support)
+			m.F1 = 2 * m.Precision * m.Recall / (m.Precision + m.Recall)
+		}
+		report.Metrics = append(report.Metrics, m)
+		report.MacroF1 += m.F1 / float64(len(report.Labels))
+
+		for j, count := range report.Matrix[i] {
+			if j != i && count > 0 {
+				report.Confused = append(report.Confused, confusedPair{Actual: label, Predicted: report.Labels[j], Count: count})
+			}
+		}
+	}
+	sort.SliceStable(report.Confused, func(i, j int) bool { return report.Confused[i].Count > report.Confused[j].Count })
+	if len(report.Confused) > maxConfusedPairs {
+		report.Confused = report.Confused[:maxConfusedPairs]
+	}
+	return report, nil
+}
+
+// runEval embeds the test file's texts as queries and classifies them
+func runEval(provider EmbeddingProvider, anchors []CustomEmbedding, path string) (evalReport, error) {
+	examples, err := readEvalFile(path)
+	if err != nil {
+		return evalReport{}, err
+	}
+	texts := make([]string, len(examples))
+	for i, e := range examples {
+		texts[i] = e.Text
+	}
+	vectors, err := embedInBatches(provider, texts, "test texts")
+	if err != nil {
+		return evalReport{}, err
+	}
+	return evaluateClassifier(anchors, examples, vectors)
+}
+
+// embedInBatches embeds texts evalBatch at a time. Unlike generateBatched it
+// prints nothing, so it can run under the TUI.
+func embedInBatches(provider EmbeddingProvider, texts []string, what string) ([][]float64, error) {
+	var vectors [][]float64
+	for start := 0; start < len(texts); start += evalBatch {
+		end := min(start+evalBatch, len(texts))
+		batch, err := provider.GenerateBatch(texts[start:end])
+		if err != nil {
+			return nil, fmt.Errorf("failed to embed %s %d-%d: %w", what, start+1, end, err)
+		}
+		if len(batch) != end-start {
+			return nil, fmt.Errorf("expected %d embeddings for %s %d-%d, got %d", end-start, what, start+1, end, len(batch))
+		}
+		vectors = append(vectors, batch...)
+	}
+	return vectors, nil
+}
+
+// writeEvalCSV writes the confusion matrix, the per-label metrics and the
+// most-confused pairs to PREFIX-matrix.csv, PREFIX-metrics.csv and
+// PREFIX-confused.csv, returning their paths
+func writeEvalCSV(prefix string, report evalReport) ([]string, error) {
+	matrix := [][]string{append([]string{"actual \\ predicted"}, report.Labels...)}
+	for i, row := range report.Matrix {
+		record := []string{report.Labels[i]}
+		for _, count := range row {
+			record = append(record, strconv.Itoa(count))
+		}
+		matrix = append(matrix, record)
+	}
+
+	metrics := [][]string{{"label", "precision", "recall", "f1", "support"}}
+	for _, m := range report.Metrics {
+		metrics = append(metrics, []string{m.Label, formatMetric(m.Precision), formatMetric(m.Recall), formatMetric(m.F1), strconv.Itoa(m.Support)})
+	}
+
+	confused := [][]string{{"actual", "predicted", "count"}}
+	for _, p := range report.Confused {
+		confused = append(confused, []string{p.Actual, p.Predicted, strconv.Itoa(p.Count)})
+	}
+
+	var paths []string
+	for _, file := range []struct {
+		suffix  string
+		records [][]string
+	}{{"matrix", matrix}, {"metrics", metrics}, {"confused", confused}} {
+		path := prefix + "-" + file.suffix + ".csv"
+		f, err := os.Create(path)
+		if err != nil {
+			return paths, fmt.Errorf("failed to create %s: %w", path, err)
+		}
+		w := csv.NewWriter(f)
+		w.WriteAll(file.records)
+		f.Close()
+		if err := w.Error(); err != nil {
+			return paths, fmt.Errorf("failed to write %s: %w", path, err)
+		}
+		paths = append(paths, path)
+	}
+	return paths, nil
+}
+
+func formatMetric(v float64) string {
+	return strconv.FormatFloat(v, 'f', 4, 64)
+}
+
+// evalFile is the test file the eval screen reads
+func evalFile() string {
+	if path := os.Getenv("EMBER_EVAL_FILE"); path != "" {
+		return path
+	}
+	return defaultEvalFile
+}
+
+// startEval classifies the eval file against the comparison set's labels in the background
+func (m *model) startEval() tea.Cmd {
+	m.currentScreen = evalScreen
+	m.evalRunning = true
+	m.evalErr = nil
+	m.evalNotice = ""
+
+	path := evalFile()
+	anchors := m.customEmbeddings
+	provider := withPurpose(withInputType(m.provider, m.queryInputType()), "eval")
+	provider = m.withCache(lanedProvider{EmbeddingProvider: provider, scheduler: m.scheduler, lane: backgroundLane}, m.queryInputType())
+	return func() tea.Msg {
+		report, err := runEval(provider, anchors, path)
+		return evalCompleteMsg{report: report, path: path, err: err}
+	}
+}
+
+// writeEvalReport writes the report on the eval screen as CSV
+func (m *model) writeEvalReport() {
+	if m.evalRunning || m.evalErr != nil {
+		return
+	}
+	paths, err := writeEvalCSV(defaultEvalReport, m.evalReport)
+	if err != nil {
+		m.evalNotice = "⚠️  " + err.Error()
+		return
+	}
+	m.evalNotice = "💾 Wrote " + strings.Join(paths, ", ")
+}
+
+func (m model) renderEvalScreen() string {
+	s := "\033[2J\033[H" // Clear screen and move cursor to top
+
+	s += "╭─────────────────────────────────────────────────────────────────────────────╮\n"
+	s += "│                         🧪 CLASSIFICATION EVAL 🧪                           │\n"
+	s += "│           Test texts classified by their nearest label centroid             │\n"
+	s += "╰─────────────────────────────────────────────────────────────────────────────╯\n\n"
+
+	instructStyle := lipgloss.NewStyle().
+		Foreground(theme.Muted).
+		Italic(true)
+	warningStyle := lipgloss.NewStyle().
+		Foreground(theme.Warning)
+
+	switch {
+	case m.evalRunning:
+		return s + m.spinner.View() + " Classifying " + evalFile() + "...\n"
+	case m.evalErr != nil:
+		s += warningStyle.Render("⚠️  "+m.evalErr.Error()) + "\n\n"
+		s += instructStyle.Render(fmt.Sprintf("Put a CSV with text and label columns in %s, or set EMBER_EVAL_FILE • Esc to return", defaultEvalFile)) + "\n"
+		return s
+	}
+
+	s += renderEvalReport(m.evalReport, m.evalPath)
+	if m.evalNotice != "" {
+		s += "\n" + lipgloss.NewStyle().Foreground(theme.Accent).Render(m.evalNotice) + "\n"
+	}
+	s += "\n" + instructStyle.Render("W to write the report as CSV • R to run again • Esc to return") + "\n"
+	return s
+}
+
+// renderEvalReport draws the confusion matrix, the per-label metrics and the
+// most-confused pairs, for the eval screen and `ember eval`
+func renderEvalReport(report evalReport, path string) string {
+	headerStyle := lipgloss.NewStyle().
+		Foreground(theme.Primary).
+		Bold(true)
+	correctStyle := lipgloss.NewStyle().
+		Foreground(theme.Accent).
+		Bold(true)
+	mutedStyle := lipgloss.NewStyle().
+		Foreground(theme.Muted)
+	warningStyle := lipgloss.NewStyle().
+		Foreground(theme.Warning)
+
+	s := headerStyle.Render(fmt.Sprintf("📄 %s • %d texts • accuracy %.1f%% • macro F1 %.3f", filepath.Base(path), report.Total, 100*report.Accuracy, report.MacroF1)) + "\n"
+	if len(report.Unknown) > 0 {
+		s += warningStyle.Render(fmt.Sprintf("⚠️  No comparison texts are tagged %s, so those texts can't be classified correctly", strings.Join(report.Unknown, ", "))) + "\n"
+	}
+
+	s += "\n" + headerStyle.Render("Confusion matrix (rows: actual, columns: predicted)") + "\n"
+	s += fmt.Sprintf("%-14s", "")
+	for i := range report.Labels {
+		s += fmt.Sprintf("%6s", fmt.Sprintf("[%d]", i+1))
+	}
+	s += "\n"
+	for i, row := range report.Matrix {
+		s += fmt.Sprintf("[%d] %-10s", i+1, truncateText(report.Labels[i], 10))
+		for j, count := range row {
+			cell := fmt.Sprintf("%6d", count)
+			switch {
+			case i == j && count > 0:
+				cell = correctStyle.Render(cell)
+			case count == 0:
+				cell = mutedStyle.Render(fmt.Sprintf("%6s", "·"))
+			}
+			s += cell
+		}
+		s += "\n"
+	}
+
+	s += "\n" + headerStyle.Render(fmt.Sprintf("%-24s %9s %9s %9s %8s", "label", "precision", "recall", "F1", "support")) + "\n"
+	for _, m := range report.Metrics {
+		s += fmt.Sprintf("%-24s %9.3f %9.3f %9.3f %8d\n", truncateText(m.Label, 24), m.Precision, m.Recall, m.F1, m.Support)
+	}
+
+	s += "\n" + headerStyle.Render("🔀 Most confused") + "\n"
+	if len(report.Confused) == 0 {
+		s += mutedStyle.Render("   None — every text got its own label") + "\n"
+	}
+	for _, p := range report.Confused {
+		s += fmt.Sprintf("   %-20s → %-20s %s\n", truncateText(p.Actual, 20), truncateText(p.Predicted, 20), warningStyle.Render(fmt.Sprintf("%d texts", p.Count)))
+	}
+	return s
+}
+
+// runEvalCommand handles `ember eval [--set NAME] [--csv PREFIX] test.csv`
+func runEvalCommand(args []string) {
+	fs := flag.NewFlagSet("eval", flag.ExitOnError)
+	name := fs.String("set", "", "set whose #labels classify the texts (default: the set opened on start)")
+	prefix := fs.String("csv", "", "also write PREFIX-matrix.csv, PREFIX-metrics.csv and PREFIX-confused.csv")
+	dryRun := fs.Bool("dry-run", false, "report what would be embedded without calling the provider")
+	format := formatFlag(fs, "table")
+	fs.Parse(args)
+
+	if fs.NArg() != 1 {
+		fmt.Println("Usage: ember eval [--set NAME] [--csv PREFIX] [--format FORMAT] [--dry-run] test.csv")
+		os.Exit(2)
+	}
+	checkFormat(format)
+	path := fs.Arg(0)
+
+	dataDir, err := userDataDir()
+	if err != nil {
+		displayError(err)
+		os.Exit(1)
+	}
+	store, closeStore, err := openStore(dataDir)
+	if err != nil {
+		displayError(err)
+		os.Exit(1)
+	}
+	defer closeStore()
+
+	var set comparisonSet
+	if *name == "" {
+		set, err = openStartupSet(store, filepath.Join(dataDir, "sets"))
+	} else {
+		set, err = store.LoadSet(*name)
+	}
+	if err != nil {
+		displayError(err)
+		os.Exit(1)
+	}
+
+	provider := setupProvider()
+	defer closeProvider(provider)
+	info := provider.ModelInfo()
+	if set.Model.Provider != info.Provider || set.Model.Model != info.Model {
+		displayError(fmt.Errorf("set %q was embedded with %s/%s, not %s/%s", set.Name, set.Model.Provider, set.Model.Model, info.Provider, info.Model))
+		os.Exit(1)
+	}
+
+	queryType := ""
+	if typed, ok := provider.(inputTypeProvider); ok && len(typed.InputTypes()) > 0 {
+		queryType = queryInputTypeFor(typed.InputTypes()[0])
+	}
+	queries := withInputType(provider, queryType)
+	if cache := setupCache(); cache != nil {
+		defer cache.Close()
+		queries = &cachedProvider{EmbeddingProvider: queries, cache: cache, model: cacheModelKey(info, queryType)}
+	}
+	if *dryRun {
+		examples, err := readEvalFile(path)
+		if err != nil {
+			displayError(err)
+			os.Exit(1)
+		}
+		plan := newEmbeddingPlan(info)
+		for _, e := range examples {
+			plan.add(queries, e.Text)
+		}
+		fmt.Print(plan.render())
+		return
+	}
+	report, err := runEval(queries, set.Embeddings, path)
+	if err != nil {
+		displayError(err)
+		os.Exit(1)
+	}
+	if *format == "table" {
+		fmt.Print(renderEvalReport(report, path))
+	} else {
+		dimensions := 0
+		if len(set.Embeddings) > 0 {
+			dimensions = len(set.Embeddings[0].Embedding)
+		}
+		if err := newEvalOutput(info, dimensions, path, report).write(*format); err != nil {
+			displayError(err)
+			os.Exit(1)
+		}
+	}
+
+	if *prefix != "" {
+		paths, err := writeEvalCSV(*prefix, report)
+		if err != nil {
+			displayError(err)
+			os.Exit(1)
+		}
+		// Machine formats keep stdout to the report
+		if *format == "table" {
+			fmt.Printf("\n💾 Wrote %s\n", strings.Join(paths, ", "))
+		} else {
+			fmt.Fprintf(os.Stderr, "💾 Wrote %s\n", strings.Join(paths, ", "))
+		}
+	}
+}
@@ -0,0 +1,141 @@
+package main
+
+import (
+	"bufio"
+	"bytes"
+	"compress/gzip"
+	_ "embed"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+	"math"
+	"sync"
+)
+
+// The example set ships as a gzipped blob rather than Go source, which kept
+// growing the compile time and binary with every vector. Regenerate it with
+// ember generate --lang blob --in texts.txt --out assets/examples.bin.gz.
+//
+//go:embed assets/examples.bin.gz
+var examplesBlob []byte
+
+// embeddingBlobMagic starts every blob so a wrong file fails loudly
+const embeddingBlobMagic = "EMBR1"
+
+// staticExamples decodes the bundled examples the first time they're needed
+var staticExamples = sync.OnceValues(func() (comparisonSet, error) {
+	return decodeEmbeddingBlob(bytes.NewReader(examplesBlob))
+})
+
+// encodeEmbeddingBlob writes the model and the embeddings, gzipped. Vectors
+// keep their full float64 precision.
+//
+// Layout: magic, then uvarint-prefixed provider and model names, a uvarint
+// count and, for each embedding, its uvarint-prefixed text, a uvarint length
+// and that many little-endian float64s.
+func encodeEmbeddingBlob(w io.Writer, info ModelInfo, embeddings []CustomEmbedding) error {
+	gz := gzip.NewWriter(w)
+	buf := bufio.NewWriter(gz)
+
+	writeUvarint := func(v int) {
+		var b [binary.MaxVarintLen64]byte
+		buf.Write(b[:binary.PutUvarint(b[:], uint64(v))])
+	}
+	writeString := func(s string) {
+		writeUvarint(len(s))
+		buf.WriteString(s)
+	}
+
+	buf.WriteString(embeddingBlobMagic)
+	writeString(info.Provider)
+	writeString(info.Model)
+	writeUvarint(len(embeddings))
+	for _, e := range embeddings {
+		writeString(e.Text)
+		writeUvarint(len(e.Embedding))
+		var b [8]byte
+		for _, v := range e.Embedding {
+			binary.LittleEndian.PutUint64(b[:], math.Float64bits(v))
+			buf.Write(b[:])
+		}
+	}
+
+	if err := buf.Flush(); err != nil {
+		return fmt.Errorf("failed to write blob: %w", err)
+	}
+	if err := gz.Close(); err != nil {
+		return fmt.Errorf("failed to write blob: %w", err)
+	}
+	return nil
+}
+
+// decodeEmbeddingBlob reads a blob written by encodeEmbeddingBlob
+func decodeEmbeddingBlob(r io.Reader) (comparisonSet, error) {
+	var set comparisonSet
+
+	gz, err := gzip.NewReader(r)
+	if err != nil {
+		return set, fmt.Errorf("failed to open embedding blob: %w", err)
+	}
+	buf := bufio.NewReader(gz)
+
+	// Lengths are bounded so a corrupt blob can't ask for gigabytes
+	readLen := func(limit uint64) (int, error) {
+		n, err := binary.ReadUvarint(buf)
+		if err == nil && n > limit {
+			err = fmt.Errorf("length %d exceeds %d", n, limit)
+		}
+		return int(n), err
+	}
+	readString := func() (string, error) {
+		n, err := readLen(1 << 20)
+		if err != nil {
+			return "", err
+		}
+		b := make([]byte, n)
+		_, err = io.ReadFull(buf, b)
+		return string(b), err
+	}
+
+	magic := make([]byte, len(embeddingBlobMagic))
+	if _, err := io.ReadFull(buf, magic); err != nil || string(magic) != embeddingBlobMagic {
+		return set, errors.New("not an embedding blob")
+	}
+
+	fail := func(err error) (comparisonSet, error) {
+		return comparisonSet{}, fmt.Errorf("corrupt embedding blob: %w", err)
+	}
+	if set.Model.Provider, err = readString(); err != nil {
+		return fail(err)
+	}
+	if set.Model.Model, err = readString(); err != nil {
+		return fail(err)
+	}
+	count, err := readLen(1 << 20)
+	if err != nil {
+		return fail(err)
+	}
+
+	set.Embeddings = make([]CustomEmbedding, count)
+	for i := range set.Embeddings {
+		e := &set.Embeddings[i]
+		if e.Text, err = readString(); err != nil {
+			return fail(err)
+		}
+		dims, err := readLen(1 << 16)
+		if err != nil {
+			return fail(err)
+		}
+		raw := make([]byte, dims*8)
+		if _, err := io.ReadFull(buf, raw); err != nil {
+			return fail(err)
+		}
+		e.Embedding = make([]float64, dims)
+		for j := range e.Embedding {
+			e.Embedding[j] = math.Float64frombits(binary.LittleEndian.Uint64(raw[j*8:]))
+		}
+		set.Model.Dimensions = dims
+	}
+	return set, nil
+}
@@ -0,0 +1,168 @@
+package main
+
+import (
+	"fmt"
+	"math"
+	"sort"
+
+	"github.com/charmbracelet/lipgloss"
+)
+
+// Number of dimensions listed in the overlap report
+const explainTopDimensions = 10
+
+// dimensionContribution is one dimension's share of a cosine similarity
+type dimensionContribution struct {
+	Dim          int
+	Input        float64
+	Comparison   float64
+	Contribution float64
+	// Anchor is the other comparison text that points furthest along this dimension
+	Anchor string
+}
+
+// explainSimilarity splits the cosine similarity of a and b into per-dimension
+// terms a[d]*b[d]/(|a||b|), which sum to the score, and returns the largest
+func explainSimilarity(a, b []float64, anchors []CustomEmbedding, n int) []dimensionContribution {
+	if len(a) != len(b) {
+		return nil
+	}
+
+	normA, normB := vectorNorm(a), vectorNorm(b)
+	if normA == 0 || normB == 0 {
+		return nil
+	}
+
+	contributions := make([]dimensionContribution, len(a))
+	for d := range a {
+		contributions[d] = dimensionContribution{
+			Dim:          d,
+			Input:        a[d] / normA,
+			Comparison:   b[d] / normB,
+			Contribution: a[d] * b[d] / (normA * normB),
+		}
+	}
+
+	sort.Slice(contributions, func(i, j int) bool {
+		return math.Abs(contributions[i].Contribution) > math.Abs(contributions[j].Contribution)
+	})
+	if len(contributions) > n {
+		contributions = contributions[:n]
+	}
+
+	for i := range contributions {
+		contributions[i].Anchor = nearestAnchor(contributions[i], anchors)
+	}
+	return contributions
+}
+
+// nearestAnchor finds the anchor with the largest normalized value in the
+// direction the input takes along the dimension
+func nearestAnchor(c dimensionContribution, anchors []CustomEmbedding) string {
+	direction := 1.0
+	if c.Input < 0 {
+		direction = -1
+	}
+
+	best, bestValue := "", 0.0
+	for _, anchor := range anchors {
+		if c.Dim >= len(anchor.Embedding) {
+			continue
+		}
+		norm := vectorNorm(anchor.Embedding)
+		if norm == 0 {
+			continue
+		}
+		if value := direction * anchor.Embedding[c.Dim] / norm; value > bestValue {
+			best, bestValue = anchor.Text, value
+		}
+	}
+	return best
+}
+
+func vectorNorm(v []float64) float64 {
+	var sum float64
+	for _, x := range v {
+		sum += x * x
+	}
+	return math.Sqrt(sum)
+}
+
+func (m model) renderExplainScreen() string {
+	s := "\033[2J\033[H" // Clear screen and move cursor to top
+
+	s += "╭─────────────────────────────────────────────────────────────────────────────╮\n"
+	s += "│                          🔬 DIMENSION OVERLAP 🔬                            │\n"
+	s += "╰─────────────────────────────────────────────────────────────────────────────╯\n\n"
+
+	instructStyle := lipgloss.NewStyle().
+		Foreground(theme.Muted).
+		Italic(true)
+
+	result, ok := m.resultAt(m.selectedResult)
+	if !ok || m.selectedResult >= len(m.comparedEmbeddings) {
+		return s + instructStyle.Render("Nothing to explain • Esc to return") + "\n"
+	}
+
+	compared := m.comparedEmbeddings[m.selectedResult]
+
+	s += fmt.Sprintf("Input:      %s\n", userInputStyle.Render(truncateText(m.lastInput, 60)))
+	s += fmt.Sprintf("Comparison: %s\n", staticTextStyle.Render(truncateText(compared.Text, 60)))
+	s += fmt.Sprintf("Cosine:     %.3f over %d dimensions\n\n", cosineSimilarity(m.lastEmbedding, compared.Embedding), len(compared.Embedding))
+	if len(result.Adjustments) > 0 {
+		s += renderAdjustments(result) + "\n\n"
+	}
+
+	// Other comparison texts act as the vocabulary for naming directions
+	var anchors []CustomEmbedding
+	for i, e := range m.comparedEmbeddings {
+		if i != m.selectedResult {
+			anchors = append(anchors, e)
+		}
+	}
+
+	contributions := explainSimilarity(m.lastEmbedding, compared.Embedding, anchors, explainTopDimensions)
+	score := cosineSimilarity(m.lastEmbedding, compared.Embedding)
+
+	headerStyle := lipgloss.NewStyle().
+		Foreground(theme.Primary).
+		Bold(true)
+	s += headerStyle.Render(fmt.Sprintf("%6s %9s %9s %9s %7s   %s", "dim", "input", "compare", "contrib", "share", "nearest anchor")) + "\n"
+
+	var covered float64
+	for _, c := range contributions {
+		share := ""
+		if score != 0 {
+			share = fmt.Sprintf("%6.1f%%", 100*c.Contribution/score)
+		}
+		covered += c.Contribution
+
+		anchor := "—"
+		if c.Anchor != "" {
+			anchor = truncateText(c.Anchor, 28)
+		}
+
+		line := fmt.Sprintf("%6d %+9.4f %+9.4f %+9.4f %7s   %s", c.Dim, c.Input, c.Comparison, c.Contribution, share, anchor)
+		if c.Contribution < 0 {
+			line = lipgloss.NewStyle().Foreground(theme.Warning).Render(line)
+		}
+		s += line + "\n"
+	}
+
+	s += "\n" + instructStyle.Render(fmt.Sprintf("Top %d dimensions contribute %.3f of %.3f • negative terms pull the score down", len(contributions), covered, score)) + "\n"
+	if len(anchors) == 0 {
+		s += instructStyle.Render("Add more comparison texts to name dimensions by their nearest anchor") + "\n"
+	}
+	s += instructStyle.Render("Press Enter or Esc to return to results") + "\n"
+
+	return s
+}
+
+// truncateText shortens text to n runes for single-line display
+func truncateText(text string, n int) string {
+	runes := []rune(text)
+	if len(runes) <= n {
+		return text
+	}
+	return string(runes[:n-1]) + "…"
+}
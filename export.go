@@ -0,0 +1,155 @@
+package main
+
+import (
+	"bufio"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// File the TUI exports embeddings to, in the working directory
+const exportPath = "ember-embeddings.jsonl"
+
+// exportRecord is one line of a JSONL export
+type exportRecord struct {
+	Text      string    `json:"text"`
+	Model     string    `json:"model"`
+	Kind      string    `json:"kind"`
+	Embedding []float64 `json:"embedding"`
+}
+
+// writeJSONL writes one JSON object per line, which pandas and most
+// embedding tools read directly
+func writeJSONL(w io.Writer, records []exportRecord) error {
+	buf := bufio.NewWriter(w)
+	enc := json.NewEncoder(buf)
+	for _, record := range records {
+		if err := enc.Encode(record); err != nil {
+			return fmt.Errorf("failed to encode %q: %w", truncateText(record.Text, 30), err)
+		}
+	}
+	return buf.Flush()
+}
+
+// exportRecords collects the comparison set and this session's inputs
+func (m model) exportRecords() []exportRecord {
+	info := m.provider.ModelInfo()
+	name := info.Provider + "/" + info.Model
+
+	records := make([]exportRecord, 0, len(m.customEmbeddings)+len(m.inputHistory))
+	for _, e := range m.customEmbeddings {
+		records = append(records, exportRecord{Text: e.Text, Model: name, Kind: "comparison", Embedding: e.Embedding})
+	}
+	for _, e := range m.inputHistory {
+		records = append(records, exportRecord{Text: e.Text, Model: name, Kind: "input", Embedding: e.Embedding})
+	}
+	return records
+}
+
+// exportEmbeddings writes the comparison set and recent inputs to path
+func (m *model) exportEmbeddings(path string) {
+	records := m.exportRecords()
+	if len(records) == 0 {
+		m.modelNotice = "⚠️  Nothing to export yet"
+		return
+	}
+
+	f, err := os.Create(path)
+	if err != nil {
+		m.modelNotice = fmt.Sprintf("⚠️  failed to create %s: %v", path, err)
+		return
+	}
+	defer f.Close()
+
+	if err := writeJSONL(f, records); err != nil {
+		m.modelNotice = fmt.Sprintf("⚠️  failed to write %s: %v", path, err)
+		return
+	}
+	m.modelNotice = fmt.Sprintf("💾 Wrote %d embeddings to %s", len(records), path)
+}
+
+// runExportCommand writes a saved comparison set, the one used last by
+// default, as JSONL, Parquet or a NumPy array with a texts sidecar
+func runExportCommand(args []string) {
+	fs := flag.NewFlagSet("export", flag.ExitOnError)
+	name := fs.String("set", "", "comparison set to export (default: the one used last)")
+	f32 := fs.Bool("float32", false, "write float32 vectors to .parquet, .npy and .npz files")
+	fs.Parse(args)
+
+	if fs.NArg() != 1 {
+		fmt.Println("Usage: ember export [--set NAME] [--float32] FILE.jsonl|FILE.parquet|FILE.npy|FILE.npz")
+		os.Exit(2)
+	}
+	path := fs.Arg(0)
+
+	dataDir, err := userDataDir()
+	if err != nil {
+		displayError(err)
+		os.Exit(1)
+	}
+	store, closeStore, err := openStore(dataDir)
+	if err != nil {
+		displayError(err)
+		os.Exit(1)
+	}
+	defer closeStore()
+
+	var set comparisonSet
+	if *name == "" {
+		if set, err = openStartupSet(store, filepath.Join(dataDir, "sets")); err != nil {
+			displayError(err)
+			os.Exit(1)
+		}
+	} else if set, err = store.LoadSet(*name); err != nil {
+		displayError(err)
+		os.Exit(1)
+	}
+	if len(set.Embeddings) == 0 {
+		displayError(fmt.Errorf("set %q has no embeddings", set.Name))
+		os.Exit(1)
+	}
+
+	switch strings.ToLower(filepath.Ext(path)) {
+	case ".parquet":
+		err = writeParquetFile(path, set, *f32)
+		if err == nil {
+			fmt.Printf("💾 Wrote %d embeddings from %q to %s\n", len(set.Embeddings), set.Name, path)
+		}
+	case ".npy", ".npz":
+		err = writeNumpyFile(path, set.Embeddings, *f32)
+		if err == nil {
+			fmt.Printf("💾 Wrote %d vectors from %q to %s and their texts to %s\n", len(set.Embeddings), set.Name, path, textsSidecar(path))
+		}
+	default:
+		err = writeSetJSONL(path, set)
+		if err == nil {
+			fmt.Printf("💾 Wrote %d embeddings from %q to %s\n", len(set.Embeddings), set.Name, path)
+		}
+	}
+	if err != nil {
+		displayError(err)
+		os.Exit(1)
+	}
+}
+
+func writeSetJSONL(path string, set comparisonSet) error {
+	model := set.Model.Provider + "/" + set.Model.Model
+	records := make([]exportRecord, len(set.Embeddings))
+	for i, e := range set.Embeddings {
+		records[i] = exportRecord{Text: e.Text, Model: model, Kind: "comparison", Embedding: e.Embedding}
+	}
+
+	f, err := os.Create(path)
+	if err != nil {
+		return fmt.Errorf("failed to create %s: %w", path, err)
+	}
+	defer f.Close()
+	if err := writeJSONL(f, records); err != nil {
+		return err
+	}
+	return f.Close()
+}
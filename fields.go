@@ -0,0 +1,153 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"strconv"
+	"strings"
+
+	"github.com/charmbracelet/lipgloss"
+)
+
+// The field holding every line that isn't labelled with another field
+const bodyField = "body"
+
+// fieldWeight is how much one field of a structured document counts
+type fieldWeight struct {
+	Name   string
+	Weight float64
+}
+
+// fieldScore is one field's part in a result's score
+type fieldScore struct {
+	Name   string
+	Score  float64
+	Weight float64
+}
+
+// loadFieldWeights reads EMBER_FIELD_WEIGHTS, e.g. "title=0.3,summary=0.2,body=0.5".
+// Only the listed fields are scored; it returns nil when multi-vector scoring is off.
+func loadFieldWeights() ([]fieldWeight, error) {
+	spec := os.Getenv("EMBER_FIELD_WEIGHTS")
+	if spec == "" {
+		return nil, nil
+	}
+
+	var weights []fieldWeight
+	for _, pair := range strings.Split(spec, ",") {
+		name, value, ok := strings.Cut(pair, "=")
+		name = strings.ToLower(strings.TrimSpace(name))
+		weight, err := strconv.ParseFloat(strings.TrimSpace(value), 64)
+		if !ok || name == "" || err != nil || weight < 0 {
+			return nil, fmt.Errorf("invalid EMBER_FIELD_WEIGHTS entry %q: use field=weight", pair)
+		}
+		weights = append(weights, fieldWeight{Name: name, Weight: weight})
+	}
+	return weights, nil
+}
+
+// splitFields picks the fields out of a structured document: lines starting
+// with "Title:" and the like, for each field in weights, and everything else
+// as the body. Texts with no labelled lines return nil and are scored whole.
+func splitFields(text string, weights []fieldWeight) map[string]string {
+	fields := make(map[string]string)
+	var body []string
+	labelled := false
+
+	for _, line := range strings.Split(text, "\n") {
+		label, value, ok := strings.Cut(line, ":")
+		name := strings.ToLower(strings.TrimSpace(label))
+		if ok && name != bodyField && hasField(weights, name) {
+			fields[name] = strings.TrimSpace(fields[name] + " " + strings.TrimSpace(value))
+			labelled = true
+			continue
+		}
+		body = append(body, line)
+	}
+	if !labelled {
+		return nil
+	}
+
+	if b := strings.TrimSpace(strings.Join(body, "\n")); b != "" && hasField(weights, bodyField) {
+		fields[bodyField] = b
+	}
+	for name, value := range fields {
+		if value == "" {
+			delete(fields, name)
+		}
+	}
+	return fields
+}
+
+func hasField(weights []fieldWeight, name string) bool {
+	for _, w := range weights {
+		if w.Name == name {
+			return true
+		}
+	}
+	return false
+}
+
+// embedFields embeds each field of text separately, in one batch
+func embedFields(provider EmbeddingProvider, weights []fieldWeight, text string) (map[string][]float64, error) {
+	if len(weights) == 0 {
+		return nil, nil
+	}
+	fields := splitFields(text, weights)
+	if len(fields) == 0 {
+		return nil, nil
+	}
+
+	var names, values []string
+	for _, w := range weights {
+		if value, ok := fields[w.Name]; ok {
+			names = append(names, w.Name)
+			values = append(values, value)
+		}
+	}
+	vectors, err := provider.GenerateBatch(values)
+	if err != nil {
+		return nil, fmt.Errorf("failed to embed fields: %w", err)
+	}
+
+	embedded := make(map[string][]float64, len(names))
+	for i, name := range names {
+		embedded[name] = vectors[i]
+	}
+	return embedded, nil
+}
+
+// applyFieldWeights scores a comparison that has field vectors by the
+// weighted mean of the input's similarity to each field. Weights of fields a
+// document lacks are left out, so a missing summary doesn't pull its score down.
+func applyFieldWeights(weights []fieldWeight, input []float64, e CustomEmbedding, result *SimilarityResult) {
+	if len(e.Fields) == 0 {
+		return
+	}
+
+	var scores []fieldScore
+	var sum, total float64
+	for _, w := range weights {
+		vector, ok := e.Fields[w.Name]
+		if !ok || len(vector) != len(input) {
+			continue
+		}
+		score := cosineSimilarity(input, vector)
+		scores = append(scores, fieldScore{Name: w.Name, Score: score, Weight: w.Weight})
+		sum += w.Weight * score
+		total += w.Weight
+	}
+	if total > 0 {
+		result.Similarity = sum / total
+		result.Fields = scores
+	}
+}
+
+// renderFieldScores shows each field's similarity and weight behind a score
+func renderFieldScores(result SimilarityResult) string {
+	parts := make([]string, len(result.Fields))
+	for i, f := range result.Fields {
+		parts[i] = fmt.Sprintf("%s %.3f ×%.2g", f.Name, f.Score, f.Weight)
+	}
+	return lipgloss.NewStyle().Foreground(theme.Muted).Render("↳ " + strings.Join(parts, " • "))
+}
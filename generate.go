@@ -0,0 +1,293 @@
+package main
+
+import (
+	"bufio"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"strconv"
+	"strings"
+)
+
+// Texts sent to the provider per request when generating
+const defaultGenerateBatch = 64
+
+// generateOptions controls how generated embeddings are written out
+type generateOptions struct {
+	lang    string
+	float32 bool
+}
+
+// runGenerateCommand embeds every line of a text file with the configured
+// provider and writes the vectors as source code, JSONL or an embedding blob.
+// It replaces the old static generator script, which only knew the two demo
+// strings.
+func runGenerateCommand(args []string) {
+	fs := flag.NewFlagSet("generate", flag.ExitOnError)
+	in := fs.String("in", "", "file with one text per line, or - for stdin")
+	out := fs.String("out", "", "file to write (default: stdout)")
+	lang := fs.String("lang", "go", "output format: go, python, ts, json or blob")
+	batch := fs.Int("batch", defaultGenerateBatch, "texts per API request")
+	f32 := fs.Bool("float32", false, "write float32 values")
+	yes := fs.Bool("yes", false, "start without previewing a sample first")
+	dryRun := fs.Bool("dry-run", false, "report what would be embedded without calling the provider")
+	fs.Parse(args)
+
+	if *in == "" {
+		fmt.Fprintln(os.Stderr, "Usage: ember generate --in texts.txt [--lang go|python|ts|json|blob] [--out FILE] [--batch N] [--float32] [--yes] [--dry-run]")
+		os.Exit(2)
+	}
+	opts := generateOptions{lang: *lang, float32: *f32}
+	if _, ok := generateWriters[opts.lang]; !ok {
+		displayError(fmt.Errorf("unknown language %q (available: go, python, ts, json, blob)", opts.lang))
+		os.Exit(2)
+	}
+	if *batch < 1 {
+		displayError(fmt.Errorf("--batch must be at least 1"))
+		os.Exit(2)
+	}
+
+	texts, err := readTextLines(*in)
+	if err != nil {
+		displayError(err)
+		os.Exit(1)
+	}
+	if len(texts) == 0 {
+		displayError(fmt.Errorf("no texts found in %s", *in))
+		os.Exit(1)
+	}
+
+	var provider EmbeddingProvider = setupProvider()
+	defer closeProvider(provider)
+	if cache := setupCache(); cache != nil {
+		defer cache.Close()
+		provider = &cachedProvider{EmbeddingProvider: provider, cache: cache, model: cacheModelKey(provider.ModelInfo(), "")}
+	}
+
+	if *dryRun {
+		plan := newEmbeddingPlan(provider.ModelInfo())
+		plan.add(provider, texts...)
+		fmt.Fprint(os.Stderr, plan.render())
+		return
+	}
+	if !*yes {
+		sampler := newCorpusSampler()
+		for _, text := range texts {
+			sampler.add(text)
+		}
+		ok, err := confirmBatchJob(provider, sampler)
+		if err != nil {
+			displayError(err)
+			os.Exit(1)
+		}
+		if !ok {
+			return
+		}
+	}
+
+	// The output and its format don't change the vectors, so any run over
+	// the same texts with the same model can pick up the progress
+	resume, err := openBatchResume("generate", *in, cacheModelKey(provider.ModelInfo(), ""))
+	if err != nil {
+		displayError(err)
+		os.Exit(1)
+	}
+	resume.noteResumed(len(texts), "embedded")
+	embeddings, err := generateBatched(provider, texts, *batch, resume)
+	if err != nil {
+		resume.close()
+		displayError(err)
+		if resume.done > 0 {
+			fmt.Fprintf(os.Stderr, "%d of %d texts were saved • run the same command again to resume\n", resume.done, len(texts))
+		}
+		os.Exit(1)
+	}
+	defer resume.finish()
+
+	w := io.Writer(os.Stdout)
+	if *out != "" {
+		f, err := os.Create(*out)
+		if err != nil {
+			displayError(fmt.Errorf("failed to create %s: %w", *out, err))
+			os.Exit(1)
+		}
+		defer f.Close()
+		w = f
+	}
+
+	buf := bufio.NewWriter(w)
+	if err := generateWriters[opts.lang](buf, provider.ModelInfo(), embeddings, opts); err != nil {
+		displayError(err)
+		os.Exit(1)
+	}
+	if err := buf.Flush(); err != nil {
+		displayError(fmt.Errorf("failed to write output: %w", err))
+		os.Exit(1)
+	}
+}
+
+// readTextLines returns the non-blank lines of path, or of stdin when path is -
+func readTextLines(path string) ([]string, error) {
+	r := io.Reader(os.Stdin)
+	if path != "-" {
+		f, err := os.Open(path)
+		if err != nil {
+			return nil, fmt.Errorf("failed to open %s: %w", path, err)
+		}
+		defer f.Close()
+		r = f
+	}
+
+	var texts []string
+	scanner := bufio.NewScanner(r)
+	scanner.Buffer(make([]byte, 64*1024), 1024*1024)
+	for scanner.Scan() {
+		if text := strings.TrimSpace(scanner.Text()); text != "" {
+			texts = append(texts, text)
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, fmt.Errorf("failed to read %s: %w", path, err)
+	}
+	return texts, nil
+}
+
+// generateBatched embeds texts size at a time, reporting progress on stderr.
+// With resume, texts an earlier run embedded aren't sent again.
+func generateBatched(provider EmbeddingProvider, texts []string, size int, resume *batchResume) ([]CustomEmbedding, error) {
+	embeddings := make([]CustomEmbedding, 0, len(texts))
+	for start := 0; start < len(texts); start += size {
+		end := min(start+size, len(texts))
+		var vectors [][]float64
+		var err error
+		if resume != nil {
+			vectors, err = resume.embed(provider, start, texts[start:end])
+		} else {
+			vectors, err = provider.GenerateBatch(texts[start:end])
+		}
+		if err != nil {
+			return nil, fmt.Errorf("failed to embed texts %d-%d: %w", start+1, end, err)
+		}
+		if len(vectors) != end-start {
+			return nil, fmt.Errorf("expected %d embeddings for texts %d-%d, got %d", end-start, start+1, end, len(vectors))
+		}
+		for i, vector := range vectors {
+			embeddings = append(embeddings, CustomEmbedding{Text: texts[start+i], Embedding: vector})
+		}
+		fmt.Fprintf(os.Stderr, "🧮 %d/%d embedded\n", end, len(texts))
+	}
+	return embeddings, nil
+}
+
+type generateWriter func(w io.Writer, info ModelInfo, embeddings []CustomEmbedding, opts generateOptions) error
+
+var generateWriters = map[string]generateWriter{
+	"go":     writeGoEmbeddings,
+	"python": writePythonEmbeddings,
+	"ts":     writeTSEmbeddings,
+	"json":   writeJSONEmbeddings,
+	"blob":   writeBlobEmbeddings,
+}
+
+// formatVector writes values separated by ", ", as float32 when asked. The
+// shortest representation that round-trips is used so nothing is lost.
+func formatVector(values []float64, float32 bool) string {
+	bits := 64
+	if float32 {
+		bits = 32
+	}
+	var b strings.Builder
+	for i, v := range values {
+		if i > 0 {
+			b.WriteString(", ")
+		}
+		b.WriteString(strconv.FormatFloat(v, 'g', -1, bits))
+	}
+	return b.String()
+}
+
+func writeGoEmbeddings(w io.Writer, info ModelInfo, embeddings []CustomEmbedding, opts generateOptions) error {
+	elem := "float64"
+	if opts.float32 {
+		elem = "float32"
+	}
+
+	fmt.Fprintf(w, "// Generated by ember with %s/%s\n", info.Provider, info.Model)
+	fmt.Fprintln(w, "package main")
+	fmt.Fprintln(w)
+	fmt.Fprintln(w, "var embeddings = []struct {")
+	fmt.Fprintln(w, "\tText      string")
+	fmt.Fprintf(w, "\tEmbedding []%s\n", elem)
+	fmt.Fprintln(w, "}{")
+	for _, e := range embeddings {
+		fmt.Fprintln(w, "\t{")
+		fmt.Fprintf(w, "\t\tText:      %q,\n", e.Text)
+		fmt.Fprintf(w, "\t\tEmbedding: []%s{%s},\n", elem, formatVector(e.Embedding, opts.float32))
+		fmt.Fprintln(w, "\t},")
+	}
+	_, err := fmt.Fprintln(w, "}")
+	return err
+}
+
+func writePythonEmbeddings(w io.Writer, info ModelInfo, embeddings []CustomEmbedding, opts generateOptions) error {
+	fmt.Fprintf(w, "# Generated by ember with %s/%s\n", info.Provider, info.Model)
+	if opts.float32 {
+		fmt.Fprintln(w, "from array import array")
+	}
+	fmt.Fprintln(w)
+	fmt.Fprintln(w, "EMBEDDINGS = [")
+	for _, e := range embeddings {
+		text, _ := json.Marshal(e.Text)
+		vector := "[" + formatVector(e.Embedding, opts.float32) + "]"
+		if opts.float32 {
+			vector = `array("f", ` + vector + ")"
+		}
+		fmt.Fprintf(w, "    {\"text\": %s, \"embedding\": %s},\n", text, vector)
+	}
+	_, err := fmt.Fprintln(w, "]")
+	return err
+}
+
+func writeTSEmbeddings(w io.Writer, info ModelInfo, embeddings []CustomEmbedding, opts generateOptions) error {
+	elem := "number[]"
+	if opts.float32 {
+		elem = "Float32Array"
+	}
+
+	fmt.Fprintf(w, "// Generated by ember with %s/%s\n\n", info.Provider, info.Model)
+	fmt.Fprintf(w, "export const embeddings: { text: string; embedding: %s }[] = [\n", elem)
+	for _, e := range embeddings {
+		text, _ := json.Marshal(e.Text)
+		vector := "[" + formatVector(e.Embedding, opts.float32) + "]"
+		if opts.float32 {
+			vector = "new Float32Array(" + vector + ")"
+		}
+		fmt.Fprintf(w, "  { text: %s, embedding: %s },\n", text, vector)
+	}
+	_, err := fmt.Fprintln(w, "];")
+	return err
+}
+
+// writeBlobEmbeddings writes the format the bundled example set is stored in
+func writeBlobEmbeddings(w io.Writer, info ModelInfo, embeddings []CustomEmbedding, opts generateOptions) error {
+	return encodeEmbeddingBlob(w, info, embeddings)
+}
+
+// writeJSONEmbeddings writes the export format, which ember import reads back
+func writeJSONEmbeddings(w io.Writer, info ModelInfo, embeddings []CustomEmbedding, opts generateOptions) error {
+	model := info.Provider + "/" + info.Model
+	records := make([]exportRecord, len(embeddings))
+	for i, e := range embeddings {
+		vector := e.Embedding
+		if opts.float32 {
+			vector = make([]float64, len(e.Embedding))
+			for j, v := range e.Embedding {
+				vector[j] = float64(float32(v))
+			}
+		}
+		records[i] = exportRecord{Text: e.Text, Model: model, Kind: "comparison", Embedding: vector}
+	}
+	return writeJSONL(w, records)
+}
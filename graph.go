@@ -0,0 +1,395 @@
+package main
+
+import (
+	"encoding/xml"
+	"fmt"
+	"io"
+	"os"
+	"slices"
+	"sort"
+	"strconv"
+	"strings"
+
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/lipgloss"
+)
+
+// Neighbors listed per node in the graph view
+const graphNeighbors = 3
+
+// graphPageSize is how many nodes the graph screen shows at once
+const graphPageSize = 10
+
+type graphNode struct {
+	ID   string
+	Text string
+	// Input is true for inputs from this session, false for comparison texts
+	Input     bool
+	Embedding []float64
+}
+
+type graphEdge struct {
+	From, To int
+	Score    float64
+}
+
+// similarityGraph links each node to its k most similar other nodes
+type similarityGraph struct {
+	Nodes []graphNode
+	Edges [][]graphEdge
+}
+
+// corpusNodes gathers the comparison set and this session's inputs, skipping
+// vectors whose size differs from the comparison set's
+func (m model) corpusNodes() []graphNode {
+	var nodes []graphNode
+	size := -1
+
+	add := func(id string, e CustomEmbedding, input bool) {
+		if size == -1 {
+			size = len(e.Embedding)
+		}
+		if len(e.Embedding) == size {
+			nodes = append(nodes, graphNode{ID: id, Text: e.Text, Input: input, Embedding: e.Embedding})
+		}
+	}
+
+	for i, e := range m.customEmbeddings {
+		add(fmt.Sprintf("C%d", i+1), e, false)
+	}
+	for i, e := range m.inputHistory {
+		add(fmt.Sprintf("I%d", i+1), e, true)
+	}
+	return nodes
+}
+
+func buildSimilarityGraph(nodes []graphNode, k int) similarityGraph {
+	graph := similarityGraph{Nodes: nodes, Edges: make([][]graphEdge, len(nodes))}
+
+	for i := range nodes {
+		edges := make([]graphEdge, 0, k+1)
+		for j := range nodes {
+			if i != j {
+				edges = keepNearest(edges, graphEdge{From: i, To: j, Score: cosineSimilarity(nodes[i].Embedding, nodes[j].Embedding)}, k)
+			}
+		}
+		graph.Edges[i] = edges
+	}
+	return graph
+}
+
+// keepNearest adds edge to edges, which are kept best first and no longer
+// than k, so a node's neighbors never take more than k edges of memory
+func keepNearest(edges []graphEdge, edge graphEdge, k int) []graphEdge {
+	at := sort.Search(len(edges), func(i int) bool { return edges[i].Score < edge.Score })
+	if at == k {
+		return edges
+	}
+	edges = slices.Insert(edges, at, edge)
+	return edges[:min(len(edges), k)]
+}
+
+// graphBuiltMsg carries the graph screen's graph and its rendered rows,
+// built off the UI goroutine
+type graphBuiltMsg struct {
+	seq    int
+	graph  similarityGraph
+	labels []weakLabel
+	rows   []string
+	spill  *spillFile
+	notice string
+}
+
+// openGraphScreen builds the graph in the background, once, and renders a
+// row per node, so paging through it doesn't compare every pair again. Past
+// spillRows nodes the rows go to a temp file as they are rendered and the
+// screen reads the page it shows.
+func (m *model) openGraphScreen() tea.Cmd {
+	m.currentScreen = graphScreen
+	m.graphNotice = ""
+	m.graphOffset = 0
+	m.graphSpill.Close()
+	m.graphSpill, m.graphRows, m.graph, m.graphLabels = nil, nil, nil, nil
+	m.graphSeq++
+	m.graphBuilding = true
+
+	seq, nodes, spillRows := m.graphSeq, m.corpusNodes(), m.spillRows
+	return func() tea.Msg {
+		return buildGraphScreen(seq, nodes, spillRows)
+	}
+}
+
+// buildGraphScreen builds the graph over nodes, propagates labels and
+// renders the screen's rows
+func buildGraphScreen(seq int, nodes []graphNode, spillRows int) graphBuiltMsg {
+	graph := buildSimilarityGraph(nodes, graphNeighbors)
+	labels := propagateLabels(graph)
+	msg := graphBuiltMsg{seq: seq, graph: graph, labels: labels}
+
+	if spillRows > 0 && len(graph.Nodes) > spillRows {
+		spill, err := spillGraphRows(graph, labels)
+		if err == nil {
+			msg.spill = spill
+			return msg
+		}
+		msg.notice = "⚠️  " + err.Error() + " • keeping the graph in memory"
+	}
+	msg.rows = make([]string, len(graph.Nodes))
+	for i := range graph.Nodes {
+		msg.rows[i] = renderGraphRow(graph, labels, i)
+	}
+	return msg
+}
+
+// spillGraphRows writes each node's row to a temp file as it is rendered
+func spillGraphRows(graph similarityGraph, labels []weakLabel) (*spillFile, error) {
+	w, err := newSpillWriter()
+	if err != nil {
+		return nil, err
+	}
+	for i := range graph.Nodes {
+		if err := w.Add(renderGraphRow(graph, labels, i)); err != nil {
+			w.Abort()
+			return nil, err
+		}
+	}
+	return w.Finish()
+}
+
+// renderGraphRow shows node i with its weak label and its nearest neighbors
+func renderGraphRow(graph similarityGraph, labels []weakLabel, i int) string {
+	idStyle := lipgloss.NewStyle().
+		Foreground(theme.Primary).
+		Bold(true)
+	inputStyle := lipgloss.NewStyle().
+		Foreground(theme.Accent).
+		Bold(true)
+	instructStyle := lipgloss.NewStyle().
+		Foreground(theme.Muted).
+		Italic(true)
+
+	node := graph.Nodes[i]
+	style := idStyle
+	if node.Input {
+		style = inputStyle
+	}
+	row := style.Render(fmt.Sprintf("%-4s", node.ID)) + " " + truncateText(node.Text, 60)
+	if label := labels[i]; !label.Seed && label.Label != "" {
+		row += inputStyle.Render(fmt.Sprintf(" → %s %.2f", label.Label, label.Confidence))
+	}
+	row += "\n"
+
+	for _, edge := range graph.Edges[i] {
+		target := graph.Nodes[edge.To]
+		row += fmt.Sprintf("     └─ %.3f %s %s\n", edge.Score, scoreGrade(edge.Score), instructStyle.Render(target.ID+" "+truncateText(target.Text, 40)))
+	}
+	return row
+}
+
+// applyGraph shows a built graph, unless the screen was opened again since
+func (m *model) applyGraph(msg graphBuiltMsg) {
+	if msg.seq != m.graphSeq {
+		msg.spill.Close()
+		return
+	}
+	m.graphBuilding = false
+	m.graph, m.graphLabels = &msg.graph, msg.labels
+	m.graphRows, m.graphSpill = msg.rows, msg.spill
+	if msg.notice != "" {
+		m.graphNotice = msg.notice
+	}
+}
+
+// graphCount is the number of nodes on the graph screen
+func (m model) graphCount() int {
+	if m.graphSpill != nil {
+		return m.graphSpill.Len()
+	}
+	return len(m.graphRows)
+}
+
+// scrollGraph moves the graph screen's page by delta nodes
+func (m *model) scrollGraph(delta int) {
+	m.graphOffset = max(0, min(m.graphOffset+delta, m.graphCount()-graphPageSize))
+}
+
+func (m model) renderGraphScreen() string {
+	s := "\033[2J\033[H" // Clear screen and move cursor to top
+
+	s += "╭─────────────────────────────────────────────────────────────────────────────╮\n"
+	s += "│                          🕸  NEAREST NEIGHBORS 🕸                            │\n"
+	s += "╰─────────────────────────────────────────────────────────────────────────────╯\n\n"
+
+	instructStyle := lipgloss.NewStyle().
+		Foreground(theme.Muted).
+		Italic(true)
+
+	count := m.graphCount()
+	if m.graphBuilding {
+		s += instructStyle.Render("🕸  Building the neighbor graph…") + "\n"
+	} else if count < 2 {
+		s += instructStyle.Render("Add comparison texts or compare some inputs to build a graph") + "\n"
+	}
+	end := min(count, m.graphOffset+graphPageSize)
+	rows := m.graphRows
+	if m.graphSpill != nil {
+		var err error
+		if rows, err = readSpilled[string](m.graphSpill, m.graphOffset, end); err != nil {
+			s += lipgloss.NewStyle().Foreground(theme.Warning).Render("⚠️  "+err.Error()) + "\n"
+		}
+	} else {
+		rows = rows[m.graphOffset:end]
+	}
+	for _, row := range rows {
+		s += row
+	}
+
+	s += "\n" + instructStyle.Render(fmt.Sprintf("C = comparison text • I = input from this session • top %d neighbors each", graphNeighbors)) + "\n"
+	if count > graphPageSize {
+		line := fmt.Sprintf("Nodes %d–%d of %d • ↑/↓ and PgUp/PgDn to scroll", m.graphOffset+1, end, count)
+		if m.graphSpill != nil {
+			line += " • 💾 read from disk"
+		}
+		s += instructStyle.Render(line) + "\n"
+	}
+	if m.graphNotice != "" {
+		s += lipgloss.NewStyle().Foreground(theme.Muted).Render(m.graphNotice) + "\n"
+	}
+	s += instructStyle.Render("D to export DOT • G to export GraphML • L to export weak labels • Esc to return") + "\n"
+
+	return s
+}
+
+// writeDOT writes the graph for Graphviz; edges point from a node to its neighbors
+func writeDOT(w io.Writer, graph similarityGraph) error {
+	var b strings.Builder
+	b.WriteString("digraph ember {\n")
+	b.WriteString("  node [shape=box];\n")
+
+	for _, node := range graph.Nodes {
+		fmt.Fprintf(&b, "  %s [label=%s, kind=%s];\n", dotQuote(node.ID), dotQuote(truncateText(node.Text, 40)), dotQuote(node.kind()))
+	}
+	for i, edges := range graph.Edges {
+		for rank, edge := range edges {
+			fmt.Fprintf(&b, "  %s -> %s [weight=%.4f, label=\"%.3f\", rank=%d];\n",
+				dotQuote(graph.Nodes[i].ID), dotQuote(graph.Nodes[edge.To].ID), edge.Score, edge.Score, rank+1)
+		}
+	}
+
+	b.WriteString("}\n")
+	_, err := io.WriteString(w, b.String())
+	return err
+}
+
+func dotQuote(s string) string {
+	return `"` + strings.NewReplacer(`\`, `\\`, `"`, `\"`, "\n", `\n`).Replace(s) + `"`
+}
+
+func (n graphNode) kind() string {
+	if n.Input {
+		return "input"
+	}
+	return "comparison"
+}
+
+type graphMLKey struct {
+	ID   string `xml:"id,attr"`
+	For  string `xml:"for,attr"`
+	Name string `xml:"attr.name,attr"`
+	Type string `xml:"attr.type,attr"`
+}
+
+type graphMLData struct {
+	Key   string `xml:"key,attr"`
+	Value string `xml:",chardata"`
+}
+
+type graphMLNode struct {
+	ID   string        `xml:"id,attr"`
+	Data []graphMLData `xml:"data"`
+}
+
+type graphMLEdge struct {
+	Source string        `xml:"source,attr"`
+	Target string        `xml:"target,attr"`
+	Data   []graphMLData `xml:"data"`
+}
+
+type graphMLDocument struct {
+	XMLName xml.Name     `xml:"graphml"`
+	XMLNS   string       `xml:"xmlns,attr"`
+	Keys    []graphMLKey `xml:"key"`
+	Graph   struct {
+		EdgeDefault string        `xml:"edgedefault,attr"`
+		Nodes       []graphMLNode `xml:"node"`
+		Edges       []graphMLEdge `xml:"edge"`
+	} `xml:"graph"`
+}
+
+// writeGraphML writes the graph for Gephi and other GraphML tools
+func writeGraphML(w io.Writer, graph similarityGraph) error {
+	doc := graphMLDocument{
+		XMLNS: "http://graphml.graphdrawing.org/xmlns",
+		Keys: []graphMLKey{
+			{ID: "text", For: "node", Name: "text", Type: "string"},
+			{ID: "kind", For: "node", Name: "kind", Type: "string"},
+			{ID: "weight", For: "edge", Name: "weight", Type: "double"},
+			{ID: "rank", For: "edge", Name: "rank", Type: "int"},
+		},
+	}
+	doc.Graph.EdgeDefault = "directed"
+
+	for _, node := range graph.Nodes {
+		doc.Graph.Nodes = append(doc.Graph.Nodes, graphMLNode{
+			ID:   node.ID,
+			Data: []graphMLData{{Key: "text", Value: node.Text}, {Key: "kind", Value: node.kind()}},
+		})
+	}
+	for i, edges := range graph.Edges {
+		for rank, edge := range edges {
+			doc.Graph.Edges = append(doc.Graph.Edges, graphMLEdge{
+				Source: graph.Nodes[i].ID,
+				Target: graph.Nodes[edge.To].ID,
+				Data: []graphMLData{
+					{Key: "weight", Value: strconv.FormatFloat(edge.Score, 'f', 4, 64)},
+					{Key: "rank", Value: strconv.Itoa(rank + 1)},
+				},
+			})
+		}
+	}
+
+	if _, err := io.WriteString(w, xml.Header); err != nil {
+		return err
+	}
+	enc := xml.NewEncoder(w)
+	enc.Indent("", "  ")
+	if err := enc.Encode(doc); err != nil {
+		return fmt.Errorf("failed to encode GraphML: %w", err)
+	}
+	_, err := io.WriteString(w, "\n")
+	return err
+}
+
+// exportGraph writes the current graph to path in the format its extension names
+func (m model) exportGraph(path string) error {
+	if m.graph == nil {
+		return fmt.Errorf("the graph is still being built")
+	}
+	graph := *m.graph
+
+	f, err := os.Create(path)
+	if err != nil {
+		return fmt.Errorf("failed to create %s: %w", path, err)
+	}
+	defer f.Close()
+
+	if strings.HasSuffix(path, ".graphml") {
+		err = writeGraphML(f, graph)
+	} else {
+		err = writeDOT(f, graph)
+	}
+	if err != nil {
+		return fmt.Errorf("failed to write %s: %w", path, err)
+	}
+	return f.Close()
+}
@@ -0,0 +1,342 @@
+package main
+
+import (
+	"bufio"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"time"
+
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/lipgloss"
+)
+
+// Entries shown at once on the history screen
+const historyPageSize = 12
+
+// historyEntry is one comparison: the input, its vector and the scores it got
+type historyEntry struct {
+	Session string `json:"session"`
+	// SessionTitle is the session's generated title, once it has one
+	SessionTitle string          `json:"session_title,omitempty"`
+	Time         time.Time       `json:"time"`
+	Input        string          `json:"input"`
+	Model        ModelInfo       `json:"model"`
+	Embedding    []float64       `json:"embedding"`
+	Results      []historyResult `json:"results"`
+	// Note is a free-text note on the run, added on the results screen
+	Note string `json:"note,omitempty"`
+}
+
+type historyResult struct {
+	Text       string  `json:"text"`
+	Similarity float64 `json:"similarity"`
+	Note       string  `json:"note,omitempty"`
+}
+
+// appendHistory adds entry to the history file, one JSON object per line
+func appendHistory(path string, entry historyEntry) error {
+	if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+		return fmt.Errorf("failed to create history directory: %w", err)
+	}
+
+	data, err := json.Marshal(entry)
+	if err != nil {
+		return fmt.Errorf("failed to marshal history entry: %w", err)
+	}
+
+	f, err := os.OpenFile(path, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0o644)
+	if err != nil {
+		return fmt.Errorf("failed to open history: %w", err)
+	}
+	defer f.Close()
+
+	if _, err := f.Write(append(sealLine(data), '\n')); err != nil {
+		return fmt.Errorf("failed to write history: %w", err)
+	}
+	return nil
+}
+
+// writeHistory replaces the history file with entries, given newest first
+// as loadHistory returns them
+func writeHistory(path string, entries []historyEntry) error {
+	var data []byte
+	for i := len(entries) - 1; i >= 0; i-- {
+		line, err := json.Marshal(entries[i])
+		if err != nil {
+			return fmt.Errorf("failed to marshal history entry: %w", err)
+		}
+		data = append(append(data, sealLine(line)...), '\n')
+	}
+
+	tmp := path + ".tmp"
+	if err := os.WriteFile(tmp, data, 0o644); err != nil {
+		return fmt.Errorf("failed to write history: %w", err)
+	}
+	if err := os.Rename(tmp, path); err != nil {
+		return fmt.Errorf("failed to write history: %w", err)
+	}
+	return nil
+}
+
+// loadHistory reads the history file, newest entry first. Lines that don't
+// parse, such as one cut short by a crash, are skipped, but encrypted lines
+// without the key are an error, so the history isn't rewritten without them.
+func loadHistory(path string) ([]historyEntry, error) {
+	f, err := os.Open(path)
+	if os.IsNotExist(err) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to open history: %w", err)
+	}
+	defer f.Close()
+
+	var entries []historyEntry
+	reader := bufio.NewReader(f)
+	for {
+		line, err := reader.ReadBytes('\n')
+		if len(line) > 0 {
+			plain, openErr := openLine(line)
+			if errors.Is(openErr, errAtRestLocked) {
+				return nil, fmt.Errorf("failed to read history: %w", openErr)
+			}
+			var entry historyEntry
+			if openErr == nil && json.Unmarshal(plain, &entry) == nil {
+				entries = append(entries, entry)
+			}
+		}
+		if err != nil {
+			break
+		}
+	}
+
+	for i, j := 0, len(entries)-1; i < j; i, j = i+1, j-1 {
+		entries[i], entries[j] = entries[j], entries[i]
+	}
+	return entries, nil
+}
+
+// recordHistory saves the comparison just shown on the results screen
+func (m *model) recordHistory() {
+	// Postgres keeps microseconds, and notes find the entry by its time
+	entry := historyEntry{
+		Session:      m.sessionID,
+		SessionTitle: m.sessionTitles[m.sessionID],
+		Time:         time.Now().Round(time.Microsecond),
+		Input:        m.lastInput,
+		Model:        m.resultModel,
+		Embedding:    m.lastEmbedding,
+	}
+	for _, result := range m.similarities {
+		entry.Results = append(entry.Results, historyResult{Text: result.Text, Similarity: result.Similarity})
+	}
+
+	m.historyNotice = ""
+	m.lastEntry = historyEntry{}
+	if err := m.store.AppendHistory(entry); err != nil {
+		m.historyNotice = "⚠️  " + err.Error()
+		return
+	}
+	m.lastEntry = entry
+	m.sessionInputs = append(m.sessionInputs, entry.Input)
+}
+
+func (m *model) openHistoryScreen() {
+	m.currentScreen = historyScreen
+	m.selectedHistory = 0
+	m.markedHistory = -1
+	m.historyDiff = false
+
+	var err error
+	m.history, err = m.store.LoadHistory()
+	if err != nil {
+		m.historyNotice = "⚠️  " + err.Error()
+	}
+	m.sessionTitles = historySessionTitles(m.history)
+}
+
+// rerunHistory compares the selected entry's input against the current
+// comparison set, reusing its stored vector when the model hasn't changed
+func (m model) rerunHistory() (model, tea.Cmd) {
+	if m.selectedHistory >= len(m.history) {
+		return m, nil
+	}
+	entry := m.history[m.selectedHistory]
+
+	info := m.provider.ModelInfo()
+	if !m.useOverride && entry.Model == info && len(entry.Embedding) > 0 {
+		m.comparisonSeq++
+		return m, tea.Batch(
+			func() tea.Msg {
+				return embeddingCompleteMsg{embedding: entry.Embedding, text: entry.Input, model: info}
+			},
+			m.generateSecondaryScores(entry.Input),
+		)
+	}
+	return m.startComparison(entry.Input)
+}
+
+// toggleHistoryMark marks the selected entry as the baseline for a diff
+func (m *model) toggleHistoryMark() {
+	if m.markedHistory == m.selectedHistory {
+		m.markedHistory = -1
+	} else {
+		m.markedHistory = m.selectedHistory
+	}
+	m.historyDiff = false
+}
+
+type historyDiffRow struct {
+	Text          string
+	Before, After float64
+	HasBefore     bool
+	HasAfter      bool
+}
+
+// diffHistory lines up two entries' scores by comparison text
+func diffHistory(before, after historyEntry) []historyDiffRow {
+	var rows []historyDiffRow
+	index := make(map[string]int)
+
+	for _, r := range before.Results {
+		index[r.Text] = len(rows)
+		rows = append(rows, historyDiffRow{Text: r.Text, Before: r.Similarity, HasBefore: true})
+	}
+	for _, r := range after.Results {
+		if i, ok := index[r.Text]; ok {
+			rows[i].After, rows[i].HasAfter = r.Similarity, true
+			continue
+		}
+		rows = append(rows, historyDiffRow{Text: r.Text, After: r.Similarity, HasAfter: true})
+	}
+	return rows
+}
+
+func (m model) renderHistoryScreen() string {
+	s := "\033[2J\033[H" // Clear screen and move cursor to top
+
+	s += "╭─────────────────────────────────────────────────────────────────────────────╮\n"
+	s += "│                              📜 HISTORY 📜                                  │\n"
+	s += "╰─────────────────────────────────────────────────────────────────────────────╯\n\n"
+
+	sessionStyle := lipgloss.NewStyle().
+		Foreground(theme.Primary).
+		Bold(true)
+	selectedStyle := lipgloss.NewStyle().
+		Foreground(theme.Accent).
+		Bold(true)
+	instructStyle := lipgloss.NewStyle().
+		Foreground(theme.Muted).
+		Italic(true)
+
+	if len(m.history) == 0 {
+		s += instructStyle.Render("No comparisons yet • results are saved here as you compare") + "\n"
+	}
+
+	start := 0
+	if m.selectedHistory >= historyPageSize {
+		start = m.selectedHistory - historyPageSize + 1
+	}
+	end := min(len(m.history), start+historyPageSize)
+
+	session := ""
+	for i := start; i < end; i++ {
+		entry := m.history[i]
+		if entry.Session != session {
+			session = entry.Session
+			label := "Session " + session
+			if title := m.sessionTitles[session]; title != "" {
+				label = title + " • " + session
+			}
+			if session == m.sessionID {
+				label += " (current)"
+			}
+			s += sessionStyle.Render("🗂  "+label) + "\n"
+		}
+
+		mark := "  "
+		if i == m.markedHistory {
+			mark = "◆ "
+		} else if entry.hasNotes() {
+			mark = "📝"
+		}
+		best := ""
+		if len(entry.Results) > 0 {
+			top := entry.Results[0]
+			for _, r := range entry.Results {
+				if r.Similarity > top.Similarity {
+					top = r
+				}
+			}
+			best = fmt.Sprintf("%.3f %s", top.Similarity, truncateText(top.Text, 24))
+		}
+
+		line := fmt.Sprintf("%s%s  %-36s %s", mark, entry.Time.Format("15:04"), truncateText(entry.Input, 36), best)
+		if i == m.selectedHistory {
+			s += selectedStyle.Render("▶ "+line) + "\n"
+		} else {
+			s += "  " + line + "\n"
+		}
+	}
+
+	if m.historyDiff && m.markedHistory >= 0 && m.markedHistory < len(m.history) && m.selectedHistory < len(m.history) {
+		before, after := m.history[m.markedHistory], m.history[m.selectedHistory]
+		if after.Time.Before(before.Time) {
+			before, after = after, before
+		}
+		s += "\n" + m.renderHistoryDiff(before, after)
+	}
+
+	if !m.historyDiff && m.selectedHistory < len(m.history) && m.history[m.selectedHistory].hasNotes() {
+		s += "\n" + renderHistoryNotes(m.history[m.selectedHistory])
+	}
+
+	if m.historyNotice != "" {
+		s += "\n" + lipgloss.NewStyle().Foreground(theme.Warning).Render(m.historyNotice) + "\n"
+	}
+	s += "\n" + instructStyle.Render("↑/↓ to select • Enter to re-run • M to mark • D to diff with the marked entry • T to title the session • E to write a report • Esc to return") + "\n"
+	return s
+}
+
+func (m model) renderHistoryDiff(before, after historyEntry) string {
+	labelStyle := lipgloss.NewStyle().
+		Foreground(theme.Primary).
+		Bold(true)
+	mutedStyle := lipgloss.NewStyle().
+		Foreground(theme.Muted)
+	upStyle := lipgloss.NewStyle().
+		Foreground(theme.Accent)
+	downStyle := lipgloss.NewStyle().
+		Foreground(theme.Warning)
+
+	s := labelStyle.Render(fmt.Sprintf("🔀 %s → %s", before.Time.Format("Jan 2 15:04"), after.Time.Format("Jan 2 15:04"))) + "\n"
+	if before.Input != after.Input {
+		s += mutedStyle.Render(fmt.Sprintf("   inputs differ: %q vs %q", truncateText(before.Input, 30), truncateText(after.Input, 30))) + "\n"
+	}
+	if before.Model != after.Model {
+		s += mutedStyle.Render(fmt.Sprintf("   models differ: %s/%s vs %s/%s", before.Model.Provider, before.Model.Model, after.Model.Provider, after.Model.Model)) + "\n"
+	}
+
+	for _, row := range diffHistory(before, after) {
+		text := fmt.Sprintf("   %-40s", truncateText(row.Text, 40))
+		switch {
+		case !row.HasBefore:
+			s += text + mutedStyle.Render(fmt.Sprintf("    —   → %.3f  (new)", row.After)) + "\n"
+		case !row.HasAfter:
+			s += text + mutedStyle.Render(fmt.Sprintf("  %.3f →   —    (removed)", row.Before)) + "\n"
+		default:
+			delta := row.After - row.Before
+			style := mutedStyle
+			if delta > 0.005 {
+				style = upStyle
+			} else if delta < -0.005 {
+				style = downStyle
+			}
+			s += text + fmt.Sprintf("  %.3f → %.3f  ", row.Before, row.After) + style.Render(fmt.Sprintf("%+.3f", delta)) + "\n"
+		}
+	}
+	return s
+}
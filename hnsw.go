@@ -0,0 +1,757 @@
+package main
+
+import (
+	"container/heap"
+	"crypto/sha1"
+	"encoding/binary"
+	"encoding/hex"
+	"errors"
+	"flag"
+	"fmt"
+	"hash/crc32"
+	"math"
+	"math/rand/v2"
+	"os"
+	"path/filepath"
+	"runtime"
+	"sort"
+	"strconv"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// Magic number and version at the start of every HNSW index file. Version 2
+// ends with a CRC-32C of the rest of the file.
+const (
+	hnswMagic   = "EMBH"
+	hnswVersion = 2
+)
+
+// Defaults for building and searching an index: neighbors per node,
+// candidates kept while inserting, and candidates kept while searching
+const (
+	defaultHNSWNeighbors = 16
+	defaultHNSWBuildEf   = 200
+	defaultHNSWSearchEf  = 64
+)
+
+// hnswIndex is a hierarchical navigable small world graph over one model's
+// vectors in the vector store, so a search visits a few thousand vectors
+// instead of all of them. Nodes point at rows of the segments the index was
+// built from; segments this store writes later are inserted as they're
+// written, those from older versions are searched exactly, and an index
+// whose segments were compacted away is ignored until it is built again.
+type hnswIndex struct {
+	model string
+	dims  int
+	// m is the most neighbors a node keeps above layer 0, which keeps twice as many
+	m        int
+	seqs     []int
+	nodes    []hnswNode
+	entry    uint32
+	maxLevel int
+
+	// modTime is when the file was written, to notice rebuilds by other processes
+	modTime time.Time
+	// segments maps the index's segment sequence numbers to mapped segments
+	segments map[int]*vectorSegment
+	// scratch is shared by searches, which hold the store's lock
+	scratch hnswScratch
+	// locks and entryMu guard the graph while it's built, and are nil after
+	locks   []sync.Mutex
+	entryMu sync.Mutex
+}
+
+// hnswScratch is reused from one layer search to the next. visited marks
+// the nodes the current search has seen with its epoch.
+type hnswScratch struct {
+	visited []uint32
+	epoch   uint32
+	friends []uint32
+}
+
+type hnswNode struct {
+	seq  uint32
+	row  uint32
+	norm float32
+	// segment holds the node's vector, resolved when the index is loaded
+	segment *vectorSegment
+	// friends holds the node's neighbors on each layer it's in
+	friends [][]uint32
+}
+
+// hnswCandidate is a node and its similarity to whatever is being searched for
+type hnswCandidate struct {
+	id  uint32
+	sim float64
+}
+
+// hnswQueue is a heap of candidates, closest first unless worstFirst
+type hnswQueue struct {
+	items      []hnswCandidate
+	worstFirst bool
+}
+
+func (q hnswQueue) Len() int { return len(q.items) }
+func (q hnswQueue) Less(i, j int) bool {
+	if q.worstFirst {
+		return q.items[i].sim < q.items[j].sim
+	}
+	return q.items[i].sim > q.items[j].sim
+}
+func (q hnswQueue) Swap(i, j int) { q.items[i], q.items[j] = q.items[j], q.items[i] }
+func (q *hnswQueue) Push(x any)   { q.items = append(q.items, x.(hnswCandidate)) }
+func (q *hnswQueue) Pop() any {
+	last := q.items[len(q.items)-1]
+	q.items = q.items[:len(q.items)-1]
+	return last
+}
+
+// hnswPath is where the index over model's vectors of the given size lives
+func hnswPath(dir, model string, dims int) string {
+	sum := sha1.Sum([]byte(fmt.Sprintf("%s\x00%d", model, dims)))
+	return filepath.Join(dir, "hnsw-"+hex.EncodeToString(sum[:6])+".idx")
+}
+
+// hnswSearchEf reads EMBER_VECTORS_EF, the candidates a search keeps:
+// more finds the true nearest texts more often, and takes longer
+func hnswSearchEf() int {
+	if n, err := strconv.Atoi(os.Getenv("EMBER_VECTORS_EF")); err == nil && n > 0 {
+		return n
+	}
+	return defaultHNSWSearchEf
+}
+
+// nodeVector decodes a node's vector from its segment
+func (ix *hnswIndex) nodeVector(id uint32) []float64 {
+	node := &ix.nodes[id]
+	return node.segment.vector(int(node.row))
+}
+
+// similarity is the cosine similarity of a node with query, whose norm is given
+func (ix *hnswIndex) similarity(id uint32, query []float64, queryNorm float64) float64 {
+	node := &ix.nodes[id]
+	offset := int(node.row) * node.segment.dims * 4
+	row := node.segment.data[offset : offset+len(query)*4]
+	var dot float64
+	for i, q := range query {
+		dot += q * float64(math.Float32frombits(binary.LittleEndian.Uint32(row[i*4:])))
+	}
+	if node.norm == 0 || queryNorm == 0 {
+		return 0
+	}
+	return dot / (float64(node.norm) * queryNorm)
+}
+
+// neighbors returns a node's neighbors on a layer. While the index is
+// being built they're copied into buf under the node's lock.
+func (ix *hnswIndex) neighbors(id uint32, level int, buf []uint32) []uint32 {
+	if ix.locks == nil {
+		return ix.nodes[id].friends[level]
+	}
+	ix.locks[id].Lock()
+	buf = append(buf[:0], ix.nodes[id].friends[level]...)
+	ix.locks[id].Unlock()
+	return buf
+}
+
+// greedy walks layer level from entry towards query, returning the closest node found
+func (ix *hnswIndex) greedy(sc *hnswScratch, query []float64, queryNorm float64, entry hnswCandidate, level int) hnswCandidate {
+	for moved := true; moved; {
+		moved = false
+		sc.friends = ix.neighbors(entry.id, level, sc.friends)
+		for _, friend := range sc.friends {
+			if sim := ix.similarity(friend, query, queryNorm); sim > entry.sim {
+				entry, moved = hnswCandidate{friend, sim}, true
+			}
+		}
+	}
+	return entry
+}
+
+// searchLayer returns the ef nodes on layer level closest to query found
+// from entry, best first
+func (ix *hnswIndex) searchLayer(sc *hnswScratch, query []float64, queryNorm float64, entry hnswCandidate, ef, level int) []hnswCandidate {
+	if len(sc.visited) != len(ix.nodes) {
+		sc.visited, sc.epoch = make([]uint32, len(ix.nodes)), 0
+	}
+	if sc.epoch++; sc.epoch == 0 {
+		clear(sc.visited)
+		sc.epoch = 1
+	}
+	sc.visited[entry.id] = sc.epoch
+	candidates := &hnswQueue{items: []hnswCandidate{entry}}
+	results := &hnswQueue{items: []hnswCandidate{entry}, worstFirst: true}
+
+	for candidates.Len() > 0 {
+		closest := heap.Pop(candidates).(hnswCandidate)
+		if results.Len() >= ef && closest.sim < results.items[0].sim {
+			break
+		}
+		sc.friends = ix.neighbors(closest.id, level, sc.friends)
+		for _, friend := range sc.friends {
+			if sc.visited[friend] == sc.epoch {
+				continue
+			}
+			sc.visited[friend] = sc.epoch
+			sim := ix.similarity(friend, query, queryNorm)
+			if results.Len() < ef || sim > results.items[0].sim {
+				heap.Push(candidates, hnswCandidate{friend, sim})
+				heap.Push(results, hnswCandidate{friend, sim})
+				if results.Len() > ef {
+					heap.Pop(results)
+				}
+			}
+		}
+	}
+
+	found := results.items
+	sort.Slice(found, func(i, j int) bool { return found[i].sim > found[j].sim })
+	return found
+}
+
+// selectNeighbors keeps up to m of candidates, best first, skipping ones
+// closer to an already kept neighbor than to the node itself so the graph
+// links across clusters, then filling any room with the skipped ones
+func (ix *hnswIndex) selectNeighbors(candidates []hnswCandidate, m int) []uint32 {
+	if len(candidates) <= m {
+		selected := make([]uint32, len(candidates))
+		for i, c := range candidates {
+			selected[i] = c.id
+		}
+		return selected
+	}
+
+	selected := make([]uint32, 0, m)
+	var keptVectors [][]float64
+	var keptNorms []float64
+	var skipped []uint32
+	for _, c := range candidates {
+		if len(selected) == m {
+			break
+		}
+		diverse := true
+		for i, kept := range keptVectors {
+			if ix.similarity(c.id, kept, keptNorms[i]) > c.sim {
+				diverse = false
+				break
+			}
+		}
+		if !diverse {
+			skipped = append(skipped, c.id)
+			continue
+		}
+		selected = append(selected, c.id)
+		keptVectors = append(keptVectors, ix.nodeVector(c.id))
+		keptNorms = append(keptNorms, float64(ix.nodes[c.id].norm))
+	}
+	for _, id := range skipped {
+		if len(selected) == m {
+			break
+		}
+		selected = append(selected, id)
+	}
+	return selected
+}
+
+// insert links node id into the graph. Inserts run concurrently: each
+// node's neighbor lists are guarded by its lock, and the entry point by
+// entryMu, which an insert that raises the top layer holds throughout.
+func (ix *hnswIndex) insert(sc *hnswScratch, id uint32, buildEf int) {
+	level := int(-math.Log(1-rand.Float64()) / math.Log(float64(ix.m)))
+	node := &ix.nodes[id]
+	ix.locks[id].Lock()
+	node.friends = make([][]uint32, level+1)
+	ix.locks[id].Unlock()
+
+	ix.entryMu.Lock()
+	entryID, maxLevel := ix.entry, ix.maxLevel
+	if level <= maxLevel {
+		ix.entryMu.Unlock()
+	} else {
+		defer ix.entryMu.Unlock()
+	}
+	// The first node inserted is the graph's entry point on every layer it's in
+	if entryID == id {
+		ix.maxLevel = max(ix.maxLevel, level)
+		return
+	}
+
+	query := ix.nodeVector(id)
+	queryNorm := float64(node.norm)
+	entry := hnswCandidate{entryID, ix.similarity(entryID, query, queryNorm)}
+	for l := maxLevel; l > level; l-- {
+		entry = ix.greedy(sc, query, queryNorm, entry, l)
+	}
+
+	for l := min(level, maxLevel); l >= 0; l-- {
+		found := ix.searchLayer(sc, query, queryNorm, entry, buildEf, l)
+		limit := ix.m
+		if l == 0 {
+			limit = 2 * ix.m
+		}
+		selected := ix.selectNeighbors(found, ix.m)
+		ix.locks[id].Lock()
+		node.friends[l] = selected
+		ix.locks[id].Unlock()
+		for _, friend := range selected {
+			ix.locks[friend].Lock()
+			ix.nodes[friend].friends[l] = append(ix.nodes[friend].friends[l], id)
+			if len(ix.nodes[friend].friends[l]) > limit {
+				ix.prune(friend, l, limit)
+			}
+			ix.locks[friend].Unlock()
+		}
+		entry = found[0]
+	}
+	if level > maxLevel {
+		ix.entry, ix.maxLevel = id, level
+	}
+}
+
+// prune cuts a node's neighbors on a layer back to the closest limit.
+// The caller holds the node's lock.
+func (ix *hnswIndex) prune(id uint32, level, limit int) {
+	vector := ix.nodeVector(id)
+	norm := float64(ix.nodes[id].norm)
+	friends := ix.nodes[id].friends[level]
+	candidates := make([]hnswCandidate, len(friends))
+	for i, friend := range friends {
+		candidates[i] = hnswCandidate{friend, ix.similarity(friend, vector, norm)}
+	}
+	sort.Slice(candidates, func(i, j int) bool { return candidates[i].sim > candidates[j].sim })
+	for i := range limit {
+		friends[i] = candidates[i].id
+	}
+	ix.nodes[id].friends[level] = friends[:limit]
+}
+
+// search returns up to ef nodes closest to query, best first
+func (ix *hnswIndex) search(query []float64, queryNorm float64, ef int) []hnswCandidate {
+	if len(ix.nodes) == 0 {
+		return nil
+	}
+	entry := hnswCandidate{ix.entry, ix.similarity(ix.entry, query, queryNorm)}
+	for l := ix.maxLevel; l > 0; l-- {
+		entry = ix.greedy(&ix.scratch, query, queryNorm, entry, l)
+	}
+	return ix.searchLayer(&ix.scratch, query, queryNorm, entry, ef, 0)
+}
+
+// covers reports whether the index was built over segment seq
+func (ix *hnswIndex) covers(seq int) bool {
+	_, ok := ix.segments[seq]
+	return ok
+}
+
+// writeHNSW saves the index to path under a temporary name, then renames it
+func writeHNSW(path string, ix *hnswIndex) error {
+	size := 36 + len(ix.model) + 4*len(ix.seqs)
+	for _, node := range ix.nodes {
+		size += 13
+		for _, friends := range node.friends {
+			size += 2 + 4*len(friends)
+		}
+	}
+	buf := make([]byte, 0, size)
+	le := binary.LittleEndian
+	buf = append(buf, hnswMagic...)
+	for _, v := range []int{hnswVersion, ix.dims, ix.m, len(ix.nodes), int(ix.entry), ix.maxLevel, len(ix.model)} {
+		buf = le.AppendUint32(buf, uint32(v))
+	}
+	buf = append(buf, ix.model...)
+	buf = le.AppendUint32(buf, uint32(len(ix.seqs)))
+	for _, seq := range ix.seqs {
+		buf = le.AppendUint32(buf, uint32(seq))
+	}
+	for _, node := range ix.nodes {
+		buf = le.AppendUint32(buf, node.seq)
+		buf = le.AppendUint32(buf, node.row)
+		buf = le.AppendUint32(buf, math.Float32bits(node.norm))
+		buf = append(buf, byte(len(node.friends)))
+		for _, friends := range node.friends {
+			buf = le.AppendUint16(buf, uint16(len(friends)))
+			for _, friend := range friends {
+				buf = le.AppendUint32(buf, friend)
+			}
+		}
+	}
+	buf = le.AppendUint32(buf, crc32.Checksum(buf, castagnoli))
+
+	if err := os.WriteFile(path+".tmp", sealAtRest(buf), 0o644); err != nil {
+		return fmt.Errorf("failed to write %s: %w", path, err)
+	}
+	if err := os.Rename(path+".tmp", path); err != nil {
+		return fmt.Errorf("failed to write %s: %w", path, err)
+	}
+	return nil
+}
+
+// readHNSW loads the index at path. The graph is read into memory; the
+// vectors stay in the mapped segments.
+func readHNSW(path string) (*hnswIndex, error) {
+	data, err := os.ReadFile(path)
+	if err == nil {
+		data, err = openAtRest(data)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to read %s: %w", path, err)
+	}
+	info, err := os.Stat(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to stat %s: %w", path, err)
+	}
+
+	corrupt := fmt.Errorf("%s isn't an ember HNSW index", path)
+	le := binary.LittleEndian
+	pos := 0
+	next := func(n int) []byte {
+		if pos+n > len(data) {
+			pos = len(data) + 1
+			return make([]byte, n)
+		}
+		pos += n
+		return data[pos-n : pos]
+	}
+	u32 := func() int { return int(le.Uint32(next(4))) }
+
+	if string(next(4)) != hnswMagic {
+		return nil, corrupt
+	}
+	switch u32() {
+	case 1:
+	case 2:
+		end := len(data) - 4
+		if end < 8 || crc32.Checksum(data[:end], castagnoli) != le.Uint32(data[end:]) {
+			return nil, fmt.Errorf("%s is corrupt: its checksum doesn't match", path)
+		}
+		data = data[:end]
+	default:
+		return nil, corrupt
+	}
+	ix := &hnswIndex{dims: u32(), m: u32(), modTime: info.ModTime()}
+	nodes := u32()
+	ix.entry, ix.maxLevel = uint32(u32()), u32()
+	ix.model = string(next(u32()))
+	ix.seqs = make([]int, u32())
+	if pos > len(data) || len(ix.seqs) > len(data) || nodes > len(data) {
+		return nil, corrupt
+	}
+	for i := range ix.seqs {
+		ix.seqs[i] = u32()
+	}
+	ix.nodes = make([]hnswNode, nodes)
+	for i := range ix.nodes {
+		node := &ix.nodes[i]
+		node.seq, node.row = uint32(u32()), uint32(u32())
+		node.norm = math.Float32frombits(uint32(u32()))
+		node.friends = make([][]uint32, next(1)[0])
+		for l := range node.friends {
+			friends := make([]uint32, le.Uint16(next(2)))
+			for j := range friends {
+				friends[j] = uint32(u32())
+				if int(friends[j]) >= nodes {
+					return nil, corrupt
+				}
+			}
+			node.friends[l] = friends
+		}
+		if pos > len(data) {
+			return nil, corrupt
+		}
+	}
+	if pos != len(data) || (nodes > 0 && int(ix.entry) >= nodes) {
+		return nil, corrupt
+	}
+	return ix, nil
+}
+
+// index returns the HNSW index over model's vectors of size dims, loading
+// it the first time and again after it's rebuilt. It returns nil when
+// there's none, or its segments were compacted away. Callers hold s.mu.
+func (s *vectorStore) index(model string, dims int) *hnswIndex {
+	path := hnswPath(s.dir, model, dims)
+	info, err := os.Stat(path)
+	if err != nil {
+		delete(s.indexes, path)
+		delete(s.unusable, path)
+		return nil
+	}
+	if ix, ok := s.indexes[path]; ok && ix.modTime.Equal(info.ModTime()) {
+		return ix
+	}
+	// A file that can't be used isn't read again until it changes
+	if written, ok := s.unusable[path]; ok && written.Equal(info.ModTime()) {
+		return nil
+	}
+	delete(s.indexes, path)
+	s.unusable[path] = info.ModTime()
+
+	ix, err := readHNSW(path)
+	if err != nil || ix.model != model || ix.dims != dims {
+		return nil
+	}
+	ix.segments = make(map[int]*vectorSegment, len(ix.seqs))
+	for _, seq := range ix.seqs {
+		for _, segment := range s.segments {
+			if segment.seq == seq {
+				ix.segments[seq] = segment
+			}
+		}
+		if ix.segments[seq] == nil {
+			return nil
+		}
+	}
+	for i := range ix.nodes {
+		node := &ix.nodes[i]
+		node.segment = ix.segments[int(node.seq)]
+		if node.segment == nil || int(node.row) >= len(node.segment.texts) {
+			return nil
+		}
+	}
+	delete(s.unusable, path)
+	s.indexes[path] = ix
+	return ix
+}
+
+// warm loads the index of every model in the store, so the first search
+// doesn't wait on it, and returns how many it loaded
+func (s *vectorStore) warm() int {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if err := s.refresh(); err != nil {
+		return 0
+	}
+	type group struct {
+		model string
+		dims  int
+	}
+	seen := make(map[group]bool)
+	warmed := 0
+	for _, segment := range s.segments {
+		g := group{segment.model, segment.dims}
+		if seen[g] {
+			continue
+		}
+		seen[g] = true
+		if s.index(g.model, g.dims) != nil {
+			warmed++
+		}
+	}
+	return warmed
+}
+
+// buildIndexes builds an HNSW index over each model's live vectors,
+// replacing any earlier index. progress is called as nodes are inserted.
+func (s *vectorStore) buildIndexes(m, buildEf int, progress func(model string, done, total int)) (built int, err error) {
+	if err := s.writable(); err != nil {
+		return 0, err
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if err := s.refresh(); err != nil {
+		return 0, err
+	}
+
+	type group struct {
+		model string
+		dims  int
+	}
+	var groups []group
+	indexes := make(map[group]*hnswIndex)
+	for _, segment := range s.segments {
+		// Segments that only delete documents have no vectors to index
+		if len(segment.texts) == 0 {
+			continue
+		}
+		g := group{segment.model, segment.dims}
+		ix := indexes[g]
+		if ix == nil {
+			ix = &hnswIndex{model: g.model, dims: g.dims, m: m, segments: make(map[int]*vectorSegment)}
+			indexes[g] = ix
+			groups = append(groups, g)
+		}
+		s.addNodes(ix, segment)
+	}
+
+	for _, g := range groups {
+		ix := indexes[g]
+		ix.insertFrom(0, buildEf, func(done, total int) {
+			if progress != nil {
+				progress(g.model, done, total)
+			}
+		})
+
+		path := hnswPath(s.dir, g.model, g.dims)
+		if err := writeHNSW(path, ix); err != nil {
+			return built, fmt.Errorf("failed to save the index: %w", err)
+		}
+		if info, err := os.Stat(path); err == nil {
+			ix.modTime = info.ModTime()
+			delete(s.unusable, path)
+			s.indexes[path] = ix
+		}
+		built++
+	}
+	return built, nil
+}
+
+// addNodes appends a node for each live row of segment, ready to insert.
+// Callers hold s.mu.
+func (s *vectorStore) addNodes(ix *hnswIndex, segment *vectorSegment) {
+	ix.seqs = append(ix.seqs, segment.seq)
+	ix.segments[segment.seq] = segment
+	for row := range segment.texts {
+		if !s.isLive(segment, row) {
+			continue
+		}
+		var norm float64
+		for _, v := range segment.vector(row) {
+			norm += v * v
+		}
+		ix.nodes = append(ix.nodes, hnswNode{seq: uint32(segment.seq), row: uint32(row), norm: float32(math.Sqrt(norm)), segment: segment})
+	}
+}
+
+// insertFrom links the nodes from first onward into the graph in parallel.
+// progress is called with the count inserted so far.
+func (ix *hnswIndex) insertFrom(first, buildEf int, progress func(done, total int)) {
+	ix.locks = make([]sync.Mutex, len(ix.nodes))
+	defer func() { ix.locks = nil }()
+	var next atomic.Int64
+	next.Store(int64(first))
+	var progressMu sync.Mutex
+	done := 0
+	// Later nodes need an entry point, so the first goes in alone
+	if first == 0 && len(ix.nodes) > 0 {
+		ix.insert(&hnswScratch{}, 0, buildEf)
+		next.Store(1)
+		done = 1
+	}
+	total := len(ix.nodes) - first
+	var wg sync.WaitGroup
+	for range runtime.GOMAXPROCS(0) {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			var sc hnswScratch
+			for id := next.Add(1) - 1; id < int64(len(ix.nodes)); id = next.Add(1) - 1 {
+				ix.insert(&sc, uint32(id), buildEf)
+				progressMu.Lock()
+				done++
+				progress(done, total)
+				progressMu.Unlock()
+			}
+		}()
+	}
+	wg.Wait()
+}
+
+// extendIndexes inserts the live rows of segments written since each index
+// was built, so puts stay searchable through the graph without a rebuild.
+// Rows that are later hidden stay in the graph to route searches through,
+// and are skipped in results. Callers hold s.mu.
+func (s *vectorStore) extendIndexes() error {
+	for _, segment := range s.segments {
+		if len(segment.texts) == 0 {
+			continue
+		}
+		ix := s.index(segment.model, segment.dims)
+		if ix == nil || ix.covers(segment.seq) {
+			continue
+		}
+		first := len(ix.nodes)
+		s.addNodes(ix, segment)
+		ix.insertFrom(first, defaultHNSWBuildEf, func(int, int) {})
+
+		path := hnswPath(s.dir, segment.model, segment.dims)
+		if err := writeHNSW(path, ix); err != nil {
+			return fmt.Errorf("failed to update the index: %w", err)
+		}
+		if info, err := os.Stat(path); err == nil {
+			ix.modTime = info.ModTime()
+		}
+	}
+	return nil
+}
+
+// removeIndexes deletes every index file, returning how many there were
+func (s *vectorStore) removeIndexes() int {
+	paths, _ := filepath.Glob(filepath.Join(s.dir, "hnsw-*.idx"))
+	for _, path := range paths {
+		os.Remove(path)
+	}
+	s.indexes = make(map[string]*hnswIndex)
+	s.unusable = make(map[string]time.Time)
+	return len(paths)
+}
+
+// runVectorsIndex handles `ember vectors index`, drawing build progress
+// with the same progress bar as the jobs screen
+func runVectorsIndex(s *vectorStore, args []string) error {
+	fs := flag.NewFlagSet("vectors index", flag.ExitOnError)
+	m := fs.Int("m", defaultHNSWNeighbors, "neighbors per node")
+	buildEf := fs.Int("ef", defaultHNSWBuildEf, "candidates kept while inserting")
+	fs.Parse(args)
+	if fs.NArg() != 0 || *m < 2 || *buildEf < 1 {
+		return errors.New("usage: ember vectors index [--m 16] [--ef 200]")
+	}
+
+	bar := newProgressBar()
+	bar.Width = 40
+	start := time.Now()
+	lastDrawn := time.Time{}
+	built, err := s.buildIndexes(*m, *buildEf, func(model string, done, total int) {
+		if done < total && time.Since(lastDrawn) < 100*time.Millisecond {
+			return
+		}
+		lastDrawn = time.Now()
+		fmt.Fprintf(os.Stderr, "\r%s %s %d/%d", truncateText(model, 32), bar.ViewAs(float64(done)/float64(total)), done, total)
+		if done == total {
+			fmt.Fprintln(os.Stderr)
+		}
+	})
+	if err != nil {
+		return err
+	}
+	if built == 0 {
+		fmt.Printf("The vector store in %s is empty • ember vectors upsert adds texts\n", s.dir)
+		return nil
+	}
+	fmt.Printf("🕸  Indexed %d texts in %s\n", s.size(), time.Since(start).Round(time.Millisecond))
+	return nil
+}
+
+// indexStatus describes a model's index for ember vectors info
+func (s *vectorStore) indexStatus(model string, dims int) string {
+	if _, err := os.Stat(hnswPath(s.dir, model, dims)); err != nil {
+		return "no index"
+	}
+	ix := s.index(model, dims)
+	if ix == nil {
+		if _, err := readHNSW(hnswPath(s.dir, model, dims)); err != nil {
+			return err.Error() + " • run ember vectors index"
+		}
+		return "index out of date • run ember vectors index"
+	}
+	newer, removed := 0, 0
+	for _, segment := range s.segments {
+		if segment.model == model && segment.dims == dims && !ix.covers(segment.seq) {
+			newer += len(segment.texts)
+		}
+	}
+	for i := range ix.nodes {
+		if !s.isLive(ix.nodes[i].segment, int(ix.nodes[i].row)) {
+			removed++
+		}
+	}
+	status := fmt.Sprintf("HNSW index of %d", len(ix.nodes)-removed)
+	if newer > 0 {
+		status += fmt.Sprintf(", %d newer texts searched exactly", newer)
+	}
+	if removed > 0 {
+		status += fmt.Sprintf(", %d removed texts kept for routing", removed)
+	}
+	return status
+}
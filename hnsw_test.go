@@ -0,0 +1,206 @@
+package main
+
+import (
+	"fmt"
+	"math"
+	"math/rand"
+	"os"
+	"path/filepath"
+	"slices"
+	"sort"
+	"strings"
+	"testing"
+)
+
+// testStore opens an empty vector store in a temporary directory
+func testStore(t *testing.T) *vectorStore {
+	t.Setenv("EMBER_VECTORS_REMOTE", "")
+	s, err := openVectorStore(t.TempDir())
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { s.Close() })
+	return s
+}
+
+// clusteredEmbeddings returns n texts whose vectors lie around clusters
+// random centers, the way embeddings of a corpus group by topic
+func clusteredEmbeddings(rng *rand.Rand, n, dims, clusters int) []CustomEmbedding {
+	centers := make([][]float64, clusters)
+	for i := range centers {
+		centers[i] = make([]float64, dims)
+		for j := range centers[i] {
+			centers[i][j] = rng.NormFloat64()
+		}
+	}
+	embeddings := make([]CustomEmbedding, n)
+	for i := range embeddings {
+		center := centers[rng.Intn(clusters)]
+		vector := make([]float64, dims)
+		for j := range vector {
+			vector[j] = center[j] + 0.3*rng.NormFloat64()
+		}
+		embeddings[i] = CustomEmbedding{Text: fmt.Sprintf("text %d", i), Embedding: vector}
+	}
+	return embeddings
+}
+
+func TestHNSWRoundTrip(t *testing.T) {
+	s := testStore(t)
+	model := ModelInfo{Provider: "mock", Model: "hashed-words"}
+	rng := rand.New(rand.NewSource(1))
+	// Two segments, so the index covers more than one
+	embeddings := clusteredEmbeddings(rng, 300, 16, 5)
+	if err := s.upsert(model, embeddings[:200]); err != nil {
+		t.Fatal(err)
+	}
+	if err := s.upsert(model, embeddings[200:]); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := s.buildIndexes(8, 100, nil); err != nil {
+		t.Fatal(err)
+	}
+
+	path := hnswPath(s.dir, "mock/hashed-words", 16)
+	built := s.indexes[path]
+	if built == nil {
+		t.Fatal("no index was built")
+	}
+	if !slices.Equal(built.seqs, []int{1, 2}) {
+		t.Fatalf("index covers segments %v, want [1 2]", built.seqs)
+	}
+
+	read, err := readHNSW(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if read.model != built.model || read.dims != built.dims || read.m != built.m || read.entry != built.entry || read.maxLevel != built.maxLevel || !slices.Equal(read.seqs, built.seqs) {
+		t.Fatalf("read %s/%d m=%d entry=%d level=%d seqs=%v, wrote %s/%d m=%d entry=%d level=%d seqs=%v",
+			read.model, read.dims, read.m, read.entry, read.maxLevel, read.seqs,
+			built.model, built.dims, built.m, built.entry, built.maxLevel, built.seqs)
+	}
+	if len(read.nodes) != len(built.nodes) {
+		t.Fatalf("read %d nodes, wrote %d", len(read.nodes), len(built.nodes))
+	}
+	for i := range read.nodes {
+		a, b := read.nodes[i], built.nodes[i]
+		if a.seq != b.seq || a.row != b.row || a.norm != b.norm || len(a.friends) != len(b.friends) {
+			t.Fatalf("node %d differs", i)
+		}
+		for l := range a.friends {
+			if !slices.Equal(a.friends[l], b.friends[l]) {
+				t.Fatalf("node %d layer %d: read friends %v, wrote %v", i, l, a.friends[l], b.friends[l])
+			}
+		}
+	}
+}
+
+func TestHNSWCorruption(t *testing.T) {
+	s := testStore(t)
+	model := ModelInfo{Provider: "mock", Model: "hashed-words"}
+	if err := s.upsert(model, clusteredEmbeddings(rand.New(rand.NewSource(2)), 50, 8, 3)); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := s.buildIndexes(8, 50, nil); err != nil {
+		t.Fatal(err)
+	}
+	path := hnswPath(s.dir, "mock/hashed-words", 8)
+	good, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for _, tc := range []struct {
+		name   string
+		damage func([]byte) []byte
+		want   string
+	}{
+		{"flipped byte", func(data []byte) []byte { data[len(data)/2] ^= 0xff; return data }, "checksum"},
+		{"truncated", func(data []byte) []byte { return data[:len(data)-7] }, "checksum"},
+		{"empty", func([]byte) []byte { return nil }, "isn't an ember HNSW index"},
+		{"wrong magic", func(data []byte) []byte { copy(data, "NOPE"); return data }, "isn't an ember HNSW index"},
+		{"unknown version", func(data []byte) []byte { data[4] = 99; return data }, "isn't an ember HNSW index"},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			damaged := filepath.Join(t.TempDir(), "hnsw.idx")
+			if err := os.WriteFile(damaged, tc.damage(slices.Clone(good)), 0o644); err != nil {
+				t.Fatal(err)
+			}
+			_, err := readHNSW(damaged)
+			if err == nil {
+				t.Fatal("read a damaged index")
+			}
+			if !strings.Contains(err.Error(), tc.want) {
+				t.Fatalf("got %q, want it to mention %q", err, tc.want)
+			}
+		})
+	}
+
+	// A damaged index in the store is ignored, and searches scan instead
+	if err := os.WriteFile(path, good[:len(good)/2], 0o644); err != nil {
+		t.Fatal(err)
+	}
+	delete(s.indexes, path)
+	if s.index("mock/hashed-words", 8) != nil {
+		t.Fatal("the store used a damaged index")
+	}
+	hits, _, err := s.Search(model, clusteredEmbeddings(rand.New(rand.NewSource(2)), 1, 8, 3)[0].Embedding, 5)
+	if err != nil || len(hits) != 5 {
+		t.Fatalf("got %d hits and %v searching past a damaged index", len(hits), err)
+	}
+}
+
+func TestHNSWRecall(t *testing.T) {
+	const (
+		texts   = 5000
+		dims    = 32
+		queries = 100
+		k       = 10
+	)
+	s := testStore(t)
+	model := ModelInfo{Provider: "mock", Model: "hashed-words"}
+	rng := rand.New(rand.NewSource(3))
+	embeddings := clusteredEmbeddings(rng, texts+queries, dims, 50)
+	corpus, held := embeddings[:texts], embeddings[texts:]
+	if err := s.upsert(model, corpus); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := s.buildIndexes(defaultHNSWNeighbors, defaultHNSWBuildEf, nil); err != nil {
+		t.Fatal(err)
+	}
+
+	found := 0
+	for _, query := range held {
+		exact := make([]int, len(corpus))
+		scores := make([]float64, len(corpus))
+		for i, e := range corpus {
+			exact[i], scores[i] = i, cosineSimilarity(query.Embedding, e.Embedding)
+		}
+		sort.Slice(exact, func(a, b int) bool { return scores[exact[a]] > scores[exact[b]] })
+		want := make(map[string]bool, k)
+		for _, i := range exact[:k] {
+			want[corpus[i].Text] = true
+		}
+
+		hits, hitScores, err := s.Search(model, query.Embedding, k)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if len(hits) != k {
+			t.Fatalf("got %d hits, want %d", len(hits), k)
+		}
+		for i, hit := range hits {
+			if want[hit.Text] {
+				found++
+			}
+			if i > 0 && hitScores[i] > hitScores[i-1] {
+				t.Fatalf("hits aren't best first: %v", hitScores)
+			}
+		}
+	}
+	recall := float64(found) / (queries * k)
+	t.Logf("recall@%d over %d texts: %.3f", k, texts, recall)
+	if recall < 0.95 || math.IsNaN(recall) {
+		t.Fatalf("recall@%d is %.3f, want at least 0.95", k, recall)
+	}
+}
@@ -0,0 +1,186 @@
+package main
+
+import (
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+)
+
+// readEmbeddingsJSONL reads pre-computed embeddings in the format Alt+E
+// exports. Input records are skipped; every other record must have text and
+// a vector of the same length, and all records must name the same model.
+func readEmbeddingsJSONL(r io.Reader) (ModelInfo, []CustomEmbedding, error) {
+	var info ModelInfo
+	var embeddings []CustomEmbedding
+	model := ""
+
+	dec := json.NewDecoder(r)
+	for line := 1; ; line++ {
+		var record exportRecord
+		err := dec.Decode(&record)
+		if errors.Is(err, io.EOF) {
+			break
+		}
+		if err != nil {
+			return info, nil, fmt.Errorf("record %d: %w", line, err)
+		}
+		if record.Kind == "input" {
+			continue
+		}
+
+		switch {
+		case strings.TrimSpace(record.Text) == "":
+			return info, nil, fmt.Errorf("record %d has no text", line)
+		case len(record.Embedding) == 0:
+			return info, nil, fmt.Errorf("record %d has no embedding", line)
+		case len(embeddings) == 0:
+			model = record.Model
+			info.Dimensions = len(record.Embedding)
+		case len(record.Embedding) != info.Dimensions:
+			return info, nil, fmt.Errorf("record %d has %d dimensions, expected %d", line, len(record.Embedding), info.Dimensions)
+		case record.Model != model:
+			return info, nil, fmt.Errorf("record %d was embedded with %q, not %q", line, record.Model, model)
+		}
+		embeddings = append(embeddings, CustomEmbedding{Text: record.Text, Embedding: record.Embedding})
+	}
+
+	if len(embeddings) == 0 {
+		return info, nil, fmt.Errorf("no comparison embeddings found")
+	}
+	info.Provider, info.Model, _ = strings.Cut(model, "/")
+	return info, embeddings, nil
+}
+
+func readEmbeddingsFile(path string) (ModelInfo, []CustomEmbedding, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return ModelInfo{}, nil, fmt.Errorf("failed to open %s: %w", path, err)
+	}
+	defer f.Close()
+
+	info, embeddings, err := readEmbeddingsJSONL(f)
+	if err != nil {
+		return info, nil, fmt.Errorf("failed to import %s: %w", path, err)
+	}
+	return info, embeddings, nil
+}
+
+// readImportFile reads embeddings from a JSONL, Parquet, .npy or .npz file.
+// NumPy files carry no model, so the returned model is empty for them.
+func readImportFile(path string) (ModelInfo, []CustomEmbedding, error) {
+	switch strings.ToLower(filepath.Ext(path)) {
+	case ".parquet":
+		return readParquetFile(path)
+	case ".npy", ".npz":
+		embeddings, err := readNumpyFile(path)
+		if err != nil {
+			return ModelInfo{}, nil, err
+		}
+		if len(embeddings) == 0 || len(embeddings[0].Embedding) == 0 {
+			return ModelInfo{}, nil, fmt.Errorf("%s holds no vectors", path)
+		}
+		return ModelInfo{Dimensions: len(embeddings[0].Embedding)}, embeddings, nil
+	}
+	return readEmbeddingsFile(path)
+}
+
+// importName names the set imported from path after the file
+func importName(path string) string {
+	return strings.TrimSuffix(filepath.Base(path), filepath.Ext(path))
+}
+
+// importEmbeddings makes the embeddings in path the comparison set. They
+// must come from the current model, so nothing needs to be re-embedded.
+func (m *model) importEmbeddings(path string) {
+	info, embeddings, err := readEmbeddingsFile(path)
+	if err != nil {
+		m.modelNotice = "⚠️  " + err.Error()
+		return
+	}
+
+	current := m.provider.ModelInfo()
+	if info.Model == "" {
+		info.Provider, info.Model = current.Provider, current.Model
+	}
+	switch {
+	case info.Provider != current.Provider || info.Model != current.Model:
+		m.modelNotice = fmt.Sprintf("⚠️  %s was embedded with %s/%s • switch to it with Alt+M first", path, info.Provider, info.Model)
+		return
+	case current.Dimensions != 0 && current.Dimensions != info.Dimensions:
+		m.modelNotice = fmt.Sprintf("⚠️  %s has %d dimensions but %s produces %d", path, info.Dimensions, current.Model, current.Dimensions)
+		return
+	}
+
+	set := comparisonSet{Name: importName(path), Model: current, Saved: time.Now(), Embeddings: embeddings}
+	if err := m.store.SaveSet(set); err != nil {
+		m.modelNotice = "⚠️  " + err.Error()
+		return
+	}
+	m.applySet(set)
+	m.trackStoredSet(set)
+	rememberSet(m.setsDir, set.Name)
+	m.modelNotice = fmt.Sprintf("📥 Imported %d embeddings from %s as %q", len(embeddings), path, set.Name)
+}
+
+// runImportCommand saves a JSONL, Parquet or NumPy file of embeddings as a
+// comparison set, which opens on the next start
+func runImportCommand(args []string) {
+	fs := flag.NewFlagSet("import", flag.ExitOnError)
+	name := fs.String("name", "", "name of the set (default: the file name)")
+	modelName := fs.String("model", "", "provider/model that produced the embeddings, when the file doesn't say")
+	fs.Parse(args)
+
+	if fs.NArg() != 1 {
+		fmt.Println("Usage: ember import [--name NAME] [--model PROVIDER/MODEL] FILE.jsonl|FILE.parquet|FILE.npy|FILE.npz")
+		os.Exit(2)
+	}
+	path := fs.Arg(0)
+
+	info, embeddings, err := readImportFile(path)
+	if err != nil {
+		displayError(err)
+		os.Exit(1)
+	}
+	if *modelName != "" {
+		provider, model, _ := strings.Cut(*modelName, "/")
+		if info.Model != "" && (info.Provider != provider || info.Model != model) {
+			displayError(fmt.Errorf("%s was embedded with %s/%s, not %s", path, info.Provider, info.Model, *modelName))
+			os.Exit(1)
+		}
+		info.Provider, info.Model = provider, model
+	}
+	if info.Model == "" {
+		displayError(fmt.Errorf("%s doesn't say which model produced it • pass --model PROVIDER/MODEL", path))
+		os.Exit(1)
+	}
+	if *name == "" {
+		*name = importName(path)
+	}
+
+	dataDir, err := userDataDir()
+	if err != nil {
+		displayError(err)
+		os.Exit(1)
+	}
+	store, closeStore, err := openStore(dataDir)
+	if err != nil {
+		displayError(err)
+		os.Exit(1)
+	}
+	defer closeStore()
+	setsDir := filepath.Join(dataDir, "sets")
+
+	set := comparisonSet{Name: *name, Model: info, Saved: time.Now(), Embeddings: embeddings}
+	if err := store.SaveSet(set); err != nil {
+		displayError(err)
+		os.Exit(1)
+	}
+	rememberSet(setsDir, set.Name)
+	fmt.Printf("📥 Saved %d embeddings (%s/%s, %d dimensions) as set %q\n", len(embeddings), info.Provider, info.Model, info.Dimensions, set.Name)
+}
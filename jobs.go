@@ -0,0 +1,574 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/lipgloss"
+)
+
+type jobState int
+
+const (
+	jobQueued jobState = iota
+	jobRunning
+	jobRateLimited
+	jobSucceeded
+	jobFailed
+	jobCancelled
+)
+
+func (s jobState) String() string {
+	switch s {
+	case jobQueued:
+		return "queued"
+	case jobRunning:
+		return "running"
+	case jobRateLimited:
+		return "rate limited"
+	case jobSucceeded:
+		return "done"
+	case jobFailed:
+		return "failed"
+	default:
+		return "cancelled"
+	}
+}
+
+// Keep only the most recent log lines per job
+const maxJobLogs = 50
+
+type jobFunc func(ctx context.Context, job *Job) (any, error)
+
+// Job is a long-running operation executed in the background
+type Job struct {
+	ID   int
+	Name string
+
+	run    jobFunc
+	notify func(*Job)
+	cancel context.CancelFunc
+
+	mu       sync.Mutex
+	state    jobState
+	done     int
+	total    int
+	pending  []string
+	logs     []string
+	result   any
+	err      error
+	paused   bool
+	retryAt  time.Time
+	started  time.Time
+	finished time.Time
+}
+
+// JobSnapshot is a copy of a job's state that is safe to render
+type JobSnapshot struct {
+	ID       int
+	Name     string
+	State    jobState
+	Done     int
+	Total    int
+	Pending  []string
+	Logs     []string
+	Err      error
+	Paused   bool
+	RetryAt  time.Time
+	Started  time.Time
+	Finished time.Time
+}
+
+func (j *Job) Snapshot() JobSnapshot {
+	j.mu.Lock()
+	defer j.mu.Unlock()
+
+	return JobSnapshot{
+		ID:       j.ID,
+		Name:     j.Name,
+		State:    j.state,
+		Done:     j.done,
+		Total:    j.total,
+		Pending:  append([]string(nil), j.pending...),
+		Logs:     append([]string(nil), j.logs...),
+		Err:      j.err,
+		Paused:   j.paused,
+		RetryAt:  j.retryAt,
+		Started:  j.started,
+		Finished: j.finished,
+	}
+}
+
+func (s JobSnapshot) IsFinished() bool {
+	return s.State == jobSucceeded || s.State == jobFailed || s.State == jobCancelled
+}
+
+func (j *Job) update(fn func()) {
+	j.mu.Lock()
+	fn()
+	j.mu.Unlock()
+	j.notify(j)
+}
+
+// SetItems records the work items so progress and the pending queue can be shown
+func (j *Job) SetItems(items []string) {
+	j.update(func() {
+		j.pending = append([]string(nil), items...)
+		j.total = len(items)
+		j.done = 0
+	})
+}
+
+// SetProgress records progress for jobs whose work isn't a list of items
+func (j *Job) SetProgress(done, total int) {
+	j.update(func() {
+		j.done = done
+		j.total = total
+	})
+}
+
+// Advance marks the oldest pending item as finished
+func (j *Job) Advance() {
+	j.update(func() {
+		if len(j.pending) > 0 {
+			j.pending = j.pending[1:]
+		}
+		j.done++
+	})
+}
+
+func (j *Job) Logf(format string, args ...any) {
+	j.update(func() {
+		line := time.Now().Format("15:04:05") + " " + fmt.Sprintf(format, args...)
+		j.logs = append(j.logs, line)
+		if len(j.logs) > maxJobLogs {
+			j.logs = j.logs[len(j.logs)-maxJobLogs:]
+		}
+	})
+}
+
+func (j *Job) setPaused(paused bool) {
+	j.update(func() {
+		j.paused = paused
+	})
+}
+
+// Checkpoint blocks while the job is paused and reports cancellation
+func (j *Job) Checkpoint(ctx context.Context) error {
+	for {
+		j.mu.Lock()
+		paused := j.paused
+		j.mu.Unlock()
+
+		if !paused {
+			return ctx.Err()
+		}
+
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-time.After(200 * time.Millisecond):
+		}
+	}
+}
+
+// WaitForRetry parks the job in the rate-limited state until the wait elapses
+func (j *Job) WaitForRetry(ctx context.Context, wait time.Duration) error {
+	j.update(func() {
+		j.state = jobRateLimited
+		j.retryAt = time.Now().Add(wait)
+	})
+
+	for {
+		j.mu.Lock()
+		ready := !j.paused && !time.Now().Before(j.retryAt)
+		j.mu.Unlock()
+
+		if ready {
+			break
+		}
+
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-time.After(time.Second):
+			// Refresh the countdown on the jobs screen
+			j.notify(j)
+		}
+	}
+
+	j.update(func() {
+		j.state = jobRunning
+	})
+	return nil
+}
+
+// TakeResult returns the job's result once, so completions are only applied a single time
+func (j *Job) TakeResult() any {
+	j.mu.Lock()
+	defer j.mu.Unlock()
+
+	result := j.result
+	j.result = nil
+	return result
+}
+
+// JobManager runs jobs in the background and reports their progress to the TUI
+type JobManager struct {
+	mu      sync.Mutex
+	jobs    []*Job
+	nextID  int
+	updates chan *Job
+	// running counts job goroutines, for Stop
+	running sync.WaitGroup
+}
+
+// Messages for job progress
+type jobUpdateMsg struct {
+	job *Job
+}
+
+func NewJobManager() *JobManager {
+	return &JobManager{
+		nextID:  1,
+		updates: make(chan *Job, 64),
+	}
+}
+
+func (jm *JobManager) Submit(name string, run jobFunc) *Job {
+	jm.mu.Lock()
+	job := &Job{
+		ID:     jm.nextID,
+		Name:   name,
+		run:    run,
+		notify: jm.notify,
+		state:  jobQueued,
+	}
+	jm.nextID++
+	jm.jobs = append(jm.jobs, job)
+	jm.mu.Unlock()
+
+	ctx, cancel := context.WithCancel(context.Background())
+	job.cancel = cancel
+
+	jm.running.Add(1)
+	go func() {
+		defer jm.running.Done()
+		job.update(func() {
+			job.state = jobRunning
+			job.started = time.Now()
+		})
+
+		result, err := job.run(ctx, job)
+
+		job.update(func() {
+			job.finished = time.Now()
+			switch {
+			case errors.Is(err, context.Canceled):
+				job.state = jobCancelled
+			case err != nil:
+				job.state = jobFailed
+				job.err = err
+				job.logs = append(job.logs, "error: "+err.Error())
+			default:
+				job.state = jobSucceeded
+				job.result = result
+			}
+		})
+		cancel()
+	}()
+
+	return job
+}
+
+// notify wakes the TUI without ever blocking a job goroutine
+func (jm *JobManager) notify(job *Job) {
+	select {
+	case jm.updates <- job:
+	default:
+	}
+}
+
+func (jm *JobManager) Cancel(id int) {
+	if job := jm.find(id); job != nil {
+		job.cancel()
+	}
+}
+
+// Stop cancels every unfinished job and waits for them to return
+func (jm *JobManager) Stop() {
+	for _, s := range jm.Snapshots() {
+		if !s.IsFinished() {
+			jm.Cancel(s.ID)
+		}
+	}
+	jm.running.Wait()
+}
+
+func (jm *JobManager) TogglePause(id int) {
+	if job := jm.find(id); job != nil {
+		job.setPaused(!job.Snapshot().Paused)
+	}
+}
+
+// Retry resubmits a finished job as a new job with the same work
+func (jm *JobManager) Retry(id int) *Job {
+	job := jm.find(id)
+	if job == nil || !job.Snapshot().IsFinished() {
+		return nil
+	}
+	return jm.Submit(job.Name, job.run)
+}
+
+func (jm *JobManager) find(id int) *Job {
+	jm.mu.Lock()
+	defer jm.mu.Unlock()
+
+	for _, job := range jm.jobs {
+		if job.ID == id {
+			return job
+		}
+	}
+	return nil
+}
+
+func (jm *JobManager) Snapshots() []JobSnapshot {
+	jm.mu.Lock()
+	jobs := append([]*Job(nil), jm.jobs...)
+	jm.mu.Unlock()
+
+	snapshots := make([]JobSnapshot, len(jobs))
+	for i, job := range jobs {
+		snapshots[i] = job.Snapshot()
+	}
+	return snapshots
+}
+
+// Active counts jobs that have not finished yet
+func (jm *JobManager) Active() int {
+	active := 0
+	for _, s := range jm.Snapshots() {
+		if !s.IsFinished() {
+			active++
+		}
+	}
+	return active
+}
+
+// applyFinishedJobs hands results of completed jobs back to the model
+func (m *model) applyFinishedJobs() {
+	m.jobs.mu.Lock()
+	jobs := append([]*Job(nil), m.jobs.jobs...)
+	m.jobs.mu.Unlock()
+
+	for _, job := range jobs {
+		switch result := job.TakeResult().(type) {
+		case []CustomEmbedding:
+			m.customEmbeddings = result
+		}
+	}
+}
+
+func (m model) selectedJobID() (int, bool) {
+	jobs := m.jobs.Snapshots()
+	if m.selectedJob < 0 || m.selectedJob >= len(jobs) {
+		return 0, false
+	}
+	return jobs[m.selectedJob].ID, true
+}
+
+func waitForJobUpdate(updates chan *Job) tea.Cmd {
+	return func() tea.Msg {
+		return jobUpdateMsg{job: <-updates}
+	}
+}
+
+// embedComparisonsJob embeds a comparison set, waiting out rate limits
+// instead of failing. Texts with {{variables}} are embedded with values
+// substituted, and keep the text as written as their template.
+func (m model) embedComparisonsJob(templates []string, values map[string]string) jobFunc {
+	texts := make([]string, len(templates))
+	for i, template := range templates {
+		texts[i] = resolveTemplate(template, values)
+	}
+	provider := m.backgroundProvider()
+	chunking, sparse, fieldWeights, summaries := m.chunking, m.sparse, m.fieldWeights, m.summary
+	base := m.provider
+	// Summaries already made are kept, since they cost an LLM call each
+	previous := make(map[string]string)
+	for _, e := range m.customEmbeddings {
+		previous[e.Text] = e.Summary
+	}
+	return func(ctx context.Context, job *Job) (any, error) {
+		job.SetItems(texts)
+		embeddings := make([]CustomEmbedding, 0, len(texts))
+
+		for i := 0; i < len(texts); {
+			if err := job.Checkpoint(ctx); err != nil {
+				return nil, err
+			}
+
+			embedding, err := provider.GenerateEmbedding(texts[i])
+			var chunks [][]float64
+			if err == nil {
+				chunks, err = chunking.embedChunks(provider, texts[i])
+			}
+			var sparseVector *SparseVector
+			if err == nil {
+				sparseVector, err = sparse.encode(base, texts[i])
+			}
+			var fields map[string][]float64
+			if err == nil {
+				fields, err = embedFields(provider, fieldWeights, texts[i])
+			}
+			var summary string
+			if err == nil {
+				summary, err = summaries.summarize(base, texts[i], previous[texts[i]])
+			}
+			if err == nil {
+				fields, err = addSummaryField(provider, fields, summary, embedding)
+			}
+
+			var rateErr *RateLimitError
+			if errors.As(err, &rateErr) {
+				job.Logf("rate limited, retrying in %s", rateErr.RetryAfter.Round(time.Second))
+				if err := job.WaitForRetry(ctx, rateErr.RetryAfter); err != nil {
+					return nil, err
+				}
+				continue
+			}
+			if err != nil {
+				return nil, err
+			}
+
+			template := ""
+			if templates[i] != texts[i] {
+				template = templates[i]
+			}
+			embeddings = append(embeddings, CustomEmbedding{
+				Text:      texts[i],
+				Template:  template,
+				Embedding: embedding,
+				Chunks:    chunks,
+				Sparse:    sparseVector,
+				Fields:    fields,
+				Summary:   summary,
+			})
+			job.Advance()
+			job.Logf("embedded text %d/%d", i+1, len(texts))
+			i++
+		}
+
+		return embeddings, nil
+	}
+}
+
+func (m model) renderJobsScreen() string {
+	s := "\033[2J\033[H" // Clear screen and move cursor to top
+
+	// Add header
+	s += "╭─────────────────────────────────────────────────────────────────────────────╮\n"
+	s += "│                               ⚙️  JOBS ⚙️                                    │\n"
+	s += "│                   Background work runs while you keep typing                │\n"
+	s += "╰─────────────────────────────────────────────────────────────────────────────╯\n\n"
+
+	labelStyle := lipgloss.NewStyle().
+		Foreground(theme.Primary).
+		Bold(true)
+
+	dimStyle := lipgloss.NewStyle().
+		Foreground(theme.Muted)
+
+	errorStyle := lipgloss.NewStyle().
+		Foreground(theme.Warning)
+
+	jobs := m.jobs.Snapshots()
+	if len(jobs) == 0 {
+		s += dimStyle.Render("No jobs yet. Generating a comparison set starts one.") + "\n"
+	}
+
+	// Newest first
+	for i := len(jobs) - 1; i >= 0; i-- {
+		job := jobs[i]
+		line := fmt.Sprintf("#%d %-30s %-12s %d/%d", job.ID, job.Name, job.State, job.Done, job.Total)
+		if job.Paused {
+			line += " (paused)"
+		}
+		if i == m.selectedJob {
+			s += labelStyle.Render("▶ "+line) + "\n"
+		} else {
+			s += "  " + line + "\n"
+		}
+	}
+
+	if m.selectedJob >= 0 && m.selectedJob < len(jobs) {
+		job := jobs[m.selectedJob]
+		s += "\n"
+
+		progress := 0.0
+		if job.Total > 0 {
+			progress = float64(job.Done) / float64(job.Total)
+		}
+		s += m.jobProgress.ViewAs(progress) + "\n\n"
+
+		if job.State == jobRateLimited {
+			wait := time.Until(job.RetryAt).Round(time.Second)
+			if wait < 0 {
+				wait = 0
+			}
+			status := fmt.Sprintf("⏱  Rate limited, retrying in %s", wait)
+			if job.Paused {
+				status = "⏸  Paused"
+			}
+			s += labelStyle.Render(status) + "\n"
+			for i, text := range job.Pending {
+				s += dimStyle.Width(75).Render(fmt.Sprintf("%d. %s", i+1, text)) + "\n"
+			}
+			s += "\n"
+		}
+
+		if job.Err != nil {
+			s += errorStyle.Render("❌ "+job.Err.Error()) + "\n"
+		}
+
+		// Tail of the job log
+		logs := job.Logs
+		if len(logs) > 5 {
+			logs = logs[len(logs)-5:]
+		}
+		for _, line := range logs {
+			s += dimStyle.Render(line) + "\n"
+		}
+	}
+
+	// Instructions
+	instructStyle := lipgloss.NewStyle().
+		Foreground(theme.Muted).
+		Italic(true)
+
+	s += "\n" + instructStyle.Render("💡 ↑/↓ to select • P to pause/resume • C to cancel • R to retry • Esc to return") + "\n"
+
+	// Add padding
+	for i := 0; i < 5; i++ {
+		s += "\n"
+	}
+
+	return s
+}
+
+// renderJobStatus is a one-line summary of running jobs for the other screens
+func (m model) renderJobStatus() string {
+	active := m.jobs.Active()
+	if active == 0 {
+		return ""
+	}
+
+	statusStyle := lipgloss.NewStyle().
+		Foreground(theme.Accent)
+
+	return statusStyle.Render(fmt.Sprintf("⚙️  %d job(s) running • Ctrl+O to view", active)) + "\n"
+}
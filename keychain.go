@@ -0,0 +1,179 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/charmbracelet/bubbles/textinput"
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/lipgloss"
+	"github.com/zalando/go-keyring"
+)
+
+// Keys are stored in the system keychain under this service, one per provider
+const keychainService = "ember"
+
+// keychainProviders records the providers whose key came from the keychain, for ember doctor
+var keychainProviders = make(map[string]bool)
+
+// errKeyPromptCancelled is returned when the key prompt is closed without a key
+var errKeyPromptCancelled = errors.New("no API key entered")
+
+// loadKeychainKeys fills in the key of the providers in use from the system
+// keychain, when the environment doesn't set one. EMBER_NO_KEYCHAIN turns
+// this off, e.g. on servers without a keychain.
+func loadKeychainKeys() {
+	if os.Getenv("EMBER_NO_KEYCHAIN") != "" {
+		return
+	}
+
+	secondary, _, _ := strings.Cut(os.Getenv("EMBER_COMPARE_PROVIDER"), ":")
+	for _, provider := range []string{loadProviderName(), secondary} {
+		env, ok := providerKeyEnv[provider]
+		if !ok || !missingAPIKey(provider) {
+			continue
+		}
+		if key, err := keyring.Get(keychainService, provider); err == nil {
+			os.Setenv(env, key)
+			keychainProviders[provider] = true
+		}
+	}
+}
+
+// missingAPIKey reports whether provider needs a key and none is set
+func missingAPIKey(provider string) bool {
+	env, ok := providerKeyEnv[provider]
+	switch {
+	case !ok:
+		return false
+	case provider == "openai":
+		// Self-hosted servers often run without authentication
+		return len(loadAPIKeys()) == 0 && os.Getenv("EMBER_BASE_URL") == ""
+	default:
+		return os.Getenv(env) == ""
+	}
+}
+
+// interactive reports whether stdin is a terminal someone can type a key into
+func interactive() bool {
+	info, err := os.Stdin.Stat()
+	return err == nil && info.Mode()&os.ModeCharDevice != 0
+}
+
+// promptForAPIKey asks for provider's key, stores it in the keychain and
+// sets it for this run. When there is no keychain the key is still used,
+// but will be asked for again next time.
+func promptForAPIKey(provider string) error {
+	final, err := tea.NewProgram(newKeyPromptModel(provider)).Run()
+	if err != nil {
+		return fmt.Errorf("failed to prompt for API key: %w", err)
+	}
+	key := final.(keyPromptModel).key
+	if key == "" {
+		return errKeyPromptCancelled
+	}
+
+	os.Setenv(providerKeyEnv[provider], key)
+	if err := keyring.Set(keychainService, provider, key); err != nil {
+		fmt.Printf("⚠️  Couldn't save the key to the system keychain (%v); it's only used this time\n", err)
+		return nil
+	}
+	keychainProviders[provider] = true
+	fmt.Println("🔑 Saved the key to the system keychain")
+	return nil
+}
+
+type keyPromptModel struct {
+	provider string
+	input    textinput.Model
+	key      string
+}
+
+func newKeyPromptModel(provider string) keyPromptModel {
+	ti := textinput.New()
+	ti.Placeholder = "paste your key"
+	ti.EchoMode = textinput.EchoPassword
+	ti.EchoCharacter = '•'
+	ti.Width = 60
+	ti.Focus()
+	return keyPromptModel{provider: provider, input: ti}
+}
+
+func (m keyPromptModel) Init() tea.Cmd {
+	return textinput.Blink
+}
+
+func (m keyPromptModel) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
+	if msg, ok := msg.(tea.KeyMsg); ok {
+		switch msg.String() {
+		case "ctrl+c", "esc":
+			return m, tea.Quit
+		case "enter":
+			if key := strings.Trim(strings.TrimSpace(m.input.Value()), "\"'"); key != "" {
+				m.key = key
+				return m, tea.Quit
+			}
+			return m, nil
+		}
+	}
+
+	var cmd tea.Cmd
+	m.input, cmd = m.input.Update(msg)
+	return m, cmd
+}
+
+func (m keyPromptModel) View() string {
+	if m.key != "" {
+		return ""
+	}
+
+	labelStyle := lipgloss.NewStyle().
+		Foreground(theme.Primary).
+		Bold(true)
+	instructStyle := lipgloss.NewStyle().
+		Foreground(theme.Muted).
+		Italic(true)
+
+	s := "\n" + labelStyle.Render(fmt.Sprintf("🔑 ember needs an API key for %s", m.provider)) + "\n\n"
+	s += m.input.View() + "\n\n"
+	s += instructStyle.Render("It's stored in your system keychain, not in a file • Enter to save • Esc to quit") + "\n"
+	return s
+}
+
+// runKeyCommand handles `ember key set [provider]` and `ember key delete [provider]`
+func runKeyCommand(args []string) {
+	if len(args) == 0 || (args[0] != "set" && args[0] != "delete") {
+		fmt.Println("Usage: ember key set|delete [provider]")
+		os.Exit(2)
+	}
+
+	provider := loadProviderName()
+	if len(args) > 1 {
+		provider = args[1]
+	}
+	if _, ok := providerKeyEnv[provider]; !ok {
+		displayError(fmt.Errorf("provider %q doesn't use an API key", provider))
+		os.Exit(1)
+	}
+
+	switch args[0] {
+	case "set":
+		if err := promptForAPIKey(provider); err != nil {
+			displayError(err)
+			os.Exit(1)
+		}
+	case "delete":
+		err := keyring.Delete(keychainService, provider)
+		if errors.Is(err, keyring.ErrNotFound) {
+			fmt.Printf("No %s key in the system keychain\n", provider)
+			return
+		}
+		if err != nil {
+			displayError(fmt.Errorf("failed to delete key: %w", err))
+			os.Exit(1)
+		}
+		fmt.Printf("🗑  Deleted the %s key from the system keychain\n", provider)
+	}
+}
@@ -0,0 +1,204 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+type keyRotation int
+
+const (
+	roundRobinRotation keyRotation = iota
+	quotaAwareRotation
+)
+
+// How long a key is skipped after the provider rate-limits it
+const rateLimitCooldown = 30 * time.Second
+
+type apiKey struct {
+	value        string
+	requests     int
+	tokens       int
+	errors       int
+	coolingUntil time.Time
+}
+
+// KeyUsage is a snapshot of how much a single API key has been used this session
+type KeyUsage struct {
+	Key      string
+	Requests int
+	Tokens   int
+	Errors   int
+	Quota    int
+	Cooling  bool
+}
+
+// keyUsageReporter is implemented by providers that rotate between several API keys
+type keyUsageReporter interface {
+	KeyUsage() []KeyUsage
+}
+
+// KeyPool hands out API keys for a provider and tracks per-key usage
+type KeyPool struct {
+	mu       sync.Mutex
+	keys     []*apiKey
+	rotation keyRotation
+	quota    int
+	next     int
+}
+
+func NewKeyPool(keys []string, rotation keyRotation, quota int) *KeyPool {
+	pool := &KeyPool{rotation: rotation, quota: quota}
+	for _, k := range keys {
+		pool.keys = append(pool.keys, &apiKey{value: k})
+	}
+	return pool
+}
+
+// loadAPIKeys collects keys from OPENAI_API_KEYS (comma separated) and OPENAI_API_KEY
+func loadAPIKeys() []string {
+	var keys []string
+	seen := make(map[string]bool)
+
+	add := func(k string) {
+		k = strings.TrimSpace(k)
+		if k != "" && !seen[k] {
+			seen[k] = true
+			keys = append(keys, k)
+		}
+	}
+
+	for _, k := range strings.Split(os.Getenv("OPENAI_API_KEYS"), ",") {
+		add(k)
+	}
+	add(os.Getenv("OPENAI_API_KEY"))
+
+	return keys
+}
+
+func loadKeyRotation() keyRotation {
+	if strings.EqualFold(os.Getenv("EMBER_KEY_ROTATION"), "quota") {
+		return quotaAwareRotation
+	}
+	return roundRobinRotation
+}
+
+// loadKeyQuota reads the per-key token budget from EMBER_KEY_QUOTA (0 means unlimited)
+func loadKeyQuota() int {
+	quota, err := strconv.Atoi(os.Getenv("EMBER_KEY_QUOTA"))
+	if err != nil || quota < 0 {
+		return 0
+	}
+	return quota
+}
+
+func (p *KeyPool) Len() int {
+	return len(p.keys)
+}
+
+// Acquire picks the next usable key according to the rotation strategy
+func (p *KeyPool) Acquire() (*apiKey, error) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	if len(p.keys) == 0 {
+		return nil, fmt.Errorf("API key not configured")
+	}
+
+	now := time.Now()
+	usable := func(k *apiKey) bool {
+		if now.Before(k.coolingUntil) {
+			return false
+		}
+		return p.quota == 0 || k.tokens < p.quota
+	}
+
+	if p.rotation == quotaAwareRotation {
+		var best *apiKey
+		for _, k := range p.keys {
+			if usable(k) && (best == nil || k.tokens < best.tokens) {
+				best = k
+			}
+		}
+		if best != nil {
+			return best, nil
+		}
+	} else {
+		for i := 0; i < len(p.keys); i++ {
+			k := p.keys[(p.next+i)%len(p.keys)]
+			if usable(k) {
+				p.next = (p.next + i + 1) % len(p.keys)
+				return k, nil
+			}
+		}
+	}
+
+	// If any key is only cooling down, report when the soonest one frees up
+	var soonest time.Duration
+	for _, k := range p.keys {
+		if wait := k.coolingUntil.Sub(now); wait > 0 && (p.quota == 0 || k.tokens < p.quota) {
+			if soonest == 0 || wait < soonest {
+				soonest = wait
+			}
+		}
+	}
+	if soonest > 0 {
+		return nil, &RateLimitError{RetryAfter: soonest}
+	}
+
+	return nil, fmt.Errorf("all %d API keys are over quota", len(p.keys))
+}
+
+// Record accounts a finished request against the key that made it
+func (p *KeyPool) Record(k *apiKey, tokens int, failed bool) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	k.requests++
+	k.tokens += tokens
+	if failed {
+		k.errors++
+	}
+}
+
+// Cooldown marks a key as rate limited so rotation skips it for a while
+func (p *KeyPool) Cooldown(k *apiKey, d time.Duration) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	if d <= 0 {
+		d = rateLimitCooldown
+	}
+	k.coolingUntil = time.Now().Add(d)
+}
+
+func (p *KeyPool) Usage() []KeyUsage {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	now := time.Now()
+	usage := make([]KeyUsage, len(p.keys))
+	for i, k := range p.keys {
+		usage[i] = KeyUsage{
+			Key:      maskKey(k.value),
+			Requests: k.requests,
+			Tokens:   k.tokens,
+			Errors:   k.errors,
+			Quota:    p.quota,
+			Cooling:  now.Before(k.coolingUntil),
+		}
+	}
+	return usage
+}
+
+// maskKey keeps only the last four characters so keys are safe to display
+func maskKey(key string) string {
+	if len(key) <= 4 {
+		return "****"
+	}
+	return "…" + key[len(key)-4:]
+}
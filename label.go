@@ -0,0 +1,217 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+)
+
+// Neighbors each text of a corpus links to when `ember label` builds its graph
+const defaultLabelNeighbors = 10
+
+// runLabelCommand spreads the #tags of a comparison set over an unlabeled
+// corpus, the local vector store or a file of embeddings, and prints the
+// weak label each text ends up with. The store's neighbors come from its
+// HNSW index where one is built, so the graph costs k searches per text
+// rather than comparing every pair.
+func runLabelCommand(args []string) {
+	fs := flag.NewFlagSet("label", flag.ExitOnError)
+	name := fs.String("set", "", "set whose #tagged texts are the seeds (default: the set opened on start)")
+	file := fs.String("file", "", "JSONL, Parquet, .npy or .npz file of embeddings to label instead of the vector store")
+	k := fs.Int("k", defaultLabelNeighbors, "neighbors per text")
+	minConfidence := fs.Float64("min-confidence", 0, "leave out texts whose label is less certain than this")
+	format := formatFlag(fs, "ndjson")
+	fs.Parse(args)
+	if fs.NArg() > 0 || *k < 1 {
+		fmt.Println("Usage: ember label [--set NAME] [--file FILE] [-k N] [--min-confidence C] [--format table|json|ndjson|csv]")
+		os.Exit(2)
+	}
+	checkFormat(format)
+
+	dataDir, err := userDataDir()
+	if err != nil {
+		displayError(err)
+		os.Exit(1)
+	}
+	store, closeStore, err := openStore(dataDir)
+	if err != nil {
+		displayError(err)
+		os.Exit(1)
+	}
+	defer closeStore()
+	var set comparisonSet
+	if *name == "" {
+		set, err = openStartupSet(store, filepath.Join(dataDir, "sets"))
+	} else {
+		set, err = store.LoadSet(*name)
+	}
+	if err != nil {
+		displayError(err)
+		os.Exit(1)
+	}
+	if set.Model.Model == "" || len(set.Embeddings) == 0 || len(set.Embeddings[0].Embedding) == 0 {
+		displayError(fmt.Errorf("set %q has no vectors yet • open it in ember to embed it", set.Name))
+		os.Exit(1)
+	}
+
+	// The set's tagged texts join the corpus as seeds, along with any
+	// tagged texts the corpus already has
+	dims := len(set.Embeddings[0].Embedding)
+	var seeds []graphNode
+	for i, e := range set.Embeddings {
+		if len(textTags(e.Text)) > 0 && len(e.Embedding) == dims {
+			seeds = append(seeds, graphNode{ID: fmt.Sprintf("C%d", i+1), Text: e.Text, Embedding: e.Embedding})
+		}
+	}
+
+	var graph similarityGraph
+	if *file != "" {
+		info, embeddings, err := readImportFile(*file)
+		if err != nil {
+			displayError(err)
+			os.Exit(1)
+		}
+		// NumPy files don't record a model, so only their dimensions can be checked
+		if info.Model != "" && (info.Provider != set.Model.Provider || info.Model != set.Model.Model) {
+			displayError(fmt.Errorf("%s was embedded with %s/%s, but set %q with %s/%s", *file, info.Provider, info.Model, set.Name, set.Model.Provider, set.Model.Model))
+			os.Exit(1)
+		}
+		nodes := append([]graphNode(nil), seeds...)
+		for i, e := range embeddings {
+			if len(e.Embedding) == dims {
+				nodes = append(nodes, graphNode{ID: strconv.Itoa(i + 1), Text: e.Text, Embedding: e.Embedding})
+			}
+		}
+		graph = buildSimilarityGraph(nodes, *k)
+	} else {
+		dir, err := vectorStoreDir()
+		if err != nil {
+			displayError(err)
+			os.Exit(1)
+		}
+		s, err := openVectorStore(dir)
+		if err != nil {
+			displayError(err)
+			os.Exit(1)
+		}
+		defer s.Close()
+		if graph, err = s.neighborGraph(set.Model.Provider+"/"+set.Model.Model, dims, seeds, *k); err != nil {
+			displayError(err)
+			os.Exit(1)
+		}
+	}
+	if len(graph.Nodes) == len(seeds) {
+		displayError(fmt.Errorf("there are no %d-dimensional texts from %s/%s to label", dims, set.Model.Provider, set.Model.Model))
+		os.Exit(1)
+	}
+	if len(seeds) == 0 && !hasTaggedNode(graph.Nodes) {
+		displayError(fmt.Errorf("set %q has no #tagged texts to spread", set.Name))
+		os.Exit(1)
+	}
+
+	var labels []weakLabel
+	for _, label := range propagateLabels(graph)[len(seeds):] {
+		if label.Label != "" && label.Confidence >= *minConfidence {
+			labels = append(labels, label)
+		}
+	}
+	if err := writeLabels(*format, labels, len(graph.Nodes)-len(seeds)); err != nil {
+		displayError(err)
+		os.Exit(1)
+	}
+}
+
+// hasTaggedNode reports whether any node's text carries a #tag
+func hasTaggedNode(nodes []graphNode) bool {
+	for _, node := range nodes {
+		if len(textTags(node.Text)) > 0 {
+			return true
+		}
+	}
+	return false
+}
+
+// writeLabels prints the weak labels of `ember label`. The table counts the
+// texts given each label out of total.
+func writeLabels(format string, labels []weakLabel, total int) error {
+	if format == "table" {
+		counts := make(map[string]int)
+		for _, label := range labels {
+			counts[label.Label]++
+		}
+		names := make([]string, 0, len(counts))
+		for name := range counts {
+			names = append(names, name)
+		}
+		sort.Strings(names)
+		sort.SliceStable(names, func(i, j int) bool { return counts[names[i]] > counts[names[j]] })
+		fmt.Printf("🏷  Labeled %d of %d texts\n", len(labels), total)
+		for _, name := range names {
+			fmt.Printf("   %-24s %d\n", name, counts[name])
+		}
+		return nil
+	}
+
+	if labels == nil {
+		labels = []weakLabel{}
+	}
+	lines := make([]any, len(labels))
+	rows := make([][]string, len(labels))
+	for i, label := range labels {
+		lines[i] = label
+		rows[i] = []string{label.ID, label.Text, label.Label, strconv.FormatFloat(label.Confidence, 'f', 4, 64), strconv.FormatBool(label.Seed)}
+	}
+	return writeOutput(format, labels, lines, []string{"id", "text", "label", "confidence", "seed"}, rows)
+}
+
+// neighborGraph links the live texts of model with dims-sized vectors, after
+// seeds, to their k nearest texts in the store. Searches go through the
+// model's HNSW index when there is one, so building the graph doesn't
+// compare every pair. Texts are numbered V1, V2, … in store order.
+func (s *vectorStore) neighborGraph(model string, dims int, seeds []graphNode, k int) (similarityGraph, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if err := s.refresh(); err != nil {
+		return similarityGraph{}, err
+	}
+
+	nodes := append([]graphNode(nil), seeds...)
+	index := make(map[vectorRow]int)
+	var rows []vectorRow
+	for _, segment := range s.segments {
+		if segment.model != model || segment.dims != dims {
+			continue
+		}
+		for row, text := range segment.texts {
+			if !s.isLive(segment, row) {
+				continue
+			}
+			r := vectorRow{segment: segment, row: row}
+			index[r] = len(nodes)
+			rows = append(rows, r)
+			// Vectors are read from the segment when searched for, not kept
+			nodes = append(nodes, graphNode{ID: fmt.Sprintf("V%d", len(rows)), Text: text})
+		}
+	}
+
+	graph := similarityGraph{Nodes: nodes, Edges: make([][]graphEdge, len(nodes))}
+	link := func(from int, query []float64) {
+		// One more than k, since a text finds itself
+		best, scores := s.searchRows(model, query, k+1)
+		for i, r := range best {
+			if to, ok := index[r]; ok && to != from && len(graph.Edges[from]) < k {
+				graph.Edges[from] = append(graph.Edges[from], graphEdge{From: from, To: to, Score: scores[i]})
+			}
+		}
+	}
+	for i, seed := range seeds {
+		link(i, seed.Embedding)
+	}
+	for i, r := range rows {
+		link(len(seeds)+i, r.segment.vector(r.row))
+	}
+	return graph, nil
+}
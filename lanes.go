@@ -0,0 +1,110 @@
+package main
+
+import (
+	"os"
+	"strconv"
+	"sync"
+)
+
+type requestLane int
+
+const (
+	interactiveLane requestLane = iota
+	backgroundLane
+)
+
+const defaultMaxConcurrency = 2
+
+// laneScheduler caps concurrent provider calls and always serves interactive
+// waiters before background ones. Background work never takes the last free
+// slot, so a single-text comparison doesn't wait behind a whole job.
+type laneScheduler struct {
+	mu       sync.Mutex
+	slots    int
+	inFlight int
+	waiting  [2][]chan struct{}
+}
+
+func newLaneScheduler(slots int) *laneScheduler {
+	return &laneScheduler{slots: max(slots, 1)}
+}
+
+// loadMaxConcurrency reads EMBER_MAX_CONCURRENCY, falling back to the default
+func loadMaxConcurrency() int {
+	n, err := strconv.Atoi(os.Getenv("EMBER_MAX_CONCURRENCY"))
+	if err != nil || n < 1 {
+		return defaultMaxConcurrency
+	}
+	return n
+}
+
+// canRun reports whether a request in lane may start now; the caller holds mu
+func (s *laneScheduler) canRun(lane requestLane) bool {
+	if lane == backgroundLane && s.slots > 1 {
+		return s.inFlight < s.slots-1
+	}
+	return s.inFlight < s.slots
+}
+
+func (s *laneScheduler) acquire(lane requestLane) {
+	s.mu.Lock()
+	if s.canRun(lane) && (lane == interactiveLane || len(s.waiting[interactiveLane]) == 0) {
+		s.inFlight++
+		s.mu.Unlock()
+		return
+	}
+
+	ready := make(chan struct{})
+	s.waiting[lane] = append(s.waiting[lane], ready)
+	s.mu.Unlock()
+
+	<-ready
+}
+
+func (s *laneScheduler) release() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.inFlight--
+
+	// Hand freed slots to waiters, interactive lane first
+	for _, lane := range []requestLane{interactiveLane, backgroundLane} {
+		for len(s.waiting[lane]) > 0 && s.canRun(lane) {
+			next := s.waiting[lane][0]
+			s.waiting[lane] = s.waiting[lane][1:]
+			s.inFlight++
+			close(next)
+		}
+	}
+}
+
+// lanedProvider routes every call of the wrapped provider through a scheduler lane
+type lanedProvider struct {
+	EmbeddingProvider
+	scheduler *laneScheduler
+	lane      requestLane
+}
+
+func (p lanedProvider) GenerateEmbedding(text string) ([]float64, error) {
+	p.scheduler.acquire(p.lane)
+	defer p.scheduler.release()
+	return p.EmbeddingProvider.GenerateEmbedding(text)
+}
+
+func (p lanedProvider) GenerateBatch(texts []string) ([][]float64, error) {
+	p.scheduler.acquire(p.lane)
+	defer p.scheduler.release()
+	return p.EmbeddingProvider.GenerateBatch(texts)
+}
+
+// interactiveProvider embeds the text typed on the input screen as a query
+func (m model) interactiveProvider() EmbeddingProvider {
+	provider := withPurpose(withInputType(m.provider, m.queryInputType()), "input")
+	return m.withCache(lanedProvider{EmbeddingProvider: provider, scheduler: m.scheduler, lane: interactiveLane}, m.queryInputType())
+}
+
+// backgroundProvider embeds comparison texts as documents
+func (m model) backgroundProvider() EmbeddingProvider {
+	provider := withPurpose(withInputType(m.provider, m.comparisonInputType), "comparison texts")
+	return m.withCache(lanedProvider{EmbeddingProvider: provider, scheduler: m.scheduler, lane: backgroundLane}, m.comparisonInputType)
+}
@@ -0,0 +1,172 @@
+package main
+
+import (
+	"fmt"
+	"math"
+	"strings"
+	"unicode"
+
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/lipgloss"
+)
+
+// tokenEmbedder is implemented by providers that can return one vector per
+// token, as ColBERT-style models do
+type tokenEmbedder interface {
+	GenerateTokenEmbeddings(text string) ([][]float64, error)
+}
+
+// Messages for the late-interaction ranking
+type lateCompleteMsg struct {
+	seq    int
+	scores []float64
+	// tokens is false when segment embeddings stood in for token vectors
+	tokens bool
+	err    error
+}
+
+// maxSim is the late-interaction score: each query vector is matched to its
+// most similar document vector and those similarities are averaged
+func maxSim(query, document [][]float64) float64 {
+	if len(query) == 0 || len(document) == 0 {
+		return 0
+	}
+
+	var sum float64
+	for _, q := range query {
+		best := math.Inf(-1)
+		for _, d := range document {
+			best = math.Max(best, cosineSimilarity(q, d))
+		}
+		sum += best
+	}
+	return sum / float64(len(query))
+}
+
+// splitSegments cuts text into sentences and lines, the proxies embedded when
+// the provider has no token vectors. Text with a single segment returns it whole.
+func splitSegments(text string) []string {
+	var segments []string
+	start := 0
+	for i, r := range text {
+		if r == '.' || r == '!' || r == '?' || r == '\n' || r == ';' {
+			if segment := strings.TrimSpace(text[start : i+1]); strings.TrimFunc(segment, unicode.IsPunct) != "" {
+				segments = append(segments, segment)
+			}
+			start = i + 1
+		}
+	}
+	if segment := strings.TrimSpace(text[start:]); segment != "" {
+		segments = append(segments, segment)
+	}
+	if len(segments) == 0 {
+		return []string{text}
+	}
+	return segments
+}
+
+// multiVectors embeds text as token vectors when the provider offers them,
+// or as one vector per segment otherwise
+func multiVectors(tokens tokenEmbedder, provider EmbeddingProvider, text string) ([][]float64, error) {
+	if tokens != nil {
+		return tokens.GenerateTokenEmbeddings(text)
+	}
+	return provider.GenerateBatch(splitSegments(text))
+}
+
+// toggleLateRanking shows or hides the late-interaction ranking, scoring the
+// results on screen if they haven't been yet
+func (m *model) toggleLateRanking() tea.Cmd {
+	m.lateRanking = !m.lateRanking
+	if !m.lateRanking || m.lateSeq == m.comparisonSeq {
+		return nil
+	}
+	return m.generateLateScores(m.lastInput, m.comparedEmbeddings)
+}
+
+// generateLateScores ranks comparisons by MaxSim over token vectors, or over
+// sentence embeddings for providers that only return one vector per text
+func (m model) generateLateScores(text string, comparisons []CustomEmbedding) tea.Cmd {
+	if !m.lateRanking || len(comparisons) == 0 {
+		return nil
+	}
+
+	seq := m.comparisonSeq
+	tokens, _ := m.provider.(tokenEmbedder)
+	query := withInputType(m.provider, m.queryInputType())
+	document := m.backgroundProvider()
+	if tokens == nil {
+		// Segment embeddings are plain embeddings, so they can be cached
+		query = m.interactiveProvider()
+	}
+	texts := make([]string, len(comparisons))
+	for i, e := range comparisons {
+		texts[i] = e.Text
+	}
+
+	return func() tea.Msg {
+		input, err := multiVectors(tokens, query, text)
+		if err != nil {
+			return lateCompleteMsg{seq: seq, err: fmt.Errorf("failed to embed input: %w", err)}
+		}
+
+		scores := make([]float64, len(texts))
+		for i, t := range texts {
+			vectors, err := multiVectors(tokens, document, t)
+			if err != nil {
+				return lateCompleteMsg{seq: seq, err: fmt.Errorf("failed to embed comparison %d: %w", i+1, err)}
+			}
+			scores[i] = maxSim(input, vectors)
+		}
+		return lateCompleteMsg{seq: seq, scores: scores, tokens: tokens != nil}
+	}
+}
+
+// renderLateRanking lists each comparison's MaxSim score and rank next to
+// its cosine rank
+func (m model) renderLateRanking() string {
+	labelStyle := lipgloss.NewStyle().
+		Foreground(theme.Primary).
+		Bold(true)
+	mutedStyle := lipgloss.NewStyle().
+		Foreground(theme.Muted)
+	warningStyle := lipgloss.NewStyle().
+		Foreground(theme.Warning)
+
+	s := labelStyle.Render("🔬 Late-interaction ranking (MaxSim)") + "\n"
+	switch {
+	case m.lateSeq != m.comparisonSeq:
+		return s + mutedStyle.Render("Scoring by late interaction...") + "\n\n"
+	case m.lateErr != nil:
+		return s + warningStyle.Render("⚠️  "+m.lateErr.Error()) + "\n\n"
+	case m.resultSpill != nil:
+		return s + mutedStyle.Render("Off while results are spilled to disk") + "\n\n"
+	case len(m.lateScores) != len(m.similarities):
+		return s + mutedStyle.Render("Comparison set changed; run the comparison again") + "\n\n"
+	}
+
+	if m.lateTokens {
+		s += mutedStyle.Render("Over the provider's token vectors") + "\n"
+	} else {
+		s += mutedStyle.Render("Over sentence embeddings • this provider has no token vectors") + "\n"
+	}
+
+	cosine := make([]float64, len(m.similarities))
+	for i, r := range m.similarities {
+		cosine[i] = r.Similarity
+	}
+	cosineRanks, lateRanks := scoreRanks(cosine), scoreRanks(m.lateScores)
+
+	order := make([]int, len(lateRanks))
+	for i, rank := range lateRanks {
+		order[rank-1] = i
+	}
+	for _, i := range order {
+		line := fmt.Sprintf("#%d %-40s %.3f", lateRanks[i], truncateText(m.similarities[i].Text, 40), m.lateScores[i])
+		if lateRanks[i] != cosineRanks[i] {
+			line = warningStyle.Render(fmt.Sprintf("%s  ↕ #%d by cosine", line, cosineRanks[i]))
+		}
+		s += line + "\n"
+	}
+	return s + "\n"
+}
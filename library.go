@@ -0,0 +1,232 @@
+package main
+
+import (
+	"fmt"
+	"slices"
+	"sort"
+	"time"
+
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/lipgloss"
+)
+
+// Items shown at once on the library screen
+const libraryPageSize = 14
+
+// libraryItem is one embedded text. The cache only keeps hashes, so the
+// library is built from what keeps the texts: saved sets and history.
+type libraryItem struct {
+	Text      string
+	Model     ModelInfo
+	Embedding []float64
+	Time      time.Time
+	// Set is the saved set holding the text, or "" for a compared input
+	Set string
+}
+
+func (item libraryItem) source() string {
+	if item.Set == "" {
+		return "history"
+	}
+	return "set " + item.Set
+}
+
+// loadLibrary lists every text in the saved sets and history, newest first.
+// An input compared several times with the same model is listed once.
+func loadLibrary(store corpusStore) ([]libraryItem, error) {
+	sets, err := store.ListSets()
+	if err != nil {
+		return nil, err
+	}
+	history, err := store.LoadHistory()
+	if err != nil {
+		return nil, err
+	}
+
+	var items []libraryItem
+	for _, set := range sets {
+		for _, e := range set.Embeddings {
+			items = append(items, libraryItem{Text: e.Text, Model: set.Model, Embedding: e.Embedding, Time: set.Saved, Set: set.Name})
+		}
+	}
+
+	seen := make(map[string]bool)
+	for _, entry := range history {
+		key := fmt.Sprintf("%s\x00%s/%s@%d", entry.Input, entry.Model.Provider, entry.Model.Model, len(entry.Embedding))
+		if len(entry.Embedding) == 0 || seen[key] {
+			continue
+		}
+		seen[key] = true
+		items = append(items, libraryItem{Text: entry.Input, Model: entry.Model, Embedding: entry.Embedding, Time: entry.Time})
+	}
+
+	sort.SliceStable(items, func(i, j int) bool { return items[i].Time.After(items[j].Time) })
+	return items, nil
+}
+
+// deleteLibraryItem removes item from the set or history it came from
+func deleteLibraryItem(store corpusStore, item libraryItem) error {
+	if item.Set == "" {
+		return store.DeleteHistory(item.Text, item.Model)
+	}
+
+	set, err := store.LoadSet(item.Set)
+	if err != nil {
+		return err
+	}
+	set.Embeddings = slices.DeleteFunc(set.Embeddings, func(e CustomEmbedding) bool { return e.Text == item.Text })
+	if len(set.Embeddings) == 0 {
+		return store.DeleteSet(set.Name)
+	}
+	return store.SaveSet(set)
+}
+
+func (m *model) openLibraryScreen() {
+	m.currentScreen = libraryScreen
+	m.selectedLibrary = 0
+	m.libraryNotice = ""
+	m.confirmDelete = false
+	m.library, m.libraryErr = loadLibrary(m.store)
+}
+
+// addLibraryItem appends the selected text to the comparison set. Its vector
+// is reused when it came from the current model; otherwise the set is re-embedded.
+func (m *model) addLibraryItem() {
+	if m.selectedLibrary >= len(m.library) {
+		return
+	}
+	item := m.library[m.selectedLibrary]
+
+	texts := make([]string, len(m.customEmbeddings))
+	for i, e := range m.customEmbeddings {
+		texts[i] = e.source()
+	}
+	switch {
+	case slices.Contains(texts, item.Text):
+		m.libraryNotice = "Already in the comparison set"
+		return
+	case len(texts) >= 10:
+		m.libraryNotice = "⚠️  The comparison set is full (10/10) • remove a text first"
+		return
+	}
+	texts = append(texts, item.Text)
+
+	info := m.provider.ModelInfo()
+	sameModel := item.Model.Provider == info.Provider && item.Model.Model == info.Model &&
+		(len(m.customEmbeddings) == 0 || len(m.customEmbeddings[0].Embedding) == len(item.Embedding))
+	if sameModel {
+		m.customEmbeddings = append(m.customEmbeddings, CustomEmbedding{Text: item.Text, Embedding: item.Embedding})
+		m.libraryNotice = fmt.Sprintf("✅ Added %q to the comparison set", truncateText(item.Text, 30))
+	} else {
+		m.jobs.Submit(fmt.Sprintf("Embed %d comparison texts with %s", len(texts), info.Model), m.embedComparisonsJob(texts, m.templateValues))
+		m.libraryNotice = fmt.Sprintf("🔁 Added %q • re-embedding the set with %s", truncateText(item.Text, 30), info.Model)
+	}
+	m.setComparisonTextAreas(texts)
+	m.embeddingTexts[0].Blur()
+}
+
+// deleteSelectedLibraryItem deletes the selected text on the second D press
+func (m *model) deleteSelectedLibraryItem() {
+	if m.selectedLibrary >= len(m.library) {
+		return
+	}
+	item := m.library[m.selectedLibrary]
+	if !m.confirmDelete {
+		m.confirmDelete = true
+		m.libraryNotice = fmt.Sprintf("Press D again to delete %q from %s", truncateText(item.Text, 30), item.source())
+		return
+	}
+
+	m.confirmDelete = false
+	if err := deleteLibraryItem(m.store, item); err != nil {
+		m.libraryNotice = "⚠️  " + err.Error()
+		return
+	}
+	m.library = slices.Delete(m.library, m.selectedLibrary, m.selectedLibrary+1)
+	m.selectedLibrary = min(m.selectedLibrary, max(len(m.library)-1, 0))
+	m.libraryNotice = fmt.Sprintf("🗑  Deleted %q from %s", truncateText(item.Text, 30), item.source())
+}
+
+// reembedLibraryItem embeds the selected text with the current model. A set
+// is re-embedded and saved as a whole, since its vectors must share a model;
+// a compared input is compared again, which records it under the new model.
+func (m model) reembedLibraryItem() (model, tea.Cmd) {
+	if m.selectedLibrary >= len(m.library) {
+		return m, nil
+	}
+	item := m.library[m.selectedLibrary]
+
+	info := m.provider.ModelInfo()
+	if item.Model.Provider == info.Provider && item.Model.Model == info.Model && item.Model.Dimensions == info.Dimensions {
+		m.libraryNotice = fmt.Sprintf("Already embedded with %s • pick another model with Alt+M first", info.Model)
+		return m, nil
+	}
+	if item.Set == "" {
+		return m.startComparison(item.Text)
+	}
+
+	set, err := m.store.LoadSet(item.Set)
+	if err != nil {
+		m.libraryNotice = "⚠️  " + err.Error()
+		return m, nil
+	}
+	m.reembedSet(set, info, fmt.Sprintf("Re-embed set %q with %s", set.Name, info.Model))
+	m.libraryNotice = fmt.Sprintf("🔁 Re-embedding set %q with %s • Ctrl+O for progress, then reopen the library", set.Name, info.Model)
+	return m, nil
+}
+
+func (m model) renderLibraryScreen() string {
+	s := "\033[2J\033[H" // Clear screen and move cursor to top
+
+	s += "╭─────────────────────────────────────────────────────────────────────────────╮\n"
+	s += "│                              📚 LIBRARY 📚                                  │\n"
+	s += "│              Every text in your saved sets and history                      │\n"
+	s += "╰─────────────────────────────────────────────────────────────────────────────╯\n\n"
+
+	labelStyle := lipgloss.NewStyle().
+		Foreground(theme.Primary).
+		Bold(true)
+	selectedStyle := lipgloss.NewStyle().
+		Foreground(theme.Accent).
+		Bold(true)
+	instructStyle := lipgloss.NewStyle().
+		Foreground(theme.Muted).
+		Italic(true)
+	warningStyle := lipgloss.NewStyle().
+		Foreground(theme.Warning)
+
+	switch {
+	case m.libraryErr != nil:
+		s += warningStyle.Render("⚠️  "+m.libraryErr.Error()) + "\n"
+	case len(m.library) == 0:
+		s += instructStyle.Render("Nothing embedded yet • saved sets and compared inputs show up here") + "\n"
+	default:
+		s += labelStyle.Render(fmt.Sprintf("%-16s %-32s %-22s %5s  %s", "Date", "Text", "Model", "Dims", "Source")) + "\n"
+	}
+
+	start := 0
+	if m.selectedLibrary >= libraryPageSize {
+		start = m.selectedLibrary - libraryPageSize + 1
+	}
+	end := min(len(m.library), start+libraryPageSize)
+
+	for i := start; i < end; i++ {
+		item := m.library[i]
+		line := fmt.Sprintf("%-16s %-32s %-22s %5d  %s", item.Time.Format("2006-01-02 15:04"), truncateText(item.Text, 32),
+			truncateText(item.Model.Provider+"/"+item.Model.Model, 22), len(item.Embedding), truncateText(item.source(), 16))
+		if i == m.selectedLibrary {
+			s += selectedStyle.Render("▶ "+line) + "\n"
+		} else {
+			s += "  " + line + "\n"
+		}
+	}
+
+	if m.selectedLibrary < len(m.library) {
+		s += "\n" + userInputStyle.Render(truncateText(m.library[m.selectedLibrary].Text, 75)) + "\n"
+	}
+	if m.libraryNotice != "" {
+		s += "\n" + warningStyle.Render(m.libraryNotice) + "\n"
+	}
+	s += "\n" + instructStyle.Render("↑/↓ to select • Enter to add to the comparison set • D to delete • R to re-embed with the current model • Esc to return") + "\n"
+	return s
+}
@@ -0,0 +1,222 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"net"
+	"net/http"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"time"
+)
+
+const (
+	llamaCppDefaultBinary  = "llama-server"
+	llamaCppStartupTimeout = 2 * time.Minute
+	llamaCppStopTimeout    = 5 * time.Second
+)
+
+// LlamaCppProvider embeds text with a llama.cpp server running a GGUF
+// embedding model. It either attaches to a server at EMBER_LLAMACPP_URL or
+// spawns llama-server for EMBER_LLAMACPP_MODEL and stops it on Close.
+type LlamaCppProvider struct {
+	*OpenAIProvider
+	cmd    *exec.Cmd
+	exited chan struct{}
+}
+
+func init() {
+	RegisterProvider("llamacpp", func() (EmbeddingProvider, error) {
+		return NewLlamaCppProvider()
+	})
+	RegisterModelReader("llamacpp", func() (string, bool) {
+		if os.Getenv("EMBER_LLAMACPP_URL") != "" {
+			return "llama.cpp", true
+		}
+		model := os.Getenv("EMBER_LLAMACPP_MODEL")
+		return strings.TrimSuffix(filepath.Base(model), ".gguf"), model != ""
+	})
+}
+
+func NewLlamaCppProvider() (*LlamaCppProvider, error) {
+	if serverURL := os.Getenv("EMBER_LLAMACPP_URL"); serverURL != "" {
+		serverURL = strings.TrimRight(serverURL, "/")
+		if err := waitForLlamaCpp(serverURL, nil, 5*time.Second); err != nil {
+			return nil, err
+		}
+		return &LlamaCppProvider{OpenAIProvider: newLlamaCppClient(serverURL, "llama.cpp")}, nil
+	}
+
+	model := os.Getenv("EMBER_LLAMACPP_MODEL")
+	if model == "" {
+		return nil, fmt.Errorf("set EMBER_LLAMACPP_MODEL to a GGUF file or EMBER_LLAMACPP_URL to a running llama.cpp server")
+	}
+	if _, err := os.Stat(model); err != nil {
+		return nil, fmt.Errorf("failed to open model: %w", err)
+	}
+
+	return startLlamaCpp(model)
+}
+
+func newLlamaCppClient(serverURL, model string) *OpenAIProvider {
+	return &OpenAIProvider{
+		keys:    NewKeyPool([]string{""}, loadKeyRotation(), 0),
+		client:  &http.Client{},
+		model:   model,
+		baseURL: serverURL + "/v1",
+		name:    "llamacpp",
+		notices: newNoticeLog(),
+	}
+}
+
+// startLlamaCpp launches llama-server on a free local port and waits until the model is loaded
+func startLlamaCpp(model string) (*LlamaCppProvider, error) {
+	binary := os.Getenv("EMBER_LLAMACPP_BIN")
+	if binary == "" {
+		binary = llamaCppDefaultBinary
+	}
+
+	port, err := freePort()
+	if err != nil {
+		return nil, err
+	}
+
+	logPath, logFile, err := openLlamaCppLog()
+	if err != nil {
+		return nil, err
+	}
+	defer logFile.Close()
+
+	args := []string{"--model", model, "--embeddings", "--host", "127.0.0.1", "--port", fmt.Sprint(port)}
+	args = append(args, strings.Fields(os.Getenv("EMBER_LLAMACPP_ARGS"))...)
+
+	// Server output goes to a log file so it doesn't draw over the TUI
+	cmd := exec.Command(binary, args...)
+	cmd.Stdout = logFile
+	cmd.Stderr = logFile
+	if err := cmd.Start(); err != nil {
+		return nil, fmt.Errorf("failed to start %s (set EMBER_LLAMACPP_BIN to its path): %w", binary, err)
+	}
+
+	exited := make(chan struct{})
+	go func() {
+		cmd.Wait()
+		close(exited)
+	}()
+
+	serverURL := fmt.Sprintf("http://127.0.0.1:%d", port)
+	fmt.Printf("🦙 Starting llama.cpp server for %s...\n", filepath.Base(model))
+
+	p := &LlamaCppProvider{
+		OpenAIProvider: newLlamaCppClient(serverURL, strings.TrimSuffix(filepath.Base(model), ".gguf")),
+		cmd:            cmd,
+		exited:         exited,
+	}
+	if err := waitForLlamaCpp(serverURL, exited, llamaCppStartupTimeout); err != nil {
+		p.Close()
+		return nil, fmt.Errorf("%w (see %s)", err, logPath)
+	}
+	return p, nil
+}
+
+// waitForLlamaCpp polls /health, which answers 503 while the model is loading
+func waitForLlamaCpp(serverURL string, exited <-chan struct{}, timeout time.Duration) error {
+	client := &http.Client{Timeout: time.Second}
+	deadline := time.Now().Add(timeout)
+
+	for {
+		resp, err := client.Get(serverURL + "/health")
+		if err == nil {
+			resp.Body.Close()
+			if resp.StatusCode == http.StatusOK {
+				return nil
+			}
+		}
+
+		if time.Now().After(deadline) {
+			if err != nil {
+				return fmt.Errorf("llama.cpp server at %s is not reachable: %w", serverURL, err)
+			}
+			return fmt.Errorf("llama.cpp server at %s did not become ready: status %d", serverURL, resp.StatusCode)
+		}
+
+		select {
+		case <-exited:
+			return errors.New("llama.cpp server exited during startup")
+		case <-time.After(250 * time.Millisecond):
+		}
+	}
+}
+
+func freePort() (int, error) {
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		return 0, fmt.Errorf("failed to find a free port: %w", err)
+	}
+	defer listener.Close()
+	return listener.Addr().(*net.TCPAddr).Port, nil
+}
+
+func openLlamaCppLog() (string, *os.File, error) {
+	cacheDir, err := os.UserCacheDir()
+	if err != nil {
+		return "", nil, fmt.Errorf("failed to find cache directory: %w", err)
+	}
+	dir := filepath.Join(cacheDir, "ember")
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		return "", nil, fmt.Errorf("failed to create cache directory: %w", err)
+	}
+
+	path := filepath.Join(dir, "llama-server.log")
+	f, err := os.Create(path)
+	if err != nil {
+		return "", nil, fmt.Errorf("failed to create server log: %w", err)
+	}
+	return path, f, nil
+}
+
+func (l *LlamaCppProvider) ModelInfo() ModelInfo {
+	return ModelInfo{Provider: "llamacpp", Model: l.model}
+}
+
+// WithModel keeps the provider as is, since the server only serves the model it was started with
+func (l *LlamaCppProvider) WithModel(model string) EmbeddingProvider {
+	return l
+}
+
+// WithPurpose keeps the provider as is, so the server it started is only
+// stopped once; its calls are recorded under the command's purpose
+func (l *LlamaCppProvider) WithPurpose(purpose string) EmbeddingProvider {
+	return l
+}
+
+// WithDimensions keeps the provider as is; llama.cpp always returns the model's native size
+func (l *LlamaCppProvider) WithDimensions(n int) EmbeddingProvider {
+	return l
+}
+
+// Close stops the server if ember started it, killing it if it ignores the interrupt
+func (l *LlamaCppProvider) Close() error {
+	if l.cmd == nil {
+		return nil
+	}
+
+	select {
+	case <-l.exited:
+		return nil
+	default:
+	}
+
+	if err := l.cmd.Process.Signal(os.Interrupt); err != nil {
+		return l.cmd.Process.Kill()
+	}
+
+	select {
+	case <-l.exited:
+		return nil
+	case <-time.After(llamaCppStopTimeout):
+		return l.cmd.Process.Kill()
+	}
+}
@@ -0,0 +1,134 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"os"
+	"strings"
+	"time"
+)
+
+const lmStudioDefaultURL = "http://localhost:1234"
+
+// LMStudioProvider embeds text with a local LM Studio server through its
+// OpenAI-compatible API, and uses LM Studio's own API to find loaded models
+type LMStudioProvider struct {
+	*OpenAIProvider
+	root string
+}
+
+type lmStudioModelsResponse struct {
+	Data []struct {
+		ID    string `json:"id"`
+		Type  string `json:"type"`
+		State string `json:"state"`
+	} `json:"data"`
+}
+
+func init() {
+	RegisterProvider("lmstudio", func() (EmbeddingProvider, error) {
+		return NewLMStudioProvider()
+	})
+	RegisterModelReader("lmstudio", modelFromEnv("EMBER_LMSTUDIO_MODEL", ""))
+}
+
+func NewLMStudioProvider() (*LMStudioProvider, error) {
+	root := os.Getenv("EMBER_LMSTUDIO_URL")
+	if root == "" {
+		root = lmStudioDefaultURL
+	}
+	root = strings.TrimRight(root, "/")
+
+	p := &LMStudioProvider{
+		OpenAIProvider: &OpenAIProvider{
+			keys:    NewKeyPool([]string{""}, loadKeyRotation(), 0),
+			client:  &http.Client{},
+			baseURL: root + "/v1",
+			name:    "lmstudio",
+			notices: newNoticeLog(),
+		},
+		root: root,
+	}
+
+	models, err := p.ListModels()
+	if err != nil {
+		return nil, fmt.Errorf("LM Studio is not reachable at %s (start its local server): %w", root, err)
+	}
+
+	p.model = os.Getenv("EMBER_LMSTUDIO_MODEL")
+	if p.model == "" {
+		if len(models) == 0 {
+			return nil, fmt.Errorf("LM Studio has no embedding model loaded")
+		}
+		p.model = models[0]
+	}
+	return p, nil
+}
+
+// detectLMStudio reports whether an LM Studio server answers at the default address
+func detectLMStudio() bool {
+	root := os.Getenv("EMBER_LMSTUDIO_URL")
+	if root == "" {
+		root = lmStudioDefaultURL
+	}
+
+	client := &http.Client{Timeout: 300 * time.Millisecond}
+	resp, err := client.Get(strings.TrimRight(root, "/") + "/v1/models")
+	if err != nil {
+		return false
+	}
+	resp.Body.Close()
+	return resp.StatusCode == http.StatusOK
+}
+
+func (l *LMStudioProvider) ModelInfo() ModelInfo {
+	return ModelInfo{Provider: "lmstudio", Model: l.model}
+}
+
+// ListModels returns the embedding models LM Studio has loaded, falling back
+// to the OpenAI-compatible list on versions without the /api/v0 endpoints
+func (l *LMStudioProvider) ListModels() ([]string, error) {
+	resp, err := l.client.Get(l.root + "/api/v0/models")
+	if err != nil {
+		return nil, fmt.Errorf("failed to make request: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode == http.StatusNotFound {
+		return l.OpenAIProvider.ListModels()
+	}
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("API returned status %d", resp.StatusCode)
+	}
+
+	var modelsResp lmStudioModelsResponse
+	if err := json.NewDecoder(resp.Body).Decode(&modelsResp); err != nil {
+		return nil, fmt.Errorf("failed to unmarshal response: %w", err)
+	}
+
+	var models []string
+	for _, m := range modelsResp.Data {
+		if m.Type == "embeddings" && m.State == "loaded" {
+			models = append(models, m.ID)
+		}
+	}
+	return models, nil
+}
+
+func (l *LMStudioProvider) WithModel(model string) EmbeddingProvider {
+	copied := *l
+	copied.OpenAIProvider = l.OpenAIProvider.WithModel(model).(*OpenAIProvider)
+	return &copied
+}
+
+func (l *LMStudioProvider) WithPurpose(purpose string) EmbeddingProvider {
+	copied := *l
+	copied.OpenAIProvider = l.OpenAIProvider.WithPurpose(purpose).(*OpenAIProvider)
+	return &copied
+}
+
+// WithDimensions keeps the provider as is; LM Studio returns the model's native size
+func (l *LMStudioProvider) WithDimensions(n int) EmbeddingProvider {
+	return l
+}
@@ -0,0 +1,124 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"time"
+
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/lipgloss"
+)
+
+const (
+	macroRecordKey = "ctrl+r"
+	macroReplayKey = "alt+r"
+)
+
+// Messages for macro replay
+type macroStepMsg struct {
+	index int
+}
+
+// loadMacro reads the macro recorded in an earlier session from path
+func loadMacro(path string) []tea.Key {
+	data, err := os.ReadFile(path)
+	if err == nil {
+		data, err = openAtRest(data)
+	}
+	if err != nil {
+		return nil
+	}
+
+	var keys []tea.Key
+	if err := json.Unmarshal(data, &keys); err != nil {
+		return nil
+	}
+	return keys
+}
+
+func saveMacro(path string, keys []tea.Key) error {
+	if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+		return fmt.Errorf("failed to create config directory: %w", err)
+	}
+
+	data, err := json.MarshalIndent(keys, "", "  ")
+	if err != nil {
+		return fmt.Errorf("failed to marshal macro: %w", err)
+	}
+
+	if err := os.WriteFile(path, sealAtRest(data), 0o644); err != nil {
+		return fmt.Errorf("failed to write macro: %w", err)
+	}
+	return nil
+}
+
+// toggleMacroRecording starts a new recording or finishes and saves the current one
+func (m *model) toggleMacroRecording() {
+	if !m.recordingMacro {
+		m.recordingMacro = true
+		m.recordedKeys = nil
+		m.macroNotice = ""
+		return
+	}
+
+	m.recordingMacro = false
+	m.macro = m.recordedKeys
+	m.recordedKeys = nil
+
+	if err := saveMacro(m.macroPath, m.macro); err != nil {
+		m.macroNotice = "⚠️  Macro kept for this session only: " + err.Error()
+		return
+	}
+	m.macroNotice = fmt.Sprintf("⏹  Recorded %d keys • Alt+R to replay", len(m.macro))
+}
+
+func (m model) startMacroReplay() tea.Cmd {
+	if len(m.macro) == 0 {
+		return nil
+	}
+	return func() tea.Msg {
+		return macroStepMsg{index: 0}
+	}
+}
+
+// handleMacroStep feeds one recorded key through Update, waiting out loading
+// screens so actions that embed text finish before the next key is replayed
+func (m model) handleMacroStep(msg macroStepMsg) (tea.Model, tea.Cmd) {
+	if msg.index >= len(m.macro) {
+		m.replayingMacro = false
+		return m, nil
+	}
+
+	if m.currentScreen == loadingScreen {
+		return m, tea.Tick(100*time.Millisecond, func(time.Time) tea.Msg {
+			return msg
+		})
+	}
+
+	m.replayingMacro = true
+	updated, cmd := m.Update(tea.KeyMsg(m.macro[msg.index]))
+
+	next := func() tea.Msg {
+		return macroStepMsg{index: msg.index + 1}
+	}
+	return updated, tea.Sequence(cmd, next)
+}
+
+// renderMacroStatus shows recording state on the editing screens
+func (m model) renderMacroStatus() string {
+	statusStyle := lipgloss.NewStyle().
+		Foreground(theme.Warning).
+		Bold(true)
+
+	switch {
+	case m.recordingMacro:
+		return statusStyle.Render(fmt.Sprintf("⏺  Recording macro (%d keys) • Ctrl+R to stop", len(m.recordedKeys))) + "\n"
+	case m.replayingMacro:
+		return statusStyle.Render("▶  Replaying macro...") + "\n"
+	case m.macroNotice != "":
+		return lipgloss.NewStyle().Foreground(theme.Muted).Render(m.macroNotice) + "\n"
+	}
+	return ""
+}
@@ -1,13 +1,18 @@
 package main
 
 import (
+	"errors"
 	"fmt"
 	"log"
 	"os"
+	"path/filepath"
+	"strings"
+	"time"
 
 	"github.com/charmbracelet/bubbles/progress"
 	"github.com/charmbracelet/bubbles/spinner"
 	"github.com/charmbracelet/bubbles/textarea"
+	"github.com/charmbracelet/bubbles/textinput"
 	tea "github.com/charmbracelet/bubbletea"
 	"github.com/charmbracelet/lipgloss"
 )
@@ -20,25 +25,43 @@ const (
 	embeddingsScreen
 	loadingScreen
 	quitConfirmationScreen
+	jobsScreen
+	explainScreen
+	coverageScreen
+	modelScreen
+	suggestScreen
+	graphScreen
+	setsScreen
+	historyScreen
+	robustnessScreen
+	libraryScreen
+	evalScreen
+	pineconeScreen
+	templateScreen
+	snippetScreen
+	pinnedScreen
+	migrateScreen
 )
 
+// Shared text styles, built from the active theme by applyTheme
 var (
-	staticTextStyle = lipgloss.NewStyle().
-			Foreground(lipgloss.Color("#9567E3")).
-			Bold(true).
-			Width(80).
-			Align(lipgloss.Left)
-
-	userInputStyle = lipgloss.NewStyle().
-			Foreground(lipgloss.Color("#C967E3")).
-			Bold(true).
-			Width(80).
-			Align(lipgloss.Left)
+	staticTextStyle lipgloss.Style
+	userInputStyle  lipgloss.Style
 )
 
 type CustomEmbedding struct {
-	Text      string
-	Embedding []float64
+	Text      string    `json:"text"`
+	Embedding []float64 `json:"embedding"`
+	// Template is the text as written, with {{variables}}, when Text was resolved from it
+	Template string `json:"template,omitempty"`
+	// Chunks holds one vector per chunk of a long text when chunk aggregation is on
+	Chunks [][]float64 `json:"chunks,omitempty"`
+	// Sparse is set when sparse scoring is on
+	Sparse *SparseVector `json:"sparse,omitempty"`
+	// Fields holds a vector per field of a structured document when field weights are set
+	Fields map[string][]float64 `json:"fields,omitempty"`
+	// Summary is the generated summary of a long document, embedded as a field
+	Summary string `json:"summary,omitempty"`
 }
 
 // Messages for async operations
@@ -46,32 +69,240 @@ type embeddingCompleteMsg struct {
 	embedding []float64
 	text      string
 	err       error
-}
-
-type customEmbeddingsCompleteMsg struct {
-	embeddings []CustomEmbedding
-	err        error
+	model     ModelInfo
+	cached    bool
+	// chunks is set when the input was long enough to be chunked
+	chunks [][]float64
+	sparse *SparseVector
+	// comparisons is set when the comparison texts were embedded for this request
+	comparisons []CustomEmbedding
+	// stored is set when Postgres scored the comparison set
+	stored []storedMatch
+	// index names the remote index the comparisons came from
+	index string
 }
 
 type model struct {
-	textarea          textarea.Model
-	embeddingsService *EmbeddingsService
-	similarities      []SimilarityResult
-	lastInput         string
-	currentScreen     screenState
-	progressBars      []progress.Model
+	textarea      textarea.Model
+	provider      EmbeddingProvider
+	scheduler     *laneScheduler
+	cache         *EmbeddingCache
+	similarities  []SimilarityResult
+	processors    []scoreProcessor
+	chunking      chunkOptions
+	sparse        sparseOptions
+	fieldWeights  []fieldWeight
+	summary       summaryOptions
+	lastInput     string
+	currentScreen screenState
+	progressBars  []progress.Model
+
+	// Results screen selection and the vectors behind it, for the overlap report
+	selectedResult     int
+	lastEmbedding      []float64
+	comparedEmbeddings []CustomEmbedding
+	resultModel        ModelInfo
+	resultCached       bool
+	resultHybrid       bool
+	resultIndex        string
+	robustness         []noiseResult
+
+	// Vector databases searched instead of the comparison set; remote is
+	// the one in use, picked with Alt+Q, or nil for the comparison set
+	indexes []remoteIndex
+	remote  remoteIndex
+
+	// Pinecone index browser, opened with Alt+B
+	pinecone        *pineconeIndex
+	pineconeStats   *pineconeStats
+	pineconePage    pineconePage
+	pineconeTokens  []string
+	pineconeLoading bool
+	pineconeErr     error
+	selectedVector  int
+
+	// Per-request model override, toggled with Alt+G
+	overrideModel string
+	// overridePending is set while Alt+G waits for the model list
+	overridePending bool
+	useOverride     bool
+
+	// Side-by-side mode; secondary is nil when EMBER_COMPARE_PROVIDER is unset
+	secondary       EmbeddingProvider
+	comparisonSeq   int
+	secondarySeq    int
+	secondaryScores []float64
+	secondaryErr    error
+
+	// Score spread over repeated runs, toggled with Alt+U
+	uncertainty       bool
+	uncertaintyRuns   int
+	uncertaintySeq    int
+	uncertaintyResult uncertaintyCompleteMsg
+
+	// Late-interaction ranking on the results screen, toggled with L
+	lateRanking bool
+	lateSeq     int
+	lateScores  []float64
+	lateTokens  bool
+	lateErr     error
+
+	// Inputs compared this session, for coverage analysis and label suggestions
+	inputHistory       []CustomEmbedding
+	selectedSuggestion int
+	suggestionLabel    int
+	suggestionNotice   string
+	graphNotice        string
+	// graphRows are the graph screen's rendered nodes, or graphSpill holds
+	// them when there are more than spillRows
+	graphRows   []string
+	graphSpill  *spillFile
+	graphOffset int
+	// graph and graphLabels are built in the background; graphSeq tells
+	// the last build's result from an earlier one's
+	graph         *similarityGraph
+	graphLabels   []weakLabel
+	graphSeq      int
+	graphBuilding bool
+
+	// Saved comparison sets
+	setsDir   string
+	activeSet string
+	// project names the project the session's data belongs to, if any
+	project string
+	// matchThreshold is the active set's tuned match threshold, or 0
+	matchThreshold float64
+	savedSets      []comparisonSet
+	selectedSet    int
+	savingSet      bool
+	setNameInput   textinput.Model
+	setNotice      string
+	setsErr        error
+
+	// Where sets and history are kept: files in the data directory, or Postgres
+	store corpusStore
+	// storedSet is the set as last read from or written to Postgres, so
+	// comparisons against it unchanged can be scored in SQL
+	storedSet *comparisonSet
+
+	// Comparison history, kept across restarts
+	sessionID       string
+	history         []historyEntry
+	selectedHistory int
+	markedHistory   int
+	historyDiff     bool
+	historyNotice   string
+	// Inputs compared this session, and the titles of sessions
+	sessionInputs []string
+	sessionTitles map[string]string
+
+	// Notes on the last comparison, typed on the results screen
+	lastEntry   historyEntry
+	noteInput   textinput.Model
+	editingNote bool
+	noteTarget  int
+	noteNotice  string
+
+	// Results past spillRows are kept in resultSpill, and the results
+	// screen reads the page it shows from there
+	spillRows   int
+	resultSpill *spillFile
+	spillNotice string
+
+	// Results pinned with P, followed across runs on the pinned screen
+	pins        []string
+	scoredRuns  []scoredRun
+	selectedPin int
+
+	// Library of everything embedded, opened with Alt+L
+	library         []libraryItem
+	selectedLibrary int
+	libraryNotice   string
+	libraryErr      error
+	confirmDelete   bool
+
+	// Snippet library, inserted into the textarea Alt+S was pressed in
+	snippetsPath    string
+	snippets        []snippet
+	snippetFilter   textinput.Model
+	selectedSnippet int
+	snippetNotice   string
+	snippetErr      error
+	snippetReturn   screenState
+
+	// Classification eval, run with V on the coverage screen
+	evalRunning bool
+	evalReport  evalReport
+	evalPath    string
+	evalErr     error
+	evalNotice  string
+
+	// Earlier inputs, recalled into the input with Alt+↑/Alt+↓
+	recallInputs []string
+	recallIndex  int
+	recallDraft  string
+
+	// Model selection
+	availableModels []string
+	selectedModel   int
+	modelsLoading   bool
+	modelsErr       error
+	modelNotice     string
+	dimensionNotice string
+
+	// Saved sets embedded with another model, offered for re-embedding
+	staleSets     []comparisonSet
+	staleErr      error
+	migrationPlan *embeddingPlan
 
 	// Embeddings selection screen
 	embeddingTexts   []textarea.Model
 	selectedTextArea int
-	customEmbeddings []CustomEmbedding
+	// Entries left out of the comparison set, by position on the configure screen
+	disabledComparisons []bool
+	// Select mode, Alt+X: entries picked for bulk operations and the tag or
+	// set name being typed for one
+	selecting           bool
+	selectedEntries     map[int]bool
+	bulkPrompt          string
+	bulkInput           textinput.Model
+	bulkNotice          string
+	customEmbeddings    []CustomEmbedding
+	comparisonInputType string
+	comparisonNotice    string
+
+	// Values of the {{variables}} in comparison texts, and the values pane
+	templateValues      map[string]string
+	templateNames       []string
+	templateInputs      []textinput.Model
+	selectedTemplateVar int
 
 	// Loading screen
 	spinner        spinner.Model
 	loadingMessage string
+
+	// Background jobs
+	jobs        *JobManager
+	selectedJob int
+	jobProgress progress.Model
+
+	// Read-only live view for spectators; nil outside ember serve
+	share *liveShare
+
+	// Keyboard macro
+	macroPath      string
+	macro          []tea.Key
+	recordedKeys   []tea.Key
+	recordingMacro bool
+	replayingMacro bool
+	macroNotice    string
+
+	// Unsent input and comparison texts, saved as they change
+	draftPath  string
+	savedDraft string
 }
 
-func initialModel() model {
+func initialModel(provider EmbeddingProvider) model {
 	ta := textarea.New()
 	ta.Placeholder = "Enter text to embed..."
 	ta.Focus()
@@ -82,38 +313,45 @@ func initialModel() model {
 	// Initialize embedding text areas with 2 default areas
 	embeddingTexts := make([]textarea.Model, 2)
 	for i := 0; i < 2; i++ {
-		ta := textarea.New()
-		ta.Placeholder = fmt.Sprintf("Enter comparison text %d...", i+1)
-		ta.SetWidth(75)
-		ta.SetHeight(3)
-		ta.ShowLineNumbers = false
-		embeddingTexts[i] = ta
+		embeddingTexts[i] = newComparisonTextArea(i)
 	}
 
 	// Initialize spinner
 	s := spinner.New()
 	s.Spinner = spinner.Dot
-	s.Style = lipgloss.NewStyle().Foreground(lipgloss.Color("#C967E3"))
+	s.Style = lipgloss.NewStyle().Foreground(theme.Accent)
 
-	// Initialize with static examples as default
-	customEmbeddings := []CustomEmbedding{
-		{Text: "I hate the state of california.", Embedding: staticExamples[0].Embedding},
-		{Text: "Washington is a really great place.", Embedding: staticExamples[1].Embedding},
+	// Providers like Cohere embed comparison texts as documents by default
+	comparisonInputType := ""
+	if typed, ok := provider.(inputTypeProvider); ok && len(typed.InputTypes()) > 0 {
+		comparisonInputType = typed.InputTypes()[0]
 	}
 
+	jobProgress := newProgressBar()
+	jobProgress.Width = 60
+
 	return model{
-		textarea:          ta,
-		embeddingsService: NewEmbeddingsService(),
-		currentScreen:     inputScreen,
-		embeddingTexts:    embeddingTexts,
-		selectedTextArea:  0,
-		customEmbeddings:  customEmbeddings,
-		spinner:           s,
+		textarea:         ta,
+		provider:         provider,
+		scheduler:        newLaneScheduler(loadMaxConcurrency()),
+		currentScreen:    inputScreen,
+		embeddingTexts:   embeddingTexts,
+		selectedTextArea: 0,
+		spinner:          s,
+		jobs:             NewJobManager(),
+		jobProgress:      jobProgress,
+		setNameInput:     newSetNameInput(),
+		noteInput:        newNoteInput(),
+		bulkInput:        newBulkInput(),
+		sessionID:        time.Now().Format("2006-01-02 15:04"),
+		markedHistory:    -1,
+
+		comparisonInputType: comparisonInputType,
 	}
 }
 
 func (m model) Init() tea.Cmd {
-	return textarea.Blink
+	return tea.Batch(textarea.Blink, waitForJobUpdate(m.jobs.updates))
 }
 
 func (m model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
@@ -124,41 +362,189 @@ func (m model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 		if msg.err != nil {
 			// Handle error - return to input screen
 			m.currentScreen = inputScreen
+			if msg.index != "" {
+				m.modelNotice = "⚠️  " + msg.err.Error()
+			}
 			return m, nil
 		}
 
 		// Success - show results
-		m.similarities = m.compareWithCustomEmbeddings(msg.embedding)
+		comparisons := m.customEmbeddings
+		if msg.comparisons != nil {
+			comparisons = msg.comparisons
+		}
+
+		// Vectors of another size come from an older model or dimension setting
+		compared, stale := splitByDimension(comparisons, len(msg.embedding))
+		m.dimensionNotice = ""
+		if stale > 0 {
+			m.dimensionNotice = fmt.Sprintf("⚠️  Skipped %d comparison texts embedded with different dimensions", stale)
+			if m.jobs.Active() == 0 {
+				m.reembedComparisons(dimensionLabel(len(msg.embedding)))
+				m.dimensionNotice += " • re-embedding them now"
+			}
+		}
+
+		// Each result is scored on its own, so large sets go to disk as
+		// they're scored rather than after
+		stored, useStored := storedResults(msg.stored, compared)
+		useStored = useStored && stale == 0
+		fuse := hybridFusion(m.sparse, msg.sparse, compared)
+		m.similarities = m.collectResults(len(compared), func(i int) SimilarityResult {
+			var result SimilarityResult
+			if useStored {
+				result = stored[i]
+			} else {
+				result = compareWithChunks(m.chunking.aggregation, msg.embedding, msg.chunks, compared[i])
+			}
+			applyFieldWeights(m.fieldWeights, msg.embedding, compared[i], &result)
+			fuse(i, &result)
+			applyScoreProcessors(m.processors, &result)
+			return result
+		})
 		m.lastInput = msg.text
+		m.lastEmbedding = msg.embedding
+		m.resultModel = msg.model
+		m.resultCached = msg.cached
+		m.resultHybrid = msg.sparse != nil
+		m.resultIndex = msg.index
+		if msg.comparisons == nil {
+			// Only inputs in the comparison set's vector space are useful for coverage
+			m.recordInput(msg.text, msg.embedding)
+		}
+		m.comparedEmbeddings = compared
+		m.selectedResult = 0
+		m.noteNotice = ""
+		m.recordRun()
 		m.setupProgressBars()
+		var cmd tea.Cmd
+		if m.store != nil {
+			m.recordHistory()
+			cmd = m.autoTitleSession()
+		}
 		m.currentScreen = resultsScreen
+		return m, cmd
+
+	case titleMsg:
+		m.handleTitle(msg)
+		return m, nil
+
+	case modelsLoadedMsg:
+		m.handleModelsLoaded(msg)
 		return m, nil
 
-	case customEmbeddingsCompleteMsg:
+	case graphBuiltMsg:
+		m.applyGraph(msg)
+		return m, nil
+
+	case secondaryCompleteMsg:
+		if msg.seq == m.comparisonSeq {
+			m.secondarySeq = msg.seq
+			m.secondaryScores = msg.scores
+			m.secondaryErr = msg.err
+		}
+		return m, nil
+
+	case uncertaintyCompleteMsg:
+		if msg.seq == m.comparisonSeq {
+			m.uncertaintySeq = msg.seq
+			m.uncertaintyResult = msg
+		}
+		return m, nil
+
+	case lateCompleteMsg:
+		if msg.seq == m.comparisonSeq {
+			m.lateSeq = msg.seq
+			m.lateScores = msg.scores
+			m.lateTokens = msg.tokens
+			m.lateErr = msg.err
+		}
+		return m, nil
+
+	case jobUpdateMsg:
+		m.applyFinishedJobs()
+		return m, waitForJobUpdate(m.jobs.updates)
+
+	case evalCompleteMsg:
+		m.evalRunning = false
+		m.evalReport, m.evalPath, m.evalErr = msg.report, msg.path, msg.err
+		return m, nil
+
+	case pineconeStatsMsg:
 		if msg.err != nil {
-			// Handle error - return to input screen
-			m.currentScreen = inputScreen
-			return m, nil
+			m.pineconeErr = msg.err
+		} else {
+			m.pineconeStats = &msg.stats
 		}
+		return m, nil
 
-		// Success - update embeddings and return to input
-		m.customEmbeddings = msg.embeddings
-		m.currentScreen = inputScreen
+	case pineconePageMsg:
+		m.applyPineconePage(msg)
 		return m, nil
 
 	case spinner.TickMsg:
-		if m.currentScreen == loadingScreen {
+		if m.currentScreen == loadingScreen || (m.currentScreen == evalScreen && m.evalRunning) || (m.currentScreen == pineconeScreen && m.pineconeLoading) {
 			m.spinner, cmd = m.spinner.Update(msg)
 			return m, cmd
 		}
 
+	case macroStepMsg:
+		return m.handleMacroStep(msg)
+
 	case tea.KeyMsg:
+		if m.recordingMacro && msg.String() != macroRecordKey {
+			m.recordedKeys = append(m.recordedKeys, tea.Key(msg))
+		}
+		if m.editingNote {
+			return m.updateNote(msg)
+		}
+		if m.selecting && m.currentScreen == embeddingsScreen {
+			return m.updateSelection(msg)
+		}
+
 		switch msg.String() {
+		case macroRecordKey:
+			if !m.replayingMacro {
+				m.toggleMacroRecording()
+			}
+			return m, nil
+		case macroReplayKey:
+			if !m.recordingMacro && !m.replayingMacro {
+				return m, m.startMacroReplay()
+			}
+			return m, nil
+		case "alt+w":
+			m.toggleSharing()
+			return m, nil
 		case "ctrl+c", "esc":
+			if (m.currentScreen == explainScreen || m.currentScreen == robustnessScreen || m.currentScreen == pinnedScreen) && msg.String() == "esc" {
+				m.currentScreen = resultsScreen
+				return m, nil
+			}
+			if (m.currentScreen == suggestScreen || m.currentScreen == graphScreen || m.currentScreen == evalScreen) && msg.String() == "esc" {
+				m.currentScreen = coverageScreen
+				return m, nil
+			}
 			if m.currentScreen == embeddingsScreen {
 				m.currentScreen = inputScreen
 				return m, nil
 			}
+			if m.currentScreen == templateScreen {
+				m.currentScreen = embeddingsScreen
+				return m, nil
+			}
+			if m.currentScreen == snippetScreen {
+				m.currentScreen = m.snippetReturn
+				return m, nil
+			}
+			if m.currentScreen == setsScreen && m.savingSet {
+				m.currentScreen = embeddingsScreen
+				return m, nil
+			}
+			if m.currentScreen == jobsScreen || m.currentScreen == coverageScreen || m.currentScreen == modelScreen || m.currentScreen == setsScreen || m.currentScreen == historyScreen || m.currentScreen == libraryScreen || m.currentScreen == pineconeScreen || m.currentScreen == migrateScreen {
+				m.currentScreen = inputScreen
+				return m, nil
+			}
 			if m.currentScreen == quitConfirmationScreen {
 				// Cancel quit confirmation - return to previous screen
 				m.currentScreen = inputScreen
@@ -172,16 +558,469 @@ func (m model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 				m.currentScreen = inputScreen
 				return m, nil
 			}
+			if m.currentScreen == templateScreen {
+				m.applyTemplateValues()
+				return m, nil
+			}
+			if m.currentScreen == snippetScreen {
+				m.insertSelectedSnippet()
+				return m, nil
+			}
+			if m.currentScreen == explainScreen || m.currentScreen == robustnessScreen || m.currentScreen == pinnedScreen {
+				m.currentScreen = resultsScreen
+				return m, nil
+			}
+			if m.currentScreen == modelScreen {
+				m.selectModel()
+				return m, nil
+			}
+			if m.currentScreen == migrateScreen {
+				m.migrateSets()
+				return m, nil
+			}
+			if m.currentScreen == suggestScreen {
+				m.applySuggestion()
+				return m, nil
+			}
+			if m.currentScreen == historyScreen {
+				return m.rerunHistory()
+			}
+			if m.currentScreen == libraryScreen {
+				m.addLibraryItem()
+				return m, nil
+			}
+			if m.currentScreen == pineconeScreen {
+				return m.compareWithPineconeVector()
+			}
+			if m.currentScreen == setsScreen {
+				if m.savingSet {
+					m.saveCurrentSet()
+				} else {
+					m.loadSelectedSet()
+				}
+				return m, nil
+			}
+		case "x", "X":
+			if m.currentScreen == resultsScreen && len(m.similarities) > 0 {
+				m.currentScreen = explainScreen
+				return m, nil
+			}
+		case "b", "B":
+			if m.currentScreen == resultsScreen && len(m.similarities) > 0 {
+				m.openRobustnessScreen()
+				return m, nil
+			}
+		case "l", "L":
+			if m.currentScreen == resultsScreen && len(m.similarities) > 0 {
+				return m, m.toggleLateRanking()
+			}
+			if m.currentScreen == graphScreen {
+				path := "ember-labels.jsonl"
+				if err := m.exportWeakLabels(path); err != nil {
+					m.graphNotice = "⚠️  " + err.Error()
+				} else {
+					m.graphNotice = "💾 Wrote " + path
+				}
+				return m, nil
+			}
 		case "y", "Y":
 			if m.currentScreen == quitConfirmationScreen {
 				return m, tea.Quit
 			}
+		case "a", "A":
+			if m.currentScreen == resultsScreen {
+				m.startNote(runNote)
+				return m, nil
+			}
 		case "n", "N":
+			if m.currentScreen == resultsScreen && len(m.similarities) > 0 {
+				m.startNote(m.selectedResult)
+				return m, nil
+			}
 			if m.currentScreen == quitConfirmationScreen {
 				m.currentScreen = inputScreen
 				return m, nil
 			}
+			if m.currentScreen == pineconeScreen {
+				cmd := m.nextPineconePage(false)
+				return m, tea.Batch(m.spinner.Tick, cmd)
+			}
+			if m.currentScreen == coverageScreen {
+				return m, m.openGraphScreen()
+			}
+		case "ctrl+l":
+			if m.currentScreen == inputScreen {
+				m.textarea.SetValue(cleanWhitespace(m.textarea.Value()))
+				return m, nil
+			} else if m.currentScreen == embeddingsScreen {
+				m.cleanComparisonWhitespace()
+				return m, nil
+			}
+		case "ctrl+x":
+			if m.currentScreen == embeddingsScreen {
+				m.autoFixComparisons()
+				return m, nil
+			}
+		case "alt+t":
+			if m.currentScreen == embeddingsScreen {
+				m.cycleComparisonInputType()
+				return m, nil
+			}
+		case "alt+x":
+			if m.currentScreen == embeddingsScreen {
+				m.startSelecting()
+				return m, nil
+			}
+		case "alt+v":
+			if m.currentScreen == embeddingsScreen {
+				m.openTemplateValues()
+				return m, nil
+			}
+		case "alt+g":
+			if m.currentScreen == inputScreen {
+				return m, m.toggleOverrideModel()
+			}
+		case "alt+q":
+			if m.currentScreen == inputScreen {
+				m.cycleRemoteIndex()
+				return m, nil
+			}
+		case "alt+b":
+			if m.currentScreen == inputScreen {
+				cmd := m.openPineconeScreen()
+				return m, tea.Batch(m.spinner.Tick, cmd)
+			}
+		case "alt+up", "alt+down":
+			if m.currentScreen == inputScreen {
+				if msg.String() == "alt+up" {
+					m.recallInput(-1)
+				} else {
+					m.recallInput(1)
+				}
+				return m, nil
+			}
+		case "alt+n":
+			if m.currentScreen == inputScreen {
+				m.dismissProviderNotices()
+				return m, nil
+			}
+		case "alt+u":
+			if m.currentScreen == inputScreen {
+				m.toggleUncertainty()
+				return m, nil
+			}
+		case "alt+d":
+			if m.currentScreen == inputScreen || m.currentScreen == embeddingsScreen {
+				m.cycleDimensions()
+				return m, nil
+			}
+		case "alt+m":
+			if m.currentScreen == inputScreen || m.currentScreen == embeddingsScreen {
+				return m, m.openModelScreen()
+			}
+		case "ctrl+g":
+			if m.currentScreen == inputScreen || m.currentScreen == embeddingsScreen {
+				m.currentScreen = coverageScreen
+				return m, nil
+			}
+		case "ctrl+s":
+			if m.currentScreen == snippetScreen {
+				m.addSnippet()
+				return m, nil
+			}
+			if m.currentScreen == embeddingsScreen {
+				m.openSetsScreen(true)
+				if autoTitles() && m.setNameInput.Value() == "" {
+					cmd := m.titleSetName(false)
+					return m, cmd
+				}
+				return m, nil
+			}
+		case "ctrl+t":
+			if m.currentScreen == setsScreen && m.savingSet {
+				cmd := m.titleSetName(true)
+				return m, cmd
+			}
+		case "alt+e":
+			if m.currentScreen == inputScreen || m.currentScreen == embeddingsScreen {
+				m.exportEmbeddings(exportPath)
+				return m, nil
+			}
+		case "alt+i":
+			if m.currentScreen == inputScreen || m.currentScreen == embeddingsScreen {
+				m.importEmbeddings(exportPath)
+				return m, nil
+			}
+		case "alt+p":
+			if m.currentScreen == inputScreen || m.currentScreen == embeddingsScreen {
+				m.cycleSet()
+				return m, nil
+			}
+		case "alt+h":
+			if m.currentScreen == inputScreen || m.currentScreen == embeddingsScreen {
+				m.openHistoryScreen()
+				return m, nil
+			}
+		case "alt+l":
+			if m.currentScreen == inputScreen || m.currentScreen == embeddingsScreen {
+				m.openLibraryScreen()
+				return m, nil
+			}
+		case "alt+s":
+			if m.currentScreen == inputScreen || m.currentScreen == embeddingsScreen {
+				m.openSnippetPicker()
+				return m, nil
+			}
+		case "ctrl+d":
+			if m.currentScreen == snippetScreen {
+				m.deleteSelectedSnippet()
+				return m, nil
+			}
+		case "ctrl+p":
+			if m.currentScreen == inputScreen || m.currentScreen == embeddingsScreen {
+				m.openSetsScreen(false)
+				return m, nil
+			}
+		case "ctrl+o":
+			if m.currentScreen == inputScreen || m.currentScreen == embeddingsScreen {
+				m.currentScreen = jobsScreen
+				m.selectedJob = len(m.jobs.Snapshots()) - 1
+				return m, nil
+			}
+		case "m", "M":
+			if m.currentScreen == historyScreen {
+				m.toggleHistoryMark()
+				return m, nil
+			}
+		case "e", "E":
+			if m.currentScreen == historyScreen {
+				m.writeSessionReport(reportPath)
+				return m, nil
+			}
+		case "t", "T":
+			if m.currentScreen == historyScreen && m.selectedHistory < len(m.history) {
+				cmd := m.titleSession(m.history[m.selectedHistory].Session)
+				return m, cmd
+			}
+		case "d", "D", "g", "G":
+			if m.currentScreen == libraryScreen && strings.ToLower(msg.String()) == "d" {
+				m.deleteSelectedLibraryItem()
+				return m, nil
+			}
+			if m.currentScreen == historyScreen && strings.ToLower(msg.String()) == "d" {
+				m.historyDiff = m.markedHistory >= 0
+				m.historyNotice = ""
+				if !m.historyDiff {
+					m.historyNotice = "Mark an entry with M first"
+				}
+				return m, nil
+			}
+			if m.currentScreen == graphScreen {
+				path := "ember-graph.dot"
+				if strings.ToLower(msg.String()) == "g" {
+					path = "ember-graph.graphml"
+				}
+				if err := m.exportGraph(path); err != nil {
+					m.graphNotice = "⚠️  " + err.Error()
+				} else {
+					m.graphNotice = "💾 Wrote " + path
+				}
+				return m, nil
+			}
+		case "v", "V":
+			if m.currentScreen == resultsScreen {
+				m.openPinnedScreen()
+				return m, nil
+			}
+			if m.currentScreen == coverageScreen {
+				return m, tea.Batch(m.spinner.Tick, m.startEval())
+			}
+		case "w", "W":
+			if m.currentScreen == evalScreen {
+				m.writeEvalReport()
+				return m, nil
+			}
+		case "s", "S":
+			if m.currentScreen == coverageScreen {
+				m.currentScreen = suggestScreen
+				m.selectedSuggestion = 0
+				m.suggestionNotice = ""
+				return m, nil
+			}
+		case "left", "right":
+			if m.currentScreen == suggestScreen {
+				if msg.String() == "right" {
+					m.suggestionLabel++
+				} else if m.suggestionLabel > 0 {
+					m.suggestionLabel--
+				}
+				return m, nil
+			}
+		case "pgup", "pgdown":
+			step := 1
+			if msg.String() == "pgup" {
+				step = -1
+			}
+			if m.currentScreen == resultsScreen {
+				m.selectedResult = max(0, min(m.selectedResult+step*resultsPageSize, m.resultCount()-1))
+				return m, nil
+			}
+			if m.currentScreen == graphScreen {
+				m.scrollGraph(step * graphPageSize)
+				return m, nil
+			}
+		case "up", "down":
+			if m.currentScreen == templateScreen {
+				if msg.String() == "up" {
+					m.moveTemplateFocus(-1)
+				} else {
+					m.moveTemplateFocus(1)
+				}
+				return m, nil
+			}
+			if m.currentScreen == pineconeScreen {
+				if msg.String() == "up" && m.selectedVector > 0 {
+					m.selectedVector--
+				} else if msg.String() == "down" && m.selectedVector < len(m.pineconePage.vectors)-1 {
+					m.selectedVector++
+				}
+				return m, nil
+			}
+			if m.currentScreen == suggestScreen {
+				if msg.String() == "up" && m.selectedSuggestion > 0 {
+					m.selectedSuggestion--
+				} else if msg.String() == "down" && m.selectedSuggestion < maxSuggestions-1 {
+					m.selectedSuggestion++
+				}
+				return m, nil
+			}
+			if m.currentScreen == historyScreen {
+				if msg.String() == "up" && m.selectedHistory > 0 {
+					m.selectedHistory--
+				} else if msg.String() == "down" && m.selectedHistory < len(m.history)-1 {
+					m.selectedHistory++
+				}
+				m.historyDiff = m.historyDiff && m.markedHistory >= 0
+				return m, nil
+			}
+			if m.currentScreen == snippetScreen {
+				if msg.String() == "up" && m.selectedSnippet > 0 {
+					m.selectedSnippet--
+				} else if msg.String() == "down" && m.selectedSnippet < len(m.filteredSnippets())-1 {
+					m.selectedSnippet++
+				}
+				m.confirmDelete = false
+				m.snippetNotice = ""
+				return m, nil
+			}
+			if m.currentScreen == libraryScreen {
+				if msg.String() == "up" && m.selectedLibrary > 0 {
+					m.selectedLibrary--
+				} else if msg.String() == "down" && m.selectedLibrary < len(m.library)-1 {
+					m.selectedLibrary++
+				}
+				m.confirmDelete = false
+				m.libraryNotice = ""
+				return m, nil
+			}
+			if m.currentScreen == setsScreen && !m.savingSet {
+				if msg.String() == "up" && m.selectedSet > 0 {
+					m.selectedSet--
+				} else if msg.String() == "down" && m.selectedSet < len(m.savedSets)-1 {
+					m.selectedSet++
+				}
+				return m, nil
+			}
+			if m.currentScreen == modelScreen {
+				if msg.String() == "up" && m.selectedModel > 0 {
+					m.selectedModel--
+				} else if msg.String() == "down" && m.selectedModel < len(m.availableModels)-1 {
+					m.selectedModel++
+				}
+				return m, nil
+			}
+			if m.currentScreen == pinnedScreen {
+				if msg.String() == "up" && m.selectedPin > 0 {
+					m.selectedPin--
+				} else if msg.String() == "down" && m.selectedPin < len(m.pins)-1 {
+					m.selectedPin++
+				}
+				return m, nil
+			}
+			if m.currentScreen == graphScreen {
+				if msg.String() == "up" {
+					m.scrollGraph(-1)
+				} else {
+					m.scrollGraph(1)
+				}
+				return m, nil
+			}
+			if m.currentScreen == resultsScreen {
+				if msg.String() == "up" && m.selectedResult > 0 {
+					m.selectedResult--
+				} else if msg.String() == "down" && m.selectedResult < m.resultCount()-1 {
+					m.selectedResult++
+				}
+				return m, nil
+			}
+			if m.currentScreen == jobsScreen {
+				// The list is rendered newest first
+				count := len(m.jobs.Snapshots())
+				if msg.String() == "up" && m.selectedJob < count-1 {
+					m.selectedJob++
+				} else if msg.String() == "down" && m.selectedJob > 0 {
+					m.selectedJob--
+				}
+				return m, nil
+			}
+		case "p", "P":
+			if m.currentScreen == resultsScreen {
+				m.togglePin()
+				return m, nil
+			}
+			if m.currentScreen == pinnedScreen {
+				m.unpinSelected()
+				return m, nil
+			}
+			if m.currentScreen == pineconeScreen {
+				cmd := m.nextPineconePage(true)
+				return m, tea.Batch(m.spinner.Tick, cmd)
+			}
+			if m.currentScreen == jobsScreen {
+				if id, ok := m.selectedJobID(); ok {
+					m.jobs.TogglePause(id)
+				}
+				return m, nil
+			}
+		case "c", "C":
+			if m.currentScreen == jobsScreen {
+				if id, ok := m.selectedJobID(); ok {
+					m.jobs.Cancel(id)
+				}
+				return m, nil
+			}
+		case "r", "R":
+			if m.currentScreen == modelScreen {
+				m.openMigrateScreen()
+				return m, nil
+			}
+			if m.currentScreen == libraryScreen {
+				return m.reembedLibraryItem()
+			}
+			if m.currentScreen == evalScreen && !m.evalRunning {
+				return m, tea.Batch(m.spinner.Tick, m.startEval())
+			}
+			if m.currentScreen == jobsScreen {
+				if id, ok := m.selectedJobID(); ok && m.jobs.Retry(id) != nil {
+					m.selectedJob = len(m.jobs.Snapshots()) - 1
+				}
+				return m, nil
+			}
 		case "tab":
+			if m.currentScreen == templateScreen {
+				m.moveTemplateFocus(1)
+				return m, nil
+			}
 			if m.currentScreen == inputScreen {
 				m.currentScreen = embeddingsScreen
 				if len(m.embeddingTexts) > 0 {
@@ -202,12 +1041,7 @@ func (m model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 		case "ctrl+n":
 			if m.currentScreen == embeddingsScreen && len(m.embeddingTexts) < 10 {
 				// Add new text area
-				ta := textarea.New()
-				ta.Placeholder = fmt.Sprintf("Enter comparison text %d...", len(m.embeddingTexts)+1)
-				ta.SetWidth(75)
-				ta.SetHeight(3)
-				ta.ShowLineNumbers = false
-				m.embeddingTexts = append(m.embeddingTexts, ta)
+				m.embeddingTexts = append(m.embeddingTexts, newComparisonTextArea(len(m.embeddingTexts)))
 				return m, nil
 			}
 		case "ctrl+m":
@@ -218,6 +1052,7 @@ func (m model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 				}
 				// Remove the currently selected text area
 				m.embeddingTexts = append(m.embeddingTexts[:m.selectedTextArea], m.embeddingTexts[m.selectedTextArea+1:]...)
+				m.dropComparisonFlag(m.selectedTextArea)
 				// Adjust selected index if needed
 				if m.selectedTextArea >= len(m.embeddingTexts) {
 					m.selectedTextArea = len(m.embeddingTexts) - 1
@@ -235,25 +1070,27 @@ func (m model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 			if m.currentScreen == inputScreen {
 				text := m.textarea.Value()
 				if text != "" {
-					m.loadingMessage = "Generating embeddings for comparison..."
-					m.currentScreen = loadingScreen
 					m.textarea.SetValue("")
-					return m, tea.Batch(m.spinner.Tick, m.generateSingleEmbedding(text))
+					m.saveDraft()
+					return m.startComparison(text)
 				}
 				return m, nil
 			} else if m.currentScreen == embeddingsScreen {
-				// Check if all text areas have content
-				texts := make([]string, 0, len(m.embeddingTexts))
-				for _, ta := range m.embeddingTexts {
-					text := ta.Value()
-					if text != "" {
-						texts = append(texts, text)
-					}
+				// Refuse to generate until the set is clean
+				texts := m.enabledComparisonValues()
+				if issues := m.comparisonIssues(); hasBlockingIssues(issues) {
+					m.comparisonNotice = fmt.Sprintf("⚠️  %d issue(s) in the comparison set • Ctrl+X to auto-fix", len(issues))
+					return m, nil
+				}
+				// Each missing value is shown under its text
+				if len(missingTemplateValues(m.templateValues, texts...)) > 0 {
+					return m, nil
 				}
 				if len(texts) > 0 {
-					m.loadingMessage = "Generating custom embeddings..."
-					m.currentScreen = loadingScreen
-					return m, tea.Batch(m.spinner.Tick, m.generateAllEmbeddings(texts))
+					// Embed in the background so the input screen stays usable
+					m.jobs.Submit(fmt.Sprintf("Embed %d comparison texts", len(texts)), m.embedComparisonsJob(texts, m.templateValues))
+					m.currentScreen = inputScreen
+					return m, nil
 				}
 				return m, nil
 			}
@@ -264,29 +1101,83 @@ func (m model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 		m.textarea, cmd = m.textarea.Update(msg)
 	} else if m.currentScreen == embeddingsScreen && len(m.embeddingTexts) > 0 {
 		m.embeddingTexts[m.selectedTextArea], cmd = m.embeddingTexts[m.selectedTextArea].Update(msg)
+	} else if m.currentScreen == templateScreen {
+		m.templateInputs[m.selectedTemplateVar], cmd = m.templateInputs[m.selectedTemplateVar].Update(msg)
+	} else if m.currentScreen == snippetScreen {
+		query := m.snippetFilter.Value()
+		m.snippetFilter, cmd = m.snippetFilter.Update(msg)
+		if m.snippetFilter.Value() != query {
+			m.selectedSnippet = 0
+			m.confirmDelete = false
+			m.snippetNotice = ""
+		}
+	} else if m.currentScreen == setsScreen && m.savingSet {
+		m.setNameInput, cmd = m.setNameInput.Update(msg)
 	}
+	m.saveDraft()
 	return m, cmd
 }
 
 func (m *model) setupProgressBars() {
-	m.progressBars = make([]progress.Model, len(m.similarities))
-	for i := range m.similarities {
-		prog := progress.New(progress.WithDefaultGradient())
+	// The results screen draws a page at a time, and the bars look alike
+	m.progressBars = make([]progress.Model, min(len(m.similarities), resultsPageSize))
+	for i := range m.progressBars {
+		prog := newProgressBar()
 		prog.Width = 60
 		m.progressBars[i] = prog
 	}
 }
 
 func (m model) View() string {
+	s := m.renderScreen()
+	if m.share != nil {
+		m.share.Publish(s)
+	}
+	return s
+}
+
+func (m model) renderScreen() string {
 	switch m.currentScreen {
 	case resultsScreen:
 		return m.renderResultsScreen()
 	case embeddingsScreen:
 		return m.renderEmbeddingsScreen()
+	case templateScreen:
+		return m.renderTemplateScreen()
+	case snippetScreen:
+		return m.renderSnippetScreen()
+	case pinnedScreen:
+		return m.renderPinnedScreen()
 	case loadingScreen:
 		return m.renderLoadingScreen()
 	case quitConfirmationScreen:
 		return m.renderQuitConfirmationScreen()
+	case jobsScreen:
+		return m.renderJobsScreen()
+	case explainScreen:
+		return m.renderExplainScreen()
+	case coverageScreen:
+		return m.renderCoverageScreen()
+	case modelScreen:
+		return m.renderModelScreen()
+	case suggestScreen:
+		return m.renderSuggestScreen()
+	case graphScreen:
+		return m.renderGraphScreen()
+	case setsScreen:
+		return m.renderSetsScreen()
+	case historyScreen:
+		return m.renderHistoryScreen()
+	case robustnessScreen:
+		return m.renderRobustnessScreen()
+	case libraryScreen:
+		return m.renderLibraryScreen()
+	case evalScreen:
+		return m.renderEvalScreen()
+	case pineconeScreen:
+		return m.renderPineconeScreen()
+	case migrateScreen:
+		return m.renderMigrateScreen()
 	default:
 		return m.renderInputScreen()
 	}
@@ -299,21 +1190,53 @@ func (m model) renderInputScreen() string {
 	s += "╭─────────────────────────────────────────────────────────────────────────────╮\n"
 	s += "│                               🟣 EMBER 🟣                                   │\n"
 	s += "╰─────────────────────────────────────────────────────────────────────────────╯\n\n"
+	s += m.renderActiveSet()
 
 	// Style the label
 	labelStyle := lipgloss.NewStyle().
-		Foreground(lipgloss.Color("#9567E3")).
+		Foreground(theme.Primary).
 		Bold(true)
 
 	s += labelStyle.Render("✨ Enter your text below:") + "\n\n"
-	s += m.textarea.View() + "\n\n"
+	s += m.textarea.View() + "\n"
+
+	// Warn about characters that silently change the embedding
+	if issues := whitespaceIssues(m.textarea.Value()); len(issues) > 0 {
+		warningStyle := lipgloss.NewStyle().
+			Foreground(theme.Warning)
+		s += warningStyle.Render("⚠️  Contains "+strings.Join(issues, ", ")+" • Ctrl+L to clean") + "\n"
+	}
+	s += "\n"
 
 	// Add styled instructions
 	instructStyle := lipgloss.NewStyle().
-		Foreground(lipgloss.Color("#666666")).
+		Foreground(theme.Muted).
 		Italic(true)
 
-	s += instructStyle.Render("💡 Alt+Enter to compare • Tab to configure comparisons • Ctrl+C to quit") + "\n"
+	s += instructStyle.Render("💡 Alt+Enter to compare • Alt+↑/↓ earlier inputs • Tab to configure comparisons • Ctrl+C to quit") + "\n"
+	s += instructStyle.Render("⌨️  Ctrl+L clean whitespace • Ctrl+G coverage • Ctrl+O jobs • Ctrl+R record macro • Alt+R replay macro") + "\n"
+	s += instructStyle.Render("📂 Ctrl+P sets • Alt+P next set • Alt+H history • Alt+L library • Alt+S snippets • Alt+E export • Alt+I import • Alt+B Pinecone") + "\n"
+	s += instructStyle.Render("🧠 Alt+M models • Alt+D dimensions • Alt+G other model • Alt+U score spread • Alt+Q vector database • Alt+N dismiss notices") + "\n"
+	s += m.renderOverrideStatus()
+	s += m.renderRemoteStatus()
+	s += m.renderUncertaintyStatus()
+	s += m.renderMacroStatus()
+	s += m.renderJobStatus()
+	s += m.renderDaemonStatus()
+	s += m.renderShareStatus()
+	s += m.renderCacheStatus()
+	if m.modelNotice != "" {
+		s += lipgloss.NewStyle().Foreground(theme.Muted).Render(m.modelNotice) + "\n"
+	}
+
+	s += m.renderProviderNotices()
+
+	// Show per-key usage when rotating between several keys
+	if reporter, ok := m.provider.(keyUsageReporter); ok {
+		if usage := reporter.KeyUsage(); len(usage) > 1 {
+			s += "\n" + m.renderKeyUsage(usage)
+		}
+	}
 
 	// Add padding to ensure clean display
 	for i := 0; i < 10; i++ {
@@ -323,23 +1246,123 @@ func (m model) renderInputScreen() string {
 	return s
 }
 
+func (m model) renderKeyUsage(usage []KeyUsage) string {
+	usageStyle := lipgloss.NewStyle().
+		Foreground(theme.Muted)
+
+	s := ""
+	for _, u := range usage {
+		line := fmt.Sprintf("🔑 %s  %d req • %d tokens", u.Key, u.Requests, u.Tokens)
+		if u.Quota > 0 {
+			line += fmt.Sprintf(" / %d", u.Quota)
+		}
+		if u.Errors > 0 {
+			line += fmt.Sprintf(" • %d errors", u.Errors)
+		}
+		if u.Cooling {
+			line += " • cooling down"
+		}
+		s += usageStyle.Render(line) + "\n"
+	}
+	return s
+}
+
 func (m model) renderResultsScreen() string {
 	// Clear screen by adding enough content to fill the terminal
 	s := "\033[2J\033[H" // ANSI escape codes to clear screen and move cursor to top
 
-	s += fmt.Sprintf("Similarity Results for:\n%s\n\n", userInputStyle.Render(m.lastInput))
+	s += fmt.Sprintf("Similarity Results for:\n%s\n", userInputStyle.Render(m.lastInput))
+	if m.resultModel.Model != "" {
+		s += lipgloss.NewStyle().Foreground(theme.Muted).Render(fmt.Sprintf("🧠 %s/%s", m.resultModel.Provider, m.resultModel.Model))
+		if m.resultModel.Dimensions > 0 {
+			s += lipgloss.NewStyle().Foreground(theme.Muted).Render(fmt.Sprintf(" • %d dimensions", m.resultModel.Dimensions))
+		}
+		if m.resultCached {
+			s += lipgloss.NewStyle().Foreground(theme.Muted).Render(" • ⚡ from cache")
+		}
+		if label := m.chunking.label(); label != "" {
+			s += lipgloss.NewStyle().Foreground(theme.Muted).Render(" • " + label)
+		}
+		if m.resultIndex != "" {
+			s += lipgloss.NewStyle().Foreground(theme.Muted).Render(fmt.Sprintf(" • 🔎 nearest %d in %s", m.resultCount(), m.resultIndex))
+		}
+		s += "\n"
+	}
+	if m.lastEntry.Note != "" {
+		s += lipgloss.NewStyle().Foreground(theme.Muted).Render("📝 "+m.lastEntry.Note) + "\n"
+	}
+	s += "\n"
 	s += "╭─────────────────────────────────────────────────────────────────────────────╮\n"
 	s += "│                            ✨ COMPARISON RESULTS ✨                         │\n"
 	s += "╰─────────────────────────────────────────────────────────────────────────────╯\n\n"
 
-	for i, result := range m.similarities {
-		s += staticTextStyle.Render(result.Text) + "\n"
-		s += fmt.Sprintf("Similarity: %.3f\n", result.Similarity)
-		if i < len(m.progressBars) {
-			s += m.progressBars[i].ViewAs(result.Similarity) + "\n\n"
+	if m.dimensionNotice != "" {
+		s += lipgloss.NewStyle().Foreground(theme.Warning).Render(m.dimensionNotice) + "\n\n"
+	}
+
+	if m.secondary != nil {
+		s += m.renderSideBySide()
+	}
+	if m.uncertainty {
+		s += m.renderUncertainty()
+	}
+	if m.lateRanking {
+		s += m.renderLateRanking()
+	}
+
+	// Only the page around the selected result is drawn, read from the
+	// spill file when the results didn't fit in memory
+	start := 0
+	if m.selectedResult >= resultsPageSize {
+		start = m.selectedResult - resultsPageSize + 1
+	}
+	page, err := m.resultPage(start, start+resultsPageSize)
+	if err != nil {
+		s += lipgloss.NewStyle().Foreground(theme.Warning).Render("⚠️  "+err.Error()) + "\n\n"
+	}
+	if m.spillNotice != "" {
+		s += lipgloss.NewStyle().Foreground(theme.Warning).Render(m.spillNotice) + "\n\n"
+	}
+	if count := m.resultCount(); count > resultsPageSize {
+		line := fmt.Sprintf("Results %d–%d of %d", start+1, start+len(page), count)
+		if m.resultSpill != nil {
+			line += fmt.Sprintf(" • 💾 past the first %d, read from disk", len(m.similarities))
+		}
+		s += lipgloss.NewStyle().Foreground(theme.Muted).Render(line) + "\n\n"
+	}
+
+	for offset, result := range page {
+		i := start + offset
+		marker := "  "
+		if i == m.selectedResult {
+			marker = "▸ "
+		}
+		pin := ""
+		if m.isPinned(result.Text) {
+			pin = "📌 "
+		}
+		s += marker + pin + staticTextStyle.Render(result.Text) + "\n"
+		s += fmt.Sprintf("Similarity: %.3f  %s%s\n", result.Similarity, scoreGrade(result.Similarity), m.matchVerdict(result.Similarity))
+		if note := m.noteFor(i); note != "" {
+			s += lipgloss.NewStyle().Foreground(theme.Muted).Render("📝 "+note) + "\n"
+		}
+		if len(result.Fields) > 0 {
+			s += renderFieldScores(result) + "\n"
+		}
+		if m.resultHybrid {
+			s += m.sparse.renderHybrid(result) + "\n"
+		}
+		if len(result.Adjustments) > 0 {
+			s += renderAdjustments(result) + "\n"
+		}
+		if offset < len(m.progressBars) {
+			s += m.progressBars[offset].ViewAs(result.Similarity) + "\n\n"
 		}
 	}
 
+	s += m.renderNoteEditor()
+	s += "↑/↓ to select • PgUp/PgDn to page • X to explain the score • B to test robustness to noise • L for late-interaction ranking\n"
+	s += "N to note the selected result • A to note the whole run • P to pin it • V for pinned results\n"
 	s += "Press Enter to return to input screen, Ctrl+C or Esc to quit."
 
 	// Add padding to ensure we cover the entire screen
@@ -358,36 +1381,82 @@ func (m model) renderEmbeddingsScreen() string {
 	s += "│                        🎯 CONFIGURE COMPARISONS 🎯                          │\n"
 	s += fmt.Sprintf("│                     Define your comparison texts (%d/10)                    │\n", len(m.embeddingTexts))
 	s += "╰─────────────────────────────────────────────────────────────────────────────╯\n\n"
+	s += m.renderActiveSet()
 
 	// Style for labels
 	labelStyle := lipgloss.NewStyle().
-		Foreground(lipgloss.Color("#9567E3")).
+		Foreground(theme.Primary).
 		Bold(true)
 
 	activeStyle := lipgloss.NewStyle().
 		BorderStyle(lipgloss.RoundedBorder()).
-		BorderForeground(lipgloss.Color("#C967E3"))
+		BorderForeground(theme.Accent)
 
 	inactiveStyle := lipgloss.NewStyle().
 		BorderStyle(lipgloss.RoundedBorder()).
-		BorderForeground(lipgloss.Color("#666666"))
+		BorderForeground(theme.Muted)
+
+	warningStyle := lipgloss.NewStyle().
+		Foreground(theme.Warning)
+
+	issues := m.comparisonIssues()
 
 	// Render all text areas
 	for i, ta := range m.embeddingTexts {
-		s += labelStyle.Render(fmt.Sprintf("📝 Comparison text %d:", i+1)) + "\n"
+		label := labelStyle.Render(fmt.Sprintf("📝 Comparison text %d:", i+1))
+		if m.selecting {
+			mark := "☐ "
+			if m.selectedEntries[i] {
+				mark = "☑ "
+			}
+			label = mark + label
+		}
+		if m.comparisonDisabled(i) {
+			label += lipgloss.NewStyle().Foreground(theme.Muted).Render(" ⏸ disabled")
+		}
+		s += label + "\n"
 		if m.selectedTextArea == i {
-			s += activeStyle.Render(ta.View()) + "\n\n"
+			s += activeStyle.Render(ta.View()) + "\n"
 		} else {
-			s += inactiveStyle.Render(ta.View()) + "\n\n"
+			s += inactiveStyle.Render(ta.View()) + "\n"
 		}
+		for _, issue := range issues {
+			if issue.index == i {
+				s += warningStyle.Render("⚠️  "+issue.message) + "\n"
+			}
+		}
+		if text := ta.Value(); len(templateVars(text)) > 0 {
+			s += lipgloss.NewStyle().Foreground(theme.Muted).Render("  → "+truncateText(strings.ReplaceAll(resolveTemplate(text, m.templateValues), "\n", " "), 72)) + "\n"
+			for _, name := range missingTemplateValues(m.templateValues, text) {
+				s += warningStyle.Render("⚠️  {{"+name+"}} has no value • Alt+V to set it") + "\n"
+			}
+		}
+		s += "\n"
 	}
 
 	// Instructions
 	instructStyle := lipgloss.NewStyle().
-		Foreground(lipgloss.Color("#666666")).
+		Foreground(theme.Muted).
 		Italic(true)
 
-	s += instructStyle.Render("💡 Tab to switch • Ctrl+N to add • Ctrl+M to remove • Alt+Enter to generate • Esc to return") + "\n"
+	if m.comparisonInputType != "" {
+		s += labelStyle.Render(fmt.Sprintf("🏷  Input type: %s", m.comparisonInputType)) + " " +
+			instructStyle.Render("(Alt+T to change)") + "\n\n"
+	}
+
+	if m.selecting {
+		s += m.renderSelection()
+	} else {
+		s += instructStyle.Render("💡 Tab to switch • Ctrl+N to add • Ctrl+M to remove • Ctrl+X to auto-fix • Ctrl+L to clean • Alt+Enter to generate • Esc to return") + "\n"
+		s += instructStyle.Render("💾 Ctrl+S save set • Ctrl+P open set • Alt+P next set • Alt+S snippets • 🧩 Alt+V template values • ☑️  Alt+X select") + "\n"
+	}
+	if m.comparisonNotice != "" && hasBlockingIssues(issues) {
+		s += warningStyle.Bold(true).Render(m.comparisonNotice) + "\n"
+	} else if strings.HasPrefix(m.comparisonNotice, "💾") || strings.HasPrefix(m.comparisonNotice, "🧩") {
+		s += lipgloss.NewStyle().Foreground(theme.Muted).Render(m.comparisonNotice) + "\n"
+	}
+	s += m.renderMacroStatus()
+	s += m.renderJobStatus()
 
 	// Add padding
 	for i := 0; i < 2; i++ {
@@ -432,16 +1501,20 @@ func (m model) renderQuitConfirmationScreen() string {
 
 	// Warning message
 	warningStyle := lipgloss.NewStyle().
-		Foreground(lipgloss.Color("#ff6b6b")).
+		Foreground(theme.Warning).
 		Bold(true).
 		Align(lipgloss.Center).
 		Width(80)
 
-	s += warningStyle.Render("You may lose any unsaved text input!") + "\n\n\n"
+	if m.draftPath != "" {
+		s += warningStyle.Render("📝 Your draft is saved and will be back next time") + "\n\n\n"
+	} else {
+		s += warningStyle.Render("You may lose any unsaved text input!") + "\n\n\n"
+	}
 
 	// Instructions
 	instructStyle := lipgloss.NewStyle().
-		Foreground(lipgloss.Color("#9567E3")).
+		Foreground(theme.Primary).
 		Bold(true).
 		Align(lipgloss.Center).
 		Width(80)
@@ -456,71 +1529,463 @@ func (m model) renderQuitConfirmationScreen() string {
 	return s
 }
 
-func (m model) compareWithCustomEmbeddings(inputEmbedding []float64) []SimilarityResult {
-	results := make([]SimilarityResult, len(m.customEmbeddings))
+// cycleComparisonInputType steps through the provider's input types for comparison texts
+func (m *model) cycleComparisonInputType() {
+	typed, ok := m.provider.(inputTypeProvider)
+	if !ok {
+		return
+	}
 
-	for i, example := range m.customEmbeddings {
-		similarity := cosineSimilarity(inputEmbedding, example.Embedding)
-		results[i] = SimilarityResult{
-			Text:       example.Text,
-			Similarity: similarity,
+	types := typed.InputTypes()
+	if len(types) == 0 {
+		return
+	}
+	for i, t := range types {
+		if t == m.comparisonInputType {
+			m.comparisonInputType = types[(i+1)%len(types)]
+			return
 		}
 	}
+	m.comparisonInputType = types[0]
+}
 
-	return results
+// queryInputType is the input type for the text being compared; retrieval
+// models pair search_document comparisons with a search_query input
+func (m model) queryInputType() string {
+	return queryInputTypeFor(m.comparisonInputType)
+}
+
+func queryInputTypeFor(comparisonInputType string) string {
+	if comparisonInputType == "search_document" {
+		return "search_query"
+	}
+	return comparisonInputType
+}
+
+// startComparison embeds text and compares it against the comparison set
+func (m model) startComparison(text string) (model, tea.Cmd) {
+	m.loadingMessage = "Generating embeddings for comparison..."
+	m.currentScreen = loadingScreen
+	m.comparisonSeq++
+	m.rememberInput(text)
+	return m, tea.Batch(m.spinner.Tick, m.generateSingleEmbedding(text), m.generateSecondaryScores(text), m.generateUncertainty(text), m.generateLateScores(text, m.customEmbeddings))
 }
 
 func (m model) generateSingleEmbedding(text string) tea.Cmd {
+	if m.remote != nil {
+		return m.generateWithRemote(text)
+	}
+	if m.useOverride {
+		return m.generateWithOverride(text)
+	}
+
+	info := m.provider.ModelInfo()
+	provider := m.interactiveProvider()
+	chunking, sparse := m.chunking, m.sparse
+	base := m.provider
+	stored, canPushDown := m.pushdownSet()
 	return func() tea.Msg {
-		embedding, err := m.embeddingsService.GenerateEmbedding(text)
+		var embedding []float64
+		var cached bool
+		var err error
+		if cp, ok := provider.(*cachedProvider); ok {
+			embedding, cached, err = cp.generateCached(text)
+		} else {
+			embedding, err = provider.GenerateEmbedding(text)
+		}
+
+		var chunks [][]float64
+		if err == nil {
+			chunks, err = chunking.embedChunks(provider, text)
+		}
+		var sparseVector *SparseVector
+		if err == nil {
+			sparseVector, err = sparse.encode(base, text)
+		}
+		var matches []storedMatch
+		if err == nil && canPushDown {
+			// Falls back to scoring in memory when the query fails
+			matches, _ = stored.nearest(embedding)
+		}
 		return embeddingCompleteMsg{
 			embedding: embedding,
 			text:      text,
 			err:       err,
+			model:     info,
+			cached:    cached,
+			chunks:    chunks,
+			sparse:    sparseVector,
+			stored:    matches,
 		}
 	}
 }
 
-func (m model) generateAllEmbeddings(texts []string) tea.Cmd {
-	return func() tea.Msg {
-		embeddings := make([]CustomEmbedding, 0, len(texts))
-
-		for _, text := range texts {
-			embedding, err := m.embeddingsService.GenerateEmbedding(text)
-			if err != nil {
-				return customEmbeddingsCompleteMsg{err: err}
+func setupProvider() EmbeddingProvider {
+	// Prefer a running daemon so connections are already warm, as long as
+	// it embeds with the configured provider and model
+	if os.Getenv("EMBER_NO_DAEMON") == "" {
+		if provider, err := connectDaemon(defaultSocketPath()); err == nil {
+			reason := daemonMismatch(provider.ModelInfo())
+			if reason == "" {
+				return provider
 			}
-			embeddings = append(embeddings, CustomEmbedding{
-				Text:      text,
-				Embedding: embedding,
-			})
+			fmt.Fprintf(os.Stderr, "⚠️  Not using the running daemon: %s • restart `ember daemon` with this configuration to use it\n", reason)
 		}
+	}
 
-		return customEmbeddingsCompleteMsg{
-			embeddings: embeddings,
-			err:        nil,
+	name := loadProviderName()
+	provider, err := NewProvider(name)
+	if err != nil && os.Getenv("EMBER_PROVIDER") == "" && detectLMStudio() {
+		// Without OpenAI credentials, fall back to a local LM Studio server
+		provider, err = NewProvider("lmstudio")
+	}
+	if err != nil && missingAPIKey(name) && interactive() {
+		// First run: ask for the key and keep it in the system keychain
+		if err = promptForAPIKey(name); err == nil {
+			provider, err = NewProvider(name)
 		}
 	}
+	if err != nil {
+		displayError(err)
+		os.Exit(1)
+	}
+	return provider
 }
 
-func checkAPIKey() {
-	apiKey := os.Getenv("OPENAI_API_KEY")
-	if apiKey == "" {
-		displayAPIKeyError()
-		os.Exit(1)
+// setupCache opens the embedding cache unless EMBER_NO_CACHE is set; ember
+// still works without it, so failures only print a warning
+func setupCache() *EmbeddingCache {
+	if os.Getenv("EMBER_NO_CACHE") != "" {
+		return nil
 	}
+
+	path, err := defaultCachePath()
+	if err == nil {
+		var opts cacheOptions
+		if opts, err = loadCacheOptions(); err == nil {
+			var cache *EmbeddingCache
+			if cache, err = openEmbeddingCache(path, opts); err == nil {
+				return cache
+			}
+		}
+	}
+	fmt.Printf("⚠️  Embedding cache disabled: %v\n", err)
+	return nil
 }
 
-func displayAPIKeyError() {
-	fmt.Println("❌ Error: OPENAI_API_KEY environment variable not set.")
+func displayError(err error) {
+	fmt.Printf("❌ Error: %v.\n", err)
 }
 
 func main() {
-	// Check for API key before starting the application
-	checkAPIKey()
-	
-	p := tea.NewProgram(initialModel())
-	if _, err := p.Run(); err != nil {
+	args, err := loadConfig(os.Args[1:])
+	if err != nil {
+		displayError(err)
+		os.Exit(1)
+	}
+	loadKeychainKeys()
+	if err := setupEncryption(); err != nil {
+		displayError(err)
+		os.Exit(1)
+	}
+
+	purpose := "tui"
+	if len(args) > 0 {
+		purpose = args[0]
+	}
+	if err := openAuditLog(purpose); err != nil {
+		displayError(err)
+		os.Exit(1)
+	}
+
+	if len(args) > 0 {
+		switch args[0] {
+		case "daemon":
+			runDaemonCommand(args[1:])
+			return
+		case "doctor":
+			runDoctorCommand(args[1:])
+			return
+		case "init":
+			runInitCommand(args[1:])
+			return
+		case "trust":
+			runTrustCommand(args[1:])
+			return
+		case "cache":
+			runCacheCommand(args[1:])
+			return
+		case "serve":
+			runServeCommand(args[1:])
+			return
+		case "embed":
+			runEmbedCommand(args[1:])
+			return
+		case "embed-batch":
+			runEmbedBatchCommand(args[1:])
+			return
+		case "embed-csv":
+			runEmbedCSVCommand(args[1:])
+			return
+		case "prefetch":
+			runPrefetchCommand(args[1:])
+			return
+		case "presets":
+			runPresetsCommand(args[1:])
+			return
+		case "compare":
+			runCompareCommand(args[1:])
+			return
+		case "similarity":
+			runSimilarityCommand(args[1:])
+			return
+		case "generate":
+			runGenerateCommand(args[1:])
+			return
+		case "export":
+			runExportCommand(args[1:])
+			return
+		case "import":
+			runImportCommand(args[1:])
+			return
+		case "export-bundle":
+			runExportBundleCommand(args[1:])
+			return
+		case "import-bundle":
+			runImportBundleCommand(args[1:])
+			return
+		case "key":
+			runKeyCommand(args[1:])
+			return
+		case "search":
+			runSearchCommand(args[1:])
+			return
+		case "label":
+			runLabelCommand(args[1:])
+			return
+		case "batch":
+			runBatchCommand(args[1:])
+			return
+		case "audit":
+			runAuditCommand(args[1:])
+			return
+		case "tune":
+			runTuneCommand(args[1:])
+			return
+		case "eval":
+			runEvalCommand(args[1:])
+			return
+		case "qdrant":
+			runQdrantCommand(args[1:])
+			return
+		case "vectors":
+			runVectorsCommand(args[1:])
+			return
+		case "weaviate":
+			runWeaviateCommand(args[1:])
+			return
+		case "pinecone":
+			runPineconeCommand(args[1:])
+			return
+		}
+	}
+
+	// Piped input is compared and printed, as `ember compare` does
+	if len(args) == 0 && stdinIsPiped() {
+		runCompareCommand(nil)
+		return
+	}
+
+	t, err := loadTheme()
+	if err != nil {
+		displayError(err)
+		os.Exit(1)
+	}
+	applyTheme(t)
+
+	processors, err := loadScoreProcessors()
+	if err != nil {
+		displayError(err)
+		os.Exit(1)
+	}
+
+	chunking, err := loadChunkOptions()
+	if err != nil {
+		displayError(err)
+		os.Exit(1)
+	}
+
+	sparse, err := loadSparseOptions()
+	if err != nil {
+		displayError(err)
+		os.Exit(1)
+	}
+
+	fieldWeights, err := loadFieldWeights()
+	if err != nil {
+		displayError(err)
+		os.Exit(1)
+	}
+
+	summary, err := loadSummaryOptions()
+	if err != nil {
+		displayError(err)
+		os.Exit(1)
+	}
+
+	pg, err := openPgStore()
+	if err != nil {
+		displayError(err)
+		os.Exit(1)
+	}
+
+	indexes, err := loadRemoteIndexes()
+	if err != nil {
+		displayError(err)
+		os.Exit(1)
+	}
+
+	spillRows, err := loadSpillRows()
+	if err != nil {
+		displayError(err)
+		os.Exit(1)
+	}
+
+	dataDir, err := userDataDir()
+	if err != nil {
+		displayError(err)
+		os.Exit(1)
+	}
+
+	// Set up the embedding provider before starting the application
+	provider := setupProvider()
+
+	secondary, err := loadSecondaryProvider()
+	if err != nil {
+		closeProvider(provider)
+		displayError(err)
+		os.Exit(1)
+	}
+
+	cfg := sessionConfig{
+		provider:   provider,
+		secondary:  secondary,
+		processors: processors,
+		chunking:   chunking,
+		sparse:     sparse,
+		fields:     fieldWeights,
+		summary:    summary,
+		pg:         pg,
+		indexes:    indexes,
+		spillRows:  spillRows,
+		cache:      setupCache(),
+	}
+	cfg.bundles, cfg.bundleErr = loadPresetBundles()
+
+	session := newSession(cfg, dataDir)
+	if projectRoot() != "" {
+		session.project = projectName()
+	}
+	// Only the local TUI watches batches, so SSH sessions don't each download them
+	session.watchOpenAIBatches()
+	p := tea.NewProgram(session)
+	final, err := p.Run()
+	if final, ok := final.(model); ok {
+		final.closeSpills()
+	}
+	cfg.close()
+	if err != nil {
 		log.Fatal(err)
 	}
 }
+
+// sessionConfig holds what every TUI session shares: the providers, score
+// processors, chunking, sparse scoring, field weights, summaries and cache
+type sessionConfig struct {
+	provider   EmbeddingProvider
+	secondary  EmbeddingProvider
+	processors []scoreProcessor
+	chunking   chunkOptions
+	sparse     sparseOptions
+	fields     []fieldWeight
+	summary    summaryOptions
+	cache      *EmbeddingCache
+	// pg is set when sets and history are kept in Postgres
+	pg      *pgStore
+	indexes []remoteIndex
+	// spillRows is how many result rows a session keeps in memory
+	spillRows int
+	// bundles are the preset bundles whose sets every session can open but not change
+	bundles   []presetBundle
+	bundleErr error
+}
+
+// newSession builds the model for one TUI session, keeping its comparison
+// sets, history and macro in dataDir
+func newSession(cfg sessionConfig, dataDir string) model {
+	m := initialModel(cfg.provider)
+	m.processors = cfg.processors
+	m.chunking = cfg.chunking
+	m.sparse = cfg.sparse
+	m.summary = cfg.summary
+	m.fieldWeights = cfg.summary.fieldWeights(cfg.fields)
+	m.secondary = cfg.secondary
+	m.cache = cfg.cache
+	m.indexes = cfg.indexes
+	m.spillRows = cfg.spillRows
+	for _, index := range cfg.indexes {
+		if p, ok := index.(*pineconeIndex); ok {
+			m.pinecone = p
+		}
+	}
+	m.setsDir = filepath.Join(dataDir, "sets")
+	m.store = newFileStore(dataDir)
+	if cfg.pg != nil {
+		m.store = cfg.pg
+	}
+	var bundleErr error
+	m.store, bundleErr = withPresetBundles(m.store, cfg.bundles)
+	m.recallInputs = loadRecallInputs(m.store)
+	m.recallIndex = len(m.recallInputs)
+	m.macroPath = filepath.Join(dataDir, "macro.json")
+	m.macro = loadMacro(m.macroPath)
+	m.snippetsPath = filepath.Join(dataDir, "snippets.json")
+	set, err := openStartupSet(m.store, m.setsDir)
+	if err = errors.Join(cfg.bundleErr, bundleErr, err); err != nil {
+		m.modelNotice = "⚠️  " + err.Error()
+	}
+	m.applySet(set)
+	if m.findStaleSets(); m.modelNotice == "" {
+		m.modelNotice = m.staleSetsNotice()
+	}
+	// Files opened through EMBER_SET and presets aren't in the store and were never saved
+	if !set.Saved.IsZero() {
+		m.trackStoredSet(set)
+	}
+	m.draftPath = filepath.Join(dataDir, "draft.json")
+	m.restoreDraft()
+	m.overrideModel, m.useOverride = loadOverrideModel()
+	m.uncertaintyRuns = loadUncertaintyRuns()
+	if _, ok := cfg.provider.(modelSelector); !ok {
+		m.useOverride = false
+	}
+	if m.useOverride && m.overrideModel == "" {
+		// There's no list to pick from yet, so starting with it on needs a model
+		m.useOverride = false
+		m.modelNotice = "⚠️  EMBER_USE_OVERRIDE needs EMBER_OVERRIDE_MODEL • press Alt+G to pick one from the provider"
+	}
+	return m
+}
+
+func (cfg sessionConfig) close() {
+	closeProvider(cfg.provider)
+	if cfg.secondary != nil {
+		closeProvider(cfg.secondary)
+	}
+	if cfg.cache != nil {
+		cfg.cache.Close()
+	}
+	if cfg.pg != nil {
+		cfg.pg.Close()
+	}
+}
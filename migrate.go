@@ -0,0 +1,190 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/charmbracelet/lipgloss"
+)
+
+// Saved sets listed at once on the migration screen
+const migratePageSize = 12
+
+// setIsStale reports whether set holds vectors from a model other than info.
+// Presets have no vectors yet, so they're never stale. Dimensions are only
+// compared when both are known.
+func setIsStale(set comparisonSet, info ModelInfo) bool {
+	if set.Model.Model == "" || len(set.Embeddings) == 0 {
+		return false
+	}
+	if set.Model.Provider != info.Provider || set.Model.Model != info.Model {
+		return true
+	}
+	return set.Model.Dimensions != 0 && info.Dimensions != 0 && set.Model.Dimensions != info.Dimensions
+}
+
+// findStaleSets checks the saved sets against the model in use
+func (m *model) findStaleSets() {
+	m.staleSets, m.staleErr = nil, nil
+	sets, err := m.store.ListSets()
+	if err != nil {
+		m.staleErr = err
+		return
+	}
+	info := m.provider.ModelInfo()
+	for _, set := range sets {
+		// Bundled sets can't be saved, so they're re-embedded when opened instead
+		if set.Bundle == "" && setIsStale(set, info) {
+			m.staleSets = append(m.staleSets, set)
+		}
+	}
+}
+
+// offerMigration opens the migration screen when saved sets were embedded
+// with another model than the one just switched to, and reports whether it did
+func (m *model) offerMigration() bool {
+	m.findStaleSets()
+	if len(m.staleSets) == 0 {
+		return false
+	}
+	m.planMigration()
+	m.currentScreen = migrateScreen
+	return true
+}
+
+// openMigrateScreen lists the stale sets, even when there are none
+func (m *model) openMigrateScreen() {
+	m.findStaleSets()
+	m.planMigration()
+	m.currentScreen = migrateScreen
+}
+
+// planMigration tallies what re-embedding the stale sets would cost. Texts
+// the cache already holds for the current model are free.
+func (m *model) planMigration() {
+	m.migrationPlan = newEmbeddingPlan(m.provider.ModelInfo())
+	provider := m.backgroundProvider()
+	for _, set := range m.staleSets {
+		for _, e := range set.Embeddings {
+			m.migrationPlan.add(provider, resolveTemplate(e.source(), set.Values))
+		}
+	}
+}
+
+// migrateSets re-embeds every stale set with the current model in the
+// background, a job per set, so each is saved as soon as it's done
+func (m *model) migrateSets() {
+	if len(m.staleSets) == 0 {
+		m.currentScreen = inputScreen
+		return
+	}
+	info := m.provider.ModelInfo()
+	for _, set := range m.staleSets {
+		m.reembedSet(set, info, fmt.Sprintf("Migrate set %q from %s to %s", set.Name, set.Model.Model, info.Model))
+	}
+	m.modelNotice = fmt.Sprintf("🔁 Migrating %d saved sets to %s • Ctrl+O for progress", len(m.staleSets), info.Model)
+	m.staleSets = nil
+	m.currentScreen = inputScreen
+}
+
+// reembedSet submits a job that embeds set's texts with the current model and
+// saves it. Texts keep their #tags and {{variables}}, and disabled entries
+// stay disabled; the threshold is dropped, having been tuned on the old
+// model's scores.
+func (m model) reembedSet(set comparisonSet, info ModelInfo, name string) {
+	texts := make([]string, len(set.Embeddings))
+	for i, e := range set.Embeddings {
+		texts[i] = e.source()
+	}
+
+	embed := m.embedComparisonsJob(texts, set.Values)
+	store := m.store
+	m.jobs.Submit(name, func(ctx context.Context, job *Job) (any, error) {
+		result, err := embed(ctx, job)
+		if err != nil {
+			return nil, err
+		}
+		set.Embeddings = result.([]CustomEmbedding)
+		set.Model = info
+		set.Saved = time.Now()
+		set.Threshold = 0
+		// The result is not returned, so the current comparison set is left alone
+		return nil, store.SaveSet(set)
+	})
+}
+
+// staleSetsNotice points at the migration screen when saved sets are out of date
+func (m model) staleSetsNotice() string {
+	if len(m.staleSets) == 0 {
+		return ""
+	}
+	return fmt.Sprintf("🔁 %d saved sets were embedded with another model • R on the models screen (Alt+M) migrates them", len(m.staleSets))
+}
+
+func (m model) renderMigrateScreen() string {
+	s := "\033[2J\033[H" // Clear screen and move cursor to top
+
+	s += "╭─────────────────────────────────────────────────────────────────────────────╮\n"
+	s += "│                           🔁 MIGRATE SAVED SETS 🔁                          │\n"
+	s += "│               Re-embed the sets saved with another model                    │\n"
+	s += "╰─────────────────────────────────────────────────────────────────────────────╯\n\n"
+
+	labelStyle := lipgloss.NewStyle().
+		Foreground(theme.Primary).
+		Bold(true)
+	mutedStyle := lipgloss.NewStyle().
+		Foreground(theme.Muted)
+	instructStyle := lipgloss.NewStyle().
+		Foreground(theme.Muted).
+		Italic(true)
+	warningStyle := lipgloss.NewStyle().
+		Foreground(theme.Warning)
+
+	info := m.provider.ModelInfo()
+	s += labelStyle.Render(fmt.Sprintf("Now using %s/%s", info.Provider, info.Model))
+	if info.Dimensions > 0 {
+		s += labelStyle.Render(fmt.Sprintf(" • %d dimensions", info.Dimensions))
+	}
+	s += "\n\n"
+
+	switch {
+	case m.staleErr != nil:
+		s += warningStyle.Render("⚠️  "+m.staleErr.Error()) + "\n\n"
+		s += instructStyle.Render("💡 Esc to return") + "\n"
+		return s
+	case len(m.staleSets) == 0:
+		s += mutedStyle.Render("✅ Every saved set was embedded with this model") + "\n\n"
+		s += instructStyle.Render("💡 Esc to return") + "\n"
+		return s
+	}
+
+	s += labelStyle.Render(fmt.Sprintf("%-26s %-40s %6s", "Set", "Embedded with", "Texts")) + "\n"
+	for i, set := range m.staleSets {
+		if i == migratePageSize {
+			s += mutedStyle.Render(fmt.Sprintf("… and %d more", len(m.staleSets)-migratePageSize)) + "\n"
+			break
+		}
+		from := set.Model.Provider + "/" + set.Model.Model
+		if set.Model.Dimensions > 0 {
+			from += fmt.Sprintf(" @%d", set.Model.Dimensions)
+		}
+		s += fmt.Sprintf("%-26s %-40s %6d\n", truncateText(set.Name, 26), truncateText(from, 40), len(set.Embeddings))
+	}
+
+	plan := m.migrationPlan
+	cost := warningStyle.Render("unknown price • set EMBER_PRICE_PER_MTOK")
+	if dollars, ok := plan.cost(); ok {
+		cost = fmt.Sprintf("$%.2f", dollars)
+	}
+	s += "\n"
+	s += fmt.Sprintf("  Texts             %d\n", plan.texts)
+	s += fmt.Sprintf("  Already cached    %d\n", plan.cached)
+	s += fmt.Sprintf("  Estimated tokens  ~%d\n", plan.tokens())
+	s += fmt.Sprintf("  Estimated cost    %s\n", cost)
+	s += "\n" + mutedStyle.Render("Texts, #tags, template values and disabled entries are kept. Tuned thresholds are") + "\n"
+	s += mutedStyle.Render("cleared, since they were set on the old model's scores.") + "\n\n"
+
+	s += instructStyle.Render("💡 Enter to re-embed them all in the background • Esc to keep the old vectors") + "\n"
+	return s
+}
@@ -0,0 +1,17 @@
+//go:build !unix
+
+package main
+
+import (
+	"fmt"
+	"os"
+)
+
+// mapFile reads path into memory where memory mapping isn't available
+func mapFile(path string) (data []byte, unmap func() error, err error) {
+	data, err = os.ReadFile(path)
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to read %s: %w", path, err)
+	}
+	return data, func() error { return nil }, nil
+}
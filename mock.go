@@ -0,0 +1,104 @@
+package main
+
+import (
+	"hash/fnv"
+	"math"
+	"strings"
+	"unicode"
+)
+
+// Size of the vectors the mock provider returns
+const mockDimensions = 256
+
+// MockProvider embeds text by hashing its words into a fixed-size vector. It
+// needs no network or key, so texts sharing words score as similar; useful
+// for demos and trying ember out, not for real comparisons.
+type MockProvider struct{}
+
+func init() {
+	RegisterProvider("mock", func() (EmbeddingProvider, error) {
+		return MockProvider{}, nil
+	})
+	RegisterModelReader("mock", func() (string, bool) {
+		return MockProvider{}.ModelInfo().Model, true
+	})
+}
+
+func (MockProvider) ModelInfo() ModelInfo {
+	return ModelInfo{Provider: "mock", Model: "hashed-words", Dimensions: mockDimensions}
+}
+
+func (MockProvider) GenerateEmbedding(text string) ([]float64, error) {
+	embedding := make([]float64, mockDimensions)
+
+	words := strings.FieldsFunc(strings.ToLower(text), func(r rune) bool {
+		return !unicode.IsLetter(r) && !unicode.IsNumber(r)
+	})
+	for _, word := range words {
+		h := fnv.New64a()
+		h.Write([]byte(word))
+		sum := h.Sum64()
+
+		// The top bit picks the sign so unrelated words tend to cancel out
+		sign := 1.0
+		if sum>>63 == 1 {
+			sign = -1
+		}
+		embedding[sum%mockDimensions] += sign
+	}
+
+	var norm float64
+	for _, v := range embedding {
+		norm += v * v
+	}
+	if norm > 0 {
+		norm = math.Sqrt(norm)
+		for i := range embedding {
+			embedding[i] /= norm
+		}
+	}
+	return embedding, nil
+}
+
+func (p MockProvider) GenerateBatch(texts []string) ([][]float64, error) {
+	embeddings := make([][]float64, len(texts))
+	for i, text := range texts {
+		embeddings[i], _ = p.GenerateEmbedding(text)
+	}
+	return embeddings, nil
+}
+
+// GenerateTokenEmbeddings returns one vector per word, each the word's
+// signed hash bucket, so MaxSim counts how many input words a text shares
+func (MockProvider) GenerateTokenEmbeddings(text string) ([][]float64, error) {
+	words := strings.FieldsFunc(strings.ToLower(text), func(r rune) bool {
+		return !unicode.IsLetter(r) && !unicode.IsNumber(r)
+	})
+
+	vectors := make([][]float64, len(words))
+	for i, word := range words {
+		h := fnv.New64a()
+		h.Write([]byte(word))
+		sum := h.Sum64()
+
+		vectors[i] = make([]float64, mockDimensions)
+		vectors[i][sum%mockDimensions] = 1
+		if sum>>63 == 1 {
+			vectors[i][sum%mockDimensions] = -1
+		}
+	}
+	return vectors, nil
+}
+
+// Summarize keeps the first sentences of text, so summaries can be tried
+// without an LLM
+func (p MockProvider) Summarize(text string) (string, error) {
+	segments := splitSegments(text)
+	return strings.Join(segments[:min(3, len(segments))], " "), nil
+}
+
+// Title keeps the first words of text
+func (p MockProvider) Title(text string) (string, error) {
+	words := strings.Fields(text)
+	return strings.Join(words[:min(5, len(words))], " "), nil
+}
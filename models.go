@@ -0,0 +1,205 @@
+package main
+
+import (
+	"fmt"
+
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/lipgloss"
+)
+
+// Messages for the model list
+type modelsLoadedMsg struct {
+	models []string
+	err    error
+}
+
+func (m model) fetchModels() tea.Cmd {
+	lister, ok := m.provider.(modelLister)
+	if !ok {
+		return nil
+	}
+	return func() tea.Msg {
+		models, err := lister.ListModels()
+		return modelsLoadedMsg{models: models, err: err}
+	}
+}
+
+// openModelScreen shows the model list, fetching it the first time
+func (m *model) openModelScreen() tea.Cmd {
+	m.currentScreen = modelScreen
+	m.findStaleSets()
+	if m.availableModels != nil || m.modelsErr != nil {
+		return nil
+	}
+
+	cmd := m.fetchModels()
+	m.modelsLoading = cmd != nil
+	return cmd
+}
+
+func (m *model) handleModelsLoaded(msg modelsLoadedMsg) {
+	m.modelsLoading = false
+	m.modelsErr = msg.err
+	m.availableModels = msg.models
+	if m.overridePending {
+		m.overridePending = false
+		if msg.err != nil {
+			m.modelNotice = "⚠️  Couldn't list models to compare with: " + msg.err.Error()
+		} else {
+			m.pickOverrideModel()
+		}
+	}
+
+	// Start the cursor on the model in use
+	current := m.provider.ModelInfo().Model
+	for i, name := range m.availableModels {
+		if name == current {
+			m.selectedModel = i
+		}
+	}
+}
+
+// selectModel switches the provider to the highlighted model and re-embeds the
+// comparison set, since vectors from different models can't be compared
+func (m *model) selectModel() {
+	if m.selectedModel >= len(m.availableModels) {
+		return
+	}
+	name := m.availableModels[m.selectedModel]
+
+	selector, ok := m.provider.(modelSelector)
+	if !ok || name == m.provider.ModelInfo().Model {
+		m.currentScreen = inputScreen
+		return
+	}
+	m.provider = selector.WithModel(name)
+
+	texts := m.reembedComparisons(name)
+	m.customEmbeddings = nil
+	m.modelNotice = fmt.Sprintf("🔁 Switched to %s • re-embedding %d comparison texts", name, len(texts))
+	if !m.offerMigration() {
+		m.currentScreen = inputScreen
+	}
+}
+
+// Dimension sizes Alt+D cycles through; 0 is the model's native size
+var dimensionOptions = []int{0, 256, 512, 1024}
+
+// cycleDimensions switches to the next vector size and re-embeds the comparison set
+func (m *model) cycleDimensions() {
+	selector, ok := m.provider.(dimensionSelector)
+	if !ok {
+		m.modelNotice = "⚠️  This provider doesn't support choosing dimensions"
+		return
+	}
+	info := m.provider.ModelInfo()
+	if info.Provider == "openai" && !supportsDimensions(info.Model) {
+		m.modelNotice = fmt.Sprintf("⚠️  %s doesn't support choosing dimensions", info.Model)
+		return
+	}
+
+	next := dimensionOptions[0]
+	for i, n := range dimensionOptions {
+		if n == info.Dimensions {
+			next = dimensionOptions[(i+1)%len(dimensionOptions)]
+		}
+	}
+	updated := selector.WithDimensions(next)
+	if updated.ModelInfo().Dimensions != next {
+		m.modelNotice = "⚠️  This provider doesn't support choosing dimensions"
+		return
+	}
+	m.provider = updated
+
+	texts := m.reembedComparisons(dimensionLabel(next))
+	m.customEmbeddings = nil
+	m.modelNotice = fmt.Sprintf("📐 Using %s • re-embedding %d comparison texts", dimensionLabel(next), len(texts))
+	m.offerMigration()
+}
+
+func dimensionLabel(n int) string {
+	if n == 0 {
+		return "native dimensions"
+	}
+	return fmt.Sprintf("%d dimensions", n)
+}
+
+// reembedComparisons submits a job that embeds the comparison set with the
+// current provider settings and returns the texts being embedded
+func (m *model) reembedComparisons(reason string) []string {
+	texts := make([]string, len(m.customEmbeddings))
+	for i, e := range m.customEmbeddings {
+		texts[i] = e.source()
+	}
+	if len(texts) > 0 {
+		m.jobs.Submit(fmt.Sprintf("Re-embed %d comparison texts for %s", len(texts), reason), m.embedComparisonsJob(texts, m.templateValues))
+	}
+	return texts
+}
+
+// splitByDimension separates comparison vectors that match the input's size from stale ones
+func splitByDimension(embeddings []CustomEmbedding, size int) (matching []CustomEmbedding, stale int) {
+	for _, e := range embeddings {
+		if len(e.Embedding) == size {
+			matching = append(matching, e)
+		} else {
+			stale++
+		}
+	}
+	return matching, stale
+}
+
+func (m model) renderModelScreen() string {
+	s := "\033[2J\033[H" // Clear screen and move cursor to top
+
+	s += "╭─────────────────────────────────────────────────────────────────────────────╮\n"
+	s += "│                              🧠 MODELS 🧠                                   │\n"
+	s += "╰─────────────────────────────────────────────────────────────────────────────╯\n\n"
+
+	labelStyle := lipgloss.NewStyle().
+		Foreground(theme.Primary).
+		Bold(true)
+	selectedStyle := lipgloss.NewStyle().
+		Foreground(theme.Accent).
+		Bold(true)
+	instructStyle := lipgloss.NewStyle().
+		Foreground(theme.Muted).
+		Italic(true)
+
+	info := m.provider.ModelInfo()
+	s += labelStyle.Render(fmt.Sprintf("Provider: %s • current model: %s", info.Provider, info.Model)) + "\n\n"
+
+	_, canSelect := m.provider.(modelSelector)
+	_, canList := m.provider.(modelLister)
+
+	switch {
+	case !canSelect || !canList:
+		s += instructStyle.Render("This provider doesn't offer a model list") + "\n"
+	case m.modelsLoading:
+		s += instructStyle.Render("⏳ Fetching models...") + "\n"
+	case m.modelsErr != nil:
+		s += lipgloss.NewStyle().Foreground(theme.Warning).Render("⚠️  Failed to fetch models: "+m.modelsErr.Error()) + "\n"
+	case len(m.availableModels) == 0:
+		s += instructStyle.Render("No embedding models found") + "\n"
+	default:
+		for i, name := range m.availableModels {
+			line := "  " + name
+			if name == info.Model {
+				line += " (current)"
+			}
+			if i == m.selectedModel {
+				s += selectedStyle.Render("▸ "+line[2:]) + "\n"
+			} else {
+				s += line + "\n"
+			}
+		}
+	}
+
+	if len(m.staleSets) > 0 {
+		s += "\n" + lipgloss.NewStyle().Foreground(theme.Warning).Render(fmt.Sprintf("⚠️  %d saved sets were embedded with another model • R to migrate them", len(m.staleSets))) + "\n"
+	}
+	s += "\n" + instructStyle.Render("💡 ↑/↓ to select • Enter to switch • R to migrate saved sets • Esc to return") + "\n"
+	s += instructStyle.Render("Switching models re-embeds your comparison texts in the background") + "\n"
+
+	return s
+}
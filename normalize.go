@@ -0,0 +1,140 @@
+package main
+
+import (
+	"fmt"
+	"strings"
+	"unicode"
+)
+
+// Characters that are invisible in the textarea but still change the embedding
+var invisibleRunes = map[rune]string{
+	'\uFEFF': "byte order mark",
+	'\u200B': "zero-width space",
+	'\u200C': "zero-width non-joiner",
+	'\u200D': "zero-width joiner",
+	'\u2060': "word joiner",
+	'\u00AD': "soft hyphen",
+	'\u200E': "left-to-right mark",
+	'\u200F': "right-to-left mark",
+	'\u202A': "bidi embedding",
+	'\u202B': "bidi embedding",
+	'\u202C': "bidi formatting",
+	'\u202D': "bidi override",
+	'\u202E': "bidi override",
+	'\u2066': "bidi isolate",
+	'\u2067': "bidi isolate",
+	'\u2068': "bidi isolate",
+	'\u2069': "bidi isolate",
+}
+
+// joiningScripts are the scripts zero-width joiners and non-joiners shape,
+// such as Persian's half-spaces and Indic conjuncts
+var joiningScripts = []*unicode.RangeTable{
+	unicode.Arabic, unicode.Syriac, unicode.Nko, unicode.Mongolian,
+	unicode.Devanagari, unicode.Bengali, unicode.Gurmukhi, unicode.Gujarati, unicode.Oriya,
+	unicode.Tamil, unicode.Telugu, unicode.Kannada, unicode.Malayalam, unicode.Sinhala,
+	unicode.Tibetan, unicode.Myanmar, unicode.Khmer,
+}
+
+// invisibleAt names the invisible character at runes[i], if it is one that
+// only changes the embedding. Zero-width joiners and non-joiners are left
+// alone between letters of scripts they shape, such as Persian and Hindi,
+// and zero-width joiners inside emoji sequences such as 👩‍💻; elsewhere,
+// as in a Latin word, they're only noise.
+func invisibleAt(runes []rune, i int) (string, bool) {
+	name, ok := invisibleRunes[runes[i]]
+	if !ok {
+		return "", false
+	}
+	if (runes[i] == '\u200C' || runes[i] == '\u200D') && i > 0 && i+1 < len(runes) {
+		// Vowel signs, viramas and variation selectors belong to the
+		// character before them
+		before := i - 1
+		for before > 0 && unicode.In(runes[before], unicode.Mn, unicode.Mc, unicode.Me) {
+			before--
+		}
+		prev, next := runes[before], runes[i+1]
+		if unicode.In(prev, joiningScripts...) && unicode.In(next, joiningScripts...) {
+			return "", false
+		}
+		if runes[i] == '\u200D' && isEmoji(prev) && isEmoji(next) {
+			return "", false
+		}
+	}
+	return name, true
+}
+
+// isEmoji reports whether r can be part of an emoji sequence: a pictograph,
+// or a skin tone modifier
+func isEmoji(r rune) bool {
+	return (r >= 0x1F3FB && r <= 0x1F3FF) || (r >= 0x2000 && unicode.Is(unicode.So, r))
+}
+
+// whitespaceIssues describes whitespace and invisible characters that cleanWhitespace would change
+func whitespaceIssues(text string) []string {
+	if cleanWhitespace(text) == text {
+		return nil
+	}
+
+	var issues []string
+
+	if strings.TrimSpace(text) != text {
+		issues = append(issues, "leading/trailing whitespace")
+	}
+
+	found := make(map[string]int)
+	var order []string
+	unusualSpaces := 0
+	runes := []rune(text)
+	for i, r := range runes {
+		if name, ok := invisibleAt(runes, i); ok {
+			if found[name] == 0 {
+				order = append(order, name)
+			}
+			found[name]++
+		} else if unicode.IsSpace(r) && r != ' ' && r != '\n' && r != '\t' && r != '\r' {
+			unusualSpaces++
+		}
+	}
+	for _, name := range order {
+		issues = append(issues, fmt.Sprintf("%d× %s", found[name], name))
+	}
+	if unusualSpaces > 0 {
+		issues = append(issues, fmt.Sprintf("%d× non-standard space", unusualSpaces))
+	}
+
+	if strings.Contains(text, "\r") {
+		issues = append(issues, "Windows line endings")
+	}
+	if strings.Contains(text, "  ") || strings.Contains(text, "\t") {
+		issues = append(issues, "repeated spaces or tabs")
+	}
+
+	return issues
+}
+
+// cleanWhitespace trims the text, drops invisible characters, turns unusual
+// spaces into plain ones and collapses runs of spaces within each line
+func cleanWhitespace(text string) string {
+	text = strings.ReplaceAll(text, "\r\n", "\n")
+	text = strings.ReplaceAll(text, "\r", "\n")
+
+	var b strings.Builder
+	runes := []rune(text)
+	for i, r := range runes {
+		if _, ok := invisibleAt(runes, i); ok {
+			continue
+		}
+		if unicode.IsSpace(r) && r != '\n' {
+			r = ' '
+		}
+		b.WriteRune(r)
+	}
+
+	lines := strings.Split(b.String(), "\n")
+	for i, line := range lines {
+		lines[i] = strings.Join(strings.Fields(line), " ")
+	}
+
+	return strings.TrimSpace(strings.Join(lines, "\n"))
+}
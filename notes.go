@@ -0,0 +1,201 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/charmbracelet/bubbles/textinput"
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/lipgloss"
+)
+
+// File the history screen writes a session's report to, in the working directory
+const reportPath = "ember-report.md"
+
+// Which note is being edited on the results screen: the run's, or a result's index
+const runNote = -1
+
+func newNoteInput() textinput.Model {
+	ti := textinput.New()
+	ti.Placeholder = "e.g. expected higher; model confuses sarcasm"
+	ti.CharLimit = 500
+	ti.Width = 70
+	return ti
+}
+
+// startNote opens the note editor for the run or the result at target
+func (m *model) startNote(target int) {
+	if m.lastEntry.Time.IsZero() {
+		m.noteNotice = "⚠️  This comparison wasn't saved to the history, so it can't take notes"
+		return
+	}
+	if target >= len(m.similarities) {
+		m.noteNotice = fmt.Sprintf("⚠️  Only the first %d results are kept in the history, so only they can take notes", len(m.similarities))
+		return
+	}
+	m.noteTarget = target
+	m.noteNotice = ""
+	m.noteInput.SetValue(m.noteFor(target))
+	m.noteInput.CursorEnd()
+	m.noteInput.Focus()
+	m.editingNote = true
+}
+
+// noteFor returns the note on the run or the result at target
+func (m model) noteFor(target int) string {
+	if target == runNote {
+		return m.lastEntry.Note
+	}
+	if i := m.resultNoteIndex(target); i >= 0 {
+		return m.lastEntry.Results[i].Note
+	}
+	return ""
+}
+
+// resultNoteIndex finds the history result for the result shown at target,
+// matching by text since the screen may be sorted differently
+func (m model) resultNoteIndex(target int) int {
+	if target < 0 || target >= len(m.similarities) {
+		return -1
+	}
+	for i, r := range m.lastEntry.Results {
+		if r.Text == m.similarities[target].Text {
+			return i
+		}
+	}
+	return -1
+}
+
+// updateNote handles keys while a note is being typed
+func (m model) updateNote(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
+	switch msg.String() {
+	case "esc", "ctrl+c":
+		m.editingNote = false
+		m.noteInput.Blur()
+		return m, nil
+	case "enter":
+		m.editingNote = false
+		m.noteInput.Blur()
+		m.saveNote(strings.TrimSpace(m.noteInput.Value()))
+		return m, nil
+	}
+	var cmd tea.Cmd
+	m.noteInput, cmd = m.noteInput.Update(msg)
+	return m, cmd
+}
+
+// saveNote stores note on the history entry; an empty note removes it
+func (m *model) saveNote(note string) {
+	entry := m.lastEntry
+	entry.Results = append([]historyResult(nil), entry.Results...)
+	if m.noteTarget == runNote {
+		entry.Note = note
+	} else if i := m.resultNoteIndex(m.noteTarget); i >= 0 {
+		entry.Results[i].Note = note
+	} else {
+		return
+	}
+
+	if err := m.store.AnnotateHistory(entry); err != nil {
+		m.noteNotice = "⚠️  " + err.Error()
+		return
+	}
+	m.lastEntry = entry
+	m.noteNotice = "📝 Note saved"
+	if note == "" {
+		m.noteNotice = "📝 Note removed"
+	}
+}
+
+// renderNoteEditor shows the note being typed, or the last note notice
+func (m model) renderNoteEditor() string {
+	if !m.editingNote {
+		if m.noteNotice == "" {
+			return ""
+		}
+		return lipgloss.NewStyle().Foreground(theme.Muted).Render(m.noteNotice) + "\n"
+	}
+	target := "this run"
+	if m.noteTarget != runNote && m.noteTarget < len(m.similarities) {
+		target = fmt.Sprintf("%q", truncateText(m.similarities[m.noteTarget].Text, 40))
+	}
+	s := lipgloss.NewStyle().Foreground(theme.Primary).Bold(true).Render("📝 Note on "+target+":") + "\n"
+	s += m.noteInput.View() + "\n"
+	s += lipgloss.NewStyle().Foreground(theme.Muted).Italic(true).Render("Enter to save • empty to remove • Esc to cancel") + "\n"
+	return s
+}
+
+// hasNotes reports whether the entry or any of its results has a note
+func (e historyEntry) hasNotes() bool {
+	if e.Note != "" {
+		return true
+	}
+	for _, r := range e.Results {
+		if r.Note != "" {
+			return true
+		}
+	}
+	return false
+}
+
+// renderHistoryNotes lists the notes on entry
+func renderHistoryNotes(entry historyEntry) string {
+	noteStyle := lipgloss.NewStyle().Foreground(theme.Muted)
+	s := ""
+	if entry.Note != "" {
+		s += noteStyle.Render("📝 "+entry.Note) + "\n"
+	}
+	for _, r := range entry.Results {
+		if r.Note != "" {
+			s += noteStyle.Render(fmt.Sprintf("📝 %s: %s", truncateText(r.Text, 24), r.Note)) + "\n"
+		}
+	}
+	return s
+}
+
+// sessionReport renders the runs of session, oldest first, as Markdown with
+// their scores and notes
+func sessionReport(entries []historyEntry, session string) string {
+	var b strings.Builder
+	title := "Session " + session
+	if titles := historySessionTitles(entries); titles[session] != "" {
+		title = titles[session] + " (" + session + ")"
+	}
+	fmt.Fprintf(&b, "# %s\n", title)
+
+	for i := len(entries) - 1; i >= 0; i-- {
+		entry := entries[i]
+		if entry.Session != session {
+			continue
+		}
+		fmt.Fprintf(&b, "\n## %s\n\n", strings.Join(strings.Fields(entry.Input), " "))
+		fmt.Fprintf(&b, "%s • %s/%s\n\n", entry.Time.Format("2006-01-02 15:04"), entry.Model.Provider, entry.Model.Model)
+		if entry.Note != "" {
+			fmt.Fprintf(&b, "> %s\n\n", entry.Note)
+		}
+		b.WriteString("| Score | Comparison | Note |\n|------:|------------|------|\n")
+		for _, r := range entry.Results {
+			fmt.Fprintf(&b, "| %.3f | %s | %s |\n", r.Similarity, markdownCell(r.Text), markdownCell(r.Note))
+		}
+	}
+	return b.String()
+}
+
+// markdownCell keeps text on one line of a Markdown table
+func markdownCell(text string) string {
+	return strings.ReplaceAll(strings.Join(strings.Fields(text), " "), "|", `\|`)
+}
+
+// writeSessionReport writes the selected entry's session to path
+func (m *model) writeSessionReport(path string) {
+	if m.selectedHistory >= len(m.history) {
+		return
+	}
+	session := m.history[m.selectedHistory].Session
+	if err := os.WriteFile(path, []byte(sessionReport(m.history, session)), 0o644); err != nil {
+		m.historyNotice = fmt.Sprintf("⚠️  failed to write %s: %v", path, err)
+		return
+	}
+	m.historyNotice = fmt.Sprintf("💾 Wrote the session to %s", path)
+}
@@ -0,0 +1,300 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"os"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/charmbracelet/lipgloss"
+)
+
+// Notices listed at once on the input screen
+const noticesShown = 3
+
+// Kinds of provider notice
+const (
+	noticeDeprecation = "deprecation"
+	noticeQuota       = "quota"
+	noticeWarning     = "warning"
+)
+
+// ProviderNotice is something a provider said alongside a response, such as
+// a model being deprecated or a quota running out
+type ProviderNotice struct {
+	Source  string
+	Kind    string
+	Message string
+	Count   int
+	Last    time.Time
+}
+
+// noticeReporter is implemented by providers that keep the notices their API sent
+type noticeReporter interface {
+	Notices() []ProviderNotice
+	DismissNotices()
+}
+
+// noticeLog collects a provider's notices for the session. Copies of a
+// provider made by WithModel and the like share one log.
+type noticeLog struct {
+	mu      sync.Mutex
+	notices []ProviderNotice
+	// dismissed are the sources and messages already seen, which aren't shown again
+	dismissed map[string]bool
+}
+
+func newNoticeLog() *noticeLog {
+	return &noticeLog{dismissed: make(map[string]bool)}
+}
+
+// record keeps the notices in a response from source, a provider and model,
+// counting repeats of the same message
+func (l *noticeLog) record(source string, resp *http.Response, body []byte) {
+	found := responseNotices(resp, body)
+	if len(found) == 0 {
+		return
+	}
+
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	for _, notice := range found {
+		if l.dismissed[source+"\x00"+notice.Message] {
+			continue
+		}
+		known := false
+		for i := range l.notices {
+			if l.notices[i].Source == source && l.notices[i].Message == notice.Message {
+				l.notices[i].Count++
+				l.notices[i].Last = time.Now()
+				known = true
+			}
+		}
+		if !known {
+			notice.Source, notice.Count, notice.Last = source, 1, time.Now()
+			l.notices = append(l.notices, notice)
+		}
+	}
+}
+
+// list returns the notices not yet dismissed, oldest first
+func (l *noticeLog) list() []ProviderNotice {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	return append([]ProviderNotice(nil), l.notices...)
+}
+
+// dismiss clears the notices, and keeps their messages from coming back
+func (l *noticeLog) dismiss() {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	for _, notice := range l.notices {
+		l.dismissed[notice.Source+"\x00"+notice.Message] = true
+	}
+	l.notices = nil
+}
+
+// providerPayload is the parts of a response body that carry notices: the
+// error object of OpenAI-compatible APIs, Cohere's top-level message and
+// meta warnings, and the warnings some compatible servers add
+type providerPayload struct {
+	Error   json.RawMessage `json:"error"`
+	Message string          `json:"message"`
+	Warning string          `json:"warning"`
+	// Warnings is a list of strings, or of objects with a message
+	Warnings json.RawMessage `json:"warnings"`
+	Meta     struct {
+		APIVersion struct {
+			Version      string `json:"version"`
+			IsDeprecated bool   `json:"is_deprecated"`
+		} `json:"api_version"`
+		Warnings []string `json:"warnings"`
+	} `json:"meta"`
+}
+
+type providerError struct {
+	Message string `json:"message"`
+	Type    string `json:"type"`
+	Code    any    `json:"code"`
+}
+
+// responseNotices reads the deprecation and quota notices in a response's
+// headers and body. Errors only count when they are about a deprecation or
+// a quota; anything else is reported as the request's error.
+func responseNotices(resp *http.Response, body []byte) []ProviderNotice {
+	var notices []ProviderNotice
+	add := func(kind, message string) {
+		if message = strings.Join(strings.Fields(message), " "); message != "" {
+			notices = append(notices, ProviderNotice{Kind: kind, Message: message})
+		}
+	}
+
+	if resp != nil {
+		// RFC 9745 and RFC 8594: the endpoint is deprecated, and goes away at Sunset
+		if resp.Header.Get("Deprecation") != "" {
+			message := "This endpoint is deprecated"
+			if sunset, err := http.ParseTime(resp.Header.Get("Sunset")); err == nil {
+				message += " and will be removed on " + sunset.Format("2006-01-02")
+			}
+			add(noticeDeprecation, message)
+		}
+		for _, warning := range resp.Header.Values("Warning") {
+			add(classifyNotice(warning, ""), warningText(warning))
+		}
+	}
+
+	var payload providerPayload
+	if json.Unmarshal(body, &payload) != nil {
+		return notices
+	}
+	if payload.Meta.APIVersion.IsDeprecated {
+		add(noticeDeprecation, fmt.Sprintf("API version %s is deprecated", payload.Meta.APIVersion.Version))
+	}
+	for _, warning := range payload.Meta.Warnings {
+		add(classifyNotice(warning, ""), warning)
+	}
+	add(classifyNotice(payload.Warning, ""), payload.Warning)
+	for _, warning := range payloadWarnings(payload.Warnings) {
+		add(classifyNotice(warning, ""), warning)
+	}
+
+	message, code := providerErrorMessage(payload)
+	if kind := classifyNotice(message, code); kind != noticeWarning {
+		add(kind, message)
+	}
+	return notices
+}
+
+// providerErrorMessage is the human-readable message of an error payload and
+// its type or code, if any
+func providerErrorMessage(payload providerPayload) (message, code string) {
+	var object providerError
+	switch {
+	case json.Unmarshal(payload.Error, &object) == nil && object.Message != "":
+		code = object.Type
+		if object.Code != nil {
+			code += fmt.Sprintf(" %v", object.Code)
+		}
+		return object.Message, code
+	case json.Unmarshal(payload.Error, &message) == nil && message != "":
+		return message, ""
+	}
+	return payload.Message, ""
+}
+
+// payloadWarnings reads a warnings field holding strings or objects with a message
+func payloadWarnings(raw json.RawMessage) []string {
+	var texts []string
+	if json.Unmarshal(raw, &texts) == nil {
+		return texts
+	}
+	var objects []struct {
+		Message string `json:"message"`
+	}
+	json.Unmarshal(raw, &objects)
+	for _, object := range objects {
+		texts = append(texts, object.Message)
+	}
+	return texts
+}
+
+// classifyNotice tells deprecations and quota problems from other warnings by
+// their wording and error code
+func classifyNotice(message, code string) string {
+	text := strings.ToLower(message + " " + code)
+	switch {
+	case strings.Contains(text, "deprecat"), strings.Contains(text, "sunset"), strings.Contains(text, "retired"), strings.Contains(text, "no longer supported"):
+		return noticeDeprecation
+	case strings.Contains(text, "quota"), strings.Contains(text, "billing"), strings.Contains(text, "credit"), strings.Contains(text, "trial key"):
+		return noticeQuota
+	}
+	return noticeWarning
+}
+
+// warningText is the quoted text of an RFC 7234 Warning header such as
+// `299 - "Deprecated model"`, or the header itself
+func warningText(header string) string {
+	start := strings.Index(header, `"`)
+	end := strings.LastIndex(header, `"`)
+	if start >= 0 && end > start {
+		return header[start+1 : end]
+	}
+	return header
+}
+
+// apiErrorMessage is the message of an error response body, or the body
+// itself when it has none
+func apiErrorMessage(body string) string {
+	var payload providerPayload
+	if json.Unmarshal([]byte(body), &payload) == nil {
+		if message, _ := providerErrorMessage(payload); message != "" {
+			return message
+		}
+	}
+	return strings.TrimSpace(body)
+}
+
+func noticeIcon(kind string) string {
+	switch kind {
+	case noticeDeprecation:
+		return "⏳"
+	case noticeQuota:
+		return "💳"
+	}
+	return "⚠️ "
+}
+
+// printProviderNotices writes the notices a headless command's provider
+// collected to stderr, so they aren't lost with the response
+func printProviderNotices(p EmbeddingProvider) {
+	reporter, ok := p.(noticeReporter)
+	if !ok {
+		return
+	}
+	for _, notice := range reporter.Notices() {
+		fmt.Fprintf(os.Stderr, "%s %s %s: %s\n", noticeIcon(notice.Kind), notice.Source, notice.Kind, notice.Message)
+	}
+}
+
+// renderProviderNotices is the input screen's list of what the provider has
+// warned about this session. It stays until dismissed with Alt+N.
+func (m model) renderProviderNotices() string {
+	reporter, ok := m.provider.(noticeReporter)
+	if !ok {
+		return ""
+	}
+	notices := reporter.Notices()
+	if len(notices) == 0 {
+		return ""
+	}
+
+	titleStyle := lipgloss.NewStyle().
+		Foreground(theme.Warning).
+		Bold(true)
+	mutedStyle := lipgloss.NewStyle().
+		Foreground(theme.Muted)
+
+	s := "\n" + titleStyle.Render("📣 Provider notices") + mutedStyle.Render(" • Alt+N to dismiss") + "\n"
+	// The latest are the ones worth reading
+	for _, notice := range notices[max(len(notices)-noticesShown, 0):] {
+		line := fmt.Sprintf("%s %s • %s", noticeIcon(notice.Kind), notice.Source, truncateText(notice.Message, 90))
+		if notice.Count > 1 {
+			line += mutedStyle.Render(fmt.Sprintf(" (×%d, last %s)", notice.Count, notice.Last.Format("15:04")))
+		}
+		s += line + "\n"
+	}
+	if len(notices) > noticesShown {
+		s += mutedStyle.Render(fmt.Sprintf("… and %d earlier", len(notices)-noticesShown)) + "\n"
+	}
+	return s
+}
+
+// dismissProviderNotices hides the notices shown until the provider sends new ones
+func (m *model) dismissProviderNotices() {
+	if reporter, ok := m.provider.(noticeReporter); ok {
+		reporter.DismissNotices()
+	}
+}
@@ -0,0 +1,302 @@
+package main
+
+import (
+	"archive/zip"
+	"bufio"
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+	"math"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+// NumPy's file format: https://numpy.org/doc/stable/reference/generated/numpy.lib.format.html
+const npyMagic = "\x93NUMPY"
+
+// Name of the array in the .npz files ember writes
+const npzArrayName = "embeddings"
+
+var (
+	npyDescr   = regexp.MustCompile(`'descr':\s*'([^']*)'`)
+	npyFortran = regexp.MustCompile(`'fortran_order':\s*(True|False)`)
+	npyShape   = regexp.MustCompile(`'shape':\s*\(([^)]*)\)`)
+)
+
+// writeNpy writes vectors as a 2-D little-endian float64 array, or float32
+// when asked, which is half the size and what most notebooks use anyway
+func writeNpy(w io.Writer, vectors [][]float64, single bool) error {
+	dims := 0
+	if len(vectors) > 0 {
+		dims = len(vectors[0])
+	}
+	descr := "<f8"
+	if single {
+		descr = "<f4"
+	}
+
+	// The header is padded so the data starts on a 64-byte boundary
+	header := fmt.Sprintf("{'descr': '%s', 'fortran_order': False, 'shape': (%d, %d), }", descr, len(vectors), dims)
+	pad := 64 - (len(npyMagic)+4+len(header)+1)%64
+	header += strings.Repeat(" ", pad%64) + "\n"
+
+	buf := bufio.NewWriter(w)
+	buf.WriteString(npyMagic)
+	buf.Write([]byte{1, 0})
+	binary.Write(buf, binary.LittleEndian, uint16(len(header)))
+	buf.WriteString(header)
+
+	var b [8]byte
+	for i, vector := range vectors {
+		if len(vector) != dims {
+			return fmt.Errorf("vector %d has %d dimensions, expected %d", i+1, len(vector), dims)
+		}
+		for _, v := range vector {
+			if single {
+				binary.LittleEndian.PutUint32(b[:4], math.Float32bits(float32(v)))
+				buf.Write(b[:4])
+			} else {
+				binary.LittleEndian.PutUint64(b[:], math.Float64bits(v))
+				buf.Write(b[:])
+			}
+		}
+	}
+	if err := buf.Flush(); err != nil {
+		return fmt.Errorf("failed to write array: %w", err)
+	}
+	return nil
+}
+
+// readNpy reads a 1-D or 2-D float32 or float64 array, one vector per row
+func readNpy(r io.Reader) ([][]float64, error) {
+	buf := bufio.NewReader(r)
+
+	prefix := make([]byte, len(npyMagic)+2)
+	if _, err := io.ReadFull(buf, prefix); err != nil || string(prefix[:len(npyMagic)]) != npyMagic {
+		return nil, errors.New("not a .npy file")
+	}
+	var headerLen uint32
+	switch prefix[len(npyMagic)] {
+	case 1:
+		var n uint16
+		if err := binary.Read(buf, binary.LittleEndian, &n); err != nil {
+			return nil, fmt.Errorf("failed to read .npy header: %w", err)
+		}
+		headerLen = uint32(n)
+	case 2, 3:
+		if err := binary.Read(buf, binary.LittleEndian, &headerLen); err != nil {
+			return nil, fmt.Errorf("failed to read .npy header: %w", err)
+		}
+	default:
+		return nil, fmt.Errorf("unsupported .npy version %d", prefix[len(npyMagic)])
+	}
+	if headerLen > 1<<16 {
+		return nil, fmt.Errorf(".npy header is %d bytes", headerLen)
+	}
+	headerBytes := make([]byte, headerLen)
+	if _, err := io.ReadFull(buf, headerBytes); err != nil {
+		return nil, fmt.Errorf("failed to read .npy header: %w", err)
+	}
+	header := string(headerBytes)
+
+	descr := npyDescr.FindStringSubmatch(header)
+	shape := npyShape.FindStringSubmatch(header)
+	if descr == nil || shape == nil {
+		return nil, fmt.Errorf("unrecognised .npy header %q", strings.TrimSpace(header))
+	}
+
+	var order binary.ByteOrder = binary.LittleEndian
+	if strings.HasPrefix(descr[1], ">") {
+		order = binary.BigEndian
+	}
+	size := 0
+	switch strings.TrimLeft(descr[1], "<>=|") {
+	case "f4":
+		size = 4
+	case "f8":
+		size = 8
+	default:
+		return nil, fmt.Errorf("unsupported .npy dtype %s (need float32 or float64)", descr[1])
+	}
+
+	var dimsList []int
+	for _, part := range strings.Split(shape[1], ",") {
+		if part = strings.TrimSpace(part); part == "" {
+			continue
+		}
+		n, err := strconv.Atoi(part)
+		if err != nil || n < 0 {
+			return nil, fmt.Errorf("bad .npy shape (%s)", shape[1])
+		}
+		dimsList = append(dimsList, n)
+	}
+	rows, dims := 1, 0
+	switch len(dimsList) {
+	case 1:
+		dims = dimsList[0]
+	case 2:
+		rows, dims = dimsList[0], dimsList[1]
+	default:
+		return nil, fmt.Errorf("expected a 1-D or 2-D array, got shape (%s)", shape[1])
+	}
+	// Checked by division, since rows*dims can overflow
+	if dims > 0 && rows > (1<<28)/dims {
+		return nil, fmt.Errorf("array of shape (%s) is too large", shape[1])
+	}
+
+	values := make([]float64, rows*dims)
+	raw := make([]byte, size)
+	for i := range values {
+		if _, err := io.ReadFull(buf, raw); err != nil {
+			return nil, fmt.Errorf("array ends after %d of %d values: %w", i, len(values), err)
+		}
+		if size == 4 {
+			values[i] = float64(math.Float32frombits(order.Uint32(raw)))
+		} else {
+			values[i] = math.Float64frombits(order.Uint64(raw))
+		}
+	}
+
+	fortran := npyFortran.FindStringSubmatch(header)
+	vectors := make([][]float64, rows)
+	for row := range vectors {
+		vectors[row] = make([]float64, dims)
+		for col := range vectors[row] {
+			if fortran != nil && fortran[1] == "True" {
+				vectors[row][col] = values[col*rows+row]
+			} else {
+				vectors[row][col] = values[row*dims+col]
+			}
+		}
+	}
+	return vectors, nil
+}
+
+// writeNpz writes vectors as the single array "embeddings" in a .npz archive
+func writeNpz(w io.Writer, vectors [][]float64, single bool) error {
+	zw := zip.NewWriter(w)
+	f, err := zw.Create(npzArrayName + ".npy")
+	if err != nil {
+		return fmt.Errorf("failed to write archive: %w", err)
+	}
+	if err := writeNpy(f, vectors, single); err != nil {
+		return err
+	}
+	return zw.Close()
+}
+
+// readNpz reads the "embeddings" array of a .npz archive, or its only array
+func readNpz(data []byte) ([][]float64, error) {
+	zr, err := zip.NewReader(bytes.NewReader(data), int64(len(data)))
+	if err != nil {
+		return nil, fmt.Errorf("not a .npz file: %w", err)
+	}
+
+	var chosen *zip.File
+	for _, f := range zr.File {
+		if f.Name == npzArrayName+".npy" {
+			chosen = f
+			break
+		}
+	}
+	if chosen == nil {
+		if len(zr.File) != 1 {
+			return nil, fmt.Errorf(".npz has %d arrays and none is named %q", len(zr.File), npzArrayName)
+		}
+		chosen = zr.File[0]
+	}
+
+	rc, err := chosen.Open()
+	if err != nil {
+		return nil, fmt.Errorf("failed to open %s: %w", chosen.Name, err)
+	}
+	defer rc.Close()
+	return readNpy(rc)
+}
+
+// textsSidecar is the file holding the texts for the vectors in path: the
+// same name with a .txt extension, one text per line
+func textsSidecar(path string) string {
+	return strings.TrimSuffix(path, filepath.Ext(path)) + ".txt"
+}
+
+// writeNumpyFile writes embeddings to a .npy or .npz file and their texts to
+// its sidecar. Line breaks inside a text become spaces, as the sidecar has
+// one text per line.
+func writeNumpyFile(path string, embeddings []CustomEmbedding, single bool) error {
+	vectors := make([][]float64, len(embeddings))
+	var texts strings.Builder
+	for i, e := range embeddings {
+		vectors[i] = e.Embedding
+		texts.WriteString(strings.Join(strings.Fields(e.Text), " ") + "\n")
+	}
+
+	f, err := os.Create(path)
+	if err != nil {
+		return fmt.Errorf("failed to create %s: %w", path, err)
+	}
+	defer f.Close()
+
+	if strings.EqualFold(filepath.Ext(path), ".npz") {
+		err = writeNpz(f, vectors, single)
+	} else {
+		err = writeNpy(f, vectors, single)
+	}
+	if err != nil {
+		return err
+	}
+	if err := f.Close(); err != nil {
+		return fmt.Errorf("failed to write %s: %w", path, err)
+	}
+
+	if err := os.WriteFile(textsSidecar(path), []byte(texts.String()), 0o644); err != nil {
+		return fmt.Errorf("failed to write texts: %w", err)
+	}
+	return nil
+}
+
+// readNumpyFile reads a .npy or .npz file and pairs its rows with the lines
+// of its texts sidecar
+func readNumpyFile(path string) ([]CustomEmbedding, error) {
+	var vectors [][]float64
+	if strings.EqualFold(filepath.Ext(path), ".npz") {
+		data, err := os.ReadFile(path)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read %s: %w", path, err)
+		}
+		vectors, err = readNpz(data)
+		if err != nil {
+			return nil, fmt.Errorf("failed to import %s: %w", path, err)
+		}
+	} else {
+		f, err := os.Open(path)
+		if err != nil {
+			return nil, fmt.Errorf("failed to open %s: %w", path, err)
+		}
+		defer f.Close()
+		vectors, err = readNpy(f)
+		if err != nil {
+			return nil, fmt.Errorf("failed to import %s: %w", path, err)
+		}
+	}
+
+	sidecar := textsSidecar(path)
+	texts, err := readTextLines(sidecar)
+	if err != nil {
+		return nil, fmt.Errorf("%s needs its texts in %s, one per line: %w", path, sidecar, err)
+	}
+	if len(texts) != len(vectors) {
+		return nil, fmt.Errorf("%s has %d vectors but %s has %d texts", path, len(vectors), sidecar, len(texts))
+	}
+
+	embeddings := make([]CustomEmbedding, len(vectors))
+	for i := range vectors {
+		embeddings[i] = CustomEmbedding{Text: texts[i], Embedding: vectors[i]}
+	}
+	return embeddings, nil
+}
@@ -0,0 +1,237 @@
+//go:build onnx
+
+package main
+
+import (
+	"fmt"
+	"io"
+	"math"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strconv"
+	"sync"
+
+	ort "github.com/yalue/onnxruntime_go"
+)
+
+const (
+	onnxDefaultModel     = "sentence-transformers/all-MiniLM-L6-v2"
+	onnxDefaultMaxTokens = 256
+	huggingFaceURL       = "https://huggingface.co"
+)
+
+// Files fetched from the model repository on first use
+var onnxModelFiles = map[string]string{
+	"model.onnx": "onnx/model.onnx",
+	"vocab.txt":  "vocab.txt",
+}
+
+// ONNXProvider runs a sentence-transformers model exported to ONNX in-process,
+// so embeddings need neither network access nor an API key once the model is cached
+type ONNXProvider struct {
+	mu        sync.Mutex
+	session   *ort.DynamicAdvancedSession
+	tokenizer *wordPieceTokenizer
+	model     string
+	maxTokens int
+}
+
+func init() {
+	RegisterProvider("onnx", func() (EmbeddingProvider, error) {
+		return NewONNXProvider()
+	})
+	RegisterModelReader("onnx", modelFromEnv("EMBER_ONNX_MODEL", onnxDefaultModel))
+}
+
+func NewONNXProvider() (*ONNXProvider, error) {
+	model := os.Getenv("EMBER_ONNX_MODEL")
+	if model == "" {
+		model = onnxDefaultModel
+	}
+
+	maxTokens := onnxDefaultMaxTokens
+	if n, err := strconv.Atoi(os.Getenv("EMBER_ONNX_MAX_TOKENS")); err == nil && n > 2 {
+		maxTokens = n
+	}
+
+	dir, err := ensureONNXModel(model)
+	if err != nil {
+		return nil, err
+	}
+
+	tokenizer, err := loadWordPieceTokenizer(filepath.Join(dir, "vocab.txt"))
+	if err != nil {
+		return nil, err
+	}
+
+	if lib := os.Getenv("EMBER_ONNX_LIB"); lib != "" {
+		ort.SetSharedLibraryPath(lib)
+	}
+	if !ort.IsInitialized() {
+		if err := ort.InitializeEnvironment(); err != nil {
+			return nil, fmt.Errorf("failed to load onnxruntime (set EMBER_ONNX_LIB to its shared library): %w", err)
+		}
+	}
+
+	session, err := ort.NewDynamicAdvancedSession(filepath.Join(dir, "model.onnx"),
+		[]string{"input_ids", "attention_mask", "token_type_ids"},
+		[]string{"last_hidden_state"}, nil)
+	if err != nil {
+		return nil, fmt.Errorf("failed to load model: %w", err)
+	}
+
+	return &ONNXProvider{
+		session:   session,
+		tokenizer: tokenizer,
+		model:     model,
+		maxTokens: maxTokens,
+	}, nil
+}
+
+// ensureONNXModel downloads the model into the user cache directory if needed
+func ensureONNXModel(model string) (string, error) {
+	cacheDir, err := os.UserCacheDir()
+	if err != nil {
+		return "", fmt.Errorf("failed to find cache directory: %w", err)
+	}
+	dir := filepath.Join(cacheDir, "ember", "models", filepath.FromSlash(model))
+
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		return "", fmt.Errorf("failed to create model directory: %w", err)
+	}
+
+	for name, remote := range onnxModelFiles {
+		path := filepath.Join(dir, name)
+		if _, err := os.Stat(path); err == nil {
+			continue
+		}
+
+		fmt.Printf("⬇️  Downloading %s from %s...\n", remote, model)
+		if err := downloadFile(fmt.Sprintf("%s/%s/resolve/main/%s", huggingFaceURL, model, remote), path); err != nil {
+			return "", err
+		}
+	}
+
+	return dir, nil
+}
+
+// downloadFile writes url to path atomically so an interrupted download isn't mistaken for a model
+func downloadFile(url, path string) error {
+	resp, err := http.Get(url)
+	if err != nil {
+		return fmt.Errorf("failed to download %s: %w", url, err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("failed to download %s: status %d", url, resp.StatusCode)
+	}
+
+	tmp, err := os.CreateTemp(filepath.Dir(path), ".download-*")
+	if err != nil {
+		return fmt.Errorf("failed to create temp file: %w", err)
+	}
+	defer os.Remove(tmp.Name())
+
+	if _, err := io.Copy(tmp, resp.Body); err != nil {
+		tmp.Close()
+		return fmt.Errorf("failed to download %s: %w", url, err)
+	}
+	if err := tmp.Close(); err != nil {
+		return fmt.Errorf("failed to write %s: %w", path, err)
+	}
+
+	return os.Rename(tmp.Name(), path)
+}
+
+func (o *ONNXProvider) ModelInfo() ModelInfo {
+	return ModelInfo{Provider: "onnx", Model: o.model}
+}
+
+func (o *ONNXProvider) GenerateBatch(texts []string) ([][]float64, error) {
+	embeddings := make([][]float64, 0, len(texts))
+	for _, text := range texts {
+		embedding, err := o.GenerateEmbedding(text)
+		if err != nil {
+			return nil, err
+		}
+		embeddings = append(embeddings, embedding)
+	}
+	return embeddings, nil
+}
+
+// GenerateEmbedding runs the model and mean-pools the token states into one normalized vector
+func (o *ONNXProvider) GenerateEmbedding(text string) ([]float64, error) {
+	ids := o.tokenizer.Encode(text, o.maxTokens)
+	n := int64(len(ids))
+
+	mask := make([]int64, n)
+	types := make([]int64, n)
+	for i := range mask {
+		mask[i] = 1
+	}
+
+	shape := ort.NewShape(1, n)
+	inputIDs, err := ort.NewTensor(shape, ids)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create input tensor: %w", err)
+	}
+	defer inputIDs.Destroy()
+
+	attention, err := ort.NewTensor(shape, mask)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create input tensor: %w", err)
+	}
+	defer attention.Destroy()
+
+	tokenTypes, err := ort.NewTensor(shape, types)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create input tensor: %w", err)
+	}
+	defer tokenTypes.Destroy()
+
+	outputs := []ort.Value{nil}
+
+	o.mu.Lock()
+	err = o.session.Run([]ort.Value{inputIDs, attention, tokenTypes}, outputs)
+	o.mu.Unlock()
+	if err != nil {
+		return nil, fmt.Errorf("inference failed: %w", err)
+	}
+	defer outputs[0].Destroy()
+
+	hidden, ok := outputs[0].(*ort.Tensor[float32])
+	if !ok {
+		return nil, fmt.Errorf("unexpected output type %T", outputs[0])
+	}
+
+	dims := hidden.GetShape()
+	if len(dims) != 3 || dims[1] != n {
+		return nil, fmt.Errorf("unexpected output shape %v", dims)
+	}
+
+	// Every token is attended to, so mean pooling is a plain average
+	size := int(dims[2])
+	data := hidden.GetData()
+	embedding := make([]float64, size)
+	for t := 0; t < int(n); t++ {
+		for d := 0; d < size; d++ {
+			embedding[d] += float64(data[t*size+d])
+		}
+	}
+
+	var norm float64
+	for d := range embedding {
+		embedding[d] /= float64(n)
+		norm += embedding[d] * embedding[d]
+	}
+	norm = math.Sqrt(norm)
+	if norm > 0 {
+		for d := range embedding {
+			embedding[d] /= norm
+		}
+	}
+
+	return embedding, nil
+}
@@ -0,0 +1,632 @@
+package main
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"mime/multipart"
+	"net/http"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Texts per request of a batch; OpenAI takes up to 2048 per request
+const defaultBatchInputs = 256
+
+// Requests OpenAI accepts in one batch
+const maxBatchRequests = 50000
+
+// How often the jobs screen checks on a batch
+const openAIBatchPoll = time.Minute
+
+// openAIBatch is a batch submitted with `ember batch submit`, as kept in
+// batches.json until its vectors are downloaded
+type openAIBatch struct {
+	ID         string    `json:"id"`
+	Model      string    `json:"model"`
+	Dimensions int       `json:"dimensions,omitempty"`
+	BaseURL    string    `json:"base_url"`
+	Input      string    `json:"input"`
+	Out        string    `json:"out"`
+	Texts      int       `json:"texts"`
+	Submitted  time.Time `json:"submitted"`
+	Downloaded time.Time `json:"downloaded,omitempty"`
+}
+
+// openAIBatchRequest is one line of a batch's input file
+type openAIBatchRequest struct {
+	CustomID string                 `json:"custom_id"`
+	Method   string                 `json:"method"`
+	URL      string                 `json:"url"`
+	Body     OpenAIEmbeddingRequest `json:"body"`
+}
+
+// openAIBatchStatus is what the Batch API reports about a batch
+type openAIBatchStatus struct {
+	ID            string `json:"id"`
+	Status        string `json:"status"`
+	OutputFileID  string `json:"output_file_id"`
+	RequestCounts struct {
+		Total     int `json:"total"`
+		Completed int `json:"completed"`
+		Failed    int `json:"failed"`
+	} `json:"request_counts"`
+	Errors *struct {
+		Data []struct {
+			Message string `json:"message"`
+		} `json:"data"`
+	} `json:"errors"`
+}
+
+// openAIBatchResult is one line of a batch's output file
+type openAIBatchResult struct {
+	CustomID string `json:"custom_id"`
+	Response *struct {
+		StatusCode int                     `json:"status_code"`
+		Body       OpenAIEmbeddingResponse `json:"body"`
+	} `json:"response"`
+}
+
+// finished reports whether the batch won't change any more
+func (s openAIBatchStatus) finished() bool {
+	switch s.Status {
+	case "completed", "failed", "expired", "cancelled":
+		return true
+	}
+	return false
+}
+
+// failure explains why a batch failed, when it did
+func (s openAIBatchStatus) failure() error {
+	switch s.Status {
+	case "failed":
+		if s.Errors != nil && len(s.Errors.Data) > 0 {
+			return fmt.Errorf("batch %s failed: %s", s.ID, s.Errors.Data[0].Message)
+		}
+		return fmt.Errorf("batch %s failed", s.ID)
+	case "cancelled":
+		return fmt.Errorf("batch %s was cancelled", s.ID)
+	case "expired":
+		if s.OutputFileID == "" {
+			return fmt.Errorf("batch %s expired before any request finished", s.ID)
+		}
+	}
+	return nil
+}
+
+// openAIBatchClient calls the Files and Batch APIs. Batches belong to the
+// key's project, so every call uses the first key in OPENAI_API_KEYS or
+// OPENAI_API_KEY rather than rotating.
+type openAIBatchClient struct {
+	baseURL string
+	key     string
+	client  *http.Client
+}
+
+func newOpenAIBatchClient(baseURL string) (*openAIBatchClient, error) {
+	keys := loadAPIKeys()
+	if len(keys) == 0 {
+		return nil, fmt.Errorf("OPENAI_API_KEY environment variable not set")
+	}
+	return &openAIBatchClient{baseURL: baseURL, key: keys[0], client: &http.Client{Timeout: 10 * time.Minute}}, nil
+}
+
+func (c *openAIBatchClient) do(method, path, contentType string, body io.Reader) ([]byte, error) {
+	req, err := http.NewRequest(method, c.baseURL+path, body)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create request: %w", err)
+	}
+	req.Header.Set("Authorization", "Bearer "+c.key)
+	if contentType != "" {
+		req.Header.Set("Content-Type", contentType)
+	}
+	resp, err := c.client.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("failed to make request: %w", err)
+	}
+	defer resp.Body.Close()
+	data, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read response: %w", err)
+	}
+	if resp.StatusCode != http.StatusOK {
+		return nil, &APIStatusError{StatusCode: resp.StatusCode, Body: string(data)}
+	}
+	return data, nil
+}
+
+// submit uploads requests as a batch input file and starts a batch over it
+func (c *openAIBatchClient) submit(requests []byte) (openAIBatchStatus, error) {
+	var form bytes.Buffer
+	w := multipart.NewWriter(&form)
+	w.WriteField("purpose", "batch")
+	part, err := w.CreateFormFile("file", "ember-batch.jsonl")
+	if err == nil {
+		_, err = part.Write(requests)
+	}
+	if err == nil {
+		err = w.Close()
+	}
+	if err != nil {
+		return openAIBatchStatus{}, fmt.Errorf("failed to build upload: %w", err)
+	}
+	data, err := c.do("POST", "/files", w.FormDataContentType(), &form)
+	if err != nil {
+		return openAIBatchStatus{}, fmt.Errorf("failed to upload the batch: %w", err)
+	}
+	var file struct {
+		ID string `json:"id"`
+	}
+	if err := json.Unmarshal(data, &file); err != nil {
+		return openAIBatchStatus{}, fmt.Errorf("failed to read the upload: %w", err)
+	}
+
+	create, _ := json.Marshal(map[string]string{
+		"input_file_id":     file.ID,
+		"endpoint":          "/v1/embeddings",
+		"completion_window": "24h",
+	})
+	if data, err = c.do("POST", "/batches", "application/json", bytes.NewReader(create)); err != nil {
+		return openAIBatchStatus{}, fmt.Errorf("failed to create the batch: %w", err)
+	}
+	var status openAIBatchStatus
+	if err := json.Unmarshal(data, &status); err != nil {
+		return openAIBatchStatus{}, fmt.Errorf("failed to read the batch: %w", err)
+	}
+	return status, nil
+}
+
+func (c *openAIBatchClient) status(id string) (openAIBatchStatus, error) {
+	data, err := c.do("GET", "/batches/"+id, "", nil)
+	if err != nil {
+		return openAIBatchStatus{}, fmt.Errorf("failed to check batch %s: %w", id, err)
+	}
+	var status openAIBatchStatus
+	if err := json.Unmarshal(data, &status); err != nil {
+		return openAIBatchStatus{}, fmt.Errorf("failed to read batch %s: %w", id, err)
+	}
+	return status, nil
+}
+
+// download writes the vectors of a finished batch to b.Out as JSONL, like
+// `ember generate --lang json`, in the order of the input. It returns how
+// many texts got a vector and how many didn't.
+func (c *openAIBatchClient) download(b openAIBatch, status openAIBatchStatus) (written, failed int, err error) {
+	requests, err := readOpenAIBatchRequests(b.ID)
+	if err != nil {
+		return 0, 0, err
+	}
+	data, err := c.do("GET", "/files/"+status.OutputFileID+"/content", "", nil)
+	if err != nil {
+		return 0, 0, fmt.Errorf("failed to download batch %s: %w", b.ID, err)
+	}
+
+	vectors := make(map[string][][]float64)
+	scanner := bufio.NewScanner(bytes.NewReader(data))
+	scanner.Buffer(make([]byte, 0, 1024*1024), 512*1024*1024)
+	for scanner.Scan() {
+		var result openAIBatchResult
+		if err := json.Unmarshal(scanner.Bytes(), &result); err != nil {
+			return 0, 0, fmt.Errorf("failed to read batch %s: %w", b.ID, err)
+		}
+		if result.Response == nil || result.Response.StatusCode != http.StatusOK {
+			continue
+		}
+		request, ok := requests[result.CustomID]
+		if !ok || len(result.Response.Body.Data) != len(request.Body.Input) {
+			continue
+		}
+		list := make([][]float64, len(request.Body.Input))
+		for _, d := range result.Response.Body.Data {
+			if d.Index >= 0 && d.Index < len(list) {
+				list[d.Index] = d.Embedding
+			}
+		}
+		vectors[result.CustomID] = list
+	}
+	if err := scanner.Err(); err != nil {
+		return 0, 0, fmt.Errorf("failed to read batch %s: %w", b.ID, err)
+	}
+
+	ids := make([]string, 0, len(requests))
+	for id := range requests {
+		ids = append(ids, id)
+	}
+	sort.Slice(ids, func(i, j int) bool {
+		a, _ := strconv.Atoi(ids[i])
+		b, _ := strconv.Atoi(ids[j])
+		return a < b
+	})
+	var embeddings []CustomEmbedding
+	for _, id := range ids {
+		list := vectors[id]
+		for i, text := range requests[id].Body.Input {
+			if list == nil || list[i] == nil {
+				failed++
+				continue
+			}
+			embeddings = append(embeddings, CustomEmbedding{Text: text, Embedding: list[i]})
+		}
+	}
+
+	tmp := b.Out + ".tmp"
+	f, err := os.Create(tmp)
+	if err != nil {
+		return 0, 0, fmt.Errorf("failed to create %s: %w", b.Out, err)
+	}
+	buf := bufio.NewWriter(f)
+	err = writeJSONEmbeddings(buf, ModelInfo{Provider: "openai", Model: b.Model, Dimensions: b.Dimensions}, embeddings, generateOptions{})
+	if err == nil {
+		err = buf.Flush()
+	}
+	if closeErr := f.Close(); err == nil {
+		err = closeErr
+	}
+	if err == nil {
+		err = os.Rename(tmp, b.Out)
+	}
+	if err != nil {
+		os.Remove(tmp)
+		return 0, 0, fmt.Errorf("failed to write %s: %w", b.Out, err)
+	}
+	return len(embeddings), failed, nil
+}
+
+// openAIBatchesDir keeps batches.json and a copy of each batch's requests,
+// which hold the texts its vectors are matched back to
+func openAIBatchesDir() (string, error) {
+	dir, err := userDataDir()
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(dir, "batches"), nil
+}
+
+func loadOpenAIBatches() ([]openAIBatch, error) {
+	dir, err := openAIBatchesDir()
+	if err != nil {
+		return nil, err
+	}
+	data, err := os.ReadFile(filepath.Join(dir, "batches.json"))
+	if errors.Is(err, os.ErrNotExist) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to read batches: %w", err)
+	}
+	var batches []openAIBatch
+	if err := json.Unmarshal(data, &batches); err != nil {
+		return nil, fmt.Errorf("failed to read batches: %w", err)
+	}
+	return batches, nil
+}
+
+// saveOpenAIBatch adds b to batches.json, or replaces the batch with its ID
+func saveOpenAIBatch(b openAIBatch) error {
+	batches, err := loadOpenAIBatches()
+	if err != nil {
+		return err
+	}
+	replaced := false
+	for i := range batches {
+		if batches[i].ID == b.ID {
+			batches[i], replaced = b, true
+		}
+	}
+	if !replaced {
+		batches = append(batches, b)
+	}
+	dir, err := openAIBatchesDir()
+	if err != nil {
+		return err
+	}
+	data, err := json.MarshalIndent(batches, "", "  ")
+	if err != nil {
+		return fmt.Errorf("failed to marshal batches: %w", err)
+	}
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		return fmt.Errorf("failed to create %s: %w", dir, err)
+	}
+	path := filepath.Join(dir, "batches.json")
+	if err := os.WriteFile(path+".tmp", data, 0o644); err != nil {
+		return fmt.Errorf("failed to save batches: %w", err)
+	}
+	if err := os.Rename(path+".tmp", path); err != nil {
+		return fmt.Errorf("failed to save batches: %w", err)
+	}
+	return nil
+}
+
+// readOpenAIBatchRequests reads the copy of a batch's requests by custom ID
+func readOpenAIBatchRequests(id string) (map[string]openAIBatchRequest, error) {
+	dir, err := openAIBatchesDir()
+	if err != nil {
+		return nil, err
+	}
+	path := filepath.Join(dir, id+".jsonl")
+	data, err := os.ReadFile(path)
+	if err == nil {
+		data, err = openAtRest(data)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to read the requests of batch %s: %w", id, err)
+	}
+	requests := make(map[string]openAIBatchRequest)
+	for _, line := range bytes.Split(bytes.TrimSpace(data), []byte("\n")) {
+		var request openAIBatchRequest
+		if err := json.Unmarshal(line, &request); err != nil {
+			return nil, fmt.Errorf("failed to read the requests of batch %s: %w", id, err)
+		}
+		requests[request.CustomID] = request
+	}
+	return requests, nil
+}
+
+// runBatchCommand handles `ember batch submit|status|download`
+func runBatchCommand(args []string) {
+	usage := "Usage: ember batch submit --in texts.txt [--out FILE.jsonl] [--inputs N] | status | download [ID]"
+	if len(args) == 0 {
+		fmt.Println(usage)
+		os.Exit(2)
+	}
+	var err error
+	switch args[0] {
+	case "submit":
+		err = runBatchSubmit(args[1:])
+	case "status":
+		err = runBatchStatus()
+	case "download":
+		err = runBatchDownload(args[1:])
+	default:
+		fmt.Println(usage)
+		os.Exit(2)
+	}
+	if err != nil {
+		displayError(err)
+		os.Exit(1)
+	}
+}
+
+// runBatchSubmit splits the lines of a file into embedding requests and
+// submits them as one batch, with the model and dimensions OpenAI is set up
+// with
+func runBatchSubmit(args []string) error {
+	fs := flag.NewFlagSet("batch submit", flag.ExitOnError)
+	in := fs.String("in", "", "file with one text per line")
+	out := fs.String("out", "", "JSONL file the vectors are downloaded to (default: next to --in)")
+	inputs := fs.Int("inputs", defaultBatchInputs, "texts per request, up to 2048")
+	fs.Parse(args)
+	if *in == "" || *in == "-" || fs.NArg() > 0 || *inputs < 1 || *inputs > 2048 {
+		return errors.New("usage: ember batch submit --in texts.txt [--out FILE.jsonl] [--inputs N]")
+	}
+	if *out == "" {
+		*out = strings.TrimSuffix(*in, filepath.Ext(*in)) + ".embeddings.jsonl"
+	}
+	outPath, err := filepath.Abs(*out)
+	if err != nil {
+		return fmt.Errorf("failed to resolve %s: %w", *out, err)
+	}
+
+	provider, err := NewOpenAIProvider()
+	if err != nil {
+		return err
+	}
+	client, err := newOpenAIBatchClient(provider.baseURL)
+	if err != nil {
+		return err
+	}
+	texts, err := readTextLines(*in)
+	if err != nil {
+		return err
+	}
+	if len(texts) == 0 {
+		return fmt.Errorf("no texts found in %s", *in)
+	}
+	if requests := (len(texts) + *inputs - 1) / *inputs; requests > maxBatchRequests {
+		return fmt.Errorf("%d texts make %d requests, over the %d a batch takes • raise --inputs or split the file", len(texts), requests, maxBatchRequests)
+	}
+
+	var lines bytes.Buffer
+	for i := 0; i*(*inputs) < len(texts); i++ {
+		chunk := texts[i*(*inputs) : min((i+1)*(*inputs), len(texts))]
+		line, err := json.Marshal(openAIBatchRequest{
+			CustomID: strconv.Itoa(i),
+			Method:   "POST",
+			URL:      "/v1/embeddings",
+			Body:     OpenAIEmbeddingRequest{Input: chunk, Model: provider.model, Dimensions: provider.dimensions},
+		})
+		if err != nil {
+			return fmt.Errorf("failed to marshal request: %w", err)
+		}
+		lines.Write(append(line, '\n'))
+	}
+
+	status, err := client.submit(lines.Bytes())
+	auditCall(provider.ModelInfo(), "batch", texts, 0, err)
+	if err != nil {
+		return err
+	}
+	dir, err := openAIBatchesDir()
+	if err == nil {
+		err = os.MkdirAll(dir, 0o755)
+	}
+	if err == nil {
+		err = os.WriteFile(filepath.Join(dir, status.ID+".jsonl"), sealAtRest(lines.Bytes()), 0o644)
+	}
+	if err == nil {
+		err = saveOpenAIBatch(openAIBatch{
+			ID: status.ID, Model: provider.model, Dimensions: provider.dimensions, BaseURL: provider.baseURL,
+			Input: *in, Out: outPath, Texts: len(texts), Submitted: time.Now(),
+		})
+	}
+	if err != nil {
+		return fmt.Errorf("batch %s was submitted, but couldn't be recorded: %w", status.ID, err)
+	}
+	fmt.Printf("📨 Submitted %d texts as batch %s with %s\n", len(texts), status.ID, provider.model)
+	fmt.Println("OpenAI finishes batches within 24 hours • the jobs screen (Ctrl+O) watches it, or run ember batch status and ember batch download")
+	return nil
+}
+
+// runBatchStatus lists the recorded batches with what OpenAI reports
+func runBatchStatus() error {
+	batches, err := loadOpenAIBatches()
+	if err != nil {
+		return err
+	}
+	if len(batches) == 0 {
+		fmt.Println("No batches • ember batch submit sends one")
+		return nil
+	}
+	for _, b := range batches {
+		line := fmt.Sprintf("%s  %s → %s  ", b.ID, filepath.Base(b.Input), b.Out)
+		if !b.Downloaded.IsZero() {
+			fmt.Println(line + "downloaded " + b.Downloaded.Format("2006-01-02 15:04"))
+			continue
+		}
+		client, err := newOpenAIBatchClient(b.BaseURL)
+		var status openAIBatchStatus
+		if err == nil {
+			status, err = client.status(b.ID)
+		}
+		if err != nil {
+			fmt.Println(line + "⚠️  " + err.Error())
+			continue
+		}
+		counts := status.RequestCounts
+		line += fmt.Sprintf("%s • %d/%d requests", status.Status, counts.Completed, counts.Total)
+		if counts.Failed > 0 {
+			line += fmt.Sprintf(", %d failed", counts.Failed)
+		}
+		fmt.Println(line)
+	}
+	return nil
+}
+
+// runBatchDownload downloads the vectors of the batch with id, or of every
+// finished batch not downloaded yet
+func runBatchDownload(args []string) error {
+	if len(args) > 1 {
+		return errors.New("usage: ember batch download [ID]")
+	}
+	batches, err := loadOpenAIBatches()
+	if err != nil {
+		return err
+	}
+	found := false
+	for _, b := range batches {
+		if len(args) == 1 && b.ID != args[0] {
+			continue
+		}
+		if len(args) == 0 && !b.Downloaded.IsZero() {
+			continue
+		}
+		found = true
+		client, err := newOpenAIBatchClient(b.BaseURL)
+		if err != nil {
+			return err
+		}
+		status, err := client.status(b.ID)
+		if err != nil {
+			return err
+		}
+		if !status.finished() {
+			fmt.Printf("⏳ %s is %s • %d/%d requests done\n", b.ID, status.Status, status.RequestCounts.Completed, status.RequestCounts.Total)
+			continue
+		}
+		if err := status.failure(); err != nil {
+			return err
+		}
+		written, failed, err := client.download(b, status)
+		if err != nil {
+			return err
+		}
+		b.Downloaded = time.Now()
+		if err := saveOpenAIBatch(b); err != nil {
+			return err
+		}
+		fmt.Printf("📥 Wrote %d vectors from %s to %s\n", written, b.ID, b.Out)
+		if failed > 0 {
+			fmt.Printf("⚠️  %d texts got no vector • submit them again to fill the gaps\n", failed)
+		}
+	}
+	if !found && len(args) == 1 {
+		return fmt.Errorf("no batch %s • ember batch status lists them", args[0])
+	}
+	return nil
+}
+
+// watchOpenAIBatches adds a job to the jobs screen for every batch that
+// hasn't been downloaded, which checks on it every minute and downloads its
+// vectors once it's done
+func (m *model) watchOpenAIBatches() {
+	batches, err := loadOpenAIBatches()
+	if err != nil {
+		m.modelNotice = "⚠️  " + err.Error()
+		return
+	}
+	for _, b := range batches {
+		if b.Downloaded.IsZero() {
+			m.jobs.Submit(fmt.Sprintf("OpenAI batch %s → %s", b.ID, filepath.Base(b.Out)), openAIBatchJob(b))
+		}
+	}
+}
+
+// openAIBatchJob polls a batch, showing its finished requests as progress.
+// Cancelling the job stops watching; the batch itself runs on.
+func openAIBatchJob(b openAIBatch) jobFunc {
+	return func(ctx context.Context, job *Job) (any, error) {
+		client, err := newOpenAIBatchClient(b.BaseURL)
+		if err != nil {
+			return nil, err
+		}
+		last := ""
+		for {
+			if err := job.Checkpoint(ctx); err != nil {
+				return nil, err
+			}
+			status, err := client.status(b.ID)
+			if err != nil {
+				job.Logf("%v", err)
+			} else {
+				counts := status.RequestCounts
+				job.SetProgress(counts.Completed+counts.Failed, counts.Total)
+				if status.Status != last {
+					job.Logf("%s", status.Status)
+					last = status.Status
+				}
+				if status.finished() {
+					if err := status.failure(); err != nil {
+						return nil, err
+					}
+					written, failed, err := client.download(b, status)
+					if err != nil {
+						return nil, err
+					}
+					b.Downloaded = time.Now()
+					if err := saveOpenAIBatch(b); err != nil {
+						return nil, err
+					}
+					job.Logf("wrote %d vectors to %s", written, b.Out)
+					if failed > 0 {
+						job.Logf("%d texts got no vector", failed)
+					}
+					return nil, nil
+				}
+			}
+			select {
+			case <-ctx.Done():
+				return nil, ctx.Err()
+			case <-time.After(openAIBatchPoll):
+			}
+		}
+	}
+}
@@ -0,0 +1,381 @@
+package main
+
+import (
+	"bufio"
+	"encoding/csv"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Output formats of the headless commands. table is for people; json,
+// ndjson and csv keep the same fields from release to release.
+var outputFormats = []string{"table", "json", "ndjson", "csv"}
+
+// Metric reported for scores from indexes whose metric ember doesn't know
+const indexMetric = "index"
+
+// formatFlag adds --format to fs, with extra formats a command also takes
+func formatFlag(fs *flag.FlagSet, def string, extra ...string) *string {
+	formats := append(slices.Clone(outputFormats), extra...)
+	return fs.String("format", def, "output format: "+strings.Join(formats, ", "))
+}
+
+// checkFormat exits with a usage error unless format is one of the output
+// formats or extra. "text" is accepted as the table format.
+func checkFormat(format *string, extra ...string) {
+	if *format == "text" {
+		*format = "table"
+	}
+	formats := append(slices.Clone(outputFormats), extra...)
+	if !slices.Contains(formats, *format) {
+		displayError(fmt.Errorf("unknown format %q (available: %s)", *format, strings.Join(formats, ", ")))
+		os.Exit(2)
+	}
+}
+
+// rankedOutput is the result of a command that ranks texts by their score
+// against a query
+type rankedOutput struct {
+	Model      string         `json:"model"`
+	Dimensions int            `json:"dimensions"`
+	Metric     string         `json:"metric"`
+	Query      string         `json:"query"`
+	Results    []rankedResult `json:"results"`
+}
+
+// rankedResult is one ranked text. ID is set for indexes that key texts by ID.
+type rankedResult struct {
+	Rank  int     `json:"rank"`
+	Score float64 `json:"score"`
+	Text  string  `json:"text"`
+	ID    string  `json:"id,omitempty"`
+}
+
+// rankedLine is one ndjson line: a result with the fields that say how it
+// was scored, so each line stands on its own
+type rankedLine struct {
+	rankedResult
+	Model      string `json:"model"`
+	Dimensions int    `json:"dimensions"`
+	Metric     string `json:"metric"`
+}
+
+// newRankedOutput starts the output of a ranking of query by info's vectors
+// of size dimensions
+func newRankedOutput(info ModelInfo, dimensions int, metric, query string) rankedOutput {
+	return rankedOutput{Model: info.Provider + "/" + info.Model, Dimensions: dimensions, Metric: metric, Query: query, Results: []rankedResult{}}
+}
+
+func (o *rankedOutput) add(score float64, text, id string) {
+	o.Results = append(o.Results, rankedResult{Rank: len(o.Results) + 1, Score: score, Text: text, ID: id})
+}
+
+// write prints o in format to stdout
+func (o rankedOutput) write(format string) error {
+	w := bufio.NewWriter(os.Stdout)
+	if err := o.writeTo(w, format); err != nil {
+		return err
+	}
+	if err := w.Flush(); err != nil {
+		return fmt.Errorf("failed to write output: %w", err)
+	}
+	return nil
+}
+
+func (o rankedOutput) writeTo(w io.Writer, format string) error {
+	switch format {
+	case "json":
+		return writeJSONOutput(w, o)
+	case "ndjson":
+		enc := json.NewEncoder(w)
+		for _, r := range o.Results {
+			if err := enc.Encode(rankedLine{rankedResult: r, Model: o.Model, Dimensions: o.Dimensions, Metric: o.Metric}); err != nil {
+				return fmt.Errorf("failed to encode results: %w", err)
+			}
+		}
+	case "csv":
+		cw := csv.NewWriter(w)
+		cw.Write([]string{"rank", "score", "text", "id", "model", "dimensions", "metric"})
+		for _, r := range o.Results {
+			cw.Write([]string{strconv.Itoa(r.Rank), formatScore(r.Score), r.Text, r.ID, o.Model, strconv.Itoa(o.Dimensions), o.Metric})
+		}
+		cw.Flush()
+		return cw.Error()
+	case "tsv":
+		for _, r := range o.Results {
+			fmt.Fprintf(w, "%.4f\t%s\n", r.Score, strings.Join(strings.Fields(r.Text), " "))
+		}
+	default:
+		for _, r := range o.Results {
+			text := strings.ReplaceAll(r.Text, "\n", " ")
+			if r.ID != "" {
+				fmt.Fprintf(w, "%2d. %.4f  %-20s %s\n", r.Rank, r.Score, truncateText(r.ID, 20), truncateText(text, 60))
+			} else {
+				fmt.Fprintf(w, "%2d. %.4f  %s\n", r.Rank, r.Score, truncateText(text, 80))
+			}
+		}
+	}
+	return nil
+}
+
+// writeJSONOutput writes v as indented JSON
+func writeJSONOutput(w io.Writer, v any) error {
+	data, err := json.MarshalIndent(v, "", "  ")
+	if err != nil {
+		return fmt.Errorf("failed to marshal output: %w", err)
+	}
+	_, err = fmt.Fprintln(w, string(data))
+	return err
+}
+
+// formatScore writes a score with full precision for machine formats
+func formatScore(score float64) string {
+	return strconv.FormatFloat(score, 'g', -1, 64)
+}
+
+// similarityOutput is the score of a pair of texts from `ember similarity`
+type similarityOutput struct {
+	Model      string  `json:"model"`
+	Dimensions int     `json:"dimensions"`
+	Metric     string  `json:"metric"`
+	A          string  `json:"a"`
+	B          string  `json:"b"`
+	Score      float64 `json:"score"`
+}
+
+// write prints o in format to stdout. The table format is the bare score.
+func (o similarityOutput) write(format string) error {
+	switch format {
+	case "json":
+		return writeJSONOutput(os.Stdout, o)
+	case "ndjson":
+		if err := json.NewEncoder(os.Stdout).Encode(o); err != nil {
+			return fmt.Errorf("failed to encode the score: %w", err)
+		}
+	case "csv":
+		cw := csv.NewWriter(os.Stdout)
+		cw.Write([]string{"score", "a", "b", "model", "dimensions", "metric"})
+		cw.Write([]string{formatScore(o.Score), o.A, o.B, o.Model, strconv.Itoa(o.Dimensions), o.Metric})
+		cw.Flush()
+		return cw.Error()
+	default:
+		fmt.Printf("%.4f\n", o.Score)
+	}
+	return nil
+}
+
+// writeOutput prints v in format to stdout: json as one object, and ndjson
+// and csv as the lines and rows lines returns. The table format is left to
+// the command.
+func writeOutput(format string, v any, lines []any, header []string, rows [][]string) error {
+	w := bufio.NewWriter(os.Stdout)
+	switch format {
+	case "json":
+		if err := writeJSONOutput(w, v); err != nil {
+			return err
+		}
+	case "ndjson":
+		enc := json.NewEncoder(w)
+		for _, line := range lines {
+			if err := enc.Encode(line); err != nil {
+				return fmt.Errorf("failed to encode output: %w", err)
+			}
+		}
+	case "csv":
+		cw := csv.NewWriter(w)
+		cw.Write(header)
+		cw.WriteAll(rows)
+		if err := cw.Error(); err != nil {
+			return fmt.Errorf("failed to write output: %w", err)
+		}
+	}
+	if err := w.Flush(); err != nil {
+		return fmt.Errorf("failed to write output: %w", err)
+	}
+	return nil
+}
+
+// evalOutput is the report of `ember eval`. Its ndjson lines and CSV rows
+// are the per-label metrics.
+type evalOutput struct {
+	Model      string         `json:"model"`
+	Dimensions int            `json:"dimensions"`
+	Metric     string         `json:"metric"`
+	File       string         `json:"file"`
+	Total      int            `json:"total"`
+	Accuracy   float64        `json:"accuracy"`
+	MacroF1    float64        `json:"macro_f1"`
+	Labels     []string       `json:"labels"`
+	Matrix     [][]int        `json:"matrix"`
+	Metrics    []labelMetrics `json:"metrics"`
+	Confused   []confusedPair `json:"confused"`
+	Unknown    []string       `json:"unknown"`
+}
+
+// evalLine is one ndjson line of evalOutput
+type evalLine struct {
+	labelMetrics
+	Model      string `json:"model"`
+	Dimensions int    `json:"dimensions"`
+	Metric     string `json:"metric"`
+}
+
+func newEvalOutput(info ModelInfo, dimensions int, path string, report evalReport) evalOutput {
+	return evalOutput{
+		Model: info.Provider + "/" + info.Model, Dimensions: dimensions, Metric: "cosine", File: path,
+		Total: report.Total, Accuracy: report.Accuracy, MacroF1: report.MacroF1,
+		Labels:   append([]string{}, report.Labels...),
+		Matrix:   append([][]int{}, report.Matrix...),
+		Metrics:  append([]labelMetrics{}, report.Metrics...),
+		Confused: append([]confusedPair{}, report.Confused...),
+		Unknown:  append([]string{}, report.Unknown...),
+	}
+}
+
+func (o evalOutput) write(format string) error {
+	var lines []any
+	var rows [][]string
+	for _, m := range o.Metrics {
+		lines = append(lines, evalLine{labelMetrics: m, Model: o.Model, Dimensions: o.Dimensions, Metric: o.Metric})
+		rows = append(rows, []string{m.Label, formatScore(m.Precision), formatScore(m.Recall), formatScore(m.F1), strconv.Itoa(m.Support), o.Model, strconv.Itoa(o.Dimensions), o.Metric})
+	}
+	return writeOutput(format, o, lines, []string{"label", "precision", "recall", "f1", "support", "model", "dimensions", "metric"}, rows)
+}
+
+// tuneOutput is the threshold sweep of `ember tune`. Its ndjson lines and
+// CSV rows are the points of the curve, with the best one marked.
+type tuneOutput struct {
+	Model      string           `json:"model"`
+	Dimensions int              `json:"dimensions"`
+	Metric     string           `json:"metric"`
+	File       string           `json:"file"`
+	Pairs      int              `json:"pairs"`
+	Positives  int              `json:"positives"`
+	Best       thresholdPoint   `json:"best"`
+	Curve      []thresholdPoint `json:"curve"`
+	// SavedSet names the set the best threshold was saved into, or is empty
+	SavedSet string `json:"saved_set"`
+}
+
+// tuneLine is one ndjson line of tuneOutput
+type tuneLine struct {
+	thresholdPoint
+	Best       bool   `json:"best"`
+	Model      string `json:"model"`
+	Dimensions int    `json:"dimensions"`
+	Metric     string `json:"metric"`
+}
+
+func newTuneOutput(info ModelInfo, path string, report tuneReport) tuneOutput {
+	return tuneOutput{
+		Model: info.Provider + "/" + info.Model, Dimensions: report.Dimensions, Metric: "cosine", File: path,
+		Pairs: report.Pairs, Positives: report.Positives, Best: report.Best,
+		Curve: append([]thresholdPoint{}, report.Curve...),
+	}
+}
+
+func (o tuneOutput) write(format string) error {
+	var lines []any
+	var rows [][]string
+	for _, p := range o.Curve {
+		lines = append(lines, tuneLine{thresholdPoint: p, Best: p == o.Best, Model: o.Model, Dimensions: o.Dimensions, Metric: o.Metric})
+		rows = append(rows, []string{formatScore(p.Threshold), formatScore(p.Precision), formatScore(p.Recall), formatScore(p.F1),
+			strconv.FormatBool(p == o.Best), o.Model, strconv.Itoa(o.Dimensions), o.Metric})
+	}
+	return writeOutput(format, o, lines, []string{"threshold", "precision", "recall", "f1", "best", "model", "dimensions", "metric"}, rows)
+}
+
+// spendOutput is the spend summary of `ember audit`. Its ndjson lines and
+// CSV rows are the groups.
+type spendOutput struct {
+	// By is day or project, what Group holds in each row
+	By    string      `json:"by"`
+	Since string      `json:"since"`
+	Rows  []spendLine `json:"rows"`
+	// TotalCost leaves out models without a known price, and Unpriced says
+	// whether there were any
+	TotalCost float64 `json:"total_cost"`
+	Unpriced  bool    `json:"unpriced"`
+}
+
+// spendLine is a group's calls to one model. Cost is null without a price.
+type spendLine struct {
+	By     string   `json:"by"`
+	Group  string   `json:"group"`
+	Model  string   `json:"model"`
+	Calls  int      `json:"calls"`
+	Failed int      `json:"failed"`
+	Texts  int      `json:"texts"`
+	Tokens int      `json:"tokens"`
+	Cost   *float64 `json:"cost"`
+}
+
+func (o spendOutput) write(format string) error {
+	var lines []any
+	var rows [][]string
+	for _, r := range o.Rows {
+		lines = append(lines, r)
+		cost := ""
+		if r.Cost != nil {
+			cost = formatScore(*r.Cost)
+		}
+		rows = append(rows, []string{r.By, r.Group, r.Model, strconv.Itoa(r.Calls), strconv.Itoa(r.Failed), strconv.Itoa(r.Texts), strconv.Itoa(r.Tokens), cost})
+	}
+	return writeOutput(format, o, lines, []string{"by", "group", "model", "calls", "failed", "texts", "tokens", "cost"}, rows)
+}
+
+// cacheStatsOutput is `ember cache stats`. Its ndjson lines and CSV rows
+// are the models.
+type cacheStatsOutput struct {
+	Path      string `json:"path"`
+	SizeBytes int64  `json:"size_bytes"`
+	Entries   int    `json:"entries"`
+	// TTLSeconds and LimitBytes are 0 when there's no TTL or size limit
+	TTLSeconds int64             `json:"ttl_seconds"`
+	LimitBytes int64             `json:"limit_bytes"`
+	Models     []cacheModelStats `json:"models"`
+}
+
+func (o cacheStatsOutput) write(format string) error {
+	var lines []any
+	var rows [][]string
+	for _, s := range o.Models {
+		lines = append(lines, s)
+		rows = append(rows, []string{s.Model, strconv.Itoa(s.Entries), strconv.FormatInt(s.Bytes, 10),
+			s.Oldest.Format(time.RFC3339), s.Newest.Format(time.RFC3339), s.Used.Format(time.RFC3339)})
+	}
+	return writeOutput(format, o, lines, []string{"model", "entries", "bytes", "oldest", "newest", "used"}, rows)
+}
+
+// doctorOutput is the checks of `ember doctor`. Its ndjson lines and CSV
+// rows are the checks.
+type doctorOutput struct {
+	OK     bool          `json:"ok"`
+	Checks []doctorEntry `json:"checks"`
+}
+
+// doctorEntry is one check. Status is ok, info or fail; Hint is a likely fix
+// for a failure.
+type doctorEntry struct {
+	Name   string `json:"name"`
+	Status string `json:"status"`
+	Detail string `json:"detail"`
+	Hint   string `json:"hint"`
+}
+
+func (o doctorOutput) write(format string) error {
+	var lines []any
+	var rows [][]string
+	for _, c := range o.Checks {
+		lines = append(lines, c)
+		rows = append(rows, []string{c.Name, c.Status, c.Detail, c.Hint})
+	}
+	return writeOutput(format, o, lines, []string{"name", "status", "detail", "hint"}, rows)
+}
@@ -0,0 +1,110 @@
+package main
+
+import (
+	"fmt"
+	"os"
+
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/lipgloss"
+)
+
+// loadOverrideModel reads EMBER_OVERRIDE_MODEL, the model Alt+G switches a
+// comparison to, and whether EMBER_USE_OVERRIDE turns it on at startup. With
+// no model set, Alt+G picks one from the provider's list.
+func loadOverrideModel() (string, bool) {
+	return os.Getenv("EMBER_OVERRIDE_MODEL"), os.Getenv("EMBER_USE_OVERRIDE") != ""
+}
+
+// toggleOverrideModel turns the per-request model override on or off. The
+// first time it is turned on without EMBER_OVERRIDE_MODEL, it lists the
+// provider's models to find one.
+func (m *model) toggleOverrideModel() tea.Cmd {
+	if _, ok := m.provider.(modelSelector); !ok {
+		m.modelNotice = "⚠️  This provider doesn't support choosing a model"
+		return nil
+	}
+	m.modelNotice = ""
+	if m.useOverride || m.overrideModel != "" {
+		m.useOverride = !m.useOverride
+		return nil
+	}
+	if m.availableModels != nil {
+		m.pickOverrideModel()
+		return nil
+	}
+
+	cmd := m.fetchModels()
+	if cmd == nil {
+		m.modelNotice = "⚠️  This provider can't list its models • set EMBER_OVERRIDE_MODEL to compare with another"
+		return nil
+	}
+	m.overridePending = true
+	m.modelsLoading = true
+	m.modelNotice = "🔭 Looking for a model to compare with…"
+	return cmd
+}
+
+// pickOverrideModel turns the override on with the first listed model other
+// than the one in use
+func (m *model) pickOverrideModel() {
+	current := m.provider.ModelInfo().Model
+	for _, name := range m.availableModels {
+		if name != current {
+			m.overrideModel = name
+			m.useOverride = true
+			m.modelNotice = ""
+			return
+		}
+	}
+	m.modelNotice = "⚠️  The provider lists no other model • set EMBER_OVERRIDE_MODEL to compare with another"
+}
+
+// generateWithOverride embeds the input and the comparison texts with the
+// override model in one go, leaving the configured model and its comparison
+// vectors untouched
+func (m model) generateWithOverride(text string) tea.Cmd {
+	provider := m.provider.(modelSelector).WithModel(m.overrideModel)
+	info := provider.ModelInfo()
+
+	texts := make([]string, len(m.customEmbeddings))
+	for i, e := range m.customEmbeddings {
+		texts[i] = e.Text
+	}
+
+	query := m.withCache(lanedProvider{EmbeddingProvider: withInputType(provider, m.queryInputType()), scheduler: m.scheduler, lane: interactiveLane}, m.queryInputType())
+	documents := m.withCache(lanedProvider{EmbeddingProvider: withInputType(provider, m.comparisonInputType), scheduler: m.scheduler, lane: interactiveLane}, m.comparisonInputType)
+
+	return func() tea.Msg {
+		embedding, err := query.GenerateEmbedding(text)
+		if err != nil {
+			return embeddingCompleteMsg{text: text, err: err}
+		}
+
+		vectors, err := documents.GenerateBatch(texts)
+		if err != nil {
+			return embeddingCompleteMsg{text: text, err: fmt.Errorf("failed to embed comparison texts: %w", err)}
+		}
+
+		comparisons := make([]CustomEmbedding, len(texts))
+		for i := range texts {
+			comparisons[i] = CustomEmbedding{Text: texts[i], Embedding: vectors[i]}
+		}
+		return embeddingCompleteMsg{
+			embedding:   embedding,
+			text:        text,
+			model:       info,
+			comparisons: comparisons,
+		}
+	}
+}
+
+// renderOverrideStatus shows which model the next comparison will use
+func (m model) renderOverrideStatus() string {
+	if !m.useOverride {
+		return ""
+	}
+	return lipgloss.NewStyle().
+		Foreground(theme.Accent).
+		Bold(true).
+		Render(fmt.Sprintf("🔭 Comparing with %s • Alt+G to switch back", m.overrideModel)) + "\n"
+}
@@ -0,0 +1,218 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+
+	"github.com/parquet-go/parquet-go"
+)
+
+// Rows read or written per batch, so large files never sit in memory as a whole
+const parquetBatchSize = 256
+
+// parquetRow is one embedding. The vector is written as a LIST column, the
+// layout pyarrow and Spark use; files with a float32 vector or extra
+// columns are read too.
+type parquetRow struct {
+	Text      string    `parquet:"text"`
+	Model     string    `parquet:"model,optional"`
+	Embedding []float64 `parquet:"embedding,list"`
+}
+
+type parquetRow32 struct {
+	Text      string    `parquet:"text"`
+	Model     string    `parquet:"model,optional"`
+	Embedding []float32 `parquet:"embedding,list"`
+}
+
+// writeParquet writes embeddings with their model, snappy-compressed
+func writeParquet(w io.Writer, model string, embeddings []CustomEmbedding, single bool) error {
+	pw := newParquetWriter(w, model, single)
+	for _, e := range embeddings {
+		if err := pw.add(e); err != nil {
+			return err
+		}
+	}
+	return pw.Close()
+}
+
+// parquetWriter writes embeddings one at a time, holding only the current
+// batch, so a file of any size can be written from a stream
+type parquetWriter struct {
+	model   string
+	single  bool
+	rows    *parquet.GenericWriter[parquetRow]
+	rows32  *parquet.GenericWriter[parquetRow32]
+	batch   []parquetRow
+	batch32 []parquetRow32
+}
+
+// newParquetWriter starts a file of embeddings from model, with float32
+// vectors when single is set
+func newParquetWriter(w io.Writer, model string, single bool) *parquetWriter {
+	p := &parquetWriter{model: model, single: single}
+	if single {
+		p.rows32 = parquet.NewGenericWriter[parquetRow32](w, parquet.Compression(&parquet.Snappy))
+		p.batch32 = make([]parquetRow32, 0, parquetBatchSize)
+	} else {
+		p.rows = parquet.NewGenericWriter[parquetRow](w, parquet.Compression(&parquet.Snappy))
+		p.batch = make([]parquetRow, 0, parquetBatchSize)
+	}
+	return p
+}
+
+func (p *parquetWriter) add(e CustomEmbedding) error {
+	if p.single {
+		vector := make([]float32, len(e.Embedding))
+		for i, v := range e.Embedding {
+			vector[i] = float32(v)
+		}
+		p.batch32 = append(p.batch32, parquetRow32{Text: e.Text, Model: p.model, Embedding: vector})
+	} else {
+		p.batch = append(p.batch, parquetRow{Text: e.Text, Model: p.model, Embedding: e.Embedding})
+	}
+	if len(p.batch)+len(p.batch32) == parquetBatchSize {
+		return p.flush()
+	}
+	return nil
+}
+
+func (p *parquetWriter) flush() error {
+	var err error
+	if p.single {
+		_, err = p.rows32.Write(p.batch32)
+		p.batch32 = p.batch32[:0]
+	} else {
+		_, err = p.rows.Write(p.batch)
+		p.batch = p.batch[:0]
+	}
+	if err != nil {
+		return fmt.Errorf("failed to write rows: %w", err)
+	}
+	return nil
+}
+
+// Close writes the last batch and the file's footer
+func (p *parquetWriter) Close() error {
+	if err := p.flush(); err != nil {
+		return err
+	}
+	var err error
+	if p.single {
+		err = p.rows32.Close()
+	} else {
+		err = p.rows.Close()
+	}
+	if err != nil {
+		return fmt.Errorf("failed to finish parquet file: %w", err)
+	}
+	return nil
+}
+
+// readParquetFile reads every embedding in path
+func readParquetFile(path string) (ModelInfo, []CustomEmbedding, error) {
+	var embeddings []CustomEmbedding
+	info, err := scanParquetFile(path, func(e CustomEmbedding) error {
+		embeddings = append(embeddings, e)
+		return nil
+	})
+	if err != nil {
+		return info, nil, err
+	}
+	return info, embeddings, nil
+}
+
+// scanParquetFile reads the text and embedding columns of path a batch at a
+// time, handing each row to fn, and checks dimensions and model as
+// readEmbeddingsJSONL does. Only a batch is held at once. fn returns io.EOF
+// to stop early, as after the first row when only the model is wanted.
+func scanParquetFile(path string, fn func(CustomEmbedding) error) (ModelInfo, error) {
+	var info ModelInfo
+
+	f, err := os.Open(path)
+	if err != nil {
+		return info, fmt.Errorf("failed to open %s: %w", path, err)
+	}
+	defer f.Close()
+	stat, err := f.Stat()
+	if err != nil {
+		return info, fmt.Errorf("failed to open %s: %w", path, err)
+	}
+
+	pf, err := parquet.OpenFile(f, stat.Size())
+	if err != nil {
+		return info, fmt.Errorf("failed to import %s: %w", path, err)
+	}
+	for _, column := range []string{"text", "embedding"} {
+		if !hasParquetColumn(pf.Schema(), column) {
+			return info, fmt.Errorf("failed to import %s: no %q column", path, column)
+		}
+	}
+
+	reader := parquet.NewGenericReader[parquetRow](pf)
+	defer reader.Close()
+
+	model := ""
+	batch := make([]parquetRow, parquetBatchSize)
+	line := 1
+	for done := false; !done; {
+		n, err := reader.Read(batch)
+		for _, row := range batch[:n] {
+			switch {
+			case strings.TrimSpace(row.Text) == "":
+				return info, fmt.Errorf("failed to import %s: row %d has no text", path, line)
+			case len(row.Embedding) == 0:
+				return info, fmt.Errorf("failed to import %s: row %d has no embedding", path, line)
+			case line == 1:
+				model = row.Model
+				info.Dimensions = len(row.Embedding)
+				info.Provider, info.Model, _ = strings.Cut(model, "/")
+			case len(row.Embedding) != info.Dimensions:
+				return info, fmt.Errorf("failed to import %s: row %d has %d dimensions, expected %d", path, line, len(row.Embedding), info.Dimensions)
+			case row.Model != model:
+				return info, fmt.Errorf("failed to import %s: row %d was embedded with %q, not %q", path, line, row.Model, model)
+			}
+			line++
+			if err := fn(CustomEmbedding{Text: row.Text, Embedding: row.Embedding}); errors.Is(err, io.EOF) {
+				return info, nil
+			} else if err != nil {
+				return info, err
+			}
+		}
+		if errors.Is(err, io.EOF) {
+			done = true
+		} else if err != nil {
+			return info, fmt.Errorf("failed to import %s: %w", path, err)
+		}
+	}
+
+	if line == 1 {
+		return info, fmt.Errorf("failed to import %s: no rows", path)
+	}
+	return info, nil
+}
+
+func hasParquetColumn(schema *parquet.Schema, name string) bool {
+	for _, field := range schema.Fields() {
+		if field.Name() == name {
+			return true
+		}
+	}
+	return false
+}
+
+func writeParquetFile(path string, set comparisonSet, single bool) error {
+	f, err := os.Create(path)
+	if err != nil {
+		return fmt.Errorf("failed to create %s: %w", path, err)
+	}
+	defer f.Close()
+
+	if err := writeParquet(f, set.Model.Provider+"/"+set.Model.Model, set.Embeddings, single); err != nil {
+		return err
+	}
+	return f.Close()
+}
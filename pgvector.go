@@ -0,0 +1,442 @@
+package main
+
+import (
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"os"
+	"strconv"
+	"strings"
+
+	_ "github.com/jackc/pgx/v5/stdlib"
+)
+
+// Tables for sets and history. Vectors use pgvector's unconstrained vector
+// type, so sets from models of any size can share a table.
+var pgSchema = []string{
+	`CREATE EXTENSION IF NOT EXISTS vector`,
+	`CREATE TABLE IF NOT EXISTS ember_sets (
+		name       TEXT PRIMARY KEY,
+		provider   TEXT NOT NULL,
+		model      TEXT NOT NULL,
+		dimensions INTEGER NOT NULL,
+		saved      TIMESTAMPTZ NOT NULL
+	)`,
+	`ALTER TABLE ember_sets ADD COLUMN IF NOT EXISTS threshold DOUBLE PRECISION NOT NULL DEFAULT 0`,
+	`ALTER TABLE ember_sets ADD COLUMN IF NOT EXISTS template_values JSONB`,
+	`ALTER TABLE ember_sets ADD COLUMN IF NOT EXISTS disabled_texts JSONB`,
+	`CREATE TABLE IF NOT EXISTS ember_set_texts (
+		set_name  TEXT NOT NULL REFERENCES ember_sets (name) ON DELETE CASCADE,
+		position  INTEGER NOT NULL,
+		text      TEXT NOT NULL,
+		embedding vector NOT NULL,
+		extra     JSONB,
+		PRIMARY KEY (set_name, position)
+	)`,
+	`CREATE TABLE IF NOT EXISTS ember_history (
+		id         BIGSERIAL PRIMARY KEY,
+		session    TEXT NOT NULL,
+		time       TIMESTAMPTZ NOT NULL,
+		input      TEXT NOT NULL,
+		provider   TEXT NOT NULL,
+		model      TEXT NOT NULL,
+		dimensions INTEGER NOT NULL,
+		embedding  vector,
+		results    JSONB NOT NULL
+	)`,
+	`CREATE INDEX IF NOT EXISTS ember_history_time ON ember_history (time)`,
+	`ALTER TABLE ember_history ADD COLUMN IF NOT EXISTS session_title TEXT NOT NULL DEFAULT ''`,
+	`ALTER TABLE ember_history ADD COLUMN IF NOT EXISTS note TEXT NOT NULL DEFAULT ''`,
+	// Rows belong to an owner: '' for the local user, the key fingerprint of
+	// an ember serve user. Set names are unique per owner.
+	`ALTER TABLE ember_sets ADD COLUMN IF NOT EXISTS owner TEXT NOT NULL DEFAULT ''`,
+	`ALTER TABLE ember_set_texts ADD COLUMN IF NOT EXISTS owner TEXT NOT NULL DEFAULT ''`,
+	`ALTER TABLE ember_history ADD COLUMN IF NOT EXISTS owner TEXT NOT NULL DEFAULT ''`,
+	`DO $$ BEGIN
+		IF NOT EXISTS (SELECT 1 FROM information_schema.key_column_usage
+			WHERE table_name = 'ember_sets' AND constraint_name = 'ember_sets_pkey' AND column_name = 'owner') THEN
+			ALTER TABLE ember_set_texts DROP CONSTRAINT IF EXISTS ember_set_texts_set_name_fkey;
+			ALTER TABLE ember_set_texts DROP CONSTRAINT IF EXISTS ember_set_texts_pkey;
+			ALTER TABLE ember_sets DROP CONSTRAINT ember_sets_pkey;
+			ALTER TABLE ember_sets ADD PRIMARY KEY (owner, name);
+			ALTER TABLE ember_set_texts ADD PRIMARY KEY (owner, set_name, position);
+			ALTER TABLE ember_set_texts ADD CONSTRAINT ember_set_texts_set_fkey
+				FOREIGN KEY (owner, set_name) REFERENCES ember_sets (owner, name) ON DELETE CASCADE;
+		END IF;
+	END $$`,
+	`CREATE INDEX IF NOT EXISTS ember_history_owner_time ON ember_history (owner, time)`,
+}
+
+// pgStore keeps comparison sets and history in Postgres with pgvector, so
+// every machine pointed at the same database shares them. Each store only
+// sees its owner's rows.
+type pgStore struct {
+	db    *sql.DB
+	owner string
+}
+
+// storedExtra holds the vectors besides the main embedding, which only ember reads
+type storedExtra struct {
+	Chunks   [][]float64          `json:"chunks,omitempty"`
+	Sparse   *SparseVector        `json:"sparse,omitempty"`
+	Fields   map[string][]float64 `json:"fields,omitempty"`
+	Summary  string               `json:"summary,omitempty"`
+	Template string               `json:"template,omitempty"`
+}
+
+// storedMatch is one text of a stored set, scored in SQL
+type storedMatch struct {
+	Position   int
+	Text       string
+	Similarity float64
+}
+
+// openPgStore connects to EMBER_PGVECTOR_URL; it returns nil when that is unset
+func openPgStore() (*pgStore, error) {
+	url := os.Getenv("EMBER_PGVECTOR_URL")
+	if url == "" {
+		return nil, nil
+	}
+
+	db, err := sql.Open("pgx", url)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open Postgres: %w", err)
+	}
+	for _, statement := range pgSchema {
+		if _, err := db.Exec(statement); err != nil {
+			db.Close()
+			return nil, fmt.Errorf("failed to set up Postgres tables: %w", err)
+		}
+	}
+	return &pgStore{db: db}, nil
+}
+
+func (s *pgStore) Close() error {
+	return s.db.Close()
+}
+
+// forOwner returns a store on the same connection that only reads and
+// writes owner's sets and history. It must not be closed.
+func (s *pgStore) forOwner(owner string) *pgStore {
+	return &pgStore{db: s.db, owner: owner}
+}
+
+// pgVector formats v as a pgvector literal. pgvector keeps float32s, so
+// vectors read back lose the digits float32 can't hold.
+func pgVector(v []float64) string {
+	return "[" + strings.ReplaceAll(formatVector(v, true), " ", "") + "]"
+}
+
+func parsePgVector(literal string) ([]float64, error) {
+	literal = strings.Trim(literal, "[]")
+	if literal == "" {
+		return nil, nil
+	}
+	parts := strings.Split(literal, ",")
+	v := make([]float64, len(parts))
+	for i, part := range parts {
+		f, err := strconv.ParseFloat(strings.TrimSpace(part), 64)
+		if err != nil {
+			return nil, fmt.Errorf("invalid vector value %q", part)
+		}
+		v[i] = f
+	}
+	return v, nil
+}
+
+// SaveSet replaces the set of the same name
+func (s *pgStore) SaveSet(set comparisonSet) error {
+	tx, err := s.db.Begin()
+	if err != nil {
+		return fmt.Errorf("failed to save set: %w", err)
+	}
+	defer tx.Rollback()
+
+	if _, err := tx.Exec(`DELETE FROM ember_sets WHERE owner = $1 AND name = $2`, s.owner, set.Name); err != nil {
+		return fmt.Errorf("failed to save set: %w", err)
+	}
+	var values any
+	if len(set.Values) > 0 {
+		data, err := json.Marshal(set.Values)
+		if err != nil {
+			return fmt.Errorf("failed to marshal set: %w", err)
+		}
+		values = string(data)
+	}
+	var disabled any
+	if len(set.Disabled) > 0 {
+		data, err := json.Marshal(set.Disabled)
+		if err != nil {
+			return fmt.Errorf("failed to marshal set: %w", err)
+		}
+		disabled = string(data)
+	}
+	_, err = tx.Exec(`INSERT INTO ember_sets (owner, name, provider, model, dimensions, saved, threshold, template_values, disabled_texts) VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9)`,
+		s.owner, set.Name, set.Model.Provider, set.Model.Model, set.Model.Dimensions, set.Saved, set.Threshold, values, disabled)
+	if err != nil {
+		return fmt.Errorf("failed to save set: %w", err)
+	}
+
+	for i, e := range set.Embeddings {
+		extra, err := json.Marshal(storedExtra{Chunks: e.Chunks, Sparse: e.Sparse, Fields: e.Fields, Summary: e.Summary, Template: e.Template})
+		if err != nil {
+			return fmt.Errorf("failed to marshal set: %w", err)
+		}
+		_, err = tx.Exec(`INSERT INTO ember_set_texts (owner, set_name, position, text, embedding, extra) VALUES ($1, $2, $3, $4, $5::vector, $6)`,
+			s.owner, set.Name, i, e.Text, pgVector(e.Embedding), string(extra))
+		if err != nil {
+			return fmt.Errorf("failed to save set: %w", err)
+		}
+	}
+
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("failed to save set: %w", err)
+	}
+	return nil
+}
+
+// ListSets returns every stored set with its vectors, most recently saved first
+func (s *pgStore) ListSets() ([]comparisonSet, error) {
+	rows, err := s.db.Query(`SELECT name FROM ember_sets WHERE owner = $1 ORDER BY saved DESC`, s.owner)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list sets: %w", err)
+	}
+	var names []string
+	for rows.Next() {
+		var name string
+		if err := rows.Scan(&name); err != nil {
+			rows.Close()
+			return nil, fmt.Errorf("failed to list sets: %w", err)
+		}
+		names = append(names, name)
+	}
+	rows.Close()
+
+	sets := make([]comparisonSet, 0, len(names))
+	for _, name := range names {
+		set, err := s.LoadSet(name)
+		if err != nil {
+			return nil, err
+		}
+		sets = append(sets, set)
+	}
+	return sets, nil
+}
+
+// LoadSet reads the named set
+func (s *pgStore) LoadSet(name string) (comparisonSet, error) {
+	set := comparisonSet{Name: name}
+	var valuesJSON, disabledJSON []byte
+	err := s.db.QueryRow(`SELECT provider, model, dimensions, saved, threshold, template_values, disabled_texts FROM ember_sets WHERE owner = $1 AND name = $2`, s.owner, name).
+		Scan(&set.Model.Provider, &set.Model.Model, &set.Model.Dimensions, &set.Saved, &set.Threshold, &valuesJSON, &disabledJSON)
+	if err != nil {
+		return set, fmt.Errorf("failed to read set %s: %w", name, err)
+	}
+	if len(valuesJSON) > 0 {
+		json.Unmarshal(valuesJSON, &set.Values)
+	}
+	if len(disabledJSON) > 0 {
+		json.Unmarshal(disabledJSON, &set.Disabled)
+	}
+
+	rows, err := s.db.Query(`SELECT text, embedding::text, extra FROM ember_set_texts WHERE owner = $1 AND set_name = $2 ORDER BY position`, s.owner, name)
+	if err != nil {
+		return set, fmt.Errorf("failed to read set %s: %w", name, err)
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var text, vector string
+		var extraJSON []byte
+		if err := rows.Scan(&text, &vector, &extraJSON); err != nil {
+			return set, fmt.Errorf("failed to read set %s: %w", name, err)
+		}
+		embedding, err := parsePgVector(vector)
+		if err != nil {
+			return set, fmt.Errorf("failed to read set %s: %w", name, err)
+		}
+		var extra storedExtra
+		if len(extraJSON) > 0 {
+			json.Unmarshal(extraJSON, &extra)
+		}
+		set.Embeddings = append(set.Embeddings, CustomEmbedding{
+			Text:      text,
+			Embedding: embedding,
+			Chunks:    extra.Chunks,
+			Sparse:    extra.Sparse,
+			Fields:    extra.Fields,
+			Summary:   extra.Summary,
+			Template:  extra.Template,
+		})
+	}
+	return set, rows.Err()
+}
+
+func (s *pgStore) DeleteSet(name string) error {
+	if _, err := s.db.Exec(`DELETE FROM ember_sets WHERE owner = $1 AND name = $2`, s.owner, name); err != nil {
+		return fmt.Errorf("failed to delete set: %w", err)
+	}
+	return nil
+}
+
+// Nearest returns the k texts of the named set most similar to vector, best
+// first. The ordering and the cosine similarity are computed by pgvector, so
+// the set's vectors never leave the database.
+func (s *pgStore) Nearest(set string, vector []float64, k int) ([]storedMatch, error) {
+	rows, err := s.db.Query(`
+		SELECT position, text, 1 - (embedding <=> $1::vector)
+		FROM ember_set_texts
+		WHERE owner = $2 AND set_name = $3 AND vector_dims(embedding) = $4
+		ORDER BY embedding <=> $1::vector
+		LIMIT $5`, pgVector(vector), s.owner, set, len(vector), k)
+	if err != nil {
+		return nil, fmt.Errorf("failed to search set %s: %w", set, err)
+	}
+	defer rows.Close()
+
+	var matches []storedMatch
+	for rows.Next() {
+		var match storedMatch
+		if err := rows.Scan(&match.Position, &match.Text, &match.Similarity); err != nil {
+			return nil, fmt.Errorf("failed to search set %s: %w", set, err)
+		}
+		matches = append(matches, match)
+	}
+	return matches, rows.Err()
+}
+
+// AppendHistory records one comparison
+func (s *pgStore) AppendHistory(entry historyEntry) error {
+	results, err := json.Marshal(entry.Results)
+	if err != nil {
+		return fmt.Errorf("failed to marshal history entry: %w", err)
+	}
+	var vector any
+	if len(entry.Embedding) > 0 {
+		vector = pgVector(entry.Embedding)
+	}
+	_, err = s.db.Exec(`INSERT INTO ember_history (owner, session, session_title, time, input, provider, model, dimensions, embedding, results, note) VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9::vector, $10, $11)`,
+		s.owner, entry.Session, entry.SessionTitle, entry.Time, entry.Input, entry.Model.Provider, entry.Model.Model, entry.Model.Dimensions, vector, string(results), entry.Note)
+	if err != nil {
+		return fmt.Errorf("failed to write history: %w", err)
+	}
+	return nil
+}
+
+// LoadHistory reads the history, newest entry first, as loadHistory does
+func (s *pgStore) LoadHistory() ([]historyEntry, error) {
+	rows, err := s.db.Query(`SELECT session, session_title, time, input, provider, model, dimensions, COALESCE(embedding::text, ''), results, note FROM ember_history WHERE owner = $1 ORDER BY time DESC, id DESC`, s.owner)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read history: %w", err)
+	}
+	defer rows.Close()
+
+	var entries []historyEntry
+	for rows.Next() {
+		var entry historyEntry
+		var vector string
+		var results []byte
+		if err := rows.Scan(&entry.Session, &entry.SessionTitle, &entry.Time, &entry.Input, &entry.Model.Provider, &entry.Model.Model, &entry.Model.Dimensions, &vector, &results, &entry.Note); err != nil {
+			return nil, fmt.Errorf("failed to read history: %w", err)
+		}
+		if entry.Embedding, err = parsePgVector(vector); err != nil {
+			return nil, fmt.Errorf("failed to read history: %w", err)
+		}
+		json.Unmarshal(results, &entry.Results)
+		entries = append(entries, entry)
+	}
+	return entries, rows.Err()
+}
+
+// DeleteHistory removes every comparison of input made with model
+// AnnotateHistory replaces the notes of the stored entry of the same session and time
+func (s *pgStore) AnnotateHistory(entry historyEntry) error {
+	results, err := json.Marshal(entry.Results)
+	if err != nil {
+		return fmt.Errorf("failed to marshal history entry: %w", err)
+	}
+	res, err := s.db.Exec(`UPDATE ember_history SET note = $3, results = $4 WHERE session = $1 AND time = $2 AND owner = $5`, entry.Session, entry.Time, entry.Note, string(results), s.owner)
+	if err != nil {
+		return fmt.Errorf("failed to save note: %w", err)
+	}
+	if n, err := res.RowsAffected(); err == nil && n == 0 {
+		return fmt.Errorf("the comparison is no longer in the history")
+	}
+	return nil
+}
+
+// SetSessionTitle records title on every entry of session
+func (s *pgStore) SetSessionTitle(session, title string) error {
+	if _, err := s.db.Exec(`UPDATE ember_history SET session_title = $2 WHERE session = $1 AND owner = $3`, session, title, s.owner); err != nil {
+		return fmt.Errorf("failed to title session: %w", err)
+	}
+	return nil
+}
+
+func (s *pgStore) DeleteHistory(input string, model ModelInfo) error {
+	_, err := s.db.Exec(`DELETE FROM ember_history WHERE input = $1 AND provider = $2 AND model = $3 AND owner = $4`, input, model.Provider, model.Model, s.owner)
+	if err != nil {
+		return fmt.Errorf("failed to delete history: %w", err)
+	}
+	return nil
+}
+
+// storedQuery scores an input against a set in Postgres
+type storedQuery struct {
+	store *pgStore
+	set   string
+	k     int
+}
+
+func (q storedQuery) nearest(embedding []float64) ([]storedMatch, error) {
+	return q.store.Nearest(q.set, embedding, q.k)
+}
+
+// trackStoredSet records set as the copy in Postgres of the comparison set,
+// when sets are kept there and its vectors are from the current model
+func (m *model) trackStoredSet(set comparisonSet) {
+	m.storedSet = nil
+	info := m.provider.ModelInfo()
+	if _, ok := pgBacked(m.store); !ok || set.Bundle != "" || set.Model.Provider != info.Provider || set.Model.Model != info.Model {
+		return
+	}
+	m.storedSet = &set
+}
+
+// pushdownSet returns a query scoring the comparison set in Postgres. That
+// only works for plain cosine scoring of a set unchanged since it was stored;
+// chunks, sparse vectors and fields are scored in memory.
+func (m model) pushdownSet() (storedQuery, bool) {
+	pg, ok := pgBacked(m.store)
+	switch {
+	case !ok || m.storedSet == nil || m.useOverride:
+		return storedQuery{}, false
+	case m.chunking.aggregation != aggregateSingle || m.sparse.mode != sparseOff || len(m.fieldWeights) > 0:
+		return storedQuery{}, false
+	case len(m.storedSet.Embeddings) != len(m.customEmbeddings):
+		return storedQuery{}, false
+	}
+	for i, e := range m.customEmbeddings {
+		if e.Text != m.storedSet.Embeddings[i].Text {
+			return storedQuery{}, false
+		}
+	}
+	return storedQuery{store: pg, set: m.storedSet.Name, k: len(m.customEmbeddings)}, true
+}
+
+// storedResults puts the matches Postgres returned back in comparison set
+// order. It fails when they don't line up with compared, e.g. because the set
+// was changed on another machine since it was loaded.
+func storedResults(matches []storedMatch, compared []CustomEmbedding) ([]SimilarityResult, bool) {
+	if matches == nil || len(matches) != len(compared) {
+		return nil, false
+	}
+	results := make([]SimilarityResult, len(compared))
+	for _, match := range matches {
+		if match.Position < 0 || match.Position >= len(compared) || compared[match.Position].Text != match.Text {
+			return nil, false
+		}
+		results[match.Position] = SimilarityResult{Text: match.Text, Similarity: match.Similarity}
+	}
+	return results, true
+}
@@ -0,0 +1,532 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"os"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/lipgloss"
+)
+
+// Data plane API version sent with every request
+const pineconeAPIVersion = "2024-07"
+
+// Metadata field holding a vector's text unless EMBER_PINECONE_TEXT_FIELD says otherwise
+const defaultPineconeTextField = "text"
+
+// Vectors listed per page on the Pinecone screen
+const pineconePageSize = 12
+
+// pineconeIndex queries one namespace of a Pinecone index through its data plane API
+type pineconeIndex struct {
+	host      string
+	apiKey    string
+	namespace string
+	// filter is a metadata filter applied to every query, as Pinecone's JSON
+	filter    map[string]any
+	textField string
+	limit     int
+	client    *http.Client
+}
+
+// pineconeVector is a vector with its metadata, as queries and fetches return them
+type pineconeVector struct {
+	ID       string         `json:"id"`
+	Score    float64        `json:"score"`
+	Values   []float64      `json:"values"`
+	Metadata map[string]any `json:"metadata"`
+}
+
+// pineconeStats is what describe_index_stats reports
+type pineconeStats struct {
+	Dimension  int `json:"dimension"`
+	TotalCount int `json:"totalVectorCount"`
+	Namespaces map[string]struct {
+		VectorCount int `json:"vectorCount"`
+	} `json:"namespaces"`
+}
+
+// pineconePage is one page of vectors on the Pinecone screen
+type pineconePage struct {
+	vectors []pineconeVector
+	next    string
+}
+
+type pineconeStatsMsg struct {
+	stats pineconeStats
+	err   error
+}
+
+type pineconePageMsg struct {
+	page  pineconePage
+	token string
+	err   error
+}
+
+// loadPineconeIndex reads EMBER_PINECONE_HOST, the index's host from the
+// Pinecone console, with PINECONE_API_KEY, EMBER_PINECONE_NAMESPACE,
+// EMBER_PINECONE_FILTER, EMBER_PINECONE_TEXT_FIELD and EMBER_PINECONE_LIMIT.
+// It returns nil when no host is set.
+func loadPineconeIndex() (*pineconeIndex, error) {
+	host := os.Getenv("EMBER_PINECONE_HOST")
+	if host == "" {
+		return nil, nil
+	}
+	if !strings.Contains(host, "://") {
+		host = "https://" + host
+	}
+	if _, err := url.Parse(host); err != nil {
+		return nil, fmt.Errorf("invalid EMBER_PINECONE_HOST %q: %w", host, err)
+	}
+	apiKey := os.Getenv("PINECONE_API_KEY")
+	if apiKey == "" {
+		return nil, fmt.Errorf("EMBER_PINECONE_HOST is set but PINECONE_API_KEY isn't")
+	}
+
+	p := &pineconeIndex{
+		host:      strings.TrimRight(host, "/"),
+		apiKey:    apiKey,
+		namespace: os.Getenv("EMBER_PINECONE_NAMESPACE"),
+		textField: os.Getenv("EMBER_PINECONE_TEXT_FIELD"),
+		limit:     defaultRemoteLimit,
+		client:    &http.Client{Timeout: 60 * time.Second},
+	}
+	if p.textField == "" {
+		p.textField = defaultPineconeTextField
+	}
+	if filter := os.Getenv("EMBER_PINECONE_FILTER"); filter != "" {
+		if err := json.Unmarshal([]byte(filter), &p.filter); err != nil {
+			return nil, fmt.Errorf("invalid EMBER_PINECONE_FILTER: %w", err)
+		}
+	}
+	if value := os.Getenv("EMBER_PINECONE_LIMIT"); value != "" {
+		limit, err := strconv.Atoi(value)
+		if err != nil || limit < 1 {
+			return nil, fmt.Errorf("invalid EMBER_PINECONE_LIMIT %q", value)
+		}
+		p.limit = limit
+	}
+	return p, nil
+}
+
+// do sends body as JSON, or a GET without one, and decodes the response into result
+func (p *pineconeIndex) do(method, path string, body, result any) error {
+	var reader io.Reader
+	if body != nil {
+		data, err := json.Marshal(body)
+		if err != nil {
+			return fmt.Errorf("failed to marshal request: %w", err)
+		}
+		reader = bytes.NewReader(data)
+	}
+
+	req, err := http.NewRequest(method, p.host+path, reader)
+	if err != nil {
+		return fmt.Errorf("failed to create request: %w", err)
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("Api-Key", p.apiKey)
+	req.Header.Set("X-Pinecone-API-Version", pineconeAPIVersion)
+
+	resp, err := p.client.Do(req)
+	if err != nil {
+		return fmt.Errorf("failed to reach Pinecone: %w", err)
+	}
+	defer resp.Body.Close()
+
+	data, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return fmt.Errorf("failed to read response: %w", err)
+	}
+	if resp.StatusCode == http.StatusTooManyRequests {
+		return &RateLimitError{RetryAfter: retryAfter(resp)}
+	}
+	if resp.StatusCode != http.StatusOK {
+		return &APIStatusError{StatusCode: resp.StatusCode, Body: string(data)}
+	}
+	if err := json.Unmarshal(data, result); err != nil {
+		return fmt.Errorf("failed to unmarshal response: %w", err)
+	}
+	return nil
+}
+
+// label names the index and namespace on screen
+func (p *pineconeIndex) label() string {
+	name := strings.SplitN(strings.TrimPrefix(strings.TrimPrefix(p.host, "https://"), "http://"), ".", 2)[0]
+	if p.namespace != "" {
+		name += "/" + p.namespace
+	}
+	return "Pinecone index " + name
+}
+
+// text is the vector's text from its metadata, falling back to its ID
+func (p *pineconeIndex) text(v pineconeVector) string {
+	if text, ok := v.Metadata[p.textField].(string); ok && text != "" {
+		return text
+	}
+	return v.ID
+}
+
+// query returns the vectors nearest to vector that match the filter, best first
+func (p *pineconeIndex) query(vector []float64, limit int) ([]pineconeVector, error) {
+	body := map[string]any{
+		"vector":          vector,
+		"topK":            limit,
+		"namespace":       p.namespace,
+		"includeValues":   true,
+		"includeMetadata": true,
+	}
+	if p.filter != nil {
+		body["filter"] = p.filter
+	}
+	var resp struct {
+		Matches []pineconeVector `json:"matches"`
+	}
+	if err := p.do("POST", "/query", body, &resp); err != nil {
+		return nil, fmt.Errorf("failed to query %s: %w", p.label(), err)
+	}
+	return resp.Matches, nil
+}
+
+// nearest returns the index's texts nearest to vector, for remoteIndex.
+// Pinecone doesn't record the model, so the namespace is assumed to hold
+// vectors from the model in use.
+func (p *pineconeIndex) nearest(model ModelInfo, vector []float64) ([]CustomEmbedding, error) {
+	matches, err := p.query(vector, p.limit)
+	if err != nil {
+		return nil, err
+	}
+	embeddings := make([]CustomEmbedding, len(matches))
+	for i, match := range matches {
+		embeddings[i] = CustomEmbedding{Text: p.text(match), Embedding: match.Values}
+	}
+	return embeddings, nil
+}
+
+func (p *pineconeIndex) stats() (pineconeStats, error) {
+	var stats pineconeStats
+	body := map[string]any{}
+	if p.filter != nil {
+		body["filter"] = p.filter
+	}
+	if err := p.do("POST", "/describe_index_stats", body, &stats); err != nil {
+		return stats, fmt.Errorf("failed to read %s: %w", p.label(), err)
+	}
+	return stats, nil
+}
+
+// page lists the namespace's vectors from token on, with their values and
+// metadata. Listing only works on serverless indexes, and ignores the filter.
+func (p *pineconeIndex) page(token string) (pineconePage, error) {
+	params := url.Values{"namespace": {p.namespace}, "limit": {strconv.Itoa(pineconePageSize)}}
+	if token != "" {
+		params.Set("paginationToken", token)
+	}
+	var list struct {
+		Vectors []struct {
+			ID string `json:"id"`
+		} `json:"vectors"`
+		Pagination struct {
+			Next string `json:"next"`
+		} `json:"pagination"`
+	}
+	if err := p.do("GET", "/vectors/list?"+params.Encode(), nil, &list); err != nil {
+		return pineconePage{}, fmt.Errorf("failed to list %s: %w", p.label(), err)
+	}
+	if len(list.Vectors) == 0 {
+		return pineconePage{}, nil
+	}
+
+	fetch := url.Values{"namespace": {p.namespace}}
+	for _, v := range list.Vectors {
+		fetch.Add("ids", v.ID)
+	}
+	var fetched struct {
+		Vectors map[string]pineconeVector `json:"vectors"`
+	}
+	if err := p.do("GET", "/vectors/fetch?"+fetch.Encode(), nil, &fetched); err != nil {
+		return pineconePage{}, fmt.Errorf("failed to fetch from %s: %w", p.label(), err)
+	}
+
+	page := pineconePage{next: list.Pagination.Next}
+	for _, v := range list.Vectors {
+		if vector, ok := fetched.Vectors[v.ID]; ok {
+			vector.ID = v.ID
+			page.vectors = append(page.vectors, vector)
+		}
+	}
+	return page, nil
+}
+
+// openPineconeScreen shows the index's stats and its first page of vectors
+func (m *model) openPineconeScreen() tea.Cmd {
+	if m.pinecone == nil {
+		m.modelNotice = "Set EMBER_PINECONE_HOST and PINECONE_API_KEY to browse a Pinecone index"
+		return nil
+	}
+	m.currentScreen = pineconeScreen
+	m.pineconeStats, m.pineconeErr = nil, nil
+	m.pineconeTokens = nil
+	m.selectedVector = 0
+	return tea.Batch(m.loadPineconeStats(), m.loadPineconePage(""))
+}
+
+func (m model) loadPineconeStats() tea.Cmd {
+	p := m.pinecone
+	return func() tea.Msg {
+		stats, err := p.stats()
+		return pineconeStatsMsg{stats: stats, err: err}
+	}
+}
+
+func (m *model) loadPineconePage(token string) tea.Cmd {
+	p := m.pinecone
+	m.pineconeLoading = true
+	return func() tea.Msg {
+		page, err := p.page(token)
+		return pineconePageMsg{page: page, token: token, err: err}
+	}
+}
+
+// nextPineconePage moves to the next page of vectors, or back with previous
+func (m *model) nextPineconePage(previous bool) tea.Cmd {
+	if m.pineconeLoading {
+		return nil
+	}
+	if previous {
+		if len(m.pineconeTokens) < 2 {
+			return nil
+		}
+		m.pineconeTokens = m.pineconeTokens[:len(m.pineconeTokens)-1]
+		token := m.pineconeTokens[len(m.pineconeTokens)-1]
+		m.pineconeTokens = m.pineconeTokens[:len(m.pineconeTokens)-1]
+		return m.loadPineconePage(token)
+	}
+	if m.pineconePage.next == "" {
+		return nil
+	}
+	return m.loadPineconePage(m.pineconePage.next)
+}
+
+// applyPineconePage shows a page that finished loading; pineconeTokens
+// records the token of each page shown, so P can go back
+func (m *model) applyPineconePage(msg pineconePageMsg) {
+	m.pineconeLoading = false
+	if msg.err != nil {
+		m.pineconeErr = msg.err
+		return
+	}
+	m.pineconePage = msg.page
+	m.pineconeTokens = append(m.pineconeTokens, msg.token)
+	m.selectedVector = 0
+}
+
+// compareWithPineconeVector shows the selected vector's nearest neighbors in
+// the index on the results screen, leaving the vector itself out
+func (m model) compareWithPineconeVector() (model, tea.Cmd) {
+	if m.selectedVector >= len(m.pineconePage.vectors) {
+		return m, nil
+	}
+	selected := m.pineconePage.vectors[m.selectedVector]
+	p := m.pinecone
+
+	m.loadingMessage = "Querying Pinecone for neighbors..."
+	m.currentScreen = loadingScreen
+	m.comparisonSeq++
+	return m, tea.Batch(m.spinner.Tick, func() tea.Msg {
+		matches, err := p.query(selected.Values, p.limit+1)
+		if err != nil {
+			return embeddingCompleteMsg{text: p.text(selected), err: err, index: p.label()}
+		}
+		var neighbors []CustomEmbedding
+		for _, match := range matches {
+			if match.ID != selected.ID && len(neighbors) < p.limit {
+				neighbors = append(neighbors, CustomEmbedding{Text: p.text(match), Embedding: match.Values})
+			}
+		}
+		return embeddingCompleteMsg{
+			embedding:   selected.Values,
+			text:        p.text(selected),
+			model:       ModelInfo{Provider: "pinecone", Model: strings.TrimPrefix(p.label(), "Pinecone index "), Dimensions: len(selected.Values)},
+			comparisons: neighbors,
+			index:       p.label(),
+		}
+	})
+}
+
+func (m model) renderPineconeScreen() string {
+	s := "\033[2J\033[H" // Clear screen and move cursor to top
+
+	s += "╭─────────────────────────────────────────────────────────────────────────────╮\n"
+	s += "│                              🌲 PINECONE 🌲                                 │\n"
+	s += "╰─────────────────────────────────────────────────────────────────────────────╯\n\n"
+
+	labelStyle := lipgloss.NewStyle().
+		Foreground(theme.Primary).
+		Bold(true)
+	selectedStyle := lipgloss.NewStyle().
+		Foreground(theme.Accent).
+		Bold(true)
+	mutedStyle := lipgloss.NewStyle().
+		Foreground(theme.Muted)
+	instructStyle := lipgloss.NewStyle().
+		Foreground(theme.Muted).
+		Italic(true)
+	warningStyle := lipgloss.NewStyle().
+		Foreground(theme.Warning)
+
+	s += labelStyle.Render("🌲 "+m.pinecone.label()) + "\n"
+	if stats := m.pineconeStats; stats != nil {
+		line := fmt.Sprintf("%d dimensions • %d vectors", stats.Dimension, stats.TotalCount)
+		if ns, ok := stats.Namespaces[m.pinecone.namespace]; ok {
+			line += fmt.Sprintf(" • %d in this namespace", ns.VectorCount)
+		}
+		s += mutedStyle.Render(line) + "\n"
+		if len(stats.Namespaces) > 1 {
+			names := make([]string, 0, len(stats.Namespaces))
+			for name := range stats.Namespaces {
+				if name == "" {
+					name = `""`
+				}
+				names = append(names, name)
+			}
+			sort.Strings(names)
+			s += mutedStyle.Render("Namespaces: "+strings.Join(names, ", ")) + "\n"
+		}
+	}
+	if m.pinecone.filter != nil {
+		filter, _ := json.Marshal(m.pinecone.filter)
+		s += mutedStyle.Render("Queries filtered by "+string(filter)) + "\n"
+	}
+	s += "\n"
+
+	switch {
+	case m.pineconeErr != nil:
+		s += warningStyle.Render("⚠️  "+m.pineconeErr.Error()) + "\n"
+	case m.pineconeLoading && len(m.pineconePage.vectors) == 0:
+		s += m.spinner.View() + " Loading vectors...\n"
+	case len(m.pineconePage.vectors) == 0:
+		s += instructStyle.Render("No vectors in this namespace") + "\n"
+	}
+
+	for i, v := range m.pineconePage.vectors {
+		line := fmt.Sprintf("%-24s %5dd  %s", truncateText(v.ID, 24), len(v.Values), truncateText(strings.ReplaceAll(m.pinecone.text(v), "\n", " "), 44))
+		if i == m.selectedVector {
+			s += selectedStyle.Render("▶ "+line) + "\n"
+			if extra := pineconeMetadata(v.Metadata, m.pinecone.textField); extra != "" {
+				s += mutedStyle.Render("    "+truncateText(extra, 72)) + "\n"
+			}
+		} else {
+			s += "  " + line + "\n"
+		}
+	}
+
+	s += "\n" + mutedStyle.Render(fmt.Sprintf("Page %d", max(len(m.pineconeTokens), 1))) + "\n"
+	s += instructStyle.Render("↑/↓ to select • Enter for its nearest neighbors • N/P next/previous page • Esc to return") + "\n"
+	return s
+}
+
+// pineconeMetadata lists a vector's metadata other than its text
+func pineconeMetadata(metadata map[string]any, textField string) string {
+	var keys []string
+	for key := range metadata {
+		if key != textField {
+			keys = append(keys, key)
+		}
+	}
+	sort.Strings(keys)
+	parts := make([]string, len(keys))
+	for i, key := range keys {
+		parts[i] = fmt.Sprintf("%s=%v", key, metadata[key])
+	}
+	return strings.Join(parts, " • ")
+}
+
+// runPineconeCommand handles `ember pinecone stats|list|query`
+func runPineconeCommand(args []string) {
+	if len(args) == 0 || (args[0] != "stats" && args[0] != "list" && args[0] != "query") {
+		fmt.Println("Usage: ember pinecone stats | list [--page TOKEN] | query [-k N] [--format table|json|ndjson|csv] QUERY")
+		os.Exit(2)
+	}
+	p, err := loadPineconeIndex()
+	if err == nil && p == nil {
+		err = fmt.Errorf("set EMBER_PINECONE_HOST to the index's host from the Pinecone console")
+	}
+	if err != nil {
+		displayError(err)
+		os.Exit(1)
+	}
+
+	switch args[0] {
+	case "stats":
+		var stats pineconeStats
+		if stats, err = p.stats(); err == nil {
+			fmt.Printf("%s • %d dimensions • %d vectors\n", p.label(), stats.Dimension, stats.TotalCount)
+			names := make([]string, 0, len(stats.Namespaces))
+			for name := range stats.Namespaces {
+				names = append(names, name)
+			}
+			sort.Strings(names)
+			for _, name := range names {
+				fmt.Printf("  %-24s %d\n", strconv.Quote(name), stats.Namespaces[name].VectorCount)
+			}
+		}
+	case "list":
+		fs := flag.NewFlagSet("pinecone list", flag.ExitOnError)
+		token := fs.String("page", "", "pagination token printed by the previous page")
+		fs.Parse(args[1:])
+		var page pineconePage
+		if page, err = p.page(*token); err == nil {
+			for _, v := range page.vectors {
+				fmt.Printf("%-24s %s\n", v.ID, truncateText(strings.ReplaceAll(p.text(v), "\n", " "), 80))
+			}
+			if page.next != "" {
+				fmt.Printf("\nNext page: ember pinecone list --page %s\n", page.next)
+			}
+		}
+	case "query":
+		fs := flag.NewFlagSet("pinecone query", flag.ExitOnError)
+		k := fs.Int("k", p.limit, "number of results")
+		format := formatFlag(fs, "table")
+		fs.Parse(args[1:])
+		query := strings.TrimSpace(strings.Join(fs.Args(), " "))
+		if query == "" || *k < 1 {
+			fmt.Println("Usage: ember pinecone query [-k N] [--format table|json|ndjson|csv] QUERY")
+			os.Exit(2)
+		}
+		checkFormat(format)
+
+		provider := setupProvider()
+		defer closeProvider(provider)
+		queryType := ""
+		if typed, ok := provider.(inputTypeProvider); ok && len(typed.InputTypes()) > 0 {
+			queryType = queryInputTypeFor(typed.InputTypes()[0])
+		}
+		var embedding []float64
+		var matches []pineconeVector
+		if embedding, err = withInputType(provider, queryType).GenerateEmbedding(query); err == nil {
+			if matches, err = p.query(embedding, *k); err == nil {
+				// The index was created outside ember, so its metric isn't known here
+				out := newRankedOutput(provider.ModelInfo(), len(embedding), indexMetric, query)
+				for _, match := range matches {
+					out.add(match.Score, p.text(match), match.ID)
+				}
+				err = out.write(*format)
+			}
+		}
+	}
+	if err != nil {
+		displayError(err)
+		os.Exit(1)
+	}
+}
@@ -0,0 +1,143 @@
+package main
+
+import (
+	"fmt"
+	"slices"
+	"strings"
+
+	"github.com/charmbracelet/lipgloss"
+)
+
+// Runs shown side by side on the pinned screen, and runs kept for it
+const (
+	pinnedColumns  = 6
+	pinnedRunsKept = 100
+)
+
+// scoredRun is the scores of one comparison this session, by comparison text
+type scoredRun struct {
+	Number int
+	Input  string
+	Scores map[string]float64
+}
+
+// recordRun keeps the scores of the comparison just made, so pinned results
+// can be followed back to before they were pinned
+func (m *model) recordRun() {
+	run := scoredRun{Number: 1, Input: m.lastInput, Scores: make(map[string]float64, len(m.similarities))}
+	if n := len(m.scoredRuns); n > 0 {
+		run.Number = m.scoredRuns[n-1].Number + 1
+	}
+	for _, result := range m.similarities {
+		run.Scores[result.Text] = result.Similarity
+	}
+	m.scoredRuns = append(m.scoredRuns, run)
+	if len(m.scoredRuns) > pinnedRunsKept {
+		m.scoredRuns = m.scoredRuns[len(m.scoredRuns)-pinnedRunsKept:]
+	}
+}
+
+// togglePin pins or unpins the comparison text of the selected result
+func (m *model) togglePin() {
+	if result, ok := m.resultAt(m.selectedResult); ok {
+		m.pinResult(result.Text)
+	}
+}
+
+func (m *model) pinResult(text string) {
+	if i := slices.Index(m.pins, text); i >= 0 {
+		m.pins = slices.Delete(m.pins, i, i+1)
+		m.noteNotice = "📌 Unpinned " + fmt.Sprintf("%q", truncateText(text, 40))
+		return
+	}
+	m.pins = append(m.pins, text)
+	m.noteNotice = "📌 Pinned " + fmt.Sprintf("%q", truncateText(text, 40)) + " • V to follow it across runs"
+}
+
+func (m model) isPinned(text string) bool {
+	return slices.Contains(m.pins, text)
+}
+
+func (m *model) openPinnedScreen() {
+	m.currentScreen = pinnedScreen
+	m.selectedPin = min(m.selectedPin, max(len(m.pins)-1, 0))
+}
+
+// unpinSelected removes the selected row of the pinned screen
+func (m *model) unpinSelected() {
+	if m.selectedPin >= len(m.pins) {
+		return
+	}
+	m.pins = slices.Delete(m.pins, m.selectedPin, m.selectedPin+1)
+	m.selectedPin = min(m.selectedPin, max(len(m.pins)-1, 0))
+}
+
+// pinnedChange is the change in a pinned text's score from the first to the
+// last of runs that compared it
+func pinnedChange(runs []scoredRun, text string) (float64, bool) {
+	var first, last float64
+	seen := 0
+	for _, run := range runs {
+		if score, ok := run.Scores[text]; ok {
+			if seen == 0 {
+				first = score
+			}
+			last = score
+			seen++
+		}
+	}
+	return last - first, seen > 1
+}
+
+func (m model) renderPinnedScreen() string {
+	s := "\033[2J\033[H" // Clear screen and move cursor to top
+
+	s += "╭─────────────────────────────────────────────────────────────────────────────╮\n"
+	s += "│                           📌 PINNED RESULTS 📌                              │\n"
+	s += "│                   Scores of pinned comparisons, run by run                  │\n"
+	s += "╰─────────────────────────────────────────────────────────────────────────────╯\n\n"
+
+	mutedStyle := lipgloss.NewStyle().Foreground(theme.Muted)
+	selectedStyle := lipgloss.NewStyle().Foreground(theme.Accent).Bold(true)
+	instructStyle := lipgloss.NewStyle().Foreground(theme.Muted).Italic(true)
+
+	if len(m.pins) == 0 {
+		s += mutedStyle.Render("Nothing pinned yet • press P on a result to pin it") + "\n\n"
+		s += instructStyle.Render("💡 Esc to return") + "\n"
+		return s
+	}
+
+	runs := m.scoredRuns[max(len(m.scoredRuns)-pinnedColumns, 0):]
+	header := fmt.Sprintf("  %-28s", "")
+	for _, run := range runs {
+		header += fmt.Sprintf("%7s", fmt.Sprintf("#%d", run.Number))
+	}
+	s += lipgloss.NewStyle().Foreground(theme.Primary).Bold(true).Render(header+fmt.Sprintf("%8s", "Δ")) + "\n"
+
+	for i, text := range m.pins {
+		row := fmt.Sprintf("%-28s", truncateText(strings.Join(strings.Fields(text), " "), 28))
+		for _, run := range runs {
+			if score, ok := run.Scores[text]; ok {
+				row += fmt.Sprintf("%7.3f", score)
+			} else {
+				row += fmt.Sprintf("%7s", "–")
+			}
+		}
+		if change, ok := pinnedChange(runs, text); ok {
+			row += fmt.Sprintf("%+8.3f", change)
+		}
+		if i == m.selectedPin {
+			s += selectedStyle.Render("▸ "+row) + "\n"
+		} else {
+			s += "  " + row + "\n"
+		}
+	}
+
+	s += "\n"
+	for _, run := range runs {
+		s += mutedStyle.Render(fmt.Sprintf("#%-4d %s", run.Number, truncateText(strings.Join(strings.Fields(run.Input), " "), 72))) + "\n"
+	}
+	s += "\n" + mutedStyle.Render("– means the text wasn't compared in that run • Δ is the change across the runs shown") + "\n\n"
+	s += instructStyle.Render("💡 ↑/↓ to select • P to unpin • Esc to return to the results") + "\n"
+	return s
+}
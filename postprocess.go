@@ -0,0 +1,240 @@
+package main
+
+import (
+	"fmt"
+	"math"
+	"os"
+	"sort"
+	"strconv"
+	"strings"
+	"unicode"
+
+	"github.com/charmbracelet/lipgloss"
+)
+
+// scoreProcessor adjusts a similarity score after it has been computed
+type scoreProcessor interface {
+	Process(result SimilarityResult) float64
+	Describe() string
+}
+
+// scoreAdjustment records one processor's effect so results can show how a score was derived
+type scoreAdjustment struct {
+	Processor string
+	Score     float64
+}
+
+// loadScoreProcessors reads the chain from EMBER_POSTPROCESS, e.g.
+// "rescale:0.2,0.9;calibrate:0.3=0.1,0.6=0.8;boost:urgent=0.05"
+func loadScoreProcessors() ([]scoreProcessor, error) {
+	processors, err := parseScoreProcessors(os.Getenv("EMBER_POSTPROCESS"))
+	if err != nil {
+		return nil, fmt.Errorf("invalid EMBER_POSTPROCESS: %w", err)
+	}
+	return processors, nil
+}
+
+func parseScoreProcessors(spec string) ([]scoreProcessor, error) {
+	var processors []scoreProcessor
+
+	for _, step := range strings.Split(spec, ";") {
+		step = strings.TrimSpace(step)
+		if step == "" {
+			continue
+		}
+
+		name, args, _ := strings.Cut(step, ":")
+		var (
+			p   scoreProcessor
+			err error
+		)
+		switch strings.TrimSpace(name) {
+		case "rescale":
+			p, err = parseRescale(args)
+		case "calibrate":
+			p, err = parseCalibration(args)
+		case "boost":
+			p, err = parseTagBoost(args)
+		default:
+			return nil, fmt.Errorf("unknown processor %q (available: rescale, calibrate, boost)", name)
+		}
+		if err != nil {
+			return nil, fmt.Errorf("%s: %w", name, err)
+		}
+		processors = append(processors, p)
+	}
+
+	return processors, nil
+}
+
+// applyScoreProcessors runs the chain over a result, keeping the raw score
+// and each step that changed it
+func applyScoreProcessors(processors []scoreProcessor, result *SimilarityResult) {
+	result.RawSimilarity = result.Similarity
+	for _, p := range processors {
+		score := p.Process(*result)
+		if score == result.Similarity {
+			continue
+		}
+		result.Similarity = score
+		result.Adjustments = append(result.Adjustments, scoreAdjustment{
+			Processor: p.Describe(),
+			Score:     score,
+		})
+	}
+}
+
+// renderAdjustments shows the raw score and each processor's result, e.g.
+// "↳ raw 0.734 → rescale 0.20–0.90 0.763 → boost #urgent +0.05 0.813"
+func renderAdjustments(result SimilarityResult) string {
+	line := fmt.Sprintf("↳ raw %.3f", result.RawSimilarity)
+	for _, adj := range result.Adjustments {
+		line += fmt.Sprintf(" → %s %.3f", adj.Processor, adj.Score)
+	}
+	return lipgloss.NewStyle().Foreground(theme.Muted).Render(line)
+}
+
+// rescaleProcessor stretches the range a model actually produces onto 0–1
+type rescaleProcessor struct {
+	min, max float64
+}
+
+func parseRescale(args string) (scoreProcessor, error) {
+	lo, hi, ok := strings.Cut(args, ",")
+	if !ok {
+		return nil, fmt.Errorf("expected rescale:min,max")
+	}
+	lower, err := strconv.ParseFloat(strings.TrimSpace(lo), 64)
+	if err != nil {
+		return nil, fmt.Errorf("invalid min %q", lo)
+	}
+	upper, err := strconv.ParseFloat(strings.TrimSpace(hi), 64)
+	if err != nil {
+		return nil, fmt.Errorf("invalid max %q", hi)
+	}
+	if upper <= lower {
+		return nil, fmt.Errorf("max must be greater than min")
+	}
+	return rescaleProcessor{min: lower, max: upper}, nil
+}
+
+func (p rescaleProcessor) Process(result SimilarityResult) float64 {
+	return clampScore((result.Similarity-p.min)/(p.max-p.min), 0, 1)
+}
+
+func (p rescaleProcessor) Describe() string {
+	return fmt.Sprintf("rescale %.2f–%.2f", p.min, p.max)
+}
+
+type calibrationPoint struct {
+	raw, score float64
+}
+
+// calibrationProcessor maps scores through a piecewise-linear curve, holding the end values beyond it
+type calibrationProcessor struct {
+	points []calibrationPoint
+}
+
+func parseCalibration(args string) (scoreProcessor, error) {
+	var points []calibrationPoint
+	for _, pair := range strings.Split(args, ",") {
+		raw, score, ok := strings.Cut(pair, "=")
+		if !ok {
+			return nil, fmt.Errorf("expected raw=score pairs, got %q", pair)
+		}
+		r, err := strconv.ParseFloat(strings.TrimSpace(raw), 64)
+		if err != nil {
+			return nil, fmt.Errorf("invalid raw score %q", raw)
+		}
+		s, err := strconv.ParseFloat(strings.TrimSpace(score), 64)
+		if err != nil {
+			return nil, fmt.Errorf("invalid calibrated score %q", score)
+		}
+		points = append(points, calibrationPoint{raw: r, score: s})
+	}
+	if len(points) < 2 {
+		return nil, fmt.Errorf("need at least two points")
+	}
+
+	sort.Slice(points, func(i, j int) bool { return points[i].raw < points[j].raw })
+	for i := 1; i < len(points); i++ {
+		if points[i].raw == points[i-1].raw {
+			return nil, fmt.Errorf("duplicate point at %.2f", points[i].raw)
+		}
+	}
+	return calibrationProcessor{points: points}, nil
+}
+
+func (p calibrationProcessor) Process(result SimilarityResult) float64 {
+	x := result.Similarity
+	if x <= p.points[0].raw {
+		return p.points[0].score
+	}
+	for i := 1; i < len(p.points); i++ {
+		a, b := p.points[i-1], p.points[i]
+		if x <= b.raw {
+			return a.score + (x-a.raw)/(b.raw-a.raw)*(b.score-a.score)
+		}
+	}
+	return p.points[len(p.points)-1].score
+}
+
+func (p calibrationProcessor) Describe() string {
+	return fmt.Sprintf("calibrate (%d points)", len(p.points))
+}
+
+// tagBoostProcessor adds a fixed amount to comparisons tagged with #tag
+type tagBoostProcessor struct {
+	tag   string
+	boost float64
+}
+
+func parseTagBoost(args string) (scoreProcessor, error) {
+	tag, amount, ok := strings.Cut(args, "=")
+	if !ok {
+		return nil, fmt.Errorf("expected boost:tag=amount")
+	}
+	tag = strings.TrimPrefix(strings.TrimSpace(tag), "#")
+	if tag == "" {
+		return nil, fmt.Errorf("missing tag")
+	}
+	boost, err := strconv.ParseFloat(strings.TrimSpace(amount), 64)
+	if err != nil {
+		return nil, fmt.Errorf("invalid amount %q", amount)
+	}
+	return tagBoostProcessor{tag: strings.ToLower(tag), boost: boost}, nil
+}
+
+func (p tagBoostProcessor) Process(result SimilarityResult) float64 {
+	for _, tag := range textTags(result.Text) {
+		if tag == p.tag {
+			return clampScore(result.Similarity+p.boost, -1, 1)
+		}
+	}
+	return result.Similarity
+}
+
+func (p tagBoostProcessor) Describe() string {
+	return fmt.Sprintf("boost #%s %+.2f", p.tag, p.boost)
+}
+
+// textTags returns the lowercased #hashtags in a comparison text
+func textTags(text string) []string {
+	var tags []string
+	for _, word := range strings.Fields(text) {
+		if !strings.HasPrefix(word, "#") {
+			continue
+		}
+		tag := strings.TrimRightFunc(word[1:], func(r rune) bool {
+			return !unicode.IsLetter(r) && !unicode.IsDigit(r) && r != '-' && r != '_'
+		})
+		if tag != "" {
+			tags = append(tags, strings.ToLower(tag))
+		}
+	}
+	return tags
+}
+
+func clampScore(score, lo, hi float64) float64 {
+	return math.Max(lo, math.Min(hi, score))
+}
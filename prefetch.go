@@ -0,0 +1,182 @@
+package main
+
+import (
+	"bufio"
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"os/signal"
+	"strconv"
+	"strings"
+	"syscall"
+	"time"
+)
+
+// runPrefetchCommand handles `ember prefetch`: it embeds a list of texts into
+// the cache ahead of time, so later sessions and CI checks get them for free.
+// Requests go through the same worker pool as embed-batch, and wait out rate
+// limits instead of failing.
+func runPrefetchCommand(args []string) {
+	fs := flag.NewFlagSet("prefetch", flag.ExitOnError)
+	as := fs.String("as", "document", "embed the texts as comparison texts (document) or as inputs (query)")
+	workers := fs.Int("workers", loadMaxConcurrency(), "requests in flight at once")
+	batch := fs.Int("batch", defaultEmbedBatchSize, "texts per request")
+	yes := fs.Bool("yes", false, "start without previewing a sample first")
+	dryRun := fs.Bool("dry-run", false, "report what would be embedded without calling the provider")
+	fs.Parse(args)
+
+	if fs.NArg() != 1 || (*as != "document" && *as != "query") || *workers < 1 || *batch < 1 {
+		fmt.Fprintln(os.Stderr, "Usage: ember prefetch [--as document|query] [--workers N] [--batch N] [--yes] [--dry-run] texts.txt")
+		fmt.Fprintln(os.Stderr, "Texts are read one per line; - reads stdin.")
+		os.Exit(2)
+	}
+
+	texts, err := readPrefetchTexts(fs.Arg(0))
+	if err == nil && len(texts) == 0 {
+		err = fmt.Errorf("%s has no texts", fs.Arg(0))
+	}
+	if err != nil {
+		displayError(err)
+		os.Exit(1)
+	}
+
+	cache := setupCache()
+	if cache == nil {
+		displayError(errors.New("the embedding cache is off, so there is nothing to prefetch into • unset EMBER_NO_CACHE"))
+		os.Exit(1)
+	}
+	defer cache.Close()
+
+	var provider EmbeddingProvider = setupProvider()
+	defer closeProvider(provider)
+	info := provider.ModelInfo()
+
+	// Texts are cached under the input type the TUI and ember compare use for them
+	inputType := ""
+	if typed, ok := provider.(inputTypeProvider); ok && len(typed.InputTypes()) > 0 {
+		inputType = typed.InputTypes()[0]
+	}
+	if *as == "query" {
+		inputType = queryInputTypeFor(inputType)
+	}
+	patient := &patientProvider{EmbeddingProvider: withInputType(provider, inputType), ctx: context.Background()}
+	cached := &cachedProvider{EmbeddingProvider: patient, cache: cache, model: cacheModelKey(info, inputType)}
+
+	var pending []batchChunk
+	for i, text := range texts {
+		if !cache.Has(cached.model, text) {
+			pending = append(pending, batchChunk{id: "line " + strconv.Itoa(i+1), text: text})
+		}
+	}
+
+	if *dryRun {
+		plan := newEmbeddingPlan(info)
+		plan.add(cached, texts...)
+		fmt.Fprint(os.Stderr, plan.render())
+		return
+	}
+	if len(pending) == 0 {
+		fmt.Printf("✅ All %d texts are already cached for %s\n", len(texts), info.Model)
+		return
+	}
+	if !*yes {
+		sampler := newCorpusSampler()
+		for _, chunk := range pending {
+			sampler.add(chunk.text)
+		}
+		ok, err := confirmBatchJob(cached, sampler)
+		if err != nil {
+			displayError(err)
+			os.Exit(1)
+		}
+		if !ok {
+			return
+		}
+	}
+
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+	go func() {
+		// A second Ctrl+C stops ember without waiting for requests in flight
+		<-ctx.Done()
+		stop()
+	}()
+	patient.ctx = ctx
+	// The cache is the output; the records themselves aren't needed
+	embedded, err := embedChunksConcurrently(ctx, cached, pending, info.Model, *batch, *workers, io.Discard)
+	switch {
+	case ctx.Err() != nil:
+		fmt.Fprintf(os.Stderr, "⏸  Stopped after %d of %d texts • run the same command again to fetch the rest\n", embedded, len(pending))
+		os.Exit(130)
+	case err != nil:
+		displayError(err)
+		fmt.Fprintf(os.Stderr, "%d of %d texts were cached • run the same command again to fetch the rest\n", embedded, len(pending))
+		os.Exit(1)
+	}
+	fmt.Printf("⚡ Cached %d texts with %s (%d were already cached)\n", embedded, info.Model, len(texts)-len(pending))
+}
+
+// patientProvider retries requests that were rate limited once the provider's
+// wait is over, until ctx is done
+type patientProvider struct {
+	EmbeddingProvider
+	ctx context.Context
+}
+
+func (p *patientProvider) GenerateEmbedding(text string) ([]float64, error) {
+	embeddings, err := p.GenerateBatch([]string{text})
+	if err != nil {
+		return nil, err
+	}
+	return embeddings[0], nil
+}
+
+func (p *patientProvider) GenerateBatch(texts []string) ([][]float64, error) {
+	for {
+		embeddings, err := p.EmbeddingProvider.GenerateBatch(texts)
+		var rateErr *RateLimitError
+		if !errors.As(err, &rateErr) {
+			return embeddings, err
+		}
+		fmt.Fprintf(os.Stderr, "\n⏳ Rate limited, retrying in %s\n", rateErr.RetryAfter.Round(time.Second))
+		select {
+		case <-time.After(rateErr.RetryAfter):
+		case <-p.ctx.Done():
+			return nil, p.ctx.Err()
+		}
+	}
+}
+
+// readPrefetchTexts reads one text per line from path, or stdin for -,
+// skipping blank lines and repeats
+func readPrefetchTexts(path string) ([]string, error) {
+	var r io.Reader = os.Stdin
+	if path != "-" {
+		f, err := os.Open(path)
+		if err != nil {
+			return nil, fmt.Errorf("failed to open %s: %w", path, err)
+		}
+		defer f.Close()
+		r = f
+	}
+
+	var texts []string
+	seen := make(map[string]bool)
+	scanner := bufio.NewScanner(r)
+	scanner.Buffer(make([]byte, 0, 64*1024), 16<<20)
+	for scanner.Scan() {
+		text := strings.TrimSpace(scanner.Text())
+		if text == "" || seen[text] {
+			continue
+		}
+		seen[text] = true
+		texts = append(texts, text)
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, fmt.Errorf("failed to read %s: %w", path, err)
+	}
+	return texts, nil
+}
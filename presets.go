@@ -0,0 +1,448 @@
+package main
+
+import (
+	"crypto/ed25519"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"time"
+)
+
+const (
+	// The largest preset bundle ember downloads
+	maxPresetBundleBytes = 32 << 20
+	// presetSignaturePrefix keeps a bundle signature from being valid for anything else
+	presetSignaturePrefix = "ember preset bundle\x00"
+)
+
+// presetBundle is a versioned collection of comparison sets a team
+// publishes for everyone to use, such as tone-of-voice anchors or policy
+// categories. Its sets are read-only in ember: they can be opened and
+// compared against, but not saved over or deleted.
+type presetBundle struct {
+	Name      string    `json:"name"`
+	Version   int       `json:"version"`
+	Published time.Time `json:"published"`
+	// Sets are kept as written, so signing doesn't fill in their defaults
+	Sets []json.RawMessage `json:"sets"`
+	// Signature is an Ed25519 signature of the rest, made by ember presets sign
+	Signature string `json:"signature,omitempty"`
+
+	// presets are the parsed sets
+	presets []presetFile
+	// source is the URL or path the bundle was read from
+	source string
+	// signedBy is the trusted key that signed it, "" when none did
+	signedBy string
+	// cached is set when the source couldn't be read and the last copy was used
+	cached bool
+}
+
+// signedBytes is what a bundle's signature covers: the bundle without it
+func (b presetBundle) signedBytes() ([]byte, error) {
+	b.Signature = ""
+	data, err := json.Marshal(b)
+	if err != nil {
+		return nil, fmt.Errorf("failed to marshal bundle: %w", err)
+	}
+	return append([]byte(presetSignaturePrefix), data...), nil
+}
+
+// presetSources reads EMBER_PRESET_BUNDLES, a comma-separated list of
+// bundle URLs and paths
+func presetSources() []string {
+	var sources []string
+	for _, source := range strings.Split(os.Getenv("EMBER_PRESET_BUNDLES"), ",") {
+		if source = strings.TrimSpace(source); source != "" {
+			sources = append(sources, source)
+		}
+	}
+	return sources
+}
+
+// presetKeys reads EMBER_PRESET_KEYS, the comma-separated base64 Ed25519
+// public keys bundles must be signed with. Without any, bundles are
+// loaded unchecked.
+func presetKeys() ([]ed25519.PublicKey, error) {
+	var keys []ed25519.PublicKey
+	for _, value := range strings.Split(os.Getenv("EMBER_PRESET_KEYS"), ",") {
+		if value = strings.TrimSpace(value); value == "" {
+			continue
+		}
+		key, err := base64.StdEncoding.DecodeString(value)
+		if err != nil || len(key) != ed25519.PublicKeySize {
+			return nil, fmt.Errorf("invalid EMBER_PRESET_KEYS key %q", value)
+		}
+		keys = append(keys, ed25519.PublicKey(key))
+	}
+	return keys, nil
+}
+
+// presetCachePath is where the last good copy of the bundle at source is
+// kept, for when the source can't be reached and to refuse older versions
+func presetCachePath(source string) (string, error) {
+	dir, err := globalDataDir()
+	if err != nil {
+		return "", err
+	}
+	sum := sha256.Sum256([]byte(source))
+	return filepath.Join(dir, "bundles", hex.EncodeToString(sum[:8])+".json"), nil
+}
+
+// readPresetSource downloads a bundle URL or reads a bundle file
+func readPresetSource(source string) ([]byte, error) {
+	if !strings.HasPrefix(source, "https://") && !strings.HasPrefix(source, "http://") {
+		data, err := os.ReadFile(source)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read preset bundle: %w", err)
+		}
+		return data, nil
+	}
+
+	client := &http.Client{Timeout: 15 * time.Second}
+	resp, err := client.Get(source)
+	if err != nil {
+		return nil, fmt.Errorf("failed to download preset bundle: %w", err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("failed to download preset bundle %s: %s", source, resp.Status)
+	}
+	data, err := io.ReadAll(io.LimitReader(resp.Body, maxPresetBundleBytes+1))
+	if err != nil {
+		return nil, fmt.Errorf("failed to download preset bundle %s: %w", source, err)
+	}
+	if len(data) > maxPresetBundleBytes {
+		return nil, fmt.Errorf("preset bundle %s is over %d MB", source, maxPresetBundleBytes>>20)
+	}
+	return data, nil
+}
+
+// parsePresetBundle parses a bundle and checks its signature against keys.
+// With no keys, any bundle is accepted.
+func parsePresetBundle(source string, data []byte, keys []ed25519.PublicKey) (presetBundle, error) {
+	var bundle presetBundle
+	if err := json.Unmarshal(data, &bundle); err != nil {
+		return bundle, fmt.Errorf("failed to parse preset bundle %s: %w", source, err)
+	}
+	bundle.source = source
+	if bundle.Name == "" || len(bundle.Sets) == 0 {
+		return bundle, fmt.Errorf("preset bundle %s needs a name and at least one set", source)
+	}
+	bundle.presets = make([]presetFile, len(bundle.Sets))
+	for i, raw := range bundle.Sets {
+		if err := json.Unmarshal(raw, &bundle.presets[i]); err != nil {
+			return bundle, fmt.Errorf("failed to parse set %d of preset bundle %s: %w", i+1, source, err)
+		}
+	}
+
+	if bundle.Signature != "" {
+		signature, err := base64.StdEncoding.DecodeString(bundle.Signature)
+		if err != nil {
+			return bundle, fmt.Errorf("preset bundle %s has an invalid signature", source)
+		}
+		signed, err := bundle.signedBytes()
+		if err != nil {
+			return bundle, err
+		}
+		for _, key := range keys {
+			if ed25519.Verify(key, signed, signature) {
+				bundle.signedBy = base64.StdEncoding.EncodeToString(key)
+			}
+		}
+	}
+	if len(keys) > 0 && bundle.signedBy == "" {
+		return bundle, fmt.Errorf("preset bundle %s isn't signed by a key in EMBER_PRESET_KEYS", source)
+	}
+	return bundle, nil
+}
+
+// loadPresetBundle reads the bundle at source, falling back to the copy
+// kept from the last time it loaded. A bundle older than that copy is
+// refused, so a stale mirror can't roll a team's presets back.
+func loadPresetBundle(source string, keys []ed25519.PublicKey) (presetBundle, error) {
+	cachePath, err := presetCachePath(source)
+	if err != nil {
+		return presetBundle{}, err
+	}
+	var last *presetBundle
+	if data, err := os.ReadFile(cachePath); err == nil {
+		if bundle, err := parsePresetBundle(source, data, keys); err == nil {
+			bundle.cached = true
+			last = &bundle
+		}
+	}
+
+	data, err := readPresetSource(source)
+	var bundle presetBundle
+	if err == nil {
+		bundle, err = parsePresetBundle(source, data, keys)
+	}
+	if err == nil && last != nil && bundle.Version < last.Version {
+		err = fmt.Errorf("preset bundle %s is version %d, older than version %d loaded before", source, bundle.Version, last.Version)
+	}
+	if err != nil {
+		if last != nil {
+			return *last, fmt.Errorf("%w • using version %d from before", err, last.Version)
+		}
+		return bundle, err
+	}
+
+	if err := os.MkdirAll(filepath.Dir(cachePath), 0o755); err == nil {
+		os.WriteFile(cachePath, data, 0o644)
+	}
+	return bundle, nil
+}
+
+// loadPresetBundles loads every bundle in EMBER_PRESET_BUNDLES. Bundles
+// that fail are reported in the error and the rest are still returned.
+func loadPresetBundles() ([]presetBundle, error) {
+	sources := presetSources()
+	if len(sources) == 0 {
+		return nil, nil
+	}
+	keys, err := presetKeys()
+	if err != nil {
+		return nil, err
+	}
+	var bundles []presetBundle
+	var errs []error
+	for _, source := range sources {
+		bundle, err := loadPresetBundle(source, keys)
+		if err != nil {
+			errs = append(errs, err)
+		}
+		if bundle.Name != "" && len(bundle.Sets) > 0 && (err == nil || bundle.cached) {
+			bundles = append(bundles, bundle)
+		}
+	}
+	return bundles, errors.Join(errs...)
+}
+
+// bundleStore is a corpusStore with the sets of preset bundles on top.
+// Bundled sets shadow stored sets of the same name, and can't be saved
+// over or deleted.
+type bundleStore struct {
+	corpusStore
+	sets []comparisonSet
+}
+
+// withPresetBundles puts the bundles' sets on top of store. Sets that
+// can't be read are reported in the error and left out.
+func withPresetBundles(store corpusStore, bundles []presetBundle) (corpusStore, error) {
+	if len(bundles) == 0 {
+		return store, nil
+	}
+	s := bundleStore{corpusStore: store}
+	var errs []error
+	for _, bundle := range bundles {
+		for _, preset := range bundle.presets {
+			set, err := preset.set(fmt.Sprintf("preset bundle %s", bundle.Name))
+			if err != nil {
+				errs = append(errs, err)
+				continue
+			}
+			set.Bundle = bundle.Name
+			set.Saved = bundle.Published
+			s.sets = append(s.sets, set)
+		}
+	}
+	return s, errors.Join(errs...)
+}
+
+// bundled returns the bundled set called name
+func (s bundleStore) bundled(name string) (comparisonSet, bool) {
+	i := slices.IndexFunc(s.sets, func(set comparisonSet) bool { return setFileName(set.Name) == setFileName(name) })
+	if i < 0 {
+		return comparisonSet{}, false
+	}
+	return s.sets[i], true
+}
+
+func (s bundleStore) ListSets() ([]comparisonSet, error) {
+	own, err := s.corpusStore.ListSets()
+	if err != nil {
+		return nil, err
+	}
+	sets := slices.Clone(s.sets)
+	for _, set := range own {
+		if _, ok := s.bundled(set.Name); !ok {
+			sets = append(sets, set)
+		}
+	}
+	slices.SortStableFunc(sets, func(a, b comparisonSet) int { return b.Saved.Compare(a.Saved) })
+	return sets, nil
+}
+
+func (s bundleStore) LoadSet(name string) (comparisonSet, error) {
+	if set, ok := s.bundled(name); ok {
+		return set, nil
+	}
+	return s.corpusStore.LoadSet(name)
+}
+
+func (s bundleStore) SaveSet(set comparisonSet) error {
+	if bundled, ok := s.bundled(set.Name); ok {
+		return fmt.Errorf("%q comes from the %s preset bundle and is read-only • save a copy under another name", set.Name, bundled.Bundle)
+	}
+	return s.corpusStore.SaveSet(set)
+}
+
+func (s bundleStore) DeleteSet(name string) error {
+	if bundled, ok := s.bundled(name); ok {
+		return fmt.Errorf("%q comes from the %s preset bundle and is read-only", name, bundled.Bundle)
+	}
+	return s.corpusStore.DeleteSet(name)
+}
+
+// pgBacked returns the Postgres store under any preset bundles
+func pgBacked(store corpusStore) (*pgStore, bool) {
+	if s, ok := store.(bundleStore); ok {
+		store = s.corpusStore
+	}
+	pg, ok := store.(*pgStore)
+	return pg, ok
+}
+
+// runPresetsCommand handles `ember presets list|keygen|sign`
+func runPresetsCommand(args []string) {
+	if len(args) == 0 || (args[0] != "list" && args[0] != "keygen" && args[0] != "sign") {
+		fmt.Println("Usage: ember presets list | keygen KEYFILE | sign --key KEYFILE BUNDLE.json")
+		os.Exit(2)
+	}
+	var err error
+	switch args[0] {
+	case "list":
+		err = listPresetBundles()
+	case "keygen":
+		err = generatePresetKey(args[1:])
+	case "sign":
+		err = signPresetBundle(args[1:])
+	}
+	if err != nil {
+		displayError(err)
+		os.Exit(1)
+	}
+}
+
+// listPresetBundles prints each bundle in EMBER_PRESET_BUNDLES, its
+// version and signature, and its sets
+func listPresetBundles() error {
+	if len(presetSources()) == 0 {
+		fmt.Println("No preset bundles • set EMBER_PRESET_BUNDLES to bundle URLs or paths")
+		return nil
+	}
+	bundles, err := loadPresetBundles()
+	if err != nil {
+		fmt.Fprintln(os.Stderr, "⚠️  "+err.Error())
+	}
+	for _, bundle := range bundles {
+		signature := "unsigned"
+		switch {
+		case bundle.signedBy != "":
+			signature = "signed by " + bundle.signedBy
+		case bundle.Signature != "":
+			signature = "signed • set EMBER_PRESET_KEYS to check it"
+		}
+		fmt.Printf("🔒 %s v%d • %s\n", bundle.Name, bundle.Version, signature)
+		source := bundle.source
+		if bundle.cached {
+			source += " (copy from before)"
+		}
+		fmt.Printf("   %s\n", source)
+		for _, preset := range bundle.presets {
+			fmt.Printf("   • %-32s %d texts\n", truncateText(preset.Name, 32), len(preset.Embeddings)+len(preset.Texts))
+		}
+	}
+	return nil
+}
+
+// generatePresetKey writes a new signing key to a file only its owner can
+// read, and prints the public key to put in EMBER_PRESET_KEYS
+func generatePresetKey(args []string) error {
+	if len(args) != 1 {
+		return errors.New("usage: ember presets keygen KEYFILE")
+	}
+	public, private, err := ed25519.GenerateKey(rand.Reader)
+	if err != nil {
+		return fmt.Errorf("failed to generate key: %w", err)
+	}
+	f, err := os.OpenFile(args[0], os.O_WRONLY|os.O_CREATE|os.O_EXCL, 0o600)
+	if err != nil {
+		return fmt.Errorf("failed to create %s: %w", args[0], err)
+	}
+	defer f.Close()
+	if _, err := fmt.Fprintln(f, base64.StdEncoding.EncodeToString(private.Seed())); err != nil {
+		return fmt.Errorf("failed to write %s: %w", args[0], err)
+	}
+	if err := f.Close(); err != nil {
+		return fmt.Errorf("failed to write %s: %w", args[0], err)
+	}
+	fmt.Printf("🔑 Wrote the signing key to %s • keep it private\n", args[0])
+	fmt.Printf("Public key, for EMBER_PRESET_KEYS: %s\n", base64.StdEncoding.EncodeToString(public))
+	return nil
+}
+
+// signPresetBundle signs a bundle file in place with a key from keygen
+func signPresetBundle(args []string) error {
+	fs := flag.NewFlagSet("presets sign", flag.ExitOnError)
+	keyPath := fs.String("key", "", "signing key written by ember presets keygen")
+	fs.Parse(args)
+	if *keyPath == "" || fs.NArg() != 1 {
+		return errors.New("usage: ember presets sign --key KEYFILE BUNDLE.json")
+	}
+
+	keyData, err := os.ReadFile(*keyPath)
+	if err != nil {
+		return fmt.Errorf("failed to read key: %w", err)
+	}
+	seed, err := base64.StdEncoding.DecodeString(strings.TrimSpace(string(keyData)))
+	if err != nil || len(seed) != ed25519.SeedSize {
+		return fmt.Errorf("%s isn't a key from ember presets keygen", *keyPath)
+	}
+	private := ed25519.NewKeyFromSeed(seed)
+
+	path := fs.Arg(0)
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return fmt.Errorf("failed to read preset bundle: %w", err)
+	}
+	bundle, err := parsePresetBundle(path, data, nil)
+	if err != nil {
+		return err
+	}
+	for _, preset := range bundle.presets {
+		if _, err := preset.set(path); err != nil {
+			return err
+		}
+	}
+	if bundle.Published.IsZero() {
+		bundle.Published = time.Now().UTC().Truncate(time.Second)
+	}
+	signed, err := bundle.signedBytes()
+	if err != nil {
+		return err
+	}
+	bundle.Signature = base64.StdEncoding.EncodeToString(ed25519.Sign(private, signed))
+
+	out, err := json.MarshalIndent(bundle, "", "  ")
+	if err != nil {
+		return fmt.Errorf("failed to marshal bundle: %w", err)
+	}
+	if err := os.WriteFile(path, append(out, '\n'), 0o644); err != nil {
+		return fmt.Errorf("failed to write %s: %w", path, err)
+	}
+	fmt.Printf("✍️  Signed %s v%d • %d sets\n", bundle.Name, bundle.Version, len(bundle.Sets))
+	return nil
+}
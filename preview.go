@@ -0,0 +1,242 @@
+package main
+
+import (
+	"bufio"
+	"fmt"
+	"math"
+	"math/rand/v2"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/charmbracelet/lipgloss"
+)
+
+// Texts embedded for a preview unless EMBER_PREVIEW_SAMPLE says otherwise
+const defaultPreviewSample = 50
+
+// Jobs with fewer texts start without a preview unless EMBER_PREVIEW_MIN says otherwise
+const defaultPreviewMin = 1000
+
+// Sample texts whose nearest neighbor the preview shows
+const previewNeighbors = 3
+
+// Rough characters per token, for estimating cost before anything is embedded
+const charsPerToken = 4
+
+// Upper bounds, in characters, of the length strata a preview samples from;
+// longer texts make up the last stratum
+var previewStrata = []int{80, 400, 2000}
+
+// embeddingPrices is what hosted models cost in dollars per million tokens.
+// Local providers are free; EMBER_PRICE_PER_MTOK covers anything else.
+var embeddingPrices = map[string]float64{
+	"text-embedding-3-small":        0.02,
+	"text-embedding-3-large":        0.13,
+	"text-embedding-ada-002":        0.10,
+	"embed-english-v3.0":            0.10,
+	"embed-multilingual-v3.0":       0.10,
+	"embed-english-light-v3.0":      0.10,
+	"embed-multilingual-light-v3.0": 0.10,
+}
+
+// corpusSampler keeps a random sample of each length stratum of a corpus
+// read once, so even a corpus streamed from a file can be previewed with
+// short and long texts in proportion
+type corpusSampler struct {
+	size   int
+	strata []corpusStratum
+	texts  int
+	chars  int
+}
+
+type corpusStratum struct {
+	count  int
+	sample []string
+}
+
+// batchPreview is what embedding a sample says about the whole job
+type batchPreview struct {
+	Texts  int
+	Sample int
+	// Tokens is estimated from the corpus' length
+	Tokens int
+	// Cost is in dollars; Priced is false when the model's price is unknown
+	Cost      float64
+	Priced    bool
+	Elapsed   time.Duration
+	Estimate  time.Duration
+	Neighbors []previewNeighbor
+}
+
+// previewNeighbor is a sample text and the most similar other sample text
+type previewNeighbor struct {
+	Text    string
+	Nearest string
+	Score   float64
+}
+
+func newCorpusSampler() *corpusSampler {
+	size := defaultPreviewSample
+	if n, err := strconv.Atoi(os.Getenv("EMBER_PREVIEW_SAMPLE")); err == nil && n > 1 {
+		size = n
+	}
+	return &corpusSampler{size: size, strata: make([]corpusStratum, len(previewStrata)+1)}
+}
+
+// add counts text and keeps it in its stratum's reservoir
+func (s *corpusSampler) add(text string) {
+	s.texts++
+	s.chars += len(text)
+
+	stratum := len(previewStrata)
+	for i, bound := range previewStrata {
+		if len(text) <= bound {
+			stratum = i
+			break
+		}
+	}
+	st := &s.strata[stratum]
+	st.count++
+	if len(st.sample) < s.size {
+		st.sample = append(st.sample, text)
+	} else if i := rand.IntN(st.count); i < s.size {
+		st.sample[i] = text
+	}
+}
+
+// sample draws from each stratum in proportion to its share of the corpus,
+// taking at least one text from every stratum that has any
+func (s *corpusSampler) sample() []string {
+	var sample []string
+	for _, st := range s.strata {
+		if st.count == 0 {
+			continue
+		}
+		n := max(1, int(math.Round(float64(s.size)*float64(st.count)/float64(s.texts))))
+		// A reservoir that never filled holds its texts in corpus order
+		for _, i := range rand.Perm(len(st.sample))[:min(n, len(st.sample))] {
+			sample = append(sample, st.sample[i])
+		}
+	}
+	return sample
+}
+
+// previewWanted reports whether a job over texts should be previewed first:
+// only when someone is at the terminal to confirm it, and it's big enough
+func previewWanted(texts int) bool {
+	minimum := defaultPreviewMin
+	if n, err := strconv.Atoi(os.Getenv("EMBER_PREVIEW_MIN")); err == nil && n >= 0 {
+		minimum = n
+	}
+	return interactive() && texts >= minimum
+}
+
+// modelPrice is the model's price per million tokens, if known
+func modelPrice(info ModelInfo) (float64, bool) {
+	if value := os.Getenv("EMBER_PRICE_PER_MTOK"); value != "" {
+		if price, err := strconv.ParseFloat(value, 64); err == nil && price >= 0 {
+			return price, true
+		}
+	}
+	switch info.Provider {
+	case "lmstudio", "onnx", "llamacpp", "mock":
+		return 0, true
+	}
+	price, ok := embeddingPrices[info.Model]
+	return price, ok
+}
+
+// previewBatchJob embeds the sample and extrapolates the job's cost and
+// time from it. Sample texts go through provider like the job's will, so a
+// cached provider has them ready when the job runs.
+func previewBatchJob(provider EmbeddingProvider, s *corpusSampler) (batchPreview, error) {
+	sample := s.sample()
+	preview := batchPreview{Texts: s.texts, Sample: len(sample), Tokens: (s.chars + charsPerToken - 1) / charsPerToken}
+	if price, ok := modelPrice(provider.ModelInfo()); ok {
+		preview.Cost, preview.Priced = price*float64(preview.Tokens)/1e6, true
+	}
+
+	start := time.Now()
+	vectors, err := embedInBatches(provider, sample, "sample texts")
+	if err != nil {
+		return preview, err
+	}
+	preview.Elapsed = time.Since(start)
+
+	sampleChars := 0
+	for _, text := range sample {
+		sampleChars += len(text)
+	}
+	if sampleChars > 0 {
+		preview.Estimate = time.Duration(float64(preview.Elapsed) * float64(s.chars) / float64(sampleChars))
+	}
+
+	for i := 0; i < len(sample) && len(preview.Neighbors) < previewNeighbors; i += max(1, len(sample)/previewNeighbors) {
+		best := previewNeighbor{Text: sample[i], Score: math.Inf(-1)}
+		for j := range sample {
+			if j == i {
+				continue
+			}
+			if score := cosineSimilarity(vectors[i], vectors[j]); score > best.Score {
+				best.Nearest, best.Score = sample[j], score
+			}
+		}
+		if best.Nearest != "" {
+			preview.Neighbors = append(preview.Neighbors, best)
+		}
+	}
+	return preview, nil
+}
+
+func renderBatchPreview(p batchPreview, info ModelInfo) string {
+	labelStyle := lipgloss.NewStyle().
+		Foreground(theme.Primary).
+		Bold(true)
+	mutedStyle := lipgloss.NewStyle().
+		Foreground(theme.Muted)
+	warningStyle := lipgloss.NewStyle().
+		Foreground(theme.Warning)
+
+	s := labelStyle.Render(fmt.Sprintf("🔬 Preview • embedded %d of %d texts with %s in %s", p.Sample, p.Texts, info.Model, p.Elapsed.Round(time.Millisecond))) + "\n\n"
+
+	cost := warningStyle.Render("unknown price • set EMBER_PRICE_PER_MTOK")
+	if p.Priced {
+		cost = fmt.Sprintf("$%.2f", p.Cost)
+	}
+	s += fmt.Sprintf("  Estimated tokens  ~%d\n", p.Tokens)
+	s += fmt.Sprintf("  Estimated cost    %s\n", cost)
+	s += fmt.Sprintf("  Estimated time    ~%s\n", p.Estimate.Round(time.Second))
+	s += mutedStyle.Render("  Estimates assume ~4 characters per token and the sample's speed; cached texts are free and faster.") + "\n\n"
+
+	if len(p.Neighbors) > 0 {
+		s += labelStyle.Render("Nearest neighbors within the sample") + "\n"
+		for _, n := range p.Neighbors {
+			s += "  " + truncateText(strings.ReplaceAll(n.Text, "\n", " "), 60) + "\n"
+			s += mutedStyle.Render(fmt.Sprintf("    → %.3f  %s", n.Score, truncateText(strings.ReplaceAll(n.Nearest, "\n", " "), 60))) + "\n"
+		}
+		s += "\n"
+	}
+	return s
+}
+
+// confirmBatchJob previews the job on stderr and asks whether to run it.
+// It says yes without asking when no preview is wanted.
+func confirmBatchJob(provider EmbeddingProvider, s *corpusSampler) (bool, error) {
+	if !previewWanted(s.texts) {
+		return true, nil
+	}
+	preview, err := previewBatchJob(provider, s)
+	if err != nil {
+		return false, err
+	}
+	fmt.Fprint(os.Stderr, renderBatchPreview(preview, provider.ModelInfo()))
+	fmt.Fprintf(os.Stderr, "Embed all %d texts? [y/N] ", s.texts)
+	answer, _ := bufio.NewReader(os.Stdin).ReadString('\n')
+	switch strings.ToLower(strings.TrimSpace(answer)) {
+	case "y", "yes":
+		return true, nil
+	}
+	return false, nil
+}
@@ -0,0 +1,136 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"strings"
+)
+
+// Rounds of label propagation; small graphs settle long before this
+const propagationRounds = 50
+
+// weakLabel is the label a node of the similarity graph was given, either as
+// a #tagged comparison text (a seed) or by propagation from its neighbors
+type weakLabel struct {
+	ID         string  `json:"id"`
+	Text       string  `json:"text"`
+	Label      string  `json:"label"`
+	Confidence float64 `json:"confidence"`
+	Seed       bool    `json:"seed"`
+}
+
+// propagateLabels spreads the #tag labels of the graph's comparison texts to
+// every other node. Each round, a node takes the similarity-weighted average
+// of its neighbors' label scores, treating edges as undirected, while seeds
+// keep their own label. A node's weak label is its best scoring one, with
+// that label's share of its scores as the confidence; nodes no seed reaches
+// get no label.
+func propagateLabels(graph similarityGraph) []weakLabel {
+	var labels []string
+	labelIndex := make(map[string]int)
+	seeds := make([]int, len(graph.Nodes))
+	for i, node := range graph.Nodes {
+		seeds[i] = -1
+		if node.Input {
+			continue
+		}
+		label := comparisonLabel(node.Text)
+		if !strings.HasPrefix(label, "#") {
+			continue
+		}
+		l, ok := labelIndex[label]
+		if !ok {
+			l = len(labels)
+			labelIndex[label] = l
+			labels = append(labels, label)
+		}
+		seeds[i] = l
+	}
+
+	// Neighbor lists only point one way, so link both ends of every edge
+	weights := make([]map[int]float64, len(graph.Nodes))
+	for i := range weights {
+		weights[i] = make(map[int]float64)
+	}
+	for i, edges := range graph.Edges {
+		for _, edge := range edges {
+			if edge.Score > 0 {
+				weights[i][edge.To] = max(weights[i][edge.To], edge.Score)
+				weights[edge.To][i] = max(weights[edge.To][i], edge.Score)
+			}
+		}
+	}
+
+	scores := make([][]float64, len(graph.Nodes))
+	for i := range scores {
+		scores[i] = make([]float64, len(labels))
+		if seeds[i] >= 0 {
+			scores[i][seeds[i]] = 1
+		}
+	}
+	for round := 0; round < propagationRounds; round++ {
+		next := make([][]float64, len(scores))
+		for i := range scores {
+			if seeds[i] >= 0 {
+				next[i] = scores[i]
+				continue
+			}
+			next[i] = make([]float64, len(labels))
+			total := 0.0
+			for j, w := range weights[i] {
+				for l, score := range scores[j] {
+					next[i][l] += w * score
+				}
+				total += w
+			}
+			for l := range next[i] {
+				next[i][l] /= max(total, 1e-12)
+			}
+		}
+		scores = next
+	}
+
+	result := make([]weakLabel, len(graph.Nodes))
+	for i, node := range graph.Nodes {
+		result[i] = weakLabel{ID: node.ID, Text: node.Text, Seed: seeds[i] >= 0}
+		best, total := -1, 0.0
+		for l, score := range scores[i] {
+			total += score
+			if best == -1 || score > scores[i][best] {
+				best = l
+			}
+		}
+		if best >= 0 && total > 0 {
+			result[i].Label = labels[best]
+			result[i].Confidence = scores[i][best] / total
+		}
+	}
+	return result
+}
+
+// exportWeakLabels writes the propagated label of every node of the graph
+// to path as JSON Lines
+func (m model) exportWeakLabels(path string) error {
+	if m.graph == nil {
+		return fmt.Errorf("the graph is still being built")
+	}
+	labels := m.graphLabels
+	if len(labels) == 0 {
+		return fmt.Errorf("there is nothing to label yet")
+	}
+
+	f, err := os.Create(path)
+	if err != nil {
+		return fmt.Errorf("failed to create %s: %w", path, err)
+	}
+	defer f.Close()
+
+	enc := json.NewEncoder(f)
+	for _, label := range labels {
+		if err := enc.Encode(label); err != nil {
+			return fmt.Errorf("failed to write %s: %w", path, err)
+		}
+	}
+	return f.Close()
+}
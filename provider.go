@@ -0,0 +1,127 @@
+package main
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"sort"
+	"strings"
+)
+
+// ModelInfo describes the model behind a provider
+type ModelInfo struct {
+	Provider string `json:"provider"`
+	Model    string `json:"model"`
+	// Dimensions is 0 when the provider only learns it from the first response
+	Dimensions int `json:"dimensions,omitempty"`
+}
+
+// EmbeddingProvider is implemented by every embedding backend
+type EmbeddingProvider interface {
+	GenerateEmbedding(text string) ([]float64, error)
+	GenerateBatch(texts []string) ([][]float64, error)
+	ModelInfo() ModelInfo
+}
+
+type providerFactory func() (EmbeddingProvider, error)
+
+var providerFactories = make(map[string]providerFactory)
+
+// RegisterProvider makes a backend selectable by name; call it from init()
+func RegisterProvider(name string, factory providerFactory) {
+	providerFactories[strings.ToLower(name)] = factory
+}
+
+func NewProvider(name string) (EmbeddingProvider, error) {
+	factory, ok := providerFactories[strings.ToLower(name)]
+	if !ok {
+		return nil, fmt.Errorf("unknown provider %q (available: %s)", name, strings.Join(providerNames(), ", "))
+	}
+	return factory()
+}
+
+func providerNames() []string {
+	names := make([]string, 0, len(providerFactories))
+	for name := range providerFactories {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// loadProviderName reads EMBER_PROVIDER, defaulting to OpenAI
+func loadProviderName() string {
+	if name := os.Getenv("EMBER_PROVIDER"); name != "" {
+		return name
+	}
+	return "openai"
+}
+
+// modelReaders read the model each provider is configured to use without
+// constructing it; see configuredModel
+var modelReaders = make(map[string]func() (string, bool))
+
+// RegisterModelReader tells configuredModel how the named provider picks its
+// model; call it from init() next to RegisterProvider
+func RegisterModelReader(name string, reader func() (string, bool)) {
+	modelReaders[strings.ToLower(name)] = reader
+}
+
+// configuredModel returns the model the named provider is configured to
+// use, or false when only connecting would tell, as with LM Studio's first
+// loaded model
+func configuredModel(name string) (string, bool) {
+	if reader, ok := modelReaders[strings.ToLower(name)]; ok {
+		return reader()
+	}
+	return "", false
+}
+
+// modelFromEnv reads a model from key, defaulting to fallback
+func modelFromEnv(key, fallback string) func() (string, bool) {
+	return func() (string, bool) {
+		if model := os.Getenv(key); model != "" {
+			return model, true
+		}
+		return fallback, fallback != ""
+	}
+}
+
+// inputTypeProvider is implemented by providers that embed queries and documents differently
+type inputTypeProvider interface {
+	InputTypes() []string
+	WithInputType(inputType string) EmbeddingProvider
+}
+
+// withInputType applies inputType when the provider supports it and is a no-op otherwise
+func withInputType(p EmbeddingProvider, inputType string) EmbeddingProvider {
+	if typed, ok := p.(inputTypeProvider); ok && inputType != "" {
+		return typed.WithInputType(inputType)
+	}
+	return p
+}
+
+// modelSelector is implemented by providers that can switch to another model of the same backend
+type modelSelector interface {
+	WithModel(model string) EmbeddingProvider
+}
+
+// dimensionSelector is implemented by providers that can return shortened vectors
+type dimensionSelector interface {
+	WithDimensions(n int) EmbeddingProvider
+}
+
+// modelLister is implemented by providers that can report which embedding models they offer
+type modelLister interface {
+	ListModels() ([]string, error)
+}
+
+// closeProvider prints the notices the provider's API sent, then releases
+// resources such as a server it started; providers that hold none don't
+// implement io.Closer
+func closeProvider(p EmbeddingProvider) {
+	printProviderNotices(p)
+	if closer, ok := p.(io.Closer); ok {
+		closer.Close()
+	}
+}
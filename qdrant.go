@@ -0,0 +1,273 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"hash/fnv"
+	"io"
+	"net/http"
+	"net/url"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Collection used unless EMBER_QDRANT_COLLECTION says otherwise
+const defaultQdrantCollection = "ember"
+
+// qdrantClient talks to a collection through Qdrant's REST API
+type qdrantClient struct {
+	baseURL    string
+	apiKey     string
+	collection string
+	limit      int
+	client     *http.Client
+}
+
+// qdrantPoint is one embedded text in the collection
+type qdrantPoint struct {
+	ID      uint64         `json:"id"`
+	Vector  []float64      `json:"vector"`
+	Payload map[string]any `json:"payload"`
+}
+
+type qdrantHit struct {
+	Score   float64   `json:"score"`
+	Vector  []float64 `json:"vector"`
+	Payload struct {
+		Text  string `json:"text"`
+		Model string `json:"model"`
+	} `json:"payload"`
+}
+
+// loadQdrantClient reads EMBER_QDRANT_URL, EMBER_QDRANT_API_KEY,
+// EMBER_QDRANT_COLLECTION and EMBER_QDRANT_LIMIT. It returns nil when no URL is set.
+func loadQdrantClient() (*qdrantClient, error) {
+	baseURL := os.Getenv("EMBER_QDRANT_URL")
+	if baseURL == "" {
+		return nil, nil
+	}
+	if _, err := url.Parse(baseURL); err != nil {
+		return nil, fmt.Errorf("invalid EMBER_QDRANT_URL %q: %w", baseURL, err)
+	}
+
+	q := &qdrantClient{
+		baseURL:    strings.TrimRight(baseURL, "/"),
+		apiKey:     os.Getenv("EMBER_QDRANT_API_KEY"),
+		collection: os.Getenv("EMBER_QDRANT_COLLECTION"),
+		limit:      defaultRemoteLimit,
+		client:     &http.Client{Timeout: 60 * time.Second},
+	}
+	if q.collection == "" {
+		q.collection = defaultQdrantCollection
+	}
+	if value := os.Getenv("EMBER_QDRANT_LIMIT"); value != "" {
+		limit, err := strconv.Atoi(value)
+		if err != nil || limit < 1 {
+			return nil, fmt.Errorf("invalid EMBER_QDRANT_LIMIT %q", value)
+		}
+		q.limit = limit
+	}
+	return q, nil
+}
+
+// do sends body as JSON and decodes the response's result into result
+func (q *qdrantClient) do(method, path string, body, result any) error {
+	var reader io.Reader
+	if body != nil {
+		data, err := json.Marshal(body)
+		if err != nil {
+			return fmt.Errorf("failed to marshal request: %w", err)
+		}
+		reader = bytes.NewReader(data)
+	}
+
+	req, err := http.NewRequest(method, q.baseURL+path, reader)
+	if err != nil {
+		return fmt.Errorf("failed to create request: %w", err)
+	}
+	req.Header.Set("Content-Type", "application/json")
+	if q.apiKey != "" {
+		req.Header.Set("api-key", q.apiKey)
+	}
+
+	resp, err := q.client.Do(req)
+	if err != nil {
+		return fmt.Errorf("failed to reach Qdrant: %w", err)
+	}
+	defer resp.Body.Close()
+
+	data, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return fmt.Errorf("failed to read response: %w", err)
+	}
+	if resp.StatusCode != http.StatusOK {
+		return &APIStatusError{StatusCode: resp.StatusCode, Body: string(data)}
+	}
+	if result == nil {
+		return nil
+	}
+
+	var envelope struct {
+		Result json.RawMessage `json:"result"`
+	}
+	if err := json.Unmarshal(data, &envelope); err != nil {
+		return fmt.Errorf("failed to unmarshal response: %w", err)
+	}
+	if err := json.Unmarshal(envelope.Result, result); err != nil {
+		return fmt.Errorf("failed to unmarshal response: %w", err)
+	}
+	return nil
+}
+
+func (q *qdrantClient) collectionPath() string {
+	return "/collections/" + url.PathEscape(q.collection)
+}
+
+// collectionSize returns the vector size of the collection, or 0 when it doesn't exist
+func (q *qdrantClient) collectionSize() (int, error) {
+	var info struct {
+		Config struct {
+			Params struct {
+				Vectors struct {
+					Size int `json:"size"`
+				} `json:"vectors"`
+			} `json:"params"`
+		} `json:"config"`
+	}
+	err := q.do("GET", q.collectionPath(), nil, &info)
+	if apiErr, ok := err.(*APIStatusError); ok && apiErr.StatusCode == http.StatusNotFound {
+		return 0, nil
+	}
+	if err != nil {
+		return 0, fmt.Errorf("failed to read collection %s: %w", q.collection, err)
+	}
+	return info.Config.Params.Vectors.Size, nil
+}
+
+// ensureCollection creates the collection for vectors of size dimensions,
+// or checks that the existing one takes them
+func (q *qdrantClient) ensureCollection(dimensions int) error {
+	size, err := q.collectionSize()
+	switch {
+	case err != nil:
+		return err
+	case size == dimensions:
+		return nil
+	case size != 0:
+		return fmt.Errorf("collection %s holds %d-dimensional vectors, not %d", q.collection, size, dimensions)
+	}
+
+	body := map[string]any{"vectors": map[string]any{"size": dimensions, "distance": "Cosine"}}
+	if err := q.do("PUT", q.collectionPath(), body, nil); err != nil {
+		return fmt.Errorf("failed to create collection %s: %w", q.collection, err)
+	}
+	// Searches filter on the model, which needs an index on large collections
+	index := map[string]any{"field_name": "model", "field_schema": "keyword"}
+	if err := q.do("PUT", q.collectionPath()+"/index?wait=true", index, nil); err != nil {
+		return fmt.Errorf("failed to index collection %s: %w", q.collection, err)
+	}
+	return nil
+}
+
+// qdrantPointID derives a point's ID from its text and model, so upserting
+// the same text again replaces it instead of adding a copy
+func qdrantPointID(model, text string) uint64 {
+	h := fnv.New64a()
+	h.Write([]byte(model))
+	h.Write([]byte{0})
+	h.Write([]byte(text))
+	return h.Sum64()
+}
+
+// upsert adds embeddings made by model to the collection
+func (q *qdrantClient) upsert(model ModelInfo, embeddings []CustomEmbedding) error {
+	name := model.Provider + "/" + model.Model
+	points := make([]qdrantPoint, len(embeddings))
+	for i, e := range embeddings {
+		points[i] = qdrantPoint{
+			ID:      qdrantPointID(name, e.Text),
+			Vector:  e.Embedding,
+			Payload: map[string]any{"text": e.Text, "model": name},
+		}
+	}
+	if err := q.do("PUT", q.collectionPath()+"/points?wait=true", map[string]any{"points": points}, nil); err != nil {
+		return fmt.Errorf("failed to upsert into %s: %w", q.collection, err)
+	}
+	return nil
+}
+
+// search returns the collection's k texts from model most similar to
+// vector, best first, with their vectors
+func (q *qdrantClient) search(model ModelInfo, vector []float64, k int) ([]CustomEmbedding, []float64, error) {
+	body := map[string]any{
+		"vector":       vector,
+		"limit":        k,
+		"with_payload": true,
+		"with_vector":  true,
+		"filter": map[string]any{
+			"must": []any{map[string]any{"key": "model", "match": map[string]any{"value": model.Provider + "/" + model.Model}}},
+		},
+	}
+	var hits []qdrantHit
+	if err := q.do("POST", q.collectionPath()+"/points/search", body, &hits); err != nil {
+		return nil, nil, fmt.Errorf("failed to search %s: %w", q.collection, err)
+	}
+
+	embeddings := make([]CustomEmbedding, len(hits))
+	scores := make([]float64, len(hits))
+	for i, hit := range hits {
+		embeddings[i] = CustomEmbedding{Text: hit.Payload.Text, Embedding: hit.Vector}
+		scores[i] = hit.Score
+	}
+	return embeddings, scores, nil
+}
+
+// label names the collection on screen
+func (q *qdrantClient) label() string {
+	return "Qdrant collection " + q.collection
+}
+
+// nearest returns the collection's texts nearest to vector, for remoteIndex
+func (q *qdrantClient) nearest(model ModelInfo, vector []float64) ([]CustomEmbedding, error) {
+	hits, _, err := q.search(model, vector, q.limit)
+	return hits, err
+}
+
+// runQdrantCommand handles `ember qdrant upsert|search|info`
+func runQdrantCommand(args []string) {
+	if len(args) == 0 || (args[0] != "upsert" && args[0] != "search" && args[0] != "info") {
+		fmt.Println("Usage: ember qdrant upsert [--redact] [--set NAME | [--batch N] [--yes] [--dry-run] FILE] | search [-k N] QUERY | info")
+		os.Exit(2)
+	}
+	q, err := loadQdrantClient()
+	if err == nil && q == nil {
+		err = fmt.Errorf("set EMBER_QDRANT_URL to the Qdrant server, e.g. http://localhost:6333")
+	}
+	if err != nil {
+		displayError(err)
+		os.Exit(1)
+	}
+
+	switch args[0] {
+	case "upsert":
+		err = runRemoteUpsert(q, "qdrant", args[1:])
+	case "search":
+		err = runRemoteSearch(q, "qdrant", q.limit, args[1:])
+	case "info":
+		var size int
+		if size, err = q.collectionSize(); err == nil {
+			if size == 0 {
+				fmt.Printf("Collection %s doesn't exist yet • ember qdrant upsert creates it\n", q.collection)
+			} else {
+				fmt.Printf("Collection %s holds %d-dimensional vectors\n", q.collection, size)
+			}
+		}
+	}
+	if err != nil {
+		displayError(err)
+		os.Exit(1)
+	}
+}
@@ -0,0 +1,55 @@
+package main
+
+// Most inputs kept for Alt+↑/Alt+↓ recall
+const maxRecallInputs = 100
+
+// loadRecallInputs seeds input recall with earlier sessions' inputs from the
+// history, oldest first
+func loadRecallInputs(store corpusStore) []string {
+	entries, _ := store.LoadHistory()
+
+	var inputs []string
+	for i := len(entries) - 1; i >= 0; i-- {
+		inputs = appendRecallInput(inputs, entries[i].Input)
+	}
+	return inputs
+}
+
+// appendRecallInput adds text unless it repeats the latest input, dropping
+// the oldest once the list is full
+func appendRecallInput(inputs []string, text string) []string {
+	if text == "" || (len(inputs) > 0 && inputs[len(inputs)-1] == text) {
+		return inputs
+	}
+	inputs = append(inputs, text)
+	if len(inputs) > maxRecallInputs {
+		inputs = inputs[len(inputs)-maxRecallInputs:]
+	}
+	return inputs
+}
+
+// rememberInput records a submitted input and ends any recall in progress
+func (m *model) rememberInput(text string) {
+	m.recallInputs = appendRecallInput(m.recallInputs, text)
+	m.recallIndex = len(m.recallInputs)
+}
+
+// recallInput steps through earlier inputs like shell history: -1 for older,
+// +1 for newer. Stepping past the newest puts back what was being typed.
+func (m *model) recallInput(step int) {
+	next := m.recallIndex + step
+	if next < 0 || next > len(m.recallInputs) {
+		return
+	}
+	if m.recallIndex == len(m.recallInputs) {
+		m.recallDraft = m.textarea.Value()
+	}
+
+	m.recallIndex = next
+	if next == len(m.recallInputs) {
+		m.textarea.SetValue(m.recallDraft)
+	} else {
+		m.textarea.SetValue(m.recallInputs[next])
+	}
+	m.textarea.CursorEnd()
+}
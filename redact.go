@@ -0,0 +1,138 @@
+package main
+
+import (
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+
+	"github.com/zalando/go-keyring"
+)
+
+const (
+	// redactedPrefix starts every text stored redacted
+	redactedPrefix = "🔒 "
+	// keychainRedactionKey is the keychain entry holding the redaction key
+	keychainRedactionKey = "redaction"
+)
+
+// redactionKey is the secret texts are hashed with, so a label can't be
+// matched against the hashes of guessed texts without it
+var (
+	redactionKey     []byte
+	redactionKeyErr  error
+	redactionKeyOnce sync.Once
+)
+
+// loadRedaction reads EMBER_REDACT, which makes the vector store and
+// remote indexes keep a hash of each text instead of the text. The
+// embedding cache only ever keeps hashes.
+func loadRedaction() bool {
+	value := strings.ToLower(os.Getenv("EMBER_REDACT"))
+	return value == "1" || value == "true" || value == "yes"
+}
+
+// setupRedaction loads the redaction key, which commands that redact call
+// before redactText. EMBER_REDACT_KEY sets it, so machines sharing a store
+// hash alike; otherwise a random key is made on first use and kept in the
+// system keychain, or in redaction.key in the data directory where there's
+// no keychain.
+func setupRedaction() error {
+	redactionKeyOnce.Do(func() {
+		redactionKey, redactionKeyErr = loadRedactionKey()
+	})
+	return redactionKeyErr
+}
+
+func loadRedactionKey() ([]byte, error) {
+	if key := os.Getenv("EMBER_REDACT_KEY"); key != "" {
+		return []byte(key), nil
+	}
+	if os.Getenv("EMBER_NO_KEYCHAIN") == "" {
+		encoded, err := keyring.Get(keychainService, keychainRedactionKey)
+		if err == nil {
+			if key, err := base64.StdEncoding.DecodeString(encoded); err == nil && len(key) == 32 {
+				return key, nil
+			}
+			return nil, errors.New("the system keychain's ember redaction key is damaged")
+		}
+		if errors.Is(err, keyring.ErrNotFound) {
+			key := make([]byte, 32)
+			rand.Read(key)
+			if keyring.Set(keychainService, keychainRedactionKey, base64.StdEncoding.EncodeToString(key)) == nil {
+				return key, nil
+			}
+		}
+	}
+
+	dir, err := globalDataDir()
+	if err != nil {
+		return nil, err
+	}
+	path := filepath.Join(dir, "redaction.key")
+	data, err := os.ReadFile(path)
+	if err == nil {
+		if data, err = openAtRest(data); err != nil {
+			return nil, fmt.Errorf("failed to read %s: %w", path, err)
+		}
+		key, err := base64.StdEncoding.DecodeString(strings.TrimSpace(string(data)))
+		if err != nil || len(key) != 32 {
+			return nil, fmt.Errorf("%s is damaged", path)
+		}
+		return key, nil
+	}
+	if !errors.Is(err, os.ErrNotExist) {
+		return nil, fmt.Errorf("failed to read %s: %w", path, err)
+	}
+	key := make([]byte, 32)
+	rand.Read(key)
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		return nil, fmt.Errorf("failed to create %s: %w", dir, err)
+	}
+	if err := os.WriteFile(path, sealAtRest([]byte(base64.StdEncoding.EncodeToString(key))), 0o600); err != nil {
+		return nil, fmt.Errorf("failed to write %s: %w", path, err)
+	}
+	return key, nil
+}
+
+// redactText replaces text with an HMAC of it and its #tags, so a stored
+// text can be told apart and grouped by label but not read back or
+// confirmed by hashing a guess. setupRedaction must have succeeded.
+func redactText(text string) string {
+	if isRedacted(text) {
+		return text
+	}
+	if redactionKey == nil {
+		panic("redactText called before setupRedaction")
+	}
+	mac := hmac.New(sha256.New, redactionKey)
+	mac.Write([]byte(text))
+	redacted := redactedPrefix + hex.EncodeToString(mac.Sum(nil)[:8])
+	for _, tag := range textTags(text) {
+		redacted += " #" + tag
+	}
+	return redacted
+}
+
+// isRedacted reports whether text is a redactText hash
+func isRedacted(text string) bool {
+	return strings.HasPrefix(text, redactedPrefix)
+}
+
+// redactEmbeddings returns a copy of embeddings with every text redacted
+func redactEmbeddings(embeddings []CustomEmbedding) []CustomEmbedding {
+	redacted := make([]CustomEmbedding, len(embeddings))
+	for i, e := range embeddings {
+		// The template is the text as written, so it goes too
+		e.Text, e.Template = redactText(e.Text), ""
+		redacted[i] = e
+	}
+	return redacted
+}
@@ -0,0 +1,414 @@
+package main
+
+import (
+	"bufio"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/lipgloss"
+)
+
+// Nearest texts a remote comparison shows unless the index's *_LIMIT variable says otherwise
+const defaultRemoteLimit = 10
+
+// remoteIndex is a vector database that comparisons can run against instead
+// of the comparison set, for corpora too large to hold in memory
+type remoteIndex interface {
+	// label names the index on screen, e.g. "Qdrant collection papers"
+	label() string
+	// nearest returns the index's texts most similar to vector, with their vectors
+	nearest(model ModelInfo, vector []float64) ([]CustomEmbedding, error)
+}
+
+// Texts embedded and upserted per request by `ember <store> upsert`
+const defaultRemoteBatch = 64
+
+// Most results a filtered search asks a store for while looking for enough
+// texts that match the filter
+const maxFilteredSearch = 10000
+
+// remoteStore is a remoteIndex ember can also load texts into and search
+// from the command line
+type remoteStore interface {
+	remoteIndex
+	// ensureCollection creates the collection for vectors of size
+	// dimensions, or checks that the existing one takes them
+	ensureCollection(dimensions int) error
+	// upsert adds embeddings made by model, replacing copies of the same texts
+	upsert(model ModelInfo, embeddings []CustomEmbedding) error
+	// search returns the k texts from model most similar to vector, best
+	// first, with their scores
+	search(model ModelInfo, vector []float64, k int) ([]CustomEmbedding, []float64, error)
+}
+
+// loadRemoteIndexes returns the vector databases configured in the environment
+func loadRemoteIndexes() ([]remoteIndex, error) {
+	var indexes []remoteIndex
+	v, err := loadVectorStore()
+	if err != nil {
+		return nil, err
+	}
+	if v != nil {
+		indexes = append(indexes, v)
+	}
+	q, err := loadQdrantClient()
+	if err != nil {
+		return nil, err
+	}
+	if q != nil {
+		indexes = append(indexes, q)
+	}
+	w, err := loadWeaviateClient()
+	if err != nil {
+		return nil, err
+	}
+	if w != nil {
+		indexes = append(indexes, w)
+	}
+	p, err := loadPineconeIndex()
+	if err != nil {
+		return nil, err
+	}
+	if p != nil {
+		indexes = append(indexes, p)
+	}
+	return indexes, nil
+}
+
+// cycleRemoteIndex moves comparisons from the comparison set to each
+// configured vector database in turn, and back
+func (m *model) cycleRemoteIndex() {
+	m.modelNotice = ""
+	if len(m.indexes) == 0 {
+		m.modelNotice = "Add texts with ember vectors upsert, or set EMBER_QDRANT_URL, EMBER_WEAVIATE_URL or EMBER_PINECONE_HOST, to compare against a vector database"
+		return
+	}
+
+	next := 0
+	for i, index := range m.indexes {
+		if index == m.remote {
+			next = i + 1
+		}
+	}
+	m.remote = nil
+	if next < len(m.indexes) {
+		m.remote = m.indexes[next]
+	}
+}
+
+// generateWithRemote embeds text and compares it with its nearest texts in
+// the remote index instead of the comparison set
+func (m model) generateWithRemote(text string) tea.Cmd {
+	info := m.provider.ModelInfo()
+	provider := m.interactiveProvider()
+	index := m.remote
+	return func() tea.Msg {
+		embedding, err := provider.GenerateEmbedding(text)
+		if err != nil {
+			return embeddingCompleteMsg{text: text, err: err}
+		}
+		hits, err := index.nearest(info, embedding)
+		if err == nil && len(hits) == 0 {
+			err = fmt.Errorf("%s has no texts embedded with %s", index.label(), info.Model)
+		}
+		if err != nil {
+			return embeddingCompleteMsg{text: text, err: err, index: index.label()}
+		}
+		return embeddingCompleteMsg{
+			embedding:   embedding,
+			text:        text,
+			model:       info,
+			comparisons: hits,
+			index:       index.label(),
+		}
+	}
+}
+
+// renderRemoteStatus shows which vector database comparisons go to
+func (m model) renderRemoteStatus() string {
+	if m.remote == nil {
+		return ""
+	}
+	next := "the comparison set"
+	for i, index := range m.indexes {
+		if index == m.remote && i+1 < len(m.indexes) {
+			next = m.indexes[i+1].label()
+		}
+	}
+	return lipgloss.NewStyle().
+		Foreground(theme.Accent).
+		Bold(true).
+		Render(fmt.Sprintf("🔎 Comparing against the nearest texts in %s • Alt+Q for %s", m.remote.label(), next)) + "\n\n"
+}
+
+// runRemoteUpsert handles `ember <command> upsert`: it upserts a saved set's
+// vectors as they are, or embeds every non-blank line of a file batch by
+// batch, so files too large to hold in memory as vectors can be loaded
+func runRemoteUpsert(s remoteStore, command string, args []string) error {
+	fs := flag.NewFlagSet(command+" upsert", flag.ExitOnError)
+	name := fs.String("set", "", "saved set to upsert, reusing its vectors")
+	batch := fs.Int("batch", defaultRemoteBatch, "texts embedded and upserted per request")
+	yes := fs.Bool("yes", false, "start without previewing a sample first")
+	dryRun := fs.Bool("dry-run", false, "report what would be embedded without calling the provider")
+	redact := fs.Bool("redact", loadRedaction(), "store a hash and the #tags of each text instead of the text")
+	fs.Parse(args)
+	if *redact {
+		if err := setupRedaction(); err != nil {
+			return err
+		}
+	}
+
+	if *name != "" {
+		dataDir, err := userDataDir()
+		if err != nil {
+			return err
+		}
+		store, closeStore, err := openStore(dataDir)
+		if err != nil {
+			return err
+		}
+		defer closeStore()
+		set, err := store.LoadSet(*name)
+		if err != nil {
+			return err
+		}
+		if len(set.Embeddings) == 0 {
+			return fmt.Errorf("set %q has no embeddings", set.Name)
+		}
+		if *dryRun {
+			fmt.Printf("🧪 Dry run • would upsert %d texts from %q into %s, reusing their vectors, so nothing would be embedded\n", len(set.Embeddings), set.Name, s.label())
+			return nil
+		}
+		if err := s.ensureCollection(len(set.Embeddings[0].Embedding)); err != nil {
+			return err
+		}
+		embeddings := set.Embeddings
+		if *redact {
+			embeddings = redactEmbeddings(embeddings)
+		}
+		if err := s.upsert(set.Model, embeddings); err != nil {
+			return err
+		}
+		fmt.Printf("📤 Upserted %d texts from %q into %s\n", len(set.Embeddings), set.Name, s.label())
+		return nil
+	}
+
+	if fs.NArg() != 1 || *batch < 1 {
+		return fmt.Errorf("usage: ember %s upsert [--redact] [--set NAME | [--batch N] [--yes] [--dry-run] FILE]", command)
+	}
+	f, err := os.Open(fs.Arg(0))
+	if err != nil {
+		return fmt.Errorf("failed to open %s: %w", fs.Arg(0), err)
+	}
+	defer f.Close()
+
+	var provider EmbeddingProvider = setupProvider()
+	defer closeProvider(provider)
+	info := provider.ModelInfo()
+	inputType := ""
+	if typed, ok := provider.(inputTypeProvider); ok && len(typed.InputTypes()) > 0 {
+		inputType = typed.InputTypes()[0]
+	}
+	provider = withInputType(provider, inputType)
+	if cache := setupCache(); cache != nil {
+		defer cache.Close()
+		provider = &cachedProvider{EmbeddingProvider: provider, cache: cache, model: cacheModelKey(info, inputType)}
+	}
+
+	if *dryRun {
+		plan := newEmbeddingPlan(info)
+		scanner := bufio.NewScanner(f)
+		scanner.Buffer(make([]byte, 0, 64*1024), 1024*1024)
+		for scanner.Scan() {
+			if text := strings.TrimSpace(scanner.Text()); text != "" {
+				plan.add(provider, text)
+			}
+		}
+		if err := scanner.Err(); err != nil {
+			return fmt.Errorf("failed to read %s: %w", fs.Arg(0), err)
+		}
+		fmt.Print(plan.render())
+		return nil
+	}
+
+	// The file is read once up front to sample it, then again to upsert
+	if !*yes {
+		sampler := newCorpusSampler()
+		scanner := bufio.NewScanner(f)
+		scanner.Buffer(make([]byte, 0, 64*1024), 1024*1024)
+		for scanner.Scan() {
+			if text := strings.TrimSpace(scanner.Text()); text != "" {
+				sampler.add(text)
+			}
+		}
+		if err := scanner.Err(); err != nil {
+			return fmt.Errorf("failed to read %s: %w", fs.Arg(0), err)
+		}
+		ok, err := confirmBatchJob(provider, sampler)
+		if err != nil || !ok {
+			return err
+		}
+		if _, err := f.Seek(0, io.SeekStart); err != nil {
+			return fmt.Errorf("failed to rewind %s: %w", fs.Arg(0), err)
+		}
+	}
+
+	// Texts upserted by an interrupted run over the same file are skipped
+	destination := s.label()
+	if local, ok := s.(*vectorStore); ok {
+		destination = local.dir
+	}
+	resume, err := openBatchResume(command+"-upsert", fs.Arg(0), destination, cacheModelKey(info, inputType), strconv.FormatBool(*redact))
+	if err != nil {
+		return err
+	}
+	defer resume.close()
+	skip := resume.done
+
+	upserted, ensured := skip, false
+	texts := make([]string, 0, *batch)
+	flush := func() error {
+		if len(texts) == 0 {
+			return nil
+		}
+		vectors, err := provider.GenerateBatch(texts)
+		if err != nil {
+			return fmt.Errorf("failed to embed texts %d-%d: %w", upserted+1, upserted+len(texts), err)
+		}
+		if !ensured {
+			if err := s.ensureCollection(len(vectors[0])); err != nil {
+				return err
+			}
+			ensured = true
+		}
+		embeddings := make([]CustomEmbedding, len(texts))
+		for i := range texts {
+			embeddings[i] = CustomEmbedding{Text: texts[i], Embedding: vectors[i]}
+		}
+		if *redact {
+			embeddings = redactEmbeddings(embeddings)
+		}
+		if err := s.upsert(info, embeddings); err != nil {
+			return err
+		}
+		if err := resume.record(len(texts)); err != nil {
+			return err
+		}
+		upserted += len(texts)
+		texts = texts[:0]
+		fmt.Fprintf(os.Stderr, "📤 %d upserted\n", upserted)
+		return nil
+	}
+
+	if skip > 0 {
+		fmt.Fprintf(os.Stderr, "⏯  Resuming • skipping %d texts upserted by an earlier run\n", skip)
+	}
+	resumeHint := func(err error) error {
+		if upserted > 0 {
+			return fmt.Errorf("%w • %d texts were upserted, run the same command again to resume", err, upserted)
+		}
+		return err
+	}
+	scanner := bufio.NewScanner(f)
+	scanner.Buffer(make([]byte, 0, 64*1024), 1024*1024)
+	for scanner.Scan() {
+		if text := strings.TrimSpace(scanner.Text()); text != "" {
+			if skip > 0 {
+				skip--
+				continue
+			}
+			texts = append(texts, text)
+		}
+		if len(texts) == *batch {
+			if err := flush(); err != nil {
+				return resumeHint(err)
+			}
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return fmt.Errorf("failed to read %s: %w", fs.Arg(0), err)
+	}
+	if err := flush(); err != nil {
+		return resumeHint(err)
+	}
+	resume.finish()
+	fmt.Printf("📤 Upserted %d texts from %s into %s with %s\n", upserted, filepath.Base(fs.Arg(0)), s.label(), info.Model)
+	return nil
+}
+
+// runRemoteSearch handles `ember <command> search`, printing the texts most
+// similar to a query, filtered the way `ember search` filters a set
+func runRemoteSearch(s remoteStore, command string, limit int, args []string) error {
+	fs := flag.NewFlagSet(command+" search", flag.ExitOnError)
+	k := fs.Int("k", limit, "number of results")
+	threshold := fs.Float64("threshold", -1, "leave out results scoring below this")
+	filter := fs.String("filter", "", `only search texts matching every word: "#tag" needs the tag, other words must appear in the text`)
+	format := formatFlag(fs, "table")
+	fs.Parse(args)
+
+	query := strings.TrimSpace(strings.Join(fs.Args(), " "))
+	if query == "" || *k < 1 {
+		return fmt.Errorf("usage: ember %s search [-k N] [--threshold SCORE] [--filter \"#tag word\"] [--format table|json|ndjson|csv] QUERY", command)
+	}
+	checkFormat(format)
+
+	provider := setupProvider()
+	defer closeProvider(provider)
+	queryType := ""
+	if typed, ok := provider.(inputTypeProvider); ok && len(typed.InputTypes()) > 0 {
+		queryType = queryInputTypeFor(typed.InputTypes()[0])
+	}
+	embedding, err := withInputType(provider, queryType).GenerateEmbedding(query)
+	if err != nil {
+		return err
+	}
+
+	info := provider.ModelInfo()
+	hits, scores, err := searchFiltered(s, info, embedding, *k, strings.Fields(*filter))
+	if err != nil {
+		return err
+	}
+	// Collections ember creates are indexed by cosine distance
+	out := newRankedOutput(info, len(embedding), "cosine", query)
+	for i, hit := range hits {
+		if scores[i] < *threshold {
+			// Hits are best first, so the rest score lower still
+			break
+		}
+		out.add(scores[i], hit.Text, "")
+	}
+	return out.write(*format)
+}
+
+// searchFiltered returns the k texts from model most similar to vector that
+// match every filter, best first. The store ranks its texts without the
+// filter, so it's asked for more of them until k match, it has no more, or
+// maxFilteredSearch have been checked.
+func searchFiltered(s remoteStore, model ModelInfo, vector []float64, k int, filters []string) ([]CustomEmbedding, []float64, error) {
+	if len(filters) == 0 {
+		return s.search(model, vector, k)
+	}
+	for fetch := k; ; fetch = min(fetch*4, maxFilteredSearch) {
+		hits, scores, err := s.search(model, vector, fetch)
+		if err != nil {
+			return nil, nil, err
+		}
+		var matched []CustomEmbedding
+		var matchedScores []float64
+		for i, hit := range hits {
+			if matchesFilters(hit.Text, filters) {
+				matched = append(matched, hit)
+				matchedScores = append(matchedScores, scores[i])
+			}
+		}
+		if len(matched) >= k || len(hits) < fetch || fetch >= maxFilteredSearch {
+			return matched[:min(k, len(matched))], matchedScores[:min(k, len(matched))], nil
+		}
+	}
+}
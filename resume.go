@@ -0,0 +1,185 @@
+package main
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strconv"
+)
+
+// batchResume records how far a batch command got, in a file kept until the
+// command finishes, so running it again after an interruption or a failed
+// request carries on instead of starting over. Commands that write their
+// output at the end keep the vectors themselves; ones that write as they
+// go, like upserts, keep only a count.
+type batchResume struct {
+	path string
+	f    *os.File
+	// done counts the texts already handled, and vectors holds theirs when
+	// they were kept
+	done    int
+	vectors [][]float64
+}
+
+// resumeBatch is one line of a resume file: a batch's vectors, or its size
+type resumeBatch struct {
+	Count   int         `json:"count,omitempty"`
+	Vectors [][]float64 `json:"vectors,omitempty"`
+}
+
+// openBatchResume opens the progress of command over the file input, for
+// the run described by key: the model, the output and anything else that
+// changes what's written. Changing the input or any part of key starts
+// over. Reading stdin can't be resumed, so it gets a resume that records
+// nothing.
+func openBatchResume(command, input string, key ...string) (*batchResume, error) {
+	if input == "-" {
+		return &batchResume{}, nil
+	}
+	abs, err := filepath.Abs(input)
+	if err != nil {
+		return nil, fmt.Errorf("failed to resolve %s: %w", input, err)
+	}
+	info, err := os.Stat(abs)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open %s: %w", input, err)
+	}
+	dir, err := os.UserCacheDir()
+	if err != nil {
+		return nil, fmt.Errorf("failed to find cache directory: %w", err)
+	}
+	h := sha256.New()
+	for _, part := range append([]string{command, abs, strconv.FormatInt(info.Size(), 10), info.ModTime().UTC().String()}, key...) {
+		h.Write([]byte(part + "\x00"))
+	}
+	r := &batchResume{path: filepath.Join(dir, "ember", "resume", command+"-"+hex.EncodeToString(h.Sum(nil)[:8])+".jsonl")}
+	if err := os.MkdirAll(filepath.Dir(r.path), 0o755); err != nil {
+		return nil, fmt.Errorf("failed to create resume directory: %w", err)
+	}
+
+	data, err := os.ReadFile(r.path)
+	if err != nil && !errors.Is(err, os.ErrNotExist) {
+		return nil, fmt.Errorf("failed to read %s: %w", r.path, err)
+	}
+	// A line cut short by an interruption, and anything after it, is dropped
+	valid := 0
+	for valid < len(data) {
+		end := bytes.IndexByte(data[valid:], '\n')
+		if end < 0 {
+			break
+		}
+		var batch resumeBatch
+		if readResumeLine(data[valid:valid+end], &batch) != nil {
+			break
+		}
+		r.done += batch.Count + len(batch.Vectors)
+		r.vectors = append(r.vectors, batch.Vectors...)
+		valid += end + 1
+	}
+	if r.f, err = os.OpenFile(r.path, os.O_WRONLY|os.O_CREATE, 0o644); err == nil {
+		err = r.f.Truncate(int64(valid))
+		if err == nil {
+			_, err = r.f.Seek(int64(valid), 0)
+		}
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to open %s: %w", r.path, err)
+	}
+	return r, nil
+}
+
+// readResumeLine decodes a line, which is sealed and base64-encoded when
+// encryption at rest is on
+func readResumeLine(line []byte, batch *resumeBatch) error {
+	if len(line) > 0 && line[0] != '{' {
+		sealed, err := base64.StdEncoding.DecodeString(string(line))
+		if err != nil {
+			return err
+		}
+		if line, err = openAtRest(sealed); err != nil {
+			return err
+		}
+	}
+	return json.Unmarshal(line, batch)
+}
+
+// record notes that count more texts were handled
+func (r *batchResume) record(count int) error {
+	r.done += count
+	return r.write(resumeBatch{Count: count})
+}
+
+// write appends a line to the resume file
+func (r *batchResume) write(batch resumeBatch) error {
+	if r.f == nil {
+		return nil
+	}
+	line, err := json.Marshal(batch)
+	if err != nil {
+		return fmt.Errorf("failed to save progress: %w", err)
+	}
+	if atRest != nil {
+		line = []byte(base64.StdEncoding.EncodeToString(sealAtRest(line)))
+	}
+	if _, err := r.f.Write(append(line, '\n')); err != nil {
+		return fmt.Errorf("failed to save progress: %w", err)
+	}
+	return nil
+}
+
+// embed returns the vectors of texts, which follow the first offset texts
+// of the run. Texts embedded by an earlier run get the vectors it kept; the
+// rest are embedded with provider and kept.
+func (r *batchResume) embed(provider EmbeddingProvider, offset int, texts []string) ([][]float64, error) {
+	vectors := make([][]float64, 0, len(texts))
+	for i := offset; i < len(r.vectors) && len(vectors) < len(texts); i++ {
+		vectors = append(vectors, r.vectors[i])
+	}
+	rest := texts[len(vectors):]
+	if len(rest) == 0 {
+		return vectors, nil
+	}
+	fresh, err := provider.GenerateBatch(rest)
+	if err == nil && len(fresh) != len(rest) {
+		err = fmt.Errorf("expected %d embeddings, got %d", len(rest), len(fresh))
+	}
+	if err != nil {
+		return nil, err
+	}
+	if offset+len(vectors) == len(r.vectors) {
+		r.vectors = append(r.vectors, fresh...)
+		r.done += len(fresh)
+		if err := r.write(resumeBatch{Vectors: fresh}); err != nil {
+			return nil, err
+		}
+	}
+	return append(vectors, fresh...), nil
+}
+
+// close keeps the progress for the next run
+func (r *batchResume) close() {
+	if r.f != nil {
+		r.f.Close()
+	}
+}
+
+// finish removes the progress once the command has succeeded
+func (r *batchResume) finish() {
+	if r.f != nil {
+		r.f.Close()
+		os.Remove(r.path)
+	}
+}
+
+// noteResumed says on stderr how many texts an earlier run already handled
+func (r *batchResume) noteResumed(total int, what string) {
+	if r.done > 0 {
+		fmt.Fprintf(os.Stderr, "⏯  Resuming • %d of %d texts were %s by an earlier run\n", min(r.done, total), total, what)
+	}
+}
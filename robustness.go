@@ -0,0 +1,137 @@
+package main
+
+import (
+	"fmt"
+	"math"
+	"math/rand/v2"
+	"slices"
+
+	"github.com/charmbracelet/lipgloss"
+)
+
+// Noise levels tried, as the noise vector's expected length relative to the input's
+var noiseLevels = []float64{0.01, 0.02, 0.05, 0.1, 0.2, 0.5}
+
+// Noisy copies of the input scored at each level
+const noiseTrials = 200
+
+// A level where the top match changes in more trials than this counts as brittle
+const brittleFlipRate = 0.1
+
+// noiseResult is how the ranking held up at one noise level
+type noiseResult struct {
+	Level float64
+	// TopKept and RankingKept are the share of trials where the best match,
+	// and the whole ranking, stayed the same
+	TopKept     float64
+	RankingKept float64
+	// MeanShift is the average absolute change in score
+	MeanShift float64
+}
+
+// noiseRobustness adds Gaussian noise to input and reports how the ranking
+// of comparisons changes at each level. The seed is fixed so the report is
+// the same every time it's opened for the same vectors.
+func noiseRobustness(input []float64, comparisons []CustomEmbedding, levels []float64, trials int) []noiseResult {
+	if len(input) == 0 || len(comparisons) == 0 {
+		return nil
+	}
+
+	base := make([]float64, len(comparisons))
+	for i, c := range comparisons {
+		base[i] = cosineSimilarity(input, c.Embedding)
+	}
+	baseRanks := scoreRanks(base)
+	baseTop := slices.Index(baseRanks, 1)
+
+	// Per-dimension deviation that gives noise of the requested relative length
+	scale := vectorNorm(input) / math.Sqrt(float64(len(input)))
+	rng := rand.New(rand.NewPCG(1, 2))
+
+	results := make([]noiseResult, len(levels))
+	noisy := make([]float64, len(input))
+	scores := make([]float64, len(comparisons))
+	for l, level := range levels {
+		r := noiseResult{Level: level}
+		for t := 0; t < trials; t++ {
+			for d, v := range input {
+				noisy[d] = v + rng.NormFloat64()*level*scale
+			}
+			for i, c := range comparisons {
+				scores[i] = cosineSimilarity(noisy, c.Embedding)
+				r.MeanShift += math.Abs(scores[i] - base[i])
+			}
+
+			ranks := scoreRanks(scores)
+			if slices.Index(ranks, 1) == baseTop {
+				r.TopKept++
+			}
+			if slices.Equal(ranks, baseRanks) {
+				r.RankingKept++
+			}
+		}
+		r.TopKept /= float64(trials)
+		r.RankingKept /= float64(trials)
+		r.MeanShift /= float64(trials * len(comparisons))
+		results[l] = r
+	}
+	return results
+}
+
+// openRobustnessScreen runs the noise test on the comparison just shown
+func (m *model) openRobustnessScreen() {
+	m.robustness = noiseRobustness(m.lastEmbedding, m.comparedEmbeddings, noiseLevels, noiseTrials)
+	m.currentScreen = robustnessScreen
+}
+
+func (m model) renderRobustnessScreen() string {
+	s := "\033[2J\033[H" // Clear screen and move cursor to top
+
+	s += "╭─────────────────────────────────────────────────────────────────────────────╮\n"
+	s += "│                          🌀 NOISE ROBUSTNESS 🌀                             │\n"
+	s += "╰─────────────────────────────────────────────────────────────────────────────╯\n\n"
+
+	headerStyle := lipgloss.NewStyle().
+		Foreground(theme.Primary).
+		Bold(true)
+	warningStyle := lipgloss.NewStyle().
+		Foreground(theme.Warning)
+	instructStyle := lipgloss.NewStyle().
+		Foreground(theme.Muted).
+		Italic(true)
+
+	if len(m.robustness) == 0 {
+		return s + instructStyle.Render("Nothing to test • compare an input first • Esc to return") + "\n"
+	}
+
+	s += fmt.Sprintf("Input: %s\n", userInputStyle.Render(truncateText(m.lastInput, 60)))
+	s += instructStyle.Render(fmt.Sprintf("Gaussian noise added to the input vector, %d trials per level, against %d comparison texts", noiseTrials, len(m.comparedEmbeddings))) + "\n\n"
+
+	s += headerStyle.Render(fmt.Sprintf("%7s %16s %16s %14s", "noise", "top match kept", "ranking kept", "mean |Δscore|")) + "\n"
+	brittleAt := 0.0
+	for _, r := range m.robustness {
+		line := fmt.Sprintf("%6.0f%% %15.0f%% %15.0f%% %14.4f", 100*r.Level, 100*r.TopKept, 100*r.RankingKept, r.MeanShift)
+		if 1-r.TopKept > brittleFlipRate {
+			line = warningStyle.Render(line)
+			if brittleAt == 0 {
+				brittleAt = r.Level
+			}
+		}
+		s += line + "\n"
+	}
+
+	s += "\n"
+	switch {
+	case len(m.comparedEmbeddings) < 2:
+		s += instructStyle.Render("Add more comparison texts to see how the ranking holds up") + "\n"
+	case brittleAt == 0:
+		s += headerStyle.Render(fmt.Sprintf("✅ The top match held in at least %.0f%% of trials at every level", 100*(1-brittleFlipRate))) + "\n"
+	case brittleAt <= 0.05:
+		s += warningStyle.Render(fmt.Sprintf("⚠️  Brittle • %.0f%% noise already changes the top match in over %.0f%% of trials", 100*brittleAt, 100*brittleFlipRate)) + "\n"
+	default:
+		s += headerStyle.Render(fmt.Sprintf("The top match starts to change at %.0f%% noise", 100*brittleAt)) + "\n"
+	}
+
+	s += instructStyle.Render("Noise is relative to the input vector's length • Press Enter or Esc to return to results") + "\n"
+	return s
+}
@@ -0,0 +1,189 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"slices"
+	"sort"
+	"strings"
+)
+
+// Results `ember search` prints unless -k says otherwise
+const defaultSearchResults = 10
+
+// runSearchCommand prints the texts of a set or an embeddings file most
+// similar to a query. With Postgres a set is ranked by pgvector; everything
+// else is scored in memory.
+func runSearchCommand(args []string) {
+	fs := flag.NewFlagSet("search", flag.ExitOnError)
+	name := fs.String("set", "", "set to search (default: the set opened on start)")
+	file := fs.String("file", "", "JSONL, Parquet, .npy or .npz file of embeddings to search instead of a set")
+	k := fs.Int("k", defaultSearchResults, "number of results")
+	threshold := fs.Float64("threshold", -1, "leave out results scoring below this")
+	filter := fs.String("filter", "", `only search texts matching every word: "#tag" needs the tag, other words must appear in the text`)
+	format := formatFlag(fs, "table")
+	fs.Parse(args)
+
+	query := strings.TrimSpace(strings.Join(fs.Args(), " "))
+	if query == "" || *k < 1 || (*name != "" && *file != "") {
+		fmt.Println("Usage: ember search [--set NAME | --file FILE] [-k N] [--threshold SCORE] [--filter \"#tag word\"] [--format table|json|ndjson|csv] QUERY")
+		os.Exit(2)
+	}
+	checkFormat(format)
+
+	var set comparisonSet
+	var store corpusStore
+	var label string
+	streamed := *file != "" && strings.EqualFold(filepath.Ext(*file), ".parquet")
+	if streamed {
+		// Parquet files are scanned after the query is embedded, keeping only
+		// the best k rows; the first row gives the model to check against
+		var err error
+		if set.Model, err = scanParquetFile(*file, func(CustomEmbedding) error { return io.EOF }); err != nil {
+			displayError(err)
+			os.Exit(1)
+		}
+		set.Name = *file
+		label = *file
+	} else if *file != "" {
+		var err error
+		if set.Model, set.Embeddings, err = readImportFile(*file); err != nil {
+			displayError(err)
+			os.Exit(1)
+		}
+		set.Name = *file
+		label = *file
+	} else {
+		dataDir, err := userDataDir()
+		if err != nil {
+			displayError(err)
+			os.Exit(1)
+		}
+		var closeStore func()
+		store, closeStore, err = openStore(dataDir)
+		if err != nil {
+			displayError(err)
+			os.Exit(1)
+		}
+		defer closeStore()
+
+		if *name == "" {
+			set, err = openStartupSet(store, filepath.Join(dataDir, "sets"))
+		} else {
+			set, err = store.LoadSet(*name)
+		}
+		if err != nil {
+			displayError(err)
+			os.Exit(1)
+		}
+		label = fmt.Sprintf("set %q", set.Name)
+	}
+
+	provider := setupProvider()
+	defer closeProvider(provider)
+	info := provider.ModelInfo()
+	// NumPy files don't record a model, so only their dimensions can be checked
+	if set.Model.Model != "" && (set.Model.Provider != info.Provider || set.Model.Model != info.Model) {
+		displayError(fmt.Errorf("%s was embedded with %s/%s, not %s/%s", label, set.Model.Provider, set.Model.Model, info.Provider, info.Model))
+		os.Exit(1)
+	}
+
+	embedding, err := provider.GenerateEmbedding(query)
+	if err != nil {
+		displayError(err)
+		os.Exit(1)
+	}
+	if set.Model.Model == "" && len(set.Embeddings) > 0 && len(set.Embeddings[0].Embedding) != len(embedding) {
+		displayError(fmt.Errorf("%s holds %d-dimensional vectors, but %s returns %d", label, len(set.Embeddings[0].Embedding), info.Model, len(embedding)))
+		os.Exit(1)
+	}
+
+	var matches []storedMatch
+	if streamed {
+		matches, err = searchParquetFile(*file, embedding, *k, strings.Fields(*filter))
+	} else {
+		matches, err = searchSet(store, set, embedding, *k, strings.Fields(*filter))
+	}
+	if err != nil {
+		displayError(err)
+		os.Exit(1)
+	}
+	out := newRankedOutput(info, len(embedding), "cosine", query)
+	for _, match := range matches {
+		if match.Similarity < *threshold {
+			// Matches are best first, so the rest score lower still
+			break
+		}
+		out.add(match.Similarity, match.Text, "")
+	}
+	if err := out.write(*format); err != nil {
+		displayError(err)
+		os.Exit(1)
+	}
+}
+
+// searchSet returns the k texts of set most similar to embedding, best
+// first, among those matching every filter
+func searchSet(store corpusStore, set comparisonSet, embedding []float64, k int, filters []string) ([]storedMatch, error) {
+	if pg, ok := pgBacked(store); ok && !set.Saved.IsZero() && set.Bundle == "" && len(filters) == 0 {
+		return pg.Nearest(set.Name, embedding, k)
+	}
+
+	var matches []storedMatch
+	for i, e := range set.Embeddings {
+		if len(e.Embedding) != len(embedding) || !matchesFilters(e.Text, filters) {
+			continue
+		}
+		matches = append(matches, storedMatch{Position: i, Text: e.Text, Similarity: cosineSimilarity(embedding, e.Embedding)})
+	}
+	sort.SliceStable(matches, func(i, j int) bool { return matches[i].Similarity > matches[j].Similarity })
+	return matches[:min(k, len(matches))], nil
+}
+
+// searchParquetFile is searchSet for a Parquet file, read a batch at a time
+// so that only the best k matches are held
+func searchParquetFile(path string, embedding []float64, k int, filters []string) ([]storedMatch, error) {
+	matches := make([]storedMatch, 0, k+1)
+	position := 0
+	_, err := scanParquetFile(path, func(e CustomEmbedding) error {
+		i := position
+		position++
+		if len(e.Embedding) != len(embedding) || !matchesFilters(e.Text, filters) {
+			return nil
+		}
+		similarity := cosineSimilarity(embedding, e.Embedding)
+		// Ties keep file order, as the stable sort in searchSet does
+		at := sort.Search(len(matches), func(j int) bool { return matches[j].Similarity < similarity })
+		if at == k {
+			return nil
+		}
+		matches = slices.Insert(matches, at, storedMatch{Position: i, Text: e.Text, Similarity: similarity})
+		if len(matches) > k {
+			matches = matches[:k]
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return matches, nil
+}
+
+// matchesFilters reports whether text has every #tag in filters and
+// contains every other word, ignoring case
+func matchesFilters(text string, filters []string) bool {
+	lower := strings.ToLower(text)
+	for _, filter := range filters {
+		if strings.HasPrefix(filter, "#") && len(filter) > 1 {
+			if !slices.Contains(textTags(text), strings.ToLower(filter[1:])) {
+				return false
+			}
+		} else if !strings.Contains(lower, strings.ToLower(filter)) {
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,289 @@
+package main
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"flag"
+	"fmt"
+	"net"
+	"os"
+	"os/signal"
+	"path/filepath"
+	"strings"
+	"sync"
+	"syscall"
+	"time"
+
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/lipgloss"
+	"github.com/charmbracelet/ssh"
+	"github.com/charmbracelet/wish"
+	"github.com/charmbracelet/wish/activeterm"
+	bm "github.com/charmbracelet/wish/bubbletea"
+	"github.com/charmbracelet/wish/logging"
+	"github.com/muesli/termenv"
+)
+
+// runServeCommand serves the TUI over SSH. Every connection gets its own
+// session; the provider, its keys and the embedding cache are shared.
+func runServeCommand(args []string) {
+	fs := flag.NewFlagSet("serve", flag.ExitOnError)
+	addr := fs.String("ssh", "localhost:2222", "address to serve the TUI over SSH on")
+	hostKey := fs.String("host-key", "", "SSH host key, created if missing (default: ember/ssh_host_ed25519 in the config directory)")
+	authorizedKeys := fs.String("authorized-keys", "", "only accept the public keys in this authorized_keys file (default: ~/.ssh/authorized_keys)")
+	allowAnyKey := fs.Bool("allow-any-key", false, "accept any public key; each key still gets its own data")
+	fs.Parse(args)
+
+	// Nobody gets in with an unknown key unless that was asked for
+	if *authorizedKeys == "" && !*allowAnyKey {
+		if home, err := os.UserHomeDir(); err == nil {
+			path := filepath.Join(home, ".ssh", "authorized_keys")
+			if _, err := os.Stat(path); err == nil {
+				*authorizedKeys = path
+			}
+		}
+		if *authorizedKeys == "" {
+			displayError(errors.New("no authorized_keys file: pass --authorized-keys, or --allow-any-key to let anyone with an SSH key in"))
+			os.Exit(2)
+		}
+	}
+
+	configDir, err := os.UserConfigDir()
+	if err != nil {
+		displayError(fmt.Errorf("failed to find config directory: %w", err))
+		os.Exit(1)
+	}
+	if *hostKey == "" {
+		*hostKey = filepath.Join(configDir, "ember", "ssh_host_ed25519")
+	}
+	if err := os.MkdirAll(filepath.Dir(*hostKey), 0o700); err != nil {
+		displayError(fmt.Errorf("failed to create host key directory: %w", err))
+		os.Exit(1)
+	}
+
+	t, err := loadTheme()
+	if err != nil {
+		displayError(err)
+		os.Exit(1)
+	}
+	applyTheme(t)
+	// Styles render with the server's renderer, which sees no terminal
+	lipgloss.SetColorProfile(termenv.ANSI256)
+
+	processors, err := loadScoreProcessors()
+	if err != nil {
+		displayError(err)
+		os.Exit(1)
+	}
+
+	chunking, err := loadChunkOptions()
+	if err != nil {
+		displayError(err)
+		os.Exit(1)
+	}
+
+	sparse, err := loadSparseOptions()
+	if err != nil {
+		displayError(err)
+		os.Exit(1)
+	}
+
+	fieldWeights, err := loadFieldWeights()
+	if err != nil {
+		displayError(err)
+		os.Exit(1)
+	}
+
+	summary, err := loadSummaryOptions()
+	if err != nil {
+		displayError(err)
+		os.Exit(1)
+	}
+
+	pg, err := openPgStore()
+	if err != nil {
+		displayError(err)
+		os.Exit(1)
+	}
+
+	indexes, err := loadRemoteIndexes()
+	if err != nil {
+		displayError(err)
+		os.Exit(1)
+	}
+
+	spillRows, err := loadSpillRows()
+	if err != nil {
+		displayError(err)
+		os.Exit(1)
+	}
+
+	provider := setupProvider()
+	secondary, err := loadSecondaryProvider()
+	if err != nil {
+		closeProvider(provider)
+		displayError(err)
+		os.Exit(1)
+	}
+
+	cfg := sessionConfig{
+		provider:   provider,
+		secondary:  secondary,
+		processors: processors,
+		chunking:   chunking,
+		sparse:     sparse,
+		fields:     fieldWeights,
+		summary:    summary,
+		pg:         pg,
+		indexes:    indexes,
+		spillRows:  spillRows,
+	}
+	cfg.bundles, cfg.bundleErr = loadPresetBundles()
+	defer cfg.close()
+
+	caches := newUserCaches()
+	defer caches.close()
+
+	_, port, _ := net.SplitHostPort(*addr)
+	shares := newShareHub(port)
+
+	// Clients are told apart by their public key alone, so nobody can open
+	// another's data by picking the same user name
+	auth := wish.WithAuthorizedKeys(*authorizedKeys)
+	if *allowAnyKey && *authorizedKeys == "" {
+		auth = wish.WithPublicKeyAuth(func(ssh.Context, ssh.PublicKey) bool { return true })
+	}
+
+	server, err := wish.NewServer(
+		wish.WithAddress(*addr),
+		wish.WithHostKeyPath(*hostKey),
+		auth,
+		wish.WithMiddleware(
+			bm.Middleware(func(s ssh.Session) (tea.Model, []tea.ProgramOption) {
+				if code, ok := strings.CutPrefix(s.User(), spectatorPrefix); ok {
+					share := shares.find(code)
+					if share == nil {
+						wish.Println(s, "No session is shared as "+code)
+						return nil, nil
+					}
+					spectator := newSpectator(share)
+					go func() {
+						<-s.Context().Done()
+						share.unsubscribe(spectator.ch)
+					}()
+					return spectator, nil
+				}
+
+				dataDir := sshUserDataDir(configDir, s)
+				session := cfg
+				session.cache = caches.get(dataDir)
+				if cfg.pg != nil {
+					session.pg = cfg.pg.forOwner(sshUserID(s))
+				}
+				m := newSession(session, dataDir)
+				m.share = shares.add()
+				go func() {
+					<-s.Context().Done()
+					m.share.End()
+				}()
+				return m, nil
+			}),
+			activeterm.Middleware(),
+			logging.Middleware(),
+		),
+	)
+	if err != nil {
+		displayError(fmt.Errorf("failed to create SSH server: %w", err))
+		os.Exit(1)
+	}
+
+	stop := make(chan os.Signal, 1)
+	signal.Notify(stop, os.Interrupt, syscall.SIGTERM)
+
+	info := provider.ModelInfo()
+	fmt.Printf("🟣 ember serving the TUI over SSH on %s (%s/%s)\n", *addr, info.Provider, info.Model)
+
+	errs := make(chan error, 1)
+	go func() { errs <- server.ListenAndServe() }()
+
+	select {
+	case err = <-errs:
+	case <-stop:
+		ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+		defer cancel()
+		err = server.Shutdown(ctx)
+	}
+	if err != nil && !errors.Is(err, ssh.ErrServerClosed) {
+		displayError(fmt.Errorf("SSH server stopped: %w", err))
+		os.Exit(1)
+	}
+}
+
+// sshUserDataDir keeps each SSH user's comparison sets, history, macro and
+// embedding cache apart, keyed by the fingerprint of their public key
+func sshUserDataDir(configDir string, s ssh.Session) string {
+	return filepath.Join(configDir, "ember", "users", sshUserID(s))
+}
+
+// sshUserID is the short fingerprint of an SSH user's public key, which also
+// owns their sets and history when those are kept in Postgres
+func sshUserID(s ssh.Session) string {
+	if key := s.PublicKey(); key != nil {
+		sum := sha256.Sum256(key.Marshal())
+		return hex.EncodeToString(sum[:8])
+	}
+	return "anonymous"
+}
+
+// userCaches opens one embedding cache per SSH user and shares it between
+// that user's sessions, so what one user embeds never shows up as another's
+// cache hit
+type userCaches struct {
+	mu     sync.Mutex
+	caches map[string]*EmbeddingCache
+	opts   cacheOptions
+	off    bool
+}
+
+func newUserCaches() *userCaches {
+	u := &userCaches{caches: make(map[string]*EmbeddingCache), off: os.Getenv("EMBER_NO_CACHE") != ""}
+
+	opts, err := loadCacheOptions()
+	if err != nil {
+		fmt.Printf("⚠️  Embedding cache disabled: %v\n", err)
+		u.off = true
+	}
+	u.opts = opts
+	return u
+}
+
+// get returns the cache kept in dataDir, or nil when caching is off or fails
+func (u *userCaches) get(dataDir string) *EmbeddingCache {
+	if u.off {
+		return nil
+	}
+
+	u.mu.Lock()
+	defer u.mu.Unlock()
+
+	if cache, ok := u.caches[dataDir]; ok {
+		return cache
+	}
+	cache, err := openEmbeddingCache(filepath.Join(dataDir, "embeddings.db"), u.opts)
+	if err != nil {
+		fmt.Printf("⚠️  Embedding cache disabled for %s: %v\n", filepath.Base(dataDir), err)
+		return nil
+	}
+	u.caches[dataDir] = cache
+	return cache
+}
+
+func (u *userCaches) close() {
+	u.mu.Lock()
+	defer u.mu.Unlock()
+	for _, cache := range u.caches {
+		cache.Close()
+	}
+}
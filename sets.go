@@ -0,0 +1,404 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"maps"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/charmbracelet/bubbles/textinput"
+	"github.com/charmbracelet/lipgloss"
+)
+
+// Name of the bundled example set, written out the first time ember runs
+const exampleSetName = "examples"
+
+// comparisonSet is a saved comparison set: its texts, their vectors and the
+// model that produced them
+type comparisonSet struct {
+	Name       string            `json:"name"`
+	Model      ModelInfo         `json:"model"`
+	Saved      time.Time         `json:"saved"`
+	Embeddings []CustomEmbedding `json:"embeddings"`
+	// Threshold is the score at which a comparison counts as a match, as
+	// tuned by ember tune; 0 when the set hasn't been tuned
+	Threshold float64 `json:"threshold,omitempty"`
+	// Values are what the texts' {{variables}} were resolved with
+	Values map[string]string `json:"values,omitempty"`
+	// Disabled are texts kept with the set but left out of comparisons
+	Disabled []string `json:"disabled,omitempty"`
+	// Bundle names the read-only preset bundle the set came from
+	Bundle string `json:"-"`
+}
+
+// userDataDir is where a local session keeps its comparison sets and
+// history: the project's .ember directory inside a project, otherwise the
+// global data directory
+func userDataDir() (string, error) {
+	if dir := projectDataDir(); dir != "" {
+		return dir, nil
+	}
+	return globalDataDir()
+}
+
+// globalDataDir is ember in the user's config directory, for data shared by every project
+func globalDataDir() (string, error) {
+	dir, err := os.UserConfigDir()
+	if err != nil {
+		return "", fmt.Errorf("failed to find config directory: %w", err)
+	}
+	return filepath.Join(dir, "ember"), nil
+}
+
+// setFileName turns a set name into a safe file name
+func setFileName(name string) string {
+	return safeName(name) + ".json"
+}
+
+// safeName keeps the characters of name that are safe in a file name
+func safeName(name string) string {
+	var b strings.Builder
+	for _, r := range strings.ToLower(strings.TrimSpace(name)) {
+		switch {
+		case r >= 'a' && r <= 'z', r >= '0' && r <= '9', r == '-', r == '_':
+			b.WriteRune(r)
+		case r == ' ' || r == '.':
+			b.WriteRune('-')
+		}
+	}
+	return b.String()
+}
+
+func saveComparisonSet(dir string, set comparisonSet) error {
+	if setFileName(set.Name) == ".json" {
+		return fmt.Errorf("set name %q has no usable characters", set.Name)
+	}
+
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		return fmt.Errorf("failed to create sets directory: %w", err)
+	}
+
+	data, err := json.Marshal(set)
+	if err != nil {
+		return fmt.Errorf("failed to marshal set: %w", err)
+	}
+
+	if err := os.WriteFile(filepath.Join(dir, setFileName(set.Name)), sealAtRest(data), 0o644); err != nil {
+		return fmt.Errorf("failed to write set: %w", err)
+	}
+	return nil
+}
+
+func loadComparisonSet(dir, name string) (comparisonSet, error) {
+	var set comparisonSet
+
+	data, err := os.ReadFile(filepath.Join(dir, setFileName(name)))
+	if err == nil {
+		data, err = openAtRest(data)
+	}
+	if err != nil {
+		return set, fmt.Errorf("failed to read set: %w", err)
+	}
+	if err := json.Unmarshal(data, &set); err != nil {
+		return set, fmt.Errorf("failed to parse set %s: %w", name, err)
+	}
+	return set, nil
+}
+
+// listComparisonSets returns the saved sets, most recently saved first. The
+// vectors are loaded too; sets are small enough that this stays fast.
+func listComparisonSets(dir string) ([]comparisonSet, error) {
+	paths, err := filepath.Glob(filepath.Join(dir, "*.json"))
+	if err != nil {
+		return nil, fmt.Errorf("failed to list sets: %w", err)
+	}
+
+	var sets []comparisonSet
+	for _, path := range paths {
+		data, err := os.ReadFile(path)
+		if err != nil {
+			continue
+		}
+		if data, err = openAtRest(data); err != nil {
+			return nil, fmt.Errorf("failed to read %s: %w", path, err)
+		}
+		var set comparisonSet
+		if json.Unmarshal(data, &set) == nil {
+			sets = append(sets, set)
+		}
+	}
+
+	sort.Slice(sets, func(i, j int) bool { return sets[i].Saved.After(sets[j].Saved) })
+	return sets, nil
+}
+
+// rememberSet records name as the set to open on the next start
+func rememberSet(dir, name string) {
+	os.WriteFile(filepath.Join(dir, ".last"), []byte(name), 0o644)
+}
+
+// exampleSet is the bundled set of example texts and their vectors
+func exampleSet() (comparisonSet, error) {
+	set, err := staticExamples()
+	set.Name = exampleSetName
+	return set, err
+}
+
+// loadStartupSet opens the set used last, as recorded in dir, falling back to
+// the examples. The examples are saved on first run so they show up in the
+// picker like any other set.
+func loadStartupSet(store corpusStore, dir string) comparisonSet {
+	if name, err := os.ReadFile(filepath.Join(dir, ".last")); err == nil {
+		if set, err := store.LoadSet(string(name)); err == nil {
+			return set
+		}
+	}
+
+	set, err := store.LoadSet(exampleSetName)
+	if err != nil {
+		if set, err = exampleSet(); err != nil {
+			return comparisonSet{Name: exampleSetName}
+		}
+		set.Saved = time.Now()
+		store.SaveSet(set)
+	}
+	return set
+}
+
+// openStartupSet opens EMBER_SET when it is set: a saved set's name, or the
+// path of a file `ember import` reads. Otherwise it opens the project's
+// preset, if there is one. When neither is set, or opening fails, it
+// returns loadStartupSet's set, along with the error.
+func openStartupSet(store corpusStore, dir string) (comparisonSet, error) {
+	name := os.Getenv("EMBER_SET")
+	if name == "" {
+		set, ok, err := loadProjectPreset()
+		if !ok {
+			return loadStartupSet(store, dir), err
+		}
+		return set, nil
+	}
+
+	if _, err := os.Stat(name); err == nil {
+		info, embeddings, err := readImportFile(name)
+		if err != nil {
+			return loadStartupSet(store, dir), err
+		}
+		return comparisonSet{Name: importName(name), Model: info, Embeddings: embeddings}, nil
+	}
+
+	set, err := store.LoadSet(name)
+	if err != nil {
+		return loadStartupSet(store, dir), fmt.Errorf("failed to open EMBER_SET %q: %w", name, err)
+	}
+	return set, nil
+}
+
+func newSetNameInput() textinput.Model {
+	ti := textinput.New()
+	ti.Placeholder = "Set name..."
+	ti.CharLimit = 64
+	ti.Width = 40
+	return ti
+}
+
+// openSetsScreen shows the saved sets, or asks for a name to save the current one under
+func (m *model) openSetsScreen(saving bool) {
+	m.currentScreen = setsScreen
+	m.savingSet = saving
+	m.setNotice = ""
+	m.selectedSet = 0
+	m.setsErr = nil
+
+	if saving {
+		m.setNameInput.SetValue(m.activeSet)
+		m.setNameInput.CursorEnd()
+		m.setNameInput.Focus()
+		return
+	}
+	m.setNameInput.Blur()
+	m.savedSets, m.setsErr = m.store.ListSets()
+}
+
+// saveCurrentSet writes the comparison set under the name typed on the sets screen
+func (m *model) saveCurrentSet() {
+	name := strings.TrimSpace(m.setNameInput.Value())
+	if name == "" {
+		m.setNotice = "⚠️  Enter a name for the set"
+		return
+	}
+	if len(m.customEmbeddings) == 0 {
+		m.setNotice = "⚠️  Nothing to save yet • generate the comparison set first"
+		return
+	}
+
+	set := comparisonSet{
+		Name:       name,
+		Model:      m.provider.ModelInfo(),
+		Saved:      time.Now(),
+		Embeddings: m.customEmbeddings,
+		Threshold:  m.matchThreshold,
+		Values:     m.usedTemplateValues(),
+		Disabled:   m.disabledComparisonValues(),
+	}
+	if err := m.store.SaveSet(set); err != nil {
+		m.setNotice = "⚠️  " + err.Error()
+		return
+	}
+
+	m.activeSet = name
+	m.trackStoredSet(set)
+	rememberSet(m.setsDir, name)
+	m.comparisonNotice = fmt.Sprintf("💾 Saved %d comparison texts as %q", len(set.Embeddings), name)
+	m.currentScreen = embeddingsScreen
+}
+
+// loadSelectedSet replaces the comparison set with the one picked on the sets
+// screen, re-embedding it when it was saved with another model
+func (m *model) loadSelectedSet() {
+	if m.selectedSet >= len(m.savedSets) {
+		return
+	}
+	set := m.savedSets[m.selectedSet]
+	m.modelNotice = fmt.Sprintf("📂 Opened %q", set.Name)
+	m.applySet(set)
+	m.trackStoredSet(set)
+	rememberSet(m.setsDir, set.Name)
+	m.currentScreen = inputScreen
+}
+
+// applySet makes set the comparison set
+func (m *model) applySet(set comparisonSet) {
+	texts := make([]string, len(set.Embeddings))
+	for i, e := range set.Embeddings {
+		texts[i] = e.source()
+	}
+
+	m.activeSet = set.Name
+	if len(set.Values) > 0 {
+		m.templateValues = maps.Clone(set.Values)
+	}
+	m.customEmbeddings = set.Embeddings
+	m.matchThreshold = set.Threshold
+	m.setComparisonTextAreas(append(texts, set.Disabled...))
+	m.disableComparisons(set.Disabled)
+	m.embeddingTexts[0].Blur()
+
+	info := m.provider.ModelInfo()
+	if set.Model.Provider != info.Provider || set.Model.Model != info.Model {
+		m.reembedComparisons(info.Model)
+		m.customEmbeddings = nil
+		m.modelNotice = fmt.Sprintf("🔁 %q was saved with %s • re-embedding %d texts", set.Name, set.Model.Model, len(texts))
+		if set.Model.Model == "" {
+			// A preset's texts have no vectors yet, and its threshold is meant for whatever model is in use
+			m.modelNotice = fmt.Sprintf("🔁 Embedding the %d texts of %q", len(texts), set.Name)
+		} else {
+			// The threshold was tuned on the other model's scores
+			m.matchThreshold = 0
+		}
+	}
+}
+
+// cycleSet switches to the next saved set in name order
+func (m *model) cycleSet() {
+	sets, err := m.store.ListSets()
+	if err != nil {
+		m.modelNotice = "⚠️  " + err.Error()
+		return
+	}
+	if len(sets) == 0 {
+		m.modelNotice = "No saved sets yet • Ctrl+S on the configure screen saves one"
+		return
+	}
+	sort.Slice(sets, func(i, j int) bool { return strings.ToLower(sets[i].Name) < strings.ToLower(sets[j].Name) })
+
+	next := 0
+	for i, set := range sets {
+		if set.Name == m.activeSet {
+			next = (i + 1) % len(sets)
+		}
+	}
+
+	m.modelNotice = fmt.Sprintf("📂 Switched to %q", sets[next].Name)
+	m.applySet(sets[next])
+	m.trackStoredSet(sets[next])
+	rememberSet(m.setsDir, sets[next].Name)
+}
+
+// renderActiveSet names the project and comparison set in use, under a screen's header
+func (m model) renderActiveSet() string {
+	var parts []string
+	if m.project != "" {
+		parts = append(parts, "📁 "+m.project)
+	}
+	if m.activeSet != "" {
+		parts = append(parts, fmt.Sprintf("📂 %s • %d comparison texts", m.activeSet, len(m.embeddingTexts)))
+	}
+	if len(parts) == 0 {
+		return ""
+	}
+	return lipgloss.NewStyle().
+		Foreground(theme.Accent).
+		Bold(true).
+		Render(strings.Join(parts, "  ")) + "\n\n"
+}
+
+func (m model) renderSetsScreen() string {
+	s := "\033[2J\033[H" // Clear screen and move cursor to top
+
+	s += "╭─────────────────────────────────────────────────────────────────────────────╮\n"
+	s += "│                          💾 COMPARISON SETS 💾                              │\n"
+	s += "╰─────────────────────────────────────────────────────────────────────────────╯\n\n"
+
+	labelStyle := lipgloss.NewStyle().
+		Foreground(theme.Primary).
+		Bold(true)
+	selectedStyle := lipgloss.NewStyle().
+		Foreground(theme.Accent).
+		Bold(true)
+	instructStyle := lipgloss.NewStyle().
+		Foreground(theme.Muted).
+		Italic(true)
+	warningStyle := lipgloss.NewStyle().
+		Foreground(theme.Warning)
+
+	if m.savingSet {
+		s += labelStyle.Render(fmt.Sprintf("💾 Save %d comparison texts as:", len(m.customEmbeddings))) + "\n\n"
+		s += m.setNameInput.View() + "\n\n"
+		if m.setNotice != "" {
+			s += warningStyle.Render(m.setNotice) + "\n"
+		}
+		s += instructStyle.Render("Enter to save • Ctrl+T to suggest a name • Esc to cancel") + "\n"
+		return s
+	}
+
+	switch {
+	case m.setsErr != nil:
+		s += warningStyle.Render("⚠️  "+m.setsErr.Error()) + "\n"
+	case len(m.savedSets) == 0:
+		s += instructStyle.Render("No saved sets yet • Ctrl+S on the configure screen saves one") + "\n"
+	}
+
+	for i, set := range m.savedSets {
+		line := fmt.Sprintf("%-24s %2d texts  %-32s %s", truncateText(set.Name, 24), len(set.Embeddings),
+			truncateText(set.Model.Provider+"/"+set.Model.Model, 32), set.Saved.Format("2006-01-02 15:04"))
+		if set.Bundle != "" {
+			line += "  🔒 " + set.Bundle
+		}
+		switch {
+		case i == m.selectedSet:
+			s += selectedStyle.Render("▶ "+line) + "\n"
+		case set.Name == m.activeSet:
+			s += labelStyle.Render("• "+line) + "\n"
+		default:
+			s += "  " + line + "\n"
+		}
+	}
+
+	s += "\n" + instructStyle.Render("↑/↓ to select • Enter to open • Esc to return") + "\n"
+	return s
+}
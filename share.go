@@ -0,0 +1,237 @@
+package main
+
+import (
+	"crypto/rand"
+	"encoding/base32"
+	"fmt"
+	"strings"
+	"sync"
+
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/lipgloss"
+)
+
+// SSH user names starting with this prefix join a shared session as a spectator
+const spectatorPrefix = "watch-"
+
+// shareHub tracks the sessions of an SSH server that can be watched
+type shareHub struct {
+	mu     sync.Mutex
+	shares map[string]*liveShare
+	// port is shown in the command spectators connect with
+	port string
+}
+
+func newShareHub(port string) *shareHub {
+	return &shareHub{shares: make(map[string]*liveShare), port: port}
+}
+
+// add gives a session a share code; spectators can only watch once it starts sharing
+func (h *shareHub) add() *liveShare {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	var code string
+	for code == "" || h.shares[code] != nil {
+		b := make([]byte, 5)
+		rand.Read(b)
+		code = strings.ToLower(base32.StdEncoding.WithPadding(base32.NoPadding).EncodeToString(b))
+	}
+
+	s := &liveShare{hub: h, code: code, watchers: make(map[chan string]struct{})}
+	h.shares[code] = s
+	return s
+}
+
+func (h *shareHub) find(code string) *liveShare {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	return h.shares[code]
+}
+
+// liveShare broadcasts one session's screen to its spectators
+type liveShare struct {
+	hub  *shareHub
+	code string
+
+	mu       sync.Mutex
+	sharing  bool
+	ended    bool
+	frame    string
+	watchers map[chan string]struct{}
+}
+
+// Toggle starts or stops sharing and reports whether the session is now shared
+func (s *liveShare) Toggle() bool {
+	s.mu.Lock()
+	s.sharing = !s.sharing
+	sharing := s.sharing
+	// Force the next frame out even if the screen hasn't changed
+	s.frame = ""
+	s.mu.Unlock()
+
+	if !sharing {
+		s.broadcast(pausedFrame)
+	}
+	return sharing
+}
+
+func (s *liveShare) Sharing() bool {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.sharing
+}
+
+func (s *liveShare) Watchers() int {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return len(s.watchers)
+}
+
+// Publish sends the owner's latest screen to spectators while sharing
+func (s *liveShare) Publish(frame string) {
+	s.mu.Lock()
+	if !s.sharing || frame == s.frame {
+		s.mu.Unlock()
+		return
+	}
+	s.frame = frame
+	s.mu.Unlock()
+
+	s.broadcast(frame)
+}
+
+// broadcast replaces any frame a spectator hasn't drawn yet, so slow
+// connections skip straight to the latest screen
+func (s *liveShare) broadcast(frame string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	for ch := range s.watchers {
+		select {
+		case <-ch:
+		default:
+		}
+		ch <- frame
+	}
+}
+
+func (s *liveShare) subscribe() chan string {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	ch := make(chan string, 1)
+	switch {
+	case s.ended:
+		close(ch)
+		return ch
+	case s.sharing:
+		ch <- s.frame
+	default:
+		ch <- pausedFrame
+	}
+	s.watchers[ch] = struct{}{}
+	return ch
+}
+
+func (s *liveShare) unsubscribe(ch chan string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if _, ok := s.watchers[ch]; ok {
+		delete(s.watchers, ch)
+		close(ch)
+	}
+}
+
+// End disconnects every spectator; called when the owner's session closes
+func (s *liveShare) End() {
+	s.hub.mu.Lock()
+	delete(s.hub.shares, s.code)
+	s.hub.mu.Unlock()
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.ended = true
+	for ch := range s.watchers {
+		close(ch)
+	}
+	s.watchers = nil
+}
+
+const pausedFrame = "⏸  Not sharing right now • waiting for the session to be shared again"
+
+// toggleSharing starts or stops the read-only live view of this session
+func (m *model) toggleSharing() {
+	if m.share == nil {
+		m.macroNotice = "⚠️  Sharing is only available when connected with ember serve"
+		return
+	}
+	m.share.Toggle()
+}
+
+// renderShareStatus tells the owner how spectators can watch
+func (m model) renderShareStatus() string {
+	if m.share == nil || !m.share.Sharing() {
+		return ""
+	}
+	return lipgloss.NewStyle().
+		Foreground(theme.Warning).
+		Bold(true).
+		Render(fmt.Sprintf("👀 Sharing read-only • ssh -p %s %s%s@<host> • %d watching • Alt+W to stop",
+			m.share.hub.port, spectatorPrefix, m.share.code, m.share.Watchers())) + "\n"
+}
+
+// Messages for spectators
+type frameMsg struct {
+	frame string
+	ok    bool
+}
+
+// spectatorModel shows another session's screen and ignores every key except quitting
+type spectatorModel struct {
+	share *liveShare
+	ch    chan string
+	frame string
+}
+
+func newSpectator(share *liveShare) spectatorModel {
+	return spectatorModel{share: share, ch: share.subscribe()}
+}
+
+func (s spectatorModel) Init() tea.Cmd {
+	return s.waitForFrame()
+}
+
+func (s spectatorModel) waitForFrame() tea.Cmd {
+	return func() tea.Msg {
+		frame, ok := <-s.ch
+		return frameMsg{frame: frame, ok: ok}
+	}
+}
+
+func (s spectatorModel) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
+	switch msg := msg.(type) {
+	case frameMsg:
+		if !msg.ok {
+			return s, tea.Quit
+		}
+		s.frame = msg.frame
+		return s, s.waitForFrame()
+	case tea.KeyMsg:
+		switch msg.String() {
+		case "q", "esc", "ctrl+c":
+			s.share.unsubscribe(s.ch)
+			return s, tea.Quit
+		}
+	}
+	return s, nil
+}
+
+func (s spectatorModel) View() string {
+	frame := strings.TrimPrefix(s.frame, "\033[2J\033[H")
+	banner := lipgloss.NewStyle().
+		Foreground(theme.Muted).
+		Italic(true).
+		Render(fmt.Sprintf("👀 Watching %s • read-only • q to leave", s.share.code))
+	return "\033[2J\033[H" + banner + "\n" + frame
+}
@@ -0,0 +1,145 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"sort"
+	"strings"
+
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/lipgloss"
+)
+
+// Messages for the second provider's scores
+type secondaryCompleteMsg struct {
+	seq    int
+	scores []float64
+	err    error
+}
+
+// loadSecondaryProvider reads EMBER_COMPARE_PROVIDER as "provider" or
+// "provider:model"; it returns nil when side-by-side mode is off
+func loadSecondaryProvider() (EmbeddingProvider, error) {
+	spec := os.Getenv("EMBER_COMPARE_PROVIDER")
+	if spec == "" {
+		return nil, nil
+	}
+
+	name, model, _ := strings.Cut(spec, ":")
+	provider, err := NewProvider(name)
+	if err != nil {
+		return nil, fmt.Errorf("failed to set up comparison provider: %w", err)
+	}
+
+	if model != "" {
+		selector, ok := provider.(modelSelector)
+		if !ok {
+			closeProvider(provider)
+			return nil, fmt.Errorf("provider %q does not support choosing a model", name)
+		}
+		provider = selector.WithModel(model)
+	}
+	return provider, nil
+}
+
+// generateSecondaryScores embeds the input and comparison texts with the second
+// provider, since vectors from different models can't be compared with each other
+func (m model) generateSecondaryScores(text string) tea.Cmd {
+	if m.secondary == nil {
+		return nil
+	}
+
+	seq := m.comparisonSeq
+	provider := m.secondary
+	texts := make([]string, len(m.customEmbeddings))
+	for i, e := range m.customEmbeddings {
+		texts[i] = e.Text
+	}
+
+	// Use the provider's own document type; the primary's may not apply to it
+	docType := ""
+	if typed, ok := provider.(inputTypeProvider); ok && len(typed.InputTypes()) > 0 {
+		docType = typed.InputTypes()[0]
+	}
+
+	return func() tea.Msg {
+		input, err := withInputType(provider, queryInputTypeFor(docType)).GenerateEmbedding(text)
+		if err != nil {
+			return secondaryCompleteMsg{seq: seq, err: err}
+		}
+
+		comparisons, err := withInputType(provider, docType).GenerateBatch(texts)
+		if err != nil {
+			return secondaryCompleteMsg{seq: seq, err: err}
+		}
+
+		scores := make([]float64, len(comparisons))
+		for i, c := range comparisons {
+			scores[i] = cosineSimilarity(input, c)
+		}
+		return secondaryCompleteMsg{seq: seq, scores: scores}
+	}
+}
+
+// scoreRanks returns each score's 1-based rank, highest first
+func scoreRanks(scores []float64) []int {
+	order := make([]int, len(scores))
+	for i := range order {
+		order[i] = i
+	}
+	sort.SliceStable(order, func(a, b int) bool { return scores[order[a]] > scores[order[b]] })
+
+	ranks := make([]int, len(scores))
+	for rank, i := range order {
+		ranks[i] = rank + 1
+	}
+	return ranks
+}
+
+// renderSideBySide lists both providers' scores and ranks for every comparison
+func (m model) renderSideBySide() string {
+	labelStyle := lipgloss.NewStyle().
+		Foreground(theme.Primary).
+		Bold(true)
+	mutedStyle := lipgloss.NewStyle().
+		Foreground(theme.Muted)
+
+	primary, secondary := m.provider.ModelInfo(), m.secondary.ModelInfo()
+	s := labelStyle.Render(fmt.Sprintf("⚖️  %s/%s vs %s/%s", primary.Provider, primary.Model, secondary.Provider, secondary.Model)) + "\n"
+
+	switch {
+	case m.secondarySeq != m.comparisonSeq:
+		return s + mutedStyle.Render("Waiting for the comparison provider...") + "\n\n"
+	case m.secondaryErr != nil:
+		return s + lipgloss.NewStyle().Foreground(theme.Warning).Render("⚠️  Comparison provider failed: "+m.secondaryErr.Error()) + "\n\n"
+	case m.resultSpill != nil:
+		return s + mutedStyle.Render("Off while results are spilled to disk") + "\n\n"
+	case len(m.secondaryScores) != len(m.similarities):
+		return s + mutedStyle.Render("Comparison set changed; run the comparison again") + "\n\n"
+	}
+
+	primaryScores := make([]float64, len(m.similarities))
+	for i, r := range m.similarities {
+		primaryScores[i] = r.Similarity
+	}
+	primaryRanks, secondaryRanks := scoreRanks(primaryScores), scoreRanks(m.secondaryScores)
+
+	s += mutedStyle.Render(fmt.Sprintf("%-40s %14s %14s", "", truncateText(primary.Model, 14), truncateText(secondary.Model, 14))) + "\n"
+
+	changed := 0
+	for i, r := range m.similarities {
+		line := fmt.Sprintf("%-40s %8.3f (#%d) %8.3f (#%d)", truncateText(r.Text, 40), r.Similarity, primaryRanks[i], m.secondaryScores[i], secondaryRanks[i])
+		if primaryRanks[i] != secondaryRanks[i] {
+			changed++
+			line = lipgloss.NewStyle().Foreground(theme.Warning).Render(line + "  ↕")
+		}
+		s += line + "\n"
+	}
+
+	if changed == 0 {
+		s += mutedStyle.Render("✅ Both models rank the comparisons the same way") + "\n\n"
+	} else {
+		s += lipgloss.NewStyle().Foreground(theme.Warning).Render(fmt.Sprintf("↕ %d of %d comparisons change rank between models", changed, len(m.similarities))) + "\n\n"
+	}
+	return s
+}
@@ -30,18 +30,43 @@ func cosineSimilarity(a, b []float64) float64 {
 type SimilarityResult struct {
 	Text       string
 	Similarity float64
-}
 
-func compareWithStaticEmbeddings(inputEmbedding []float64) []SimilarityResult {
-	results := make([]SimilarityResult, len(staticExamples))
+	// Set when score processors are configured
+	RawSimilarity float64
+	Adjustments   []scoreAdjustment
 
-	for i, example := range staticExamples {
-		similarity := cosineSimilarity(inputEmbedding, example.Embedding)
-		results[i] = SimilarityResult{
-			Text:       example.Text,
-			Similarity: similarity,
-		}
-	}
+	// Set when hybrid scoring fused a sparse score into Similarity
+	DenseSimilarity float64
+	SparseScore     float64
+
+	// Set when the comparison was scored by its fields
+	Fields []fieldScore
+}
 
-	return results
+// similarityMetrics scores a pair of vectors for ember similarity. Cosine
+// and dot are similarities; euclidean and manhattan are distances, where
+// lower means closer.
+var similarityMetrics = map[string]func(a, b []float64) float64{
+	"cosine": cosineSimilarity,
+	"dot": func(a, b []float64) float64 {
+		var dot float64
+		for i := range min(len(a), len(b)) {
+			dot += a[i] * b[i]
+		}
+		return dot
+	},
+	"euclidean": func(a, b []float64) float64 {
+		var sum float64
+		for i := range min(len(a), len(b)) {
+			sum += (a[i] - b[i]) * (a[i] - b[i])
+		}
+		return math.Sqrt(sum)
+	},
+	"manhattan": func(a, b []float64) float64 {
+		var sum float64
+		for i := range min(len(a), len(b)) {
+			sum += math.Abs(a[i] - b[i])
+		}
+		return sum
+	},
 }
@@ -0,0 +1,253 @@
+package main
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"slices"
+	"sort"
+	"strings"
+	"time"
+	"unicode"
+
+	"github.com/charmbracelet/bubbles/textinput"
+	"github.com/charmbracelet/lipgloss"
+)
+
+// Snippets shown at once in the picker
+const snippetPageSize = 12
+
+// snippet is a reusable text, such as an anchor or a test sentence, that can
+// be inserted into the input or any comparison text
+type snippet struct {
+	Text  string    `json:"text"`
+	Added time.Time `json:"added"`
+}
+
+// loadSnippets reads the snippet library, which is empty until one is saved
+func loadSnippets(path string) ([]snippet, error) {
+	data, err := os.ReadFile(path)
+	if errors.Is(err, os.ErrNotExist) {
+		return nil, nil
+	}
+	if err == nil {
+		data, err = openAtRest(data)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to read snippets: %w", err)
+	}
+	var snippets []snippet
+	if err := json.Unmarshal(data, &snippets); err != nil {
+		return nil, fmt.Errorf("failed to parse %s: %w", path, err)
+	}
+	return snippets, nil
+}
+
+func saveSnippets(path string, snippets []snippet) error {
+	if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+		return fmt.Errorf("failed to create config directory: %w", err)
+	}
+	data, err := json.MarshalIndent(snippets, "", "  ")
+	if err != nil {
+		return fmt.Errorf("failed to marshal snippets: %w", err)
+	}
+	if err := os.WriteFile(path, sealAtRest(data), 0o644); err != nil {
+		return fmt.Errorf("failed to write snippets: %w", err)
+	}
+	return nil
+}
+
+// fuzzyScore reports whether every character of query appears in text in
+// order, ignoring case, and scores the match: runs of consecutive characters
+// and matches at the start of words score higher
+func fuzzyScore(query, text string) (int, bool) {
+	q := []rune(strings.ToLower(strings.Join(strings.Fields(query), "")))
+	if len(q) == 0 {
+		return 0, true
+	}
+	score, next, prev := 0, 0, -2
+	runes := []rune(strings.ToLower(text))
+	for i, r := range runes {
+		if next == len(q) {
+			break
+		}
+		if r != q[next] {
+			continue
+		}
+		score++
+		if prev == i-1 {
+			score += 2
+		}
+		if i == 0 || !unicode.IsLetter(runes[i-1]) && !unicode.IsDigit(runes[i-1]) {
+			score += 3
+		}
+		prev = i
+		next++
+	}
+	return score, next == len(q)
+}
+
+// filteredSnippets lists the indexes of the snippets matching the picker's
+// query, best match first, or all of them newest first without a query
+func (m model) filteredSnippets() []int {
+	query := m.snippetFilter.Value()
+	type match struct{ index, score int }
+	var matches []match
+	for i, s := range m.snippets {
+		if score, ok := fuzzyScore(query, s.Text); ok {
+			matches = append(matches, match{i, score})
+		}
+	}
+	sort.SliceStable(matches, func(i, j int) bool {
+		if matches[i].score != matches[j].score {
+			return matches[i].score > matches[j].score
+		}
+		return m.snippets[matches[i].index].Added.After(m.snippets[matches[j].index].Added)
+	})
+	indexes := make([]int, len(matches))
+	for i, match := range matches {
+		indexes[i] = match.index
+	}
+	return indexes
+}
+
+// openSnippetPicker shows the snippet library for the textarea being typed
+// in, which is where Enter inserts
+func (m *model) openSnippetPicker() {
+	m.snippetReturn = m.currentScreen
+	m.snippets, m.snippetErr = loadSnippets(m.snippetsPath)
+	m.snippetFilter = textinput.New()
+	m.snippetFilter.Placeholder = "type to filter"
+	m.snippetFilter.Width = 60
+	m.snippetFilter.Focus()
+	m.selectedSnippet = 0
+	m.snippetNotice = ""
+	m.confirmDelete = false
+	m.currentScreen = snippetScreen
+}
+
+// snippetTarget is the text the picker was opened from
+func (m model) snippetTarget() string {
+	if m.snippetReturn == embeddingsScreen {
+		return m.embeddingTexts[m.selectedTextArea].Value()
+	}
+	return m.textarea.Value()
+}
+
+// insertSelectedSnippet inserts the selected snippet at the cursor of the
+// textarea the picker was opened from
+func (m *model) insertSelectedSnippet() {
+	matches := m.filteredSnippets()
+	if m.selectedSnippet >= len(matches) {
+		return
+	}
+	text := m.snippets[matches[m.selectedSnippet]].Text
+	if m.snippetReturn == embeddingsScreen {
+		m.embeddingTexts[m.selectedTextArea].InsertString(text)
+	} else {
+		m.textarea.InsertString(text)
+	}
+	m.currentScreen = m.snippetReturn
+}
+
+// addSnippet saves the text the picker was opened from to the library
+func (m *model) addSnippet() {
+	text := strings.TrimSpace(m.snippetTarget())
+	switch {
+	case text == "":
+		m.snippetNotice = "⚠️  The text you came from is empty • type a snippet there first"
+		return
+	case slices.ContainsFunc(m.snippets, func(s snippet) bool { return s.Text == text }):
+		m.snippetNotice = "Already in the snippets"
+		return
+	}
+	snippets := append(slices.Clone(m.snippets), snippet{Text: text, Added: time.Now()})
+	if err := saveSnippets(m.snippetsPath, snippets); err != nil {
+		m.snippetNotice = "⚠️  " + err.Error()
+		return
+	}
+	m.snippets = snippets
+	m.snippetFilter.SetValue("")
+	m.selectedSnippet = 0
+	m.snippetNotice = fmt.Sprintf("✅ Saved %q as a snippet", truncateText(text, 30))
+}
+
+// deleteSelectedSnippet deletes the selected snippet on the second Ctrl+D press
+func (m *model) deleteSelectedSnippet() {
+	matches := m.filteredSnippets()
+	if m.selectedSnippet >= len(matches) {
+		return
+	}
+	if !m.confirmDelete {
+		m.confirmDelete = true
+		m.snippetNotice = "Press Ctrl+D again to delete this snippet"
+		return
+	}
+	m.confirmDelete = false
+	snippets := slices.Delete(slices.Clone(m.snippets), matches[m.selectedSnippet], matches[m.selectedSnippet]+1)
+	if err := saveSnippets(m.snippetsPath, snippets); err != nil {
+		m.snippetNotice = "⚠️  " + err.Error()
+		return
+	}
+	m.snippets = snippets
+	m.selectedSnippet = max(0, min(m.selectedSnippet, len(m.filteredSnippets())-1))
+	m.snippetNotice = "🗑️  Deleted the snippet"
+}
+
+func (m model) renderSnippetScreen() string {
+	s := "\033[2J\033[H" // Clear screen and move cursor to top
+
+	s += "╭─────────────────────────────────────────────────────────────────────────────╮\n"
+	s += "│                              📎 SNIPPETS 📎                                 │\n"
+	s += "│                Reusable anchors and test sentences to insert                │\n"
+	s += "╰─────────────────────────────────────────────────────────────────────────────╯\n\n"
+
+	labelStyle := lipgloss.NewStyle().
+		Foreground(theme.Primary).
+		Bold(true)
+	selectedStyle := lipgloss.NewStyle().
+		Foreground(theme.Accent).
+		Bold(true)
+	instructStyle := lipgloss.NewStyle().
+		Foreground(theme.Muted).
+		Italic(true)
+	warningStyle := lipgloss.NewStyle().
+		Foreground(theme.Warning)
+
+	s += labelStyle.Render("🔍 ") + m.snippetFilter.View() + "\n\n"
+
+	matches := m.filteredSnippets()
+	switch {
+	case m.snippetErr != nil:
+		s += warningStyle.Render("⚠️  "+m.snippetErr.Error()) + "\n"
+	case len(m.snippets) == 0:
+		s += instructStyle.Render("No snippets yet • Ctrl+S saves the text you came from as one") + "\n"
+	case len(matches) == 0:
+		s += instructStyle.Render("No snippets match") + "\n"
+	}
+
+	start := 0
+	if m.selectedSnippet >= snippetPageSize {
+		start = m.selectedSnippet - snippetPageSize + 1
+	}
+	end := min(len(matches), start+snippetPageSize)
+	for i := start; i < end; i++ {
+		line := truncateText(strings.Join(strings.Fields(m.snippets[matches[i]].Text), " "), 74)
+		if i == m.selectedSnippet {
+			s += selectedStyle.Render("▶ "+line) + "\n"
+		} else {
+			s += "  " + line + "\n"
+		}
+	}
+	if len(matches) > end-start {
+		s += instructStyle.Render(fmt.Sprintf("  %d of %d shown", end-start, len(matches))) + "\n"
+	}
+
+	if m.snippetNotice != "" {
+		s += "\n" + warningStyle.Render(m.snippetNotice) + "\n"
+	}
+	s += "\n" + instructStyle.Render("↑/↓ to select • Enter to insert • Ctrl+S to save the text you came from • Ctrl+D to delete • Esc to return") + "\n"
+	return s
+}
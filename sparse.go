@@ -0,0 +1,191 @@
+package main
+
+import (
+	"fmt"
+	"hash/fnv"
+	"math"
+	"os"
+	"slices"
+	"strconv"
+	"strings"
+	"unicode"
+
+	"github.com/charmbracelet/lipgloss"
+)
+
+// SparseVector holds the non-zero weights of a vocabulary-sized vector, in
+// ascending index order, as SPLADE models and BM25 encoders produce
+type SparseVector struct {
+	Indices []uint32  `json:"indices"`
+	Values  []float64 `json:"values"`
+}
+
+// sparseEmbedder is implemented by providers that can return sparse vectors
+type sparseEmbedder interface {
+	GenerateSparse(text string) (SparseVector, error)
+}
+
+// sparseMode picks where sparse vectors come from
+type sparseMode string
+
+const (
+	sparseOff sparseMode = ""
+	// sparseBM25 encodes term frequencies locally and scores them with BM25
+	sparseBM25 sparseMode = "bm25"
+	// sparseProvider asks the provider, for SPLADE-style models
+	sparseProvider sparseMode = "provider"
+)
+
+// Weight of the sparse score in the fused score unless EMBER_HYBRID_WEIGHT says otherwise
+const defaultHybridWeight = 0.3
+
+// BM25 term frequency saturation
+const bm25K1 = 1.2
+
+type sparseOptions struct {
+	mode   sparseMode
+	weight float64
+}
+
+// loadSparseOptions reads EMBER_SPARSE (bm25 or provider) and EMBER_HYBRID_WEIGHT
+func loadSparseOptions() (sparseOptions, error) {
+	opts := sparseOptions{mode: sparseMode(strings.ToLower(os.Getenv("EMBER_SPARSE"))), weight: defaultHybridWeight}
+	if opts.mode != sparseOff && opts.mode != sparseBM25 && opts.mode != sparseProvider {
+		return opts, fmt.Errorf("invalid EMBER_SPARSE %q (use bm25 or provider)", os.Getenv("EMBER_SPARSE"))
+	}
+	if value := os.Getenv("EMBER_HYBRID_WEIGHT"); value != "" {
+		weight, err := strconv.ParseFloat(value, 64)
+		if err != nil || weight < 0 || weight > 1 {
+			return opts, fmt.Errorf("invalid EMBER_HYBRID_WEIGHT %q: use a number from 0 to 1", value)
+		}
+		opts.weight = weight
+	}
+	return opts, nil
+}
+
+// encode returns text's sparse vector, or nil when sparse scoring is off
+func (o sparseOptions) encode(provider EmbeddingProvider, text string) (*SparseVector, error) {
+	switch o.mode {
+	case sparseBM25:
+		v := bm25Vector(text)
+		return &v, nil
+	case sparseProvider:
+		sparse, ok := provider.(sparseEmbedder)
+		if !ok {
+			return nil, fmt.Errorf("provider %q does not return sparse vectors", provider.ModelInfo().Provider)
+		}
+		v, err := sparse.GenerateSparse(text)
+		if err != nil {
+			return nil, fmt.Errorf("failed to generate sparse vector: %w", err)
+		}
+		return &v, nil
+	}
+	return nil, nil
+}
+
+// bm25Vector hashes each word of text to an index and weights it by its
+// saturated term frequency. IDF depends on the comparison set, so it is
+// applied when scoring.
+func bm25Vector(text string) SparseVector {
+	counts := make(map[uint32]float64)
+	words := strings.FieldsFunc(strings.ToLower(text), func(r rune) bool {
+		return !unicode.IsLetter(r) && !unicode.IsNumber(r)
+	})
+	for _, word := range words {
+		h := fnv.New32a()
+		h.Write([]byte(word))
+		counts[h.Sum32()]++
+	}
+
+	var v SparseVector
+	for index := range counts {
+		v.Indices = append(v.Indices, index)
+	}
+	slices.Sort(v.Indices)
+	for _, index := range v.Indices {
+		tf := counts[index]
+		v.Values = append(v.Values, tf*(bm25K1+1)/(tf+bm25K1))
+	}
+	return v
+}
+
+// dot multiplies the weights of the indices both vectors share, each scaled
+// by weight(index) when weight is set
+func (v SparseVector) dot(w SparseVector, weight func(uint32) float64) float64 {
+	var sum float64
+	for i, j := 0, 0; i < len(v.Indices) && j < len(w.Indices); {
+		switch {
+		case v.Indices[i] < w.Indices[j]:
+			i++
+		case v.Indices[i] > w.Indices[j]:
+			j++
+		default:
+			product := v.Values[i] * w.Values[j]
+			if weight != nil {
+				product *= weight(v.Indices[i])
+			}
+			sum += product
+			i++
+			j++
+		}
+	}
+	return sum
+}
+
+// sparseScores scores input against each comparison. BM25 weights terms by
+// their inverse document frequency across the comparison set; provider
+// vectors are already weighted, so they use a plain dot product.
+func sparseScores(mode sparseMode, input SparseVector, embeddings []CustomEmbedding) []float64 {
+	var idf func(uint32) float64
+	if mode == sparseBM25 {
+		df := make(map[uint32]int)
+		for _, e := range embeddings {
+			if e.Sparse != nil {
+				for _, index := range e.Sparse.Indices {
+					df[index]++
+				}
+			}
+		}
+		n := float64(len(embeddings))
+		idf = func(index uint32) float64 {
+			d := float64(df[index])
+			return math.Log(1 + (n-d+0.5)/(d+0.5))
+		}
+	}
+
+	scores := make([]float64, len(embeddings))
+	for i, e := range embeddings {
+		if e.Sparse != nil {
+			scores[i] = input.dot(*e.Sparse, idf)
+		}
+	}
+	return scores
+}
+
+// hybridFusion returns a function that fuses the dense score of the result
+// for embeddings[i] with its sparse score. Sparse scores have no fixed range,
+// so they are divided by the set's best before mixing. Comparisons embedded
+// before sparse scoring was turned on score 0 on the sparse side.
+func hybridFusion(opts sparseOptions, input *SparseVector, embeddings []CustomEmbedding) func(i int, result *SimilarityResult) {
+	if opts.mode == sparseOff || input == nil {
+		return func(int, *SimilarityResult) {}
+	}
+
+	scores := sparseScores(opts.mode, *input, embeddings)
+	best := slices.Max(append([]float64{0}, scores...))
+	return func(i int, result *SimilarityResult) {
+		result.DenseSimilarity = result.Similarity
+		result.SparseScore = scores[i]
+		normalized := 0.0
+		if best > 0 {
+			normalized = scores[i] / best
+		}
+		result.Similarity = (1-opts.weight)*result.Similarity + opts.weight*normalized
+	}
+}
+
+// renderHybrid shows the dense and sparse scores behind a fused score
+func (o sparseOptions) renderHybrid(result SimilarityResult) string {
+	return lipgloss.NewStyle().Foreground(theme.Muted).Render(
+		fmt.Sprintf("↳ dense %.3f • %s %.3f • fused at %.0f%% sparse", result.DenseSimilarity, o.mode, result.SparseScore, 100*o.weight))
+}
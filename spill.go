@@ -0,0 +1,226 @@
+package main
+
+import (
+	"bufio"
+	"encoding/json"
+	"fmt"
+	"os"
+	"strconv"
+)
+
+// defaultSpillRows is how many results or graph rows stay in memory before
+// the rest go to a temp file
+const defaultSpillRows = 1000
+
+// loadSpillRows reads EMBER_SPILL_ROWS, the rows kept in memory before the
+// rest are spilled to disk; 0 keeps everything in memory
+func loadSpillRows() (int, error) {
+	value := os.Getenv("EMBER_SPILL_ROWS")
+	if value == "" {
+		return defaultSpillRows, nil
+	}
+	rows, err := strconv.Atoi(value)
+	if err != nil || rows < 0 {
+		return 0, fmt.Errorf("invalid EMBER_SPILL_ROWS %q (use a number of rows, or 0 to keep them all in memory)", value)
+	}
+	return rows, nil
+}
+
+// spillFile keeps rows as JSON Lines in a temp file, remembering only where
+// each starts, so a screen can read the page it shows. Lines are encrypted
+// when EMBER_ENCRYPT is set, like everything else ember writes.
+type spillFile struct {
+	file    *os.File
+	offsets []int64
+	size    int64
+}
+
+// spillWriter appends rows to a spill file as they are produced, so they
+// never have to be held in memory all at once
+type spillWriter struct {
+	s *spillFile
+	w *bufio.Writer
+}
+
+// newSpillWriter creates an empty temp file to spill rows to
+func newSpillWriter() (*spillWriter, error) {
+	file, err := os.CreateTemp("", "ember-spill-*.jsonl")
+	if err != nil {
+		return nil, fmt.Errorf("failed to create a spill file: %w", err)
+	}
+	// Where the OS allows it, the open file outlives its name, so nothing is
+	// left behind if ember is killed
+	os.Remove(file.Name())
+	return &spillWriter{s: &spillFile{file: file}, w: bufio.NewWriter(file)}, nil
+}
+
+// Add appends row to the file
+func (w *spillWriter) Add(row any) error {
+	data, err := json.Marshal(row)
+	if err != nil {
+		return fmt.Errorf("failed to marshal a spilled row: %w", err)
+	}
+	line := append(sealLine(data), '\n')
+	if _, err := w.w.Write(line); err != nil {
+		return fmt.Errorf("failed to write the spill file: %w", err)
+	}
+	w.s.offsets = append(w.s.offsets, w.s.size)
+	w.s.size += int64(len(line))
+	return nil
+}
+
+// Finish flushes the rows and returns the file to read them from
+func (w *spillWriter) Finish() (*spillFile, error) {
+	if err := w.w.Flush(); err != nil {
+		w.s.Close()
+		return nil, fmt.Errorf("failed to write the spill file: %w", err)
+	}
+	return w.s, nil
+}
+
+// Abort removes the file after a failed write
+func (w *spillWriter) Abort() {
+	w.s.Close()
+}
+
+// Len is the number of rows in the file
+func (s *spillFile) Len() int {
+	return len(s.offsets)
+}
+
+// readSpilled reads rows start to end from s
+func readSpilled[T any](s *spillFile, start, end int) ([]T, error) {
+	start, end = max(start, 0), min(end, s.Len())
+	if start >= end {
+		return nil, nil
+	}
+	stop := s.size
+	if end < s.Len() {
+		stop = s.offsets[end]
+	}
+	data := make([]byte, stop-s.offsets[start])
+	if _, err := s.file.ReadAt(data, s.offsets[start]); err != nil {
+		return nil, fmt.Errorf("failed to read the spill file: %w", err)
+	}
+
+	rows := make([]T, 0, end-start)
+	for i := start; i < end; i++ {
+		from, to := s.offsets[i]-s.offsets[start], int64(len(data))
+		if i+1 < end {
+			to = s.offsets[i+1] - s.offsets[start]
+		}
+		line, err := openLine(data[from:to])
+		if err != nil {
+			return nil, fmt.Errorf("failed to read the spill file: %w", err)
+		}
+		var row T
+		if err := json.Unmarshal(line, &row); err != nil {
+			return nil, fmt.Errorf("failed to parse the spill file: %w", err)
+		}
+		rows = append(rows, row)
+	}
+	return rows, nil
+}
+
+// Close removes the file; s may be nil
+func (s *spillFile) Close() {
+	if s == nil {
+		return
+	}
+	s.file.Close()
+	os.Remove(s.file.Name())
+}
+
+// resultsPageSize is how many results the results screen shows at once
+const resultsPageSize = 8
+
+// collectResults produces the n results of a comparison in order, keeping
+// the first spillRows in memory. Once there are more, every result is
+// written to a temp file as it is produced, replacing the last run's file,
+// so only the head is ever held. The history, pins and notes see the
+// results kept in memory.
+func (m *model) collectResults(n int, result func(i int) SimilarityResult) []SimilarityResult {
+	m.resultSpill.Close()
+	m.resultSpill = nil
+	m.spillNotice = ""
+	if m.spillRows == 0 || n <= m.spillRows {
+		results := make([]SimilarityResult, n)
+		for i := range results {
+			results[i] = result(i)
+		}
+		return results
+	}
+
+	head := make([]SimilarityResult, 0, m.spillRows)
+	w, err := newSpillWriter()
+	for i := 0; i < n; i++ {
+		r := result(i)
+		if w == nil || len(head) < m.spillRows {
+			head = append(head, r)
+		}
+		if w == nil {
+			continue
+		}
+		if err = w.Add(r); err != nil {
+			// Carry on in memory, producing again the results that were
+			// only on disk
+			w.Abort()
+			w = nil
+			for j := len(head); j <= i; j++ {
+				head = append(head, result(j))
+			}
+		}
+	}
+	if w != nil {
+		m.resultSpill, err = w.Finish()
+	}
+	if err != nil {
+		m.spillNotice = "⚠️  " + err.Error() + " • keeping every result in memory"
+		if m.resultSpill == nil && len(head) < n {
+			// Finishing the file failed after the rest were dropped
+			for i := len(head); i < n; i++ {
+				head = append(head, result(i))
+			}
+		}
+	}
+	return head
+}
+
+// resultCount is the number of results, spilled ones included
+func (m model) resultCount() int {
+	if m.resultSpill != nil {
+		return m.resultSpill.Len()
+	}
+	return len(m.similarities)
+}
+
+// resultPage returns results start to end, reading the spill file for any
+// not kept in memory
+func (m model) resultPage(start, end int) ([]SimilarityResult, error) {
+	end = min(end, m.resultCount())
+	if start >= end {
+		return nil, nil
+	}
+	if end <= len(m.similarities) || m.resultSpill == nil {
+		return m.similarities[start:end], nil
+	}
+	return readSpilled[SimilarityResult](m.resultSpill, start, end)
+}
+
+// resultAt returns result i, if there is one and it can be read
+func (m model) resultAt(i int) (SimilarityResult, bool) {
+	if i < 0 || i >= m.resultCount() {
+		return SimilarityResult{}, false
+	}
+	page, err := m.resultPage(i, i+1)
+	if err != nil || len(page) == 0 {
+		return SimilarityResult{}, false
+	}
+	return page[0], true
+}
+
+// closeSpills removes the session's spill files
+func (m model) closeSpills() {
+	m.resultSpill.Close()
+	m.graphSpill.Close()
+}
@@ -1,19 +0,0 @@
-// Generated static embeddings for demo
-package main
-
-type StaticEmbedding struct {
-	Text      string
-	Embedding []float64
-}
-
-var staticExamples = []StaticEmbedding{
-	{
-		Text:      "I hate the state of california.",
-		Embedding: []float64{-0.01661728, -0.01943711, -0.01256740, 0.05950658, -0.02592389, -0.01165066, 0.00230199, -0.06308069, -0.05054810, 0.01406435, -0.01512033, -0.00836666, -0.04732212, 0.05611814, 0.05412221, -0.02378871, 0.02065556, -0.00931821, -0.00957350, 0.01882209, -0.01853198, -0.00547430, -0.03109938, -0.00168262, 0.00108935, -0.00240788, 0.00986941, 0.01643162, 0.01564253, 0.00128154, -0.00590076, -0.04576715, 0.01456333, -0.01122131, 0.02118936, 0.00920217, -0.01812583, 0.02615597, -0.02898741, 0.01674493, -0.01831150, -0.03850289, 0.05686081, 0.05082660, -0.02467063, 0.00253263, -0.02176957, 0.01214965, 0.00412241, -0.00588625, -0.06530870, -0.01262542, 0.03845648, 0.02390475, 0.00447344, 0.00715402, 0.04196096, 0.01249777, -0.01421520, -0.01171449, 0.05115152, -0.03861893, -0.04251796, 0.04312138, -0.01291553, -0.01039741, 0.04472277, -0.01709306, 0.03295606, 0.03495199, -0.02998538, -0.00474614, 0.02367266, 0.00699156, 0.00219610, -0.01356536, -0.01428483, -0.01397151, 0.01850878, 0.01503910, -0.01099502, 0.06758314, 0.02817511, 0.01832311, -0.02982292, 0.01431964, -0.02082962, 0.01624595, 0.00005861, -0.05161569, 0.00046997, 0.03711039, -0.01636199, -0.00134754, -0.03000858, 0.01907738, -0.02399758, -0.01022334, 0.01131994, -0.03613562, -0.01544525, -0.00053271, 0.02159550, 0.04558148, -0.02033064, -0.01530600, -0.02127059, 0.00530024, 0.00775164, 0.01321724, -0.02250064, -0.00385551, -0.00542789, 0.01397151, -0.04337668, 0.04068449, -0.02222213, -0.01256740, 0.01557290, -0.00226573, -0.00290541, -0.00597619, 0.00280388, 0.01192917, -0.02775736, 0.04567432, 0.04270363, 0.02959083, -0.07208558, -0.00168117, 0.04762383, -0.02464742, 0.01068751, -0.04483881, 0.00498112, 0.02280235, -0.06897564, -0.00964893, -0.00178850, 0.00276036, 0.02239620, 0.02518122, -0.00369015, -0.04089337, 0.03453424, -0.05955300, 0.00572380, 0.00586305, -0.01906578, -0.00024750, 0.04416576, -0.00119379, -0.05166211, -0.03687830, 0.02312727, -0.07733070, 0.00724685, 0.01783573, -0.00634172, 0.00381200, 0.00782706, 0.01650124, 0.06038850, 0.01850878, 0.07250334, 0.00349868, 0.00045692, -0.01024655, -0.02534368, -0.05713932, 0.01384387, 0.01285751, 0.03469670, -0.02259347, -0.06270935, 0.02186240, 0.02171155, 0.02180438, -0.00768201, 0.02269791, -0.02109652, 0.05820691, -0.04182171, -0.01171449, -0.00898169, 0.00634752, 0.02940516, 0.02251224, -0.01545686, 0.00920217, 0.01213804, 0.04451389, -0.02836078, -0.03316494, -0.05217269, 0.01225408, 0.02136342, 0.04465314, 0.00177110, -0.02648089, 0.03098334, -0.03481274, 0.00100232, -0.00211632, 0.01319403, -0.03687830, 0.00428197, -0.01771969, 0.02108492, 0.01213804, 0.02515801, 0.02212930, 0.03012462, 0.03736568, 0.01525959, 0.01680295, -0.01209163, -0.01870605, 0.00289091, 0.02476346, -0.00363503, 0.01255580, -0.00807075, -0.00072200, -0.02506517, 0.04467635, -0.01415718, -0.01624595, 0.01421520, -0.00216419, 0.03745851, 0.05486488, -0.03044955, 0.00226283, 0.01835792, -0.00465330, 0.02221053, 0.01840434, -0.02337095, 0.02390475, -0.00499563, -0.00367565, 0.01221927, -0.04943409, 0.00747314, 0.01017692, 0.01248617, 0.03300248, -0.03632129, 0.01428483, -0.03358269, -0.01926305, 0.02332454, -0.03827081, -0.03977936, 0.08183315, 0.04200738, 0.02979971, 0.00867417, 0.01956476, -0.04237871, 0.01833471, -0.00285029, 0.00173483, -0.03212056, -0.03880460, 0.00463300, -0.03044955, 0.01977364, 0.01184213, -0.01037420, 0.02204807, 0.01751081, 0.04855217, -0.03114580, 0.00796631, -0.02824474, 0.01501590, 0.02029583, 0.05272970, -0.02316208, 0.00528864, 0.00183347, 0.07055382, 0.04667228, 0.02469384, -0.00086016, 0.00330721, -0.02434571, -0.01408756, -0.00513778, -0.03344344, -0.00691613, 0.00942265, 0.02296481, 0.02233818, 0.01529440, -0.01479541, 0.02150267, -0.01393670, -0.02552934, -0.00022139, -0.03386119, -0.00819260, 0.02248903, 0.03084409, 0.02092246, 0.01321724, -0.05825332, -0.02766452, -0.02492592, 0.05319387, 0.04170566, -0.01285751, 0.00639974, 0.01503910, -0.05254403, 0.00249201, 0.03685509, -0.00741511, 0.02552934, -0.01119230, 0.00458948, -0.00039998, -0.02221053, 0.00503044, 0.05834616, 0.00692774, 0.01970401, -0.02406721, -0.03820118, -0.02975329, -0.04223946, -0.02093406, 0.02340577, -0.06660838, 0.03448782, 0.05110510, -0.00970115, -0.00334783, -0.01922824, 0.01498108, 0.02224534, 0.06238443, 0.00750215, -0.03729605, -0.01137216, -0.01036840, -0.02982292, -0.02587747, -0.01821867, 0.03154034, -0.03270077, 0.03530012, -0.02838399, 0.03140109, 0.05556114, 0.00098999, 0.00876121, 0.00780966, -0.03718001, 0.06085267, 0.05978508, -0.00373947, 0.03854931, -0.01010730, 0.01767327, -0.01190596, 0.02136342, 0.03810835, -0.00616766, -0.04850575, 0.00121917, 0.01085577, 0.05899599, 0.03555541, 0.02201326, 0.01363499, 0.02829115, 0.00526253, 0.01525959, -0.02778057, 0.00454306, 0.01429643, 0.06554079, 0.02146786, -0.00209892, -0.00534085, -0.00357701, -0.03286323, 0.07570611, 0.01298515, 0.01696541, 0.00633012, -0.00527123, -0.03625167, 0.03627488, 0.04066128, -0.05361162, 0.06897564, 0.01275307, -0.01537563, -0.00216999, -0.02492592, -0.00473163, 0.02163032, 0.01821867, 0.01133155, 0.07482418, -0.00832025, 0.02017979, -0.04291251, 0.05272970, 0.02309245, 0.01177251, -0.00595298, -0.00184508, -0.03207414, 0.03126184, -0.01479541, 0.04685795, 0.04934126, -0.00586595, 0.00583403, 0.02318529, 0.02277914, 0.02335935, -0.01104144, 0.03142430, -0.00758918, -0.00272410, -0.00349288, -0.04618490, 0.00752535, 0.04179850, 0.04910918, -0.03680867, 0.00640554, -0.00080795, 0.01176671, -0.02606314, -0.04184492, 0.01173770, -0.00945166, -0.07821263, 0.01371622, 0.01928626, -0.05036243, -0.04688116, 0.00949808, 0.02713073, -0.00051167, -0.00353059, -0.00648677, -0.00050225, 0.04704362, -0.01958797, 0.00838407, -0.07106441, -0.02664335, 0.04135754, 0.00081447, -0.00888885, 0.01515515, 0.01579338, 0.01238173, -0.02942837, 0.02266309, -0.01898455, 0.00996805, 0.01213804, -0.02420646, 0.00557294, 0.06145610, -0.00553523, -0.02301122, -0.00807656, -0.01883369, 0.01503910, -0.02153748, -0.06261652, 0.03592675, -0.03502162, -0.00254133, 0.01419199, -0.01943711, -0.01748760, -0.00264142, -0.04063807, 0.01798658, 0.06535512, 0.03386119, -0.03019425, -0.00901650, 0.00410790, 0.06957906, -0.03130826, 0.00502754, -0.02127059, -0.03323456, -0.00841888, -0.00069662, 0.02631843, 0.00689292, 0.03265435, 0.02861607, 0.00482447, -0.02056273, -0.00724105, -0.03147072, -0.01628076, 0.04785592, 0.00619667, -0.02476346, -0.04384085, -0.02305764, 0.01305478, -0.00251087, -0.01425002, 0.01834631, 0.03873498, 0.03666942, 0.01622274, -0.04177529, -0.00342325, 0.01419199, 0.01449371, 0.02736282, 0.03755134, -0.04743816, 0.03697113, 0.00602260, 0.02020299, 0.02617918, -0.01520156, 0.01985487, 0.00162895, 0.01469098, 0.01159264, -0.02441534, -0.00436320, -0.01357697, 0.01982005, -0.00776904, -0.03021746, 0.03091371, 0.00346387, 0.00559615, 0.01816065, 0.04920201, -0.00542208, 0.03425574, -0.02880174, -0.02081802, -0.02548293, 0.03497520, 0.01995930, 0.00613284, -0.00801273, -0.02689864, 0.00122570, -0.04757741, 0.00639394, 0.06646913, 0.06632987, 0.04502448, 0.02545972, -0.04177529, 0.00974176, 0.01089059, -0.00129387, -0.00735129, -0.00738030, -0.00072962, 0.03246868, 0.00620827, 0.04832008, -0.01054246, 0.01897294, 0.03634450, -0.00310414, -0.04128791, -0.02427608, -0.03741209, -0.01306638, -0.00507106, 0.04142716, -0.00664923, -0.01481862, 0.01068751, -0.02031904, 0.01921663, 0.00588045, -0.04569753, -0.01476060, -0.00503914, -0.00060161, 0.02655052, -0.00092036, -0.00985201, 0.03720321, -0.02361464, 0.03653017, 0.03000858, 0.00585434, 0.01108786, -0.01238173, -0.00171743, 0.00353639, -0.04370160, 0.00608063, 0.03608921, 0.03028708, -0.03530012, -0.02539009, -0.00743832, 0.02339416, -0.00793731, 0.00946326, -0.00882503, -0.00378298, -0.01362339, -0.04702041, -0.00646937, -0.03866535, -0.02678260, 0.01286911, 0.00196692, -0.00770522, 0.00246155, 0.02375389, 0.01118649, 0.00431388, -0.03425574, -0.00908032, -0.00416592, -0.02439213, 0.02183919, -0.01376264, -0.04579036, 0.04790233, 0.01618792, 0.00280098, -0.00368145, 0.01334489, 0.00187409, -0.00440671, 0.01682616, 0.00194226, 0.00539888, 0.05574681, 0.02664335, -0.02229176, 0.00734549, 0.01362339, -0.00974757, 0.01579338, -0.04319101, -0.01921663, -0.00594718, -0.00326079, 0.02409042, -0.00499273, -0.00185233, 0.00271394, -0.00432838, -0.02034225, -0.01051925, -0.00697415, -0.03789947, 0.01680295, 0.01565413, -0.01994770, -0.03253831, 0.00497532, 0.01626916, 0.00869738, -0.03318815, -0.05421504, -0.03163318, 0.04247155, 0.00372206, 0.01567734, 0.00197562, -0.03105297, 0.02033064, -0.01162165, 0.00151290, 0.00214388, -0.03163318, -0.01599065, 0.01126772, -0.01954155, 0.02420646, -0.01982005, 0.00030461, -0.01006088, -0.04370160, 0.02492592, 0.03344344, -0.00124673, 0.01428483, -0.00476064, 0.00129677, 0.03251510, 0.01119230, -0.05110510, -0.04363197, -0.01900776, 0.02933554, 0.02617918, -0.03411649, -0.02157230, 0.00694514, 0.03615883, 0.05342595, 0.03769060, 0.02785019, 0.01466777, -0.00327240, 0.00376558, 0.00717723, 0.02195524, -0.03260793, -0.00188714, -0.00557584, -0.05537547, -0.03109938, -0.00930661, -0.00294023, 0.01005508, 0.00982299, -0.01535242, 0.04198417, 0.00992743, 0.02046989, -0.00477805, -0.04562790, -0.00023390, 0.02052791, -0.04565111, 0.03720321, 0.02272112, -0.02214090, -0.00404988, -0.01156363, 0.00146721, 0.00400056, -0.01412237, -0.02926591, 0.00348418, 0.00726426, -0.01781252, 0.01806781, 0.04514052, -0.03736568, 0.00295908, -0.01048444, 0.04168246, -0.03864214, -0.01089639, 0.02699148, 0.01096601, -0.00577021, 0.00429937, -0.02443854, -0.05082660, -0.01524798, 0.04948051, 0.03353627, -0.00571799, -0.00028104, -0.00651578, 0.03919915, 0.03615883, -0.01434285, 0.03430216, -0.06178101, 0.02000572, 0.01281109, -0.01242815, 0.01247457, 0.02238459, -0.00155062, -0.02002893, -0.01975043, 0.02757169, 0.01250938, 0.00854653, -0.01760364, -0.00560485, -0.02427608, -0.00420364, 0.01221927, 0.02181598, 0.00110240, 0.02680581, -0.00366694, -0.00843629, 0.05955300, 0.04414255, -0.00002611, -0.00036209, -0.01940230, 0.00536406, 0.01914701, 0.00082245, -0.00765880, 0.02717715, -0.03588033, 0.00395705, 0.02966046, -0.03402365, -0.02363785, 0.01573536, -0.04790233, -0.02122417, 0.02006374, -0.01495788, 0.02281395, -0.01360018, 0.03437178, -0.00403538, -0.03448782, 0.00382070, -0.03801551, -0.02949800, 0.02931233, -0.01717429, -0.01849717, -0.02968366, 0.03212056, -0.00623728, -0.00110095, 0.02650410, -0.00984620, 0.02564539, 0.00915575, 0.00783867, 0.02569180, -0.00505655, 0.00108935, 0.02483309, 0.00556714, -0.01286911, 0.00053307, 0.00255729, 0.00180881, 0.02323170, 0.03042634, -0.05946016, 0.00716562, -0.03024067, -0.03174922, -0.03265435, 0.01339130, 0.03144751, 0.00615605, 0.03601958, -0.04091658, -0.00675367, 0.00469682, -0.00969535, 0.00134537, 0.00989262, -0.03314173, -0.00407889, -0.02752527, -0.00098709, -0.02160711, -0.01383226, -0.03497520, 0.02089925, -0.00444443, 0.02196684, -0.01078615, -0.01096601, -0.00093414, -0.00581953, -0.04532619, -0.00436900, 0.01674493, 0.05087302, -0.01088478, 0.01469098, -0.04205379, -0.00852332, 0.00068066, 0.00705538, 0.04177529, -0.05365804, 0.01966920, -0.01056567, 0.01271825, 0.00565127, -0.03778343, 0.01788215, -0.00806495, -0.00756597, -0.02664335, 0.02411362, -0.02013337, -0.01210323, 0.03618204, -0.03741209, -0.01937909, -0.01416879, -0.03311852, -0.01644322, -0.01332168, 0.02222213, -0.01919343, -0.02880174, 0.01107625, 0.02333614, 0.04233229, -0.03156355, 0.03448782, 0.00220771, 0.01477221, -0.03232943, 0.01631557, -0.01212644, -0.00737450, 0.03490558, 0.01850878, -0.00170727, 0.04595282, 0.01657086, -0.00959091, 0.00519580, 0.01976203, -0.00964313, -0.02425288, 0.02076000, -0.01597905, 0.01559611, -0.00794891, -0.04019711, 0.01096021, -0.01611830, 0.01165647, 0.01715108, -0.03834043, 0.04001144, 0.01589782, -0.02571501, -0.01262542, 0.00676528, 0.01683776, -0.01516675, -0.00753696, -0.03685509, 0.03534654, -0.02055112, 0.02226855, 0.02448496, -0.02258187, 0.03235264, 0.02140984, 0.00803014, 0.01369301, -0.01306638, -0.02973008, -0.02513480, -0.03441820, 0.00926599, 0.01234692, 0.05277612, 0.03880460, -0.01183053, -0.02525084, -0.01991289, -0.02706110, -0.00164490, -0.01318242, -0.00427036, -0.00877281, 0.02149107, -0.02720036, -0.03437178, 0.03369873, -0.01386708, 0.00764140, 0.00492020, 0.00797792, -0.00363503, 0.01473739, -0.00001536, -0.03441820, 0.02144465, -0.03070484, 0.01610670, 0.02247743, 0.03692472, -0.01798658, 0.00165941, -0.05207986, 0.02924270, -0.01197558, -0.04284288, 0.01242815, 0.03947765, 0.02550613, -0.03223660, 0.00972436, -0.03112259, 0.00249926, -0.01731354, -0.03019425, -0.00162169, -0.04395689, 0.01305478, 0.01686097, -0.02325491, -0.01219606, 0.02696827, -0.01017112, -0.01165647, -0.04704362, -0.00765300, -0.01825348, 0.01329847, 0.00813458, 0.02361464, -0.03256152, 0.02580785, -0.00573540, 0.02192042, 0.00919637, -0.00920797, 0.00054794, 0.02425288, 0.00897588, -0.03286323, 0.01332168, -0.02508838, -0.03188847, -0.01163906, 0.03444141, -0.01252098, -0.04813442, -0.00605161, 0.01992449, -0.02215251, 0.05087302, -0.02035385, 0.01134315, -0.00000870, 0.03659980, 0.00601100, -0.01767327, -0.02043508, 0.00234841, -0.05579322, 0.02664335, 0.01281109, 0.01548007, 0.00801273, -0.02743244, 0.02863928, 0.06187385, -0.03919915, -0.06150251, -0.01792856, 0.02462421, 0.03581071, 0.00519870, -0.01467937, 0.02648089, 0.03806193, 0.04042920, 0.01320563, -0.00488829, -0.03831723, 0.00192340, -0.00925439, 0.00125108, -0.00621988, 0.01065850, -0.00044858, 0.01247457, 0.02130540, -0.00407309, -0.02043508, -0.04045241, -0.01161005, -0.02057433, -0.01763846, 0.03061201, 0.00380909, -0.02819832, 0.01631557, -0.00306062, -0.03615883, -0.00737450, 0.00622568, 0.00521901, -0.04873784, -0.01734835, -0.05514338, 0.00923118, 0.01344932, 0.00476354, 0.01994770, -0.02086444, -0.00032474, 0.04005786, 0.01593263, -0.01382066, 0.03225981, 0.02425288, -0.00381200, 0.00456337, 0.01520156, -0.01062949, 0.01321724, -0.03379157, -0.00496372, -0.00028503, -0.02694506, -0.02208288, -0.00511457, 0.02325491, 0.02664335, -0.03321135, -0.01442408, -0.01471418, -0.01112267, 0.02689864, 0.00861035, 0.04022032, -0.01288071, -0.01175510, 0.01746439, 0.01358857, 0.01712787, -0.01035679, 0.01684937, -0.01430804, 0.04548865, -0.02138663, -0.01538723, -0.00444153, 0.02189722, 0.00119451, -0.01325205, 0.08136898, 0.00074050, -0.00384101, 0.00095372, -0.02854645, 0.00590366, -0.00738610, 0.02467063, 0.00917896, -0.00645776, -0.02178117, -0.02887137, 0.02349860, 0.02100369, 0.02467063, -0.00807656, 0.04750779, 0.01340290, 0.02268630, -0.03288644, -0.00750215, -0.00898169, 0.01020013, 0.01109946, -0.00109588, -0.00140774, 0.02675939, 0.01080936, 0.03140109, -0.03878139, 0.00212358, -0.01742958, 0.04985185, -0.00827383, -0.01525959, 0.03026388, -0.02912666, -0.01963439, 0.01455173, 0.00921377, 0.02536688, 0.00769942, -0.01243975, 0.00756017, 0.01165066, 0.02299962, 0.00751375, 0.02100369, -0.01642001, 0.03509124, 0.00760658, -0.02212930, -0.01682616, 0.00217144, 0.00048665, 0.00542208, 0.00050442, -0.00387582, 0.01993610, 0.04103262, -0.03576429, 0.00745573, -0.02381192, 0.00947487, 0.03873498, -0.02327812, 0.01354216, -0.01259061, -0.00985201, 0.00212213, -0.01203360, -0.01538723, 0.00458078, 0.01269505, 0.03116901, 0.01495788, -0.02004053, 0.00645776, 0.02120096, 0.03209735, -0.00697996, -0.00089063, 0.02332454, 0.02759490, -0.00339134, -0.00393094, 0.00498402, -0.01142438, 0.02434571, -0.01868284, -0.00962572, 0.01551488, -0.03513766, -0.01767327, -0.01313601, -0.01855519, -0.04439785, -0.01785894, -0.00161299, -0.00241658, -0.00103423, -0.01279948, -0.00856974, -0.00933562, 0.01020013, 0.00358861, 0.02122417, 0.00558454, -0.07589178, -0.01532921, 0.01509712, 0.02211769, -0.00867998, -0.00426166, 0.03172601, -0.06465886, 0.00177835, -0.01842755, -0.00203800, 0.01977364, 0.00185233, 0.00328110, 0.04479239, -0.04883067, 0.02137502, -0.05727857, -0.01304317, -0.00858134, 0.01201040, -0.05365804, -0.01744118, 0.02246582, -0.00039563, 0.02289518, -0.03277040, -0.01816065, -0.02664335, -0.01366980, -0.00283724, 0.00382070, -0.02617918, 0.01812583, 0.02901062, 0.00776904, -0.02070198, -0.01226569, 0.02045829, -0.00971275, -0.01435445, -0.01753402, 0.03666942, 0.03114580, -0.05491130, 0.02354502, 0.01919343, 0.01491146, 0.02852324, 0.00269219, 0.02578464, -0.01360018, 0.00741511, 0.02361464, 0.01587461, -0.06976473, 0.01643162, 0.03270077, -0.01382066, -0.03362911, -0.01205681, -0.03729605, -0.00166376, -0.00203074, -0.00243399, 0.01891492, 0.01534082, -0.01971562, -0.00389032, 0.02193203, -0.01143599, 0.01073973, -0.00054177, -0.00659121, 0.00916155, 0.01477221, -0.00163330, 0.03374515, -0.02416004, -0.02086444, 0.01732514, -0.02805907, -0.00291557, 0.01716268, -0.01094281, -0.00435739, -0.01127933, -0.01376264, 0.02808228, -0.01811423, -0.00037460, -0.01414558, 0.01888011, -0.02331293, -0.00852332, 0.06024925, 0.02682902, -0.04695078, -0.00924278, -0.02088764, -0.00939364, 0.06591213, -0.00868578, 0.03711039, -0.01522477, 0.00792570, -0.02425288, 0.00112271, -0.00897008, -0.00280823, 0.01293874, -0.01333328, -0.03599637, -0.01109366, 0.00994484, 0.01071652, 0.00453436, 0.02257026, 0.02340577, -0.03882781, 0.01607188, 0.02940516, 0.01260221, 0.00746153, -0.04275005, -0.00047033, -0.01906578, 0.01137216, -0.00702637, 0.00108645, -0.00797792, -0.00085726, -0.00796051, 0.03629809, 0.00563386, -0.02127059, -0.01995930, 0.04697399, -0.00369885, -0.01635038, 0.00727586, 0.01806781, 0.00720624, -0.04632416, 0.01227729, 0.02829115, -0.01291553, 0.01739477, 0.01172029, -0.01840434, 0.04648662, -0.00089425, -0.01709306, 0.03506803, 0.03676226, -0.00321148, -0.00071402, -0.03411649, 0.00732228, -0.02497234, -0.02782698, 0.01003767, -0.01471418, 0.03372194, 0.00095517, 0.00861615, -0.01434285, 0.00220336, -0.03441820, 0.02001733, 0.01010150, -0.01393670, 0.00086452, 0.01011310, 0.03286323, 0.01157524, 0.02534368, -0.02798945, -0.00523352, 0.00177255, -0.00342615, 0.00809977, -0.03880460, -0.00877281, -0.04033636, -0.00706118, -0.00637653, -0.00370756, -0.01546846, 0.00910353, 0.04428181, 0.00373657, 0.01353055, -0.00530314, 0.00128445, 0.05286895, 0.01819546, -0.01430804, -0.00170727, 0.02130540, -0.00635332, 0.00267768, -0.02085283, -0.00651578, -0.02536688, 0.02622560, -0.02006374, -0.00673627, 0.00869738, 0.00671886, 0.02231497, -0.02221053, -0.01033358, -0.00097113, -0.00367565, -0.02356823, 0.03302569, 0.01042642, -0.02281395, 0.04859859, 0.02106171, 0.00242384, -0.00724685, -0.00644036, 0.01847396, 0.02854645, -0.03237585, -0.02569180, -0.00303161, -0.03061201, 0.01655926, -0.00952709, 0.02239620, 0.00094285, 0.03427895, 0.00655640, -0.00736870, 0.00398606, -0.01089059, 0.02771094, -0.00199448, -0.00733388, -0.01760364, 0.00806495, -0.01069912, -0.01044963, -0.02740923, 0.04827367, 0.03606600, 0.03290965, 0.03989540, -0.01925145, -0.03158676, -0.03112259, -0.01332168, 0.01920503, 0.00201914, -0.01130254, -0.00642875, 0.02159550, 0.00034069, 0.03541616, 0.02889457, -0.00439221, -0.00585724, -0.01622274, 0.00598779, -0.01724391, 0.00515809, -0.00000957, -0.01527119, 0.00514939, -0.02030743, -0.01306638, 0.02889457, 0.02564539, 0.00198288, 0.02608635, 0.03337381, 0.00582823, -0.00374527},
-	},
-
-	{
-		Text:      "Washington is a really great place.",
-		Embedding: []float64{0.00348734, -0.02159369, -0.03133548, 0.12847547, 0.01762621, -0.01209828, 0.00133065, 0.02467669, 0.02064604, 0.00345891, 0.03343294, 0.02508102, -0.03378672, 0.00394536, 0.02691314, 0.01959731, -0.03163873, 0.02719111, 0.03492390, 0.04513320, -0.02511893, -0.00135118, -0.04624511, -0.03702136, 0.00115534, -0.03760258, -0.01714607, 0.03280117, -0.02486622, 0.01575619, 0.07333513, -0.03186616, -0.00353156, 0.01218672, -0.01206669, 0.00277976, -0.01487172, -0.02671097, 0.01763884, -0.01652694, 0.03547985, 0.01599626, 0.06160960, 0.03992747, 0.04573969, 0.00083393, -0.03866394, 0.04050869, 0.02903586, -0.00822556, 0.01875075, 0.01001346, -0.01766411, -0.00907213, -0.02951600, 0.05544359, 0.00151465, 0.03166400, 0.03929571, -0.00999450, 0.06893806, 0.00016140, 0.00652612, 0.05054110, -0.04108991, -0.00281451, 0.03674338, 0.00618813, 0.03378672, -0.01637532, 0.01020930, 0.02620556, 0.00244177, -0.01502334, 0.03641486, 0.02885897, -0.01163709, -0.02465142, -0.05731361, -0.02077239, -0.09991976, 0.00757485, -0.00531945, -0.01518760, -0.04851946, -0.04260615, 0.01019035, 0.05102124, -0.00006332, 0.01857385, 0.03383727, 0.00979234, 0.01781574, -0.00672828, -0.00863621, 0.02535900, 0.02110091, 0.00081024, -0.02898532, 0.01650167, 0.02083557, -0.02845464, -0.00438128, -0.00410646, 0.05316924, -0.03401416, 0.04364224, 0.05402844, 0.00613127, 0.00995028, -0.04912595, -0.01814426, -0.01368400, 0.01378509, -0.04336426, 0.00703785, -0.04738228, -0.01145388, 0.00520257, 0.00681041, 0.00627973, 0.03115859, -0.02025435, 0.01405043, -0.00123826, -0.03254847, -0.07641815, 0.03623797, -0.03358456, 0.04177222, 0.01873811, -0.00206113, 0.00859830, -0.05524142, 0.01115063, 0.01151705, -0.02581387, -0.03626324, -0.01616052, 0.00390114, 0.04108991, 0.00874361, 0.02485359, -0.02436081, 0.01576882, -0.02615502, -0.03421633, 0.05786956, -0.01154232, -0.00934379, -0.00889523, 0.01815689, -0.03055210, 0.01451793, -0.00502884, -0.02547271, 0.00667774, 0.04434982, 0.03457011, -0.01231307, -0.02097456, 0.03646540, -0.01469482, -0.00262024, 0.03694554, 0.03295280, -0.02513156, -0.01488436, -0.02185903, 0.00959017, 0.01081580, -0.08157334, 0.02317309, 0.02380486, 0.01708289, 0.00245124, 0.05291653, 0.04159533, -0.00999450, 0.02850518, 0.01909190, -0.02164423, 0.02058286, -0.01641322, 0.00284136, 0.01375981, 0.01762621, 0.00396748, -0.00856040, 0.04288412, -0.01257210, 0.03532823, 0.00219380, 0.00168523, 0.03583364, 0.02041860, 0.00194583, 0.01111272, -0.00101240, -0.02868208, -0.05231004, -0.00886365, -0.00718947, 0.01484645, -0.01527605, 0.02060813, -0.00235648, 0.02012799, -0.01904136, -0.05418006, -0.01718397, 0.02177058, 0.02160632, 0.04531010, 0.04968190, -0.01008927, -0.00139225, 0.01670383, 0.00217169, 0.04965663, -0.01178871, 0.01523814, 0.01246470, 0.01521287, 0.01614788, 0.04500685, 0.00651348, -0.01659012, -0.01015244, 0.03093115, -0.02724165, 0.00573957, -0.00585645, -0.03244739, -0.02088611, -0.01315332, 0.01636268, 0.02515683, 0.00631132, -0.00919216, -0.00543633, 0.00328833, 0.01837169, 0.02499257, 0.02926330, 0.01926879, -0.00147517, 0.03151238, -0.01814426, 0.03684446, 0.00564481, -0.00542369, -0.02558643, -0.02638245, -0.01561720, 0.00404013, -0.01149810, 0.04826675, 0.01194665, -0.03378672, -0.03669284, 0.01739877, 0.00651980, -0.01943305, -0.02663516, -0.02437345, 0.01244575, 0.00681041, -0.02539690, -0.03651594, -0.07353730, -0.00252548, 0.01238889, 0.01808108, -0.00580907, -0.03057736, -0.04945447, 0.00511729, -0.04033180, 0.02322364, -0.00996291, -0.02410810, 0.01228149, 0.01244575, -0.02255396, 0.00825715, -0.01605943, -0.00936274, -0.06600668, -0.01609734, -0.03505025, 0.03742569, -0.02976871, 0.02338789, 0.01120117, -0.00856672, -0.00780860, 0.02520737, 0.01493490, 0.05367465, 0.03075426, -0.00947014, 0.03540404, 0.01101796, 0.04326318, 0.01701972, -0.02033016, -0.04733174, 0.01034197, 0.06454098, -0.01270477, 0.03838597, 0.01969839, -0.01742404, -0.01610997, -0.01089161, -0.05994175, 0.06140744, -0.05397790, -0.01728506, -0.02693841, 0.01322913, -0.00268500, -0.00416016, -0.03350875, -0.00387587, 0.05134976, -0.01573092, 0.00660825, -0.03659176, 0.03590945, 0.01335549, -0.01070840, 0.06984780, -0.01823270, -0.05104651, 0.03227049, 0.01540240, 0.02420919, 0.05615116, -0.01104955, -0.00618813, -0.01174449, -0.00944487, 0.02348898, 0.00118377, 0.04604294, -0.00683568, -0.01741141, -0.00365791, -0.03318023, 0.03323077, -0.02336262, 0.02714057, -0.01387353, 0.00730319, 0.04045815, -0.00561006, -0.04700322, -0.02630664, -0.02261714, 0.01396198, 0.02610448, 0.02966762, -0.05650495, 0.03383727, -0.04081194, 0.02087347, 0.03065318, 0.03353402, -0.00935642, 0.00777701, -0.00713893, 0.02966762, 0.02266768, 0.01710816, 0.06342908, 0.00190477, -0.01044305, 0.04151951, -0.03065318, 0.02477777, -0.01375981, -0.01877602, 0.03767839, 0.00223013, 0.03497444, -0.06262042, -0.01187084, -0.03651594, 0.02903586, -0.02456298, 0.00353156, 0.04215128, -0.04399603, -0.00862989, 0.03290226, 0.01791682, -0.03173981, -0.01839696, 0.01038620, 0.00028212, -0.02292039, -0.02620556, 0.03535350, 0.03990220, -0.04060977, -0.03734987, -0.03520188, -0.04341481, 0.03204306, 0.01461901, 0.03575783, -0.03376146, -0.01909190, 0.02863153, 0.03214414, -0.06009337, 0.00481720, -0.00083551, 0.01580673, 0.08445418, -0.01838433, 0.01653958, 0.04225236, 0.02428500, 0.00936906, -0.04129208, -0.02068395, -0.02533373, -0.00735373, -0.03997801, 0.02372905, -0.01203510, -0.00656403, -0.01426522, -0.04495631, 0.04275777, 0.00737900, -0.02554853, -0.01810635, -0.04068559, -0.02480304, -0.00187634, -0.03734987, 0.00386324, 0.01705762, -0.01084107, -0.01504861, 0.02628137, 0.02139152, -0.03292753, -0.00121457, -0.02513156, 0.03808272, 0.02401966, 0.00717052, -0.00386008, 0.01791682, -0.05973958, -0.01334285, 0.01642586, -0.02427236, 0.00669038, 0.00181316, 0.00243387, 0.00091843, 0.02144206, -0.01536449, -0.00967230, 0.02777233, 0.01842223, 0.00960281, 0.01828324, 0.01689336, -0.01709553, 0.02986979, 0.03444376, 0.03431741, -0.09304617, 0.02563697, 0.01154232, -0.02496730, 0.04237871, -0.01883919, -0.08637475, -0.01661539, -0.00935010, -0.05387681, -0.00154466, 0.00087262, 0.02456298, -0.01507388, -0.01203510, 0.00980497, 0.02931384, 0.01420205, -0.01593308, 0.00325990, 0.02845464, 0.01664066, -0.05102124, 0.02794923, -0.00534472, -0.01041779, 0.00260603, -0.04081194, -0.00373372, 0.03505025, -0.03118386, -0.01250892, -0.02179585, 0.01981211, -0.03856286, -0.00635554, 0.01545294, 0.01122012, 0.04611875, 0.00378427, -0.00426441, 0.00760012, 0.01106218, 0.00939433, -0.01835906, -0.00511729, 0.01594572, -0.01771465, 0.06888752, 0.02479041, 0.01561720, -0.02523265, 0.00077588, 0.00840878, -0.02081030, -0.00385692, 0.03712244, -0.01211091, -0.05132449, 0.05882984, 0.03856286, 0.01752513, 0.03095642, -0.02648354, -0.01015876, 0.01155496, 0.00523732, 0.02367851, 0.00156756, 0.01257210, -0.02540954, -0.03517660, -0.01714607, -0.03161346, 0.00711366, 0.01170026, 0.01877602, 0.00397379, 0.00089868, 0.02538427, 0.02875789, 0.01418941, -0.00796654, 0.04480468, -0.00216853, 0.02807558, 0.06989835, 0.01871284, 0.01538977, 0.00078141, 0.04745809, 0.01001977, 0.02605394, -0.01144124, -0.03755204, 0.00963440, -0.01698181, 0.04627038, -0.04071085, 0.02183375, -0.00044697, -0.00061163, 0.00677251, -0.04455198, 0.02429763, 0.04116573, 0.04247979, -0.00520889, -0.02936438, 0.00255548, 0.01849804, 0.00526891, 0.04712957, 0.00680410, -0.01234466, 0.01262264, -0.00764434, -0.04718012, -0.01924352, -0.03997801, 0.01137175, -0.01384826, 0.01088529, 0.01157391, -0.03123440, 0.00125247, -0.00342100, -0.02403229, -0.01335549, 0.04002855, 0.01717134, 0.01015876, -0.02342580, -0.02102510, 0.03040047, -0.01186452, 0.01969839, -0.01906663, -0.01725979, 0.03070372, -0.00230752, 0.00787178, 0.01373454, -0.01552875, -0.01263527, -0.01224990, 0.04346535, -0.01276794, -0.00285399, -0.00191898, -0.00579643, -0.02177058, 0.03034993, 0.02129044, -0.03224522, -0.00864885, -0.04328845, -0.00652612, -0.00439392, -0.02029225, -0.00828242, -0.00266920, -0.00076799, -0.00244019, 0.01677965, 0.01604680, 0.06504640, -0.02558643, 0.05438222, -0.00962176, -0.04803932, 0.00834560, -0.01176344, -0.00942591, 0.02825247, -0.01048728, -0.01242679, 0.01842223, -0.00660825, -0.03904300, 0.00697467, 0.01728506, 0.03742569, -0.01517497, 0.00662088, -0.00893946, -0.02427236, -0.01437894, 0.03287699, 0.01387353, 0.01125171, 0.00669038, -0.01410097, 0.03277591, -0.03085534, 0.06115473, -0.03398889, 0.03029939, -0.02079766, -0.00129512, -0.01028511, 0.01616052, -0.01289430, -0.00737268, 0.01106218, 0.01031038, -0.02199801, -0.01594572, -0.01456847, 0.00477297, -0.01473273, -0.00921112, -0.00138277, 0.05569629, 0.02530846, 0.02769652, 0.00589120, 0.00011470, -0.02431027, 0.01402515, 0.00355683, -0.00861094, 0.00312881, -0.01025984, -0.05316924, -0.04169641, -0.02698895, 0.01280585, -0.04346535, 0.03307915, -0.01610997, 0.00117824, -0.01736087, -0.02693841, -0.01044305, -0.01953414, -0.03679392, 0.01792946, -0.01038620, -0.00535104, -0.01297643, -0.00766329, -0.01410097, 0.00716420, -0.01830851, 0.00829506, 0.01250892, 0.02779761, -0.02375432, -0.01292589, -0.02855572, -0.02121463, -0.01487172, -0.03868921, 0.04212601, 0.00490249, 0.01497280, 0.00516467, 0.01795473, -0.05655549, 0.01636268, -0.01929406, 0.01518760, 0.00289348, 0.02062077, -0.00130933, -0.03227049, 0.00047027, -0.03714771, -0.03679392, -0.01863703, -0.00633659, 0.00936906, -0.00830137, 0.00806131, 0.00453291, -0.02029225, 0.00314776, -0.03750150, 0.01830851, 0.00628921, -0.01972367, 0.02941492, 0.00237069, -0.01148547, -0.00082050, -0.01501071, -0.04728120, -0.02342580, -0.02751963, -0.03927043, -0.00647558, 0.00678514, -0.00260918, 0.03216941, 0.00449816, 0.01958468, -0.01487172, 0.00578064, 0.00061005, -0.00467189, 0.01647640, -0.01531395, -0.01003241, 0.02855572, -0.01824534, -0.02395648, -0.00440655, -0.02936438, 0.00556584, -0.01756303, -0.02660989, -0.01348184, 0.03540404, 0.00306879, -0.04060977, 0.01771465, -0.00823820, -0.01733560, -0.01334285, 0.03095642, 0.00991869, -0.03118386, 0.00311775, -0.00006096, 0.00067678, 0.00333255, -0.02986979, -0.02369114, -0.01803054, -0.00465294, -0.03469647, -0.00543317, -0.01744932, -0.01712080, -0.00053818, 0.02719111, 0.00796022, -0.02038070, -0.00980497, 0.00064045, -0.04786242, 0.03674338, 0.00967862, -0.03790583, 0.04212601, -0.02525791, 0.01339339, 0.02946546, -0.00092159, 0.00600491, -0.00448552, 0.00596701, -0.02226336, 0.01627423, -0.00795391, 0.01335549, 0.00754958, -0.01302697, -0.00158494, -0.00309248, -0.00325990, 0.02706476, -0.01929406, -0.05746523, -0.00574589, -0.00933747, -0.01423996, -0.00416964, 0.00705048, -0.03530296, -0.02933911, 0.01032934, 0.01146651, 0.00049514, 0.02582650, 0.03825961, -0.03540404, -0.00878152, 0.00814343, -0.02285721, 0.00265657, 0.02422182, 0.00132276, 0.00121772, -0.00408119, -0.00260760, -0.01031038, -0.03259901, 0.01985002, -0.03502498, -0.02600340, 0.00218432, -0.00003144, 0.05086962, 0.00006703, 0.02036806, 0.01023457, -0.02436081, -0.00060254, -0.03360983, 0.03072899, 0.02228863, -0.01464428, 0.01075894, 0.00552793, 0.01386090, -0.02425973, -0.00125563, -0.04015490, 0.01160550, 0.00292349, 0.01982475, 0.00787178, -0.01067681, -0.01655221, -0.00654507, 0.01340603, 0.01864967, -0.00091922, -0.02548535, -0.00292349, 0.05271437, 0.00125800, 0.00515835, 0.03580837, -0.03717298, 0.02535900, 0.00990606, 0.00321884, -0.03783001, 0.00785914, -0.03350875, -0.01039883, 0.01652694, 0.01525078, 0.01048728, -0.02539690, 0.04121627, -0.05943634, 0.03633905, 0.02150524, -0.00452027, 0.00548055, 0.01907927, -0.00890155, 0.00675987, -0.00072692, 0.02623083, 0.00743586, -0.00673460, 0.00416964, -0.05195625, -0.01265423, 0.03277591, 0.00905317, 0.02285721, -0.00804235, -0.01200983, -0.00215747, 0.01825797, 0.00878152, -0.03527769, 0.01091056, -0.01520024, 0.01166868, -0.00640608, 0.02835356, -0.02518210, 0.03628851, 0.03762785, 0.01565511, -0.00302141, 0.00586909, 0.01501071, -0.01809371, 0.00119403, -0.00462451, -0.00549950, 0.02528319, 0.00826979, 0.00506043, -0.00672197, 0.00320778, -0.00579012, -0.06105365, -0.02812612, 0.01509915, 0.02757017, 0.03221995, 0.03573256, 0.03302861, -0.01012086, -0.01552875, 0.00386008, -0.02297093, -0.00329149, 0.02835356, 0.03408997, -0.01458111, 0.00864253, 0.00481404, 0.07328460, -0.03259901, 0.00518046, 0.01068944, 0.00462135, 0.00804235, 0.04692741, 0.01840960, 0.01325440, 0.01456847, 0.00541106, -0.03457011, -0.00808026, 0.01242679, 0.01561720, 0.01311542, 0.00425493, 0.00107400, -0.00856040, -0.03537877, -0.01835906, 0.02520737, 0.00754958, 0.02170740, -0.02923803, -0.00498462, 0.02734273, -0.01327967, 0.05038948, 0.00838982, 0.00115297, -0.02237707, 0.05650495, -0.02837883, -0.06545073, -0.00586593, 0.00895841, -0.05152665, -0.04273250, 0.02062077, 0.00034174, -0.01211723, -0.01930670, 0.00322673, -0.03679392, 0.01442948, -0.00643451, -0.00075101, 0.01589518, 0.00398643, 0.04414765, -0.00441919, 0.00850354, 0.02926330, 0.01437894, -0.00071745, -0.01336812, 0.00456765, 0.04440036, -0.01469482, -0.00378111, 0.03232103, 0.03894192, -0.01969839, 0.02726692, 0.00048685, -0.00169471, 0.00467821, 0.04071085, 0.00613127, -0.02779761, -0.05503926, -0.01448003, -0.00026653, -0.00888892, 0.03108278, 0.01921825, 0.01158655, -0.03393835, 0.01972367, -0.00849722, -0.02551062, -0.04232817, -0.05503926, 0.00367371, 0.00524680, -0.01801790, 0.00658298, 0.03446903, 0.01369664, -0.00673460, -0.00701890, -0.02893478, -0.01882656, -0.02007745, -0.01709553, 0.00306090, 0.01379772, 0.04313683, 0.02951600, -0.02422182, -0.01624896, -0.02270559, -0.01765148, -0.01055046, 0.01101796, -0.03970004, -0.00496882, -0.01324177, 0.02964235, -0.03366037, -0.00110006, -0.00838350, -0.00946382, 0.02302147, 0.00202006, 0.03118386, -0.02201065, 0.01741141, -0.02832829, -0.01403779, 0.01152969, 0.02896005, 0.01483381, 0.00628605, 0.02698895, 0.00615970, -0.01776520, -0.01403779, 0.04455198, 0.01877602, 0.01089161, -0.00663352, -0.00811185, 0.00525943, 0.02452507, -0.05023785, -0.02610448, 0.03189144, 0.01979948, -0.04682633, 0.02107564, 0.00206587, 0.00823820, 0.04336426, -0.04839310, -0.01152969, -0.00582170, 0.03431741, 0.00965335, 0.03123440, -0.02068395, -0.03692028, -0.02376695, 0.01810635, 0.00838350, -0.01084738, -0.02532109, 0.04998515, 0.02643300, -0.00110954, -0.02628137, 0.00607757, 0.02549799, 0.00704417, 0.03093115, 0.09916164, 0.02729219, -0.00247020, 0.00775174, 0.01210459, -0.00068152, -0.03131021, -0.02605394, 0.03181562, 0.02190957, -0.02878316, -0.01384826, 0.00786546, -0.00690518, -0.00232331, -0.00195057, -0.01454320, -0.01803054, 0.00010562, -0.02460088, 0.02380486, -0.03383727, -0.03659176, -0.03042574, 0.00332624, 0.00343679, 0.01306487, 0.00708839, 0.00714525, -0.00645031, 0.01622369, -0.02660989, 0.03505025, -0.02827774, -0.00774542, 0.02926330, -0.00114665, -0.04867108, 0.04531010, 0.00838982, -0.01339339, -0.00921743, -0.02842937, 0.00828874, 0.01790418, 0.03201779, 0.00381585, -0.00479825, -0.00663984, 0.01823270, -0.02415864, -0.00350629, 0.00118456, 0.00215589, 0.00383165, -0.00601755, 0.07030267, -0.01776520, 0.01839696, -0.00076325, 0.01104323, 0.02832829, -0.03280117, -0.00337046, -0.00780860, -0.03206833, 0.00429599, -0.01184557, -0.01675438, 0.03259901, 0.00296771, -0.01298906, 0.01656485, -0.03224522, 0.02388067, 0.01670383, -0.02908640, -0.00439076, 0.02858099, -0.00275449, -0.01394934, 0.00354735, -0.00569219, 0.00729687, 0.03760258, 0.00782123, 0.01497280, -0.00369582, -0.00675355, -0.05898146, -0.00251442, 0.03426687, 0.01661539, -0.02134098, 0.01445475, -0.00208798, -0.03090588, -0.01585727, -0.00847195, 0.01734823, -0.00682305, -0.02551062, -0.00772015, 0.02901059, -0.00065190, 0.01340603, 0.04942920, -0.01575619, -0.06838211, 0.01427786, -0.05786956, 0.00579959, 0.01876338, -0.01080316, 0.02650881, -0.06666371, 0.00699994, -0.03242212, -0.02500521, -0.00620708, -0.00153677, 0.04664944, 0.00416016, 0.01207932, 0.00610284, -0.01629950, 0.01348184, -0.00172156, 0.01626160, -0.01191506, 0.00434969, 0.03992747, 0.01583200, 0.01334285, -0.04889851, 0.03558093, -0.01517497, 0.01446739, 0.01632477, 0.00593858, 0.00576484, 0.01589518, -0.01604680, -0.00782123, 0.00132039, -0.00378742, 0.00706312, 0.01995110, -0.01170026, 0.01056941, -0.05076854, 0.00468137, -0.01135280, -0.01362083, -0.00094765, 0.01087266, 0.03239685, -0.00669038, -0.00880047, 0.00098160, -0.01295116, -0.02650881, -0.01570565, -0.00056030, -0.01666593, 0.01856122, 0.01246470, -0.06514748, -0.01310278, -0.00050265, 0.03088061, 0.03638959, -0.01458111, -0.01408833, 0.00594174, -0.01151705, 0.01656485, -0.04131735, -0.01503598, -0.01151073, 0.00466242, -0.00382849, 0.00292033, 0.01670383, 0.03275063, 0.05994175, -0.02992033, -0.01586991, -0.04571443, 0.00629553, -0.03330658, 0.00268815, -0.01899082, 0.00272448, -0.01431577, 0.06246880, -0.01166868, -0.03901773, -0.02419655, -0.03815853, 0.01918035, -0.00805499, -0.00013316, 0.01671647, -0.00543949, -0.00147043, -0.01897818, 0.01303960, 0.05589846, -0.04005382, 0.01007031, -0.00312249, 0.00427072, 0.00582802, 0.02648354, 0.01482118, -0.02678678, 0.02289512, -0.03520188, -0.01794209, 0.02573805, -0.06418720, 0.01102428, -0.03045101, -0.05913309, 0.02911167, -0.02741855, 0.01533922, -0.00976075, 0.03201779, 0.01364610, 0.01365873, 0.00533209, -0.00362001, -0.04023072, -0.02278140, 0.02259187, -0.03676865, 0.01475800, -0.03085534, -0.00467821, 0.03755204, 0.00953332, 0.01777783, 0.00625446, 0.03302861, -0.00660825, 0.00016475, 0.04647254, 0.02529582, -0.00333255, -0.00397379, 0.01243311, -0.01887710, -0.03095642, -0.00277344, 0.01445475, -0.02221282, -0.00451711, -0.01945832, -0.00662088, 0.00458029, -0.00474139, 0.04338954, 0.02220018, -0.04695268, 0.01656485, 0.01379772, 0.00602387, -0.01559193, -0.01043674, 0.00182738, -0.02475250, -0.00564165, -0.00778965, 0.01345657, 0.01363346, 0.01918035, 0.01426522, -0.00730951, -0.00695572, -0.00381269, -0.03280117, 0.00343364, -0.01437894, 0.00012931, 0.01490962, 0.02769652, -0.04503212, -0.00600491, -0.00837719, -0.01094215, -0.01950887, 0.02858099, 0.02131571, 0.05109705, 0.01105586, -0.02178321, -0.00156835, 0.00580591, 0.00153361, 0.00375899, 0.01305224, 0.01149810, 0.05903200, -0.00631448, -0.00900263, 0.04126681, -0.00317303, 0.00129827, 0.01257842, -0.02597813, 0.01423996, 0.01766411, 0.00639345, -0.04328845, 0.01300170, 0.01809371, -0.00006722, 0.01805581, 0.01339339, 0.00578380, -0.00079760, -0.01482118, -0.02597813, 0.00301825, -0.00964071, -0.03555566, 0.00359158, 0.00338625, 0.00901527, 0.02451243, 0.01936988, -0.04376859, -0.01861176, -0.04927757, -0.01440421, 0.04584078, 0.01236362, 0.00268342, 0.01894028, 0.00018983, -0.00394221, -0.01303960, 0.00458976, 0.01605943, 0.00895841, 0.00862357, 0.02888424, 0.02369114, 0.00182422, 0.00730319, -0.01044305, -0.01322913, 0.00259181, 0.00702521, 0.00587540, -0.02623083, -0.00164732, 0.01028511, -0.00014442, 0.01914244, 0.01885183, -0.02898532, -0.00170418, -0.01178239, -0.01344393, -0.02746909, -0.02366587, 0.03590945, 0.01535186, 0.03686974, -0.02302147, -0.00300877, 0.01579410, -0.04874689, -0.00154466, -0.01074630, -0.02880843, 0.02640772, -0.00307827, 0.01065154, 0.02660989, 0.00785282, 0.01755040, 0.00279082, -0.02500521, 0.00114112, 0.00024323},
-	},
-}
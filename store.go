@@ -0,0 +1,160 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"slices"
+)
+
+// corpusStore keeps the comparison sets and the history of comparisons
+type corpusStore interface {
+	ListSets() ([]comparisonSet, error)
+	LoadSet(name string) (comparisonSet, error)
+	SaveSet(set comparisonSet) error
+	DeleteSet(name string) error
+	AppendHistory(entry historyEntry) error
+	LoadHistory() ([]historyEntry, error)
+	DeleteHistory(input string, model ModelInfo) error
+	SetSessionTitle(session, title string) error
+	AnnotateHistory(entry historyEntry) error
+}
+
+// fileStore keeps sets as JSON files and history as a JSONL file in a data directory
+type fileStore struct {
+	setsDir     string
+	historyPath string
+	// globalSetsDir is read for sets a project doesn't have, "" outside a project
+	globalSetsDir string
+}
+
+func newFileStore(dataDir string) fileStore {
+	s := fileStore{
+		setsDir:     filepath.Join(dataDir, "sets"),
+		historyPath: filepath.Join(dataDir, "history.jsonl"),
+	}
+	if dataDir == projectDataDir() {
+		if global, err := globalDataDir(); err == nil {
+			s.globalSetsDir = filepath.Join(global, "sets")
+		}
+	}
+	return s
+}
+
+// ListSets lists the project's sets and then the global sets it doesn't shadow
+func (s fileStore) ListSets() ([]comparisonSet, error) {
+	sets, err := listComparisonSets(s.setsDir)
+	if err != nil || s.globalSetsDir == "" {
+		return sets, err
+	}
+	global, err := listComparisonSets(s.globalSetsDir)
+	if err != nil {
+		return nil, err
+	}
+	for _, set := range global {
+		if !slices.ContainsFunc(sets, func(own comparisonSet) bool { return setFileName(own.Name) == setFileName(set.Name) }) {
+			sets = append(sets, set)
+		}
+	}
+	slices.SortFunc(sets, func(a, b comparisonSet) int { return b.Saved.Compare(a.Saved) })
+	return sets, nil
+}
+
+// LoadSet loads a set, falling back to the global set of that name in a
+// project. Saving it again writes it into the project.
+func (s fileStore) LoadSet(name string) (comparisonSet, error) {
+	set, err := loadComparisonSet(s.setsDir, name)
+	if err != nil && s.globalSetsDir != "" {
+		if global, globalErr := loadComparisonSet(s.globalSetsDir, name); globalErr == nil {
+			return global, nil
+		}
+	}
+	return set, err
+}
+
+func (s fileStore) SaveSet(set comparisonSet) error {
+	return saveComparisonSet(s.setsDir, set)
+}
+
+func (s fileStore) DeleteSet(name string) error {
+	path := filepath.Join(s.setsDir, setFileName(name))
+	if _, err := os.Stat(path); err != nil && s.globalSetsDir != "" {
+		if _, err := os.Stat(filepath.Join(s.globalSetsDir, setFileName(name))); err == nil {
+			return fmt.Errorf("%q is a global set • delete it outside the project", name)
+		}
+	}
+	if err := os.Remove(path); err != nil {
+		return fmt.Errorf("failed to delete set: %w", err)
+	}
+	return nil
+}
+
+func (s fileStore) AppendHistory(entry historyEntry) error {
+	return appendHistory(s.historyPath, entry)
+}
+
+func (s fileStore) LoadHistory() ([]historyEntry, error) {
+	return loadHistory(s.historyPath)
+}
+
+func (s fileStore) DeleteHistory(input string, model ModelInfo) error {
+	history, err := loadHistory(s.historyPath)
+	if err != nil {
+		return err
+	}
+	history = slices.DeleteFunc(history, func(entry historyEntry) bool {
+		return entry.Input == input && entry.Model.Provider == model.Provider && entry.Model.Model == model.Model
+	})
+	return writeHistory(s.historyPath, history)
+}
+
+// AnnotateHistory replaces the notes of the stored entry of the same session and time
+func (s fileStore) AnnotateHistory(entry historyEntry) error {
+	history, err := loadHistory(s.historyPath)
+	if err != nil {
+		return err
+	}
+	i := slices.IndexFunc(history, func(e historyEntry) bool { return e.Session == entry.Session && e.Time.Equal(entry.Time) })
+	if i < 0 {
+		return fmt.Errorf("the comparison is no longer in the history")
+	}
+	history[i].Note, history[i].Results = entry.Note, entry.Results
+	return writeHistory(s.historyPath, history)
+}
+
+// SetSessionTitle records title on every entry of session
+func (s fileStore) SetSessionTitle(session, title string) error {
+	history, err := loadHistory(s.historyPath)
+	if err != nil {
+		return err
+	}
+	for i := range history {
+		if history[i].Session == session {
+			history[i].SessionTitle = title
+		}
+	}
+	return writeHistory(s.historyPath, history)
+}
+
+// openStore returns the Postgres store when EMBER_PGVECTOR_URL is set, and
+// the files in dataDir otherwise, with the sets of any preset bundles on
+// top. Bundles that fail to load are reported and left out.
+func openStore(dataDir string) (corpusStore, func(), error) {
+	pg, err := openPgStore()
+	if err != nil {
+		return nil, nil, err
+	}
+	var store corpusStore = newFileStore(dataDir)
+	closeStore := func() {}
+	if pg != nil {
+		store, closeStore = pg, func() { pg.Close() }
+	}
+	bundles, err := loadPresetBundles()
+	if err != nil {
+		fmt.Fprintln(os.Stderr, "⚠️  "+err.Error())
+	}
+	if store, err = withPresetBundles(store, bundles); err != nil {
+		fmt.Fprintln(os.Stderr, "⚠️  "+err.Error())
+	}
+	return store, closeStore, nil
+}
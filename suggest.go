@@ -0,0 +1,161 @@
+package main
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/charmbracelet/lipgloss"
+)
+
+// Ambiguous inputs listed on the suggestion screen
+const maxSuggestions = 10
+
+// labelSuggestion is an input whose two best labels score almost the same
+type labelSuggestion struct {
+	Input  CustomEmbedding
+	First  string
+	Second string
+	Margin float64
+}
+
+// ambiguousInputs ranks the input history by the margin between the two best
+// labels, smallest first, so labelling them teaches the set the most
+func ambiguousInputs(anchors, history []CustomEmbedding) []labelSuggestion {
+	labels := labelCentroids(anchors)
+	if len(labels) < 2 {
+		return nil
+	}
+
+	var suggestions []labelSuggestion
+	for _, input := range history {
+		type scored struct {
+			label string
+			score float64
+		}
+		scores := make([]scored, len(labels))
+		for i, label := range labels {
+			scores[i] = scored{label.Label, cosineSimilarity(input.Embedding, label.Embedding)}
+		}
+		sort.Slice(scores, func(i, j int) bool { return scores[i].score > scores[j].score })
+
+		suggestions = append(suggestions, labelSuggestion{
+			Input:  input,
+			First:  scores[0].label,
+			Second: scores[1].label,
+			Margin: scores[0].score - scores[1].score,
+		})
+	}
+
+	sort.SliceStable(suggestions, func(i, j int) bool { return suggestions[i].Margin < suggestions[j].Margin })
+	if len(suggestions) > maxSuggestions {
+		suggestions = suggestions[:maxSuggestions]
+	}
+	return suggestions
+}
+
+// taggedLabels lists the #tag labels an input can be assigned to
+func taggedLabels(anchors []CustomEmbedding) []string {
+	var tags []string
+	for _, label := range labelCentroids(anchors) {
+		if strings.HasPrefix(label.Label, "#") {
+			tags = append(tags, label.Label)
+		}
+	}
+	return tags
+}
+
+// applySuggestion appends the selected input to the comparison set under the
+// selected label. The input's own embedding lacks the tag, so the set is
+// embedded again in the background, as it would be after typing the text.
+func (m *model) applySuggestion() {
+	suggestions := ambiguousInputs(m.customEmbeddings, m.inputHistory)
+	labels := taggedLabels(m.customEmbeddings)
+	if m.selectedSuggestion >= len(suggestions) || len(labels) == 0 {
+		return
+	}
+	if len(m.embeddingTexts) >= 10 {
+		m.suggestionNotice = "⚠️  The comparison set is full (10/10) • remove a text first"
+		return
+	}
+
+	s := suggestions[m.selectedSuggestion]
+	label := labels[m.suggestionLabel%len(labels)]
+
+	text := s.Input.Text + " " + label
+	for _, ta := range m.embeddingTexts {
+		if ta.Value() == text {
+			m.suggestionNotice = "Already in the comparison set"
+			return
+		}
+	}
+
+	texts := make([]string, len(m.customEmbeddings))
+	for i, e := range m.customEmbeddings {
+		texts[i] = e.source()
+	}
+	texts = append(texts, text)
+	m.jobs.Submit(fmt.Sprintf("Embed %d comparison texts", len(texts)), m.embedComparisonsJob(texts, m.templateValues))
+
+	ta := newComparisonTextArea(len(m.embeddingTexts))
+	ta.SetValue(text)
+	m.embeddingTexts = append(m.embeddingTexts, ta)
+
+	m.suggestionNotice = fmt.Sprintf("🔁 Added %q as %s • embedding it with the label", truncateText(s.Input.Text, 30), label)
+	m.selectedSuggestion = 0
+}
+
+func (m model) renderSuggestScreen() string {
+	s := "\033[2J\033[H" // Clear screen and move cursor to top
+
+	s += "╭─────────────────────────────────────────────────────────────────────────────╮\n"
+	s += "│                          🎓 LABEL SUGGESTIONS 🎓                            │\n"
+	s += "│             Inputs your labels can't tell apart, most ambiguous first       │\n"
+	s += "╰─────────────────────────────────────────────────────────────────────────────╯\n\n"
+
+	labelStyle := lipgloss.NewStyle().
+		Foreground(theme.Primary).
+		Bold(true)
+	selectedStyle := lipgloss.NewStyle().
+		Foreground(theme.Accent).
+		Bold(true)
+	instructStyle := lipgloss.NewStyle().
+		Foreground(theme.Muted).
+		Italic(true)
+
+	suggestions := ambiguousInputs(m.customEmbeddings, m.inputHistory)
+	labels := taggedLabels(m.customEmbeddings)
+
+	switch {
+	case len(labelCentroids(m.customEmbeddings)) < 2:
+		s += instructStyle.Render("Add at least two labels to find ambiguous inputs") + "\n"
+	case len(suggestions) == 0:
+		s += instructStyle.Render("No inputs compared yet this session") + "\n"
+	default:
+		s += labelStyle.Render(fmt.Sprintf("%7s  %-36s %s", "margin", "input", "top two labels")) + "\n"
+		for i, sug := range suggestions {
+			line := fmt.Sprintf("%7.3f  %-36s %s / %s", sug.Margin, truncateText(sug.Input.Text, 36), sug.First, sug.Second)
+			if i == m.selectedSuggestion {
+				s += selectedStyle.Render("▸"+line[1:]) + "\n"
+			} else {
+				s += line + "\n"
+			}
+		}
+		s += "\n"
+
+		if len(labels) == 0 {
+			s += instructStyle.Render("Tag comparison texts with #label to assign inputs to labels") + "\n"
+		} else {
+			s += labelStyle.Render("Label: ") + selectedStyle.Render(labels[m.suggestionLabel%len(labels)]) +
+				instructStyle.Render(fmt.Sprintf("  (%d of %d, ←/→ to change)", m.suggestionLabel%len(labels)+1, len(labels))) + "\n"
+		}
+	}
+
+	if m.suggestionNotice != "" {
+		s += "\n" + lipgloss.NewStyle().Foreground(theme.Muted).Render(m.suggestionNotice) + "\n"
+	}
+
+	s += "\n" + instructStyle.Render("💡 ↑/↓ to select • Enter to add as a comparison text • Esc to return") + "\n"
+
+	return s
+}
@@ -0,0 +1,209 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"strconv"
+	"strings"
+)
+
+// Name of the field a generated summary is embedded as
+const summaryField = "summary"
+
+// Model used for summaries unless EMBER_SUMMARY_MODEL says otherwise
+const defaultSummaryModel = "gpt-4o-mini"
+
+// Share of the score given to the summary unless EMBER_SUMMARY_WEIGHT says otherwise
+const defaultSummaryWeight = 0.5
+
+const summaryPrompt = "Summarize the following document in a single paragraph of at most 150 words. " +
+	"Cover every main topic it discusses, keep names and key terms, and reply with the summary only."
+
+// summarizer is implemented by providers that can summarize text with an LLM
+type summarizer interface {
+	Summarize(text string) (string, error)
+}
+
+type summaryOptions struct {
+	// words is the length above which documents are summarized; zero turns summaries off
+	words  int
+	weight float64
+}
+
+// loadSummaryOptions reads EMBER_SUMMARIZE_WORDS and EMBER_SUMMARY_WEIGHT
+func loadSummaryOptions() (summaryOptions, error) {
+	opts := summaryOptions{weight: defaultSummaryWeight}
+	if value := os.Getenv("EMBER_SUMMARIZE_WORDS"); value != "" {
+		words, err := strconv.Atoi(value)
+		if err != nil || words < 1 {
+			return opts, fmt.Errorf("invalid EMBER_SUMMARIZE_WORDS %q", value)
+		}
+		opts.words = words
+	}
+	if value := os.Getenv("EMBER_SUMMARY_WEIGHT"); value != "" {
+		weight, err := strconv.ParseFloat(value, 64)
+		if err != nil || weight < 0 || weight > 1 {
+			return opts, fmt.Errorf("invalid EMBER_SUMMARY_WEIGHT %q: use a number from 0 to 1", value)
+		}
+		opts.weight = weight
+	}
+	return opts, nil
+}
+
+// fieldWeights adds the summary to weights. Without configured fields, the
+// whole text counts as the body and shares the score with the summary.
+func (o summaryOptions) fieldWeights(weights []fieldWeight) []fieldWeight {
+	if o.words == 0 || hasField(weights, summaryField) {
+		return weights
+	}
+	if len(weights) == 0 {
+		return []fieldWeight{{Name: bodyField, Weight: 1 - o.weight}, {Name: summaryField, Weight: o.weight}}
+	}
+	return append(weights, fieldWeight{Name: summaryField, Weight: o.weight})
+}
+
+// summarize returns an LLM summary of text when it is long enough, reusing
+// previous, a summary made for the same text earlier
+func (o summaryOptions) summarize(provider EmbeddingProvider, text, previous string) (string, error) {
+	if o.words == 0 || len(strings.Fields(text)) <= o.words {
+		return "", nil
+	}
+	if previous != "" {
+		return previous, nil
+	}
+
+	s, ok := provider.(summarizer)
+	if !ok {
+		return "", fmt.Errorf("provider %q can't summarize documents", provider.ModelInfo().Provider)
+	}
+	summary, err := s.Summarize(text)
+	if err != nil {
+		return "", fmt.Errorf("failed to summarize: %w", err)
+	}
+	return summary, nil
+}
+
+// addSummaryField embeds summary into fields, adding the whole text's vector
+// as the body when the text has no labelled fields
+func addSummaryField(provider EmbeddingProvider, fields map[string][]float64, summary string, whole []float64) (map[string][]float64, error) {
+	if summary == "" {
+		return fields, nil
+	}
+	vector, err := provider.GenerateEmbedding(summary)
+	if err != nil {
+		return nil, fmt.Errorf("failed to embed summary: %w", err)
+	}
+
+	if fields == nil {
+		fields = map[string][]float64{bodyField: whole}
+	}
+	if _, ok := fields[summaryField]; !ok {
+		fields[summaryField] = vector
+	}
+	return fields, nil
+}
+
+type openAIChatMessage struct {
+	Role    string `json:"role"`
+	Content string `json:"content"`
+}
+
+type openAIChatRequest struct {
+	Model    string              `json:"model"`
+	Messages []openAIChatMessage `json:"messages"`
+}
+
+type openAIChatResponse struct {
+	Choices []struct {
+		Message openAIChatMessage `json:"message"`
+	} `json:"choices"`
+	Usage struct {
+		TotalTokens int `json:"total_tokens"`
+	} `json:"usage"`
+}
+
+// Summarize asks the chat completions endpoint of the same server for a
+// summary, with EMBER_SUMMARY_MODEL
+func (e *OpenAIProvider) Summarize(text string) (string, error) {
+	return e.chat("summary", summaryModel(), summaryPrompt, text)
+}
+
+// summaryModel is the chat model summaries are written with
+func summaryModel() string {
+	if model := os.Getenv("EMBER_SUMMARY_MODEL"); model != "" {
+		return model
+	}
+	return defaultSummaryModel
+}
+
+// chat sends text to the chat completions endpoint with a system prompt and
+// returns the reply, recording the call in the audit log under purpose
+func (e *OpenAIProvider) chat(purpose, model, prompt, text string) (reply string, err error) {
+	key, err := e.keys.Acquire()
+	if err != nil {
+		return "", err
+	}
+
+	jsonData, err := json.Marshal(openAIChatRequest{
+		Model: model,
+		Messages: []openAIChatMessage{
+			{Role: "system", Content: prompt},
+			{Role: "user", Content: text},
+		},
+	})
+	if err != nil {
+		return "", fmt.Errorf("failed to marshal request: %w", err)
+	}
+
+	req, err := http.NewRequest("POST", e.baseURL+"/chat/completions", bytes.NewBuffer(jsonData))
+	if err != nil {
+		return "", fmt.Errorf("failed to create request: %w", err)
+	}
+	req.Header.Set("Content-Type", "application/json")
+	if key.value != "" {
+		req.Header.Set("Authorization", "Bearer "+key.value)
+	}
+
+	tokens := 0
+	defer func() { auditCall(e.auditInfo(model), purpose, []string{text}, tokens, err) }()
+
+	resp, err := e.client.Do(req)
+	if err != nil {
+		e.keys.Record(key, 0, true)
+		return "", fmt.Errorf("failed to make request: %w", err)
+	}
+	defer resp.Body.Close()
+
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return "", fmt.Errorf("failed to read response: %w", err)
+	}
+	if resp.StatusCode == http.StatusTooManyRequests {
+		e.keys.Record(key, 0, true)
+		wait := retryAfter(resp)
+		e.keys.Cooldown(key, wait)
+		if wait <= 0 {
+			wait = rateLimitCooldown
+		}
+		return "", &RateLimitError{RetryAfter: wait}
+	}
+	if resp.StatusCode != http.StatusOK {
+		e.keys.Record(key, 0, true)
+		return "", &APIStatusError{StatusCode: resp.StatusCode, Body: string(body)}
+	}
+
+	var chatResp openAIChatResponse
+	if err := json.Unmarshal(body, &chatResp); err != nil {
+		return "", fmt.Errorf("failed to unmarshal response: %w", err)
+	}
+	tokens = chatResp.Usage.TotalTokens
+	e.keys.Record(key, tokens, false)
+	if len(chatResp.Choices) == 0 || strings.TrimSpace(chatResp.Choices[0].Message.Content) == "" {
+		return "", fmt.Errorf("empty %s", purpose)
+	}
+	return strings.TrimSpace(chatResp.Choices[0].Message.Content), nil
+}
@@ -0,0 +1,168 @@
+package main
+
+import (
+	"fmt"
+	"maps"
+	"regexp"
+	"slices"
+	"strings"
+
+	"github.com/charmbracelet/bubbles/textinput"
+	"github.com/charmbracelet/lipgloss"
+)
+
+// templateVarPattern matches a {{variable}} in a comparison text
+var templateVarPattern = regexp.MustCompile(`\{\{\s*([A-Za-z_][A-Za-z0-9_]*)\s*\}\}`)
+
+// templateVars lists the variables used in texts, in order of first use
+func templateVars(texts ...string) []string {
+	var names []string
+	for _, text := range texts {
+		for _, match := range templateVarPattern.FindAllStringSubmatch(text, -1) {
+			if !slices.Contains(names, match[1]) {
+				names = append(names, match[1])
+			}
+		}
+	}
+	return names
+}
+
+// resolveTemplate substitutes values into text. Variables without a value
+// are left as they are.
+func resolveTemplate(text string, values map[string]string) string {
+	return templateVarPattern.ReplaceAllStringFunc(text, func(match string) string {
+		name := templateVarPattern.FindStringSubmatch(match)[1]
+		if value, ok := values[name]; ok && value != "" {
+			return value
+		}
+		return match
+	})
+}
+
+// missingTemplateValues lists the variables used in texts that have no value
+func missingTemplateValues(values map[string]string, texts ...string) []string {
+	var missing []string
+	for _, name := range templateVars(texts...) {
+		if values[name] == "" {
+			missing = append(missing, name)
+		}
+	}
+	return missing
+}
+
+// source is the text as written: the template it was resolved from, if any
+func (e CustomEmbedding) source() string {
+	if e.Template != "" {
+		return e.Template
+	}
+	return e.Text
+}
+
+// usedTemplateValues is the values of the variables the comparison texts
+// use, which is what a saved set keeps
+func (m model) usedTemplateValues() map[string]string {
+	var values map[string]string
+	for _, name := range templateVars(m.comparisonValues()...) {
+		if value := m.templateValues[name]; value != "" {
+			if values == nil {
+				values = make(map[string]string)
+			}
+			values[name] = value
+		}
+	}
+	return values
+}
+
+// openTemplateValues shows an input for each variable in the comparison texts
+func (m *model) openTemplateValues() {
+	m.templateNames = templateVars(m.comparisonValues()...)
+	if len(m.templateNames) == 0 {
+		m.comparisonNotice = "🧩 No {{variables}} in the comparison texts • write one like {{product}} to reuse the set"
+		return
+	}
+	m.templateInputs = make([]textinput.Model, len(m.templateNames))
+	for i, name := range m.templateNames {
+		ti := textinput.New()
+		ti.Placeholder = "value of " + name
+		ti.Width = 50
+		ti.SetValue(m.templateValues[name])
+		m.templateInputs[i] = ti
+	}
+	m.selectedTemplateVar = 0
+	m.templateInputs[0].Focus()
+	m.currentScreen = templateScreen
+}
+
+// moveTemplateFocus focuses the next or previous variable's input
+func (m *model) moveTemplateFocus(delta int) {
+	m.templateInputs[m.selectedTemplateVar].Blur()
+	m.selectedTemplateVar = (m.selectedTemplateVar + delta + len(m.templateInputs)) % len(m.templateInputs)
+	m.templateInputs[m.selectedTemplateVar].Focus()
+}
+
+// applyTemplateValues keeps the values typed on the values pane and, when
+// that changes what the embedded set's texts resolve to, re-embeds it. The
+// cache is keyed on the resolved texts, so values used before are free.
+func (m *model) applyTemplateValues() {
+	values := maps.Clone(m.templateValues)
+	if values == nil {
+		values = make(map[string]string)
+	}
+	for i, name := range m.templateNames {
+		values[name] = strings.TrimSpace(m.templateInputs[i].Value())
+	}
+	m.templateValues = values
+	m.currentScreen = embeddingsScreen
+
+	changed := false
+	texts := make([]string, len(m.customEmbeddings))
+	for i, e := range m.customEmbeddings {
+		texts[i] = e.source()
+		if resolveTemplate(texts[i], values) != e.Text {
+			changed = true
+		}
+	}
+	if !changed {
+		m.comparisonNotice = "🧩 Template values saved"
+		return
+	}
+	m.jobs.Submit(fmt.Sprintf("Re-embed %d comparison texts with new values", len(texts)), m.embedComparisonsJob(texts, values))
+	m.comparisonNotice = fmt.Sprintf("🧩 Template values saved • re-embedding %d texts", len(texts))
+}
+
+func (m model) renderTemplateScreen() string {
+	s := "\033[2J\033[H" // Clear screen and move cursor to top
+
+	s += "╭─────────────────────────────────────────────────────────────────────────────╮\n"
+	s += "│                           🧩 TEMPLATE VALUES 🧩                             │\n"
+	s += "│             Substituted into the comparison texts before embedding          │\n"
+	s += "╰─────────────────────────────────────────────────────────────────────────────╯\n\n"
+	s += m.renderActiveSet()
+
+	labelStyle := lipgloss.NewStyle().
+		Foreground(theme.Primary).
+		Bold(true)
+	mutedStyle := lipgloss.NewStyle().
+		Foreground(theme.Muted)
+
+	for i, name := range m.templateNames {
+		s += labelStyle.Render("{{"+name+"}}") + "\n"
+		s += "  " + m.templateInputs[i].View() + "\n\n"
+	}
+
+	// Preview what the texts become with the values typed so far
+	values := make(map[string]string, len(m.templateNames))
+	for i, name := range m.templateNames {
+		values[name] = strings.TrimSpace(m.templateInputs[i].Value())
+	}
+	s += labelStyle.Render("Preview") + "\n"
+	for _, text := range m.comparisonValues() {
+		if len(templateVars(text)) > 0 {
+			s += mutedStyle.Render("  → "+truncateText(strings.ReplaceAll(resolveTemplate(text, values), "\n", " "), 72)) + "\n"
+		}
+	}
+	s += "\n"
+
+	s += mutedStyle.Italic(true).Render("💡 Tab/↑/↓ to move • Enter to apply • Esc to cancel") + "\n"
+	return s
+}
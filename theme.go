@@ -0,0 +1,121 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"sort"
+	"strings"
+
+	"github.com/charmbracelet/bubbles/progress"
+	"github.com/charmbracelet/lipgloss"
+)
+
+// Theme holds every color the TUI uses
+type Theme struct {
+	Primary lipgloss.Color
+	Accent  lipgloss.Color
+	Muted   lipgloss.Color
+	Warning lipgloss.Color
+
+	// Progress bars use a gradient unless SolidFill is set
+	GradientStart string
+	GradientEnd   string
+	SolidFill     string
+
+	// Colors for strong, moderate and weak similarity grades
+	Grades [3]lipgloss.Color
+}
+
+var themes = map[string]Theme{
+	"default": {
+		Primary:       "#9567E3",
+		Accent:        "#C967E3",
+		Muted:         "#666666",
+		Warning:       "#ff6b6b",
+		GradientStart: "#5A56E0",
+		GradientEnd:   "#EE6FF8",
+		Grades:        [3]lipgloss.Color{"#C967E3", "#9567E3", "#666666"},
+	},
+	// Okabe-Ito palette, distinguishable with the common forms of color blindness
+	"colorblind": {
+		Primary:       "#0072B2",
+		Accent:        "#56B4E9",
+		Muted:         "#999999",
+		Warning:       "#E69F00",
+		GradientStart: "#0072B2",
+		GradientEnd:   "#E69F00",
+		Grades:        [3]lipgloss.Color{"#0072B2", "#F0E442", "#D55E00"},
+	},
+	"high-contrast": {
+		Primary:   "#FFFFFF",
+		Accent:    "#FFFF00",
+		Muted:     "#C0C0C0",
+		Warning:   "#FF5555",
+		SolidFill: "#FFFF00",
+		Grades:    [3]lipgloss.Color{"#FFFFFF", "#FFFF00", "#C0C0C0"},
+	},
+}
+
+// theme is the active theme; set it with applyTheme
+var theme = themes["default"]
+
+// loadTheme reads EMBER_THEME, defaulting to the original purple palette
+func loadTheme() (Theme, error) {
+	name := os.Getenv("EMBER_THEME")
+	if name == "" {
+		return themes["default"], nil
+	}
+
+	t, ok := themes[strings.ToLower(name)]
+	if !ok {
+		names := make([]string, 0, len(themes))
+		for n := range themes {
+			names = append(names, n)
+		}
+		sort.Strings(names)
+		return Theme{}, fmt.Errorf("unknown theme %q (available: %s)", name, strings.Join(names, ", "))
+	}
+	return t, nil
+}
+
+func applyTheme(t Theme) {
+	theme = t
+
+	staticTextStyle = lipgloss.NewStyle().
+		Foreground(theme.Primary).
+		Bold(true).
+		Width(80).
+		Align(lipgloss.Left)
+
+	userInputStyle = lipgloss.NewStyle().
+		Foreground(theme.Accent).
+		Bold(true).
+		Width(80).
+		Align(lipgloss.Left)
+}
+
+// newProgressBar creates a progress bar in the active theme's colors
+func newProgressBar() progress.Model {
+	if theme.SolidFill != "" {
+		return progress.New(progress.WithSolidFill(theme.SolidFill))
+	}
+	return progress.New(progress.WithGradient(theme.GradientStart, theme.GradientEnd))
+}
+
+// scoreGrade labels a similarity with a shape and a word so the grade
+// doesn't depend on telling colors apart
+func scoreGrade(similarity float64) string {
+	var symbol, label string
+	var color lipgloss.Color
+
+	switch {
+	case similarity >= 0.5:
+		symbol, label, color = "▲", "strong", theme.Grades[0]
+	case similarity >= 0.3:
+		symbol, label, color = "■", "moderate", theme.Grades[1]
+	default:
+		symbol, label, color = "▼", "weak", theme.Grades[2]
+	}
+
+	return lipgloss.NewStyle().Foreground(color).Bold(true).Render(symbol + " " + label)
+}
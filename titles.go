@@ -0,0 +1,164 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"strings"
+
+	tea "github.com/charmbracelet/bubbletea"
+)
+
+// Comparisons a session has when it's given a title with EMBER_AUTO_TITLE
+const sessionTitleAfter = 3
+
+const titlePrompt = "Write a short, descriptive title of at most six words for the following texts, " +
+	"which were compared with text embeddings. Reply with the title only, without quotes."
+
+// titler is implemented by providers that can title text with an LLM
+type titler interface {
+	Title(text string) (string, error)
+}
+
+// Title asks the chat completions endpoint for a title, with
+// EMBER_TITLE_MODEL, falling back to the summary model
+func (e *OpenAIProvider) Title(text string) (string, error) {
+	model := os.Getenv("EMBER_TITLE_MODEL")
+	if model == "" {
+		model = summaryModel()
+	}
+	return e.chat("title", model, titlePrompt, text)
+}
+
+// autoTitles reports whether EMBER_AUTO_TITLE asks for sets and sessions to
+// be titled without pressing a key
+func autoTitles() bool {
+	return os.Getenv("EMBER_AUTO_TITLE") != ""
+}
+
+// cleanTitle trims what models tend to wrap titles in: quotes, a label, a
+// final full stop and extra lines
+func cleanTitle(title string) string {
+	title, _, _ = strings.Cut(strings.TrimSpace(title), "\n")
+	title = strings.TrimPrefix(title, "Title:")
+	title = strings.Trim(strings.TrimSpace(title), "\"'`*")
+	title = strings.TrimSuffix(title, ".")
+	return truncateText(strings.Join(strings.Fields(title), " "), 48)
+}
+
+// titleMsg delivers a generated title. session is "" for the name of the set
+// being saved.
+type titleMsg struct {
+	session string
+	title   string
+	err     error
+	// replace is set when the title was asked for, so it replaces a typed name
+	replace bool
+}
+
+// generateTitle titles texts in the background
+func (m model) generateTitle(texts []string, session string, replace bool) tea.Cmd {
+	t, ok := m.provider.(titler)
+	if !ok {
+		err := fmt.Errorf("provider %q can't generate titles", m.provider.ModelInfo().Provider)
+		return func() tea.Msg { return titleMsg{session: session, err: err, replace: replace} }
+	}
+	text := strings.Join(texts, "\n")
+	return func() tea.Msg {
+		title, err := t.Title(text)
+		return titleMsg{session: session, title: cleanTitle(title), err: err, replace: replace}
+	}
+}
+
+// titleSetName suggests a name for the set being saved from its texts
+func (m *model) titleSetName(replace bool) tea.Cmd {
+	if len(m.customEmbeddings) == 0 {
+		return nil
+	}
+	texts := make([]string, len(m.customEmbeddings))
+	for i, e := range m.customEmbeddings {
+		texts[i] = e.Text
+	}
+	m.setNotice = "✨ Suggesting a name…"
+	return m.generateTitle(texts, "", replace)
+}
+
+// titleSession titles session from the inputs compared in it
+func (m *model) titleSession(session string) tea.Cmd {
+	var inputs []string
+	for _, entry := range m.history {
+		if entry.Session == session {
+			inputs = append(inputs, entry.Input)
+		}
+	}
+	if session == m.sessionID {
+		inputs = m.sessionInputs
+	}
+	if len(inputs) == 0 {
+		return nil
+	}
+	m.historyNotice = "✨ Titling the session…"
+	return m.generateTitle(inputs, session, true)
+}
+
+// autoTitleSession titles the current session once it has enough
+// comparisons to say what it was about
+func (m *model) autoTitleSession() tea.Cmd {
+	if !autoTitles() || m.sessionTitles[m.sessionID] != "" || len(m.sessionInputs) != sessionTitleAfter {
+		return nil
+	}
+	return m.titleSession(m.sessionID)
+}
+
+func (m *model) handleTitle(msg titleMsg) {
+	if msg.session == "" {
+		if m.currentScreen != setsScreen || !m.savingSet {
+			return
+		}
+		m.setNotice = ""
+		if msg.err != nil {
+			m.setNotice = "⚠️  " + msg.err.Error()
+			return
+		}
+		if msg.replace || strings.TrimSpace(m.setNameInput.Value()) == "" {
+			m.setNameInput.SetValue(msg.title)
+			m.setNameInput.CursorEnd()
+			m.setNotice = "✨ Suggested a name • edit it or press Enter to save"
+		}
+		return
+	}
+
+	if msg.err != nil {
+		// A failed automatic title isn't worth interrupting the session for
+		if m.currentScreen == historyScreen {
+			m.historyNotice = "⚠️  " + msg.err.Error()
+		}
+		return
+	}
+	if err := m.store.SetSessionTitle(msg.session, msg.title); err != nil {
+		m.historyNotice = "⚠️  " + err.Error()
+		return
+	}
+	if m.sessionTitles == nil {
+		m.sessionTitles = make(map[string]string)
+	}
+	m.sessionTitles[msg.session] = msg.title
+	for i := range m.history {
+		if m.history[i].Session == msg.session {
+			m.history[i].SessionTitle = msg.title
+		}
+	}
+	if m.currentScreen == historyScreen {
+		m.historyNotice = fmt.Sprintf("✨ Titled the session %q", msg.title)
+	}
+}
+
+// historySessionTitles collects the title of each session in entries
+func historySessionTitles(entries []historyEntry) map[string]string {
+	titles := make(map[string]string)
+	for _, entry := range entries {
+		if entry.SessionTitle != "" && titles[entry.Session] == "" {
+			titles[entry.Session] = entry.SessionTitle
+		}
+	}
+	return titles
+}
@@ -0,0 +1,406 @@
+package main
+
+import (
+	"encoding/csv"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"math"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/charmbracelet/lipgloss"
+)
+
+// Size of the precision/recall plot in characters
+const (
+	curvePlotWidth  = 50
+	curvePlotHeight = 12
+)
+
+// Thresholds listed under the plot
+const tuneTableRows = 8
+
+// labeledPair is one row of a pairs file: two texts and whether they match
+type labeledPair struct {
+	A     string
+	B     string
+	Match bool
+}
+
+// thresholdPoint is how pairs are classified when scores at or above Threshold count as matches
+type thresholdPoint struct {
+	Threshold float64 `json:"threshold"`
+	Precision float64 `json:"precision"`
+	Recall    float64 `json:"recall"`
+	F1        float64 `json:"f1"`
+}
+
+// tuneReport is a threshold sweep over a pairs file
+type tuneReport struct {
+	// Curve has a point for every distinct score, highest threshold first
+	Curve     []thresholdPoint
+	Best      thresholdPoint
+	Pairs     int
+	Positives int
+	// Dimensions is the size of the vectors the pairs were scored with
+	Dimensions int
+}
+
+// readPairsFile reads a CSV with a header naming two text columns (text_a
+// and text_b, or a and b) and a match column holding 1/0, true/false or
+// yes/no. Other columns are ignored.
+func readPairsFile(path string) ([]labeledPair, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open %s: %w", path, err)
+	}
+	defer f.Close()
+
+	r := csv.NewReader(f)
+	r.FieldsPerRecord = -1
+	header, err := r.Read()
+	if err != nil {
+		return nil, fmt.Errorf("failed to read %s: %w", path, err)
+	}
+	aColumn, bColumn, matchColumn := -1, -1, -1
+	for i, name := range header {
+		switch strings.ToLower(strings.TrimSpace(name)) {
+		case "text_a", "a":
+			aColumn = i
+		case "text_b", "b":
+			bColumn = i
+		case "match", "label":
+			matchColumn = i
+		}
+	}
+	if aColumn < 0 || bColumn < 0 || matchColumn < 0 {
+		return nil, fmt.Errorf("%s needs a header with text_a, text_b and match columns", path)
+	}
+
+	var pairs []labeledPair
+	for line := 2; ; line++ {
+		record, err := r.Read()
+		if errors.Is(err, io.EOF) {
+			break
+		}
+		if err != nil {
+			return nil, fmt.Errorf("failed to read %s: %w", path, err)
+		}
+		if max(aColumn, bColumn, matchColumn) >= len(record) {
+			continue
+		}
+		a, b := strings.TrimSpace(record[aColumn]), strings.TrimSpace(record[bColumn])
+		if a == "" || b == "" {
+			continue
+		}
+		var match bool
+		switch strings.ToLower(strings.TrimSpace(record[matchColumn])) {
+		case "1", "true", "yes", "match":
+			match = true
+		case "0", "false", "no", "":
+		default:
+			return nil, fmt.Errorf("%s line %d: match must be 1/0, true/false or yes/no, not %q", path, line, record[matchColumn])
+		}
+		pairs = append(pairs, labeledPair{A: a, B: b, Match: match})
+	}
+	if len(pairs) == 0 {
+		return nil, fmt.Errorf("no pairs found in %s", path)
+	}
+	return pairs, nil
+}
+
+// sweepThresholds tries every distinct score as the threshold and picks the
+// one with the best F1, preferring the higher threshold on ties
+func sweepThresholds(scores []float64, matches []bool) (tuneReport, error) {
+	report := tuneReport{Pairs: len(scores)}
+	for _, match := range matches {
+		if match {
+			report.Positives++
+		}
+	}
+	if report.Positives == 0 {
+		return report, fmt.Errorf("the pairs file has no matching pairs")
+	}
+
+	order := make([]int, len(scores))
+	for i := range order {
+		order[i] = i
+	}
+	sort.Slice(order, func(i, j int) bool { return scores[order[i]] > scores[order[j]] })
+
+	truePositives, falsePositives := 0, 0
+	for n, i := range order {
+		if matches[i] {
+			truePositives++
+		} else {
+			falsePositives++
+		}
+		// Pairs with equal scores are on the same side of any threshold
+		if n+1 < len(order) && scores[order[n+1]] == scores[i] {
+			continue
+		}
+		point := thresholdPoint{
+			Threshold: scores[i],
+			Precision: float64(truePositives) / float64(truePositives+falsePositives),
+			Recall:    float64(truePositives) / float64(report.Positives),
+		}
+		if point.Precision+point.Recall > 0 {
+			point.F1 = 2 * point.Precision * point.Recall / (point.Precision + point.Recall)
+		}
+		report.Curve = append(report.Curve, point)
+		if point.F1 > report.Best.F1 {
+			report.Best = point
+		}
+	}
+	return report, nil
+}
+
+// runTune embeds both sides of every pair, text_a as the input and text_b as
+// a comparison text, and sweeps thresholds over their similarities
+func runTune(queries, documents EmbeddingProvider, path string) (tuneReport, error) {
+	pairs, err := readPairsFile(path)
+	if err != nil {
+		return tuneReport{}, err
+	}
+	as := make([]string, len(pairs))
+	bs := make([]string, len(pairs))
+	matches := make([]bool, len(pairs))
+	for i, p := range pairs {
+		as[i], bs[i], matches[i] = p.A, p.B, p.Match
+	}
+
+	aVectors, err := embedInBatches(queries, as, "text_a texts")
+	if err != nil {
+		return tuneReport{}, err
+	}
+	bVectors, err := embedInBatches(documents, bs, "text_b texts")
+	if err != nil {
+		return tuneReport{}, err
+	}
+	scores := make([]float64, len(pairs))
+	for i := range pairs {
+		scores[i] = cosineSimilarity(aVectors[i], bVectors[i])
+	}
+	report, err := sweepThresholds(scores, matches)
+	report.Dimensions = len(aVectors[0])
+	return report, err
+}
+
+// renderTuneReport plots precision against recall, marking the best-F1
+// threshold, and lists a few thresholds along the curve
+func renderTuneReport(report tuneReport, path string) string {
+	labelStyle := lipgloss.NewStyle().
+		Foreground(theme.Primary).
+		Bold(true)
+	curveStyle := lipgloss.NewStyle().
+		Foreground(theme.Primary)
+	bestStyle := lipgloss.NewStyle().
+		Foreground(theme.Accent).
+		Bold(true)
+	mutedStyle := lipgloss.NewStyle().
+		Foreground(theme.Muted)
+
+	s := labelStyle.Render(fmt.Sprintf("🎚  %s • %d pairs, %d matching", path, report.Pairs, report.Positives)) + "\n\n"
+
+	grid := make([][]rune, curvePlotHeight)
+	for y := range grid {
+		grid[y] = []rune(strings.Repeat(" ", curvePlotWidth))
+	}
+	cell := func(p thresholdPoint) (int, int) {
+		x := int(math.Round(p.Recall * float64(curvePlotWidth-1)))
+		y := int(math.Round((1 - p.Precision) * float64(curvePlotHeight-1)))
+		return x, y
+	}
+	for _, p := range report.Curve {
+		x, y := cell(p)
+		grid[y][x] = '•'
+	}
+	bestX, bestY := cell(report.Best)
+
+	s += mutedStyle.Render("precision") + "\n"
+	for y, row := range grid {
+		axis := "     │"
+		switch y {
+		case 0:
+			axis = " 1.0 ┤"
+		case curvePlotHeight / 2:
+			axis = " 0.5 ┤"
+		case curvePlotHeight - 1:
+			axis = " 0.0 ┤"
+		}
+		line := mutedStyle.Render(axis)
+		for x, r := range row {
+			switch {
+			case x == bestX && y == bestY:
+				line += bestStyle.Render("◆")
+			case r != ' ':
+				line += curveStyle.Render(string(r))
+			default:
+				line += " "
+			}
+		}
+		s += line + "\n"
+	}
+	s += mutedStyle.Render("     └"+strings.Repeat("─", curvePlotWidth)) + "\n"
+	s += mutedStyle.Render(fmt.Sprintf("      0.0%*s%*s recall", curvePlotWidth/2, "0.5", curvePlotWidth/2-3, "1.0")) + "\n\n"
+
+	s += fmt.Sprintf("  %-10s %-10s %-10s %s\n", "threshold", "precision", "recall", "f1")
+	for _, p := range tuneTableSample(report.Curve) {
+		row := fmt.Sprintf("  %-10.4f %-10.4f %-10.4f %.4f", p.Threshold, p.Precision, p.Recall, p.F1)
+		if p == report.Best {
+			row = bestStyle.Render(row + "  ◆ best F1")
+		}
+		s += row + "\n"
+	}
+	s += "\n" + bestStyle.Render(fmt.Sprintf("◆ Best F1 %.4f at threshold %.4f (precision %.4f, recall %.4f)",
+		report.Best.F1, report.Best.Threshold, report.Best.Precision, report.Best.Recall)) + "\n"
+	return s
+}
+
+// tuneTableSample picks points evenly spaced along the curve, always including the best
+func tuneTableSample(curve []thresholdPoint) []thresholdPoint {
+	if len(curve) <= tuneTableRows {
+		return curve
+	}
+	best := 0
+	for i, p := range curve {
+		if p.F1 > curve[best].F1 {
+			best = i
+		}
+	}
+	picked := map[int]bool{best: true}
+	for row := 0; row < tuneTableRows; row++ {
+		picked[row*(len(curve)-1)/(tuneTableRows-1)] = true
+	}
+	var sample []thresholdPoint
+	for i, p := range curve {
+		if picked[i] {
+			sample = append(sample, p)
+		}
+	}
+	return sample
+}
+
+// matchVerdict marks a comparison-set score as a match or not when the set has a tuned threshold
+func (m model) matchVerdict(similarity float64) string {
+	if m.matchThreshold == 0 || m.resultIndex != "" {
+		return ""
+	}
+	if similarity >= m.matchThreshold {
+		return lipgloss.NewStyle().Foreground(theme.Grades[0]).Render("  ✓ match")
+	}
+	return lipgloss.NewStyle().Foreground(theme.Muted).Render("  ✗ no match")
+}
+
+func runTuneCommand(args []string) {
+	fs := flag.NewFlagSet("tune", flag.ExitOnError)
+	name := fs.String("set", "", "set the best threshold is saved into (default: the set opened on start)")
+	noSave := fs.Bool("no-save", false, "report the thresholds without saving one")
+	dryRun := fs.Bool("dry-run", false, "report what would be embedded without calling the provider")
+	format := formatFlag(fs, "table")
+	fs.Parse(args)
+
+	if fs.NArg() != 1 {
+		fmt.Println("Usage: ember tune [--set NAME] [--no-save] [--format FORMAT] [--dry-run] pairs.csv")
+		os.Exit(2)
+	}
+	checkFormat(format)
+	path := fs.Arg(0)
+
+	provider := setupProvider()
+	defer closeProvider(provider)
+	info := provider.ModelInfo()
+
+	var store corpusStore
+	var set comparisonSet
+	save := !*noSave && !*dryRun
+	if save {
+		dataDir, err := userDataDir()
+		if err != nil {
+			displayError(err)
+			os.Exit(1)
+		}
+		var closeStore func()
+		store, closeStore, err = openStore(dataDir)
+		if err != nil {
+			displayError(err)
+			os.Exit(1)
+		}
+		defer closeStore()
+
+		if *name == "" {
+			set, err = openStartupSet(store, filepath.Join(dataDir, "sets"))
+		} else {
+			set, err = store.LoadSet(*name)
+		}
+		if err == nil && len(set.Embeddings) == 0 {
+			err = fmt.Errorf("no saved set to keep the threshold in • pass --set NAME or --no-save")
+		}
+		if err == nil && (set.Model.Provider != info.Provider || set.Model.Model != info.Model) {
+			err = fmt.Errorf("set %q was embedded with %s/%s, not %s/%s", set.Name, set.Model.Provider, set.Model.Model, info.Provider, info.Model)
+		}
+		if err != nil {
+			displayError(err)
+			os.Exit(1)
+		}
+	}
+
+	documentType := ""
+	if typed, ok := provider.(inputTypeProvider); ok && len(typed.InputTypes()) > 0 {
+		documentType = typed.InputTypes()[0]
+	}
+	queryType := queryInputTypeFor(documentType)
+	queries := withInputType(provider, queryType)
+	documents := withInputType(provider, documentType)
+	if cache := setupCache(); cache != nil {
+		defer cache.Close()
+		queries = &cachedProvider{EmbeddingProvider: queries, cache: cache, model: cacheModelKey(info, queryType)}
+		documents = &cachedProvider{EmbeddingProvider: documents, cache: cache, model: cacheModelKey(info, documentType)}
+	}
+	if *dryRun {
+		pairs, err := readPairsFile(path)
+		if err != nil {
+			displayError(err)
+			os.Exit(1)
+		}
+		plan := newEmbeddingPlan(info)
+		for _, p := range pairs {
+			plan.add(queries, p.A)
+			plan.add(documents, p.B)
+		}
+		fmt.Print(plan.render())
+		return
+	}
+	report, err := runTune(queries, documents, path)
+	if err != nil {
+		displayError(err)
+		os.Exit(1)
+	}
+	if save {
+		set.Threshold = report.Best.Threshold
+		if err := store.SaveSet(set); err != nil {
+			displayError(err)
+			os.Exit(1)
+		}
+	}
+
+	if *format != "table" {
+		output := newTuneOutput(info, path, report)
+		if save {
+			output.SavedSet = set.Name
+		}
+		if err := output.write(*format); err != nil {
+			displayError(err)
+			os.Exit(1)
+		}
+		return
+	}
+	fmt.Print(renderTuneReport(report, path))
+	if save {
+		fmt.Printf("\n💾 Saved threshold %.4f into set %q\n", set.Threshold, set.Name)
+	}
+}
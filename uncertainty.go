@@ -0,0 +1,222 @@
+package main
+
+import (
+	"fmt"
+	"math"
+	"os"
+	"slices"
+	"strconv"
+	"strings"
+
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/lipgloss"
+)
+
+// Times the input is embedded to measure score spread, unless EMBER_UNCERTAINTY_RUNS says otherwise
+const defaultUncertaintyRuns = 5
+
+// Standard deviations above this are worth pointing out
+const noisySpread = 0.01
+
+// Messages for the score spread
+type uncertaintyCompleteMsg struct {
+	seq       int
+	spreads   []scoreSpread
+	runs      int
+	perturbed bool
+	// rankChanges counts runs whose ranking differs from the first run's
+	rankChanges int
+	err         error
+}
+
+// scoreSpread summarises one comparison's scores across repeated runs
+type scoreSpread struct {
+	Text         string
+	Mean, StdDev float64
+	Min, Max     float64
+}
+
+// loadUncertaintyRuns reads EMBER_UNCERTAINTY_RUNS, falling back to the
+// default for values that aren't a number of at least 2
+func loadUncertaintyRuns() int {
+	if runs, err := strconv.Atoi(os.Getenv("EMBER_UNCERTAINTY_RUNS")); err == nil && runs >= 2 {
+		return runs
+	}
+	return defaultUncertaintyRuns
+}
+
+// toggleUncertainty turns the score spread measurement on or off
+func (m *model) toggleUncertainty() {
+	m.uncertainty = !m.uncertainty
+	m.modelNotice = ""
+}
+
+// perturbInput returns up to n variants of text that mean the same thing,
+// for providers that return the same vector for the same text every time
+func perturbInput(text string, n int) []string {
+	candidates := []string{
+		text + " ",
+		" " + text,
+		text + ".",
+		strings.TrimRight(text, ".!?"),
+		strings.ToLower(text),
+		strings.Join(strings.Fields(text), "  "),
+		text + "\n",
+		strings.ToUpper(text[:min(1, len(text))]) + text[min(1, len(text)):],
+	}
+
+	var variants []string
+	for _, c := range candidates {
+		if c != text && !slices.Contains(variants, c) && len(variants) < n {
+			variants = append(variants, c)
+		}
+	}
+	return variants
+}
+
+// generateUncertainty embeds the input several times and measures how much
+// each score moves. When the first two vectors are identical the provider is
+// deterministic, so the remaining runs use slightly perturbed inputs instead.
+// Results skip the cache, which would hide any variation.
+func (m model) generateUncertainty(text string) tea.Cmd {
+	if !m.uncertainty || m.useOverride || len(m.customEmbeddings) == 0 {
+		return nil
+	}
+
+	seq, runs := m.comparisonSeq, m.uncertaintyRuns
+	provider := lanedProvider{EmbeddingProvider: withInputType(m.provider, m.queryInputType()), scheduler: m.scheduler, lane: backgroundLane}
+	comparisons := m.customEmbeddings
+
+	return func() tea.Msg {
+		fail := func(err error) tea.Msg {
+			return uncertaintyCompleteMsg{seq: seq, err: err}
+		}
+
+		first, err := provider.GenerateEmbedding(text)
+		if err != nil {
+			return fail(err)
+		}
+		second, err := provider.GenerateEmbedding(text)
+		if err != nil {
+			return fail(err)
+		}
+
+		vectors := [][]float64{first}
+		perturbed := slices.Equal(first, second)
+		if perturbed {
+			variants := perturbInput(text, runs-1)
+			more, err := provider.GenerateBatch(variants)
+			if err != nil {
+				return fail(err)
+			}
+			vectors = append(vectors, more...)
+		} else {
+			vectors = append(vectors, second)
+			for len(vectors) < runs {
+				v, err := provider.GenerateEmbedding(text)
+				if err != nil {
+					return fail(err)
+				}
+				vectors = append(vectors, v)
+			}
+		}
+
+		spreads, rankChanges := scoreSpreads(vectors, comparisons)
+		return uncertaintyCompleteMsg{seq: seq, spreads: spreads, runs: len(vectors), perturbed: perturbed, rankChanges: rankChanges}
+	}
+}
+
+// scoreSpreads scores every run against the comparisons and summarises each
+// comparison's scores. It also counts the runs that rank the comparisons
+// differently from the first run.
+func scoreSpreads(vectors [][]float64, comparisons []CustomEmbedding) ([]scoreSpread, int) {
+	compared, _ := splitByDimension(comparisons, len(vectors[0]))
+
+	scores := make([][]float64, len(vectors))
+	for run, v := range vectors {
+		scores[run] = make([]float64, len(compared))
+		for i, c := range compared {
+			scores[run][i] = cosineSimilarity(v, c.Embedding)
+		}
+	}
+
+	spreads := make([]scoreSpread, len(compared))
+	for i, c := range compared {
+		s := scoreSpread{Text: c.Text, Min: math.Inf(1), Max: math.Inf(-1)}
+		for run := range scores {
+			score := scores[run][i]
+			s.Mean += score
+			s.Min = math.Min(s.Min, score)
+			s.Max = math.Max(s.Max, score)
+		}
+		s.Mean /= float64(len(scores))
+		for run := range scores {
+			s.StdDev += (scores[run][i] - s.Mean) * (scores[run][i] - s.Mean)
+		}
+		s.StdDev = math.Sqrt(s.StdDev / float64(len(scores)))
+		spreads[i] = s
+	}
+
+	rankChanges := 0
+	firstRanks := scoreRanks(scores[0])
+	for _, run := range scores[1:] {
+		if !slices.Equal(scoreRanks(run), firstRanks) {
+			rankChanges++
+		}
+	}
+	return spreads, rankChanges
+}
+
+// renderUncertaintyStatus shows on the input screen that score spread is measured
+func (m model) renderUncertaintyStatus() string {
+	if !m.uncertainty {
+		return ""
+	}
+	return lipgloss.NewStyle().
+		Foreground(theme.Accent).
+		Bold(true).
+		Render(fmt.Sprintf("🎲 Measuring score spread over %d runs • Alt+U to turn off", m.uncertaintyRuns)) + "\n"
+}
+
+// renderUncertainty lists each comparison's mean score, standard deviation
+// and range across the repeated runs
+func (m model) renderUncertainty() string {
+	labelStyle := lipgloss.NewStyle().
+		Foreground(theme.Primary).
+		Bold(true)
+	mutedStyle := lipgloss.NewStyle().
+		Foreground(theme.Muted)
+	warningStyle := lipgloss.NewStyle().
+		Foreground(theme.Warning)
+
+	s := labelStyle.Render("🎲 Score spread") + "\n"
+	switch {
+	case m.useOverride:
+		return s + mutedStyle.Render("Not measured while comparing with the large model") + "\n\n"
+	case m.uncertaintySeq != m.comparisonSeq:
+		return s + mutedStyle.Render(fmt.Sprintf("Embedding the input %d times...", m.uncertaintyRuns)) + "\n\n"
+	case m.uncertaintyResult.err != nil:
+		return s + warningStyle.Render("⚠️  "+m.uncertaintyResult.err.Error()) + "\n\n"
+	}
+
+	how := fmt.Sprintf("%d runs of the same input", m.uncertaintyResult.runs)
+	if m.uncertaintyResult.perturbed {
+		how = fmt.Sprintf("the input and %d perturbed variants • this provider is deterministic", m.uncertaintyResult.runs-1)
+	}
+	s += mutedStyle.Render("Over "+how) + "\n"
+
+	for _, spread := range m.uncertaintyResult.spreads {
+		line := fmt.Sprintf("%-40s %.3f ± %.4f  [%.3f – %.3f]", truncateText(spread.Text, 40), spread.Mean, spread.StdDev, spread.Min, spread.Max)
+		if spread.StdDev > noisySpread {
+			line = warningStyle.Render(line + "  ⚠")
+		}
+		s += line + "\n"
+	}
+
+	if m.uncertaintyResult.rankChanges == 0 {
+		s += mutedStyle.Render("✅ Every run ranks the comparisons the same way") + "\n\n"
+	} else {
+		s += warningStyle.Render(fmt.Sprintf("↕ %d of the other %d runs rank the comparisons differently from the first", m.uncertaintyResult.rankChanges, m.uncertaintyResult.runs-1)) + "\n\n"
+	}
+	return s
+}
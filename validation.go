@@ -0,0 +1,188 @@
+package main
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/charmbracelet/bubbles/textarea"
+)
+
+// OpenAI's embedding models accept at most 8191 tokens per input
+const maxComparisonTokens = 8191
+
+type comparisonIssueKind int
+
+const (
+	emptyComparison comparisonIssueKind = iota
+	duplicateComparison
+	tooLongComparison
+	whitespaceComparison
+)
+
+type comparisonIssue struct {
+	index   int
+	kind    comparisonIssueKind
+	message string
+}
+
+// blocking issues must be fixed before generating; whitespace issues are only warnings
+func (i comparisonIssue) blocking() bool {
+	return i.kind != whitespaceComparison
+}
+
+func hasBlockingIssues(issues []comparisonIssue) bool {
+	for _, issue := range issues {
+		if issue.blocking() {
+			return true
+		}
+	}
+	return false
+}
+
+// estimateTokens approximates the token count at roughly four characters per token
+func estimateTokens(text string) int {
+	return (len(text) + 3) / 4
+}
+
+// validateComparisonTexts reports empty, duplicate and over-limit entries.
+// Entries of nothing but whitespace count as empty.
+func validateComparisonTexts(texts []string) []comparisonIssue {
+	var issues []comparisonIssue
+	firstSeen := make(map[string]int)
+
+	for i, text := range texts {
+		if strings.TrimSpace(text) == "" {
+			issues = append(issues, comparisonIssue{
+				index:   i,
+				kind:    emptyComparison,
+				message: "Empty — will be removed",
+			})
+			continue
+		}
+
+		if first, ok := firstSeen[text]; ok {
+			issues = append(issues, comparisonIssue{
+				index:   i,
+				kind:    duplicateComparison,
+				message: fmt.Sprintf("Duplicate of text %d", first+1),
+			})
+			continue
+		}
+		firstSeen[text] = i
+
+		if tokens := estimateTokens(text); tokens > maxComparisonTokens {
+			issues = append(issues, comparisonIssue{
+				index:   i,
+				kind:    tooLongComparison,
+				message: fmt.Sprintf("~%d tokens, over the %d token limit — will be truncated", tokens, maxComparisonTokens),
+			})
+		}
+
+		if ws := whitespaceIssues(text); len(ws) > 0 {
+			issues = append(issues, comparisonIssue{
+				index:   i,
+				kind:    whitespaceComparison,
+				message: "Contains " + strings.Join(ws, ", ") + " • Ctrl+L to clean",
+			})
+		}
+	}
+
+	return issues
+}
+
+// fixComparisonTexts cleans whitespace, drops empty and duplicate entries and truncates long ones
+func fixComparisonTexts(texts []string) []string {
+	cleaned := make([]string, len(texts))
+	for i, text := range texts {
+		cleaned[i] = cleanWhitespace(text)
+	}
+	texts = cleaned
+
+	fixed := make([]string, 0, len(texts))
+	issues := validateComparisonTexts(texts)
+
+	byIndex := make(map[int]comparisonIssueKind, len(issues))
+	for _, issue := range issues {
+		byIndex[issue.index] = issue.kind
+	}
+
+	for i, text := range texts {
+		kind, ok := byIndex[i]
+		switch {
+		case !ok, kind == whitespaceComparison:
+			fixed = append(fixed, text)
+		case kind == tooLongComparison:
+			fixed = append(fixed, truncateToTokens(text, maxComparisonTokens))
+		}
+	}
+
+	return fixed
+}
+
+// truncateToTokens cuts text to roughly the given token budget on a rune boundary
+func truncateToTokens(text string, tokens int) string {
+	limit := tokens * 4
+	if len(text) <= limit {
+		return text
+	}
+
+	cut := 0
+	for i := range text {
+		if i > limit {
+			break
+		}
+		cut = i
+	}
+	return text[:cut]
+}
+
+func (m model) comparisonValues() []string {
+	texts := make([]string, len(m.embeddingTexts))
+	for i, ta := range m.embeddingTexts {
+		texts[i] = ta.Value()
+	}
+	return texts
+}
+
+// autoFixComparisons applies fixComparisonTexts to the configure screen's text areas
+func (m *model) autoFixComparisons() {
+	disabled := m.disabledComparisonValues()
+	m.setComparisonTextAreas(fixComparisonTexts(m.comparisonValues()))
+	m.disableComparisons(disabled)
+	m.comparisonNotice = ""
+}
+
+// setComparisonTextAreas replaces the configure screen's text areas with texts
+func (m *model) setComparisonTextAreas(texts []string) {
+	if len(texts) == 0 {
+		texts = []string{""}
+	}
+
+	m.embeddingTexts = make([]textarea.Model, len(texts))
+	m.disabledComparisons = nil
+	for i, text := range texts {
+		m.embeddingTexts[i] = newComparisonTextArea(i)
+		m.embeddingTexts[i].SetValue(text)
+	}
+
+	m.selectedTextArea = 0
+	m.embeddingTexts[0].Focus()
+}
+
+// cleanComparisonWhitespace normalizes every comparison text in place
+func (m *model) cleanComparisonWhitespace() {
+	for i := range m.embeddingTexts {
+		if cleaned := cleanWhitespace(m.embeddingTexts[i].Value()); cleaned != m.embeddingTexts[i].Value() {
+			m.embeddingTexts[i].SetValue(cleaned)
+		}
+	}
+}
+
+func newComparisonTextArea(i int) textarea.Model {
+	ta := textarea.New()
+	ta.Placeholder = fmt.Sprintf("Enter comparison text %d...", i+1)
+	ta.SetWidth(75)
+	ta.SetHeight(3)
+	ta.ShowLineNumbers = false
+	return ta
+}
@@ -0,0 +1,890 @@
+package main
+
+import (
+	"encoding/binary"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"hash/crc32"
+	"io"
+	"math"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/klauspost/compress/zstd"
+)
+
+// Magic number and version at the start of every segment's vector file.
+// Version 1 files are bare float32 rows; version 2 adds flags after the
+// header and a CRC-32C of the whole file at the end.
+const (
+	vectorMagic   = "EMBV"
+	vectorVersion = 2
+	// vectorHeader is the magic, the version, the dimensions and the row count
+	vectorHeader = 16
+	// vectorFlags and vectorFooter are the flags and the checksum of version 2
+	vectorFlags  = 4
+	vectorFooter = 4
+)
+
+// Flags of a version 2 file. vectorCompressed marks rows that are
+// zstd-compressed, with their bytes grouped by position in the float so the
+// exponents compress together; vectorEncrypted marks rows sealed with the
+// EMBER_ENCRYPT key, after any compression.
+const (
+	vectorCompressed = 1
+	vectorEncrypted  = 2
+)
+
+var castagnoli = crc32.MakeTable(crc32.Castagnoli)
+
+// vectorStore is ember's own on-disk vector store. Each upsert appends an
+// immutable segment: a file of float32 vectors and a JSON file naming the
+// model and texts, written last so a segment without one was never
+// finished. A text upserted again is found in a newer segment and hides the
+// older copy; compact rewrites the live rows.
+//
+// Texts can belong to a document, such as a file's chunks. Putting a
+// document again hides all of its older chunks, and deleting it writes a
+// segment with no vectors that hides them everywhere.
+type vectorStore struct {
+	dir   string
+	limit int
+	// remote is the bucket a pulled copy came from
+	remote string
+
+	mu       sync.Mutex
+	segments []*vectorSegment
+	// live maps a row's key to the newest row holding it
+	live map[string]vectorRow
+	// docs maps a document ID to the keys of its chunks
+	docs map[string][]string
+	// next is the sequence number of the next segment written
+	next int
+	// indexes caches loaded HNSW indexes by path, and unusable remembers
+	// when the files that couldn't be used were written
+	indexes  map[string]*hnswIndex
+	unusable map[string]time.Time
+}
+
+// vectorSegment is one upsert's vectors, all from the same model
+type vectorSegment struct {
+	seq   int
+	model string
+	dims  int
+	texts []string
+	// docs holds the document of each row, or is nil when none has one
+	docs []string
+	// deleted lists the documents the segment removes
+	deleted []string
+	// data is the vector file's rows, mapped or decompressed
+	data  []byte
+	unmap func() error
+}
+
+type vectorRow struct {
+	segment *vectorSegment
+	row     int
+}
+
+// segmentMeta is the JSON half of a segment
+type segmentMeta struct {
+	Model      string   `json:"model"`
+	Dimensions int      `json:"dimensions"`
+	Texts      []string `json:"texts"`
+	// Docs are the rows' document IDs, empty for texts upserted on their own
+	Docs []string `json:"docs,omitempty"`
+	// Deleted are the documents this segment removes
+	Deleted []string `json:"deleted,omitempty"`
+	// Checksum is the CRC-32C of the rest; older segments have none
+	Checksum uint32 `json:"checksum,omitempty"`
+}
+
+// checksum covers the model, texts and documents, so a damaged text is
+// caught on load
+func (meta segmentMeta) checksum() uint32 {
+	h := crc32.New(castagnoli)
+	io.WriteString(h, meta.Model)
+	for _, text := range meta.Texts {
+		io.WriteString(h, "\x00"+text)
+	}
+	for _, doc := range meta.Docs {
+		io.WriteString(h, "\x01"+doc)
+	}
+	for _, doc := range meta.Deleted {
+		io.WriteString(h, "\x02"+doc)
+	}
+	return h.Sum32()
+}
+
+// compressVectors reads EMBER_VECTORS_COMPRESS; segments are compressed
+// unless it is 0, which keeps them memory-mapped instead of read into memory
+func compressVectors() bool {
+	return os.Getenv("EMBER_VECTORS_COMPRESS") != "0"
+}
+
+// vectorStoreDir is the copy of EMBER_VECTORS_REMOTE when a bucket is set,
+// or else the local store
+func vectorStoreDir() (string, error) {
+	remote, err := loadVectorsRemote()
+	if err != nil {
+		return "", err
+	}
+	if remote != nil {
+		return remote.cacheDir, nil
+	}
+	return localVectorStoreDir()
+}
+
+// localVectorStoreDir reads EMBER_VECTORS_DIR, falling back to vectors in the data directory
+func localVectorStoreDir() (string, error) {
+	if dir := os.Getenv("EMBER_VECTORS_DIR"); dir != "" {
+		return dir, nil
+	}
+	dataDir, err := userDataDir()
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(dataDir, "vectors"), nil
+}
+
+// openVectorStore maps the finished segments in dir. The directory is
+// created by the first upsert, so a missing one is an empty store. The copy
+// of a bucket is pulled first.
+func openVectorStore(dir string) (*vectorStore, error) {
+	s := &vectorStore{dir: dir, limit: defaultRemoteLimit, live: make(map[string]vectorRow), docs: make(map[string][]string), next: 1, indexes: make(map[string]*hnswIndex), unusable: make(map[string]time.Time)}
+	if value := os.Getenv("EMBER_VECTORS_LIMIT"); value != "" {
+		limit, err := strconv.Atoi(value)
+		if err != nil || limit < 1 {
+			return nil, fmt.Errorf("invalid EMBER_VECTORS_LIMIT %q", value)
+		}
+		s.limit = limit
+	}
+	remote, err := loadVectorsRemote()
+	if err != nil {
+		return nil, err
+	}
+	if remote != nil && filepath.Clean(dir) == remote.cacheDir {
+		if err := remote.pull(); err != nil {
+			return nil, err
+		}
+		s.remote = remote.url
+	}
+	if err := s.refresh(); err != nil {
+		s.Close()
+		return nil, err
+	}
+	return s, nil
+}
+
+// loadVectorStore opens the store for comparisons, returning nil while it's empty
+func loadVectorStore() (*vectorStore, error) {
+	dir, err := vectorStoreDir()
+	if err != nil {
+		return nil, err
+	}
+	s, err := openVectorStore(dir)
+	if err != nil {
+		return nil, err
+	}
+	if s.size() == 0 {
+		s.Close()
+		return nil, nil
+	}
+	return s, nil
+}
+
+func segmentPath(dir string, seq int, ext string) string {
+	return filepath.Join(dir, fmt.Sprintf("seg-%06d%s", seq, ext))
+}
+
+func vectorKey(model, text string) string {
+	return model + "\x00" + text
+}
+
+// key identifies a row in the store's live map: its model and text, and its
+// document, so two documents with the same chunk keep their own copies
+func (seg *vectorSegment) key(row int) string {
+	key := vectorKey(seg.model, seg.texts[row])
+	if seg.docs != nil && seg.docs[row] != "" {
+		key += "\x00" + seg.docs[row]
+	}
+	return key
+}
+
+// isLive reports whether a row is its key's newest copy, and its document
+// hasn't been deleted or put again since. Callers hold s.mu.
+func (s *vectorStore) isLive(segment *vectorSegment, row int) bool {
+	live, ok := s.live[segment.key(row)]
+	return ok && live.segment == segment && live.row == row
+}
+
+// dropDocument hides doc's chunks from model, or from every model when model
+// is empty. Callers hold s.mu.
+func (s *vectorStore) dropDocument(doc, model string) {
+	var kept []string
+	for _, key := range s.docs[doc] {
+		if model == "" || strings.HasPrefix(key, model+"\x00") {
+			delete(s.live, key)
+		} else {
+			kept = append(kept, key)
+		}
+	}
+	if len(kept) == 0 {
+		delete(s.docs, doc)
+	} else {
+		s.docs[doc] = kept
+	}
+}
+
+// refresh maps segments written since the store was opened, by this
+// process or another one. Callers hold s.mu or own s exclusively.
+func (s *vectorStore) refresh() error {
+	entries, err := os.ReadDir(s.dir)
+	if errors.Is(err, os.ErrNotExist) {
+		return nil
+	}
+	if err != nil {
+		return fmt.Errorf("failed to read %s: %w", s.dir, err)
+	}
+
+	var seqs []int
+	for _, entry := range entries {
+		name := entry.Name()
+		if !strings.HasPrefix(name, "seg-") || !strings.HasSuffix(name, ".json") {
+			continue
+		}
+		seq, err := strconv.Atoi(strings.TrimSuffix(strings.TrimPrefix(name, "seg-"), ".json"))
+		if err == nil && seq >= s.next {
+			seqs = append(seqs, seq)
+		}
+	}
+	sort.Ints(seqs)
+
+	for _, seq := range seqs {
+		segment, err := loadSegment(s.dir, seq)
+		if err != nil {
+			return err
+		}
+		s.segments = append(s.segments, segment)
+		for _, doc := range segment.deleted {
+			s.dropDocument(doc, "")
+		}
+		// A document put again replaces every chunk it had for the model
+		put := make(map[string]bool)
+		for _, doc := range segment.docs {
+			if doc != "" && !put[doc] {
+				put[doc] = true
+				s.dropDocument(doc, segment.model)
+			}
+		}
+		for row := range segment.texts {
+			key := segment.key(row)
+			if _, ok := s.live[key]; !ok && segment.docs != nil && segment.docs[row] != "" {
+				s.docs[segment.docs[row]] = append(s.docs[segment.docs[row]], key)
+			}
+			s.live[key] = vectorRow{segment: segment, row: row}
+		}
+		s.next = seq + 1
+	}
+	return nil
+}
+
+// loadSegment reads a segment's texts and its vectors. Uncompressed vectors
+// are mapped; compressed ones are read into memory. Checksums are checked
+// where the segment has them.
+func loadSegment(dir string, seq int) (*vectorSegment, error) {
+	metaPath := segmentPath(dir, seq, ".json")
+	data, err := os.ReadFile(metaPath)
+	if err == nil {
+		data, err = openAtRest(data)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to read %s: %w", metaPath, err)
+	}
+	var meta segmentMeta
+	if err := json.Unmarshal(data, &meta); err != nil {
+		return nil, fmt.Errorf("failed to read %s: %w", metaPath, err)
+	}
+	if meta.Checksum != 0 && meta.Checksum != meta.checksum() {
+		return nil, fmt.Errorf("%s is corrupt: its checksum doesn't match", metaPath)
+	}
+	if meta.Docs != nil && len(meta.Docs) != len(meta.Texts) {
+		return nil, fmt.Errorf("%s is corrupt: it has %d documents for %d texts", metaPath, len(meta.Docs), len(meta.Texts))
+	}
+	segment := &vectorSegment{seq: seq, model: meta.Model, texts: meta.Texts, docs: meta.Docs, deleted: meta.Deleted}
+
+	vecPath := segmentPath(dir, seq, ".vec")
+	mapped, unmap, err := mapFile(vecPath)
+	if err != nil {
+		return nil, err
+	}
+	le := binary.LittleEndian
+	if len(mapped) < vectorHeader || string(mapped[:4]) != vectorMagic {
+		unmap()
+		return nil, fmt.Errorf("%s isn't an ember vector file", vecPath)
+	}
+	version := le.Uint32(mapped[4:])
+	dims := int(le.Uint32(mapped[8:]))
+	rows := int(le.Uint32(mapped[12:]))
+	if dims != meta.Dimensions || rows != len(meta.Texts) {
+		unmap()
+		return nil, fmt.Errorf("%s doesn't match %s", vecPath, metaPath)
+	}
+	size := rows * dims * 4
+
+	switch version {
+	case 1:
+		if len(mapped) != vectorHeader+size {
+			unmap()
+			return nil, fmt.Errorf("%s doesn't match %s", vecPath, metaPath)
+		}
+		segment.dims, segment.data, segment.unmap = dims, mapped[vectorHeader:], unmap
+		return segment, nil
+	case 2:
+	default:
+		unmap()
+		return nil, fmt.Errorf("%s was written by a newer ember (version %d)", vecPath, version)
+	}
+
+	end := len(mapped) - vectorFooter
+	if end < vectorHeader+vectorFlags || crc32.Checksum(mapped[:end], castagnoli) != le.Uint32(mapped[end:]) {
+		unmap()
+		return nil, fmt.Errorf("%s is corrupt: its checksum doesn't match", vecPath)
+	}
+	payload := mapped[vectorHeader+vectorFlags : end]
+	flags := le.Uint32(mapped[vectorHeader:])
+	if flags&vectorEncrypted != 0 {
+		plain, err := openAtRest(payload)
+		unmap()
+		if err != nil {
+			return nil, fmt.Errorf("failed to read %s: %w", vecPath, err)
+		}
+		// The decrypted rows live on the heap, like decompressed ones
+		payload, unmap = plain, func() error { return nil }
+	}
+	if flags&vectorCompressed == 0 {
+		if len(payload) != size {
+			unmap()
+			return nil, fmt.Errorf("%s doesn't match %s", vecPath, metaPath)
+		}
+		segment.dims, segment.data, segment.unmap = dims, payload, unmap
+		return segment, nil
+	}
+
+	decoder, err := zstd.NewReader(nil, zstd.WithDecoderConcurrency(1))
+	if err != nil {
+		unmap()
+		return nil, fmt.Errorf("failed to read %s: %w", vecPath, err)
+	}
+	shuffled, err := decoder.DecodeAll(payload, make([]byte, 0, size))
+	decoder.Close()
+	unmap()
+	if err != nil || len(shuffled) != size {
+		return nil, fmt.Errorf("%s is corrupt: its vectors don't decompress", vecPath)
+	}
+	segment.dims, segment.data, segment.unmap = dims, unshuffleFloats(shuffled), func() error { return nil }
+	return segment, nil
+}
+
+// shuffleFloats groups the bytes of little-endian float32s by their position
+// in the float: every float's first byte, then every second byte, and so on
+func shuffleFloats(data []byte) []byte {
+	n := len(data) / 4
+	out := make([]byte, len(data))
+	for i := range n {
+		for b := range 4 {
+			out[b*n+i] = data[i*4+b]
+		}
+	}
+	return out
+}
+
+// unshuffleFloats undoes shuffleFloats
+func unshuffleFloats(data []byte) []byte {
+	n := len(data) / 4
+	out := make([]byte, len(data))
+	for i := range n {
+		for b := range 4 {
+			out[i*4+b] = data[b*n+i]
+		}
+	}
+	return out
+}
+
+// vector decodes a row of the segment
+func (seg *vectorSegment) vector(row int) []float64 {
+	vector := make([]float64, seg.dims)
+	offset := row * seg.dims * 4
+	for i := range vector {
+		vector[i] = float64(math.Float32frombits(binary.LittleEndian.Uint32(seg.data[offset+i*4:])))
+	}
+	return vector
+}
+
+// cosine is the cosine similarity of a row with query, whose norm is given,
+// read straight from the mapped file
+func (seg *vectorSegment) cosine(row int, query []float64, queryNorm float64) float64 {
+	offset := row * seg.dims * 4
+	var dot, norm float64
+	for i, q := range query {
+		v := float64(math.Float32frombits(binary.LittleEndian.Uint32(seg.data[offset+i*4:])))
+		dot += q * v
+		norm += v * v
+	}
+	if norm == 0 || queryNorm == 0 {
+		return 0
+	}
+	return dot / (math.Sqrt(norm) * queryNorm)
+}
+
+// writeSegment writes texts and vectors as segment seq, with the model,
+// documents and deletions in meta. Vectors are compressed unless
+// EMBER_VECTORS_COMPRESS is 0. The vector file is written first and the
+// JSON file last, each under a temporary name.
+func writeSegment(dir string, seq int, meta segmentMeta, embeddings []CustomEmbedding) error {
+	dims := 0
+	if len(embeddings) > 0 {
+		dims = len(embeddings[0].Embedding)
+	}
+	rows := make([]byte, len(embeddings)*dims*4)
+	meta.Dimensions, meta.Texts = dims, make([]string, len(embeddings))
+	offset := 0
+	for i, e := range embeddings {
+		if len(e.Embedding) != dims {
+			return fmt.Errorf("%q has %d dimensions, not %d", truncateText(e.Text, 40), len(e.Embedding), dims)
+		}
+		meta.Texts[i] = e.Text
+		for _, v := range e.Embedding {
+			binary.LittleEndian.PutUint32(rows[offset:], math.Float32bits(float32(v)))
+			offset += 4
+		}
+	}
+	meta.Checksum = meta.checksum()
+
+	le := binary.LittleEndian
+	buf := make([]byte, 0, vectorHeader+vectorFlags+len(rows)+vectorFooter)
+	buf = append(buf, vectorMagic...)
+	buf = le.AppendUint32(buf, vectorVersion)
+	buf = le.AppendUint32(buf, uint32(dims))
+	buf = le.AppendUint32(buf, uint32(len(embeddings)))
+	var flags uint32
+	payload := rows
+	if compressVectors() {
+		encoder, err := zstd.NewWriter(nil)
+		if err != nil {
+			return fmt.Errorf("failed to compress segment: %w", err)
+		}
+		flags |= vectorCompressed
+		payload = encoder.EncodeAll(shuffleFloats(rows), nil)
+		encoder.Close()
+	}
+	if atRest != nil {
+		flags |= vectorEncrypted
+		payload = sealAtRest(payload)
+	}
+	buf = le.AppendUint32(buf, flags)
+	buf = append(buf, payload...)
+	buf = le.AppendUint32(buf, crc32.Checksum(buf, castagnoli))
+
+	data, err := json.Marshal(meta)
+	if err != nil {
+		return fmt.Errorf("failed to marshal segment: %w", err)
+	}
+
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		return fmt.Errorf("failed to create %s: %w", dir, err)
+	}
+	for _, file := range []struct {
+		ext  string
+		data []byte
+	}{{".vec", buf}, {".json", sealAtRest(data)}} {
+		path := segmentPath(dir, seq, file.ext)
+		if err := os.WriteFile(path+".tmp", file.data, 0o644); err != nil {
+			return fmt.Errorf("failed to write %s: %w", path, err)
+		}
+		if err := os.Rename(path+".tmp", path); err != nil {
+			return fmt.Errorf("failed to write %s: %w", path, err)
+		}
+	}
+	return nil
+}
+
+// Close unmaps every segment
+func (s *vectorStore) Close() error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	for _, segment := range s.segments {
+		segment.unmap()
+	}
+	s.segments = nil
+	s.live = make(map[string]vectorRow)
+	s.docs = make(map[string][]string)
+	s.indexes = make(map[string]*hnswIndex)
+	s.unusable = make(map[string]time.Time)
+	return nil
+}
+
+// size is the number of live texts across models
+func (s *vectorStore) size() int {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return len(s.live)
+}
+
+// ensureCollection is a no-op: each segment records its own dimensions
+func (s *vectorStore) ensureCollection(dimensions int) error {
+	return nil
+}
+
+// upsert appends embeddings made by model as a new segment
+func (s *vectorStore) upsert(model ModelInfo, embeddings []CustomEmbedding) error {
+	if len(embeddings) == 0 {
+		return nil
+	}
+	return s.write(segmentMeta{Model: model.Provider + "/" + model.Model}, embeddings)
+}
+
+// putDocuments appends chunks made by model as a new segment, where docs
+// holds each chunk's document. The documents' older chunks from the model
+// are hidden, so a changed file can be put again without a rebuild.
+func (s *vectorStore) putDocuments(model ModelInfo, docs []string, embeddings []CustomEmbedding) error {
+	if len(embeddings) == 0 {
+		return nil
+	}
+	return s.write(segmentMeta{Model: model.Provider + "/" + model.Model, Docs: docs}, embeddings)
+}
+
+// deleteDocuments hides every chunk of the documents, from every model, and
+// returns how many chunks that was
+func (s *vectorStore) deleteDocuments(docs []string) (int, error) {
+	s.mu.Lock()
+	removed := 0
+	var found []string
+	if err := s.refresh(); err != nil {
+		s.mu.Unlock()
+		return 0, err
+	}
+	for _, doc := range docs {
+		if keys := s.docs[doc]; len(keys) > 0 {
+			removed += len(keys)
+			found = append(found, doc)
+		}
+	}
+	s.mu.Unlock()
+	if len(found) == 0 {
+		return 0, nil
+	}
+	return removed, s.write(segmentMeta{Deleted: found}, nil)
+}
+
+// write appends a segment, then adds its rows to the HNSW indexes of their
+// model so they're searched through the graph rather than scanned
+func (s *vectorStore) write(meta segmentMeta, embeddings []CustomEmbedding) error {
+	if err := s.writable(); err != nil {
+		return err
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	// Another process may have written segments since
+	if err := s.refresh(); err != nil {
+		return err
+	}
+	if err := writeSegment(s.dir, s.next, meta, embeddings); err != nil {
+		return fmt.Errorf("failed to upsert into the vector store: %w", err)
+	}
+	if err := s.refresh(); err != nil {
+		return err
+	}
+	return s.extendIndexes()
+}
+
+// Search returns the k live texts from model most similar to query, best
+// first, with their vectors and scores. Segments covered by an HNSW index
+// are searched through it, approximately; the rest are scanned.
+func (s *vectorStore) Search(model ModelInfo, query []float64, k int) ([]CustomEmbedding, []float64, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if err := s.refresh(); err != nil {
+		return nil, nil, err
+	}
+
+	best, scores := s.searchRows(model.Provider+"/"+model.Model, query, k)
+	embeddings := make([]CustomEmbedding, len(best))
+	for i, r := range best {
+		text := r.segment.texts[r.row]
+		// A redacted chunk is labeled with the document it came from
+		if isRedacted(text) && r.segment.docs != nil && r.segment.docs[r.row] != "" {
+			text += " • " + r.segment.docs[r.row]
+		}
+		embeddings[i] = CustomEmbedding{Text: text, Embedding: r.segment.vector(r.row)}
+	}
+	return embeddings, scores, nil
+}
+
+// searchRows returns the k live rows of the model named name most similar
+// to query, best first, with their scores. Callers hold s.mu.
+func (s *vectorStore) searchRows(name string, query []float64, k int) ([]vectorRow, []float64) {
+	queryNorm := 0.0
+	for _, q := range query {
+		queryNorm += q * q
+	}
+	queryNorm = math.Sqrt(queryNorm)
+
+	// best is kept sorted, best first, and never grows past k
+	var best []vectorRow
+	var scores []float64
+	keep := func(segment *vectorSegment, row int, score float64) {
+		if !s.isLive(segment, row) {
+			return
+		}
+		if len(best) == k && score <= scores[k-1] {
+			return
+		}
+		i := sort.Search(len(scores), func(i int) bool { return scores[i] < score })
+		if len(best) < k {
+			best = append(best, vectorRow{})
+			scores = append(scores, 0)
+		}
+		copy(best[i+1:], best[i:])
+		copy(scores[i+1:], scores[i:])
+		best[i] = vectorRow{segment: segment, row: row}
+		scores[i] = score
+	}
+
+	ix := s.index(name, len(query))
+	if ix != nil {
+		for _, hit := range ix.search(query, queryNorm, max(k, hnswSearchEf())) {
+			node := ix.nodes[hit.id]
+			keep(node.segment, int(node.row), hit.sim)
+		}
+	}
+	for _, segment := range s.segments {
+		if segment.model != name || segment.dims != len(query) || (ix != nil && ix.covers(segment.seq)) {
+			continue
+		}
+		for row := range segment.texts {
+			keep(segment, row, segment.cosine(row, query, queryNorm))
+		}
+	}
+	return best, scores
+}
+
+// search is Search, for remoteStore
+func (s *vectorStore) search(model ModelInfo, vector []float64, k int) ([]CustomEmbedding, []float64, error) {
+	return s.Search(model, vector, k)
+}
+
+// label names the store on screen
+func (s *vectorStore) label() string {
+	if s.remote != "" {
+		return "the vector store in " + s.remote
+	}
+	return "the local vector store"
+}
+
+// nearest returns the store's texts nearest to vector, for remoteIndex
+func (s *vectorStore) nearest(model ModelInfo, vector []float64) ([]CustomEmbedding, error) {
+	hits, _, err := s.Search(model, vector, s.limit)
+	return hits, err
+}
+
+// liveGroup is a store's live rows of one model and vector size
+type liveGroup struct {
+	model      string
+	dims       int
+	embeddings []CustomEmbedding
+	docs       []string
+	hasDocs    bool
+}
+
+// meta is the segment the group is written as
+func (g *liveGroup) meta() segmentMeta {
+	meta := segmentMeta{Model: g.model}
+	if g.hasDocs {
+		meta.Docs = g.docs
+	}
+	return meta
+}
+
+// liveGroups collects the live rows in store order. A model's vectors can
+// differ in size after a dimensions change, so rows are grouped by model
+// and size. Callers hold s.mu.
+func (s *vectorStore) liveGroups() []*liveGroup {
+	type group struct {
+		model string
+		dims  int
+	}
+	index := make(map[group]*liveGroup)
+	var groups []*liveGroup
+	for _, segment := range s.segments {
+		for row, text := range segment.texts {
+			if !s.isLive(segment, row) {
+				continue
+			}
+			g := index[group{segment.model, segment.dims}]
+			if g == nil {
+				g = &liveGroup{model: segment.model, dims: segment.dims}
+				index[group{segment.model, segment.dims}] = g
+				groups = append(groups, g)
+			}
+			g.embeddings = append(g.embeddings, CustomEmbedding{Text: text, Embedding: segment.vector(row)})
+			doc := ""
+			if segment.docs != nil {
+				doc = segment.docs[row]
+			}
+			g.docs = append(g.docs, doc)
+			g.hasDocs = g.hasDocs || doc != ""
+		}
+	}
+	return groups
+}
+
+// compact rewrites the live rows into one segment per model and removes the
+// old segments, dropping texts that were upserted again. Indexes point at
+// the old segments, so they are removed too; indexed reports whether any were.
+func (s *vectorStore) compact() (before, after int, indexed bool, err error) {
+	if err := s.writable(); err != nil {
+		return 0, 0, false, err
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if err := s.refresh(); err != nil {
+		return 0, 0, false, err
+	}
+
+	old := s.segments
+	written := s.next
+	for _, group := range s.liveGroups() {
+		if err := writeSegment(s.dir, s.next, group.meta(), group.embeddings); err != nil {
+			return len(old), 0, false, fmt.Errorf("failed to compact the vector store: %w", err)
+		}
+		s.next++
+	}
+
+	indexed = s.removeIndexes() > 0
+	for _, segment := range old {
+		segment.unmap()
+		// The JSON file goes first, so a crash leaves no half segment that looks finished
+		os.Remove(segmentPath(s.dir, segment.seq, ".json"))
+		os.Remove(segmentPath(s.dir, segment.seq, ".vec"))
+	}
+	s.segments, s.live, s.docs, s.next = nil, make(map[string]vectorRow), make(map[string][]string), written
+	if err := s.refresh(); err != nil {
+		return len(old), 0, indexed, err
+	}
+	return len(old), len(s.segments), indexed, nil
+}
+
+// runVectorsCommand handles `ember vectors upsert|put|delete|search|info|compact|merge|index|export|push`
+func runVectorsCommand(args []string) {
+	if len(args) == 0 || (args[0] != "upsert" && args[0] != "put" && args[0] != "delete" && args[0] != "search" && args[0] != "info" && args[0] != "compact" && args[0] != "merge" && args[0] != "index" && args[0] != "export" && args[0] != "push") {
+		fmt.Println("Usage: ember vectors upsert [--redact] [--set NAME | [--batch N] [--yes] [--dry-run] FILE] | put [--id ID] [--chunk-words N] [--redact] FILE... | delete ID... | search [-k N] QUERY | info | compact | merge STORE STORE... -o DIR | index [--m 16] [--ef 200] | export [--model M] [--dimensions N] FILE.db|FILE.fbin | push [DIR]")
+		os.Exit(2)
+	}
+	if args[0] == "merge" || args[0] == "push" {
+		run := runVectorsMerge
+		if args[0] == "push" {
+			run = runVectorsPush
+		}
+		if err := run(args[1:]); err != nil {
+			displayError(err)
+			os.Exit(1)
+		}
+		return
+	}
+	dir, err := vectorStoreDir()
+	if err != nil {
+		displayError(err)
+		os.Exit(1)
+	}
+	s, err := openVectorStore(dir)
+	if err != nil {
+		displayError(err)
+		os.Exit(1)
+	}
+	defer s.Close()
+
+	switch args[0] {
+	case "upsert":
+		err = runRemoteUpsert(s, "vectors", args[1:])
+	case "put":
+		err = runVectorsPut(s, args[1:])
+	case "delete":
+		err = runVectorsDelete(s, args[1:])
+	case "search":
+		err = runRemoteSearch(s.withDaemon(), "vectors", s.limit, args[1:])
+	case "info":
+		s.printInfo()
+	case "compact":
+		var before, after int
+		var indexed bool
+		if before, after, indexed, err = s.compact(); err == nil {
+			fmt.Printf("🗜  Compacted %d segments into %d • %d texts\n", before, after, s.size())
+			if indexed {
+				fmt.Println("The HNSW indexes were removed • run ember vectors index to rebuild them")
+			}
+		}
+	case "index":
+		err = runVectorsIndex(s, args[1:])
+	case "export":
+		err = runVectorsExport(s, args[1:])
+	}
+	if err != nil {
+		displayError(err)
+		os.Exit(1)
+	}
+}
+
+// printInfo lists the live texts per model and the space the segments take
+func (s *vectorStore) printInfo() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if len(s.segments) == 0 {
+		fmt.Printf("The vector store in %s is empty • ember vectors upsert adds texts\n", s.dir)
+		return
+	}
+
+	counts := make(map[string]int)
+	dims := make(map[string]int)
+	for key, r := range s.live {
+		model, _, _ := strings.Cut(key, "\x00")
+		counts[model]++
+		dims[model] = r.segment.dims
+	}
+	models := make([]string, 0, len(counts))
+	for model := range counts {
+		models = append(models, model)
+	}
+	sort.Strings(models)
+
+	var bytes int64
+	rows := 0
+	for _, segment := range s.segments {
+		rows += len(segment.texts)
+		for _, ext := range []string{".vec", ".json"} {
+			if info, err := os.Stat(segmentPath(s.dir, segment.seq, ext)); err == nil {
+				bytes += info.Size()
+			}
+		}
+	}
+
+	fmt.Printf("Path:      %s\n", s.dir)
+	if s.remote != "" {
+		fmt.Printf("Pulled:    %s\n", s.remote)
+	}
+	fmt.Printf("Segments:  %d (%.1f MB)\n", len(s.segments), float64(bytes)/(1<<20))
+	fmt.Printf("Texts:     %d", len(s.live))
+	if stale := rows - len(s.live); stale > 0 {
+		fmt.Printf(" • %d replaced or deleted copies, removed by ember vectors compact", stale)
+	}
+	fmt.Println()
+	for _, model := range models {
+		fmt.Printf("  %-48s %d × %d dimensions • %s\n", model, counts[model], dims[model], s.indexStatus(model, dims[model]))
+	}
+}
@@ -0,0 +1,139 @@
+package main
+
+import (
+	"errors"
+	"flag"
+	"fmt"
+	"os"
+	"path/filepath"
+)
+
+// runVectorsPut handles `ember vectors put`: each file is a document whose
+// chunks are embedded and put in the store under its ID, hiding the chunks
+// an earlier put of the same ID left behind. The HNSW indexes are updated
+// in place, so a changed file never needs a rebuild.
+func runVectorsPut(s *vectorStore, args []string) error {
+	chunking, err := loadChunkOptions()
+	if err != nil {
+		return err
+	}
+	fs := flag.NewFlagSet("vectors put", flag.ExitOnError)
+	id := fs.String("id", "", "document ID, for a single file; defaults to the file's path")
+	words := fs.Int("chunk-words", chunking.words, "words per chunk")
+	batch := fs.Int("batch", defaultRemoteBatch, "chunks embedded per request")
+	yes := fs.Bool("yes", false, "start without previewing a sample first")
+	dryRun := fs.Bool("dry-run", false, "report what would be embedded without calling the provider")
+	redact := fs.Bool("redact", loadRedaction(), "store a hash and the #tags of each chunk instead of the chunk")
+	fs.Parse(args)
+	if *redact {
+		if err := setupRedaction(); err != nil {
+			return err
+		}
+	}
+	if fs.NArg() == 0 || (*id != "" && fs.NArg() != 1) || *words < 1 || *batch < 1 {
+		return errors.New("usage: ember vectors put [--id ID] [--chunk-words N] [--batch N] [--redact] [--yes] [--dry-run] FILE...")
+	}
+
+	chunks, err := chunkFiles(fs.Args(), *words)
+	if err != nil {
+		return err
+	}
+	if len(chunks) == 0 {
+		return errors.New("the files are empty")
+	}
+	if *id != "" {
+		for i := range chunks {
+			chunks[i].path = *id
+		}
+	}
+
+	var provider EmbeddingProvider = setupProvider()
+	defer closeProvider(provider)
+	info := provider.ModelInfo()
+	inputType := documentInputType(provider)
+	provider = withInputType(provider, inputType)
+	if cache := setupCache(); cache != nil {
+		defer cache.Close()
+		provider = &cachedProvider{EmbeddingProvider: provider, cache: cache, model: cacheModelKey(info, inputType)}
+	}
+
+	if *dryRun {
+		plan := newEmbeddingPlan(info)
+		for _, chunk := range chunks {
+			plan.add(provider, chunk.text)
+		}
+		fmt.Print(plan.render())
+		return nil
+	}
+	if !*yes {
+		sampler := newCorpusSampler()
+		for _, chunk := range chunks {
+			sampler.add(chunk.text)
+		}
+		ok, err := confirmBatchJob(provider, sampler)
+		if err != nil || !ok {
+			return err
+		}
+	}
+
+	// A document's chunks go in one segment, since a segment naming the
+	// document hides every chunk it had before
+	documents := 0
+	for start := 0; start < len(chunks); {
+		end := start + 1
+		for end < len(chunks) && chunks[end].path == chunks[start].path {
+			end++
+		}
+		doc := chunks[start:end]
+		embeddings := make([]CustomEmbedding, 0, len(doc))
+		for i := 0; i < len(doc); i += *batch {
+			texts := make([]string, 0, *batch)
+			for _, chunk := range doc[i:min(i+*batch, len(doc))] {
+				texts = append(texts, chunk.text)
+			}
+			vectors, err := provider.GenerateBatch(texts)
+			if err != nil {
+				return fmt.Errorf("failed to embed %s: %w", doc[0].path, err)
+			}
+			for j, text := range texts {
+				embeddings = append(embeddings, CustomEmbedding{Text: text, Embedding: vectors[j]})
+			}
+		}
+		if *redact {
+			embeddings = redactEmbeddings(embeddings)
+		}
+		ids := make([]string, len(doc))
+		for i := range ids {
+			ids[i] = doc[0].path
+		}
+		if err := s.putDocuments(info, ids, embeddings); err != nil {
+			return err
+		}
+		documents++
+		fmt.Fprintf(os.Stderr, "📤 %s • %d chunks\n", doc[0].path, len(doc))
+		start = end
+	}
+	fmt.Printf("📤 Put %d documents into %s\n", documents, s.label())
+	return nil
+}
+
+// runVectorsDelete handles `ember vectors delete`, removing documents put
+// by ID. Paths are matched the way put names them.
+func runVectorsDelete(s *vectorStore, args []string) error {
+	if len(args) == 0 {
+		return errors.New("usage: ember vectors delete ID...")
+	}
+	ids := make([]string, len(args))
+	for i, id := range args {
+		ids[i] = filepath.ToSlash(id)
+	}
+	removed, err := s.deleteDocuments(ids)
+	if err != nil {
+		return err
+	}
+	if removed == 0 {
+		return fmt.Errorf("no documents in %s match %v", s.label(), args)
+	}
+	fmt.Printf("🗑  Deleted %d chunks from %s\n", removed, s.label())
+	return nil
+}
@@ -0,0 +1,230 @@
+package main
+
+import (
+	"bufio"
+	"database/sql"
+	"encoding/binary"
+	"errors"
+	"flag"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// Tables of a sqlite-vec export. Vectors are float32 blobs, the format
+// sqlite-vec's functions and vec0 tables take.
+const sqliteVecSchema = `
+CREATE TABLE ember_vectors (
+	id        INTEGER PRIMARY KEY,
+	text      TEXT NOT NULL,
+	embedding BLOB NOT NULL
+);
+CREATE TABLE ember_meta (
+	key   TEXT PRIMARY KEY,
+	value TEXT NOT NULL
+)`
+
+// vectorGroup is a model's live vectors of one size in the vector store
+type vectorGroup struct {
+	model string
+	dims  int
+	count int
+}
+
+func (g vectorGroup) String() string {
+	return fmt.Sprintf("%s (%d × %d dimensions)", g.model, g.count, g.dims)
+}
+
+// groups lists the models in the store and the sizes of their vectors
+func (s *vectorStore) groups() []vectorGroup {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	counts := make(map[vectorGroup]int)
+	for _, r := range s.live {
+		counts[vectorGroup{model: r.segment.model, dims: r.segment.dims}]++
+	}
+	groups := make([]vectorGroup, 0, len(counts))
+	for g, count := range counts {
+		g.count = count
+		groups = append(groups, g)
+	}
+	sort.Slice(groups, func(i, j int) bool {
+		if groups[i].model != groups[j].model {
+			return groups[i].model < groups[j].model
+		}
+		return groups[i].dims < groups[j].dims
+	})
+	return groups
+}
+
+// eachLive calls fn with every live text of g and its vector as the
+// float32 little-endian bytes stored in the segment, in the order they
+// were upserted. Callers hold s.mu.
+func (s *vectorStore) eachLive(g vectorGroup, fn func(text string, vector []byte) error) error {
+	for _, segment := range s.segments {
+		if segment.model != g.model || segment.dims != g.dims {
+			continue
+		}
+		for row, text := range segment.texts {
+			if !s.isLive(segment, row) {
+				continue
+			}
+			size := segment.dims * 4
+			if err := fn(text, segment.data[row*size:(row+1)*size]); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// exportSQLiteVec writes g to a new SQLite database at path, replacing any
+// file there. Each row's id is its position in the export.
+func (s *vectorStore) exportSQLiteVec(path string, g vectorGroup) error {
+	if err := os.Remove(path); err != nil && !errors.Is(err, os.ErrNotExist) {
+		return fmt.Errorf("failed to replace %s: %w", path, err)
+	}
+	db, err := sql.Open("sqlite", path)
+	if err != nil {
+		return fmt.Errorf("failed to create %s: %w", path, err)
+	}
+	defer db.Close()
+	if _, err := db.Exec(sqliteVecSchema); err != nil {
+		return fmt.Errorf("failed to create tables in %s: %w", path, err)
+	}
+
+	tx, err := db.Begin()
+	if err != nil {
+		return fmt.Errorf("failed to write %s: %w", path, err)
+	}
+	defer tx.Rollback()
+	for key, value := range map[string]string{"model": g.model, "dimensions": strconv.Itoa(g.dims), "element_type": "float32"} {
+		if _, err := tx.Exec(`INSERT INTO ember_meta (key, value) VALUES (?, ?)`, key, value); err != nil {
+			return fmt.Errorf("failed to write %s: %w", path, err)
+		}
+	}
+	insert, err := tx.Prepare(`INSERT INTO ember_vectors (id, text, embedding) VALUES (?, ?, ?)`)
+	if err != nil {
+		return fmt.Errorf("failed to write %s: %w", path, err)
+	}
+	defer insert.Close()
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	id := 0
+	err = s.eachLive(g, func(text string, vector []byte) error {
+		if _, err := insert.Exec(id, text, vector); err != nil {
+			return fmt.Errorf("failed to write %s: %w", path, err)
+		}
+		id++
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("failed to write %s: %w", path, err)
+	}
+	return nil
+}
+
+// exportFbin writes g as an .fbin matrix, which usearch and the ANN
+// benchmarks read: the row and column counts as uint32s, then float32 rows.
+// The texts go to the sidecar, one per line, so row i is line i.
+func (s *vectorStore) exportFbin(path string, g vectorGroup) error {
+	f, err := os.Create(path)
+	if err != nil {
+		return fmt.Errorf("failed to create %s: %w", path, err)
+	}
+	defer f.Close()
+	sidecar, err := os.Create(textsSidecar(path))
+	if err != nil {
+		return fmt.Errorf("failed to create %s: %w", textsSidecar(path), err)
+	}
+	defer sidecar.Close()
+
+	vectors := bufio.NewWriter(f)
+	texts := bufio.NewWriter(sidecar)
+	var header [8]byte
+	binary.LittleEndian.PutUint32(header[0:], uint32(g.count))
+	binary.LittleEndian.PutUint32(header[4:], uint32(g.dims))
+	vectors.Write(header[:])
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.eachLive(g, func(text string, vector []byte) error {
+		vectors.Write(vector)
+		texts.WriteString(strings.Join(strings.Fields(text), " ") + "\n")
+		return nil
+	})
+
+	if err := vectors.Flush(); err != nil {
+		return fmt.Errorf("failed to write %s: %w", path, err)
+	}
+	if err := f.Close(); err != nil {
+		return fmt.Errorf("failed to write %s: %w", path, err)
+	}
+	if err := texts.Flush(); err != nil {
+		return fmt.Errorf("failed to write texts: %w", err)
+	}
+	if err := sidecar.Close(); err != nil {
+		return fmt.Errorf("failed to write texts: %w", err)
+	}
+	return nil
+}
+
+// runVectorsExport handles `ember vectors export`, writing one model's
+// vectors for sqlite-vec or usearch
+func runVectorsExport(s *vectorStore, args []string) error {
+	fs := flag.NewFlagSet("vectors export", flag.ExitOnError)
+	model := fs.String("model", "", "model whose vectors to export, as provider/model (default: the only one)")
+	dims := fs.Int("dimensions", 0, "size of the vectors to export, when the model has several")
+	fs.Parse(args)
+	if fs.NArg() != 1 {
+		return errors.New("usage: ember vectors export [--model provider/model] [--dimensions N] FILE.db|FILE.sqlite|FILE.fbin")
+	}
+	path := fs.Arg(0)
+	ext := strings.ToLower(filepath.Ext(path))
+	if ext != ".db" && ext != ".sqlite" && ext != ".sqlite3" && ext != ".fbin" {
+		return fmt.Errorf("can't export to %s • use .db or .sqlite for sqlite-vec, or .fbin for usearch", path)
+	}
+
+	var matches []vectorGroup
+	all := s.groups()
+	for _, g := range all {
+		if (*model == "" || g.model == *model) && (*dims == 0 || g.dims == *dims) {
+			matches = append(matches, g)
+		}
+	}
+	if len(all) == 0 {
+		return fmt.Errorf("the vector store in %s is empty • ember vectors upsert adds texts", s.dir)
+	}
+	if len(matches) != 1 {
+		names := make([]string, len(all))
+		for i, g := range all {
+			names[i] = g.String()
+		}
+		what := "vectors from several models or of several sizes"
+		if len(matches) == 0 {
+			what = "no vectors like that"
+		}
+		return fmt.Errorf("the store holds %s • pick one with --model and --dimensions: %s", what, strings.Join(names, ", "))
+	}
+	g := matches[0]
+
+	if ext == ".fbin" {
+		if err := s.exportFbin(path, g); err != nil {
+			return err
+		}
+		fmt.Printf("💾 Wrote %d vectors from %s to %s and their texts to %s\n", g.count, g.model, path, textsSidecar(path))
+		return nil
+	}
+	if err := s.exportSQLiteVec(path, g); err != nil {
+		return err
+	}
+	fmt.Printf("💾 Wrote %d embeddings from %s to %s\n", g.count, g.model, path)
+	return nil
+}
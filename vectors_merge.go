@@ -0,0 +1,102 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"os"
+	"path/filepath"
+)
+
+// mergeVectorStores writes the live rows of sources into the empty store
+// out, as if each source had been upserted after the one before it: a text
+// in several stores is kept once, from the last, and a document in several
+// keeps only the chunks of its last copy. out ends up compacted to one
+// segment per model. It returns the rows read and the rows kept.
+func mergeVectorStores(out *vectorStore, sources []*vectorStore) (read, kept int, err error) {
+	out.mu.Lock()
+	if err := out.refresh(); err != nil {
+		out.mu.Unlock()
+		return 0, 0, err
+	}
+	if len(out.segments) > 0 {
+		out.mu.Unlock()
+		return 0, 0, fmt.Errorf("%s already has texts • merge into a new directory", out.dir)
+	}
+	seq := out.next
+	for _, s := range sources {
+		s.mu.Lock()
+		err := s.refresh()
+		var groups []*liveGroup
+		if err == nil {
+			groups = s.liveGroups()
+		}
+		s.mu.Unlock()
+		if err != nil {
+			out.mu.Unlock()
+			return read, 0, err
+		}
+		for _, group := range groups {
+			if err := writeSegment(out.dir, seq, group.meta(), group.embeddings); err != nil {
+				out.mu.Unlock()
+				return read, 0, fmt.Errorf("failed to merge %s: %w", s.dir, err)
+			}
+			seq++
+			read += len(group.embeddings)
+		}
+	}
+	// compact reads the new segments back in order, so later copies win
+	out.mu.Unlock()
+	if _, _, _, err := out.compact(); err != nil {
+		return read, 0, err
+	}
+	return read, out.size(), nil
+}
+
+// runVectorsMerge handles `ember vectors merge A B... -o OUT`
+func runVectorsMerge(args []string) error {
+	fs := flag.NewFlagSet("vectors merge", flag.ExitOnError)
+	output := fs.String("o", "", "directory of the merged store, which must be new or empty")
+	// Flags may follow the stores, as in merge A B -o C
+	var dirs []string
+	fs.Parse(args)
+	for fs.NArg() > 0 {
+		dirs = append(dirs, fs.Arg(0))
+		fs.Parse(fs.Args()[1:])
+	}
+	if len(dirs) < 2 || *output == "" {
+		fmt.Println("Usage: ember vectors merge STORE STORE... -o DIR")
+		os.Exit(2)
+	}
+
+	sources := make([]*vectorStore, len(dirs))
+	for i, dir := range dirs {
+		if filepath.Clean(dir) == filepath.Clean(*output) {
+			return fmt.Errorf("the merged store can't be one of the stores merged")
+		}
+		if _, err := os.Stat(dir); err != nil {
+			return fmt.Errorf("failed to open %s: %w", dir, err)
+		}
+		s, err := openVectorStore(dir)
+		if err != nil {
+			return err
+		}
+		defer s.Close()
+		sources[i] = s
+	}
+	out, err := openVectorStore(*output)
+	if err != nil {
+		return err
+	}
+	defer out.Close()
+
+	read, kept, err := mergeVectorStores(out, sources)
+	if err != nil {
+		return err
+	}
+	fmt.Printf("🔀 Merged %d stores into %s • %d texts", len(sources), *output, kept)
+	if dropped := read - kept; dropped > 0 {
+		fmt.Printf(", %d duplicates dropped", dropped)
+	}
+	fmt.Println()
+	return nil
+}
@@ -0,0 +1,468 @@
+package main
+
+import (
+	"crypto/hmac"
+	"crypto/md5"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"encoding/xml"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+)
+
+// vectorsRemote is a vector store kept in an S3 or GCS bucket and read
+// through a copy in the cache directory, so a team can build one store and
+// search it from any machine. Segments never change once written, so a pull
+// only downloads the files that are new, or for HNSW indexes changed, since
+// the last one.
+//
+// Requests go to the S3 XML API, which GCS also serves, signed with
+// Signature Version 4 when credentials are set and sent anonymously to
+// public buckets otherwise.
+type vectorsRemote struct {
+	url string
+	// endpoint is the bucket's URL; keys are prefix plus a file name
+	endpoint string
+	prefix   string
+	region   string
+	keyID    string
+	secret   string
+	token    string
+	client   *http.Client
+	// cacheDir holds the copy searches read
+	cacheDir string
+}
+
+// remoteObject is a store file in the bucket
+type remoteObject struct {
+	etag string
+	size int64
+}
+
+// loadVectorsRemote reads EMBER_VECTORS_REMOTE, s3://BUCKET/PREFIX or
+// gs://BUCKET/PREFIX, with EMBER_VECTORS_ENDPOINT for other S3-compatible
+// services and the AWS_* credentials, which for GCS are an HMAC key. It
+// returns nil when no bucket is set.
+func loadVectorsRemote() (*vectorsRemote, error) {
+	value := os.Getenv("EMBER_VECTORS_REMOTE")
+	if value == "" {
+		return nil, nil
+	}
+	u, err := url.Parse(value)
+	if err != nil || (u.Scheme != "s3" && u.Scheme != "gs") || u.Host == "" {
+		return nil, fmt.Errorf("invalid EMBER_VECTORS_REMOTE %q: use s3://BUCKET/PREFIX or gs://BUCKET/PREFIX", value)
+	}
+
+	r := &vectorsRemote{
+		url:    value,
+		region: os.Getenv("AWS_REGION"),
+		keyID:  os.Getenv("AWS_ACCESS_KEY_ID"),
+		secret: os.Getenv("AWS_SECRET_ACCESS_KEY"),
+		token:  os.Getenv("AWS_SESSION_TOKEN"),
+		client: &http.Client{Timeout: 10 * time.Minute},
+	}
+	if r.region == "" {
+		r.region = os.Getenv("AWS_DEFAULT_REGION")
+	}
+	if r.region == "" {
+		r.region = "us-east-1"
+		if u.Scheme == "gs" {
+			r.region = "auto"
+		}
+	}
+	if r.keyID != "" && r.secret == "" {
+		return nil, fmt.Errorf("AWS_ACCESS_KEY_ID is set but AWS_SECRET_ACCESS_KEY isn't")
+	}
+	if prefix := strings.Trim(u.Path, "/"); prefix != "" {
+		r.prefix = prefix + "/"
+	}
+
+	// Buckets with dots in their names don't match the certificate of a
+	// virtual-hosted address, so they're addressed by path
+	bucket := u.Host
+	switch endpoint := os.Getenv("EMBER_VECTORS_ENDPOINT"); {
+	case endpoint != "":
+		if !strings.Contains(endpoint, "://") {
+			endpoint = "https://" + endpoint
+		}
+		if _, err := url.Parse(endpoint); err != nil {
+			return nil, fmt.Errorf("invalid EMBER_VECTORS_ENDPOINT %q: %w", endpoint, err)
+		}
+		r.endpoint = strings.TrimRight(endpoint, "/") + "/" + bucket
+	case u.Scheme == "gs":
+		r.endpoint = "https://storage.googleapis.com/" + bucket
+	case strings.Contains(bucket, "."):
+		r.endpoint = "https://s3." + r.region + ".amazonaws.com/" + bucket
+	default:
+		r.endpoint = "https://" + bucket + ".s3." + r.region + ".amazonaws.com"
+	}
+
+	dir, err := os.UserCacheDir()
+	if err != nil {
+		return nil, fmt.Errorf("failed to find cache directory: %w", err)
+	}
+	sum := sha256.Sum256([]byte(r.endpoint + "/" + r.prefix))
+	r.cacheDir = filepath.Join(dir, "ember", "vectors-remote", hex.EncodeToString(sum[:6]))
+	return r, nil
+}
+
+// isStoreFile reports whether name is a segment or index file, as opposed
+// to a temporary file or anything else kept beside them
+func isStoreFile(name string) bool {
+	if strings.Contains(name, "/") {
+		return false
+	}
+	if strings.HasPrefix(name, "seg-") {
+		return strings.HasSuffix(name, ".json") || strings.HasSuffix(name, ".vec")
+	}
+	return strings.HasPrefix(name, "hnsw-") && strings.HasSuffix(name, ".idx")
+}
+
+// finishesSegment orders store files so a segment's JSON file comes after
+// its vectors and the indexes, the order they're written in locally
+func finishesSegment(names []string) {
+	sort.Slice(names, func(i, j int) bool {
+		ji, jj := strings.HasSuffix(names[i], ".json"), strings.HasSuffix(names[j], ".json")
+		if ji != jj {
+			return jj
+		}
+		return names[i] < names[j]
+	})
+}
+
+// do sends a request for key, or for the bucket when key is empty, and
+// returns the response when it succeeded
+func (r *vectorsRemote) do(method, key string, query url.Values, body io.Reader, size int64) (*http.Response, error) {
+	target := r.endpoint + "/" + r.prefix + key
+	if key == "" {
+		target = r.endpoint + "/"
+	}
+	if len(query) > 0 {
+		// Signing needs spaces as %20, where Encode writes +
+		target += "?" + strings.ReplaceAll(query.Encode(), "+", "%20")
+	}
+	req, err := http.NewRequest(method, target, body)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create request: %w", err)
+	}
+	if body != nil {
+		req.ContentLength = size
+	}
+	r.sign(req)
+
+	resp, err := r.client.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("failed to reach %s: %w", r.url, err)
+	}
+	if resp.StatusCode/100 != 2 {
+		defer resp.Body.Close()
+		data, _ := io.ReadAll(io.LimitReader(resp.Body, 4096))
+		return nil, &APIStatusError{StatusCode: resp.StatusCode, Body: string(data)}
+	}
+	return resp, nil
+}
+
+// sign adds a Signature Version 4 authorization to req. The payload isn't
+// hashed, which S3 and GCS allow over HTTPS, so uploads can stream.
+func (r *vectorsRemote) sign(req *http.Request) {
+	if r.keyID == "" {
+		return
+	}
+	stamp := time.Now().UTC().Format("20060102T150405Z")
+	day := stamp[:8]
+	req.Header.Set("X-Amz-Date", stamp)
+	req.Header.Set("X-Amz-Content-Sha256", "UNSIGNED-PAYLOAD")
+	headers := []string{"host", "x-amz-content-sha256", "x-amz-date"}
+	values := []string{req.URL.Host, "UNSIGNED-PAYLOAD", stamp}
+	if r.token != "" {
+		req.Header.Set("X-Amz-Security-Token", r.token)
+		headers = append(headers, "x-amz-security-token")
+		values = append(values, r.token)
+	}
+
+	var canonical strings.Builder
+	canonical.WriteString(req.Method + "\n" + req.URL.EscapedPath() + "\n" + req.URL.RawQuery + "\n")
+	for i, header := range headers {
+		canonical.WriteString(header + ":" + values[i] + "\n")
+	}
+	signed := strings.Join(headers, ";")
+	canonical.WriteString("\n" + signed + "\nUNSIGNED-PAYLOAD")
+
+	scope := day + "/" + r.region + "/s3/aws4_request"
+	sum := sha256.Sum256([]byte(canonical.String()))
+	key := []byte("AWS4" + r.secret)
+	for _, part := range []string{day, r.region, "s3", "aws4_request"} {
+		key = hmacSHA256(key, part)
+	}
+	signature := hmacSHA256(key, "AWS4-HMAC-SHA256\n"+stamp+"\n"+scope+"\n"+hex.EncodeToString(sum[:]))
+	req.Header.Set("Authorization", fmt.Sprintf("AWS4-HMAC-SHA256 Credential=%s/%s, SignedHeaders=%s, Signature=%s", r.keyID, scope, signed, hex.EncodeToString(signature)))
+}
+
+func hmacSHA256(key []byte, data string) []byte {
+	mac := hmac.New(sha256.New, key)
+	mac.Write([]byte(data))
+	return mac.Sum(nil)
+}
+
+// list returns the store files under the prefix by name
+func (r *vectorsRemote) list() (map[string]remoteObject, error) {
+	objects := make(map[string]remoteObject)
+	token := ""
+	for {
+		query := url.Values{"list-type": {"2"}, "prefix": {r.prefix}}
+		if token != "" {
+			query.Set("continuation-token", token)
+		}
+		resp, err := r.do("GET", "", query, nil, 0)
+		if err != nil {
+			return nil, fmt.Errorf("failed to list %s: %w", r.url, err)
+		}
+		var page struct {
+			Contents []struct {
+				Key  string
+				ETag string
+				Size int64
+			}
+			IsTruncated           bool
+			NextContinuationToken string
+		}
+		err = xml.NewDecoder(resp.Body).Decode(&page)
+		resp.Body.Close()
+		if err != nil {
+			return nil, fmt.Errorf("failed to list %s: %w", r.url, err)
+		}
+		for _, object := range page.Contents {
+			if name := strings.TrimPrefix(object.Key, r.prefix); isStoreFile(name) {
+				objects[name] = remoteObject{etag: strings.Trim(object.ETag, `"`), size: object.Size}
+			}
+		}
+		if !page.IsTruncated || page.NextContinuationToken == "" {
+			return objects, nil
+		}
+		token = page.NextContinuationToken
+	}
+}
+
+// pull brings the cached copy up to date with the bucket: files gone from
+// it are removed and new or changed ones downloaded, each segment's JSON
+// file last. When the bucket can't be reached, the copy from the last pull
+// is used.
+func (r *vectorsRemote) pull() error {
+	if err := os.MkdirAll(r.cacheDir, 0o755); err != nil {
+		return fmt.Errorf("failed to create %s: %w", r.cacheDir, err)
+	}
+	// pulled.json records the ETag each file had when it was downloaded
+	manifest := filepath.Join(r.cacheDir, "pulled.json")
+	pulled := make(map[string]string)
+	if data, err := os.ReadFile(manifest); err == nil {
+		json.Unmarshal(data, &pulled)
+	}
+	objects, err := r.list()
+	if err != nil {
+		if len(pulled) == 0 {
+			return err
+		}
+		fmt.Fprintf(os.Stderr, "⚠️  %v • searching the copy pulled earlier\n", err)
+		return nil
+	}
+
+	entries, err := os.ReadDir(r.cacheDir)
+	if err != nil {
+		return fmt.Errorf("failed to read %s: %w", r.cacheDir, err)
+	}
+	var stale []string
+	for _, entry := range entries {
+		if name := entry.Name(); isStoreFile(name) {
+			if _, ok := objects[name]; !ok {
+				stale = append(stale, name)
+			}
+		}
+	}
+	// Removed in the reverse of the order they're written, JSON files first
+	finishesSegment(stale)
+	for i := len(stale) - 1; i >= 0; i-- {
+		os.Remove(filepath.Join(r.cacheDir, stale[i]))
+		delete(pulled, stale[i])
+	}
+
+	var names []string
+	for name, object := range objects {
+		info, err := os.Stat(filepath.Join(r.cacheDir, name))
+		if err != nil || pulled[name] != object.etag || info.Size() != object.size {
+			names = append(names, name)
+		}
+	}
+	finishesSegment(names)
+	var bytes int64
+	for _, name := range names {
+		if err = r.download(name); err != nil {
+			break
+		}
+		pulled[name] = objects[name].etag
+		bytes += objects[name].size
+	}
+	if data, err := json.Marshal(pulled); err == nil {
+		os.WriteFile(manifest, data, 0o644)
+	}
+	if err != nil {
+		return err
+	}
+	if len(names) > 0 {
+		fmt.Fprintf(os.Stderr, "☁️  Pulled %d files (%.1f MB) from %s\n", len(names), float64(bytes)/(1<<20), r.url)
+	}
+	return nil
+}
+
+// download replaces the cached copy of name with the bucket's
+func (r *vectorsRemote) download(name string) error {
+	resp, err := r.do("GET", name, nil, nil, 0)
+	if err != nil {
+		return fmt.Errorf("failed to download %s from %s: %w", name, r.url, err)
+	}
+	defer resp.Body.Close()
+	f, err := os.CreateTemp(r.cacheDir, name+".*.tmp")
+	if err != nil {
+		return fmt.Errorf("failed to download %s: %w", name, err)
+	}
+	defer os.Remove(f.Name())
+	_, err = io.Copy(f, resp.Body)
+	if closeErr := f.Close(); err == nil {
+		err = closeErr
+	}
+	if err == nil {
+		err = os.Rename(f.Name(), filepath.Join(r.cacheDir, name))
+	}
+	if err != nil {
+		return fmt.Errorf("failed to download %s: %w", name, err)
+	}
+	return nil
+}
+
+// push uploads the store files in dir that the bucket doesn't have as they
+// are, each segment's JSON file after its vectors, then deletes the ones
+// dir no longer has, such as segments replaced by compacting
+func (r *vectorsRemote) push(dir string) (uploaded, removed int, err error) {
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		return 0, 0, fmt.Errorf("failed to read %s: %w", dir, err)
+	}
+	objects, err := r.list()
+	if err != nil {
+		return 0, 0, err
+	}
+
+	var names []string
+	local := make(map[string]bool)
+	for _, entry := range entries {
+		if name := entry.Name(); isStoreFile(name) {
+			names = append(names, name)
+			local[name] = true
+		}
+	}
+	finishesSegment(names)
+	for _, name := range names {
+		// A single-part upload's ETag is the MD5 of its content
+		f, err := os.Open(filepath.Join(dir, name))
+		if err != nil {
+			return uploaded, 0, fmt.Errorf("failed to open %s: %w", name, err)
+		}
+		h := md5.New()
+		size, err := io.Copy(h, f)
+		if err == nil {
+			if object, ok := objects[name]; ok && object.size == size && object.etag == hex.EncodeToString(h.Sum(nil)) {
+				f.Close()
+				continue
+			}
+			_, err = f.Seek(0, io.SeekStart)
+		}
+		if err == nil {
+			var resp *http.Response
+			if resp, err = r.do("PUT", name, nil, f, size); err == nil {
+				resp.Body.Close()
+			}
+		}
+		f.Close()
+		if err != nil {
+			return uploaded, 0, fmt.Errorf("failed to upload %s to %s: %w", name, r.url, err)
+		}
+		uploaded++
+	}
+
+	var stale []string
+	for name := range objects {
+		if !local[name] {
+			stale = append(stale, name)
+		}
+	}
+	finishesSegment(stale)
+	for i := len(stale) - 1; i >= 0; i-- {
+		resp, err := r.do("DELETE", stale[i], nil, nil, 0)
+		if err != nil {
+			return uploaded, removed, fmt.Errorf("failed to delete %s from %s: %w", stale[i], r.url, err)
+		}
+		resp.Body.Close()
+		removed++
+	}
+	return uploaded, removed, nil
+}
+
+// runVectorsPush handles `ember vectors push [DIR]`, uploading the local
+// store, or DIR, to EMBER_VECTORS_REMOTE
+func runVectorsPush(args []string) error {
+	if len(args) > 1 {
+		fmt.Println("Usage: ember vectors push [DIR]")
+		os.Exit(2)
+	}
+	r, err := loadVectorsRemote()
+	if err != nil {
+		return err
+	}
+	if r == nil {
+		return errors.New("EMBER_VECTORS_REMOTE isn't set • set it to s3://BUCKET/PREFIX or gs://BUCKET/PREFIX")
+	}
+	dir := ""
+	if len(args) == 1 {
+		dir = args[0]
+	} else if dir, err = localVectorStoreDir(); err != nil {
+		return err
+	}
+	if filepath.Clean(dir) == r.cacheDir {
+		return fmt.Errorf("%s is the copy pulled from %s • push a local store instead", dir, r.url)
+	}
+
+	// Opening the store checks its segments before they're shared
+	s, err := openVectorStore(dir)
+	if err != nil {
+		return err
+	}
+	texts := s.size()
+	s.Close()
+	if texts == 0 {
+		return fmt.Errorf("the vector store in %s is empty", dir)
+	}
+
+	uploaded, removed, err := r.push(dir)
+	if err != nil {
+		return err
+	}
+	fmt.Printf("☁️  Pushed %s to %s • %d texts • %d files uploaded, %d removed\n", dir, r.url, texts, uploaded, removed)
+	return nil
+}
+
+// writable refuses changes to a copy pulled from a bucket, which the next
+// pull would undo
+func (s *vectorStore) writable() error {
+	if s.remote != "" {
+		return fmt.Errorf("the vector store is a copy of %s • change a local store and ember vectors push it", s.remote)
+	}
+	return nil
+}
@@ -0,0 +1,171 @@
+package main
+
+import (
+	"crypto/aes"
+	"crypto/cipher"
+	"fmt"
+	"math"
+	"os"
+	"strings"
+	"testing"
+)
+
+// testEmbeddings returns n texts with dims-dimensional vectors that vary by row
+func testEmbeddings(n, dims int) []CustomEmbedding {
+	embeddings := make([]CustomEmbedding, n)
+	for i := range embeddings {
+		vector := make([]float64, dims)
+		for j := range vector {
+			vector[j] = math.Sin(float64(i*dims+j)) / 3
+		}
+		embeddings[i] = CustomEmbedding{Text: fmt.Sprintf("text %d", i), Embedding: vector}
+	}
+	return embeddings
+}
+
+// useTestEncryption turns encryption at rest on with a fixed key until the test ends
+func useTestEncryption(t *testing.T) {
+	block, err := aes.NewCipher(make([]byte, 32))
+	if err != nil {
+		t.Fatal(err)
+	}
+	aead, err := cipher.NewGCM(block)
+	if err != nil {
+		t.Fatal(err)
+	}
+	atRest = aead
+	t.Cleanup(func() { atRest = nil })
+}
+
+func TestSegmentRoundTrip(t *testing.T) {
+	for _, tc := range []struct {
+		name      string
+		compress  string
+		encrypted bool
+	}{
+		{"compressed", "1", false},
+		{"mapped", "0", false},
+		{"encrypted", "1", true},
+		{"encrypted uncompressed", "0", true},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			t.Setenv("EMBER_VECTORS_COMPRESS", tc.compress)
+			if tc.encrypted {
+				useTestEncryption(t)
+			}
+			dir := t.TempDir()
+			embeddings := testEmbeddings(5, 7)
+			meta := segmentMeta{Model: "mock/hashed-words", Docs: []string{"a", "a", "b", "", "c"}, Deleted: []string{"old"}}
+			if err := writeSegment(dir, 3, meta, embeddings); err != nil {
+				t.Fatal(err)
+			}
+
+			segment, err := loadSegment(dir, 3)
+			if err != nil {
+				t.Fatal(err)
+			}
+			defer segment.unmap()
+			if segment.seq != 3 || segment.model != meta.Model || segment.dims != 7 {
+				t.Fatalf("got seq %d, model %q, dims %d", segment.seq, segment.model, segment.dims)
+			}
+			if strings.Join(segment.docs, ",") != "a,a,b,,c" || strings.Join(segment.deleted, ",") != "old" {
+				t.Fatalf("got docs %q, deleted %q", segment.docs, segment.deleted)
+			}
+			for row, e := range embeddings {
+				if segment.texts[row] != e.Text {
+					t.Errorf("row %d: text %q, want %q", row, segment.texts[row], e.Text)
+				}
+				// Vectors are stored as float32
+				for i, v := range segment.vector(row) {
+					if v != float64(float32(e.Embedding[i])) {
+						t.Fatalf("row %d: vector[%d] is %v, want %v", row, i, v, float32(e.Embedding[i]))
+					}
+				}
+			}
+		})
+	}
+}
+
+func TestSegmentWithoutVectors(t *testing.T) {
+	dir := t.TempDir()
+	if err := writeSegment(dir, 1, segmentMeta{Deleted: []string{"doc"}}, nil); err != nil {
+		t.Fatal(err)
+	}
+	segment, err := loadSegment(dir, 1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer segment.unmap()
+	if len(segment.texts) != 0 || strings.Join(segment.deleted, ",") != "doc" {
+		t.Fatalf("got texts %q, deleted %q", segment.texts, segment.deleted)
+	}
+}
+
+func TestSegmentCorruption(t *testing.T) {
+	for _, tc := range []struct {
+		name     string
+		compress string
+		ext      string
+		damage   func([]byte) []byte
+		want     string
+	}{
+		{"flipped vector byte", "1", ".vec", func(data []byte) []byte { data[len(data)/2] ^= 0xff; return data }, "checksum"},
+		{"flipped mapped vector byte", "0", ".vec", func(data []byte) []byte { data[len(data)/2] ^= 0xff; return data }, "checksum"},
+		{"truncated vectors", "1", ".vec", func(data []byte) []byte { return data[:len(data)-9] }, "checksum"},
+		{"empty vector file", "1", ".vec", func([]byte) []byte { return nil }, "isn't an ember vector file"},
+		{"wrong magic", "1", ".vec", func(data []byte) []byte { copy(data, "NOPE"); return data }, "isn't an ember vector file"},
+		{"newer version", "0", ".vec", func(data []byte) []byte { data[4] = 99; return data }, "newer ember"},
+		{"edited text", "1", ".json", func(data []byte) []byte { return []byte(strings.Replace(string(data), "text 2", "text 9", 1)) }, "checksum"},
+		{"truncated texts", "1", ".json", func(data []byte) []byte { return data[:len(data)/2] }, "failed to read"},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			t.Setenv("EMBER_VECTORS_COMPRESS", tc.compress)
+			dir := t.TempDir()
+			if err := writeSegment(dir, 1, segmentMeta{Model: "mock/hashed-words"}, testEmbeddings(4, 16)); err != nil {
+				t.Fatal(err)
+			}
+			path := segmentPath(dir, 1, tc.ext)
+			data, err := os.ReadFile(path)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if err := os.WriteFile(path, tc.damage(data), 0o644); err != nil {
+				t.Fatal(err)
+			}
+
+			segment, err := loadSegment(dir, 1)
+			if err == nil {
+				segment.unmap()
+				t.Fatal("loaded a damaged segment")
+			}
+			if !strings.Contains(err.Error(), tc.want) {
+				t.Fatalf("got %q, want it to mention %q", err, tc.want)
+			}
+		})
+	}
+}
+
+func TestSegmentMissingVectors(t *testing.T) {
+	dir := t.TempDir()
+	if err := writeSegment(dir, 1, segmentMeta{Model: "mock/hashed-words"}, testEmbeddings(2, 4)); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.Remove(segmentPath(dir, 1, ".vec")); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := loadSegment(dir, 1); err == nil {
+		t.Fatal("loaded a segment without its vector file")
+	}
+}
+
+func TestSegmentEncryptedNeedsKey(t *testing.T) {
+	useTestEncryption(t)
+	dir := t.TempDir()
+	if err := writeSegment(dir, 1, segmentMeta{Model: "mock/hashed-words"}, testEmbeddings(2, 4)); err != nil {
+		t.Fatal(err)
+	}
+	atRest = nil
+	if _, err := loadSegment(dir, 1); err == nil || !strings.Contains(err.Error(), "encrypted") {
+		t.Fatalf("got %v, want an error saying the segment is encrypted", err)
+	}
+}
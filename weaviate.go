@@ -0,0 +1,345 @@
+package main
+
+import (
+	"bytes"
+	"crypto/sha1"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"os"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Class used unless EMBER_WEAVIATE_CLASS says otherwise
+const defaultWeaviateClass = "EmberText"
+
+// Weaviate class names are GraphQL type names starting with a capital
+var weaviateClassName = regexp.MustCompile(`^[A-Z][_0-9A-Za-z]*$`)
+
+// weaviateClient keeps comparison texts as objects of one class, with the
+// vectors ember makes, through Weaviate's REST and GraphQL APIs
+type weaviateClient struct {
+	baseURL string
+	apiKey  string
+	class   string
+	limit   int
+	client  *http.Client
+}
+
+// weaviateObject is one embedded text in the class
+type weaviateObject struct {
+	Class      string         `json:"class"`
+	ID         string         `json:"id"`
+	Properties map[string]any `json:"properties"`
+	Vector     []float64      `json:"vector"`
+}
+
+// loadWeaviateClient reads EMBER_WEAVIATE_URL, EMBER_WEAVIATE_API_KEY,
+// EMBER_WEAVIATE_CLASS and EMBER_WEAVIATE_LIMIT. It returns nil when no URL is set.
+func loadWeaviateClient() (*weaviateClient, error) {
+	baseURL := os.Getenv("EMBER_WEAVIATE_URL")
+	if baseURL == "" {
+		return nil, nil
+	}
+	if _, err := url.Parse(baseURL); err != nil {
+		return nil, fmt.Errorf("invalid EMBER_WEAVIATE_URL %q: %w", baseURL, err)
+	}
+
+	w := &weaviateClient{
+		baseURL: strings.TrimRight(baseURL, "/"),
+		apiKey:  os.Getenv("EMBER_WEAVIATE_API_KEY"),
+		class:   os.Getenv("EMBER_WEAVIATE_CLASS"),
+		limit:   defaultRemoteLimit,
+		client:  &http.Client{Timeout: 60 * time.Second},
+	}
+	if w.class == "" {
+		w.class = defaultWeaviateClass
+	}
+	// Weaviate capitalizes class names itself, but GraphQL queries need the real name
+	w.class = strings.ToUpper(w.class[:1]) + w.class[1:]
+	if !weaviateClassName.MatchString(w.class) {
+		return nil, fmt.Errorf("invalid EMBER_WEAVIATE_CLASS %q: use letters, digits and underscores", w.class)
+	}
+	if value := os.Getenv("EMBER_WEAVIATE_LIMIT"); value != "" {
+		limit, err := strconv.Atoi(value)
+		if err != nil || limit < 1 {
+			return nil, fmt.Errorf("invalid EMBER_WEAVIATE_LIMIT %q", value)
+		}
+		w.limit = limit
+	}
+	return w, nil
+}
+
+// do sends body as JSON and decodes the response into result
+func (w *weaviateClient) do(method, path string, body, result any) error {
+	var reader io.Reader
+	if body != nil {
+		data, err := json.Marshal(body)
+		if err != nil {
+			return fmt.Errorf("failed to marshal request: %w", err)
+		}
+		reader = bytes.NewReader(data)
+	}
+
+	req, err := http.NewRequest(method, w.baseURL+path, reader)
+	if err != nil {
+		return fmt.Errorf("failed to create request: %w", err)
+	}
+	req.Header.Set("Content-Type", "application/json")
+	if w.apiKey != "" {
+		req.Header.Set("Authorization", "Bearer "+w.apiKey)
+	}
+
+	resp, err := w.client.Do(req)
+	if err != nil {
+		return fmt.Errorf("failed to reach Weaviate: %w", err)
+	}
+	defer resp.Body.Close()
+
+	data, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return fmt.Errorf("failed to read response: %w", err)
+	}
+	if resp.StatusCode != http.StatusOK {
+		return &APIStatusError{StatusCode: resp.StatusCode, Body: string(data)}
+	}
+	if result == nil {
+		return nil
+	}
+	if err := json.Unmarshal(data, result); err != nil {
+		return fmt.Errorf("failed to unmarshal response: %w", err)
+	}
+	return nil
+}
+
+// graphql runs query and decodes its data into result
+func (w *weaviateClient) graphql(query string, result any) error {
+	var resp struct {
+		Data   json.RawMessage `json:"data"`
+		Errors []struct {
+			Message string `json:"message"`
+		} `json:"errors"`
+	}
+	if err := w.do("POST", "/v1/graphql", map[string]string{"query": query}, &resp); err != nil {
+		return err
+	}
+	if len(resp.Errors) > 0 {
+		return fmt.Errorf("%s", resp.Errors[0].Message)
+	}
+	if err := json.Unmarshal(resp.Data, result); err != nil {
+		return fmt.Errorf("failed to unmarshal response: %w", err)
+	}
+	return nil
+}
+
+// classInfo reports whether the class exists and, if it holds any objects,
+// the size of their vectors. Weaviate's schema doesn't record the size.
+func (w *weaviateClient) classInfo() (bool, int, error) {
+	err := w.do("GET", "/v1/schema/"+url.PathEscape(w.class), nil, nil)
+	if apiErr, ok := err.(*APIStatusError); ok && apiErr.StatusCode == http.StatusNotFound {
+		return false, 0, nil
+	}
+	if err != nil {
+		return false, 0, fmt.Errorf("failed to read class %s: %w", w.class, err)
+	}
+
+	var objects struct {
+		Objects []struct {
+			Vector []float64 `json:"vector"`
+		} `json:"objects"`
+	}
+	params := url.Values{"class": {w.class}, "limit": {"1"}, "include": {"vector"}}
+	if err := w.do("GET", "/v1/objects?"+params.Encode(), nil, &objects); err != nil {
+		return true, 0, fmt.Errorf("failed to read class %s: %w", w.class, err)
+	}
+	if len(objects.Objects) == 0 {
+		return true, 0, nil
+	}
+	return true, len(objects.Objects[0].Vector), nil
+}
+
+// ensureCollection creates the class for vectors ember supplies, or checks
+// that its objects have vectors of size dimensions
+func (w *weaviateClient) ensureCollection(dimensions int) error {
+	exists, size, err := w.classInfo()
+	switch {
+	case err != nil:
+		return err
+	case exists && (size == 0 || size == dimensions):
+		return nil
+	case exists:
+		return fmt.Errorf("class %s holds %d-dimensional vectors, not %d", w.class, size, dimensions)
+	}
+
+	class := map[string]any{
+		"class":             w.class,
+		"description":       "Texts embedded by ember",
+		"vectorizer":        "none",
+		"vectorIndexConfig": map[string]any{"distance": "cosine"},
+		"properties": []any{
+			map[string]any{"name": "text", "dataType": []string{"text"}},
+			// Searches filter on the model, so it's matched whole rather than by word
+			map[string]any{"name": "model", "dataType": []string{"text"}, "tokenization": "field"},
+		},
+	}
+	if err := w.do("POST", "/v1/schema", class, nil); err != nil {
+		return fmt.Errorf("failed to create class %s: %w", w.class, err)
+	}
+	return nil
+}
+
+// weaviateObjectID derives an object's UUID from its text and model, so
+// upserting the same text again replaces it instead of adding a copy
+func weaviateObjectID(model, text string) string {
+	h := sha1.New()
+	h.Write([]byte(model))
+	h.Write([]byte{0})
+	h.Write([]byte(text))
+	b := h.Sum(nil)[:16]
+	// Mark it as a name-based (version 5) UUID
+	b[6] = b[6]&0x0f | 0x50
+	b[8] = b[8]&0x3f | 0x80
+	return fmt.Sprintf("%x-%x-%x-%x-%x", b[0:4], b[4:6], b[6:8], b[8:10], b[10:16])
+}
+
+// upsert adds embeddings made by model to the class
+func (w *weaviateClient) upsert(model ModelInfo, embeddings []CustomEmbedding) error {
+	name := model.Provider + "/" + model.Model
+	objects := make([]weaviateObject, len(embeddings))
+	for i, e := range embeddings {
+		objects[i] = weaviateObject{
+			Class:      w.class,
+			ID:         weaviateObjectID(name, e.Text),
+			Properties: map[string]any{"text": e.Text, "model": name},
+			Vector:     e.Embedding,
+		}
+	}
+
+	// The batch succeeds as a whole even when objects fail, so each result is checked
+	var results []struct {
+		Result struct {
+			Errors struct {
+				Error []struct {
+					Message string `json:"message"`
+				} `json:"error"`
+			} `json:"errors"`
+		} `json:"result"`
+	}
+	if err := w.do("POST", "/v1/batch/objects", map[string]any{"objects": objects}, &results); err != nil {
+		return fmt.Errorf("failed to upsert into %s: %w", w.class, err)
+	}
+	for i, r := range results {
+		if len(r.Result.Errors.Error) > 0 {
+			return fmt.Errorf("failed to upsert %q into %s: %s", truncateText(embeddings[i].Text, 40), w.class, r.Result.Errors.Error[0].Message)
+		}
+	}
+	return nil
+}
+
+// search returns the class's k texts from model most similar to vector,
+// best first, with their vectors
+func (w *weaviateClient) search(model ModelInfo, vector []float64, k int) ([]CustomEmbedding, []float64, error) {
+	vectorJSON, _ := json.Marshal(vector)
+	modelJSON, _ := json.Marshal(model.Provider + "/" + model.Model)
+	query := fmt.Sprintf(`{ Get { %s(nearVector: {vector: %s}, limit: %d, where: {path: ["model"], operator: Equal, valueText: %s}) { text _additional { distance vector } } } }`,
+		w.class, vectorJSON, k, modelJSON)
+
+	var data struct {
+		Get map[string][]struct {
+			Text       string `json:"text"`
+			Additional struct {
+				Distance float64   `json:"distance"`
+				Vector   []float64 `json:"vector"`
+			} `json:"_additional"`
+		} `json:"Get"`
+	}
+	if err := w.graphql(query, &data); err != nil {
+		return nil, nil, fmt.Errorf("failed to search %s: %w", w.class, err)
+	}
+
+	hits := data.Get[w.class]
+	embeddings := make([]CustomEmbedding, len(hits))
+	scores := make([]float64, len(hits))
+	for i, hit := range hits {
+		embeddings[i] = CustomEmbedding{Text: hit.Text, Embedding: hit.Additional.Vector}
+		// Weaviate reports cosine distance
+		scores[i] = 1 - hit.Additional.Distance
+	}
+	return embeddings, scores, nil
+}
+
+// count returns the number of objects in the class
+func (w *weaviateClient) count() (int, error) {
+	var data struct {
+		Aggregate map[string][]struct {
+			Meta struct {
+				Count int `json:"count"`
+			} `json:"meta"`
+		} `json:"Aggregate"`
+	}
+	if err := w.graphql(fmt.Sprintf(`{ Aggregate { %s { meta { count } } } }`, w.class), &data); err != nil {
+		return 0, fmt.Errorf("failed to count %s: %w", w.class, err)
+	}
+	if groups := data.Aggregate[w.class]; len(groups) > 0 {
+		return groups[0].Meta.Count, nil
+	}
+	return 0, nil
+}
+
+// label names the class on screen
+func (w *weaviateClient) label() string {
+	return "Weaviate class " + w.class
+}
+
+// nearest returns the class's texts nearest to vector, for remoteIndex
+func (w *weaviateClient) nearest(model ModelInfo, vector []float64) ([]CustomEmbedding, error) {
+	hits, _, err := w.search(model, vector, w.limit)
+	return hits, err
+}
+
+// runWeaviateCommand handles `ember weaviate upsert|search|info`
+func runWeaviateCommand(args []string) {
+	if len(args) == 0 || (args[0] != "upsert" && args[0] != "search" && args[0] != "info") {
+		fmt.Println("Usage: ember weaviate upsert [--redact] [--set NAME | [--batch N] [--yes] [--dry-run] FILE] | search [-k N] QUERY | info")
+		os.Exit(2)
+	}
+	w, err := loadWeaviateClient()
+	if err == nil && w == nil {
+		err = fmt.Errorf("set EMBER_WEAVIATE_URL to the Weaviate server, e.g. http://localhost:8080")
+	}
+	if err != nil {
+		displayError(err)
+		os.Exit(1)
+	}
+
+	switch args[0] {
+	case "upsert":
+		err = runRemoteUpsert(w, "weaviate", args[1:])
+	case "search":
+		err = runRemoteSearch(w, "weaviate", w.limit, args[1:])
+	case "info":
+		var exists bool
+		var size, count int
+		if exists, size, err = w.classInfo(); err == nil {
+			switch {
+			case !exists:
+				fmt.Printf("Class %s doesn't exist yet • ember weaviate upsert creates it\n", w.class)
+			case size == 0:
+				fmt.Printf("Class %s is empty\n", w.class)
+			default:
+				if count, err = w.count(); err == nil {
+					fmt.Printf("Class %s holds %d objects with %d-dimensional vectors\n", w.class, count, size)
+				}
+			}
+		}
+	}
+	if err != nil {
+		displayError(err)
+		os.Exit(1)
+	}
+}
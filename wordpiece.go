@@ -0,0 +1,140 @@
+//go:build onnx
+
+package main
+
+import (
+	"bufio"
+	"fmt"
+	"os"
+	"strings"
+	"unicode"
+
+	"golang.org/x/text/unicode/norm"
+)
+
+// Words longer than this are mapped to [UNK], as in the reference BERT tokenizer
+const maxWordPieceChars = 100
+
+// wordPieceTokenizer is an uncased BERT tokenizer driven by a vocab.txt file
+type wordPieceTokenizer struct {
+	vocab map[string]int64
+	cls   int64
+	sep   int64
+	unk   int64
+}
+
+func loadWordPieceTokenizer(vocabPath string) (*wordPieceTokenizer, error) {
+	f, err := os.Open(vocabPath)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open vocab: %w", err)
+	}
+	defer f.Close()
+
+	vocab := make(map[string]int64)
+	scanner := bufio.NewScanner(f)
+	for id := int64(0); scanner.Scan(); id++ {
+		vocab[strings.TrimRight(scanner.Text(), "\r")] = id
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, fmt.Errorf("failed to read vocab: %w", err)
+	}
+
+	t := &wordPieceTokenizer{vocab: vocab}
+	for token, dst := range map[string]*int64{"[CLS]": &t.cls, "[SEP]": &t.sep, "[UNK]": &t.unk} {
+		id, ok := vocab[token]
+		if !ok {
+			return nil, fmt.Errorf("vocab is missing %s", token)
+		}
+		*dst = id
+	}
+
+	return t, nil
+}
+
+// Encode returns token IDs wrapped in [CLS]/[SEP], truncated to maxTokens
+func (t *wordPieceTokenizer) Encode(text string, maxTokens int) []int64 {
+	ids := []int64{t.cls}
+	for _, word := range basicTokenize(text) {
+		ids = append(ids, t.wordPiece(word)...)
+	}
+
+	if len(ids) > maxTokens-1 {
+		ids = ids[:maxTokens-1]
+	}
+	return append(ids, t.sep)
+}
+
+// wordPiece splits a word into the longest matching vocabulary pieces
+func (t *wordPieceTokenizer) wordPiece(word string) []int64 {
+	runes := []rune(word)
+	if len(runes) > maxWordPieceChars {
+		return []int64{t.unk}
+	}
+
+	var ids []int64
+	for start := 0; start < len(runes); {
+		end := len(runes)
+		found := false
+		for ; end > start; end-- {
+			piece := string(runes[start:end])
+			if start > 0 {
+				piece = "##" + piece
+			}
+			if id, ok := t.vocab[piece]; ok {
+				ids = append(ids, id)
+				found = true
+				break
+			}
+		}
+		if !found {
+			return []int64{t.unk}
+		}
+		start = end
+	}
+	return ids
+}
+
+// basicTokenize lowercases, strips accents and splits on whitespace and punctuation
+func basicTokenize(text string) []string {
+	var words []string
+	var current strings.Builder
+
+	flush := func() {
+		if current.Len() > 0 {
+			words = append(words, current.String())
+			current.Reset()
+		}
+	}
+
+	for _, r := range norm.NFD.String(strings.ToLower(text)) {
+		switch {
+		case r == 0 || r == unicode.ReplacementChar || unicode.IsControl(r) && !unicode.IsSpace(r):
+			continue
+		case unicode.Is(unicode.Mn, r):
+			// Combining accent left over from NFD
+			continue
+		case unicode.IsSpace(r):
+			flush()
+		case isBertPunctuation(r) || isCJK(r):
+			flush()
+			words = append(words, string(r))
+		default:
+			current.WriteRune(r)
+		}
+	}
+	flush()
+
+	return words
+}
+
+func isBertPunctuation(r rune) bool {
+	if (r >= 33 && r <= 47) || (r >= 58 && r <= 64) || (r >= 91 && r <= 96) || (r >= 123 && r <= 126) {
+		return true
+	}
+	return unicode.IsPunct(r)
+}
+
+// isCJK matches the ideographs BERT splits into single-character tokens
+func isCJK(r rune) bool {
+	return unicode.Is(unicode.Han, r)
+}
@@ -0,0 +1,375 @@
+package main
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Name of the directory that marks a project root and holds its data
+const projectDirName = ".ember"
+
+// projectIgnore is the .gitignore ember init writes, so a project's sets
+// and config can be committed without its cache, history and indexes
+const projectIgnore = `# Written by ember init: keep sets and config.toml, ignore the rest
+cache/
+vectors/
+history.jsonl
+draft.json
+macro.json
+`
+
+// projectRoot is the project ember is running in, or "" outside one. It's
+// found once, from the directory ember started in.
+var projectRoot = sync.OnceValue(func() string {
+	if os.Getenv("EMBER_NO_PROJECT") != "" {
+		return ""
+	}
+	if dir := os.Getenv("EMBER_PROJECT_DIR"); dir != "" {
+		if abs, err := filepath.Abs(dir); err == nil {
+			return abs
+		}
+		return dir
+	}
+	cwd, err := os.Getwd()
+	if err != nil {
+		return ""
+	}
+	return findProjectRoot(cwd)
+})
+
+// findProjectRoot walks up from dir to the nearest directory holding a
+// .ember directory, the way git looks for .git, returning "" if none does
+func findProjectRoot(dir string) string {
+	for {
+		if info, err := os.Stat(filepath.Join(dir, projectDirName)); err == nil && info.IsDir() {
+			return dir
+		}
+		parent := filepath.Dir(dir)
+		if parent == dir {
+			return ""
+		}
+		dir = parent
+	}
+}
+
+// projectDataDir is the project's .ember directory, or "" outside a project
+func projectDataDir() string {
+	if root := projectRoot(); root != "" {
+		return filepath.Join(root, projectDirName)
+	}
+	return ""
+}
+
+// projectName labels the project in the audit log and on screen
+func projectName() string {
+	if name := os.Getenv("EMBER_PROJECT"); name != "" {
+		return name
+	}
+	if root := projectRoot(); root != "" {
+		return filepath.Base(root)
+	}
+	return ""
+}
+
+// presetFile is .ember/preset.json: a set committed with a project and
+// opened when ember starts inside it. It's either a saved set's JSON, with
+// vectors, or a name and texts, which are embedded with the current model.
+type presetFile struct {
+	comparisonSet
+	Texts []string `json:"texts"`
+}
+
+// loadProjectPreset reads the project's preset.json. ok is false outside a
+// project, or when the project has no preset.
+func loadProjectPreset() (set comparisonSet, ok bool, err error) {
+	dir := projectDataDir()
+	if dir == "" {
+		return set, false, nil
+	}
+	path := filepath.Join(dir, "preset.json")
+	data, err := os.ReadFile(path)
+	if errors.Is(err, os.ErrNotExist) {
+		return set, false, nil
+	}
+	if err != nil {
+		return set, false, fmt.Errorf("failed to read %s: %w", path, err)
+	}
+
+	var preset presetFile
+	if err := json.Unmarshal(data, &preset); err != nil {
+		return set, false, fmt.Errorf("failed to parse %s: %w", path, err)
+	}
+	if preset.Name == "" {
+		preset.Name = projectName()
+	}
+	set, err = preset.set(path)
+	if err != nil {
+		return set, false, err
+	}
+	// The preset isn't in the store, whatever it says about being saved
+	set.Saved = time.Time{}
+	return set, true, nil
+}
+
+// set turns the preset into a comparison set. source names where it came
+// from in errors.
+func (preset presetFile) set(source string) (comparisonSet, error) {
+	set := preset.comparisonSet
+	if len(preset.Texts) > 0 {
+		if len(set.Embeddings) > 0 {
+			return set, fmt.Errorf("%s has both texts and embeddings for %q • keep one", source, set.Name)
+		}
+		// Without vectors or a model, applySet embeds the texts
+		set.Model = ModelInfo{}
+		for _, text := range preset.Texts {
+			if strings.TrimSpace(text) != "" {
+				set.Embeddings = append(set.Embeddings, CustomEmbedding{Text: text})
+			}
+		}
+	}
+	if len(set.Embeddings) == 0 {
+		return set, fmt.Errorf("%s has no texts for %q", source, set.Name)
+	}
+	return set, nil
+}
+
+// overlay returns cfg with the settings project makes replacing its own.
+// A project that picks another provider doesn't inherit the global model,
+// which belongs to the global provider.
+func (cfg fileConfig) overlay(project fileConfig) fileConfig {
+	merged := cfg
+	if project.Provider != "" && project.Provider != cfg.Provider {
+		merged.Provider, merged.Model = project.Provider, ""
+	}
+	if project.Model != "" {
+		merged.Model = project.Model
+	}
+	if project.Theme != "" {
+		merged.Theme = project.Theme
+	}
+	if project.Set != "" {
+		merged.Set = project.Set
+	}
+	if project.MaxConcurrency > 0 {
+		merged.MaxConcurrency = project.MaxConcurrency
+	}
+	if len(project.Env) > 0 {
+		merged.Env = make(map[string]string, len(cfg.Env)+len(project.Env))
+		for name, value := range cfg.Env {
+			merged.Env[name] = value
+		}
+		for name, value := range project.Env {
+			merged.Env[name] = value
+		}
+	}
+	return merged
+}
+
+// projectSafeSettings are the [env] variables a project's config.toml may
+// set without being trusted: they change how texts are chunked, weighted and
+// scored, never where they are sent, which credentials are used or what runs
+var projectSafeSettings = map[string]bool{
+	"EMBER_CHUNK_AGGREGATION": true,
+	"EMBER_CHUNK_WORDS":       true,
+	"EMBER_FIELD_WEIGHTS":     true,
+	"EMBER_HYBRID_WEIGHT":     true,
+	"EMBER_SUMMARY_WEIGHT":    true,
+	"EMBER_SUMMARIZE_WORDS":   true,
+	"EMBER_POSTPROCESS":       true,
+	"EMBER_SPARSE":            true,
+	"EMBER_PREVIEW_MIN":       true,
+	"EMBER_PREVIEW_SAMPLE":    true,
+	"EMBER_UNCERTAINTY_RUNS":  true,
+	"EMBER_SPILL_ROWS":        true,
+	"EMBER_VECTORS_EF":        true,
+	"EMBER_VECTORS_LIMIT":     true,
+}
+
+// restrict returns the settings of a project's config that apply without
+// trust, and names the ones it leaves out. A cloned repository can pick the
+// model, theme, set and chunking, but not the provider, URLs, binaries, keys
+// or anything else that runs code or sends data elsewhere.
+func (project fileConfig) restrict() (fileConfig, []string) {
+	safe := fileConfig{Model: project.Model, Theme: project.Theme, Set: project.Set, MaxConcurrency: project.MaxConcurrency}
+	var ignored []string
+	if project.Provider != "" {
+		ignored = append(ignored, "provider")
+	}
+	for name, value := range project.Env {
+		if !projectSafeSettings[name] {
+			ignored = append(ignored, name)
+			continue
+		}
+		if safe.Env == nil {
+			safe.Env = make(map[string]string)
+		}
+		safe.Env[name] = value
+	}
+	sort.Strings(ignored)
+	return safe, ignored
+}
+
+// trustedProjectsPath is where `ember trust` records the project configs the
+// user has allowed, outside any project so a repository can't trust itself
+func trustedProjectsPath() (string, error) {
+	dir, err := globalDataDir()
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(dir, "trusted-projects.json"), nil
+}
+
+// loadTrustedProjects maps each trusted project root to the SHA-256 of the
+// config.toml that was trusted
+func loadTrustedProjects() (map[string]string, error) {
+	trusted := make(map[string]string)
+	path, err := trustedProjectsPath()
+	if err != nil {
+		return trusted, err
+	}
+	data, err := os.ReadFile(path)
+	if errors.Is(err, os.ErrNotExist) {
+		return trusted, nil
+	}
+	if err != nil {
+		return trusted, fmt.Errorf("failed to read %s: %w", path, err)
+	}
+	if err := json.Unmarshal(data, &trusted); err != nil {
+		return trusted, fmt.Errorf("failed to parse %s: %w", path, err)
+	}
+	return trusted, nil
+}
+
+// projectConfigHash is the SHA-256 of the project's config.toml, or "" when
+// it has none
+func projectConfigHash(root string) (string, error) {
+	path := filepath.Join(root, projectDirName, "config.toml")
+	data, err := os.ReadFile(path)
+	if errors.Is(err, os.ErrNotExist) {
+		return "", nil
+	}
+	if err != nil {
+		return "", fmt.Errorf("failed to read %s: %w", path, err)
+	}
+	sum := sha256.Sum256(data)
+	return hex.EncodeToString(sum[:]), nil
+}
+
+// projectTrusted reports whether the user ran `ember trust` on the project's
+// config.toml as it is now. Any edit, such as one pulled from a remote,
+// needs trusting again, as direnv does.
+func projectTrusted(root string) bool {
+	hash, err := projectConfigHash(root)
+	if err != nil || hash == "" {
+		return false
+	}
+	trusted, err := loadTrustedProjects()
+	return err == nil && trusted[root] == hash
+}
+
+// runTrustCommand handles `ember trust [--revoke]`, allowing the current
+// project's config.toml to set anything the global config can
+func runTrustCommand(args []string) {
+	fs := flag.NewFlagSet("trust", flag.ExitOnError)
+	revoke := fs.Bool("revoke", false, "stop trusting the project's config.toml")
+	fs.Parse(args)
+	if fs.NArg() > 0 {
+		fmt.Println("Usage: ember trust [--revoke]")
+		os.Exit(2)
+	}
+
+	root := projectRoot()
+	if root == "" {
+		displayError(fmt.Errorf("not in an ember project • run ember init first"))
+		os.Exit(1)
+	}
+	trusted, err := loadTrustedProjects()
+	if err != nil {
+		displayError(err)
+		os.Exit(1)
+	}
+	if *revoke {
+		delete(trusted, root)
+	} else {
+		hash, err := projectConfigHash(root)
+		if err != nil {
+			displayError(err)
+			os.Exit(1)
+		}
+		if hash == "" {
+			displayError(fmt.Errorf("%s has no config.toml to trust", filepath.Join(root, projectDirName)))
+			os.Exit(1)
+		}
+		trusted[root] = hash
+	}
+
+	path, err := trustedProjectsPath()
+	if err != nil {
+		displayError(err)
+		os.Exit(1)
+	}
+	data, err := json.MarshalIndent(trusted, "", "  ")
+	if err != nil {
+		displayError(fmt.Errorf("failed to marshal trusted projects: %w", err))
+		os.Exit(1)
+	}
+	if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+		displayError(fmt.Errorf("failed to create %s: %w", filepath.Dir(path), err))
+		os.Exit(1)
+	}
+	if err := os.WriteFile(path, data, 0o600); err != nil {
+		displayError(fmt.Errorf("failed to write %s: %w", path, err))
+		os.Exit(1)
+	}
+	if *revoke {
+		fmt.Printf("🔒 No longer trusting %s\n", filepath.Join(root, projectDirName, "config.toml"))
+		return
+	}
+	fmt.Printf("🔓 Trusting %s as it is now • edits to it need ember trust again\n", filepath.Join(root, projectDirName, "config.toml"))
+}
+
+// runInitCommand handles `ember init [DIR]`, making DIR (by default the
+// current directory) a project root
+func runInitCommand(args []string) {
+	if len(args) > 1 {
+		fmt.Println("Usage: ember init [DIR]")
+		os.Exit(2)
+	}
+	dir := "."
+	if len(args) == 1 {
+		dir = args[0]
+	}
+	root, err := filepath.Abs(dir)
+	if err != nil {
+		displayError(fmt.Errorf("failed to resolve %s: %w", dir, err))
+		os.Exit(1)
+	}
+
+	path := filepath.Join(root, projectDirName)
+	if _, err := os.Stat(path); err == nil {
+		fmt.Printf("%s is already an ember project\n", root)
+		return
+	}
+	if err := os.MkdirAll(path, 0o755); err != nil {
+		displayError(fmt.Errorf("failed to create %s: %w", path, err))
+		os.Exit(1)
+	}
+	ignore := filepath.Join(path, ".gitignore")
+	if err := os.WriteFile(ignore, []byte(projectIgnore), 0o644); err != nil {
+		displayError(fmt.Errorf("failed to write %s: %w", ignore, err))
+		os.Exit(1)
+	}
+	fmt.Printf("📁 Initialized an ember project in %s\n", path)
+	fmt.Println("Sets, history, the embedding cache and vector indexes now live here when ember runs inside " + root)
+	fmt.Println("Settings in " + filepath.Join(path, "config.toml") + " override the global config")
+}